@@ -0,0 +1,181 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/wire"
+)
+
+// assumeValidHeaders houses the header chain that leads from a block in the
+// block index to the assumed valid block.  Blocks are only in the block index
+// once their data is known, so the header chain is used to determine which
+// blocks are ancestors of the assumed valid block before it is known.
+type assumeValidHeaders struct {
+	// baseHeight is the height of the block the first hash in hashes
+	// builds on.
+	baseHeight int64
+
+	// hashes are the hashes of the headers in the chain ordered by height
+	// starting at baseHeight+1.
+	hashes []chainhash.Hash
+
+	// workSum is the total cumulative work of the header chain.
+	workSum *big.Int
+
+	// resolved indicates the assumed valid block has been reached.  The
+	// hashes are discarded when the chain does not have sufficient work so
+	// that no blocks are considered ancestors.
+	resolved bool
+}
+
+// hasSufficientWork returns whether or not the provided cumulative work is at
+// least the minimum known work for the network.
+func (b *BlockChain) hasSufficientWork(workSum *big.Int) bool {
+	minKnownWork := b.chainParams.MinKnownChainWork
+	return minKnownWork == nil || workSum.Cmp(minKnownWork) >= 0
+}
+
+// NeedAssumeValidHeaders returns whether or not the chain requires the headers
+// that lead to the configured assumed valid block in order to skip script
+// validation for its ancestors.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) NeedAssumeValidHeaders() bool {
+	if b.assumeValid == *zeroHash {
+		return false
+	}
+
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+	if b.assumeValidHeaders != nil && b.assumeValidHeaders.resolved {
+		return false
+	}
+	return !b.index.HaveBlock(&b.assumeValid)
+}
+
+// ProcessAssumeValidHeaders processes a batch of headers that are expected to
+// lead to the configured assumed valid block.  The first header must either
+// connect to a block in the main chain or to the final header of a previously
+// processed batch.  The headers must connect to each other and satisfy their
+// claimed proof of work.
+//
+// It returns true once the assumed valid block has been reached, at which
+// point no more headers are needed.  Script validation is only skipped for the
+// ancestors of the assumed valid block when the header chain that leads to it
+// has at least the minimum known work for the network.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessAssumeValidHeaders(headers []wire.BlockHeader) (bool, error) {
+	if b.assumeValid == *zeroHash {
+		return true, nil
+	}
+
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	if b.assumeValidHeaders != nil && b.assumeValidHeaders.resolved {
+		return true, nil
+	}
+	if len(headers) == 0 {
+		return false, nil
+	}
+
+	// Start a new header chain when the first header builds on a block in
+	// the main chain rather than the final header of the existing chain.
+	avHeaders := b.assumeValidHeaders
+	prevHash := &headers[0].PrevBlock
+	if avHeaders == nil || len(avHeaders.hashes) == 0 ||
+		avHeaders.hashes[len(avHeaders.hashes)-1] != *prevHash {
+
+		prevNode := b.index.LookupNode(prevHash)
+		if prevNode == nil || !b.bestChain.Contains(prevNode) {
+			str := fmt.Sprintf("header %v does not connect to the main "+
+				"chain or previous headers", headers[0].BlockHash())
+			return false, ruleError(ErrMissingParent, str)
+		}
+		avHeaders = &assumeValidHeaders{
+			baseHeight: prevNode.height,
+			workSum:    new(big.Int).Set(prevNode.workSum),
+		}
+	}
+
+	// Ensure the headers connect and satisfy their claimed proof of work
+	// while accumulating the total work of the header chain.
+	workSum := new(big.Int).Set(avHeaders.workSum)
+	hashes := avHeaders.hashes
+	for i := range headers {
+		header := &headers[i]
+		if i > 0 && header.PrevBlock != hashes[len(hashes)-1] {
+			str := fmt.Sprintf("header %v does not connect to the "+
+				"previous header", header.BlockHash())
+			return false, ruleError(ErrMissingParent, str)
+		}
+		err := checkProofOfWork(header, b.chainParams.PowLimit, BFNone)
+		if err != nil {
+			return false, err
+		}
+
+		hashes = append(hashes, header.BlockHash())
+		workSum.Add(workSum, standalone.CalcWork(header.Bits))
+		if hashes[len(hashes)-1] != b.assumeValid {
+			continue
+		}
+
+		// The assumed valid block has been reached.  Script validation is
+		// only skipped when the header chain has sufficient work.
+		avHeight := avHeaders.baseHeight + int64(len(hashes))
+		if !b.hasSufficientWork(workSum) {
+			log.Warnf("The header chain to assumed valid block %v "+
+				"(height %d) does not have the minimum known work -- "+
+				"validating all scripts", b.assumeValid, avHeight)
+			b.assumeValidHeaders = &assumeValidHeaders{resolved: true}
+			return true, nil
+		}
+		avHeaders.hashes = hashes
+		avHeaders.workSum = workSum
+		avHeaders.resolved = true
+		b.assumeValidHeaders = avHeaders
+		log.Infof("Assuming valid scripts for ancestors of block %v "+
+			"(height %d)", b.assumeValid, avHeight)
+		return true, nil
+	}
+
+	avHeaders.hashes = hashes
+	avHeaders.workSum = workSum
+	b.assumeValidHeaders = avHeaders
+	return false, nil
+}
+
+// isAssumeValidAncestor returns whether or not the provided node is an ancestor
+// of the configured assumed valid block and the chain that leads to it has
+// sufficient work.  The assumed valid block itself is included.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) isAssumeValidAncestor(node *blockNode) bool {
+	if b.assumeValid == *zeroHash {
+		return false
+	}
+
+	// Use the block index when the assumed valid block is known.
+	if avNode := b.index.LookupNode(&b.assumeValid); avNode != nil {
+		return !b.index.NodeStatus(avNode).KnownInvalid() &&
+			b.hasSufficientWork(avNode.workSum) &&
+			avNode.Ancestor(node.height) == node
+	}
+
+	// Otherwise, use the header chain that leads to it when it is known.
+	avHeaders := b.assumeValidHeaders
+	if avHeaders == nil || !avHeaders.resolved {
+		return false
+	}
+	idx := node.height - avHeaders.baseHeight - 1
+	return idx >= 0 && idx < int64(len(avHeaders.hashes)) &&
+		avHeaders.hashes[idx] == node.hash
+}
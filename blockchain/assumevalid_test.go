@@ -0,0 +1,158 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestAssumeValid ensures the header chain that leads to the assumed valid
+// block is processed as expected and that only the ancestors of the assumed
+// valid block are considered assumed valid when the header chain has
+// sufficient work.
+func TestAssumeValid(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "assumevalidtest")
+	defer teardownFunc()
+
+	// ---------------------------------------------------------------------
+	// Create some convenience functions to improve test readability.
+	// ---------------------------------------------------------------------
+
+	// headersByName returns the headers of the blocks with the provided
+	// names.
+	headersByName := func(blockNames ...string) []wire.BlockHeader {
+		headers := make([]wire.BlockHeader, 0, len(blockNames))
+		for _, blockName := range blockNames {
+			headers = append(headers, g.BlockByName(blockName).Header)
+		}
+		return headers
+	}
+
+	// processHeaders processes the headers for the blocks with the provided
+	// names and ensures the result is the expected one.
+	processHeaders := func(wantDone bool, blockNames ...string) {
+		t.Helper()
+
+		done, err := g.chain.ProcessAssumeValidHeaders(
+			headersByName(blockNames...))
+		if err != nil {
+			t.Fatalf("unexpected error processing headers %v: %v",
+				blockNames, err)
+		}
+		if done != wantDone {
+			t.Fatalf("unexpected done flag processing headers %v -- got "+
+				"%v, want %v", blockNames, done, wantDone)
+		}
+	}
+
+	// assertNeedHeaders ensures whether or not the chain needs the headers
+	// that lead to the assumed valid block is the expected value.
+	assertNeedHeaders := func(want bool) {
+		t.Helper()
+
+		if got := g.chain.NeedAssumeValidHeaders(); got != want {
+			t.Fatalf("unexpected need assume valid headers -- got %v, "+
+				"want %v", got, want)
+		}
+	}
+
+	// assertAssumeValid ensures whether or not the block with the provided
+	// name is considered an ancestor of the assumed valid block is the
+	// expected value.
+	assertAssumeValid := func(blockName string, want bool) {
+		t.Helper()
+
+		hash := g.BlockByName(blockName).BlockHash()
+		node := g.chain.index.LookupNode(&hash)
+		if node == nil {
+			t.Fatalf("unable to find block node for %q", blockName)
+		}
+		g.chain.chainLock.RLock()
+		got := g.chain.isAssumeValidAncestor(node)
+		g.chain.chainLock.RUnlock()
+		if got != want {
+			t.Fatalf("unexpected assume valid ancestor result for %q -- "+
+				"got %v, want %v", blockName, got, want)
+		}
+	}
+
+	// ---------------------------------------------------------------------
+	// Generate blocks that are not yet known to the chain and mark one of
+	// them as the assumed valid block.
+	//
+	//   genesis -> bfb -> b0 -> b1 -> b2 -> b3 -> b4
+	// ---------------------------------------------------------------------
+
+	g.CreateBlockOne("bfb", 0)
+	g.AcceptTipBlock()
+	for i := 0; i < 5; i++ {
+		g.NextBlock(fmt.Sprintf("b%d", i), nil, nil)
+	}
+	g.chain.assumeValid = g.BlockByName("b3").BlockHash()
+	assertNeedHeaders(true)
+
+	// ---------------------------------------------------------------------
+	// Ensure nothing is assumed valid when the header chain that leads to
+	// the assumed valid block does not have sufficient work.
+	// ---------------------------------------------------------------------
+
+	g.chain.chainParams.MinKnownChainWork = new(big.Int).Lsh(big.NewInt(1), 200)
+	processHeaders(true, "b0", "b1", "b2", "b3")
+	assertNeedHeaders(false)
+	g.AcceptBlock("b0")
+	assertAssumeValid("b0", false)
+
+	// Reset the state for the remaining tests.
+	g.chain.chainParams.MinKnownChainWork = nil
+	g.chain.assumeValidHeaders = nil
+	assertNeedHeaders(true)
+
+	// ---------------------------------------------------------------------
+	// Ensure headers that do not connect are rejected.
+	// ---------------------------------------------------------------------
+
+	_, err := g.chain.ProcessAssumeValidHeaders(headersByName("b2"))
+	if !errors.Is(err, ErrMissingParent) {
+		t.Fatalf("unexpected error processing disconnected header -- "+
+			"got %v, want %v", err, ErrMissingParent)
+	}
+	_, err = g.chain.ProcessAssumeValidHeaders(headersByName("b1", "b3"))
+	if !errors.Is(err, ErrMissingParent) {
+		t.Fatalf("unexpected error processing disconnected header -- "+
+			"got %v, want %v", err, ErrMissingParent)
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure the header chain is built across multiple batches and only the
+	// ancestors of the assumed valid block are considered assumed valid.
+	// ---------------------------------------------------------------------
+
+	processHeaders(false, "b1")
+	assertNeedHeaders(true)
+	processHeaders(true, "b2", "b3", "b4")
+	assertNeedHeaders(false)
+
+	g.AcceptBlock("b1")
+	assertAssumeValid("b1", true)
+	g.AcceptBlock("b2")
+	assertAssumeValid("b2", true)
+
+	// Ensure the block index is used once the assumed valid block is known.
+	g.chain.assumeValidHeaders = nil
+	g.AcceptBlock("b3")
+	assertAssumeValid("b3", true)
+	assertAssumeValid("b1", true)
+	g.AcceptBlock("b4")
+	assertAssumeValid("b4", false)
+	assertNeedHeaders(false)
+}
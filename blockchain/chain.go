@@ -145,6 +145,7 @@ type BlockChain struct {
 	notifications       NotificationCallback
 	sigCache            *txscript.SigCache
 	indexManager        indexers.IndexManager
+	pruneTarget         uint64
 
 	// subsidyCache is the cache that provides quick lookup of subsidy
 	// values.
@@ -217,6 +218,49 @@ type BlockChain struct {
 	// it is unlikely to be referenced in the future.
 	pruner *chainPruner
 
+	// prunedHeight is the height of the most recent block that has had its
+	// data removed due to pruning or -1 when no blocks have been pruned.
+	// It is protected by its own lock since it is accessed when fetching
+	// blocks without the chain lock held.
+	prunedHeightLock sync.RWMutex
+	prunedHeight     int64
+
+	// utxoCache is the write-back cache that sits between utxo viewpoints
+	// and the utxo set in the database.  It has its own lock since it is
+	// accessed when fetching utxos without the chain lock held.
+	utxoCache *utxoCache
+
+	// utxoStats houses the statistics of the utxo set as of the current
+	// best chain tip.  It is protected by the chain lock.
+	utxoStats *utxoSetStats
+
+	// assumeValid is the hash of the block for which the scripts of it and
+	// all of its ancestors are assumed to be valid.  It is the zero hash
+	// when all scripts are validated.
+	//
+	// assumeValidHeaders is the header chain that leads to the assumed valid
+	// block before the block itself is known.  It is protected by the chain
+	// lock.
+	assumeValid        chainhash.Hash
+	assumeValidHeaders *assumeValidHeaders
+
+	// historyValidation houses the state of the background validation of
+	// the blocks that lead to a loaded utxo set snapshot.  It is nil when
+	// the chain was not bootstrapped from a snapshot or the history has
+	// already been validated.  It is protected by the chain lock.
+	historyValidation *historyValidation
+
+	// snapshotDumps is the number of utxo set snapshots that are currently
+	// being written.  Block data is not pruned while any are in progress
+	// since the snapshots include recent blocks from the database view they
+	// were started with.  It is protected by the chain lock.
+	snapshotDumps int
+
+	// pruneSuspensions is the number of outstanding requests to suspend the
+	// pruning of block data, such as while a database backup that includes
+	// all stored blocks is in progress.  It is protected by the chain lock.
+	pruneSuspensions int
+
 	// The following maps are various caches for the stake version/voting
 	// system.  The goal of these is to reduce disk access to load blocks
 	// from disk.  Measurements indicate that it is slightly more expensive
@@ -428,7 +472,10 @@ func (b *BlockChain) fetchMainChainBlockByNode(node *blockNode) (*dcrutil.Block,
 		block, err = dbFetchBlockByNode(dbTx, node)
 		return err
 	})
-	return block, err
+	if err != nil {
+		return nil, b.maybePrunedBlockError(node, err)
+	}
+	return block, nil
 }
 
 // fetchBlockByNode returns the block associated with the given node all known
@@ -449,7 +496,10 @@ func (b *BlockChain) fetchBlockByNode(node *blockNode) (*dcrutil.Block, error) {
 		block, err = dbFetchBlockByNode(dbTx, node)
 		return err
 	})
-	return block, err
+	if err != nil {
+		return nil, b.maybePrunedBlockError(node, err)
+	}
+	return block, nil
 }
 
 // pruneStakeNodes removes references to old stake nodes which should no
@@ -589,7 +639,15 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *dcrutil.Block,
 		node.stakeNode.Winners(), node.stakeNode.MissedTickets(),
 		node.stakeNode.FinalState())
 
+	// Calculate the statistics of the utxo set that results from connecting
+	// the block.
+	utxoStats, err := b.utxoStatsAfterView(view)
+	if err != nil {
+		return err
+	}
+
 	// Atomically insert info into the database.
+	newPrunedHeight := int64(-1)
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -597,13 +655,19 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *dcrutil.Block,
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
+		// Store the statistics of the utxo set as of the block and remove
+		// the statistics for the block that is no longer recent enough to
+		// retain them for.
+		err = dbPutUtxoSetStats(dbTx, &node.hash, utxoStats)
 		if err != nil {
 			return err
 		}
+		if expired := expiredUtxoStatsNode(node); expired != nil {
+			err = dbRemoveUtxoSetStats(dbTx, &expired.hash)
+			if err != nil {
+				return err
+			}
+		}
 
 		// Update the transaction spend journal by adding a record for
 		// the block that contains all txos spent by it.
@@ -648,14 +712,37 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *dcrutil.Block,
 			}
 		}
 
+		// Remove the data for old blocks as needed when pruning is
+		// enabled.
+		if b.pruneTarget != 0 {
+			newPrunedHeight, err = b.pruneBlockData(dbTx, node)
+			if err != nil {
+				return err
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
 		return err
 	}
 
+	// Update the pruned height when blocks were pruned.
+	if newPrunedHeight != -1 {
+		b.prunedHeightLock.Lock()
+		b.prunedHeight = newPrunedHeight
+		b.prunedHeightLock.Unlock()
+	}
+
+	// Update the utxo cache using the state of the utxo view.  This entails
+	// removing all of the utxos spent and adding the new ones created by the
+	// block.  The cache writes the changes to the utxo set in the database
+	// when it is flushed.
+	b.utxoCache.commit(view)
+	b.utxoStats = utxoStats
+
 	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// now that the modifications have been committed to the utxo cache.
 	view.commit()
 
 	// This node is now the end of the best chain.
@@ -670,6 +757,12 @@ func (b *BlockChain) connectBlock(node *blockNode, block, parent *dcrutil.Block,
 	b.stateSnapshot = state
 	b.stateLock.Unlock()
 
+	// Flush the utxo cache to the database when it has grown too large or
+	// has not been flushed recently.
+	if err := b.utxoCache.maybeFlush(&node.hash, node.height, false); err != nil {
+		return err
+	}
+
 	// Notify the caller that the block was connected to the main chain.
 	// The caller would typically want to react with actions such as
 	// updating wallets.
@@ -783,6 +876,21 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *dcrutil.Blo
 		prevNode.stakeNode.Winners(), prevNode.stakeNode.MissedTickets(),
 		prevNode.stakeNode.FinalState())
 
+	// Flush the utxo cache so the utxo set in the database is current since
+	// the changes made by disconnecting the block are written directly to the
+	// database below.
+	err = b.utxoCache.maybeFlush(&node.hash, node.height, true)
+	if err != nil {
+		return err
+	}
+
+	// Calculate the statistics of the utxo set that results from
+	// disconnecting the block.
+	utxoStats, err := b.utxoStatsAfterView(view)
+	if err != nil {
+		return err
+	}
+
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -790,6 +898,17 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *dcrutil.Blo
 			return err
 		}
 
+		// Replace the statistics of the utxo set as of the block with
+		// those as of its parent.
+		err = dbRemoveUtxoSetStats(dbTx, &node.hash)
+		if err != nil {
+			return err
+		}
+		err = dbPutUtxoSetStats(dbTx, &prevNode.hash, utxoStats)
+		if err != nil {
+			return err
+		}
+
 		// Update the utxo set using the state of the utxo view.  This
 		// entails restoring all of the utxos spent and removing the new
 		// ones created by the block.
@@ -797,6 +916,10 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *dcrutil.Blo
 		if err != nil {
 			return err
 		}
+		err = dbPutUtxoSetState(dbTx, &prevNode.hash, prevNode.height)
+		if err != nil {
+			return err
+		}
 
 		// Update the transaction spend journal by removing the record
 		// that contains all txos spent by the block.
@@ -832,6 +955,11 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block, parent *dcrutil.Blo
 		return err
 	}
 
+	// Remove the entries modified by the block from the utxo cache since
+	// they were written directly to the database.
+	b.utxoCache.evict(view, &prevNode.hash, prevNode.height)
+	b.utxoStats = utxoStats
+
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
 	view.commit()
@@ -2033,20 +2161,31 @@ func (q *chainQueryerAdapter) IsTreasuryEnabled(hash *chainhash.Hash) (bool, err
 	return q.IsTreasuryAgendaActive(hash)
 }
 
-// PrevScripts returns a source of previous transaction scripts and their
-// associated versions spent by the given block by using the spend journal.
+// CommittedTip returns the hash and height of the tip of the main chain as
+// stored in the database as of the provided database transaction.
 //
 // It is defined via a separate internal struct to avoid polluting the public
 // API of the BlockChain type itself.
 //
 // This is part of the indexers.ChainQueryer interface.
-func (q *chainQueryerAdapter) PrevScripts(dbTx database.Tx, block *dcrutil.Block) (indexers.PrevScripter, error) {
-	prevHash := &block.MsgBlock().Header.PrevBlock
-	isTreasuryEnabled, err := q.IsTreasuryAgendaActive(prevHash)
+func (q *chainQueryerAdapter) CommittedTip(dbTx database.Tx) (*chainhash.Hash, int64, error) {
+	state, err := dbFetchBestState(dbTx)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
+	return &state.hash, int64(state.height), nil
+}
 
+// PrevScripts returns a source of previous transaction scripts and their
+// associated versions spent by the given block by using the spend journal.
+// The treasury flag specifies whether or not the treasury agenda is active for
+// the block since the spend journal serialization depends on it.
+//
+// It is defined via a separate internal struct to avoid polluting the public
+// API of the BlockChain type itself.
+//
+// This is part of the indexers.ChainQueryer interface.
+func (q *chainQueryerAdapter) PrevScripts(dbTx database.Tx, block *dcrutil.Block, isTreasuryEnabled bool) (indexers.PrevScripter, error) {
 	// Load all of the spent transaction output data from the database.
 	stxos, err := dbFetchSpendJournalEntry(dbTx, block, isTreasuryEnabled)
 	if err != nil {
@@ -2119,6 +2258,32 @@ type Config struct {
 	// This field can be nil if the caller does not wish to make use of an
 	// index manager.
 	IndexManager indexers.IndexManager
+
+	// PruneTarget is the target size in bytes of all stored block data.
+	// When it is nonzero, the data for the oldest blocks that are deep
+	// enough in the main chain is removed as new blocks are connected in
+	// order to keep the total size of the block data near the target.
+	//
+	// This field can be zero to disable pruning.
+	PruneTarget uint64
+
+	// UtxoCacheMaxSize is the maximum size in bytes of the cache of unspent
+	// transaction outputs.  Modified outputs are held in the cache and
+	// written to the database in batches once it reaches the maximum size,
+	// periodically, and on shutdown.
+	//
+	// This field can be zero to write the modified outputs to the database
+	// after every block.
+	UtxoCacheMaxSize uint64
+
+	// AssumeValid is the hash of a block for which the scripts of it and all
+	// of its ancestors are assumed to be valid.  Script validation is only
+	// skipped for those blocks when the header chain that leads to the block
+	// has at least the minimum known work for the network.  All other
+	// consensus rules are still enforced.
+	//
+	// This field can be the zero hash to validate the scripts of all blocks.
+	AssumeValid chainhash.Hash
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -2172,6 +2337,10 @@ func New(ctx context.Context, config *Config) (*BlockChain, error) {
 		notifications:                 config.Notifications,
 		sigCache:                      config.SigCache,
 		indexManager:                  config.IndexManager,
+		pruneTarget:                   config.PruneTarget,
+		prunedHeight:                  -1,
+		utxoCache:                     newUtxoCache(config.DB, config.UtxoCacheMaxSize),
+		assumeValid:                   config.AssumeValid,
 		subsidyCache:                  subsidyCache,
 		index:                         newBlockIndex(config.DB),
 		bestChain:                     newChainView(nil),
@@ -2192,6 +2361,25 @@ func New(ctx context.Context, config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Recover the utxo set by replaying the blocks after the most recent
+	// utxo cache flush when the previous shutdown was not clean.
+	if err := b.initUtxoCache(ctx); err != nil {
+		return nil, err
+	}
+
+	// Load the statistics of the utxo set as of the current best chain tip,
+	// calculating them from the entire utxo set when they are not
+	// available.
+	if err := b.initUtxoStats(ctx); err != nil {
+		return nil, err
+	}
+
+	// Resume the background validation of the history that leads to a
+	// loaded utxo set snapshot as needed.
+	if err := b.initHistoryValidation(ctx); err != nil {
+		return nil, err
+	}
+
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	queryAdapter := chainQueryerAdapter{BlockChain: &b}
@@ -16,7 +16,6 @@ import (
 	"time"
 
 	"github.com/decred/dcrd/blockchain/stake/v3"
-	"github.com/decred/dcrd/blockchain/standalone/v2"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database/v2"
 	"github.com/decred/dcrd/dcrutil/v3"
@@ -68,6 +67,14 @@ var (
 	// bcdbInfoBucketName bucket.
 	bcdbInfoCreatedKeyName = []byte("created")
 
+	// prunedHeightKeyName is the name of the db key used to store the
+	// height of the most recent block that has had its data pruned.
+	prunedHeightKeyName = []byte("prunedheight")
+
+	// utxoSetStateKeyName is the name of the db key used to store the hash
+	// and height of the block the utxo set in the database represents.
+	utxoSetStateKeyName = []byte("utxosetstate")
+
 	// chainStateKeyName is the name of the db key used to store the best chain
 	// state.
 	chainStateKeyName = []byte("chainstate")
@@ -1164,9 +1171,17 @@ func deserializeUtxoEntry(serialized []byte) (*UtxoEntry, error) {
 // When there is no entry for the provided hash, nil will be returned for the
 // both the entry and the error.
 func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error) {
+	return dbFetchUtxoEntryFromBucket(dbTx, utxoSetBucketName, hash)
+}
+
+// dbFetchUtxoEntryFromBucket uses an existing database transaction to fetch
+// all unspent outputs for the provided transaction hash from the utxo set
+// housed in the bucket with the provided name.  It is otherwise identical to
+// dbFetchUtxoEntry.
+func dbFetchUtxoEntryFromBucket(dbTx database.Tx, bucketName []byte, hash *chainhash.Hash) (*UtxoEntry, error) {
 	// Fetch the unspent transaction output information for the passed
 	// transaction hash.  Return now when there is no entry.
-	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+	utxoBucket := dbTx.Metadata().Bucket(bucketName)
 	serializedUtxo := utxoBucket.Get(hash[:])
 	if serializedUtxo == nil {
 		return nil, nil
@@ -1198,68 +1213,19 @@ func dbFetchUtxoEntry(dbTx database.Tx, hash *chainhash.Hash) (*UtxoEntry, error
 	return entry, nil
 }
 
-// dbFetchUxtoStats fetches statistics on the current unspent trnsaction output set.
-func dbFetchUtxoStats(dbTx database.Tx) (*UtxoStats, error) {
-	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
-
-	var stats UtxoStats
-	transactions := make(map[chainhash.Hash]int64)
-	leaves := make([]chainhash.Hash, 0)
-	cursor := utxoBucket.Cursor()
-
-	for ok := cursor.First(); ok; ok = cursor.Next() {
-		key := cursor.Key()
-		hash := chainhash.HashH(key)
-		serializedUtxo := cursor.Value()
-		entrySize := len(serializedUtxo)
-
-		// A non-nil zero-length entry means there is an entry in the database
-		// for a fully spent transaction which should never be the case.
-		if entrySize == 0 {
-			return nil, AssertError(fmt.Sprintf("database contains entry "+
-				"for fully spent tx %s", hash))
-		}
-
-		stats.Utxos++
-		stats.Size += int64(entrySize)
-		transactions[hash]++
-
-		leaves = append(leaves, chainhash.HashH(serializedUtxo))
-
-		// Deserialize the utxo entry and return it.
-		entry, err := deserializeUtxoEntry(serializedUtxo)
-		if err != nil {
-			// Ensure any deserialization errors are returned as database
-			// corruption errors.
-			if isDeserializeErr(err) {
-				return nil, database.Error{
-					ErrorCode: database.ErrCorruption,
-					Description: fmt.Sprintf("corrupt utxo entry "+
-						"for %v: %v", hash, err),
-				}
-			}
-
-			return nil, err
-		}
-
-		for _, out := range entry.sparseOutputs {
-			stats.Utxos++
-			stats.Total += out.amount
-		}
-	}
-
-	stats.SerializedHash = standalone.CalcMerkleRootInPlace(leaves)
-	stats.Transactions = int64(len(transactions))
-
-	return &stats, nil
-}
-
 // dbPutUtxoView uses an existing database transaction to update the utxo set
 // in the database based on the provided utxo view contents and state.  In
 // particular, only the entries that have been marked as modified are written
 // to the database.
 func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
-	utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+	return dbPutUtxoViewToBucket(dbTx, utxoSetBucketName, view)
+}
+
+// dbPutUtxoViewToBucket uses an existing database transaction to update the
+// utxo set housed in the bucket with the provided name based on the provided
+// utxo view contents and state.  It is otherwise identical to dbPutUtxoView.
+func dbPutUtxoViewToBucket(dbTx database.Tx, bucketName []byte, view *UtxoViewpoint) error {
+	utxoBucket := dbTx.Metadata().Bucket(bucketName)
 	for txHashIter, entry := range view.entries {
 		// No need to update the database if the entry was not modified.
 		if entry == nil || !entry.modified {
@@ -1592,6 +1558,29 @@ func dbFetchBestState(dbTx database.Tx) (bestChainState, error) {
 	return deserializeBestChainState(serializedData)
 }
 
+// dbPutPrunedHeight uses an existing database transaction to update the height
+// of the most recent block that has had its data pruned.
+func dbPutPrunedHeight(dbTx database.Tx, height int64) error {
+	var serialized [4]byte
+	byteOrder.PutUint32(serialized[:], uint32(height))
+	return dbTx.Metadata().Put(prunedHeightKeyName, serialized[:])
+}
+
+// dbFetchPrunedHeight uses an existing database transaction to fetch the
+// height of the most recent block that has had its data pruned.  It returns -1
+// when no blocks have been pruned.
+func dbFetchPrunedHeight(dbTx database.Tx) (int64, error) {
+	serialized := dbTx.Metadata().Get(prunedHeightKeyName)
+	if serialized == nil {
+		return -1, nil
+	}
+	if len(serialized) != 4 {
+		return 0, AssertError(fmt.Sprintf("unexpected pruned height "+
+			"length: got %d, want 4", len(serialized)))
+	}
+	return int64(byteOrder.Uint32(serialized)), nil
+}
+
 // createChainState initializes both the database and the chain state to the
 // genesis block.  This includes creating the necessary buckets and inserting
 // the genesis block, so it must only be called on an uninitialized database.
@@ -1665,6 +1654,13 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// Store the state of the utxo set which is initially the genesis
+		// block.
+		err = dbPutUtxoSetState(dbTx, &node.hash, node.height)
+		if err != nil {
+			return err
+		}
+
 		// Initialize the stake buckets in the database, along with
 		// the best state for the stake database.
 		_, err = stake.InitDatabaseState(dbTx, b.chainParams,
@@ -1903,6 +1899,12 @@ func (b *BlockChain) initChainState(ctx context.Context) error {
 
 		log.Debugf("Block index loaded in %v", time.Since(bidxStart))
 
+		// Load the height of the most recently pruned block.
+		b.prunedHeight, err = dbFetchPrunedHeight(dbTx)
+		if err != nil {
+			return err
+		}
+
 		// Exception for version 1 blockchains: skip loading the stake
 		// node, as the upgrade path handles ensuring this is correctly
 		// set.
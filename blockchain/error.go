@@ -586,6 +586,37 @@ const (
 	// ErrNoTreasuryBalance indicates the treasury balance for a given block
 	// hash does not exist.
 	ErrNoTreasuryBalance = ErrorKind("ErrNoTreasuryBalance")
+
+	// ErrBlockPruned indicates the data for a requested block is no longer
+	// available because it has been pruned.
+	ErrBlockPruned = ErrorKind("ErrBlockPruned")
+
+	// ErrInvalidSnapshot indicates a utxo set snapshot is malformed, is not
+	// known to be valid for the network, or can not be loaded into the
+	// database.
+	ErrInvalidSnapshot = ErrorKind("ErrInvalidSnapshot")
+
+	// ErrSnapshotMismatch indicates the history that leads to a loaded utxo
+	// set snapshot does not produce the state in the snapshot.
+	ErrSnapshotMismatch = ErrorKind("ErrSnapshotMismatch")
+
+	// ErrHistoryNotValidated indicates an operation that requires the full
+	// history of the chain was requested before the history that leads to a
+	// loaded utxo set snapshot has been validated.
+	ErrHistoryNotValidated = ErrorKind("ErrHistoryNotValidated")
+
+	// ErrNoUtxoStats indicates the utxo set statistics for a given block do
+	// not exist.
+	ErrNoUtxoStats = ErrorKind("ErrNoUtxoStats")
+
+	// ErrNoSpendJournal indicates the spend journal for a given block does
+	// not exist.
+	ErrNoSpendJournal = ErrorKind("ErrNoSpendJournal")
+
+	// ErrInvalidBlockRange indicates a requested range of blocks is invalid
+	// because the end block is before the start block or the range contains
+	// more than the maximum number of blocks allowed.
+	ErrInvalidBlockRange = ErrorKind("ErrInvalidBlockRange")
 )
 
 // Error satisfies the error interface and prints human-readable errors.
@@ -623,6 +654,13 @@ func unknownBlockError(hash *chainhash.Hash) ContextError {
 	return contextError(ErrUnknownBlock, str)
 }
 
+// blockPrunedError create a ContextError with the kind of error set to
+// ErrBlockPruned and a description that includes the provided hash.
+func blockPrunedError(hash *chainhash.Hash) ContextError {
+	str := fmt.Sprintf("block %s data has been pruned", hash)
+	return contextError(ErrBlockPruned, str)
+}
+
 // RuleError identifies a rule violation.  It is used to indicate that
 // processing of a block or transaction failed due to one of the many validation
 // rules.  It has full support for errors.Is and errors.As, so the caller can
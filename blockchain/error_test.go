@@ -153,6 +153,13 @@ func TestErrorKindStringer(t *testing.T) {
 		{ErrUnknownBlock, "ErrUnknownBlock"},
 		{ErrNoFilter, "ErrNoFilter"},
 		{ErrNoTreasuryBalance, "ErrNoTreasuryBalance"},
+		{ErrBlockPruned, "ErrBlockPruned"},
+		{ErrInvalidSnapshot, "ErrInvalidSnapshot"},
+		{ErrSnapshotMismatch, "ErrSnapshotMismatch"},
+		{ErrHistoryNotValidated, "ErrHistoryNotValidated"},
+		{ErrNoUtxoStats, "ErrNoUtxoStats"},
+		{ErrNoSpendJournal, "ErrNoSpendJournal"},
+		{ErrInvalidBlockRange, "ErrInvalidBlockRange"},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -3,11 +3,12 @@ module github.com/decred/dcrd/blockchain/v4
 go 1.13
 
 require (
-	github.com/decred/dcrd/blockchain/stake/v3 v3.0.0
+	github.com/decred/dcrd/blockchain/stake/v3 v3.1.0
 	github.com/decred/dcrd/blockchain/standalone/v2 v2.0.0
 	github.com/decred/dcrd/chaincfg/chainhash v1.0.2
-	github.com/decred/dcrd/chaincfg/v3 v3.0.0
-	github.com/decred/dcrd/database/v2 v2.0.2
+	github.com/decred/dcrd/chaincfg/v3 v3.1.0
+	github.com/decred/dcrd/crypto/blake256 v1.0.0
+	github.com/decred/dcrd/database/v2 v2.1.0
 	github.com/decred/dcrd/dcrec v1.0.0
 	github.com/decred/dcrd/dcrec/secp256k1/v3 v3.0.0
 	github.com/decred/dcrd/dcrutil/v3 v3.0.0
@@ -7,6 +7,7 @@ package blockchain
 import (
 	"fmt"
 
+	"github.com/decred/dcrd/blockchain/standalone/v2"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/database/v2"
 	"github.com/decred/dcrd/dcrutil/v3"
@@ -42,6 +43,13 @@ func CalcCommitmentRootV1(filterHash chainhash.Hash) chainhash.Hash {
 	return filterHash
 }
 
+// headerCmtLeavesV1 returns the leaves of the merkle tree the v1 block
+// commitment root commits to for the given filter hash.  The leaves are ordered
+// by their proof index.
+func headerCmtLeavesV1(filterHash chainhash.Hash) []chainhash.Hash {
+	return []chainhash.Hash{filterHash}
+}
+
 // FetchUtxoViewParentTemplate loads utxo details from the point of view of just
 // having connected the given block, which must be a block template that
 // connects to the parent of the tip of the main chain.  In other words, the
@@ -145,3 +153,92 @@ func (b *BlockChain) FilterByBlockHash(hash *chainhash.Hash) (*gcs.FilterV2, err
 	}
 	return filter, err
 }
+
+// FilterV2WithProof houses the version 2 GCS filter for a block along with an
+// inclusion proof that can be used to prove the filter is committed to by the
+// block header.  Note that the proof is only useful once the vote to enable
+// header commitments is active.
+type FilterV2WithProof struct {
+	BlockHash   chainhash.Hash
+	Filter      *gcs.FilterV2
+	ProofIndex  uint32
+	ProofHashes []chainhash.Hash
+}
+
+// LocateCFiltersV2 returns the version 2 GCS filters, along with the header
+// commitment inclusion proofs for them, for the contiguous range of blocks in
+// the main chain that starts with the given start block hash and ends with the
+// given end block hash (inclusive).  The filters are ordered by the height of
+// their associated blocks.
+//
+// An error that wraps ErrUnknownBlock will be returned when either of the
+// blocks is not in the main chain and an error that wraps ErrInvalidBlockRange
+// will be returned when the end block is before the start block or the range
+// contains more than the provided maximum number of blocks.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) LocateCFiltersV2(startHash, endHash *chainhash.Hash, maxFilters uint32) ([]FilterV2WithProof, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	// Ensure both ends of the range are in the main chain and the range is
+	// sane.
+	startNode := b.index.LookupNode(startHash)
+	if startNode == nil || !b.bestChain.Contains(startNode) {
+		str := fmt.Sprintf("block %s is not in the main chain", startHash)
+		return nil, contextError(ErrUnknownBlock, str)
+	}
+	endNode := b.index.LookupNode(endHash)
+	if endNode == nil || !b.bestChain.Contains(endNode) {
+		str := fmt.Sprintf("block %s is not in the main chain", endHash)
+		return nil, contextError(ErrUnknownBlock, str)
+	}
+	if endNode.height < startNode.height {
+		str := fmt.Sprintf("end block %s (height %d) is before start block "+
+			"%s (height %d)", endHash, endNode.height, startHash,
+			startNode.height)
+		return nil, contextError(ErrInvalidBlockRange, str)
+	}
+	numFilters := endNode.height - startNode.height + 1
+	if numFilters > int64(maxFilters) {
+		str := fmt.Sprintf("range from block %s to block %s contains %d "+
+			"blocks which exceeds the max allowed of %d", startHash, endHash,
+			numFilters, maxFilters)
+		return nil, contextError(ErrInvalidBlockRange, str)
+	}
+
+	// Load the filters and generate the inclusion proofs for them.  Since the
+	// desired result is from the start node to the end node in forward order,
+	// but they are iterated in reverse, add them in reverse order.
+	filters := make([]FilterV2WithProof, numFilters)
+	err := b.db.View(func(dbTx database.Tx) error {
+		node := endNode
+		for i := numFilters - 1; i >= 0; i-- {
+			filter, err := dbFetchGCSFilter(dbTx, &node.hash)
+			if err != nil {
+				return err
+			}
+			if filter == nil {
+				str := fmt.Sprintf("no filter available for block %s",
+					node.hash)
+				return contextError(ErrNoFilter, str)
+			}
+
+			leaves := headerCmtLeavesV1(filter.Hash())
+			filters[i] = FilterV2WithProof{
+				BlockHash:  node.hash,
+				Filter:     filter,
+				ProofIndex: HeaderCmtFilterIndex,
+				ProofHashes: standalone.GenerateInclusionProof(leaves,
+					HeaderCmtFilterIndex),
+			}
+			node = node.parent
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return filters, nil
+}
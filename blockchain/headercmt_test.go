@@ -1,13 +1,16 @@
-// Copyright (c) 2019 The Decred developers
+// Copyright (c) 2019-2021 The Decred developers
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
 package blockchain
 
 import (
+	"errors"
 	"testing"
 
+	"github.com/decred/dcrd/blockchain/standalone/v2"
 	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
 )
 
 // TestCalcCommitmentRootV1 ensures the expected version 1 commitment root is
@@ -54,3 +57,138 @@ func TestCalcCommitmentRootV1(t *testing.T) {
 		}
 	}
 }
+
+// TestLocateCFiltersV2 ensures locating the version 2 GCS filters and header
+// commitment inclusion proofs for ranges of blocks in the main chain works as
+// expected.
+func TestLocateCFiltersV2(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "locatecfiltersv2test")
+	defer teardownFunc()
+
+	// Generate a few blocks along with a side chain block.
+	//
+	//   genesis -> bfb -> ... -> b4 -> b5
+	//                              \-> b5a
+	g.AdvanceToHeight(3, 0)
+	g.NextBlock("b4", nil, nil)
+	g.AcceptTipBlock()
+	g.NextBlock("b5", nil, nil)
+	g.AcceptTipBlock()
+	g.SetTip("b4")
+	g.NextBlock("b5a", nil, nil)
+	g.AcceptedToSideChainWithExpectedTip("b5")
+
+	// Collect the hashes of the blocks in the main chain.
+	mainChainHashes := make([]chainhash.Hash, 6)
+	for height := int64(0); height < 6; height++ {
+		hash, err := g.chain.BlockHashByHeight(height)
+		if err != nil {
+			t.Fatalf("failed to get hash for height %d: %v", height, err)
+		}
+		mainChainHashes[height] = *hash
+	}
+	sideChainHash := g.BlockByName("b5a").BlockHash()
+	unknownHash := chainhash.Hash{0x01}
+
+	tests := []struct {
+		name       string          // test description
+		start      *chainhash.Hash // start hash of the range
+		end        *chainhash.Hash // end hash of the range
+		maxFilters uint32          // max filters to allow
+		want       []chainhash.Hash
+		err        error
+	}{{
+		name:       "entire main chain",
+		start:      &mainChainHashes[0],
+		end:        &mainChainHashes[5],
+		maxFilters: 10,
+		want:       mainChainHashes,
+	}, {
+		name:       "partial range at max allowed",
+		start:      &mainChainHashes[2],
+		end:        &mainChainHashes[4],
+		maxFilters: 3,
+		want:       mainChainHashes[2:5],
+	}, {
+		name:       "single block",
+		start:      &mainChainHashes[5],
+		end:        &mainChainHashes[5],
+		maxFilters: 1,
+		want:       mainChainHashes[5:6],
+	}, {
+		name:       "range exceeds max allowed",
+		start:      &mainChainHashes[1],
+		end:        &mainChainHashes[4],
+		maxFilters: 3,
+		err:        ErrInvalidBlockRange,
+	}, {
+		name:       "end before start",
+		start:      &mainChainHashes[4],
+		end:        &mainChainHashes[1],
+		maxFilters: 10,
+		err:        ErrInvalidBlockRange,
+	}, {
+		name:       "side chain end block",
+		start:      &mainChainHashes[1],
+		end:        &sideChainHash,
+		maxFilters: 10,
+		err:        ErrUnknownBlock,
+	}, {
+		name:       "unknown start block",
+		start:      &unknownHash,
+		end:        &mainChainHashes[1],
+		maxFilters: 10,
+		err:        ErrUnknownBlock,
+	}}
+
+	for _, test := range tests {
+		filters, err := g.chain.LocateCFiltersV2(test.start, test.end,
+			test.maxFilters)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%q: unexpected error -- got %v, want %v", test.name,
+				err, test.err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+
+		if len(filters) != len(test.want) {
+			t.Errorf("%q: unexpected number of filters -- got %d, want %d",
+				test.name, len(filters), len(test.want))
+			continue
+		}
+		for i, filter := range filters {
+			if filter.BlockHash != test.want[i] {
+				t.Errorf("%q: unexpected block hash for filter %d -- got %v, "+
+					"want %v", test.name, i, filter.BlockHash, test.want[i])
+				continue
+			}
+
+			// Ensure the filter matches the one for the individual block and
+			// the proof commits to it.
+			wantFilter, err := g.chain.FilterByBlockHash(&filter.BlockHash)
+			if err != nil {
+				t.Errorf("%q: unexpected error loading filter %d: %v",
+					test.name, i, err)
+				continue
+			}
+			if filter.Filter.Hash() != wantFilter.Hash() {
+				t.Errorf("%q: mismatched filter %d -- got %x, want %x",
+					test.name, i, filter.Filter.Bytes(), wantFilter.Bytes())
+				continue
+			}
+			filterHash := filter.Filter.Hash()
+			root := CalcCommitmentRootV1(filterHash)
+			if !standalone.VerifyInclusionProof(&root, &filterHash,
+				filter.ProofIndex, filter.ProofHashes) {
+
+				t.Errorf("%q: invalid inclusion proof for filter %d", test.name,
+					i)
+				continue
+			}
+		}
+	}
+}
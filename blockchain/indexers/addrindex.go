@@ -633,6 +633,17 @@ func (idx *AddrIndex) NeedsInputs() bool {
 	return true
 }
 
+// Ensure the AddrIndex type implements the DependsOner interface.
+var _ DependsOner = (*AddrIndex)(nil)
+
+// DependsOn signals that the index requires the transaction index since it
+// uses the block IDs it assigns.
+//
+// This implements the DependsOner interface.
+func (idx *AddrIndex) DependsOn() [][]byte {
+	return [][]byte{txIndexKey}
+}
+
 // Init is only provided to satisfy the Indexer interface as there is nothing to
 // initialize for this index.
 //
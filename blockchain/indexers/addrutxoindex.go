@@ -0,0 +1,697 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// addrUtxoIndexName is the human-readable name for the index.
+	addrUtxoIndexName = "address utxo index"
+
+	// addrUtxoIndexVersion is the current version of the address utxo index.
+	addrUtxoIndexVersion = 1
+
+	// addrUtxoKeyPrefix and addrUtxoUndoKeyPrefix are the prefixes of the
+	// unspent output and undo keys in the address utxo index, respectively.
+	addrUtxoKeyPrefix     = 'u'
+	addrUtxoUndoKeyPrefix = 'x'
+
+	// addrUtxoKeySize is the size of an unspent output key.  It consists of 1
+	// byte prefix + 21 bytes address key + 32 bytes transaction hash + 4 bytes
+	// output index + 1 byte tree.
+	addrUtxoKeySize = 1 + addrKeySize + chainhash.HashSize + 4 + 1
+
+	// addrUtxoEntryMinSize is the minimum size of an unspent output entry.
+	// It consists of 8 bytes amount + 4 bytes block height + 2 bytes script
+	// version followed by the public key script.
+	addrUtxoEntryMinSize = 8 + 4 + 2
+
+	// addrUtxoUndoKeySize is the size of an undo key.  It consists of 1 byte
+	// prefix + 4 bytes block height.
+	addrUtxoUndoKeySize = 1 + 4
+)
+
+var (
+	// addrUtxoIndexKey is the key of the address utxo index and the db bucket
+	// used to house it.
+	addrUtxoIndexKey = []byte("addrutxoidx")
+)
+
+// -----------------------------------------------------------------------------
+// The address utxo index is a companion to the address index that maps every
+// address to the unspent outputs in the main chain that pay to it.  It uses the
+// same address keys as the address index and, like it, maps outputs that pay to
+// multiple addresses, such as bare multisig outputs, to each of them.
+//
+// All entries are housed in a single flat bucket and are distinguished by a
+// one byte key prefix.
+//
+// The serialized format for the unspent output entries is:
+//
+//   'u'<addr key><outpoint> = <amount><block height><script version><pkscript>
+//
+//   Field              Type              Size
+//   addr key           [21]byte          21 bytes
+//   outpoint hash      chainhash.Hash    32 bytes
+//   outpoint index     uint32            4 bytes
+//   outpoint tree      int8              1 byte
+//   -----
+//   amount             int64             8 bytes
+//   block height       uint32            4 bytes
+//   script version     uint16            2 bytes
+//   pkscript           []byte            variable
+//
+// The unspent output entries are removed as the outputs are spent.  In order to
+// support disconnecting blocks along with blocks that disapprove the regular
+// transaction tree of their parent, the outputs spent by each block are stored
+// in an undo entry:
+//
+//   'x'<block height> = [<addr key><outpoint><tree><entry len><entry>,...]
+//
+//   Field              Type              Size
+//   block height       uint32            4 bytes (big endian)
+//   -----
+//   addr key           [21]byte          21 bytes
+//   outpoint hash      chainhash.Hash    32 bytes
+//   outpoint index     uint32            4 bytes
+//   outpoint tree      int8              1 byte
+//   spending tree      int8              1 byte
+//   entry len          uint16            2 bytes
+//   entry              []byte            variable (unspent output entry)
+// -----------------------------------------------------------------------------
+
+// AddrUtxoEntry houses information about an unspent output in the main chain
+// that pays to an address.
+type AddrUtxoEntry struct {
+	// OutPoint identifies the unspent output.
+	OutPoint wire.OutPoint
+
+	// Amount is the value of the output in atoms.
+	Amount int64
+
+	// BlockHeight is the height of the block that contains the transaction
+	// that created the output.
+	BlockHeight int64
+
+	// ScriptVersion and PkScript are the version and public key script of
+	// the output.
+	ScriptVersion uint16
+	PkScript      []byte
+}
+
+// addrUtxo houses an unspent output tracked by the address utxo index along
+// with the address key it is stored under.
+type addrUtxo struct {
+	addrKey [addrKeySize]byte
+	AddrUtxoEntry
+}
+
+// addrUtxoIndexKeyForUtxo returns the key in the address utxo index for the
+// unspent output entry of the provided address key and outpoint.
+func addrUtxoIndexKeyForUtxo(addrKey *[addrKeySize]byte, outpoint *wire.OutPoint) [addrUtxoKeySize]byte {
+	var key [addrUtxoKeySize]byte
+	key[0] = addrUtxoKeyPrefix
+	offset := 1 + copy(key[1:], addrKey[:])
+	offset += copy(key[offset:], outpoint.Hash[:])
+	byteOrder.PutUint32(key[offset:], outpoint.Index)
+	key[addrUtxoKeySize-1] = byte(outpoint.Tree)
+	return key
+}
+
+// addrUtxoIndexKeyForUndo returns the key in the address utxo index for the
+// undo entry of the block at the provided height.
+func addrUtxoIndexKeyForUndo(height int64) [addrUtxoUndoKeySize]byte {
+	var key [addrUtxoUndoKeySize]byte
+	key[0] = addrUtxoUndoKeyPrefix
+	binary.BigEndian.PutUint32(key[1:], uint32(height))
+	return key
+}
+
+// serializeAddrUtxoEntry serializes the provided unspent output into the
+// format described in detail above.
+func serializeAddrUtxoEntry(entry *AddrUtxoEntry) []byte {
+	serialized := make([]byte, addrUtxoEntryMinSize+len(entry.PkScript))
+	byteOrder.PutUint64(serialized, uint64(entry.Amount))
+	byteOrder.PutUint32(serialized[8:], uint32(entry.BlockHeight))
+	byteOrder.PutUint16(serialized[12:], entry.ScriptVersion)
+	copy(serialized[addrUtxoEntryMinSize:], entry.PkScript)
+	return serialized
+}
+
+// deserializeAddrUtxoEntry decodes the passed serialized byte slice into the
+// provided unspent output according to the format described in detail above.
+// The outpoint is not modified since it is stored in the key.
+func deserializeAddrUtxoEntry(serialized []byte, entry *AddrUtxoEntry) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < addrUtxoEntryMinSize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	entry.Amount = int64(byteOrder.Uint64(serialized))
+	entry.BlockHeight = int64(byteOrder.Uint32(serialized[8:]))
+	entry.ScriptVersion = byteOrder.Uint16(serialized[12:])
+	entry.PkScript = make([]byte, len(serialized)-addrUtxoEntryMinSize)
+	copy(entry.PkScript, serialized[addrUtxoEntryMinSize:])
+	return nil
+}
+
+// putAddrUtxoUndoEntry appends the serialized provided spent output along with
+// the tree of the spending transaction to the passed buffer.
+func putAddrUtxoUndoEntry(buf *bytes.Buffer, utxo *addrUtxo, spendingTree int8) {
+	key := addrUtxoIndexKeyForUtxo(&utxo.addrKey, &utxo.OutPoint)
+	serialized := serializeAddrUtxoEntry(&utxo.AddrUtxoEntry)
+	var header [2 + 1]byte
+	header[0] = byte(spendingTree)
+	byteOrder.PutUint16(header[1:], uint16(len(serialized)))
+	buf.Write(key[1:])
+	buf.Write(header[:])
+	buf.Write(serialized)
+}
+
+// deserializeAddrUtxoUndoEntries decodes the passed serialized undo entry into
+// the spent outputs it contains along with the trees of the spending
+// transactions.
+func deserializeAddrUtxoUndoEntries(serialized []byte) ([]addrUtxo, []int8, error) {
+	const headerSize = addrUtxoKeySize - 1 + 1 + 2
+	var utxos []addrUtxo
+	var spendingTrees []int8
+	for len(serialized) > 0 {
+		if len(serialized) < headerSize {
+			return nil, nil, errDeserialize("unexpected end of data")
+		}
+		var utxo addrUtxo
+		offset := copy(utxo.addrKey[:], serialized)
+		offset += copy(utxo.OutPoint.Hash[:], serialized[offset:])
+		utxo.OutPoint.Index = byteOrder.Uint32(serialized[offset:])
+		offset += 4
+		utxo.OutPoint.Tree = int8(serialized[offset])
+		spendingTree := int8(serialized[offset+1])
+		entryLen := int(byteOrder.Uint16(serialized[offset+2:]))
+		serialized = serialized[headerSize:]
+		if len(serialized) < entryLen {
+			return nil, nil, errDeserialize("unexpected end of data")
+		}
+		err := deserializeAddrUtxoEntry(serialized[:entryLen],
+			&utxo.AddrUtxoEntry)
+		if err != nil {
+			return nil, nil, err
+		}
+		serialized = serialized[entryLen:]
+
+		utxos = append(utxos, utxo)
+		spendingTrees = append(spendingTrees, spendingTree)
+	}
+	return utxos, spendingTrees, nil
+}
+
+// dbFetchAddrUtxoUndoEntries uses an existing database bucket to fetch the
+// outputs spent by the block at the provided height along with the trees of
+// the spending transactions from the address utxo index.
+func dbFetchAddrUtxoUndoEntries(bucket database.Bucket, height int64) ([]addrUtxo, []int8, error) {
+	key := addrUtxoIndexKeyForUndo(height)
+	utxos, spendingTrees, err := deserializeAddrUtxoUndoEntries(
+		bucket.Get(key[:]))
+	if err != nil {
+		return nil, nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt address utxo index undo entry "+
+				"for height %d: %v", height, err),
+		}
+	}
+	return utxos, spendingTrees, nil
+}
+
+// dbPutAddrUtxo uses an existing database bucket to add an unspent output
+// entry to the address utxo index.
+func dbPutAddrUtxo(bucket database.Bucket, utxo *addrUtxo) error {
+	key := addrUtxoIndexKeyForUtxo(&utxo.addrKey, &utxo.OutPoint)
+	return bucket.Put(key[:], serializeAddrUtxoEntry(&utxo.AddrUtxoEntry))
+}
+
+// dbRemoveAddrUtxo uses an existing database bucket to remove an unspent
+// output entry from the address utxo index.
+func dbRemoveAddrUtxo(bucket database.Bucket, utxo *addrUtxo) error {
+	key := addrUtxoIndexKeyForUtxo(&utxo.addrKey, &utxo.OutPoint)
+	return bucket.Delete(key[:])
+}
+
+// AddrUtxoIndex implements an unspent output by address index.  That is to
+// say, it supports querying all unspent outputs in the main chain that pay to a
+// given address along with its confirmed balance.  It is intended to be used
+// alongside the address index which provides the transaction history and the
+// unconfirmed transactions for an address.
+type AddrUtxoIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the AddrUtxoIndex type implements the Indexer interface.
+var _ Indexer = (*AddrUtxoIndex)(nil)
+
+// Ensure the AddrUtxoIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*AddrUtxoIndex)(nil)
+
+// Ensure the AddrUtxoIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*AddrUtxoIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *AddrUtxoIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Key() []byte {
+	return addrUtxoIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Name() string {
+	return addrUtxoIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Version() uint32 {
+	return addrUtxoIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the address
+// utxo index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(addrUtxoIndexKey)
+	return err
+}
+
+// addrKeysForScript returns the address keys for all of the supported
+// addresses the passed public key script pays to.
+func (idx *AddrUtxoIndex) addrKeysForScript(scriptVersion uint16, pkScript []byte, isTreasuryEnabled bool) [][addrKeySize]byte {
+	// Nothing to index if the script is non-standard or otherwise doesn't
+	// contain any addresses.
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(scriptVersion,
+		pkScript, idx.chainParams, isTreasuryEnabled)
+	if err != nil || len(addrs) == 0 {
+		return nil
+	}
+
+	addrKeys := make([][addrKeySize]byte, 0, len(addrs))
+	for _, addr := range addrs {
+		addrKey, err := addrToKey(addr)
+		if err != nil {
+			// Ignore unsupported address types.
+			continue
+		}
+		addrKeys = append(addrKeys, addrKey)
+	}
+	return addrKeys
+}
+
+// forEachTxAddrUtxo invokes the provided function with every spendable output
+// that pays to a supported address created by the passed transaction in the
+// given tree of a block at the provided height.  Outputs that pay to multiple
+// addresses result in an invocation for each of them.
+func (idx *AddrUtxoIndex) forEachTxAddrUtxo(tx *dcrutil.Tx, tree int8, height int64, isTreasuryEnabled bool, f func(utxo *addrUtxo) error) error {
+	for txOutIdx, txOut := range tx.MsgTx().TxOut {
+		// Provably unspendable outputs are never added to the set of unspent
+		// outputs.
+		if txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+			continue
+		}
+
+		addrKeys := idx.addrKeysForScript(txOut.Version, txOut.PkScript,
+			isTreasuryEnabled)
+		for _, addrKey := range addrKeys {
+			utxo := addrUtxo{
+				addrKey: addrKey,
+				AddrUtxoEntry: AddrUtxoEntry{
+					OutPoint: wire.OutPoint{
+						Hash:  *tx.Hash(),
+						Index: uint32(txOutIdx),
+						Tree:  tree,
+					},
+					Amount:        txOut.Value,
+					BlockHeight:   height,
+					ScriptVersion: txOut.Version,
+					PkScript:      txOut.PkScript,
+				},
+			}
+			if err := f(&utxo); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// forEachAddrUtxo invokes the provided function with every spendable output
+// that pays to a supported address created by the transactions in the given
+// tree of the passed block.  Outputs that pay to multiple addresses result in
+// an invocation for each of them.
+func (idx *AddrUtxoIndex) forEachAddrUtxo(block *dcrutil.Block, tree int8, isTreasuryEnabled bool, f func(utxo *addrUtxo) error) error {
+	txns := block.Transactions()
+	if tree == wire.TxTreeStake {
+		txns = block.STransactions()
+	}
+	for _, tx := range txns {
+		err := idx.forEachTxAddrUtxo(tx, tree, block.Height(),
+			isTreasuryEnabled, f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// connectTree removes the unspent outputs spent by the transactions in the
+// given tree of the passed block while recording them in the provided undo
+// buffer and adds the unspent outputs they create.
+//
+// The transactions are applied in order so outputs that are created and then
+// spent by later transactions in the same tree are recorded as spent in the
+// undo buffer as opposed to remaining in the set of unspent outputs.
+func (idx *AddrUtxoIndex) connectTree(bucket database.Bucket, block *dcrutil.Block, tree int8, prevScripts PrevScripter, isTreasuryEnabled bool, undo *bytes.Buffer) error {
+	txns := block.Transactions()
+	if tree == wire.TxTreeStake {
+		txns = block.STransactions()
+	}
+	for _, tx := range txns {
+		for _, txIn := range tx.MsgTx().TxIn {
+			// Inputs that reference the zero hash, such as those of
+			// coinbases, stakebases, treasurybases, and treasury spends, do
+			// not spend a previous output.
+			origin := &txIn.PreviousOutPoint
+			if origin.Hash == (chainhash.Hash{}) {
+				continue
+			}
+
+			// The input should always be available since the index contract
+			// requires it, however, be safe and simply ignore any missing
+			// entries.
+			version, pkScript, ok := prevScripts.PrevScript(origin)
+			if !ok {
+				log.Warnf("Missing input %v:%d for tx %v while indexing "+
+					"block %v (height %v)", origin, origin.Tree, tx.Hash(),
+					block.Hash(), block.Height())
+				continue
+			}
+
+			// Remove the spent output for every address it pays to while
+			// recording them so they can be restored.
+			addrKeys := idx.addrKeysForScript(version, pkScript,
+				isTreasuryEnabled)
+			for _, addrKey := range addrKeys {
+				key := addrUtxoIndexKeyForUtxo(&addrKey, origin)
+				serialized := bucket.Get(key[:])
+				if serialized == nil {
+					continue
+				}
+				utxo := addrUtxo{addrKey: addrKey}
+				utxo.OutPoint = *origin
+				err := deserializeAddrUtxoEntry(serialized, &utxo.AddrUtxoEntry)
+				if err != nil {
+					return database.Error{
+						ErrorCode: database.ErrCorruption,
+						Description: fmt.Sprintf("corrupt address utxo "+
+							"index entry for %v: %v", origin, err),
+					}
+				}
+				putAddrUtxoUndoEntry(undo, &utxo, tree)
+				if err := bucket.Delete(key[:]); err != nil {
+					return err
+				}
+			}
+		}
+
+		err := idx.forEachTxAddrUtxo(tx, tree, block.Height(),
+			isTreasuryEnabled, func(utxo *addrUtxo) error {
+				return dbPutAddrUtxo(bucket, utxo)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disconnectDisapprovedTree reverses the effects of the regular transaction
+// tree of the passed block on the unspent outputs.
+func (idx *AddrUtxoIndex) disconnectDisapprovedTree(bucket database.Bucket, block *dcrutil.Block, isTreasuryEnabled bool) error {
+	// Restore the outputs spent by the regular tree prior to removing the
+	// outputs it created so outputs that are both created and spent within
+	// the tree are not restored.
+	utxos, spendingTrees, err := dbFetchAddrUtxoUndoEntries(bucket,
+		block.Height())
+	if err != nil {
+		return err
+	}
+	for i := range utxos {
+		if spendingTrees[i] != wire.TxTreeRegular {
+			continue
+		}
+		if err := dbPutAddrUtxo(bucket, &utxos[i]); err != nil {
+			return err
+		}
+	}
+	return idx.forEachAddrUtxo(block, wire.TxTreeRegular, isTreasuryEnabled,
+		func(utxo *addrUtxo) error {
+			return dbRemoveAddrUtxo(bucket, utxo)
+		})
+}
+
+// reconnectDisapprovedTree reapplies the effects of the regular transaction
+// tree of the passed block on the unspent outputs after they were reversed by
+// disconnectDisapprovedTree.
+func (idx *AddrUtxoIndex) reconnectDisapprovedTree(bucket database.Bucket, block *dcrutil.Block, isTreasuryEnabled bool) error {
+	// Add the outputs created by the regular tree prior to removing the
+	// outputs it spent so outputs that are both created and spent within the
+	// tree are not added.
+	err := idx.forEachAddrUtxo(block, wire.TxTreeRegular, isTreasuryEnabled,
+		func(utxo *addrUtxo) error {
+			return dbPutAddrUtxo(bucket, utxo)
+		})
+	if err != nil {
+		return err
+	}
+	utxos, spendingTrees, err := dbFetchAddrUtxoUndoEntries(bucket,
+		block.Height())
+	if err != nil {
+		return err
+	}
+	for i := range utxos {
+		if spendingTrees[i] != wire.TxTreeRegular {
+			continue
+		}
+		if err := dbRemoveAddrUtxo(bucket, &utxos[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer updates the unspent outputs of
+// every address the transactions in the block involve.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, isTreasuryEnabled bool) error {
+	bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+
+	// Reverse the effects of the regular tree of the parent on the unspent
+	// outputs when the block disapproves it.
+	header := &block.MsgBlock().Header
+	if parent != nil && !dcrutil.IsFlagSet16(header.VoteBits, dcrutil.BlockValid) {
+		err := idx.disconnectDisapprovedTree(bucket, parent, isTreasuryEnabled)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Connect the stake tree prior to the regular tree to match the order
+	// the transactions are applied in by consensus.
+	var undo bytes.Buffer
+	err := idx.connectTree(bucket, block, wire.TxTreeStake, prevScripts,
+		isTreasuryEnabled, &undo)
+	if err != nil {
+		return err
+	}
+	err = idx.connectTree(bucket, block, wire.TxTreeRegular, prevScripts,
+		isTreasuryEnabled, &undo)
+	if err != nil {
+		return err
+	}
+
+	key := addrUtxoIndexKeyForUndo(block.Height())
+	if undo.Len() == 0 {
+		return bucket.Delete(key[:])
+	}
+	return bucket.Put(key[:], undo.Bytes())
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer reverses the effects of the
+// block on the unspent outputs.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+	height := block.Height()
+
+	// Restore the outputs spent by the block prior to removing the outputs
+	// it created so outputs that are both created and spent within the block
+	// are not restored.
+	utxos, _, err := dbFetchAddrUtxoUndoEntries(bucket, height)
+	if err != nil {
+		return err
+	}
+	for i := range utxos {
+		if err := dbPutAddrUtxo(bucket, &utxos[i]); err != nil {
+			return err
+		}
+	}
+	for _, tree := range []int8{wire.TxTreeRegular, wire.TxTreeStake} {
+		err := idx.forEachAddrUtxo(block, tree, isTreasuryEnabled,
+			func(utxo *addrUtxo) error {
+				return dbRemoveAddrUtxo(bucket, utxo)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	key := addrUtxoIndexKeyForUndo(height)
+	if err := bucket.Delete(key[:]); err != nil {
+		return err
+	}
+
+	// Reapply the effects of the regular tree of the parent on the unspent
+	// outputs when the block disapproved it.
+	header := &block.MsgBlock().Header
+	if parent != nil && !dcrutil.IsFlagSet16(header.VoteBits, dcrutil.BlockValid) {
+		return idx.reconnectDisapprovedTree(bucket, parent, isTreasuryEnabled)
+	}
+	return nil
+}
+
+// forEachUtxoForAddress invokes the provided function with every unspent
+// output in the main chain that pays to the provided address.  Unsupported
+// address types are ignored and will result in no invocations.
+func (idx *AddrUtxoIndex) forEachUtxoForAddress(addr dcrutil.Address, f func(entry *AddrUtxoEntry)) error {
+	// Ignore unsupported address types.
+	addrKey, err := addrToKey(addr)
+	if err != nil {
+		return nil
+	}
+
+	var prefix [1 + addrKeySize]byte
+	prefix[0] = addrUtxoKeyPrefix
+	copy(prefix[1:], addrKey[:])
+	return idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(addrUtxoIndexKey).Cursor()
+		for ok := cursor.Seek(prefix[:]); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != addrUtxoKeySize || !bytes.HasPrefix(key, prefix[:]) {
+				break
+			}
+
+			var entry AddrUtxoEntry
+			offset := copy(entry.OutPoint.Hash[:], key[len(prefix):])
+			offset += len(prefix)
+			entry.OutPoint.Index = byteOrder.Uint32(key[offset:])
+			entry.OutPoint.Tree = int8(key[offset+4])
+			err := deserializeAddrUtxoEntry(cursor.Value(), &entry)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt address utxo index "+
+						"entry for %v: %v", entry.OutPoint, err),
+				}
+			}
+			f(&entry)
+		}
+		return nil
+	})
+}
+
+// UtxosForAddress returns all unspent outputs in the main chain that pay to
+// the provided address from the address utxo index ordered by outpoint.
+// Unsupported address types are ignored and will result in no results.
+//
+// This function is safe for concurrent access.
+func (idx *AddrUtxoIndex) UtxosForAddress(addr dcrutil.Address) ([]AddrUtxoEntry, error) {
+	var entries []AddrUtxoEntry
+	err := idx.forEachUtxoForAddress(addr, func(entry *AddrUtxoEntry) {
+		entries = append(entries, *entry)
+	})
+	return entries, err
+}
+
+// BalanceForAddress returns the total amount in atoms of the unspent outputs in
+// the main chain that pay to the provided address along with the number of them
+// from the address utxo index.  Unsupported address types are ignored and will
+// result in a zero balance.
+//
+// This function is safe for concurrent access.
+func (idx *AddrUtxoIndex) BalanceForAddress(addr dcrutil.Address) (int64, uint32, error) {
+	var balance int64
+	var numUtxos uint32
+	err := idx.forEachUtxoForAddress(addr, func(entry *AddrUtxoEntry) {
+		balance += entry.Amount
+		numUtxos++
+	})
+	return balance, numUtxos, err
+}
+
+// NewAddrUtxoIndex returns a new instance of an indexer that is used to create
+// a mapping of all addresses in the blockchain to the unspent outputs that pay
+// to them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewAddrUtxoIndex(db database.DB, chainParams *chaincfg.Params) *AddrUtxoIndex {
+	return &AddrUtxoIndex{db: db, chainParams: chainParams}
+}
+
+// DropAddrUtxoIndex drops the address utxo index from the provided database if
+// it exists.
+func DropAddrUtxoIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, addrUtxoIndexKey, addrUtxoIndexName)
+}
+
+// DropIndex drops the address utxo index from the provided database if it
+// exists.
+func (*AddrUtxoIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropAddrUtxoIndex(ctx, db)
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestAddrUtxoIndexSerialization ensures serializing and deserializing address
+// utxo index entries works as expected.
+func TestAddrUtxoIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Ensure unspent output entries round trip and truncated entries are
+	// rejected.
+	pkScript, _ := hex.DecodeString("76a91462e907b15cbf27d5425399ebf6f0fb50eb" +
+		"b88f1888ac")
+	entry := AddrUtxoEntry{
+		Amount:        1234567890,
+		BlockHeight:   123456,
+		ScriptVersion: 0,
+		PkScript:      pkScript,
+	}
+	serialized := serializeAddrUtxoEntry(&entry)
+	if len(serialized) != addrUtxoEntryMinSize+len(entry.PkScript) {
+		t.Fatalf("unexpected serialized entry size -- got %d, want %d",
+			len(serialized), addrUtxoEntryMinSize+len(entry.PkScript))
+	}
+	var gotEntry AddrUtxoEntry
+	err := deserializeAddrUtxoEntry(serialized, &gotEntry)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing entry: %v", err)
+	}
+	if !reflect.DeepEqual(gotEntry, entry) {
+		t.Fatalf("mismatched entry -- got %+v, want %+v", gotEntry, entry)
+	}
+	err = deserializeAddrUtxoEntry(serialized[:addrUtxoEntryMinSize-1],
+		&gotEntry)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated entry -- got %v, want "+
+			"errDeserialize", err)
+	}
+
+	// Ensure undo entries round trip and truncated entries are rejected.
+	utxos := []addrUtxo{{
+		addrKey: [addrKeySize]byte{addrKeyTypePubKeyHash, 0x01},
+		AddrUtxoEntry: AddrUtxoEntry{
+			OutPoint: wire.OutPoint{Hash: chainhash.Hash{0x02}, Index: 2,
+				Tree: wire.TxTreeStake},
+			Amount:        1234567890,
+			BlockHeight:   123456,
+			ScriptVersion: 0,
+			PkScript:      entry.PkScript,
+		},
+	}, {
+		addrKey: [addrKeySize]byte{addrKeyTypeScriptHash, 0x03},
+		AddrUtxoEntry: AddrUtxoEntry{
+			OutPoint:      wire.OutPoint{Hash: chainhash.Hash{0x04}},
+			Amount:        1,
+			BlockHeight:   1,
+			ScriptVersion: 1,
+			PkScript:      []byte{},
+		},
+	}}
+	spendingTrees := []int8{wire.TxTreeRegular, wire.TxTreeStake}
+	var buf bytes.Buffer
+	for i := range utxos {
+		putAddrUtxoUndoEntry(&buf, &utxos[i], spendingTrees[i])
+	}
+	serialized = buf.Bytes()
+	gotUtxos, gotSpendingTrees, err := deserializeAddrUtxoUndoEntries(
+		serialized)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing undo entries: %v", err)
+	}
+	if !reflect.DeepEqual(gotUtxos, utxos) {
+		t.Fatalf("mismatched undo utxos -- got %+v, want %+v", gotUtxos, utxos)
+	}
+	if !reflect.DeepEqual(gotSpendingTrees, spendingTrees) {
+		t.Fatalf("mismatched undo spending trees -- got %v, want %v",
+			gotSpendingTrees, spendingTrees)
+	}
+	for _, truncatedLen := range []int{1, addrUtxoKeySize, len(serialized) - 1} {
+		_, _, err = deserializeAddrUtxoUndoEntries(serialized[:truncatedLen])
+		if !isDeserializeErr(err) {
+			t.Fatalf("unexpected error for undo entries truncated to %d "+
+				"bytes -- got %v, want errDeserialize", truncatedLen, err)
+		}
+	}
+}
+
+// TestAddrUtxoIndexConnectDisconnect ensures connecting and disconnecting
+// blocks to and from the address utxo index maintains the expected unspent
+// outputs, including outputs that are created and spent within the same block
+// and blocks that disapprove the regular transaction tree of their parent.
+func TestAddrUtxoIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewAddrUtxoIndex(db, chaincfg.RegNetParams())
+	})
+	defer teardown()
+	idx := h.idx.(*AddrUtxoIndex)
+
+	// assertUtxos ensures the unspent outputs in the index that pay to the
+	// address with the provided id are the given outpoints.
+	assertUtxos := func(stage string, id byte, want ...wire.OutPoint) {
+		t.Helper()
+
+		entries, err := idx.UtxosForAddress(testP2PKHAddr(t, id))
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching utxos: %v", stage, err)
+		}
+		got := make(map[wire.OutPoint]struct{}, len(entries))
+		for _, entry := range entries {
+			got[entry.OutPoint] = struct{}{}
+		}
+		if len(got) != len(want) {
+			t.Fatalf("%s: unexpected utxos for address %d -- got %v, want %v",
+				stage, id, entries, want)
+		}
+		for _, outpoint := range want {
+			if _, ok := got[outpoint]; !ok {
+				t.Fatalf("%s: missing utxo %v for address %d", stage,
+					outpoint, id)
+			}
+		}
+	}
+
+	const approve, disapprove = dcrutil.BlockValid, 0
+
+	// Block 1 creates an output that pays to each of addresses 1 and 2.
+	cb1 := newTestTx(nil, wire.NewTxOut(10, testP2PKHScript(1)),
+		wire.NewTxOut(20, testP2PKHScript(2)))
+	block1 := newTestBlock(1, approve, []*wire.MsgTx{cb1}, nil)
+	h.connect(block1, false)
+	assertUtxos("block 1", 1, testOutPoint(cb1, 0, wire.TxTreeRegular))
+	assertUtxos("block 1", 2, testOutPoint(cb1, 1, wire.TxTreeRegular))
+
+	// Block 2 contains a chain of regular transactions where the output to
+	// address 3 is spent by a later transaction in the same block along with
+	// a stake transaction that spends the output to address 2.
+	cb2 := newTestTx(nil, wire.NewTxOut(1, testP2PKHScript(6)))
+	tx1 := newTestTx([]wire.OutPoint{testOutPoint(cb1, 0, wire.TxTreeRegular)},
+		wire.NewTxOut(9, testP2PKHScript(3)))
+	tx2 := newTestTx([]wire.OutPoint{testOutPoint(tx1, 0, wire.TxTreeRegular)},
+		wire.NewTxOut(8, testP2PKHScript(4)))
+	stx := newTestTx([]wire.OutPoint{testOutPoint(cb1, 1, wire.TxTreeRegular)},
+		wire.NewTxOut(19, testP2PKHScript(5)))
+	block2 := newTestBlock(2, approve, []*wire.MsgTx{cb2, tx1, tx2},
+		[]*wire.MsgTx{stx})
+	h.connect(block2, false)
+	assertUtxos("block 2", 1)
+	assertUtxos("block 2", 2)
+	assertUtxos("block 2", 3)
+	assertUtxos("block 2", 4, testOutPoint(tx2, 0, wire.TxTreeRegular))
+	assertUtxos("block 2", 5, testOutPoint(stx, 0, wire.TxTreeStake))
+	assertUtxos("block 2", 6, testOutPoint(cb2, 0, wire.TxTreeRegular))
+
+	// Block 3 disapproves the regular tree of block 2, so the outputs it
+	// spent must be restored while the outputs it created, including the
+	// one it also spent, must be removed.  The stake tree of block 2 is not
+	// affected.
+	cb3 := newTestTx(nil, wire.NewTxOut(2, testP2PKHScript(7)))
+	block3 := newTestBlock(3, disapprove, []*wire.MsgTx{cb3}, nil)
+	h.connect(block3, false)
+	assertUtxos("block 3", 1, testOutPoint(cb1, 0, wire.TxTreeRegular))
+	assertUtxos("block 3", 2)
+	assertUtxos("block 3", 3)
+	assertUtxos("block 3", 4)
+	assertUtxos("block 3", 5, testOutPoint(stx, 0, wire.TxTreeStake))
+	assertUtxos("block 3", 6)
+	assertUtxos("block 3", 7, testOutPoint(cb3, 0, wire.TxTreeRegular))
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnect()
+	h.disconnect()
+	assertUtxos("disconnect", 1, testOutPoint(cb1, 0, wire.TxTreeRegular))
+	assertUtxos("disconnect", 2, testOutPoint(cb1, 1, wire.TxTreeRegular))
+	assertUtxos("disconnect", 3)
+	assertUtxos("disconnect", 4)
+	h.disconnectAll()
+	assertUtxos("disconnect all", 1)
+}
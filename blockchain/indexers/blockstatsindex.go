@@ -0,0 +1,478 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sort"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// blockStatsIndexName is the human-readable name for the index.
+	blockStatsIndexName = "block stats index"
+
+	// blockStatsIndexVersion is the current version of the block stats
+	// index.
+	blockStatsIndexVersion = 1
+
+	// blockStatsKeySize is the size of a block stats key.  It consists of 4
+	// bytes block height.
+	blockStatsKeySize = 4
+
+	// blockStatsEntrySize is the size of a block stats entry.  It consists
+	// of 32 bytes block hash + 4 bytes timestamp + 4 bytes size + 7*4 bytes
+	// transaction counts + 9*8 bytes fees and fee rates + 4*8 bytes
+	// treasury amounts and utxo increase.
+	blockStatsEntrySize = chainhash.HashSize + 4 + 4 + 7*4 + 9*8 + 4*8
+)
+
+var (
+	// blockStatsIndexKey is the key of the block stats index and the db
+	// bucket used to house it.
+	blockStatsIndexKey = []byte("blockstatsidx")
+
+	// BlockStatsFeeRatePercentiles are the percentiles of the fee rates paid
+	// by the transactions in a block that are tracked by the block stats
+	// index.
+	BlockStatsFeeRatePercentiles = [5]int{10, 25, 50, 75, 90}
+)
+
+// -----------------------------------------------------------------------------
+// The block stats index consists of an entry for every block in the main chain
+// that records statistics about the block and the transactions it contains.
+// The entries are keyed by the height of the block serialized as big endian so
+// they are ordered by their position in the chain.
+//
+// The serialized format for the entries is:
+//
+//   <block height> = <block hash><timestamp><size><counts><fees><treasury>
+//
+//   Field                  Type              Size
+//   block height           uint32            4 bytes (big endian)
+//   -----
+//   block hash             chainhash.Hash    32 bytes
+//   timestamp              uint32            4 bytes
+//   size                   uint32            4 bytes
+//   num regular txns       uint32            4 bytes
+//   num stake txns         uint32            4 bytes
+//   num tickets            uint32            4 bytes
+//   num votes              uint32            4 bytes
+//   num revocations        uint32            4 bytes
+//   num treasury adds      uint32            4 bytes
+//   num treasury spends    uint32            4 bytes
+//   total fee              int64             8 bytes
+//   min fee rate           int64             8 bytes
+//   max fee rate           int64             8 bytes
+//   avg fee rate           int64             8 bytes
+//   fee rate percentiles   [5]int64          40 bytes
+//   treasury add amount    int64             8 bytes
+//   treasury base amount   int64             8 bytes
+//   treasury spend amount  int64             8 bytes
+//   utxo increase          int64             8 bytes
+//   -----
+//   Total: 172 bytes
+//
+// Fee rates are in atoms per kB and only consider transactions that do not
+// create new coins.  In other words, the coinbase, treasury base, votes, and
+// treasury spends are excluded from the fee rates, but the fees they pay are
+// included in the total fee.  The percentiles are weighted by the size of the
+// transactions.
+// -----------------------------------------------------------------------------
+
+// BlockStats houses statistics about a block in the main chain and the
+// transactions it contains.
+type BlockStats struct {
+	// Hash, Height, Time, and Size describe the block itself.  Time is the
+	// timestamp from the block header in seconds since the Unix epoch.
+	Hash   chainhash.Hash
+	Height int64
+	Time   int64
+	Size   uint32
+
+	// NumTxns and NumSTxns are the number of transactions in the regular
+	// and stake trees of the block, respectively.
+	NumTxns  uint32
+	NumSTxns uint32
+
+	// These fields are the number of each type of stake transaction in the
+	// block.
+	NumTickets      uint32
+	NumVotes        uint32
+	NumRevocations  uint32
+	NumTreasuryAdds uint32
+	NumTSpends      uint32
+
+	// TotalFee is the total fees in atoms paid by all transactions in the
+	// block.
+	TotalFee int64
+
+	// MinFeeRate, MaxFeeRate, and AvgFeeRate are the minimum, maximum, and
+	// size-weighted average fee rates in atoms per kB paid by the
+	// transactions in the block that do not create new coins.
+	// FeeRatePercentiles are the size-weighted fee rates at each of the
+	// percentiles in BlockStatsFeeRatePercentiles.
+	MinFeeRate         int64
+	MaxFeeRate         int64
+	AvgFeeRate         int64
+	FeeRatePercentiles [5]int64
+
+	// TreasuryAddAmount, TreasuryBaseAmount, and TSpendAmount are the total
+	// amounts in atoms the treasury adds and treasury base in the block add
+	// to the treasury and the treasury spends in the block pay out of it,
+	// respectively.  The amounts paid by treasury spends exclude the fees.
+	TreasuryAddAmount  int64
+	TreasuryBaseAmount int64
+	TSpendAmount       int64
+
+	// UtxoIncrease is the number of spendable outputs created by the
+	// transactions in the block minus the number of outputs they spend.
+	UtxoIncrease int64
+}
+
+// blockStatsIndexKeyForHeight returns the key in the block stats index for the
+// entry of the block at the provided height.
+func blockStatsIndexKeyForHeight(height int64) [blockStatsKeySize]byte {
+	var key [blockStatsKeySize]byte
+	binary.BigEndian.PutUint32(key[:], uint32(height))
+	return key
+}
+
+// serializeBlockStatsEntry serializes the provided block stats into the format
+// described in detail above.  The height is not included since it is part of
+// the key.
+func serializeBlockStatsEntry(stats *BlockStats) []byte {
+	serialized := make([]byte, blockStatsEntrySize)
+	offset := copy(serialized, stats.Hash[:])
+	for _, v := range [...]uint32{uint32(stats.Time), stats.Size,
+		stats.NumTxns, stats.NumSTxns, stats.NumTickets, stats.NumVotes,
+		stats.NumRevocations, stats.NumTreasuryAdds, stats.NumTSpends} {
+
+		byteOrder.PutUint32(serialized[offset:], v)
+		offset += 4
+	}
+	int64s := [...]int64{stats.TotalFee, stats.MinFeeRate, stats.MaxFeeRate,
+		stats.AvgFeeRate, stats.FeeRatePercentiles[0],
+		stats.FeeRatePercentiles[1], stats.FeeRatePercentiles[2],
+		stats.FeeRatePercentiles[3], stats.FeeRatePercentiles[4],
+		stats.TreasuryAddAmount, stats.TreasuryBaseAmount, stats.TSpendAmount,
+		stats.UtxoIncrease}
+	for _, v := range int64s {
+		byteOrder.PutUint64(serialized[offset:], uint64(v))
+		offset += 8
+	}
+	return serialized
+}
+
+// deserializeBlockStatsEntry decodes the passed serialized byte slice into the
+// provided block stats according to the format described in detail above.  The
+// height is not set since it is part of the key.
+func deserializeBlockStatsEntry(serialized []byte, stats *BlockStats) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < blockStatsEntrySize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	offset := copy(stats.Hash[:], serialized)
+	stats.Time = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	for _, v := range [...]*uint32{&stats.Size, &stats.NumTxns,
+		&stats.NumSTxns, &stats.NumTickets, &stats.NumVotes,
+		&stats.NumRevocations, &stats.NumTreasuryAdds, &stats.NumTSpends} {
+
+		*v = byteOrder.Uint32(serialized[offset:])
+		offset += 4
+	}
+	int64s := [...]*int64{&stats.TotalFee, &stats.MinFeeRate,
+		&stats.MaxFeeRate, &stats.AvgFeeRate, &stats.FeeRatePercentiles[0],
+		&stats.FeeRatePercentiles[1], &stats.FeeRatePercentiles[2],
+		&stats.FeeRatePercentiles[3], &stats.FeeRatePercentiles[4],
+		&stats.TreasuryAddAmount, &stats.TreasuryBaseAmount,
+		&stats.TSpendAmount, &stats.UtxoIncrease}
+	for _, v := range int64s {
+		*v = int64(byteOrder.Uint64(serialized[offset:]))
+		offset += 8
+	}
+	return nil
+}
+
+// txFeeRate houses the fee rate paid by a transaction along with its size for
+// use when calculating size-weighted fee rate statistics.
+type txFeeRate struct {
+	feeRate int64
+	size    int64
+}
+
+// calcFeeRateStats sets the minimum, maximum, average, and percentile fee
+// rates of the provided block stats from the passed transaction fee rates,
+// total fee, and total size.  The fee rates are sorted in place.
+func calcFeeRateStats(stats *BlockStats, feeRates []txFeeRate, totalFee, totalSize int64) {
+	if len(feeRates) == 0 || totalSize == 0 {
+		return
+	}
+
+	sort.Slice(feeRates, func(i, j int) bool {
+		return feeRates[i].feeRate < feeRates[j].feeRate
+	})
+	stats.MinFeeRate = feeRates[0].feeRate
+	stats.MaxFeeRate = feeRates[len(feeRates)-1].feeRate
+	stats.AvgFeeRate = totalFee * 1000 / totalSize
+
+	// The fee rate at each percentile is the fee rate of the transaction
+	// which brings the cumulative size of the transactions ordered by their
+	// fee rate to at least that percentile of the total size.
+	var cumulativeSize int64
+	var pctIdx int
+	percentiles := BlockStatsFeeRatePercentiles
+	for _, feeRate := range feeRates {
+		cumulativeSize += feeRate.size
+		for pctIdx < len(percentiles) && cumulativeSize*100 >=
+			totalSize*int64(percentiles[pctIdx]) {
+
+			stats.FeeRatePercentiles[pctIdx] = feeRate.feeRate
+			pctIdx++
+		}
+	}
+}
+
+// calcBlockStats returns the statistics for the passed block.
+//
+// Fees are calculated from the input amounts committed to by the transactions
+// since they are validated against the outputs they spend by consensus.
+func calcBlockStats(block *dcrutil.Block, isTreasuryEnabled bool) *BlockStats {
+	msgBlock := block.MsgBlock()
+	stats := &BlockStats{
+		Hash:     *block.Hash(),
+		Height:   block.Height(),
+		Time:     msgBlock.Header.Timestamp.Unix(),
+		Size:     uint32(msgBlock.SerializeSize()),
+		NumTxns:  uint32(len(msgBlock.Transactions)),
+		NumSTxns: uint32(len(msgBlock.STransactions)),
+	}
+
+	var feeRates []txFeeRate
+	var feeRateTotalFee, feeRateTotalSize int64
+	processTx := func(tx *wire.MsgTx, txType stake.TxType) {
+		// Treasury bases do not spend any outputs or pay a fee and the
+		// amount they create is added to the treasury balance rather than
+		// the set of unspent outputs.
+		if txType == stake.TxTypeTreasuryBase {
+			stats.TreasuryBaseAmount += tx.TxOut[0].Value
+			return
+		}
+
+		// Account for the spendable outputs created and the outputs spent
+		// by the transaction.  Note that coinbases, stakebase inputs of
+		// votes, and treasury spends do not spend any outputs.
+		var totalIn, totalOut int64
+		for _, txIn := range tx.TxIn {
+			totalIn += txIn.ValueIn
+			if txIn.PreviousOutPoint.Hash != (chainhash.Hash{}) {
+				stats.UtxoIncrease--
+			}
+		}
+		for _, txOut := range tx.TxOut {
+			totalOut += txOut.Value
+			if !txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+				stats.UtxoIncrease++
+			}
+		}
+
+		switch txType {
+		case stake.TxTypeSStx:
+			stats.NumTickets++
+		case stake.TxTypeSSGen:
+			stats.NumVotes++
+		case stake.TxTypeSSRtx:
+			stats.NumRevocations++
+		case stake.TxTypeTAdd:
+			// Note that the second output, if it exists, is change.
+			stats.NumTreasuryAdds++
+			stats.TreasuryAddAmount += tx.TxOut[0].Value
+		case stake.TxTypeTSpend:
+			// The first output is an OP_RETURN and the remaining outputs
+			// pay out of the treasury.
+			stats.NumTSpends++
+			stats.TSpendAmount += totalOut
+		}
+
+		fee := totalIn - totalOut
+		stats.TotalFee += fee
+
+		// Only transactions that do not create new coins are included in
+		// the fee rates.
+		if txType == stake.TxTypeSSGen || txType == stake.TxTypeTSpend {
+			return
+		}
+		size := int64(tx.SerializeSize())
+		feeRates = append(feeRates, txFeeRate{
+			feeRate: fee * 1000 / size,
+			size:    size,
+		})
+		feeRateTotalFee += fee
+		feeRateTotalSize += size
+	}
+
+	// The coinbase does not pay a fee.
+	for _, tx := range msgBlock.Transactions[1:] {
+		processTx(tx, stake.TxTypeRegular)
+	}
+	for _, tx := range msgBlock.STransactions {
+		processTx(tx, stake.DetermineTxType(tx, isTreasuryEnabled))
+	}
+
+	// Account for the outputs created by the coinbase.
+	for _, txOut := range msgBlock.Transactions[0].TxOut {
+		if !txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+			stats.UtxoIncrease++
+		}
+	}
+
+	calcFeeRateStats(stats, feeRates, feeRateTotalFee, feeRateTotalSize)
+	return stats
+}
+
+// BlockStatsIndex implements a block statistics index.  That is to say, it
+// records statistics such as the fees, fee rates, transaction counts, treasury
+// flows, and change in the number of unspent transaction outputs for every
+// block in the main chain as they are connected so they are available without
+// needing to load and examine the blocks.
+type BlockStatsIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the BlockStatsIndex type implements the Indexer interface.
+var _ Indexer = (*BlockStatsIndex)(nil)
+
+// Ensure the BlockStatsIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*BlockStatsIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) Key() []byte {
+	return blockStatsIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) Name() string {
+	return blockStatsIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) Version() uint32 {
+	return blockStatsIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the block stats
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(blockStatsIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry with the statistics
+// for the passed block.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	stats := calcBlockStats(block, isTreasuryEnabled)
+	key := blockStatsIndexKeyForHeight(stats.Height)
+	bucket := dbTx.Metadata().Bucket(blockStatsIndexKey)
+	return bucket.Put(key[:], serializeBlockStatsEntry(stats))
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entry for the
+// passed block.
+//
+// This is part of the Indexer interface.
+func (idx *BlockStatsIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, _ bool) error {
+	key := blockStatsIndexKeyForHeight(block.Height())
+	return dbTx.Metadata().Bucket(blockStatsIndexKey).Delete(key[:])
+}
+
+// Stats returns the statistics for the block at the provided height in the
+// main chain from the block stats index.  When there is no entry for the
+// height, nil will be returned for both the stats and the error.
+//
+// This function is safe for concurrent access.
+func (idx *BlockStatsIndex) Stats(height int64) (*BlockStats, error) {
+	if height < 0 {
+		return nil, nil
+	}
+
+	var stats *BlockStats
+	err := idx.db.View(func(dbTx database.Tx) error {
+		key := blockStatsIndexKeyForHeight(height)
+		serialized := dbTx.Metadata().Bucket(blockStatsIndexKey).Get(key[:])
+		if len(serialized) == 0 {
+			return nil
+		}
+
+		stats = &BlockStats{Height: height}
+		if err := deserializeBlockStatsEntry(serialized, stats); err != nil {
+			return database.Error{
+				ErrorCode: database.ErrCorruption,
+				Description: fmt.Sprintf("corrupt block stats index entry "+
+					"for height %d: %v", height, err),
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// NewBlockStatsIndex returns a new instance of an indexer that is used to
+// record statistics about every block in the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewBlockStatsIndex(db database.DB, chainParams *chaincfg.Params) *BlockStatsIndex {
+	return &BlockStatsIndex{db: db, chainParams: chainParams}
+}
+
+// DropBlockStatsIndex drops the block stats index from the provided database if
+// it exists.
+func DropBlockStatsIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, blockStatsIndexKey, blockStatsIndexName)
+}
+
+// DropIndex drops the block stats index from the provided database if it
+// exists.
+func (*BlockStatsIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropBlockStatsIndex(ctx, db)
+}
@@ -0,0 +1,253 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestBlockStatsIndexSerialization ensures serializing and deserializing block
+// stats index entries works as expected.
+func TestBlockStatsIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Ensure entries round trip and truncated entries are rejected.  Note
+	// that the height is not part of the serialized entry.
+	stats := BlockStats{
+		Hash:               chainhash.Hash{0x01, 0x02},
+		Time:               1611000000,
+		Size:               12345,
+		NumTxns:            10,
+		NumSTxns:           9,
+		NumTickets:         3,
+		NumVotes:           5,
+		NumRevocations:     1,
+		NumTreasuryAdds:    2,
+		NumTSpends:         1,
+		TotalFee:           123456789,
+		MinFeeRate:         10000,
+		MaxFeeRate:         500000,
+		AvgFeeRate:         20000,
+		FeeRatePercentiles: [5]int64{10000, 10000, 15000, 20000, 100000},
+		TreasuryAddAmount:  100000000,
+		TreasuryBaseAmount: 50000000,
+		TSpendAmount:       75000000,
+		UtxoIncrease:       -3,
+	}
+	serialized := serializeBlockStatsEntry(&stats)
+	if len(serialized) != blockStatsEntrySize {
+		t.Fatalf("unexpected serialized entry size -- got %d, want %d",
+			len(serialized), blockStatsEntrySize)
+	}
+	var gotStats BlockStats
+	if err := deserializeBlockStatsEntry(serialized, &gotStats); err != nil {
+		t.Fatalf("unexpected error deserializing entry: %v", err)
+	}
+	if !reflect.DeepEqual(gotStats, stats) {
+		t.Fatalf("mismatched entry -- got %+v, want %+v", gotStats, stats)
+	}
+	err := deserializeBlockStatsEntry(serialized[:blockStatsEntrySize-1],
+		&gotStats)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated entry -- got %v, want "+
+			"errDeserialize", err)
+	}
+}
+
+// TestCalcFeeRateStats ensures the fee rate statistics are weighted by the
+// size of the transactions as expected.
+func TestCalcFeeRateStats(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		feeRates []txFeeRate
+		want     BlockStats
+	}{{
+		name: "no transactions",
+		want: BlockStats{},
+	}, {
+		name:     "single transaction",
+		feeRates: []txFeeRate{{feeRate: 10000, size: 250}},
+		want: BlockStats{
+			MinFeeRate:         10000,
+			MaxFeeRate:         10000,
+			AvgFeeRate:         10000,
+			FeeRatePercentiles: [5]int64{10000, 10000, 10000, 10000, 10000},
+		},
+	}, {
+		name: "weighted by size",
+		feeRates: []txFeeRate{
+			{feeRate: 30000, size: 100},
+			{feeRate: 10000, size: 700},
+			{feeRate: 20000, size: 200},
+		},
+		want: BlockStats{
+			MinFeeRate:         10000,
+			MaxFeeRate:         30000,
+			AvgFeeRate:         14000,
+			FeeRatePercentiles: [5]int64{10000, 10000, 10000, 20000, 20000},
+		},
+	}}
+
+	for _, test := range tests {
+		var totalFee, totalSize int64
+		for _, feeRate := range test.feeRates {
+			totalFee += feeRate.feeRate * feeRate.size / 1000
+			totalSize += feeRate.size
+		}
+		var stats BlockStats
+		calcFeeRateStats(&stats, test.feeRates, totalFee, totalSize)
+		if !reflect.DeepEqual(stats, test.want) {
+			t.Errorf("%q: mismatched stats -- got %+v, want %+v", test.name,
+				stats, test.want)
+		}
+	}
+}
+
+// TestBlockStatsIndexConnectDisconnect ensures connecting and disconnecting
+// blocks to and from the block stats index maintains the expected statistics
+// for blocks with regular, stake, and treasury transactions.
+func TestBlockStatsIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegNetParams()
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewBlockStatsIndex(db, params)
+	})
+	defer teardown()
+	idx := h.idx.(*BlockStatsIndex)
+
+	// newBlock returns a block with the provided transactions and a
+	// timestamp that is representable by the index.
+	const approve = dcrutil.BlockValid
+	newBlock := func(height uint32, txns, stxns []*wire.MsgTx) *dcrutil.Block {
+		block := newTestBlock(height, approve, txns, stxns)
+		block.MsgBlock().Header.Timestamp = time.Unix(1611000000+
+			int64(height), 0)
+		return dcrutil.NewBlock(block.MsgBlock())
+	}
+
+	// withValuesIn sets the input amounts of the provided transaction to the
+	// given values and returns it.
+	withValuesIn := func(tx *wire.MsgTx, valuesIn ...int64) *wire.MsgTx {
+		for i, valueIn := range valuesIn {
+			tx.TxIn[i].ValueIn = valueIn
+		}
+		return tx
+	}
+
+	// assertStats ensures the statistics for the block at the provided
+	// height match the given ones or that there are none when they are nil.
+	assertStats := func(stage string, height int64, want *BlockStats) {
+		t.Helper()
+
+		stats, err := idx.Stats(height)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching stats: %v", stage, err)
+		}
+		if !reflect.DeepEqual(stats, want) {
+			t.Fatalf("%s: mismatched stats for height %d -- got %+v, want "+
+				"%+v", stage, height, stats, want)
+		}
+	}
+
+	// Block 1 contains a coinbase with a null data output, two regular
+	// transactions that pay fees, a ticket, a vote, and a revocation.
+	cb1 := newTestTx(nil, wire.NewTxOut(100, testP2PKHScript(1)),
+		wire.NewTxOut(0, testNullDataScript(t, []byte{0x01})))
+	tx1 := withValuesIn(newTestTx([]wire.OutPoint{{Hash: chainhash.Hash{1}}},
+		wire.NewTxOut(900, testP2PKHScript(2))), 1000)
+	tx2 := withValuesIn(newTestTx([]wire.OutPoint{{Hash: chainhash.Hash{2}},
+		{Hash: chainhash.Hash{3}}}, wire.NewTxOut(990, testP2PKHScript(3))),
+		500, 500)
+	ticket := withValuesIn(newTestTicket(t, 4, 100, 4, 5), 120)
+	vote := withValuesIn(newTestVote(t, newTestTicket(t, 5, 100, 6, 7),
+		h.tip()), 5, 100)
+	revocation := withValuesIn(newTestRevocation(t,
+		newTestTicket(t, 6, 100, 8, 9)), 100)
+	block1 := newBlock(1, []*wire.MsgTx{cb1, tx1, tx2},
+		[]*wire.MsgTx{ticket, vote, revocation})
+	h.connect(block1, false)
+
+	// The vote is excluded from the fee rates while the fee it pays is part
+	// of the total fee.  The coinbase creates one spendable output, the
+	// second regular transaction spends two outputs to create one, and the
+	// other transactions spend and create one spendable output each.
+	stats1 := &BlockStats{
+		Hash:           *block1.Hash(),
+		Height:         1,
+		Time:           1611000001,
+		Size:           uint32(block1.MsgBlock().SerializeSize()),
+		NumTxns:        3,
+		NumSTxns:       3,
+		NumTickets:     1,
+		NumVotes:       1,
+		NumRevocations: 1,
+		TotalFee:       100 + 10 + 20 + 5,
+		UtxoIncrease:   0,
+	}
+	var feeRates []txFeeRate
+	var totalSize int64
+	for _, test := range []struct {
+		tx  *wire.MsgTx
+		fee int64
+	}{{tx1, 100}, {tx2, 10}, {ticket, 20}, {revocation, 0}} {
+		size := int64(test.tx.SerializeSize())
+		feeRates = append(feeRates, txFeeRate{test.fee * 1000 / size, size})
+		totalSize += size
+	}
+	calcFeeRateStats(stats1, feeRates, 130, totalSize)
+	if stats1.MinFeeRate != 0 || stats1.MaxFeeRate == 0 {
+		t.Fatalf("unexpected fee rate range %d-%d", stats1.MinFeeRate,
+			stats1.MaxFeeRate)
+	}
+	assertStats("block 1", 1, stats1)
+
+	// Block 2 is connected with the treasury agenda active and contains a
+	// treasury base, a treasury add with change, and a treasury spend.  The
+	// treasury base does not create any unspent outputs nor pay a fee and
+	// the treasury spend is excluded from the fee rates.
+	cb2 := newTestTx(nil, wire.NewTxOut(100, testP2PKHScript(1)))
+	tbase := newTestTreasuryBase(t, 2, 100)
+	tadd := withValuesIn(newTestTAdd(t, 7, 50), 60)
+	tspend := newTestTSpend(t, 35, 10, 20)
+	block2 := newBlock(2, []*wire.MsgTx{cb2},
+		[]*wire.MsgTx{tbase, tadd, tspend})
+	h.connect(block2, true)
+	taddSize := int64(tadd.SerializeSize())
+	stats2 := &BlockStats{
+		Hash:               *block2.Hash(),
+		Height:             2,
+		Time:               1611000002,
+		Size:               uint32(block2.MsgBlock().SerializeSize()),
+		NumTxns:            1,
+		NumSTxns:           3,
+		NumTreasuryAdds:    1,
+		NumTSpends:         1,
+		TotalFee:           9 + 5,
+		TreasuryAddAmount:  50,
+		TreasuryBaseAmount: 100,
+		TSpendAmount:       30,
+		UtxoIncrease:       1 + 1 + 2,
+	}
+	calcFeeRateStats(stats2, []txFeeRate{{9 * 1000 / taddSize, taddSize}}, 9,
+		taddSize)
+	assertStats("block 2", 2, stats2)
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnect()
+	assertStats("disconnect block 2", 2, nil)
+	h.disconnect()
+	assertStats("disconnect block 1", 1, nil)
+}
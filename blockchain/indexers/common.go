@@ -35,6 +35,13 @@ type NeedsInputser interface {
 	NeedsInputs() bool
 }
 
+// DependsOner provides a generic interface for an indexer to specify the keys
+// of the indexes it depends on.  The index manager never connects blocks to an
+// index before the indexes it depends on have indexed them.
+type DependsOner interface {
+	DependsOn() [][]byte
+}
+
 // PrevScripter defines an interface that provides access to scripts and their
 // associated version keyed by an outpoint.  It is used within this package as a
 // generic means to provide the scripts referenced by the inputs to transactions
@@ -92,12 +99,25 @@ type ChainQueryer interface {
 	BlockHashByHeight(int64) (*chainhash.Hash, error)
 
 	// PrevScripts returns a source of previous transaction scripts and their
-	// associated versions spent by the given block.
-	PrevScripts(database.Tx, *dcrutil.Block) (PrevScripter, error)
+	// associated versions spent by the given block.  The boolean specifies
+	// whether or not the treasury agenda is active for the block.
+	//
+	// This function MUST NOT wait on chain processing since it is called with
+	// a database transaction open.
+	PrevScripts(database.Tx, *dcrutil.Block, bool) (PrevScripter, error)
 
 	// IsTreasuryEnabled returns true if the treasury agenda is active at
 	// the provided block.
 	IsTreasuryEnabled(*chainhash.Hash) (bool, error)
+
+	// CommittedTip returns the hash and height of the tip of the main chain
+	// as stored in the database as of the provided database transaction.  It
+	// differs from the main chain state reported by the other methods while
+	// the chain is being updated after committing a block to the database.
+	//
+	// This function MUST NOT wait on chain processing since it is called with
+	// a database transaction open.
+	CommittedTip(database.Tx) (*chainhash.Hash, int64, error)
 }
 
 // IndexManager provides a generic interface that is called when blocks are
@@ -112,12 +132,18 @@ type IndexManager interface {
 	Init(context.Context, ChainQueryer) error
 
 	// ConnectBlock is invoked when a new block has been connected to the main
-	// chain.
+	// chain.  Implementations may update the indexes asynchronously.
 	ConnectBlock(database.Tx, *dcrutil.Block, *dcrutil.Block, PrevScripter, bool) error
 
 	// DisconnectBlock is invoked when a block has been disconnected from the
 	// main chain.
 	DisconnectBlock(database.Tx, *dcrutil.Block, *dcrutil.Block, PrevScripter, bool) error
+
+	// LowestTipHeight returns the height of the lowest tip of the managed
+	// indexes as of the provided database transaction or -1 when there are
+	// none.  The data for the block at that height and all blocks after it
+	// must be retained since the indexes still need it to catch up.
+	LowestTipHeight(database.Tx) (int64, error)
 }
 
 // IndexDropper provides a method to remove an index from the database. Indexers
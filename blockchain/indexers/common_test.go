@@ -0,0 +1,316 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	_ "github.com/decred/dcrd/database/v2/ffldb"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// testPrevScript houses the script version and public key script of an output
+// tracked by a testPrevScripter.
+type testPrevScript struct {
+	version  uint16
+	pkScript []byte
+}
+
+// testPrevScripter provides a mock PrevScripter backed by the outputs of the
+// transactions added to it.
+type testPrevScripter map[wire.OutPoint]testPrevScript
+
+// Ensure the testPrevScripter type implements the PrevScripter interface.
+var _ PrevScripter = testPrevScripter(nil)
+
+// PrevScript returns the script version and public key script of the provided
+// outpoint along with whether or not it was found.
+//
+// This is part of the PrevScripter interface.
+func (p testPrevScripter) PrevScript(outpoint *wire.OutPoint) (uint16, []byte, bool) {
+	entry, ok := p[*outpoint]
+	return entry.version, entry.pkScript, ok
+}
+
+// addBlock adds the outputs created by the transactions in the provided block
+// to the mock prev scripter.
+func (p testPrevScripter) addBlock(block *dcrutil.Block) {
+	for _, tx := range block.Transactions() {
+		p.addTx(tx.MsgTx(), wire.TxTreeRegular)
+	}
+	for _, stx := range block.STransactions() {
+		p.addTx(stx.MsgTx(), wire.TxTreeStake)
+	}
+}
+
+// addTx adds the outputs created by the provided transaction in the given tree
+// to the mock prev scripter.
+func (p testPrevScripter) addTx(tx *wire.MsgTx, tree int8) {
+	txHash := tx.TxHash()
+	for i, txOut := range tx.TxOut {
+		outpoint := wire.OutPoint{Hash: txHash, Index: uint32(i), Tree: tree}
+		p[outpoint] = testPrevScript{txOut.Version, txOut.PkScript}
+	}
+}
+
+// createTestDB creates a new database in a temporary directory along with a
+// teardown function the caller should invoke when done testing to clean up.
+func createTestDB(t *testing.T) (database.DB, func()) {
+	t.Helper()
+
+	dbPath, err := ioutil.TempDir("", "indexers")
+	if err != nil {
+		t.Fatalf("unable to create test db path: %v", err)
+	}
+	db, err := database.Create("ffldb", dbPath, wire.RegNet)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("error creating db: %v", err)
+	}
+	teardown := func() {
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+	return db, teardown
+}
+
+// createTestIndexDB creates a new database in a temporary directory along with
+// the provided index by invoking the constructor with the database.  It also
+// returns a teardown function the caller should invoke when done testing to
+// clean up.
+func createTestIndexDB(t *testing.T, newIndex func(db database.DB) Indexer) (database.DB, Indexer, func()) {
+	t.Helper()
+
+	db, teardown := createTestDB(t)
+	idx := newIndex(db)
+	err := db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("unable to create %s: %v", idx.Name(), err)
+	}
+	if err := idx.Init(); err != nil {
+		teardown()
+		t.Fatalf("unable to initialize %s: %v", idx.Name(), err)
+	}
+	return db, idx, teardown
+}
+
+// testIndexHarness provides a harness for connecting and disconnecting a chain
+// of test blocks to and from an index.  It records the state of the index prior
+// to connecting each block so that disconnecting the block can be verified to
+// restore the exact prior state and it tracks the outputs created by the blocks
+// for the indexes that require the scripts of the outputs spent by them.
+type testIndexHarness struct {
+	t           *testing.T
+	db          database.DB
+	idx         Indexer
+	prevScripts testPrevScripter
+	blocks      []*dcrutil.Block
+	treasury    []bool
+	states      []map[string][]byte
+}
+
+// newTestIndexHarness creates a new database in a temporary directory along
+// with the provided index and returns a harness with a chain that consists of
+// a test genesis block along with a teardown function the caller should invoke
+// when done testing to clean up.
+func newTestIndexHarness(t *testing.T, newIndex func(db database.DB) Indexer) (*testIndexHarness, func()) {
+	t.Helper()
+
+	db, idx, teardown := createTestIndexDB(t, newIndex)
+	genesis := newTestBlock(0, dcrutil.BlockValid, []*wire.MsgTx{
+		newTestTx(nil)}, nil)
+	h := &testIndexHarness{
+		t:           t,
+		db:          db,
+		idx:         idx,
+		prevScripts: make(testPrevScripter),
+		blocks:      []*dcrutil.Block{genesis},
+		treasury:    []bool{false},
+	}
+	return h, teardown
+}
+
+// tip returns the current tip of the harness chain.
+func (h *testIndexHarness) tip() *dcrutil.Block {
+	return h.blocks[len(h.blocks)-1]
+}
+
+// connect connects the provided block to the index as the child of the
+// current tip of the harness chain with the treasury agenda active per the
+// provided flag and fails the test on error.
+func (h *testIndexHarness) connect(block *dcrutil.Block, isTreasuryEnabled bool) {
+	h.t.Helper()
+
+	state := dumpTestIndexBucket(h.t, h.db, h.idx)
+	h.prevScripts.addBlock(block)
+	err := h.db.Update(func(dbTx database.Tx) error {
+		return h.idx.ConnectBlock(dbTx, block, h.tip(), h.prevScripts,
+			isTreasuryEnabled)
+	})
+	if err != nil {
+		h.t.Fatalf("unable to connect block %d to %s: %v", block.Height(),
+			h.idx.Name(), err)
+	}
+	h.blocks = append(h.blocks, block)
+	h.treasury = append(h.treasury, isTreasuryEnabled)
+	h.states = append(h.states, state)
+}
+
+// disconnect disconnects the current tip of the harness chain from the index
+// and fails the test on error or when doing so does not restore the exact
+// state of the index prior to connecting it.
+func (h *testIndexHarness) disconnect() {
+	h.t.Helper()
+
+	numBlocks := len(h.blocks)
+	if numBlocks < 2 {
+		h.t.Fatal("unable to disconnect the genesis block")
+	}
+	block, parent := h.blocks[numBlocks-1], h.blocks[numBlocks-2]
+	isTreasuryEnabled := h.treasury[numBlocks-1]
+	err := h.db.Update(func(dbTx database.Tx) error {
+		return h.idx.DisconnectBlock(dbTx, block, parent, h.prevScripts,
+			isTreasuryEnabled)
+	})
+	if err != nil {
+		h.t.Fatalf("unable to disconnect block %d from %s: %v",
+			block.Height(), h.idx.Name(), err)
+	}
+	want := h.states[len(h.states)-1]
+	if got := dumpTestIndexBucket(h.t, h.db, h.idx); !reflect.DeepEqual(got, want) {
+		h.t.Fatalf("disconnect block %d: mismatched %s -- got %x, want %x",
+			block.Height(), h.idx.Name(), got, want)
+	}
+	h.blocks = h.blocks[:numBlocks-1]
+	h.treasury = h.treasury[:numBlocks-1]
+	h.states = h.states[:len(h.states)-1]
+}
+
+// disconnectAll disconnects all blocks aside from the genesis block of the
+// harness chain from the index while ensuring each one restores the exact
+// prior state of the index.
+func (h *testIndexHarness) disconnectAll() {
+	h.t.Helper()
+
+	for len(h.blocks) > 1 {
+		h.disconnect()
+	}
+}
+
+// dumpTestIndexBucket returns a copy of all key/value pairs in the bucket of
+// the provided index keyed by the string form of the keys.  It is used to
+// ensure disconnecting blocks restores the exact prior state of an index.
+func dumpTestIndexBucket(t *testing.T, db database.DB, idx Indexer) map[string][]byte {
+	t.Helper()
+
+	entries := make(map[string][]byte)
+	err := db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(idx.Key())
+		return bucket.ForEach(func(k, v []byte) error {
+			entries[string(k)] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("unable to dump %s: %v", idx.Name(), err)
+	}
+	return entries
+}
+
+// newTestBlock returns a block at the provided height with the given vote bits
+// and regular and stake transactions.  The vote bits determine whether or not
+// the block approves the regular transaction tree of its parent.
+func newTestBlock(height uint32, voteBits uint16, txns, stxns []*wire.MsgTx) *dcrutil.Block {
+	msgBlock := &wire.MsgBlock{
+		Header: wire.BlockHeader{
+			Height:   height,
+			VoteBits: voteBits,
+		},
+		Transactions:  txns,
+		STransactions: stxns,
+	}
+	return dcrutil.NewBlock(msgBlock)
+}
+
+// newTestTx returns a transaction that spends the provided outpoints and
+// creates the passed outputs.  A coinbase-like input that references the zero
+// hash is used when no outpoints are provided.
+func newTestTx(spends []wire.OutPoint, outputs ...*wire.TxOut) *wire.MsgTx {
+	tx := wire.NewMsgTx()
+	if len(spends) == 0 {
+		prevOut := wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex,
+			wire.TxTreeRegular)
+		tx.AddTxIn(wire.NewTxIn(prevOut, 0, nil))
+	}
+	for i := range spends {
+		tx.AddTxIn(wire.NewTxIn(&spends[i], 0, nil))
+	}
+	for _, txOut := range outputs {
+		tx.AddTxOut(txOut)
+	}
+	return tx
+}
+
+// testPubKeyHash returns a deterministic pubkey hash derived from the provided
+// id.
+func testPubKeyHash(id byte) []byte {
+	pkHash := make([]byte, 20)
+	for i := range pkHash {
+		pkHash[i] = id
+	}
+	return pkHash
+}
+
+// testP2PKHScript returns a pay-to-pubkey-hash script that pays to the pubkey
+// hash derived from the provided id.
+func testP2PKHScript(id byte) []byte {
+	script := []byte{0x76, 0xa9, 0x14}
+	script = append(script, testPubKeyHash(id)...)
+	return append(script, 0x88, 0xac)
+}
+
+// testP2PKHAddr returns the regression test network pay-to-pubkey-hash address
+// for the pubkey hash derived from the provided id.
+func testP2PKHAddr(t *testing.T, id byte) dcrutil.Address {
+	t.Helper()
+
+	addr, err := dcrutil.NewAddressPubKeyHash(testPubKeyHash(id),
+		chaincfg.RegNetParams(), dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unable to create address: %v", err)
+	}
+	return addr
+}
+
+// testOutPoint returns the outpoint for the output at the provided index of
+// the passed transaction in the given tree.
+func testOutPoint(tx *wire.MsgTx, index uint32, tree int8) wire.OutPoint {
+	return wire.OutPoint{Hash: tx.TxHash(), Index: index, Tree: tree}
+}
+
+// testNullDataScript returns a script that consists of an OP_RETURN followed by
+// a push of the provided data.
+func testNullDataScript(t *testing.T, data []byte) []byte {
+	t.Helper()
+
+	script, err := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).
+		AddData(data).Script()
+	if err != nil {
+		t.Fatalf("unable to create null data script: %v", err)
+	}
+	return script
+}
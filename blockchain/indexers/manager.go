@@ -6,8 +6,13 @@
 package indexers
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/decred/dcrd/blockchain/v4/internal/progresslog"
 	"github.com/decred/dcrd/chaincfg/chainhash"
@@ -16,6 +21,25 @@ import (
 	"github.com/decred/dcrd/dcrutil/v3"
 )
 
+const (
+	// maxCatchUpBlocks is the maximum number of blocks that are connected to
+	// indexes that are behind the best chain tip before giving the other
+	// indexes a chance to be synced.
+	maxCatchUpBlocks = 1000
+
+	// syncInterval is the interval at which the index manager checks if the
+	// indexes need to be synced in addition to when it is notified about
+	// blocks being connected to the main chain.
+	syncInterval = time.Second
+
+	// syncRetryInterval and maxSyncRetryInterval are the initial and maximum
+	// amount of time the index manager waits before trying to sync the
+	// indexes again after a failure.  The delay doubles with every
+	// consecutive failure.
+	syncRetryInterval    = 5 * time.Second
+	maxSyncRetryInterval = 5 * time.Minute
+)
+
 var (
 	// indexTipsBucketName is the name of the db bucket used to house the
 	// current tip of each index.
@@ -183,6 +207,36 @@ type Manager struct {
 	params         *chaincfg.Params
 	db             database.DB
 	enabledIndexes []Indexer
+	progressLogger *progresslog.BlockProgressLogger
+
+	// wakeup is used to signal the goroutine that syncs the indexes when
+	// blocks are connected to the main chain.
+	wakeup chan struct{}
+
+	// syncMtx prevents the indexes from being caught up while an index is
+	// being rewound so the two do not repeatedly undo each other's work.
+	syncMtx sync.Mutex
+
+	// syncRetryInterval is the initial amount of time to wait before trying
+	// to sync the indexes again after a failure.  It is only modified by
+	// tests.
+	syncRetryInterval time.Duration
+
+	// These fields are protected by the mutex.
+	//
+	// chain provides access to the main chain once the manager is
+	// initialized.
+	//
+	// infos houses the sync state of each enabled index and tipChanged is
+	// closed and replaced whenever the tip of an index changes.
+	//
+	// syncErr is the unrecoverable error that stopped the indexes from being
+	// synced, if any.
+	mtx        sync.Mutex
+	chain      ChainQueryer
+	infos      []IndexInfo
+	tipChanged chan struct{}
+	syncErr    error
 }
 
 // Ensure the Manager type implements the IndexManager interface.
@@ -372,11 +426,11 @@ func dbFetchBlockByHash(dbTx database.Tx, hash *chainhash.Hash) (*dcrutil.Block,
 }
 
 // Init initializes the enabled indexes.  This is called during chain
-// initialization and primarily consists of catching up all indexes to the
-// current best chain tip.  This is necessary since each index can be disabled
-// and re-enabled at any time and attempting to catch-up indexes at the same
-// time new blocks are being downloaded would lead to an overall longer time to
-// catch up due to the I/O contention.
+// initialization and consists of creating and upgrading the indexes as needed
+// along with removing any blocks that are no longer in the main chain from
+// them.  Catching up the indexes to the current best chain tip is done in the
+// background by Run since each index can be disabled and re-enabled at any
+// time and doing so here would delay startup until all of them are caught up.
 //
 // This is part of the IndexManager interface.
 func (m *Manager) Init(ctx context.Context, chain ChainQueryer) error {
@@ -462,24 +516,24 @@ func (m *Manager) Init(ctx context.Context, chain ChainQueryer) error {
 				}
 				cachedBlock = parent
 
+				// Find out if treasury was enabled at the parent.
+				isTreasuryEnabled, err := chain.IsTreasuryEnabled(parent.Hash())
+				if err != nil {
+					return err
+				}
+
 				// When the index requires all of the referenced
 				// txouts they need to be retrieved from the
 				// database.
 				var prevScripts PrevScripter
 				if indexNeedsInputs(indexer) {
-					var err error
-					prevScripts, err = chain.PrevScripts(dbTx, block)
+					prevScripts, err = chain.PrevScripts(dbTx, block,
+						isTreasuryEnabled)
 					if err != nil {
 						return err
 					}
 				}
 
-				// Find out if treasury was enabled at the parent.
-				isTreasuryEnabled, err := chain.IsTreasuryEnabled(parent.Hash())
-				if err != nil {
-					return err
-				}
-
 				// Remove all of the index entries associated
 				// with the block and update the indexer tip.
 				err = dbIndexDisconnectBlock(dbTx, indexer,
@@ -519,176 +573,805 @@ func (m *Manager) Init(ctx context.Context, chain ChainQueryer) error {
 		}
 	}
 
-	// Fetch the current tip heights for each index along with tracking the
-	// lowest one so the catchup code only needs to start at the earliest
-	// block and is able to skip connecting the block for the indexes that
-	// don't need it.
-	bestHeight := int32(chain.BestHeight())
-	lowestHeight := bestHeight
-	indexerHeights := make([]int32, len(m.enabledIndexes))
-	err = m.db.View(func(dbTx database.Tx) error {
+	// Load the current tip of each index so the sync state of the indexes can
+	// be reported.  Any indexes that are behind the current best chain tip are
+	// caught up in the background once the manager is started via Run.
+	tips, err := m.fetchTips()
+	if err != nil {
+		return err
+	}
+	bestHeight := chain.BestHeight()
+	m.mtx.Lock()
+	m.chain = chain
+	for i, indexer := range m.enabledIndexes {
+		tip := &tips[i]
+		log.Debugf("Current %s tip (height %d, hash %v)", indexer.Name(),
+			tip.height, tip.hash)
+
+		info := &m.infos[i]
+		info.Hash = tip.hash
+		info.Height = tip.height
+		info.Synced = tip.height >= bestHeight
+		if !info.Synced {
+			log.Infof("The %s will be caught up from height %d to %d in the "+
+				"background", indexer.Name(), tip.height, bestHeight)
+		}
+	}
+	m.mtx.Unlock()
+
+	return nil
+}
+
+// indexNeedsInputs returns whether or not the index needs access to the txouts
+// referenced by the transaction inputs being indexed.
+func indexNeedsInputs(index Indexer) bool {
+	if idx, ok := index.(NeedsInputser); ok {
+		return idx.NeedsInputs()
+	}
+
+	return false
+}
+
+// ConnectBlock must be invoked when a block is extending the main chain.  The
+// indexes are not updated with the block here since they are synced with the
+// main chain asynchronously, so it only wakes the goroutine that syncs them.
+//
+// This is part of the IndexManager interface.
+func (m *Manager) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, isTreasuryEnabled bool) error {
+	// Wake the sync goroutine without blocking when it is already pending.
+	select {
+	case m.wakeup <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// DisconnectBlock must be invoked when a block is being disconnected from the
+// end of the main chain.  It invokes each indexer that has already indexed the
+// block to remove the index entries associated with it and updates the tips
+// accordingly.
+//
+// This must be done synchronously, unlike connecting blocks, since the spend
+// journal entry for the block, which is required to remove the entries from
+// some indexes, is removed along with it.
+//
+// This is part of the IndexManager interface.
+func (m *Manager) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, isTreasuryEnabled bool) error {
+	// Call each of the currently active optional indexes that have indexed
+	// the block being disconnected so they can update accordingly.
+	blockHash := block.Hash()
+	prevHash := &block.MsgBlock().Header.PrevBlock
+	for i, index := range m.enabledIndexes {
+		tipHash, _, err := dbFetchIndexerTip(dbTx, index.Key())
+		if err != nil {
+			return err
+		}
+		if *tipHash != *blockHash {
+			continue
+		}
+
+		err = dbIndexDisconnectBlock(dbTx, index, block, parent,
+			prevScripts, isTreasuryEnabled)
+		if err != nil {
+			return err
+		}
+		m.updateTip(i, prevHash, block.Height()-1, false)
+	}
+	return nil
+}
+
+// LowestTipHeight returns the height of the lowest tip of the enabled indexes
+// as of the provided database transaction or -1 when no indexes are enabled.
+//
+// This is part of the IndexManager interface.
+func (m *Manager) LowestTipHeight(dbTx database.Tx) (int64, error) {
+	lowest := int64(-1)
+	for _, indexer := range m.enabledIndexes {
+		_, height, err := dbFetchIndexerTip(dbTx, indexer.Key())
+		if err != nil {
+			return 0, err
+		}
+		if lowest == -1 || int64(height) < lowest {
+			lowest = int64(height)
+		}
+	}
+	return lowest, nil
+}
+
+// blockUnavailableError identifies an error that indicates the data for a main
+// chain block the indexes need in order to catch up is no longer available,
+// such as when it was pruned before the indexes were enabled.  The indexes are
+// unable to make progress without it, so it is treated as unrecoverable.
+type blockUnavailableError struct {
+	hash   chainhash.Hash
+	height int64
+	names  []string
+}
+
+// Error returns the error as a human-readable string and satisfies the error
+// interface.
+func (e blockUnavailableError) Error() string {
+	return fmt.Sprintf("the data for block %v (height %d) that is required "+
+		"to sync the %s is not available since it was pruned -- the "+
+		"affected indexes must be dropped or the node resynced without "+
+		"pruning", e.hash, e.height, strings.Join(e.names, ", "))
+}
+
+// fetchCatchUpBlock loads the main chain block with the provided hash and
+// height that the enabled indexes at the provided positions need in order to
+// catch up.  An error of type blockUnavailableError is returned when the block
+// data does not exist.
+func (m *Manager) fetchCatchUpBlock(dbTx database.Tx, hash *chainhash.Hash, height int64, group []int) (*dcrutil.Block, error) {
+	block, err := dbFetchBlockByHash(dbTx, hash)
+	if database.IsError(err, database.ErrBlockNotFound) {
+		names := make([]string, 0, len(group))
+		for _, idx := range group {
+			names = append(names, m.enabledIndexes[idx].Name())
+		}
+		return nil, blockUnavailableError{*hash, height, names}
+	}
+	return block, err
+}
+
+// indexTip houses the hash and height of the current tip of an index.
+type indexTip struct {
+	hash   chainhash.Hash
+	height int64
+}
+
+// fetchTips loads the current tip of each of the enabled indexes from the
+// database.
+func (m *Manager) fetchTips() ([]indexTip, error) {
+	tips := make([]indexTip, len(m.enabledIndexes))
+	err := m.db.View(func(dbTx database.Tx) error {
 		for i, indexer := range m.enabledIndexes {
-			idxKey := indexer.Key()
-			hash, height, err := dbFetchIndexerTip(dbTx, idxKey)
+			hash, height, err := dbFetchIndexerTip(dbTx, indexer.Key())
 			if err != nil {
 				return err
 			}
-
-			log.Debugf("Current %s tip (height %d, hash %v)",
-				indexer.Name(), height, hash)
-			indexerHeights[i] = height
-			if height < lowestHeight {
-				lowestHeight = height
-			}
+			tips[i] = indexTip{hash: *hash, height: int64(height)}
 		}
 		return nil
 	})
-	if err != nil {
-		return err
+	return tips, err
+}
+
+// updateTip updates the reported tip of the enabled index at the provided
+// position and notifies any callers that are waiting for indexes to reach a
+// given height.  The index is marked synced when the synced flag is set and
+// remains so afterwards.
+//
+// This function is safe for concurrent access.
+func (m *Manager) updateTip(idx int, hash *chainhash.Hash, height int64, synced bool) {
+	m.mtx.Lock()
+	info := &m.infos[idx]
+	info.Hash = *hash
+	info.Height = height
+	if synced && !info.Synced {
+		info.Synced = true
+		log.Infof("The %s is caught up to height %d", info.Name, height)
 	}
+	close(m.tipChanged)
+	m.tipChanged = make(chan struct{})
+	m.mtx.Unlock()
+}
 
-	// Nothing to index if all of the indexes are caught up.
-	if lowestHeight == bestHeight {
-		return nil
+// committedMainChainHash returns the hash of the main chain block at the
+// provided height when the main chain state matches the main chain committed
+// to the database as of the passed database transaction and the block is part
+// of it.  Otherwise, nil is returned.
+//
+// The main chain state is updated after the database when blocks are connected
+// and disconnected, so it briefly refers to blocks that are no longer in the
+// main chain, or have not been added to it yet, as far as the database is
+// concerned.  Since the chain is not able to commit more blocks to the database
+// while the transaction is open, the main chain state is consistent with the
+// database for the duration of the transaction once they match.
+func (m *Manager) committedMainChainHash(dbTx database.Tx, height int64) (*chainhash.Hash, error) {
+	tipHash, tipHeight, err := m.chain.CommittedTip(dbTx)
+	if err != nil {
+		return nil, err
+	}
+	if height > tipHeight {
+		return nil, nil
 	}
+	hash, err := m.chain.BlockHashByHeight(tipHeight)
+	if err != nil || *hash != *tipHash {
+		return nil, nil
+	}
+	hash, err = m.chain.BlockHashByHeight(height)
+	if err != nil {
+		return nil, nil
+	}
+	return hash, nil
+}
 
-	// Create a progress logger for the indexing process below.
-	progressLogger := progresslog.NewBlockProgressLogger("Indexed", log)
+// dependenciesIndexed returns whether or not all of the indexes the enabled
+// index at the provided position depends on have indexed the block at the
+// provided height.
+func (m *Manager) dependenciesIndexed(idx int, tips []indexTip, height int64) bool {
+	dependent, ok := m.enabledIndexes[idx].(DependsOner)
+	if !ok {
+		return true
+	}
+	for _, key := range dependent.DependsOn() {
+		for i, indexer := range m.enabledIndexes {
+			if bytes.Equal(indexer.Key(), key) && tips[i].height < height {
+				return false
+			}
+		}
+	}
+	return true
+}
 
-	// At this point, one or more indexes are behind the current best chain
-	// tip and need to be caught up, so log the details and loop through
-	// each block that needs to be indexed.
-	log.Infof("Catching up indexes from height %d to %d", lowestHeight,
-		bestHeight)
+// catchUpIndexes connects up to maxCatchUpBlocks main chain blocks to the
+// enabled indexes at the provided positions, which must all have the same tip,
+// and updates the provided tips accordingly.  It returns the number of blocks
+// that were connected.
+//
+// Indexes stop being updated when their tip is modified concurrently or the
+// indexes they depend on have not indexed the next block yet.  No more blocks
+// are connected once the main chain no longer extends the tip, such as during
+// a reorganization, so they can be connected once the reorganization is
+// complete.
+func (m *Manager) catchUpIndexes(ctx context.Context, group []int, tips []indexTip, bestHeight int64) (int, error) {
+	prevHash := tips[group[0]].hash
+	var parent *dcrutil.Block
+	var numConnected int
+	for height := tips[group[0]].height + 1; height <= bestHeight &&
+		numConnected < maxCatchUpBlocks; height++ {
 
-	var cachedParent *dcrutil.Block
-	for height := lowestHeight + 1; height <= bestHeight; height++ {
 		if interruptRequested(ctx) {
-			return errInterruptRequested
+			return numConnected, errInterruptRequested
 		}
 
-		var block, parent *dcrutil.Block
-		err = m.db.Update(func(dbTx database.Tx) error {
-			// Get the parent of the block, unless it's already cached.
-			if cachedParent == nil && height > 0 {
-				parentHash, err := chain.BlockHashByHeight(int64(height - 1))
-				if err != nil {
-					return err
-				}
-				parent, err = dbFetchBlockByHash(dbTx, parentHash)
-				if err != nil {
-					return err
-				}
-			} else {
-				parent = cachedParent
-			}
+		// Find out if treasury was enabled at the parent.  This is done prior
+		// to starting the database transaction since the chain holds its lock
+		// while it updates the database.
+		isTreasuryEnabled, err := m.chain.IsTreasuryEnabled(&prevHash)
+		if err != nil {
+			return numConnected, err
+		}
 
-			// Load the block for the height since it is required to index
-			// it.
-			hash, err := chain.BlockHashByHeight(int64(height))
+		var block *dcrutil.Block
+		var connected []int
+		err = m.db.Update(func(dbTx database.Tx) error {
+			// Nothing more to connect when the main chain no longer
+			// extends the tip.  Note that the main chain state is updated
+			// after the database, so nothing is connected until it
+			// matches the database either.  The indexes are synced again
+			// once the chain notifies the manager about the next block.
+			hash, err := m.committedMainChainHash(dbTx, height)
 			if err != nil {
 				return err
 			}
-			block, err = dbFetchBlockByHash(dbTx, hash)
+			if hash == nil {
+				return nil
+			}
+			block, err = m.fetchCatchUpBlock(dbTx, hash, height, group)
 			if err != nil {
 				return err
 			}
-			cachedParent = block
-
-			if interruptRequested(ctx) {
-				return errInterruptRequested
+			if block.MsgBlock().Header.PrevBlock != prevHash {
+				return nil
+			}
+			if parent == nil {
+				parent, err = m.fetchCatchUpBlock(dbTx, &prevHash,
+					height-1, group)
+				if err != nil {
+					return err
+				}
 			}
 
-			// Connect the block for all indexes that need it.
+			// Connect the block for all indexes that still need it.
 			var prevScripts PrevScripter
-			for i, indexer := range m.enabledIndexes {
-				// Skip indexes that don't need to be updated with this
-				// block.
-				if indexerHeights[i] >= height {
+			for _, idx := range group {
+				indexer := m.enabledIndexes[idx]
+				tipHash, _, err := dbFetchIndexerTip(dbTx, indexer.Key())
+				if err != nil {
+					return err
+				}
+				if *tipHash != prevHash ||
+					!m.dependenciesIndexed(idx, tips, height) {
+
 					continue
 				}
 
-				// When the index requires all of the referenced
-				// txouts and they haven't been loaded yet, they
-				// need to be retrieved from the transaction
-				// index.
+				// When the index requires all of the referenced txouts and
+				// they haven't been loaded yet, they need to be retrieved
+				// from the spend journal.
 				if prevScripts == nil && indexNeedsInputs(indexer) {
-					var errMakeView error
-					prevScripts, errMakeView = chain.PrevScripts(dbTx, block)
-					if errMakeView != nil {
-						return errMakeView
+					prevScripts, err = m.chain.PrevScripts(dbTx, block,
+						isTreasuryEnabled)
+					if err != nil {
+						return err
 					}
 				}
 
-				// Find out if treasury was enabled at the parent.
-				isTreasuryEnabled, err := chain.IsTreasuryEnabled(parent.Hash())
-				if err != nil {
-					return err
-				}
-				err = dbIndexConnectBlock(dbTx, indexer, block,
-					parent, prevScripts, isTreasuryEnabled)
+				err = dbIndexConnectBlock(dbTx, indexer, block, parent,
+					prevScripts, isTreasuryEnabled)
 				if err != nil {
 					return err
 				}
-
-				indexerHeights[i] = height
+				tips[idx] = indexTip{hash: *hash, height: height}
+				connected = append(connected, idx)
 			}
-
 			return nil
 		})
 		if err != nil {
-			return err
+			return numConnected, err
 		}
-		progressLogger.LogBlockHeight(block.MsgBlock(), parent.MsgBlock())
+		if len(connected) == 0 {
+			break
+		}
+
+		// Update the reported tips and log the progress of the indexes that
+		// are catching up.
+		var catchingUp bool
+		for _, idx := range connected {
+			m.mtx.Lock()
+			catchingUp = catchingUp || !m.infos[idx].Synced
+			m.mtx.Unlock()
+			m.updateTip(idx, block.Hash(), height, height >= bestHeight)
+		}
+		if catchingUp {
+			m.progressLogger.LogBlockHeight(block.MsgBlock(),
+				parent.MsgBlock())
+		}
+
+		group = connected
+		prevHash = *block.Hash()
+		parent = block
+		numConnected++
 	}
 
-	log.Infof("Indexes caught up to height %d", bestHeight)
-	return nil
+	return numConnected, nil
 }
 
-// indexNeedsInputs returns whether or not the index needs access to the txouts
-// referenced by the transaction inputs being indexed.
-func indexNeedsInputs(index Indexer) bool {
-	if idx, ok := index.(NeedsInputser); ok {
-		return idx.NeedsInputs()
+// syncIndexes connects the main chain blocks that each enabled index is
+// missing until they are all caught up to the current best chain tip.  Indexes
+// that have the same tip are caught up together so the blocks only need to be
+// loaded once, while the indexes that are further behind are caught up a
+// limited number of blocks at a time so they do not hold up the others.
+func (m *Manager) syncIndexes(ctx context.Context) error {
+	for {
+		numConnected, err := m.syncIndexesRound(ctx)
+		if err != nil {
+			return err
+		}
+
+		// All indexes are synced when there was nothing to connect.
+		if numConnected == 0 {
+			return nil
+		}
 	}
+}
 
-	return false
+// syncIndexesRound performs a single round of catching up the enabled indexes
+// that are behind the current best chain tip and returns the number of blocks
+// that were connected.  The indexes are not rewound while a round is in
+// progress.
+func (m *Manager) syncIndexesRound(ctx context.Context) (int, error) {
+	m.syncMtx.Lock()
+	defer m.syncMtx.Unlock()
+
+	// Load the tips from the database since blocks can be disconnected from
+	// the indexes at any time.
+	tips, err := m.fetchTips()
+	if err != nil {
+		return 0, err
+	}
+	bestHeight := m.chain.BestHeight()
+	for i := range tips {
+		m.updateTip(i, &tips[i].hash, tips[i].height,
+			tips[i].height >= bestHeight)
+	}
+
+	var numConnected int
+	handled := make([]bool, len(m.enabledIndexes))
+	for i := range m.enabledIndexes {
+		if handled[i] || tips[i].height >= bestHeight {
+			continue
+		}
+
+		// Catch up all of the indexes that have the same tip together.
+		var group []int
+		tipHash := tips[i].hash
+		for j := i; j < len(m.enabledIndexes); j++ {
+			if !handled[j] && tips[j].hash == tipHash {
+				group = append(group, j)
+				handled[j] = true
+			}
+		}
+		n, err := m.catchUpIndexes(ctx, group, tips, bestHeight)
+		if err != nil {
+			return numConnected, err
+		}
+		numConnected += n
+	}
+	return numConnected, nil
 }
 
-// ConnectBlock must be invoked when a block is extending the main chain.  It
-// keeps track of the state of each index it is managing, performs some sanity
-// checks, and invokes each indexer.
+// Run catches up the enabled indexes that are behind the current best chain
+// tip and keeps them synced with the main chain as new blocks are connected to
+// it until the provided context is cancelled.  Each index is synced from its
+// own tip independently from chain processing, so new blocks are processed
+// without waiting on the indexes.
 //
-// This is part of the IndexManager interface.
-func (m *Manager) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, isTreasuryEnabled bool) error {
-	// Call each of the currently active optional indexes with the block
-	// being connected so they can update accordingly.
-	for _, index := range m.enabledIndexes {
-		err := dbIndexConnectBlock(dbTx, index, block, parent,
-			prevScripts, isTreasuryEnabled)
+// Failures to sync the indexes, such as transient database errors, are logged
+// and syncing is retried after a delay that doubles with every consecutive
+// failure up to a maximum.  The exception is when the data for a block the
+// indexes need is no longer available, such as when it was pruned, since they
+// are unable to make progress without it.  In that case, syncing stops and the
+// error is returned to all callers waiting for the indexes to reach a height.
+//
+// Init must be called before this function.
+func (m *Manager) Run(ctx context.Context) {
+	// Nothing to do when no indexes are enabled or the manager has not been
+	// initialized.
+	m.mtx.Lock()
+	initialized := m.chain != nil
+	m.mtx.Unlock()
+	if len(m.enabledIndexes) == 0 || !initialized {
+		return
+	}
+
+	ticker := time.NewTicker(syncInterval)
+	defer ticker.Stop()
+	var retryInterval time.Duration
+	for {
+		err := m.syncIndexes(ctx)
+		if errors.Is(err, errInterruptRequested) {
+			return
+		}
+		var unavailableErr blockUnavailableError
+		if errors.As(err, &unavailableErr) {
+			log.Errorf("Unable to sync indexes: %v", err)
+			m.mtx.Lock()
+			m.syncErr = err
+			close(m.tipChanged)
+			m.tipChanged = make(chan struct{})
+			m.mtx.Unlock()
+			return
+		}
 		if err != nil {
+			// Wait before trying again with a delay that doubles with every
+			// consecutive failure.  Note that notifications about new blocks
+			// do not cut the delay short.
+			if retryInterval == 0 {
+				retryInterval = m.syncRetryInterval
+			} else if retryInterval < maxSyncRetryInterval {
+				retryInterval *= 2
+				if retryInterval > maxSyncRetryInterval {
+					retryInterval = maxSyncRetryInterval
+				}
+			}
+			log.Errorf("Unable to sync indexes (retrying in %v): %v",
+				retryInterval, err)
+			select {
+			case <-time.After(retryInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+		retryInterval = 0
+
+		select {
+		case <-m.wakeup:
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// IndexInfo houses information about the sync state of an index.
+type IndexInfo struct {
+	// Name is the human-readable name of the index.
+	Name string
+
+	// Hash and Height identify the current tip of the index.
+	Hash   chainhash.Hash
+	Height int64
+
+	// Synced specifies whether or not the index has caught up to the best
+	// chain tip since it was initialized.  Indexes that are synced may still
+	// briefly lag behind the best chain tip as new blocks are connected.
+	Synced bool
+}
+
+// IndexInfo returns the sync state of each of the enabled indexes.
+//
+// This function is safe for concurrent access.
+func (m *Manager) IndexInfo() []IndexInfo {
+	m.mtx.Lock()
+	infos := make([]IndexInfo, len(m.infos))
+	copy(infos, m.infos)
+	m.mtx.Unlock()
+	return infos
+}
+
+// WaitForHeight blocks until the enabled index with the provided name has
+// indexed the main chain block at the provided height or the provided context
+// is done, in which case the error from the context is returned.  The error
+// that stopped the indexes from being synced is returned when they are unable
+// to reach the height.
+//
+// This function is safe for concurrent access.
+func (m *Manager) WaitForHeight(ctx context.Context, name string, height int64) error {
+	idx := m.enabledIndex(name)
+	if idx == -1 {
+		return fmt.Errorf("the %s is not enabled", IndexName(name))
+	}
+
+	for {
+		m.mtx.Lock()
+		reached := m.infos[idx].Height >= height
+		tipChanged := m.tipChanged
+		syncErr := m.syncErr
+		m.mtx.Unlock()
+		if reached {
+			return nil
+		}
+		if syncErr != nil {
+			return syncErr
+		}
+
+		select {
+		case <-tipChanged:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// indexShortNames maps the short names of the optional indexes, which match the
+// command line options used to enable them, to their human-readable names.
+var indexShortNames = map[string]string{
+	"txindex":         txIndexName,
+	"addrindex":       addrIndexName,
+	"existsaddrindex": existsAddressIndexName,
+	"spendindex":      spendIndexName,
+	"ticketindex":     ticketIndexName,
+	"treasuryindex":   treasuryIndexName,
+	"scripthashindex": scriptHashIndexName,
+	"addrutxoindex":   addrUtxoIndexName,
+	"blockstatsindex": blockStatsIndexName,
+	"nulldataindex":   nullDataIndexName,
+}
+
+// IndexName returns the human-readable name of the index identified by the
+// provided name, which is either the short name of the index that matches the
+// command line option used to enable it, such as txindex, or the human-readable
+// name itself, such as transaction index.  The provided name is returned
+// unmodified when it is not the short name of an index.
+func IndexName(name string) string {
+	if indexName, ok := indexShortNames[name]; ok {
+		return indexName
+	}
+	return name
+}
+
+// enabledIndex returns the position of the enabled index identified by the
+// provided name, which may be a short name as described by IndexName, or -1
+// when the index is not enabled.
+func (m *Manager) enabledIndex(name string) int {
+	name = IndexName(name)
+	for i, indexer := range m.enabledIndexes {
+		if indexer.Name() == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// dependentIndexes returns the positions of all of the enabled indexes that
+// depend on the enabled index at the provided position either directly or
+// through other indexes.  They are ordered such that each index comes before
+// all of the indexes it depends on.
+func (m *Manager) dependentIndexes(idx int) []int {
+	var dependents []int
+	visited := make(map[int]struct{})
+	var visit func(idx int)
+	visit = func(idx int) {
+		key := m.enabledIndexes[idx].Key()
+		for i, indexer := range m.enabledIndexes {
+			dependent, ok := indexer.(DependsOner)
+			if !ok {
+				continue
+			}
+			if _, ok := visited[i]; ok {
+				continue
+			}
+			for _, depKey := range dependent.DependsOn() {
+				if bytes.Equal(depKey, key) {
+					visited[i] = struct{}{}
+					visit(i)
+					dependents = append(dependents, i)
+					break
+				}
+			}
+		}
+	}
+	visit(idx)
+	return dependents
+}
+
+// Reindex rewinds the enabled index with the provided name to the provided
+// height by disconnecting the main chain blocks after it from the index, one
+// block at a time, and then wakes the goroutine that syncs the indexes so the
+// blocks are indexed again.  This allows an index to be rebuilt from a given
+// height, such as after fixing an issue that caused it to store incorrect
+// entries, without dropping it and rebuilding it from scratch.  The name may
+// be a short name as described by IndexName.
+//
+// The enabled indexes that depend on the index, such as the address index for
+// the transaction index, are rewound to the same height first since they
+// reference the entries of the index.  The other enabled indexes are not
+// modified.
+//
+// Nothing is done for an index that has not indexed any blocks after the
+// provided height.  The blocks that were already disconnected remain so when
+// the provided context is done, in which case the error from the context is
+// returned.
+//
+// This function is safe for concurrent access.
+func (m *Manager) Reindex(ctx context.Context, name string, height int64) error {
+	idx := m.enabledIndex(name)
+	if idx == -1 {
+		return fmt.Errorf("the %s is not enabled", IndexName(name))
+	}
+	name = m.enabledIndexes[idx].Name()
+	if height < 0 {
+		return fmt.Errorf("unable to rewind the %s to negative height %d",
+			name, height)
+	}
+
+	m.mtx.Lock()
+	chain := m.chain
+	m.mtx.Unlock()
+	if chain == nil {
+		return fmt.Errorf("unable to rewind the %s before the index "+
+			"manager is initialized", name)
+	}
+
+	// Prevent the indexes from being caught up while the indexes are
+	// rewound.
+	m.syncMtx.Lock()
+	defer m.syncMtx.Unlock()
+
+	// Rewind the indexes that depend on the index prior to the index itself
+	// to ensure they never reference blocks that are no longer indexed.
+	for _, depIdx := range m.dependentIndexes(idx) {
+		log.Infof("Rewinding the %s since it depends on the %s",
+			m.enabledIndexes[depIdx].Name(), name)
+		if err := m.rewindIndex(ctx, chain, depIdx, height); err != nil {
 			return err
 		}
 	}
+	if err := m.rewindIndex(ctx, chain, idx, height); err != nil {
+		return err
+	}
+
+	// Wake the sync goroutine without blocking when it is already pending so
+	// the indexes are caught up again.
+	select {
+	case m.wakeup <- struct{}{}:
+	default:
+	}
 	return nil
 }
 
-// DisconnectBlock must be invoked when a block is being disconnected from the
-// end of the main chain.  It keeps track of the state of each index it is
-// managing, performs some sanity checks, and invokes each indexer to remove
-// the index entries associated with the block.
+// rewindIndex rewinds the enabled index at the provided position to the
+// provided height by disconnecting the main chain blocks after it from the
+// index, one block at a time.
 //
-// This is part of the IndexManager interface.
-func (m *Manager) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, isTreasuryEnabled bool) error {
-	// Call each of the currently active optional indexes with the block
-	// being disconnected so they can update accordingly.
-	for _, index := range m.enabledIndexes {
-		err := dbIndexDisconnectBlock(dbTx, index, block, parent,
-			prevScripts, isTreasuryEnabled)
+// This function MUST be called with the sync mutex held.
+func (m *Manager) rewindIndex(ctx context.Context, chain ChainQueryer, idx int, height int64) error {
+	indexer := m.enabledIndexes[idx]
+	idxKey := indexer.Key()
+	var initialHeight, tipHeight int64
+	for first := true; ; first = false {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		// Load the current tip of the index along with the block it
+		// references.  Note that the tip is loaded again for each block
+		// since blocks can be disconnected from the index at any time
+		// during chain reorganizations.
+		var block *dcrutil.Block
+		err := m.db.View(func(dbTx database.Tx) error {
+			hash, tipHeight32, err := dbFetchIndexerTip(dbTx, idxKey)
+			if err != nil {
+				return err
+			}
+			tipHeight = int64(tipHeight32)
+			if tipHeight <= height {
+				return nil
+			}
+			block, err = dbFetchBlockByHash(dbTx, hash)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if first {
+			initialHeight = tipHeight
+		}
+		if block == nil {
+			break
+		}
+
+		// Mark the index as no longer synced since it is behind the best
+		// chain tip until it is caught up again.
+		if first {
+			log.Infof("Rewinding the %s from height %d to %d",
+				indexer.Name(), tipHeight, height)
+			m.mtx.Lock()
+			m.infos[idx].Synced = false
+			m.mtx.Unlock()
+		}
+
+		// Find out if treasury was enabled at the parent.  This is done
+		// prior to starting the database transaction since the chain holds
+		// its lock while it updates the database.
+		prevHash := &block.MsgBlock().Header.PrevBlock
+		isTreasuryEnabled, err := chain.IsTreasuryEnabled(prevHash)
+		if err != nil {
+			return err
+		}
+
+		err = m.db.Update(func(dbTx database.Tx) error {
+			// Try again when the tip of the index was modified
+			// concurrently.
+			tipHash, _, err := dbFetchIndexerTip(dbTx, idxKey)
+			if err != nil {
+				return err
+			}
+			if *tipHash != *block.Hash() {
+				block = nil
+				return nil
+			}
+
+			// Load the parent block since it is required to remove the
+			// block.
+			parent, err := dbFetchBlockByHash(dbTx, prevHash)
+			if err != nil {
+				return err
+			}
+
+			// When the index requires all of the referenced txouts they
+			// need to be retrieved from the spend journal.
+			var prevScripts PrevScripter
+			if indexNeedsInputs(indexer) {
+				prevScripts, err = chain.PrevScripts(dbTx, block,
+					isTreasuryEnabled)
+				if err != nil {
+					return err
+				}
+			}
+
+			// Remove all of the index entries associated with the block
+			// and update the index tip.
+			return dbIndexDisconnectBlock(dbTx, indexer, block, parent,
+				prevScripts, isTreasuryEnabled)
+		})
 		if err != nil {
 			return err
 		}
+		if block != nil {
+			m.updateTip(idx, prevHash, block.Height()-1, false)
+		}
+	}
+
+	if initialHeight > tipHeight {
+		log.Infof("Removed %d blocks from the %s (heights %d to %d)",
+			initialHeight-tipHeight, indexer.Name(), tipHeight+1,
+			initialHeight)
 	}
 	return nil
 }
@@ -696,12 +1379,22 @@ func (m *Manager) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block
 // NewManager returns a new index manager with the provided indexes enabled.
 //
 // The manager returned satisfies the IndexManager interface and thus cleanly
-// plugs into the normal blockchain processing path.
+// plugs into the normal blockchain processing path.  Run must be called in
+// order to sync the indexes with the main chain.
 func NewManager(db database.DB, enabledIndexes []Indexer, params *chaincfg.Params) *Manager {
+	infos := make([]IndexInfo, len(enabledIndexes))
+	for i, indexer := range enabledIndexes {
+		infos[i].Name = indexer.Name()
+	}
 	return &Manager{
-		db:             db,
-		enabledIndexes: enabledIndexes,
-		params:         params,
+		db:                db,
+		enabledIndexes:    enabledIndexes,
+		params:            params,
+		progressLogger:    progresslog.NewBlockProgressLogger("Indexed", log),
+		wakeup:            make(chan struct{}, 1),
+		infos:             infos,
+		tipChanged:        make(chan struct{}),
+		syncRetryInterval: syncRetryInterval,
 	}
 }
 
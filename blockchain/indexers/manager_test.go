@@ -0,0 +1,611 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// testChain provides a mock ChainQueryer backed by blocks stored in a test
+// database.  The tip of the main chain reported by it and the tip it reports as
+// committed to the database are tracked separately so tests are able to
+// simulate the main chain state lagging behind the database.
+type testChain struct {
+	blocks []*dcrutil.Block
+
+	mtx             sync.Mutex
+	mainChainHeight int64
+	committedHeight int64
+}
+
+// Ensure the testChain type implements the ChainQueryer interface.
+var _ ChainQueryer = (*testChain)(nil)
+
+// newTestChain stores the provided number of blocks that extend the genesis
+// block of the passed params in the provided database and returns a mock chain
+// with all of them in the main chain and committed.
+func newTestChain(t *testing.T, db database.DB, params *chaincfg.Params, numBlocks int) *testChain {
+	t.Helper()
+
+	blocks := []*dcrutil.Block{dcrutil.NewBlock(params.GenesisBlock)}
+	for i := 1; i <= numBlocks; i++ {
+		coinbase := newTestTx(nil, wire.NewTxOut(int64(i),
+			testP2PKHScript(byte(i))))
+		msgBlock := newTestBlock(uint32(i), dcrutil.BlockValid,
+			[]*wire.MsgTx{coinbase}, nil).MsgBlock()
+		msgBlock.Header.PrevBlock = *blocks[i-1].Hash()
+		blocks = append(blocks, dcrutil.NewBlock(msgBlock))
+	}
+	err := db.Update(func(dbTx database.Tx) error {
+		for _, block := range blocks {
+			if err := dbTx.StoreBlock(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to store blocks: %v", err)
+	}
+	return &testChain{
+		blocks:          blocks,
+		mainChainHeight: int64(numBlocks),
+		committedHeight: int64(numBlocks),
+	}
+}
+
+// setState sets the height of the tip of the main chain reported by the mock
+// chain along with the height of the tip it reports as committed to the
+// database.
+func (c *testChain) setState(mainChainHeight, committedHeight int64) {
+	c.mtx.Lock()
+	c.mainChainHeight = mainChainHeight
+	c.committedHeight = committedHeight
+	c.mtx.Unlock()
+}
+
+// MainChainHasBlock returns whether or not the block with the given hash is in
+// the main chain.
+//
+// This is part of the ChainQueryer interface.
+func (c *testChain) MainChainHasBlock(hash *chainhash.Hash) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for _, block := range c.blocks[:c.mainChainHeight+1] {
+		if *block.Hash() == *hash {
+			return true
+		}
+	}
+	return false
+}
+
+// BestHeight returns the height of the tip of the main chain.
+//
+// This is part of the ChainQueryer interface.
+func (c *testChain) BestHeight() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.mainChainHeight
+}
+
+// BlockHashByHeight returns the hash of the block at the given height in the
+// main chain.
+//
+// This is part of the ChainQueryer interface.
+func (c *testChain) BlockHashByHeight(height int64) (*chainhash.Hash, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if height < 0 || height > c.mainChainHeight {
+		return nil, fmt.Errorf("no block at height %d", height)
+	}
+	return c.blocks[height].Hash(), nil
+}
+
+// PrevScripts returns an empty source of previous scripts since the mock
+// indexes do not need them.
+//
+// This is part of the ChainQueryer interface.
+func (c *testChain) PrevScripts(database.Tx, *dcrutil.Block, bool) (PrevScripter, error) {
+	return make(testPrevScripter), nil
+}
+
+// IsTreasuryEnabled always returns false since the treasury agenda is not
+// relevant to the mock indexes.
+//
+// This is part of the ChainQueryer interface.
+func (c *testChain) IsTreasuryEnabled(*chainhash.Hash) (bool, error) {
+	return false, nil
+}
+
+// CommittedTip returns the hash and height of the tip the mock chain reports as
+// committed to the database.
+//
+// This is part of the ChainQueryer interface.
+func (c *testChain) CommittedTip(database.Tx) (*chainhash.Hash, int64, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.blocks[c.committedHeight].Hash(), c.committedHeight, nil
+}
+
+// testIndexer provides a mock index that fails to connect blocks a
+// configurable number of times and records whether it was ever connected ahead
+// of the indexes it depends on.
+type testIndexer struct {
+	key       []byte
+	dependsOn [][]byte
+
+	mtx            sync.Mutex
+	failures       int
+	aheadOfDepends bool
+}
+
+// Ensure the testIndexer type implements the Indexer and DependsOner
+// interfaces.
+var _ Indexer = (*testIndexer)(nil)
+var _ DependsOner = (*testIndexer)(nil)
+
+// Key returns the database key of the mock index.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) Key() []byte {
+	return idx.key
+}
+
+// Name returns the name of the mock index.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) Name() string {
+	return string(idx.key)
+}
+
+// Version returns the version of the mock index.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) Version() uint32 {
+	return 1
+}
+
+// Init is only provided to satisfy the Indexer interface.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) Init() error {
+	return nil
+}
+
+// Create is only provided to satisfy the Indexer interface.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) Create(database.Tx) error {
+	return nil
+}
+
+// DependsOn returns the keys of the indexes the mock index depends on.
+//
+// This is part of the DependsOner interface.
+func (idx *testIndexer) DependsOn() [][]byte {
+	return idx.dependsOn
+}
+
+// ConnectBlock fails when there are failures remaining and otherwise records
+// whether or not the indexes the mock index depends on have indexed the block.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) ConnectBlock(dbTx database.Tx, block, _ *dcrutil.Block, _ PrevScripter, _ bool) error {
+	idx.mtx.Lock()
+	defer idx.mtx.Unlock()
+	if idx.failures > 0 {
+		idx.failures--
+		return errors.New("injected failure")
+	}
+	for _, key := range idx.dependsOn {
+		_, height, err := dbFetchIndexerTip(dbTx, key)
+		if err != nil {
+			return err
+		}
+		if int64(height) < block.Height() {
+			idx.aheadOfDepends = true
+		}
+	}
+	return nil
+}
+
+// DisconnectBlock is only provided to satisfy the Indexer interface.
+//
+// This is part of the Indexer interface.
+func (idx *testIndexer) DisconnectBlock(database.Tx, *dcrutil.Block, *dcrutil.Block, PrevScripter, bool) error {
+	return nil
+}
+
+// newTestManager creates a new index manager for the provided indexes backed
+// by the passed database and initializes it with the provided mock chain.
+func newTestManager(t *testing.T, db database.DB, chain *testChain, indexes ...Indexer) *Manager {
+	t.Helper()
+
+	m := NewManager(db, indexes, chaincfg.RegNetParams())
+	m.syncRetryInterval = time.Millisecond
+	if err := m.Init(context.Background(), chain); err != nil {
+		t.Fatalf("unable to initialize index manager: %v", err)
+	}
+	return m
+}
+
+// assertTipHeight ensures the tip of the index with the provided name as
+// reported by the passed manager is at the provided height.
+func assertTipHeight(t *testing.T, m *Manager, name string, height int64) {
+	t.Helper()
+
+	for _, info := range m.IndexInfo() {
+		if info.Name == name && info.Height != height {
+			t.Fatalf("unexpected %s tip height -- got %d, want %d", name,
+				info.Height, height)
+		}
+	}
+}
+
+// TestWaitForHeight ensures waiting for an index to reach a height and
+// reporting the sync state of the indexes works as expected.
+func TestWaitForHeight(t *testing.T) {
+	t.Parallel()
+
+	spendIndex := NewSpendIndex(nil)
+	m := NewManager(nil, []Indexer{spendIndex}, chaincfg.RegNetParams())
+
+	// Ensure waiting for an index that is not enabled fails.
+	ctx := context.Background()
+	if err := m.WaitForHeight(ctx, txIndexName, 0); err == nil {
+		t.Fatal("did not receive error for index that is not enabled")
+	}
+
+	// Ensure waiting for a height the index has already reached returns
+	// immediately.
+	if err := m.WaitForHeight(ctx, spendIndexName, 0); err != nil {
+		t.Fatalf("unexpected error waiting for height 0: %v", err)
+	}
+
+	// Ensure the index may also be identified by its short name.
+	if err := m.WaitForHeight(ctx, "spendindex", 0); err != nil {
+		t.Fatalf("unexpected error waiting for height 0 by short name: %v",
+			err)
+	}
+
+	// Ensure waiting times out when the index does not reach the height.
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	err := m.WaitForHeight(timeoutCtx, spendIndexName, 2)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("unexpected error waiting for unreached height -- got %v, "+
+			"want %v", err, context.DeadlineExceeded)
+	}
+
+	// Ensure waiting returns once the index reaches the height.
+	errChan := make(chan error)
+	go func() {
+		errChan <- m.WaitForHeight(ctx, spendIndexName, 2)
+	}()
+	tipHash := chainhash.Hash{0x01}
+	m.updateTip(0, &tipHash, 1, false)
+	m.updateTip(0, &tipHash, 2, true)
+	select {
+	case err := <-errChan:
+		if err != nil {
+			t.Fatalf("unexpected error waiting for height 2: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for index to reach height 2")
+	}
+
+	// Ensure the sync state reflects the updated tip and remains synced once
+	// it has been marked synced.
+	m.updateTip(0, &tipHash, 1, false)
+	infos := m.IndexInfo()
+	want := IndexInfo{
+		Name:   spendIndexName,
+		Hash:   tipHash,
+		Height: 1,
+		Synced: true,
+	}
+	if len(infos) != 1 || infos[0] != want {
+		t.Fatalf("unexpected index info -- got %+v, want [%+v]", infos, want)
+	}
+}
+
+// TestReindexErrors ensures rewinding an index with invalid parameters or
+// before the index manager is initialized fails.
+func TestReindexErrors(t *testing.T) {
+	t.Parallel()
+
+	spendIndex := NewSpendIndex(nil)
+	m := NewManager(nil, []Indexer{spendIndex}, chaincfg.RegNetParams())
+
+	ctx := context.Background()
+	tests := []struct {
+		name   string
+		index  string
+		height int64
+	}{{
+		name:   "index that is not enabled",
+		index:  txIndexName,
+		height: 0,
+	}, {
+		name:   "short name of index that is not enabled",
+		index:  "txindex",
+		height: 0,
+	}, {
+		name:   "negative height",
+		index:  spendIndexName,
+		height: -1,
+	}, {
+		name:   "manager not initialized",
+		index:  spendIndexName,
+		height: 0,
+	}}
+	for _, test := range tests {
+		if err := m.Reindex(ctx, test.index, test.height); err == nil {
+			t.Fatalf("%q: did not receive expected error", test.name)
+		}
+	}
+}
+
+// TestReindexDependents ensures rewinding an index also rewinds the indexes
+// that depend on it, either directly or through other indexes, without
+// modifying the other indexes.
+func TestReindexDependents(t *testing.T) {
+	t.Parallel()
+
+	db, teardown := createTestDB(t)
+	defer teardown()
+	chain := newTestChain(t, db, chaincfg.RegNetParams(), 5)
+	base := &testIndexer{key: []byte("base")}
+	dependent := &testIndexer{
+		key:       []byte("dependent"),
+		dependsOn: [][]byte{base.key},
+	}
+	indirect := &testIndexer{
+		key:       []byte("indirect"),
+		dependsOn: [][]byte{dependent.key},
+	}
+	other := &testIndexer{key: []byte("other")}
+	m := newTestManager(t, db, chain, indirect, other, dependent, base)
+	ctx := context.Background()
+	if err := m.syncIndexes(ctx); err != nil {
+		t.Fatalf("unexpected error syncing indexes: %v", err)
+	}
+
+	// Ensure rewinding the dependent index rewinds the index that depends on
+	// it while leaving the index it depends on untouched.
+	if err := m.Reindex(ctx, dependent.Name(), 3); err != nil {
+		t.Fatalf("unexpected error rewinding %s: %v", dependent.Name(), err)
+	}
+	assertTipHeight(t, m, base.Name(), 5)
+	assertTipHeight(t, m, dependent.Name(), 3)
+	assertTipHeight(t, m, indirect.Name(), 3)
+	assertTipHeight(t, m, other.Name(), 5)
+
+	// Ensure rewinding the base index rewinds all of the indexes that depend
+	// on it, including those it is only an indirect dependency of.
+	if err := m.Reindex(ctx, base.Name(), 1); err != nil {
+		t.Fatalf("unexpected error rewinding %s: %v", base.Name(), err)
+	}
+	assertTipHeight(t, m, base.Name(), 1)
+	assertTipHeight(t, m, dependent.Name(), 1)
+	assertTipHeight(t, m, indirect.Name(), 1)
+	assertTipHeight(t, m, other.Name(), 5)
+
+	// Ensure the indexes are caught up again afterwards.
+	if err := m.syncIndexes(ctx); err != nil {
+		t.Fatalf("unexpected error syncing indexes: %v", err)
+	}
+	for _, idx := range []*testIndexer{base, dependent, indirect, other} {
+		assertTipHeight(t, m, idx.Name(), 5)
+	}
+	if dependent.aheadOfDepends || indirect.aheadOfDepends {
+		t.Fatal("dependent index connected ahead of the index it depends on")
+	}
+}
+
+// TestManagerRunRetries ensures the index manager keeps retrying to sync the
+// indexes after failures and never connects blocks to an index before the
+// indexes it depends on have indexed them.
+func TestManagerRunRetries(t *testing.T) {
+	t.Parallel()
+
+	db, teardown := createTestDB(t)
+	defer teardown()
+	chain := newTestChain(t, db, chaincfg.RegNetParams(), 20)
+
+	// Enable the dependent index prior to the index it depends on and cause
+	// the latter to fail several times.
+	base := &testIndexer{key: []byte("base"), failures: 3}
+	dependent := &testIndexer{
+		key:       []byte("dependent"),
+		dependsOn: [][]byte{base.key},
+	}
+	m := newTestManager(t, db, chain, dependent, base)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		m.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer waitCancel()
+	for _, idx := range []*testIndexer{base, dependent} {
+		if err := m.WaitForHeight(waitCtx, idx.Name(), 20); err != nil {
+			t.Fatalf("%s did not reach height 20: %v", idx.Name(), err)
+		}
+	}
+	base.mtx.Lock()
+	failures := base.failures
+	base.mtx.Unlock()
+	if failures != 0 {
+		t.Fatalf("unexpected remaining failures -- got %d, want 0", failures)
+	}
+	dependent.mtx.Lock()
+	aheadOfDepends := dependent.aheadOfDepends
+	dependent.mtx.Unlock()
+	if aheadOfDepends {
+		t.Fatal("dependent index connected ahead of the index it depends on")
+	}
+}
+
+// TestManagerBlockUnavailable ensures the index manager stops syncing the
+// indexes and reports an error to callers waiting for them when the data for a
+// block they need is not available, such as when it was pruned, along with
+// reporting the lowest tip of the indexes that must be retained.
+func TestManagerBlockUnavailable(t *testing.T) {
+	t.Parallel()
+
+	// Create a mock chain with blocks that are stored in a separate database
+	// and only store the first few of them in the database used by the index
+	// manager so the later ones appear to have been pruned.
+	chainDB, chainTeardown := createTestDB(t)
+	defer chainTeardown()
+	chain := newTestChain(t, chainDB, chaincfg.RegNetParams(), 10)
+	db, teardown := createTestDB(t)
+	defer teardown()
+	err := db.Update(func(dbTx database.Tx) error {
+		for _, block := range chain.blocks[:4] {
+			if err := dbTx.StoreBlock(block); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unable to store blocks: %v", err)
+	}
+
+	base := &testIndexer{key: []byte("base")}
+	dependent := &testIndexer{
+		key:       []byte("dependent"),
+		dependsOn: [][]byte{base.key},
+	}
+	m := newTestManager(t, db, chain, base, dependent)
+
+	// Ensure the manager stops syncing on its own once the indexes reach the
+	// first block that is not available.
+	done := make(chan struct{})
+	go func() {
+		m.Run(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("index manager did not stop syncing")
+	}
+	assertTipHeight(t, m, base.Name(), 3)
+	assertTipHeight(t, m, dependent.Name(), 3)
+
+	// Ensure waiting for a height the indexes are unable to reach returns the
+	// error instead of blocking.
+	waitCtx, waitCancel := context.WithTimeout(context.Background(),
+		10*time.Second)
+	defer waitCancel()
+	err = m.WaitForHeight(waitCtx, base.Name(), 10)
+	var unavailableErr blockUnavailableError
+	if !errors.As(err, &unavailableErr) {
+		t.Fatalf("unexpected error waiting for unavailable block -- got "+
+			"%v, want %T", err, unavailableErr)
+	}
+	if unavailableErr.height != 4 {
+		t.Fatalf("unexpected unavailable block height -- got %d, want 4",
+			unavailableErr.height)
+	}
+	if err := m.WaitForHeight(waitCtx, base.Name(), 3); err != nil {
+		t.Fatalf("unexpected error waiting for reached height: %v", err)
+	}
+
+	// Ensure the lowest tip reflects the blocks the indexes still need.
+	var lowestTip int64
+	err = db.View(func(dbTx database.Tx) error {
+		var err error
+		lowestTip, err = m.LowestTipHeight(dbTx)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unable to fetch lowest tip: %v", err)
+	}
+	if lowestTip != 3 {
+		t.Fatalf("unexpected lowest tip height -- got %d, want 3", lowestTip)
+	}
+}
+
+// TestManagerCommittedTip ensures the index manager does not connect blocks
+// while the main chain state differs from the main chain committed to the
+// database and that blocks the chain failed to disconnect do not prevent the
+// indexes from being synced.
+func TestManagerCommittedTip(t *testing.T) {
+	t.Parallel()
+
+	db, teardown := createTestDB(t)
+	defer teardown()
+	chain := newTestChain(t, db, chaincfg.RegNetParams(), 5)
+	idx := &testIndexer{key: []byte("idx")}
+	m := newTestManager(t, db, chain, idx)
+	ctx := context.Background()
+
+	// syncAndAssertTip syncs the indexes and ensures the tip of the index is
+	// at the provided height afterwards.
+	syncAndAssertTip := func(height int64) {
+		t.Helper()
+
+		if err := m.syncIndexes(ctx); err != nil {
+			t.Fatalf("unexpected error syncing indexes: %v", err)
+		}
+		assertTipHeight(t, m, idx.Name(), height)
+	}
+
+	// Ensure nothing is connected while the main chain state has not been
+	// updated with the block the chain committed to the database.
+	chain.setState(4, 5)
+	syncAndAssertTip(0)
+	chain.setState(5, 5)
+	syncAndAssertTip(5)
+
+	// Ensure a block that was disconnected from the database is not
+	// connected again before the main chain state is updated.
+	block, parent := chain.blocks[5], chain.blocks[4]
+	err := db.Update(func(dbTx database.Tx) error {
+		return m.DisconnectBlock(dbTx, block, parent, nil, false)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error disconnecting block: %v", err)
+	}
+	chain.setState(5, 4)
+	syncAndAssertTip(4)
+
+	// Ensure a block the chain failed to disconnect, and therefore remains
+	// in the main chain, is connected.
+	chain.setState(5, 5)
+	errRollback := errors.New("rollback")
+	err = db.Update(func(dbTx database.Tx) error {
+		if err := m.DisconnectBlock(dbTx, block, parent, nil, false); err != nil {
+			return err
+		}
+		return errRollback
+	})
+	if !errors.Is(err, errRollback) {
+		t.Fatalf("unexpected error from rolled back disconnect: %v", err)
+	}
+	syncAndAssertTip(5)
+}
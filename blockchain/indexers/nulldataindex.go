@@ -0,0 +1,361 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+)
+
+const (
+	// nullDataIndexName is the human-readable name for the index.
+	nullDataIndexName = "null data index"
+
+	// nullDataIndexVersion is the current version of the null data index.
+	nullDataIndexVersion = 1
+
+	// nullDataKeyPrefixSize is the maximum number of leading bytes of a
+	// null data payload that are included in the keys of the index.
+	nullDataKeyPrefixSize = 32
+
+	// nullDataKeySize is the size of a null data key.  It consists of 32
+	// bytes payload prefix + 1 byte payload prefix length + 4 bytes block
+	// height + 4 bytes transaction index + 4 bytes output index.
+	nullDataKeySize = nullDataKeyPrefixSize + 1 + 4 + 4 + 4
+
+	// nullDataEntryMinSize is the minimum size of a null data entry.  It
+	// consists of 32 bytes transaction hash followed by the payload.
+	nullDataEntryMinSize = chainhash.HashSize
+)
+
+var (
+	// nullDataIndexKey is the key of the null data index and the db bucket
+	// used to house it.
+	nullDataIndexKey = []byte("nulldataidx")
+)
+
+// -----------------------------------------------------------------------------
+// The null data index consists of an entry for every null data (OP_RETURN)
+// output with a non-empty payload in the regular transaction tree of the
+// blocks in the main chain.  The coinbase is excluded since its null data
+// output is required by consensus.  Likewise, null data outputs in the stake
+// tree are excluded since they are commitments required by consensus as well.
+//
+// The entries are keyed by the leading bytes of the payload followed by the
+// location of the output so they may be searched by a payload prefix.  The
+// payload prefix in the key is padded with zeros when the payload is shorter
+// than the maximum key prefix size, and the length of the prefix is stored in
+// the key to distinguish the padding from the payload.  Heights and indexes in
+// keys are serialized as big endian so entries for the same payload prefix
+// are ordered by their position in the chain.
+//
+// The serialized format for the entries is:
+//
+//   <payload prefix><prefix len><block height><tx index><output index> =
+//     <tx hash><payload>
+//
+//   Field              Type              Size
+//   payload prefix     []byte            32 bytes
+//   prefix len         uint8             1 byte
+//   block height       uint32            4 bytes (big endian)
+//   tx index           uint32            4 bytes (big endian)
+//   output index       uint32            4 bytes (big endian)
+//   -----
+//   tx hash            chainhash.Hash    32 bytes
+//   payload            []byte            variable
+// -----------------------------------------------------------------------------
+
+// NullDataEntry houses information about a null data output in the main chain.
+type NullDataEntry struct {
+	// TxHash is the hash of the transaction that contains the output.
+	TxHash chainhash.Hash
+
+	// BlockHeight is the height of the block that contains the transaction.
+	BlockHeight int64
+
+	// TxIndex and OutputIndex are the index of the transaction within the
+	// regular tree of its block and the index of the output within the
+	// transaction, respectively.
+	TxIndex     uint32
+	OutputIndex uint32
+
+	// Data is the payload of the output.
+	Data []byte
+}
+
+// nullDataIndexKeyForOutput returns the key in the null data index for the
+// output with the provided payload and location.
+func nullDataIndexKeyForOutput(payload []byte, height int64, txIdx, outIdx uint32) [nullDataKeySize]byte {
+	var key [nullDataKeySize]byte
+	prefixLen := copy(key[:nullDataKeyPrefixSize], payload)
+	key[nullDataKeyPrefixSize] = uint8(prefixLen)
+	offset := nullDataKeyPrefixSize + 1
+	binary.BigEndian.PutUint32(key[offset:], uint32(height))
+	binary.BigEndian.PutUint32(key[offset+4:], txIdx)
+	binary.BigEndian.PutUint32(key[offset+8:], outIdx)
+	return key
+}
+
+// serializeNullDataEntry serializes the transaction hash and payload of the
+// provided entry into the format described in detail above.
+func serializeNullDataEntry(entry *NullDataEntry) []byte {
+	serialized := make([]byte, nullDataEntryMinSize+len(entry.Data))
+	offset := copy(serialized, entry.TxHash[:])
+	copy(serialized[offset:], entry.Data)
+	return serialized
+}
+
+// deserializeNullDataEntry decodes the passed serialized byte slice into the
+// transaction hash and payload of the provided entry according to the format
+// described in detail above.
+func deserializeNullDataEntry(serialized []byte, entry *NullDataEntry) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < nullDataEntryMinSize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	offset := copy(entry.TxHash[:], serialized)
+	entry.Data = make([]byte, len(serialized)-offset)
+	copy(entry.Data, serialized[offset:])
+	return nil
+}
+
+// nullDataPayload returns the payload of the passed public key script when it
+// is a null data script.  It returns nil for all other scripts along with null
+// data scripts that do not have a payload or push a small integer.
+func nullDataPayload(scriptVersion uint16, pkScript []byte) []byte {
+	if txscript.GetScriptClass(scriptVersion, pkScript, false) !=
+		txscript.NullDataTy || len(pkScript) < 2 {
+
+		return nil
+	}
+
+	tokenizer := txscript.MakeScriptTokenizer(scriptVersion, pkScript[1:])
+	if !tokenizer.Next() {
+		return nil
+	}
+	return tokenizer.Data()
+}
+
+// forEachNullDataOutput invokes the provided function with the key and entry
+// for every null data output with a non-empty payload in the regular tree of
+// the passed block, excluding the coinbase.
+func forEachNullDataOutput(block *dcrutil.Block, f func(key []byte, entry *NullDataEntry) error) error {
+	height := block.Height()
+	for txIdx, tx := range block.Transactions() {
+		// The null data output of the coinbase is required by consensus.
+		if txIdx == 0 {
+			continue
+		}
+
+		var txHash *chainhash.Hash
+		for outIdx, txOut := range tx.MsgTx().TxOut {
+			payload := nullDataPayload(txOut.Version, txOut.PkScript)
+			if len(payload) == 0 {
+				continue
+			}
+			if txHash == nil {
+				txHash = tx.Hash()
+			}
+
+			entry := NullDataEntry{
+				TxHash:      *txHash,
+				BlockHeight: height,
+				TxIndex:     uint32(txIdx),
+				OutputIndex: uint32(outIdx),
+				Data:        payload,
+			}
+			key := nullDataIndexKeyForOutput(payload, height, uint32(txIdx),
+				uint32(outIdx))
+			if err := f(key[:], &entry); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// NullDataIndex implements a null data index.  That is to say, it supports
+// searching for the null data (OP_RETURN) outputs in the regular transaction
+// tree of the main chain by a prefix of their payloads.  This is useful for
+// locating data that is anchored into the chain by applications.
+type NullDataIndex struct {
+	db database.DB
+}
+
+// Ensure the NullDataIndex type implements the Indexer interface.
+var _ Indexer = (*NullDataIndex)(nil)
+
+// Ensure the NullDataIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*NullDataIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) Key() []byte {
+	return nullDataIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) Name() string {
+	return nullDataIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) Version() uint32 {
+	return nullDataIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the null data
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(nullDataIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every null data
+// output with a non-empty payload in the regular tree of the passed block.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, _ bool) error {
+	bucket := dbTx.Metadata().Bucket(nullDataIndexKey)
+	return forEachNullDataOutput(block, func(key []byte, entry *NullDataEntry) error {
+		return bucket.Put(key, serializeNullDataEntry(entry))
+	})
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entries for the
+// null data outputs in the passed block.
+//
+// This is part of the Indexer interface.
+func (idx *NullDataIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, _ bool) error {
+	bucket := dbTx.Metadata().Bucket(nullDataIndexKey)
+	return forEachNullDataOutput(block, func(key []byte, _ *NullDataEntry) error {
+		return bucket.Delete(key)
+	})
+}
+
+// Search returns the null data outputs in the main chain from the null data
+// index with payloads that start with the provided prefix and that are in
+// blocks with heights in the provided inclusive range.  The results are ordered
+// by the leading bytes of their payloads and then by their position in the
+// chain.  The provided number of matching outputs are skipped and at most the
+// provided count of them are returned.
+//
+// An empty prefix does not match any outputs.
+//
+// This function is safe for concurrent access.
+func (idx *NullDataIndex) Search(prefix []byte, startHeight, endHeight int64, skip, count int) ([]NullDataEntry, error) {
+	if len(prefix) == 0 || startHeight < 0 || endHeight < startHeight ||
+		count <= 0 {
+
+		return nil, nil
+	}
+
+	// Only the leading bytes of the payloads are in the keys, so longer
+	// prefixes are matched against the full payloads in the entries.
+	keyPrefix := prefix
+	if len(keyPrefix) > nullDataKeyPrefixSize {
+		keyPrefix = keyPrefix[:nullDataKeyPrefixSize]
+	}
+
+	var entries []NullDataEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(nullDataIndexKey).Cursor()
+		for ok := cursor.Seek(keyPrefix); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != nullDataKeySize || !bytes.HasPrefix(key, keyPrefix) {
+				break
+			}
+
+			// Skip keys that only match due to the zero padding of payloads
+			// that are shorter than the prefix along with those outside of
+			// the requested range.
+			offset := nullDataKeyPrefixSize
+			if int(key[offset]) < len(keyPrefix) {
+				continue
+			}
+			offset++
+			height := int64(binary.BigEndian.Uint32(key[offset:]))
+			if height < startHeight || height > endHeight {
+				continue
+			}
+
+			entry := NullDataEntry{
+				BlockHeight: height,
+				TxIndex:     binary.BigEndian.Uint32(key[offset+4:]),
+				OutputIndex: binary.BigEndian.Uint32(key[offset+8:]),
+			}
+			err := deserializeNullDataEntry(cursor.Value(), &entry)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt null data index "+
+						"entry for height %d: %v", height, err),
+				}
+			}
+			if !bytes.HasPrefix(entry.Data, prefix) {
+				continue
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+			entries = append(entries, entry)
+			if len(entries) >= count {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// NewNullDataIndex returns a new instance of an indexer that is used to create
+// a mapping from the payloads of the null data outputs in the main chain to
+// their locations.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewNullDataIndex(db database.DB) *NullDataIndex {
+	return &NullDataIndex{db: db}
+}
+
+// DropNullDataIndex drops the null data index from the provided database if it
+// exists.
+func DropNullDataIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, nullDataIndexKey, nullDataIndexName)
+}
+
+// DropIndex drops the null data index from the provided database if it exists.
+func (*NullDataIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropNullDataIndex(ctx, db)
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestNullDataIndexSerialization ensures extracting null data payloads along
+// with serializing and deserializing null data index keys and entries works as
+// expected.
+func TestNullDataIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Ensure payloads are only extracted from null data scripts with data
+	// pushes.
+	payloadTests := []struct {
+		name     string
+		script   string
+		expected string
+	}{{
+		name:     "null data with small push",
+		script:   "6a0401020304",
+		expected: "01020304",
+	}, {
+		name:     "null data with pushdata1",
+		script:   "6a4c50" + strings.Repeat("ab", 80),
+		expected: strings.Repeat("ab", 80),
+	}, {
+		name:     "bare OP_RETURN",
+		script:   "6a",
+		expected: "",
+	}, {
+		name:     "null data with small integer",
+		script:   "6a51",
+		expected: "",
+	}, {
+		name:     "pay-to-pubkey-hash",
+		script:   "76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac",
+		expected: "",
+	}}
+	for _, test := range payloadTests {
+		script, _ := hex.DecodeString(test.script)
+		payload := nullDataPayload(0, script)
+		if hex.EncodeToString(payload) != test.expected {
+			t.Fatalf("%q: unexpected payload -- got %x, want %s", test.name,
+				payload, test.expected)
+		}
+	}
+
+	// Ensure payloads shorter than the key prefix size are padded and record
+	// their length while longer payloads are truncated.
+	shortKey := nullDataIndexKeyForOutput([]byte{0x01, 0x02}, 0x0102, 1, 2)
+	if !bytes.Equal(shortKey[:3], []byte{0x01, 0x02, 0x00}) ||
+		shortKey[nullDataKeyPrefixSize] != 2 {
+
+		t.Fatalf("unexpected short payload key %x", shortKey)
+	}
+	longPayload := bytes.Repeat([]byte{0xff}, nullDataKeyPrefixSize+8)
+	longKey := nullDataIndexKeyForOutput(longPayload, 0x0102, 1, 2)
+	if !bytes.Equal(longKey[:nullDataKeyPrefixSize],
+		longPayload[:nullDataKeyPrefixSize]) ||
+		longKey[nullDataKeyPrefixSize] != nullDataKeyPrefixSize {
+
+		t.Fatalf("unexpected long payload key %x", longKey)
+	}
+
+	// Ensure keys for the same payload are ordered by height, transaction
+	// index, and output index.
+	payload := []byte{0x01, 0x02}
+	key1 := nullDataIndexKeyForOutput(payload, 0x0102, 2, 0x0201)
+	key2 := nullDataIndexKeyForOutput(payload, 0x0201, 1, 0x0201)
+	key3 := nullDataIndexKeyForOutput(payload, 0x0201, 1, 0x0202)
+	key4 := nullDataIndexKeyForOutput(payload, 0x0201, 2, 0x0000)
+	keys := [][]byte{key1[:], key2[:], key3[:], key4[:]}
+	for i := 1; i < len(keys); i++ {
+		if bytes.Compare(keys[i-1], keys[i]) >= 0 {
+			t.Fatalf("keys %d and %d are not ordered: %x >= %x", i-1, i,
+				keys[i-1], keys[i])
+		}
+	}
+
+	// Ensure entries round trip and truncated entries are rejected.  Note
+	// that the location of the output is not part of the serialized entry.
+	entry := NullDataEntry{
+		TxHash: chainhash.Hash{0x01},
+		Data:   longPayload,
+	}
+	serialized := serializeNullDataEntry(&entry)
+	var gotEntry NullDataEntry
+	if err := deserializeNullDataEntry(serialized, &gotEntry); err != nil {
+		t.Fatalf("unexpected error deserializing entry: %v", err)
+	}
+	if !reflect.DeepEqual(gotEntry, entry) {
+		t.Fatalf("mismatched entry -- got %+v, want %+v", gotEntry, entry)
+	}
+	err := deserializeNullDataEntry(serialized[:nullDataEntryMinSize-1],
+		&gotEntry)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated entry -- got %v, want "+
+			"errDeserialize", err)
+	}
+}
+
+// TestNullDataIndexConnectDisconnect ensures connecting and disconnecting
+// blocks to and from the null data index maintains the expected entries and
+// that they are searchable by payload prefix.
+func TestNullDataIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewNullDataIndex(db)
+	})
+	defer teardown()
+	idx := h.idx.(*NullDataIndex)
+
+	// assertSearch ensures searching for the provided prefix within the
+	// given range of heights returns the passed entries.
+	assertSearch := func(stage, prefix string, startHeight, endHeight int64, skip, count int, want ...NullDataEntry) {
+		t.Helper()
+
+		entries, err := idx.Search([]byte(prefix), startHeight, endHeight,
+			skip, count)
+		if err != nil {
+			t.Fatalf("%s: unexpected error searching for %q: %v", stage,
+				prefix, err)
+		}
+		if len(want) == 0 {
+			want = nil
+		}
+		if !reflect.DeepEqual(entries, want) {
+			t.Fatalf("%s: mismatched entries for %q -- got %+v, want %+v",
+				stage, prefix, entries, want)
+		}
+	}
+
+	// entry returns the expected entry for the output at the provided
+	// position with the given payload.
+	entry := func(tx *wire.MsgTx, height int64, txIdx, outIdx uint32, payload string) NullDataEntry {
+		return NullDataEntry{
+			TxHash:      tx.TxHash(),
+			BlockHeight: height,
+			TxIndex:     txIdx,
+			OutputIndex: outIdx,
+			Data:        []byte(payload),
+		}
+	}
+
+	// nullData returns a null data output with the provided payload.
+	nullData := func(payload string) *wire.TxOut {
+		return wire.NewTxOut(0, testNullDataScript(t, []byte(payload)))
+	}
+
+	// Block 1 contains null data outputs in the coinbase and the stake tree,
+	// which must not be indexed, along with regular transactions with null
+	// data outputs that include a payload that is longer than the prefix in
+	// the keys and an output without a payload.
+	const approve = dcrutil.BlockValid
+	longPayload := "hello" + strings.Repeat("z", 40)
+	cb1 := newTestTx(nil, wire.NewTxOut(100, testP2PKHScript(1)),
+		nullData("hello coinbase"))
+	tx1 := newTestTx([]wire.OutPoint{{Hash: chainhash.Hash{1}}},
+		wire.NewTxOut(10, testP2PKHScript(2)), nullData("hello world"),
+		wire.NewTxOut(0, []byte{txscript.OP_RETURN}))
+	tx2 := newTestTx([]wire.OutPoint{{Hash: chainhash.Hash{2}}},
+		nullData("help"), nullData(longPayload))
+	stx := newTestTx([]wire.OutPoint{{Hash: chainhash.Hash{3}}},
+		nullData("hello stake"))
+	block1 := newTestBlock(1, approve, []*wire.MsgTx{cb1, tx1, tx2},
+		[]*wire.MsgTx{stx})
+	h.connect(block1, false)
+	if got := dumpTestIndexBucket(t, h.db, idx); len(got) != 3 {
+		t.Fatalf("block 1: unexpected number of entries -- got %d, want 3",
+			len(got))
+	}
+
+	// Block 2 contains another matching null data output.
+	cb2 := newTestTx(nil, wire.NewTxOut(100, testP2PKHScript(1)))
+	tx3 := newTestTx([]wire.OutPoint{{Hash: chainhash.Hash{4}}},
+		nullData("hello again"))
+	block2 := newTestBlock(2, approve, []*wire.MsgTx{cb2, tx3}, nil)
+	h.connect(block2, false)
+
+	// The results are ordered by the leading bytes of their payloads.
+	helloAgain := entry(tx3, 2, 1, 0, "hello again")
+	helloWorld := entry(tx1, 1, 1, 1, "hello world")
+	helloLong := entry(tx2, 1, 2, 1, longPayload)
+	help := entry(tx2, 1, 2, 0, "help")
+	assertSearch("block 2", "hel", 0, 2, 0, 10, helloAgain, helloWorld,
+		helloLong, help)
+	assertSearch("block 2", "hello", 1, 1, 0, 10, helloWorld, helloLong)
+	assertSearch("block 2", "hel", 0, 2, 1, 2, helloWorld, helloLong)
+	assertSearch("block 2", longPayload[:40], 0, 2, 0, 10, helloLong)
+	assertSearch("block 2", "hello coinbase", 0, 2, 0, 10)
+	assertSearch("block 2", "hello stake", 0, 2, 0, 10)
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnectAll()
+	assertSearch("disconnect block 1", "hel", 0, 2, 0, 10)
+}
@@ -0,0 +1,765 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// scriptHashIndexName is the human-readable name for the index.
+	scriptHashIndexName = "script hash index"
+
+	// scriptHashIndexVersion is the current version of the script hash
+	// index.
+	scriptHashIndexVersion = 1
+
+	// scriptHashHistoryKeyPrefix, scriptHashUtxoKeyPrefix, and
+	// scriptHashUndoKeyPrefix are the prefixes of the history, unspent
+	// output, and undo keys in the script hash index, respectively.
+	scriptHashHistoryKeyPrefix = 'h'
+	scriptHashUtxoKeyPrefix    = 'u'
+	scriptHashUndoKeyPrefix    = 'x'
+
+	// scriptHashHistoryKeySize is the size of a history key.  It consists of
+	// 1 byte prefix + 32 bytes script hash + 4 bytes block height + 1 byte
+	// tree + 4 bytes transaction index.
+	scriptHashHistoryKeySize = 1 + chainhash.HashSize + 4 + 1 + 4
+
+	// scriptHashUtxoKeySize is the size of an unspent output key.  It
+	// consists of 1 byte prefix + 32 bytes script hash + 32 bytes transaction
+	// hash + 4 bytes output index + 1 byte tree.
+	scriptHashUtxoKeySize = 1 + chainhash.HashSize + chainhash.HashSize + 4 + 1
+
+	// scriptHashUtxoEntrySize is the size of an unspent output entry.  It
+	// consists of 8 bytes amount + 4 bytes block height.
+	scriptHashUtxoEntrySize = 8 + 4
+
+	// scriptHashUndoKeySize is the size of an undo key.  It consists of 1
+	// byte prefix + 4 bytes block height.
+	scriptHashUndoKeySize = 1 + 4
+
+	// scriptHashUndoEntrySize is the size of a single spent output in an
+	// undo entry.  It consists of the unspent output key without the prefix
+	// + the unspent output entry + 1 byte spending tree.
+	scriptHashUndoEntrySize = scriptHashUtxoKeySize - 1 +
+		scriptHashUtxoEntrySize + 1
+)
+
+var (
+	// scriptHashIndexKey is the key of the script hash index and the db
+	// bucket used to house it.
+	scriptHashIndexKey = []byte("scripthashidx")
+)
+
+// -----------------------------------------------------------------------------
+// The script hash index maps the hash of every public key script involved in a
+// transaction in the main chain to the transactions that involve it and the
+// unspent outputs that pay to it.  Unlike the address index, it is keyed on the
+// single SHA256 hash of the full public key script, so it covers non-standard
+// and null data scripts along with scripts that map to addresses.  This is the
+// same scheme used by the Electrum protocol.
+//
+// All entries are housed in a single flat bucket and are distinguished by a
+// one byte key prefix.  Heights and transaction indexes in keys are serialized
+// as big endian so the history entries for a script hash are ordered by their
+// position in the chain.
+//
+// The serialized format for the history entries is:
+//
+//   'h'<script hash><block height><tree><tx index> = <tx hash>
+//
+//   Field              Type              Size
+//   script hash        chainhash.Hash    32 bytes
+//   block height       uint32            4 bytes (big endian)
+//   tree               int8              1 byte
+//   tx index           uint32            4 bytes (big endian)
+//   -----
+//   tx hash            chainhash.Hash    32 bytes
+//
+// The serialized format for the unspent output entries is:
+//
+//   'u'<script hash><outpoint> = <amount><block height>
+//
+//   Field              Type              Size
+//   script hash        chainhash.Hash    32 bytes
+//   outpoint hash      chainhash.Hash    32 bytes
+//   outpoint index     uint32            4 bytes
+//   outpoint tree      int8              1 byte
+//   -----
+//   amount             int64             8 bytes
+//   block height       uint32            4 bytes
+//
+// The unspent output entries are removed as the outputs are spent.  In order to
+// support disconnecting blocks along with blocks that disapprove the regular
+// transaction tree of their parent, the outputs spent by each block are stored
+// in an undo entry:
+//
+//   'x'<block height> = [<script hash><outpoint><amount><block height><tree>,...]
+//
+//   Field              Type              Size
+//   block height       uint32            4 bytes (big endian)
+//   -----
+//   script hash        chainhash.Hash    32 bytes
+//   outpoint hash      chainhash.Hash    32 bytes
+//   outpoint index     uint32            4 bytes
+//   outpoint tree      int8              1 byte
+//   amount             int64             8 bytes
+//   block height       uint32            4 bytes
+//   spending tree      int8              1 byte
+//
+// NOTE: The history of a script hash includes transactions in regular trees
+// that were later disapproved since they still exist within the main chain,
+// however, the unspent outputs reflect the effects of the disapproval.
+// -----------------------------------------------------------------------------
+
+// CalcScriptHash returns the SHA256 hash of the provided public key script that
+// is used as the key of the script hash index.  Note that the string form of
+// the returned hash is the byte-reversed hex encoding of the hash, which matches
+// the script hashes used by the Electrum protocol.
+func CalcScriptHash(pkScript []byte) chainhash.Hash {
+	return chainhash.Hash(sha256.Sum256(pkScript))
+}
+
+// ScriptHashHistoryEntry houses information about a transaction in the main
+// chain that involves a script hash.
+type ScriptHashHistoryEntry struct {
+	// TxHash is the hash of the transaction.
+	TxHash chainhash.Hash
+
+	// BlockHeight is the height of the block that contains the transaction.
+	BlockHeight int64
+
+	// Tree and TxIndex identify the position of the transaction within the
+	// block.
+	Tree    int8
+	TxIndex uint32
+}
+
+// scriptHashUtxo houses an unspent output tracked by the script hash index.
+type scriptHashUtxo struct {
+	scriptHash chainhash.Hash
+	outpoint   wire.OutPoint
+	amount     int64
+	height     uint32
+}
+
+// scriptHashIndexKeyForHistory returns the key in the script hash index for
+// the history entry of the provided script hash and transaction position.
+func scriptHashIndexKeyForHistory(scriptHash *chainhash.Hash, height int64, tree int8, txIdx uint32) [scriptHashHistoryKeySize]byte {
+	var key [scriptHashHistoryKeySize]byte
+	key[0] = scriptHashHistoryKeyPrefix
+	offset := 1 + copy(key[1:], scriptHash[:])
+	binary.BigEndian.PutUint32(key[offset:], uint32(height))
+	offset += 4
+	key[offset] = byte(tree)
+	binary.BigEndian.PutUint32(key[offset+1:], txIdx)
+	return key
+}
+
+// scriptHashIndexKeyForUtxo returns the key in the script hash index for the
+// unspent output entry of the provided script hash and outpoint.
+func scriptHashIndexKeyForUtxo(scriptHash *chainhash.Hash, outpoint *wire.OutPoint) [scriptHashUtxoKeySize]byte {
+	var key [scriptHashUtxoKeySize]byte
+	key[0] = scriptHashUtxoKeyPrefix
+	offset := 1 + copy(key[1:], scriptHash[:])
+	offset += copy(key[offset:], outpoint.Hash[:])
+	byteOrder.PutUint32(key[offset:], outpoint.Index)
+	key[scriptHashUtxoKeySize-1] = byte(outpoint.Tree)
+	return key
+}
+
+// scriptHashIndexKeyForUndo returns the key in the script hash index for the
+// undo entry of the block at the provided height.
+func scriptHashIndexKeyForUndo(height int64) [scriptHashUndoKeySize]byte {
+	var key [scriptHashUndoKeySize]byte
+	key[0] = scriptHashUndoKeyPrefix
+	binary.BigEndian.PutUint32(key[1:], uint32(height))
+	return key
+}
+
+// serializeScriptHashUtxoEntry serializes the amount and height of the provided
+// unspent output into the format described in detail above.
+func serializeScriptHashUtxoEntry(utxo *scriptHashUtxo) []byte {
+	serialized := make([]byte, scriptHashUtxoEntrySize)
+	byteOrder.PutUint64(serialized, uint64(utxo.amount))
+	byteOrder.PutUint32(serialized[8:], utxo.height)
+	return serialized
+}
+
+// deserializeScriptHashUtxoEntry decodes the passed serialized byte slice into
+// the amount and height of the provided unspent output according to the format
+// described in detail above.
+func deserializeScriptHashUtxoEntry(serialized []byte, utxo *scriptHashUtxo) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < scriptHashUtxoEntrySize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	utxo.amount = int64(byteOrder.Uint64(serialized))
+	utxo.height = byteOrder.Uint32(serialized[8:])
+	return nil
+}
+
+// putScriptHashUndoEntry appends the serialized provided spent output along
+// with the tree of the spending transaction to the passed buffer.
+func putScriptHashUndoEntry(buf *bytes.Buffer, utxo *scriptHashUtxo, spendingTree int8) {
+	key := scriptHashIndexKeyForUtxo(&utxo.scriptHash, &utxo.outpoint)
+	buf.Write(key[1:])
+	buf.Write(serializeScriptHashUtxoEntry(utxo))
+	buf.WriteByte(byte(spendingTree))
+}
+
+// deserializeScriptHashUndoEntries decodes the passed serialized undo entry
+// into the spent outputs it contains along with the trees of the spending
+// transactions.
+func deserializeScriptHashUndoEntries(serialized []byte) ([]scriptHashUtxo, []int8, error) {
+	if len(serialized)%scriptHashUndoEntrySize != 0 {
+		return nil, nil, errDeserialize("unexpected length for undo entry")
+	}
+
+	numEntries := len(serialized) / scriptHashUndoEntrySize
+	utxos := make([]scriptHashUtxo, numEntries)
+	spendingTrees := make([]int8, numEntries)
+	for i := 0; i < numEntries; i++ {
+		entry := serialized[i*scriptHashUndoEntrySize:]
+		utxo := &utxos[i]
+		offset := copy(utxo.scriptHash[:], entry)
+		offset += copy(utxo.outpoint.Hash[:], entry[offset:])
+		utxo.outpoint.Index = byteOrder.Uint32(entry[offset:])
+		offset += 4
+		utxo.outpoint.Tree = int8(entry[offset])
+		offset++
+		err := deserializeScriptHashUtxoEntry(entry[offset:], utxo)
+		if err != nil {
+			return nil, nil, err
+		}
+		offset += scriptHashUtxoEntrySize
+		spendingTrees[i] = int8(entry[offset])
+	}
+	return utxos, spendingTrees, nil
+}
+
+// dbFetchScriptHashUndoEntries uses an existing database bucket to fetch
+// the outputs spent by the block at the provided height along with the trees
+// of the spending transactions from the script hash index.
+func dbFetchScriptHashUndoEntries(bucket database.Bucket, height int64) ([]scriptHashUtxo, []int8, error) {
+	key := scriptHashIndexKeyForUndo(height)
+	utxos, spendingTrees, err := deserializeScriptHashUndoEntries(
+		bucket.Get(key[:]))
+	if err != nil {
+		return nil, nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt script hash index undo entry "+
+				"for height %d: %v", height, err),
+		}
+	}
+	return utxos, spendingTrees, nil
+}
+
+// dbPutScriptHashUtxo uses an existing database bucket to add an unspent
+// output entry to the script hash index.
+func dbPutScriptHashUtxo(bucket database.Bucket, utxo *scriptHashUtxo) error {
+	key := scriptHashIndexKeyForUtxo(&utxo.scriptHash, &utxo.outpoint)
+	return bucket.Put(key[:], serializeScriptHashUtxoEntry(utxo))
+}
+
+// dbRemoveScriptHashUtxo uses an existing database bucket to remove an unspent
+// output entry from the script hash index.
+func dbRemoveScriptHashUtxo(bucket database.Bucket, utxo *scriptHashUtxo) error {
+	key := scriptHashIndexKeyForUtxo(&utxo.scriptHash, &utxo.outpoint)
+	return bucket.Delete(key[:])
+}
+
+// forEachScriptHashTxUtxo invokes the provided function with every spendable
+// output created by the passed transaction in the given tree of the block at
+// the provided height.
+func forEachScriptHashTxUtxo(tx *dcrutil.Tx, tree int8, height int64, f func(utxo *scriptHashUtxo) error) error {
+	for txOutIdx, txOut := range tx.MsgTx().TxOut {
+		// Provably unspendable outputs are never added to the set of
+		// unspent outputs.
+		if txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+			continue
+		}
+
+		utxo := scriptHashUtxo{
+			scriptHash: CalcScriptHash(txOut.PkScript),
+			outpoint:   wire.OutPoint{Hash: *tx.Hash(), Tree: tree},
+			amount:     txOut.Value,
+			height:     uint32(height),
+		}
+		utxo.outpoint.Index = uint32(txOutIdx)
+		if err := f(&utxo); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forEachScriptHashUtxo invokes the provided function with every spendable
+// output created by the transactions in the given tree of the passed block.
+func forEachScriptHashUtxo(block *dcrutil.Block, tree int8, f func(utxo *scriptHashUtxo) error) error {
+	txns := block.Transactions()
+	if tree == wire.TxTreeStake {
+		txns = block.STransactions()
+	}
+	for _, tx := range txns {
+		if err := forEachScriptHashTxUtxo(tx, tree, block.Height(), f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScriptHashIndex implements a transaction history and unspent output index
+// keyed by the hash of public key scripts.  That is to say, it supports
+// querying all transactions in the main chain that involve a given public key
+// script along with its confirmed balance regardless of whether or not the
+// script maps to an address.
+type ScriptHashIndex struct {
+	db database.DB
+}
+
+// Ensure the ScriptHashIndex type implements the Indexer interface.
+var _ Indexer = (*ScriptHashIndex)(nil)
+
+// Ensure the ScriptHashIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*ScriptHashIndex)(nil)
+
+// Ensure the ScriptHashIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*ScriptHashIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *ScriptHashIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Key() []byte {
+	return scriptHashIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Name() string {
+	return scriptHashIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Version() uint32 {
+	return scriptHashIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the script hash
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(scriptHashIndexKey)
+	return err
+}
+
+// connectTree adds the history entries for the transactions in the given tree
+// of the passed block, removes the unspent outputs they spend while recording
+// them in the provided undo buffer, and adds the unspent outputs they create.
+// The transactions are applied in order so outputs that are both created and
+// spent within the tree are recorded in the undo buffer rather than remaining
+// unspent.
+func (idx *ScriptHashIndex) connectTree(bucket database.Bucket, block *dcrutil.Block, tree int8, prevScripts PrevScripter, undo *bytes.Buffer) error {
+	txns := block.Transactions()
+	if tree == wire.TxTreeStake {
+		txns = block.STransactions()
+	}
+	height := block.Height()
+	for txIdx, tx := range txns {
+		putHistory := func(pkScript []byte) error {
+			scriptHash := CalcScriptHash(pkScript)
+			key := scriptHashIndexKeyForHistory(&scriptHash, height, tree,
+				uint32(txIdx))
+			return bucket.Put(key[:], tx.Hash()[:])
+		}
+
+		for _, txIn := range tx.MsgTx().TxIn {
+			// Inputs that reference the zero hash, such as those of
+			// coinbases, stakebases, treasurybases, and treasury spends, do
+			// not spend a previous output.
+			origin := &txIn.PreviousOutPoint
+			if origin.Hash == (chainhash.Hash{}) {
+				continue
+			}
+
+			// The input should always be available since the index contract
+			// requires it, however, be safe and simply ignore any missing
+			// entries.
+			_, pkScript, ok := prevScripts.PrevScript(origin)
+			if !ok {
+				log.Warnf("Missing input %v:%d for tx %v while indexing "+
+					"block %v (height %v)", origin, origin.Tree, tx.Hash(),
+					block.Hash(), height)
+				continue
+			}
+			if err := putHistory(pkScript); err != nil {
+				return err
+			}
+
+			// Remove the spent output while recording it so it can be
+			// restored.
+			utxo := scriptHashUtxo{
+				scriptHash: CalcScriptHash(pkScript),
+				outpoint:   *origin,
+			}
+			key := scriptHashIndexKeyForUtxo(&utxo.scriptHash, origin)
+			serialized := bucket.Get(key[:])
+			if serialized == nil {
+				continue
+			}
+			err := deserializeScriptHashUtxoEntry(serialized, &utxo)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt script hash index "+
+						"unspent output entry for %v: %v", origin, err),
+				}
+			}
+			putScriptHashUndoEntry(undo, &utxo, tree)
+			if err := bucket.Delete(key[:]); err != nil {
+				return err
+			}
+		}
+
+		for _, txOut := range tx.MsgTx().TxOut {
+			if err := putHistory(txOut.PkScript); err != nil {
+				return err
+			}
+		}
+		err := forEachScriptHashTxUtxo(tx, tree, height,
+			func(utxo *scriptHashUtxo) error {
+				return dbPutScriptHashUtxo(bucket, utxo)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// disconnectDisapprovedTree reverses the effects of the regular transaction
+// tree of the passed block on the unspent outputs.
+func (idx *ScriptHashIndex) disconnectDisapprovedTree(bucket database.Bucket, block *dcrutil.Block) error {
+	// Restore the outputs spent by the regular tree prior to removing the
+	// outputs it created so outputs that are both created and spent within
+	// the tree are not restored.
+	utxos, spendingTrees, err := dbFetchScriptHashUndoEntries(bucket,
+		block.Height())
+	if err != nil {
+		return err
+	}
+	for i := range utxos {
+		if spendingTrees[i] != wire.TxTreeRegular {
+			continue
+		}
+		if err := dbPutScriptHashUtxo(bucket, &utxos[i]); err != nil {
+			return err
+		}
+	}
+	return forEachScriptHashUtxo(block, wire.TxTreeRegular,
+		func(utxo *scriptHashUtxo) error {
+			return dbRemoveScriptHashUtxo(bucket, utxo)
+		})
+}
+
+// reconnectDisapprovedTree reapplies the effects of the regular transaction
+// tree of the passed block on the unspent outputs after they were reversed by
+// disconnectDisapprovedTree.
+func (idx *ScriptHashIndex) reconnectDisapprovedTree(bucket database.Bucket, block *dcrutil.Block) error {
+	// Add the outputs created by the regular tree prior to removing the
+	// outputs it spent so outputs that are both created and spent within the
+	// tree are not added.
+	err := forEachScriptHashUtxo(block, wire.TxTreeRegular,
+		func(utxo *scriptHashUtxo) error {
+			return dbPutScriptHashUtxo(bucket, utxo)
+		})
+	if err != nil {
+		return err
+	}
+	utxos, spendingTrees, err := dbFetchScriptHashUndoEntries(bucket,
+		block.Height())
+	if err != nil {
+		return err
+	}
+	for i := range utxos {
+		if spendingTrees[i] != wire.TxTreeRegular {
+			continue
+		}
+		if err := dbRemoveScriptHashUtxo(bucket, &utxos[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds a history entry for every
+// public key script involved in the transactions in the block and updates the
+// unspent outputs accordingly.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, _ bool) error {
+	bucket := dbTx.Metadata().Bucket(scriptHashIndexKey)
+
+	// Reverse the effects of the regular tree of the parent on the unspent
+	// outputs when the block disapproves it.
+	header := &block.MsgBlock().Header
+	if parent != nil && !dcrutil.IsFlagSet16(header.VoteBits, dcrutil.BlockValid) {
+		if err := idx.disconnectDisapprovedTree(bucket, parent); err != nil {
+			return err
+		}
+	}
+
+	// Connect the stake tree prior to the regular tree to match the order
+	// the transactions are applied in by consensus.
+	var undo bytes.Buffer
+	err := idx.connectTree(bucket, block, wire.TxTreeStake, prevScripts, &undo)
+	if err != nil {
+		return err
+	}
+	err = idx.connectTree(bucket, block, wire.TxTreeRegular, prevScripts, &undo)
+	if err != nil {
+		return err
+	}
+
+	key := scriptHashIndexKeyForUndo(block.Height())
+	if undo.Len() == 0 {
+		return bucket.Delete(key[:])
+	}
+	return bucket.Put(key[:], undo.Bytes())
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the history entries
+// for the transactions in the block and reverses its effects on the unspent
+// outputs.
+//
+// This is part of the Indexer interface.
+func (idx *ScriptHashIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, prevScripts PrevScripter, _ bool) error {
+	bucket := dbTx.Metadata().Bucket(scriptHashIndexKey)
+	height := block.Height()
+
+	// Remove the history entries for all transactions in the block.
+	for _, tree := range []int8{wire.TxTreeRegular, wire.TxTreeStake} {
+		txns := block.Transactions()
+		if tree == wire.TxTreeStake {
+			txns = block.STransactions()
+		}
+		for txIdx, tx := range txns {
+			removeHistory := func(pkScript []byte) error {
+				scriptHash := CalcScriptHash(pkScript)
+				key := scriptHashIndexKeyForHistory(&scriptHash, height,
+					tree, uint32(txIdx))
+				return bucket.Delete(key[:])
+			}
+			for _, txIn := range tx.MsgTx().TxIn {
+				origin := &txIn.PreviousOutPoint
+				if origin.Hash == (chainhash.Hash{}) {
+					continue
+				}
+				_, pkScript, ok := prevScripts.PrevScript(origin)
+				if !ok {
+					log.Warnf("Missing input %v:%d for tx %v while "+
+						"unindexing block %v (height %v)", origin,
+						origin.Tree, tx.Hash(), block.Hash(), height)
+					continue
+				}
+				if err := removeHistory(pkScript); err != nil {
+					return err
+				}
+			}
+			for _, txOut := range tx.MsgTx().TxOut {
+				if err := removeHistory(txOut.PkScript); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	// Restore the outputs spent by the block prior to removing the outputs
+	// it created so outputs that are both created and spent within the block
+	// are not restored.
+	utxos, _, err := dbFetchScriptHashUndoEntries(bucket, height)
+	if err != nil {
+		return err
+	}
+	for i := range utxos {
+		if err := dbPutScriptHashUtxo(bucket, &utxos[i]); err != nil {
+			return err
+		}
+	}
+	for _, tree := range []int8{wire.TxTreeRegular, wire.TxTreeStake} {
+		err := forEachScriptHashUtxo(block, tree,
+			func(utxo *scriptHashUtxo) error {
+				return dbRemoveScriptHashUtxo(bucket, utxo)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	key := scriptHashIndexKeyForUndo(height)
+	if err := bucket.Delete(key[:]); err != nil {
+		return err
+	}
+
+	// Reapply the effects of the regular tree of the parent on the unspent
+	// outputs when the block disapproved it.
+	header := &block.MsgBlock().Header
+	if parent != nil && !dcrutil.IsFlagSet16(header.VoteBits, dcrutil.BlockValid) {
+		return idx.reconnectDisapprovedTree(bucket, parent)
+	}
+	return nil
+}
+
+// History returns the transactions in the main chain that involve the provided
+// script hash from the script hash index ordered by their position in the
+// chain.  The provided number of transactions are skipped and at most the
+// provided count of them are returned.
+//
+// This function is safe for concurrent access.
+func (idx *ScriptHashIndex) History(scriptHash *chainhash.Hash, skip, count int) ([]ScriptHashHistoryEntry, error) {
+	if count <= 0 {
+		return nil, nil
+	}
+
+	var prefix [1 + chainhash.HashSize]byte
+	prefix[0] = scriptHashHistoryKeyPrefix
+	copy(prefix[1:], scriptHash[:])
+
+	var entries []ScriptHashHistoryEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(scriptHashIndexKey).Cursor()
+		for ok := cursor.Seek(prefix[:]); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != scriptHashHistoryKeySize ||
+				!bytes.HasPrefix(key, prefix[:]) {
+
+				break
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+
+			value := cursor.Value()
+			if len(value) < chainhash.HashSize {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt script hash index "+
+						"history entry for %v", scriptHash),
+				}
+			}
+			var entry ScriptHashHistoryEntry
+			copy(entry.TxHash[:], value)
+			offset := len(prefix)
+			entry.BlockHeight = int64(binary.BigEndian.Uint32(key[offset:]))
+			entry.Tree = int8(key[offset+4])
+			entry.TxIndex = binary.BigEndian.Uint32(key[offset+5:])
+			entries = append(entries, entry)
+			if len(entries) >= count {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// Balance returns the total amount in atoms of the unspent outputs in the main
+// chain that pay to the provided script hash along with the number of them
+// from the script hash index.
+//
+// This function is safe for concurrent access.
+func (idx *ScriptHashIndex) Balance(scriptHash *chainhash.Hash) (int64, uint32, error) {
+	var prefix [1 + chainhash.HashSize]byte
+	prefix[0] = scriptHashUtxoKeyPrefix
+	copy(prefix[1:], scriptHash[:])
+
+	var balance int64
+	var numUtxos uint32
+	err := idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(scriptHashIndexKey).Cursor()
+		for ok := cursor.Seek(prefix[:]); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != scriptHashUtxoKeySize ||
+				!bytes.HasPrefix(key, prefix[:]) {
+
+				break
+			}
+
+			var utxo scriptHashUtxo
+			err := deserializeScriptHashUtxoEntry(cursor.Value(), &utxo)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt script hash index "+
+						"unspent output entry for %v: %v", scriptHash, err),
+				}
+			}
+			balance += utxo.amount
+			numUtxos++
+		}
+		return nil
+	})
+	return balance, numUtxos, err
+}
+
+// NewScriptHashIndex returns a new instance of an indexer that is used to
+// create a mapping of the hashes of all public key scripts involved in
+// transactions in the blockchain to the transactions that involve them and the
+// unspent outputs that pay to them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewScriptHashIndex(db database.DB) *ScriptHashIndex {
+	return &ScriptHashIndex{db: db}
+}
+
+// DropScriptHashIndex drops the script hash index from the provided database
+// if it exists.
+func DropScriptHashIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, scriptHashIndexKey, scriptHashIndexName)
+}
+
+// DropIndex drops the script hash index from the provided database if it
+// exists.
+func (*ScriptHashIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropScriptHashIndex(ctx, db)
+}
@@ -0,0 +1,234 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestScriptHashIndexSerialization ensures calculating script hashes along
+// with serializing and deserializing script hash index keys and entries works
+// as expected.
+func TestScriptHashIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Ensure the script hash matches the form used by the Electrum protocol.
+	pkScript, _ := hex.DecodeString("76a91462e907b15cbf27d5425399ebf6f0fb50eb" +
+		"b88f1888ac")
+	scriptHash := CalcScriptHash(pkScript)
+	const wantScriptHash = "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a" +
+		"0cfbf90b5c39161"
+	if scriptHash.String() != wantScriptHash {
+		t.Fatalf("unexpected script hash -- got %v, want %v", scriptHash,
+			wantScriptHash)
+	}
+
+	// Ensure history keys are ordered by height, tree, and index.
+	key1 := scriptHashIndexKeyForHistory(&scriptHash, 0x0102, 1, 0x0201)
+	key2 := scriptHashIndexKeyForHistory(&scriptHash, 0x0201, 0, 0x0201)
+	key3 := scriptHashIndexKeyForHistory(&scriptHash, 0x0201, 0, 0x0202)
+	key4 := scriptHashIndexKeyForHistory(&scriptHash, 0x0201, 1, 0x0000)
+	keys := [][]byte{key1[:], key2[:], key3[:], key4[:]}
+	for i := 1; i < len(keys); i++ {
+		if bytes.Compare(keys[i-1], keys[i]) >= 0 {
+			t.Fatalf("history keys %d and %d are not ordered: %x >= %x", i-1,
+				i, keys[i-1], keys[i])
+		}
+	}
+
+	// Ensure undo entries round trip and entries with an invalid length are
+	// rejected.
+	utxos := []scriptHashUtxo{{
+		scriptHash: scriptHash,
+		outpoint:   wire.OutPoint{Hash: chainhash.Hash{0x01}, Index: 2, Tree: 1},
+		amount:     1234567890,
+		height:     123456,
+	}, {
+		scriptHash: chainhash.Hash{0x02},
+		outpoint:   wire.OutPoint{Hash: chainhash.Hash{0x03}, Index: 0},
+		amount:     1,
+		height:     1,
+	}}
+	spendingTrees := []int8{wire.TxTreeStake, wire.TxTreeRegular}
+	var buf bytes.Buffer
+	for i := range utxos {
+		putScriptHashUndoEntry(&buf, &utxos[i], spendingTrees[i])
+	}
+	serialized := buf.Bytes()
+	if len(serialized) != len(utxos)*scriptHashUndoEntrySize {
+		t.Fatalf("unexpected serialized undo size -- got %d, want %d",
+			len(serialized), len(utxos)*scriptHashUndoEntrySize)
+	}
+	gotUtxos, gotSpendingTrees, err := deserializeScriptHashUndoEntries(
+		serialized)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing undo entries: %v", err)
+	}
+	if !reflect.DeepEqual(gotUtxos, utxos) {
+		t.Fatalf("mismatched undo utxos -- got %+v, want %+v", gotUtxos,
+			utxos)
+	}
+	if !reflect.DeepEqual(gotSpendingTrees, spendingTrees) {
+		t.Fatalf("mismatched undo spending trees -- got %v, want %v",
+			gotSpendingTrees, spendingTrees)
+	}
+	_, _, err = deserializeScriptHashUndoEntries(serialized[:len(serialized)-1])
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated undo entries -- got %v, "+
+			"want errDeserialize", err)
+	}
+
+	// Ensure unspent output entries round trip and truncated entries are
+	// rejected.
+	serialized = serializeScriptHashUtxoEntry(&utxos[0])
+	var gotUtxo scriptHashUtxo
+	err = deserializeScriptHashUtxoEntry(serialized, &gotUtxo)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing utxo entry: %v", err)
+	}
+	if gotUtxo.amount != utxos[0].amount || gotUtxo.height != utxos[0].height {
+		t.Fatalf("mismatched utxo entry -- got %+v, want %+v", gotUtxo,
+			utxos[0])
+	}
+	err = deserializeScriptHashUtxoEntry(serialized[:len(serialized)-1],
+		&gotUtxo)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated utxo entry -- got %v, want "+
+			"errDeserialize", err)
+	}
+}
+
+// TestScriptHashIndexConnectDisconnect ensures connecting and disconnecting
+// blocks to and from the script hash index maintains the expected history and
+// unspent outputs, including for outputs that are created and spent in the
+// same block and when a block disapproves the regular transaction tree of its
+// parent.
+func TestScriptHashIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewScriptHashIndex(db)
+	})
+	defer teardown()
+	idx := h.idx.(*ScriptHashIndex)
+
+	// assertBalance ensures the balance and number of unspent outputs of the
+	// provided script are the given values.
+	assertBalance := func(stage string, pkScript []byte, wantBalance int64, wantUtxos uint32) {
+		t.Helper()
+
+		scriptHash := CalcScriptHash(pkScript)
+		balance, numUtxos, err := idx.Balance(&scriptHash)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching balance: %v", stage, err)
+		}
+		if balance != wantBalance || numUtxos != wantUtxos {
+			t.Fatalf("%s: mismatched balance for %x -- got %d (%d utxos), "+
+				"want %d (%d utxos)", stage, pkScript, balance, numUtxos,
+				wantBalance, wantUtxos)
+		}
+	}
+
+	// assertHistory ensures the history of the provided script consists of
+	// the given entries.
+	assertHistory := func(stage string, pkScript []byte, want ...ScriptHashHistoryEntry) {
+		t.Helper()
+
+		scriptHash := CalcScriptHash(pkScript)
+		entries, err := idx.History(&scriptHash, 0, 100)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching history: %v", stage, err)
+		}
+		if len(want) == 0 {
+			want = nil
+		}
+		if !reflect.DeepEqual(entries, want) {
+			t.Fatalf("%s: mismatched history for %x -- got %+v, want %+v",
+				stage, pkScript, entries, want)
+		}
+	}
+
+	// historyEntry returns the expected history entry for the provided
+	// transaction at the given position.
+	historyEntry := func(tx *wire.MsgTx, height int64, tree int8, txIdx uint32) ScriptHashHistoryEntry {
+		return ScriptHashHistoryEntry{
+			TxHash:      tx.TxHash(),
+			BlockHeight: height,
+			Tree:        tree,
+			TxIndex:     txIdx,
+		}
+	}
+
+	const approve, disapprove = dcrutil.BlockValid, 0
+	const regular, stake = wire.TxTreeRegular, wire.TxTreeStake
+	script1, script2, script3 := testP2PKHScript(1), testP2PKHScript(2),
+		testP2PKHScript(3)
+	script4, script5, script6 := testP2PKHScript(4), testP2PKHScript(5),
+		testP2PKHScript(6)
+
+	// Block 1 creates some outputs to spend along with a null data output
+	// which is part of the history but never unspent.
+	nullData := testNullDataScript(t, []byte{0x01})
+	cb1 := newTestTx(nil, wire.NewTxOut(10, script1),
+		wire.NewTxOut(20, script2), wire.NewTxOut(0, nullData))
+	block1 := newTestBlock(1, approve, []*wire.MsgTx{cb1}, nil)
+	h.connect(block1, false)
+	assertBalance("block 1", script1, 10, 1)
+	assertBalance("block 1", script2, 20, 1)
+	assertBalance("block 1", nullData, 0, 0)
+	assertHistory("block 1", nullData, historyEntry(cb1, 1, regular, 0))
+
+	// Block 2 spends the first output of block 1 in the regular tree with a
+	// transaction whose output is spent again in the same block and the
+	// second output of block 1 in the stake tree.
+	cb2 := newTestTx(nil, wire.NewTxOut(1, script3))
+	tx1 := newTestTx([]wire.OutPoint{testOutPoint(cb1, 0, regular)},
+		wire.NewTxOut(9, script4))
+	tx2 := newTestTx([]wire.OutPoint{testOutPoint(tx1, 0, regular)},
+		wire.NewTxOut(8, script1))
+	stx := newTestTx([]wire.OutPoint{testOutPoint(cb1, 1, regular)},
+		wire.NewTxOut(19, script5))
+	block2 := newTestBlock(2, approve, []*wire.MsgTx{cb2, tx1, tx2},
+		[]*wire.MsgTx{stx})
+	h.connect(block2, false)
+	assertBalance("block 2", script1, 8, 1)
+	assertBalance("block 2", script2, 0, 0)
+	assertBalance("block 2", script3, 1, 1)
+	assertBalance("block 2", script4, 0, 0)
+	assertBalance("block 2", script5, 19, 1)
+	assertHistory("block 2", script1, historyEntry(cb1, 1, regular, 0),
+		historyEntry(tx1, 2, regular, 1), historyEntry(tx2, 2, regular, 2))
+	assertHistory("block 2", script4, historyEntry(tx1, 2, regular, 1),
+		historyEntry(tx2, 2, regular, 2))
+	assertHistory("block 2", script2, historyEntry(cb1, 1, regular, 0),
+		historyEntry(stx, 2, stake, 0))
+
+	// Block 3 disapproves the regular tree of block 2 and mines the first
+	// transaction of it again.  The history of the disapproved transactions
+	// remains while their effects on the unspent outputs are reversed.  The
+	// stake tree of block 2 is not affected.
+	cb3 := newTestTx(nil, wire.NewTxOut(2, script6))
+	block3 := newTestBlock(3, disapprove, []*wire.MsgTx{cb3, tx1}, nil)
+	h.connect(block3, false)
+	assertBalance("block 3", script1, 0, 0)
+	assertBalance("block 3", script2, 0, 0)
+	assertBalance("block 3", script3, 0, 0)
+	assertBalance("block 3", script4, 9, 1)
+	assertBalance("block 3", script5, 19, 1)
+	assertBalance("block 3", script6, 2, 1)
+	assertHistory("block 3", script4, historyEntry(tx1, 2, regular, 1),
+		historyEntry(tx2, 2, regular, 2), historyEntry(tx1, 3, regular, 1))
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnectAll()
+}
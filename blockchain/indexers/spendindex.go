@@ -0,0 +1,340 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// spendIndexName is the human-readable name for the index.
+	spendIndexName = "spend index"
+
+	// spendIndexVersion is the current version of the spend index.
+	spendIndexVersion = 1
+
+	// spendKeySize is the size of a spend index key.  It consists of 32
+	// bytes transaction hash + 4 bytes output index + 1 byte tree.
+	spendKeySize = chainhash.HashSize + 4 + 1
+
+	// spendEntrySize is the size of a spend index entry.  It consists of 32
+	// bytes spending transaction hash + 4 bytes input index + 32 bytes block
+	// hash + 4 bytes block height.
+	spendEntrySize = chainhash.HashSize + 4 + chainhash.HashSize + 4
+)
+
+var (
+	// spendIndexKey is the key of the spend index and the db bucket used
+	// to house it.
+	spendIndexKey = []byte("spendbyoutpointidx")
+)
+
+// -----------------------------------------------------------------------------
+// The spend index consists of an entry for every previous transaction output
+// spent by a transaction in the main chain.  It maps each outpoint to the
+// transaction that spends it along with the block that contains the spending
+// transaction.
+//
+// NOTE: Inputs that do not spend a previous output, such as those of the
+// coinbase, stakebases, treasurybases, and treasury spends, are not indexed.
+//
+// The serialized format for keys and values in the spend index bucket is:
+//   <outpoint> = <spending tx hash><input index><block hash><block height>
+//
+//   Field              Type              Size
+//   outpoint hash      chainhash.Hash    32 bytes
+//   outpoint index     uint32            4 bytes
+//   outpoint tree      int8              1 byte
+//   -----
+//   Total: 37 bytes
+//
+//   Field              Type              Size
+//   spending tx hash   chainhash.Hash    32 bytes
+//   input index        uint32            4 bytes
+//   block hash         chainhash.Hash    32 bytes
+//   block height       uint32            4 bytes
+//   -----
+//   Total: 72 bytes
+// -----------------------------------------------------------------------------
+
+// SpendIndexEntry houses information about the transaction that spends a
+// previous transaction output in the main chain.
+type SpendIndexEntry struct {
+	// SpendingTx is the hash of the transaction that spends the output.
+	SpendingTx chainhash.Hash
+
+	// InputIndex is the index of the spending transaction input.
+	InputIndex uint32
+
+	// BlockHash and BlockHeight identify the block that contains the
+	// spending transaction.
+	BlockHash   chainhash.Hash
+	BlockHeight int64
+}
+
+// spendIndexKeyForOutPoint returns the key in the spend index for the provided
+// outpoint.
+func spendIndexKeyForOutPoint(outpoint *wire.OutPoint) [spendKeySize]byte {
+	var key [spendKeySize]byte
+	copy(key[:], outpoint.Hash[:])
+	byteOrder.PutUint32(key[chainhash.HashSize:], outpoint.Index)
+	key[spendKeySize-1] = byte(outpoint.Tree)
+	return key
+}
+
+// serializeSpendIndexEntry serializes the provided spend index entry into the
+// format described in detail above.
+func serializeSpendIndexEntry(entry *SpendIndexEntry) []byte {
+	serialized := make([]byte, spendEntrySize)
+	offset := copy(serialized, entry.SpendingTx[:])
+	byteOrder.PutUint32(serialized[offset:], entry.InputIndex)
+	offset += 4
+	offset += copy(serialized[offset:], entry.BlockHash[:])
+	byteOrder.PutUint32(serialized[offset:], uint32(entry.BlockHeight))
+	return serialized
+}
+
+// deserializeSpendIndexEntry decodes the passed serialized byte slice into the
+// provided spend index entry according to the format described in detail
+// above.
+func deserializeSpendIndexEntry(serialized []byte, entry *SpendIndexEntry) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < spendEntrySize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	offset := copy(entry.SpendingTx[:], serialized)
+	entry.InputIndex = byteOrder.Uint32(serialized[offset:])
+	offset += 4
+	offset += copy(entry.BlockHash[:], serialized[offset:])
+	entry.BlockHeight = int64(byteOrder.Uint32(serialized[offset:]))
+	return nil
+}
+
+// forEachSpendingInput invokes the provided function with every transaction
+// input in the passed transactions that spends a previous transaction output
+// along with the transaction that contains it and its index.
+func forEachSpendingInput(txns []*wire.MsgTx, f func(tx *wire.MsgTx, txInIdx int) error) error {
+	for _, tx := range txns {
+		for txInIdx, txIn := range tx.TxIn {
+			// Inputs that reference the zero hash, such as those of
+			// coinbases, stakebases, treasurybases, and treasury spends,
+			// do not spend a previous output.
+			if txIn.PreviousOutPoint.Hash == (chainhash.Hash{}) {
+				continue
+			}
+			if err := f(tx, txInIdx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// dbAddSpendIndexEntries uses an existing database transaction to add a spend
+// index entry for every previous transaction output spent by the provided
+// transactions of the passed block.
+func dbAddSpendIndexEntries(dbTx database.Tx, block *dcrutil.Block, txns []*wire.MsgTx) error {
+	spendIndex := dbTx.Metadata().Bucket(spendIndexKey)
+	blockHash := block.Hash()
+	blockHeight := block.Height()
+	return forEachSpendingInput(txns, func(tx *wire.MsgTx, txInIdx int) error {
+		key := spendIndexKeyForOutPoint(&tx.TxIn[txInIdx].PreviousOutPoint)
+		entry := SpendIndexEntry{
+			SpendingTx:  tx.TxHash(),
+			InputIndex:  uint32(txInIdx),
+			BlockHash:   *blockHash,
+			BlockHeight: blockHeight,
+		}
+		return spendIndex.Put(key[:], serializeSpendIndexEntry(&entry))
+	})
+}
+
+// dbRemoveSpendIndexEntries uses an existing database transaction to remove the
+// spend index entry for every previous transaction output spent by the provided
+// transactions.
+func dbRemoveSpendIndexEntries(dbTx database.Tx, txns []*wire.MsgTx) error {
+	spendIndex := dbTx.Metadata().Bucket(spendIndexKey)
+	return forEachSpendingInput(txns, func(tx *wire.MsgTx, txInIdx int) error {
+		key := spendIndexKeyForOutPoint(&tx.TxIn[txInIdx].PreviousOutPoint)
+		return spendIndex.Delete(key[:])
+	})
+}
+
+// dbFetchSpendIndexEntry uses an existing database transaction to fetch the
+// spend index entry for the provided outpoint.  When there is no entry for the
+// provided outpoint, nil will be returned for the both the entry and the error.
+func dbFetchSpendIndexEntry(dbTx database.Tx, outpoint *wire.OutPoint) (*SpendIndexEntry, error) {
+	key := spendIndexKeyForOutPoint(outpoint)
+	serialized := dbTx.Metadata().Bucket(spendIndexKey).Get(key[:])
+	if len(serialized) == 0 {
+		return nil, nil
+	}
+
+	var entry SpendIndexEntry
+	if err := deserializeSpendIndexEntry(serialized, &entry); err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt spend index entry for "+
+				"%v: %v", outpoint, err),
+		}
+	}
+	return &entry, nil
+}
+
+// SpendIndex implements an outpoint-based spend index.  That is to say, it
+// supports querying the transaction that spends a given previous transaction
+// output in the main chain.
+type SpendIndex struct {
+	db database.DB
+}
+
+// Ensure the SpendIndex type implements the Indexer interface.
+var _ Indexer = (*SpendIndex)(nil)
+
+// Ensure the SpendIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*SpendIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Key() []byte {
+	return spendIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Name() string {
+	return spendIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Version() uint32 {
+	return spendIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the spend
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(spendIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an outpoint-to-spender
+// mapping for every previous output spent by the passed block and removes the
+// mappings for the outputs spent by the regular transaction tree of the parent
+// when the block disapproves it since those outputs are no longer spent.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	// Remove the entries for the regular tree of the parent when the block
+	// disapproves it.  This must be done prior to adding the entries for
+	// the block since it commonly mines the disapproved transactions again.
+	header := &block.MsgBlock().Header
+	if parent != nil && !dcrutil.IsFlagSet16(header.VoteBits, dcrutil.BlockValid) {
+		err := dbRemoveSpendIndexEntries(dbTx, parent.MsgBlock().Transactions)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Add the entries for the stake tree prior to the regular tree to match
+	// the order the transactions are applied in by consensus.
+	msgBlock := block.MsgBlock()
+	err := dbAddSpendIndexEntries(dbTx, block, msgBlock.STransactions)
+	if err != nil {
+		return err
+	}
+	return dbAddSpendIndexEntries(dbTx, block, msgBlock.Transactions)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the
+// outpoint-to-spender mapping for every previous output spent by the block and
+// restores the mappings for the regular transaction tree of the parent when the
+// block disapproves it.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	msgBlock := block.MsgBlock()
+	err := dbRemoveSpendIndexEntries(dbTx, msgBlock.Transactions)
+	if err != nil {
+		return err
+	}
+	err = dbRemoveSpendIndexEntries(dbTx, msgBlock.STransactions)
+	if err != nil {
+		return err
+	}
+
+	// Restore the entries for the regular tree of the parent when the block
+	// disapproves it.  This must be done after removing the entries for the
+	// block since it commonly mined the disapproved transactions again.
+	header := &msgBlock.Header
+	if parent != nil && !dcrutil.IsFlagSet16(header.VoteBits, dcrutil.BlockValid) {
+		return dbAddSpendIndexEntries(dbTx, parent,
+			parent.MsgBlock().Transactions)
+	}
+	return nil
+}
+
+// Entry returns details about the transaction in the main chain that spends
+// the provided outpoint from the spend index.  When there is no entry for the
+// provided outpoint, nil will be returned for the both the entry and the
+// error.
+//
+// This function is safe for concurrent access.
+func (idx *SpendIndex) Entry(outpoint *wire.OutPoint) (*SpendIndexEntry, error) {
+	var entry *SpendIndexEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		entry, err = dbFetchSpendIndexEntry(dbTx, outpoint)
+		return err
+	})
+	return entry, err
+}
+
+// NewSpendIndex returns a new instance of an indexer that is used to create a
+// mapping of every previous transaction output spent in the blockchain to the
+// transaction that spends it and the block that contains that transaction.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewSpendIndex(db database.DB) *SpendIndex {
+	return &SpendIndex{db: db}
+}
+
+// DropSpendIndex drops the spend index from the provided database if it
+// exists.
+func DropSpendIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, spendIndexKey, spendIndexName)
+}
+
+// DropIndex drops the spend index from the provided database if it exists.
+func (*SpendIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropSpendIndex(ctx, db)
+}
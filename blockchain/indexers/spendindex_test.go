@@ -0,0 +1,155 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestSpendIndexSerialization ensures serializing and deserializing spend index
+// keys and entries works as expected.
+func TestSpendIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Ensure the key includes all of the outpoint fields.
+	outpoint := wire.OutPoint{
+		Hash:  chainhash.Hash{0x01, 0x02},
+		Index: 0x03040506,
+		Tree:  wire.TxTreeStake,
+	}
+	wantKey := make([]byte, spendKeySize)
+	copy(wantKey, outpoint.Hash[:])
+	copy(wantKey[chainhash.HashSize:], []byte{0x06, 0x05, 0x04, 0x03, 0x01})
+	key := spendIndexKeyForOutPoint(&outpoint)
+	if !bytes.Equal(key[:], wantKey) {
+		t.Fatalf("mismatched key -- got %x, want %x", key, wantKey)
+	}
+
+	// Ensure entries round trip.
+	entry := SpendIndexEntry{
+		SpendingTx:  chainhash.Hash{0x07},
+		InputIndex:  2,
+		BlockHash:   chainhash.Hash{0x08},
+		BlockHeight: 123456,
+	}
+	serialized := serializeSpendIndexEntry(&entry)
+	if len(serialized) != spendEntrySize {
+		t.Fatalf("unexpected serialized size -- got %d, want %d",
+			len(serialized), spendEntrySize)
+	}
+	var gotEntry SpendIndexEntry
+	if err := deserializeSpendIndexEntry(serialized, &gotEntry); err != nil {
+		t.Fatalf("unexpected error deserializing entry: %v", err)
+	}
+	if gotEntry != entry {
+		t.Fatalf("mismatched entry -- got %+v, want %+v", gotEntry, entry)
+	}
+
+	// Ensure truncated entries are rejected.
+	err := deserializeSpendIndexEntry(serialized[:spendEntrySize-1], &gotEntry)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated entry -- got %v, want "+
+			"errDeserialize", err)
+	}
+}
+
+// TestSpendIndexConnectDisconnect ensures connecting and disconnecting blocks
+// to and from the spend index maintains the expected entries, including when a
+// block disapproves the regular transaction tree of its parent.
+func TestSpendIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewSpendIndex(db)
+	})
+	defer teardown()
+	idx := h.idx.(*SpendIndex)
+
+	// assertSpender ensures the spend index entry for the provided outpoint
+	// refers to the given input of the passed transaction in the block or
+	// that there is no entry when the transaction is nil.
+	assertSpender := func(stage string, outpoint wire.OutPoint, tx *wire.MsgTx, txInIdx uint32, block *dcrutil.Block) {
+		t.Helper()
+
+		entry, err := idx.Entry(&outpoint)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching entry: %v", stage, err)
+		}
+		if tx == nil {
+			if entry != nil {
+				t.Fatalf("%s: unexpected entry for %v: %+v", stage,
+					outpoint, entry)
+			}
+			return
+		}
+		want := SpendIndexEntry{
+			SpendingTx:  tx.TxHash(),
+			InputIndex:  txInIdx,
+			BlockHash:   *block.Hash(),
+			BlockHeight: block.Height(),
+		}
+		if entry == nil || *entry != want {
+			t.Fatalf("%s: mismatched entry for %v -- got %+v, want %+v",
+				stage, outpoint, entry, want)
+		}
+	}
+
+	const approve, disapprove = dcrutil.BlockValid, 0
+
+	// Block 1 creates some outputs to spend.  Coinbase inputs must not be
+	// indexed.
+	cb1 := newTestTx(nil, wire.NewTxOut(10, testP2PKHScript(1)),
+		wire.NewTxOut(20, testP2PKHScript(2)))
+	block1 := newTestBlock(1, approve, []*wire.MsgTx{cb1}, nil)
+	h.connect(block1, false)
+	if got := dumpTestIndexBucket(t, h.db, idx); len(got) != 0 {
+		t.Fatalf("block 1: unexpected entries %x", got)
+	}
+
+	// Block 2 spends the first output of block 1 in the regular tree with a
+	// transaction that is spent again in the same block and the second
+	// output of block 1 in the stake tree.
+	cb2 := newTestTx(nil, wire.NewTxOut(1, testP2PKHScript(3)))
+	tx1 := newTestTx([]wire.OutPoint{testOutPoint(cb1, 0, wire.TxTreeRegular)},
+		wire.NewTxOut(9, testP2PKHScript(4)))
+	tx2 := newTestTx([]wire.OutPoint{testOutPoint(tx1, 0, wire.TxTreeRegular)},
+		wire.NewTxOut(8, testP2PKHScript(5)))
+	stx := newTestTx([]wire.OutPoint{testOutPoint(cb1, 1, wire.TxTreeRegular)},
+		wire.NewTxOut(19, testP2PKHScript(6)))
+	block2 := newTestBlock(2, approve, []*wire.MsgTx{cb2, tx1, tx2},
+		[]*wire.MsgTx{stx})
+	h.connect(block2, false)
+	assertSpender("block 2", testOutPoint(cb1, 0, wire.TxTreeRegular), tx1, 0,
+		block2)
+	assertSpender("block 2", testOutPoint(tx1, 0, wire.TxTreeRegular), tx2, 0,
+		block2)
+	assertSpender("block 2", testOutPoint(cb1, 1, wire.TxTreeRegular), stx, 0,
+		block2)
+
+	// Block 3 disapproves the regular tree of block 2 and mines the first
+	// transaction of it again, so the outputs spent by the disapproved
+	// transactions must no longer be spent aside from the one that is spent
+	// again by block 3.  The stake tree of block 2 is not affected.
+	cb3 := newTestTx(nil, wire.NewTxOut(2, testP2PKHScript(7)))
+	block3 := newTestBlock(3, disapprove, []*wire.MsgTx{cb3, tx1}, nil)
+	h.connect(block3, false)
+	assertSpender("block 3", testOutPoint(cb1, 0, wire.TxTreeRegular), tx1, 0,
+		block3)
+	assertSpender("block 3", testOutPoint(tx1, 0, wire.TxTreeRegular), nil, 0,
+		nil)
+	assertSpender("block 3", testOutPoint(cb1, 1, wire.TxTreeRegular), stx, 0,
+		block2)
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnectAll()
+	assertSpender("disconnect", testOutPoint(cb1, 0, wire.TxTreeRegular), nil,
+		0, nil)
+}
@@ -0,0 +1,693 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// ticketIndexName is the human-readable name for the index.
+	ticketIndexName = "ticket index"
+
+	// ticketIndexVersion is the current version of the ticket index.
+	ticketIndexVersion = 1
+
+	// ticketKeyPrefix, ticketAddrKeyPrefix, and ticketMissedKeyPrefix are
+	// the prefixes of the ticket entry, address, and missed ticket keys in
+	// the ticket index, respectively.
+	ticketKeyPrefix       = 't'
+	ticketAddrKeyPrefix   = 'a'
+	ticketMissedKeyPrefix = 'm'
+
+	// ticketKeySize is the size of a ticket entry key.  It consists of 1
+	// byte prefix + 32 bytes ticket hash.
+	ticketKeySize = 1 + chainhash.HashSize
+
+	// ticketAddrKeySize is the size of an address key.  It consists of 1
+	// byte prefix + address key + 4 bytes purchase height + 32 bytes ticket
+	// hash.
+	ticketAddrKeySize = 1 + addrKeySize + 4 + chainhash.HashSize
+
+	// ticketMissedKeySize is the size of a missed ticket key.  It consists
+	// of 1 byte prefix + 4 bytes block height.
+	ticketMissedKeySize = 1 + 4
+
+	// ticketEntryMinSize is the minimum size of a ticket entry.  It consists
+	// of 32 bytes purchase block hash + 4 bytes purchase height + 8 bytes
+	// price + 1 byte flags + 32 bytes spending transaction hash + 4 bytes
+	// spending height + 4 bytes missed height + 4 bytes expired height +
+	// voting address key + 1 byte number of reward addresses.
+	ticketEntryMinSize = chainhash.HashSize + 4 + 8 + 1 + chainhash.HashSize +
+		4 + 4 + 4 + addrKeySize + 1
+
+	// ticketFlagVoted, ticketFlagRevoked, and ticketFlagHasVotingAddr are
+	// the flags in a ticket entry which indicate the ticket was spent by a
+	// vote, the ticket was spent by a revocation, and the entry contains a
+	// voting address, respectively.
+	ticketFlagVoted         = 1 << 0
+	ticketFlagRevoked       = 1 << 1
+	ticketFlagHasVotingAddr = 1 << 2
+)
+
+var (
+	// ticketIndexKey is the key of the ticket index and the db bucket used
+	// to house it.
+	ticketIndexKey = []byte("ticketidx")
+)
+
+// -----------------------------------------------------------------------------
+// The ticket index consists of an entry for every ticket purchased in the main
+// chain which records the history of the ticket along with entries that map
+// the addresses involved in tickets to the tickets and entries that track
+// which tickets were missed or expired by each block.
+//
+// All entries are housed in a single flat bucket and are distinguished by a
+// one byte key prefix.  Heights in keys are serialized as big endian so the
+// entries for an address are ordered by the height of the ticket purchases.
+//
+// The serialized format for the ticket entries is:
+//
+//   't'<ticket hash> = <purchase block hash><purchase height><price><flags>
+//                      <spending tx hash><spending height><missed height>
+//                      <expired height><voting addr key><num reward addrs>
+//                      <reward addr keys>
+//
+//   Field               Type              Size
+//   purchase block hash chainhash.Hash    32 bytes
+//   purchase height     uint32            4 bytes
+//   price               int64             8 bytes
+//   flags               byte              1 byte
+//   spending tx hash    chainhash.Hash    32 bytes
+//   spending height     uint32            4 bytes
+//   missed height       uint32            4 bytes
+//   expired height      uint32            4 bytes
+//   voting addr key     [21]byte          21 bytes
+//   num reward addrs    uint8             1 byte
+//   reward addr keys    [][21]byte        21 bytes each
+//   -----
+//   Total: 111 bytes + 21 bytes per reward address
+//
+// The serialized format for the address entries is:
+//
+//   'a'<addr key><purchase height><ticket hash> = <empty>
+//
+//   Field               Type              Size
+//   addr key            [21]byte          21 bytes
+//   purchase height     uint32            4 bytes (big endian)
+//   ticket hash         chainhash.Hash    32 bytes
+//
+// The serialized format for the missed ticket entries is:
+//
+//   'm'<block height> = <ticket hashes>
+//
+//   Field               Type              Size
+//   block height        uint32            4 bytes (big endian)
+//   ticket hashes       []chainhash.Hash  32 bytes each
+// -----------------------------------------------------------------------------
+
+// TicketIndexEntry houses the history of a ticket purchased in the main chain.
+type TicketIndexEntry struct {
+	// PurchaseBlock and PurchaseHeight identify the block that contains the
+	// ticket purchase.
+	PurchaseBlock  chainhash.Hash
+	PurchaseHeight int64
+
+	// Price is the amount of the ticket in atoms.
+	Price int64
+
+	// VotingAddress is the address that is able to vote with the ticket.  It
+	// is nil when the voting script does not contain a supported address.
+	VotingAddress dcrutil.Address
+
+	// RewardAddresses are the addresses committed to receive the rewards of
+	// the ticket.
+	RewardAddresses []dcrutil.Address
+
+	// Voted and Revoked specify whether the ticket was spent by a vote or a
+	// revocation, respectively.  SpendingTx and SpendingHeight identify the
+	// vote or revocation when either is set.
+	Voted          bool
+	Revoked        bool
+	SpendingTx     chainhash.Hash
+	SpendingHeight int64
+
+	// MissedHeight and ExpiredHeight are the heights of the blocks that
+	// missed and expired the ticket, respectively.  They are zero when the
+	// ticket was not missed or did not expire.
+	MissedHeight  int64
+	ExpiredHeight int64
+}
+
+// keyToAddr converts the passed address key to the address it represents.
+// Note that this is a lossy conversion since pay-to-pubkey addresses are
+// converted to the associated pay-to-pubkey-hash address.
+func keyToAddr(key []byte, params dcrutil.AddressParams) (dcrutil.Address, error) {
+	hash := key[1:addrKeySize]
+	switch key[0] {
+	case addrKeyTypePubKeyHash:
+		return dcrutil.NewAddressPubKeyHash(hash, params,
+			dcrec.STEcdsaSecp256k1)
+	case addrKeyTypePubKeyHashEdwards:
+		return dcrutil.NewAddressPubKeyHash(hash, params, dcrec.STEd25519)
+	case addrKeyTypePubKeyHashSchnorr:
+		return dcrutil.NewAddressPubKeyHash(hash, params,
+			dcrec.STSchnorrSecp256k1)
+	case addrKeyTypeScriptHash:
+		return dcrutil.NewAddressScriptHashFromHash(hash, params)
+	}
+
+	return nil, errUnsupportedAddressType
+}
+
+// ticketIndexKeyForTicket returns the key in the ticket index for the entry of
+// the provided ticket.
+func ticketIndexKeyForTicket(ticket *chainhash.Hash) [ticketKeySize]byte {
+	var key [ticketKeySize]byte
+	key[0] = ticketKeyPrefix
+	copy(key[1:], ticket[:])
+	return key
+}
+
+// ticketIndexKeyForAddr returns the key in the ticket index that maps the
+// provided address key to the provided ticket purchased at the given height.
+func ticketIndexKeyForAddr(addrKey [addrKeySize]byte, height int64, ticket *chainhash.Hash) [ticketAddrKeySize]byte {
+	var key [ticketAddrKeySize]byte
+	key[0] = ticketAddrKeyPrefix
+	offset := 1 + copy(key[1:], addrKey[:])
+	binary.BigEndian.PutUint32(key[offset:], uint32(height))
+	copy(key[offset+4:], ticket[:])
+	return key
+}
+
+// ticketIndexKeyForMissed returns the key in the ticket index for the tickets
+// missed or expired by the block at the provided height.
+func ticketIndexKeyForMissed(height int64) [ticketMissedKeySize]byte {
+	var key [ticketMissedKeySize]byte
+	key[0] = ticketMissedKeyPrefix
+	binary.BigEndian.PutUint32(key[1:], uint32(height))
+	return key
+}
+
+// serializeTicketIndexEntry serializes the provided ticket index entry into the
+// format described in detail above.  Addresses of unsupported types are not
+// serialized.
+func serializeTicketIndexEntry(entry *TicketIndexEntry) []byte {
+	var flags byte
+	if entry.Voted {
+		flags |= ticketFlagVoted
+	}
+	if entry.Revoked {
+		flags |= ticketFlagRevoked
+	}
+	var votingAddrKey [addrKeySize]byte
+	if entry.VotingAddress != nil {
+		addrKey, err := addrToKey(entry.VotingAddress)
+		if err == nil {
+			votingAddrKey = addrKey
+			flags |= ticketFlagHasVotingAddr
+		}
+	}
+	rewardAddrKeys := make([][addrKeySize]byte, 0, len(entry.RewardAddresses))
+	for _, addr := range entry.RewardAddresses {
+		addrKey, err := addrToKey(addr)
+		if err != nil {
+			continue
+		}
+		rewardAddrKeys = append(rewardAddrKeys, addrKey)
+	}
+
+	serialized := make([]byte, ticketEntryMinSize+
+		len(rewardAddrKeys)*addrKeySize)
+	offset := copy(serialized, entry.PurchaseBlock[:])
+	byteOrder.PutUint32(serialized[offset:], uint32(entry.PurchaseHeight))
+	offset += 4
+	byteOrder.PutUint64(serialized[offset:], uint64(entry.Price))
+	offset += 8
+	serialized[offset] = flags
+	offset++
+	offset += copy(serialized[offset:], entry.SpendingTx[:])
+	byteOrder.PutUint32(serialized[offset:], uint32(entry.SpendingHeight))
+	offset += 4
+	byteOrder.PutUint32(serialized[offset:], uint32(entry.MissedHeight))
+	offset += 4
+	byteOrder.PutUint32(serialized[offset:], uint32(entry.ExpiredHeight))
+	offset += 4
+	offset += copy(serialized[offset:], votingAddrKey[:])
+	serialized[offset] = uint8(len(rewardAddrKeys))
+	offset++
+	for i := range rewardAddrKeys {
+		offset += copy(serialized[offset:], rewardAddrKeys[i][:])
+	}
+	return serialized
+}
+
+// deserializeTicketIndexEntry decodes the passed serialized byte slice into the
+// provided ticket index entry according to the format described in detail
+// above.
+func deserializeTicketIndexEntry(serialized []byte, entry *TicketIndexEntry, params dcrutil.AddressParams) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < ticketEntryMinSize {
+		return errDeserialize("unexpected end of data")
+	}
+	numRewardAddrs := int(serialized[ticketEntryMinSize-1])
+	if len(serialized) < ticketEntryMinSize+numRewardAddrs*addrKeySize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	offset := copy(entry.PurchaseBlock[:], serialized)
+	entry.PurchaseHeight = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	entry.Price = int64(byteOrder.Uint64(serialized[offset:]))
+	offset += 8
+	flags := serialized[offset]
+	entry.Voted = flags&ticketFlagVoted != 0
+	entry.Revoked = flags&ticketFlagRevoked != 0
+	offset++
+	offset += copy(entry.SpendingTx[:], serialized[offset:])
+	entry.SpendingHeight = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	entry.MissedHeight = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	entry.ExpiredHeight = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	entry.VotingAddress = nil
+	if flags&ticketFlagHasVotingAddr != 0 {
+		addr, err := keyToAddr(serialized[offset:], params)
+		if err != nil {
+			return errDeserialize(fmt.Sprintf("unable to decode voting "+
+				"address: %v", err))
+		}
+		entry.VotingAddress = addr
+	}
+	offset += addrKeySize + 1
+	entry.RewardAddresses = make([]dcrutil.Address, 0, numRewardAddrs)
+	for i := 0; i < numRewardAddrs; i++ {
+		addr, err := keyToAddr(serialized[offset:], params)
+		if err != nil {
+			return errDeserialize(fmt.Sprintf("unable to decode reward "+
+				"address %d: %v", i, err))
+		}
+		entry.RewardAddresses = append(entry.RewardAddresses, addr)
+		offset += addrKeySize
+	}
+	return nil
+}
+
+// ticketAddrKeys returns the unique address keys of the voting and reward
+// addresses of the provided ticket index entry.
+func ticketAddrKeys(entry *TicketIndexEntry) [][addrKeySize]byte {
+	addrs := make([]dcrutil.Address, 0, len(entry.RewardAddresses)+1)
+	if entry.VotingAddress != nil {
+		addrs = append(addrs, entry.VotingAddress)
+	}
+	addrs = append(addrs, entry.RewardAddresses...)
+
+	addrKeys := make([][addrKeySize]byte, 0, len(addrs))
+	seen := make(map[[addrKeySize]byte]struct{}, len(addrs))
+	for _, addr := range addrs {
+		addrKey, err := addrToKey(addr)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[addrKey]; ok {
+			continue
+		}
+		seen[addrKey] = struct{}{}
+		addrKeys = append(addrKeys, addrKey)
+	}
+	return addrKeys
+}
+
+// TicketIndex implements a ticket history index.  That is to say, it supports
+// querying the purchase of every ticket in the main chain along with the vote
+// or revocation that spent it and the heights at which it was missed or
+// expired.  It also supports querying all tickets that involve a given address
+// as either the voting address or one of the reward addresses.
+type TicketIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the TicketIndex type implements the Indexer interface.
+var _ Indexer = (*TicketIndex)(nil)
+
+// Ensure the TicketIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*TicketIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Key() []byte {
+	return ticketIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Name() string {
+	return ticketIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Version() uint32 {
+	return ticketIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the ticket
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(ticketIndexKey)
+	return err
+}
+
+// fetchEntry uses an existing database transaction to fetch the entry for the
+// provided ticket from the index.  When there is no entry for the ticket, nil
+// will be returned for the both the entry and the error.
+func (idx *TicketIndex) fetchEntry(dbTx database.Tx, ticket *chainhash.Hash) (*TicketIndexEntry, error) {
+	key := ticketIndexKeyForTicket(ticket)
+	serialized := dbTx.Metadata().Bucket(ticketIndexKey).Get(key[:])
+	if len(serialized) == 0 {
+		return nil, nil
+	}
+
+	var entry TicketIndexEntry
+	err := deserializeTicketIndexEntry(serialized, &entry, idx.chainParams)
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt ticket index entry for "+
+				"%v: %v", ticket, err),
+		}
+	}
+	return &entry, nil
+}
+
+// updateEntry uses an existing database transaction to invoke the provided
+// function with the entry for the given ticket and store the modified entry.
+// Tickets that do not have an entry are ignored.
+func (idx *TicketIndex) updateEntry(dbTx database.Tx, ticket *chainhash.Hash, f func(entry *TicketIndexEntry)) error {
+	entry, err := idx.fetchEntry(dbTx, ticket)
+	if err != nil || entry == nil {
+		return err
+	}
+	f(entry)
+	key := ticketIndexKeyForTicket(ticket)
+	bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+	return bucket.Put(key[:], serializeTicketIndexEntry(entry))
+}
+
+// purchaseEntry returns a new ticket index entry for the provided ticket
+// purchase in the passed block.
+func (idx *TicketIndex) purchaseEntry(tx *wire.MsgTx, block *dcrutil.Block, isTreasuryEnabled bool) *TicketIndexEntry {
+	entry := &TicketIndexEntry{
+		PurchaseBlock:  *block.Hash(),
+		PurchaseHeight: block.Height(),
+		Price:          tx.TxOut[0].Value,
+	}
+
+	// Note that the functions used here require v0 scripts.  Hence it is
+	// used for the script version.  This will ultimately need to updated to
+	// support new script versions.
+	const scriptVersion = 0
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(scriptVersion,
+		tx.TxOut[0].PkScript, idx.chainParams, isTreasuryEnabled)
+	if err == nil && len(addrs) > 0 {
+		entry.VotingAddress = addrs[0]
+	}
+
+	// The reward addresses are committed to in the odd outputs.
+	for i := 1; i < len(tx.TxOut); i += 2 {
+		addr, err := stake.AddrFromSStxPkScrCommitment(tx.TxOut[i].PkScript,
+			idx.chainParams)
+		if err != nil {
+			continue
+		}
+		entry.RewardAddresses = append(entry.RewardAddresses, addr)
+	}
+	return entry
+}
+
+// spentTicket returns the hash of the ticket spent by the provided stake
+// transaction along with whether it is a vote or a revocation.  The ok flag
+// is false when the transaction does not spend a ticket.
+func spentTicket(tx *wire.MsgTx, isTreasuryEnabled bool) (ticket *chainhash.Hash, isVote bool, ok bool) {
+	switch {
+	case stake.IsSSGen(tx, isTreasuryEnabled):
+		return &tx.TxIn[1].PreviousOutPoint.Hash, true, true
+	case stake.IsSSRtx(tx):
+		return &tx.TxIn[0].PreviousOutPoint.Hash, false, true
+	}
+	return nil, false, false
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for every ticket
+// purchased by the passed block and updates the entries of the tickets that
+// the block spends, misses, or expires.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+	blockHeight := block.Height()
+	for _, stx := range block.STransactions() {
+		tx := stx.MsgTx()
+		if stake.IsSStx(tx) {
+			entry := idx.purchaseEntry(tx, block, isTreasuryEnabled)
+			key := ticketIndexKeyForTicket(stx.Hash())
+			err := bucket.Put(key[:], serializeTicketIndexEntry(entry))
+			if err != nil {
+				return err
+			}
+			for _, addrKey := range ticketAddrKeys(entry) {
+				key := ticketIndexKeyForAddr(addrKey, blockHeight, stx.Hash())
+				if err := bucket.Put(key[:], nil); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		ticket, isVote, ok := spentTicket(tx, isTreasuryEnabled)
+		if !ok {
+			continue
+		}
+		err := idx.updateEntry(dbTx, ticket, func(entry *TicketIndexEntry) {
+			entry.Voted = isVote
+			entry.Revoked = !isVote
+			entry.SpendingTx = *stx.Hash()
+			entry.SpendingHeight = blockHeight
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Mark the tickets that were missed or expired by the block using the
+	// ticket undo data from the stake database and keep track of them so
+	// they can be restored when the block is disconnected.
+	undoData, err := stake.FetchBlockUndoData(dbTx, uint32(blockHeight))
+	if err != nil {
+		return err
+	}
+	var missed []byte
+	for i := range undoData {
+		undo := &undoData[i]
+		if !undo.Missed || undo.Revoked {
+			continue
+		}
+		err := idx.updateEntry(dbTx, &undo.TicketHash,
+			func(entry *TicketIndexEntry) {
+				if undo.Expired {
+					entry.ExpiredHeight = blockHeight
+				} else {
+					entry.MissedHeight = blockHeight
+				}
+			})
+		if err != nil {
+			return err
+		}
+		missed = append(missed, undo.TicketHash[:]...)
+	}
+	if len(missed) == 0 {
+		return nil
+	}
+	key := ticketIndexKeyForMissed(blockHeight)
+	return bucket.Put(key[:], missed)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entries for
+// every ticket purchased by the passed block and reverts the entries of the
+// tickets that the block spent, missed, or expired.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+	blockHeight := block.Height()
+
+	// Revert the tickets that were missed or expired by the block.
+	missedKey := ticketIndexKeyForMissed(blockHeight)
+	missed := bucket.Get(missedKey[:])
+	for len(missed) >= chainhash.HashSize {
+		var ticket chainhash.Hash
+		copy(ticket[:], missed)
+		missed = missed[chainhash.HashSize:]
+		err := idx.updateEntry(dbTx, &ticket, func(entry *TicketIndexEntry) {
+			if entry.ExpiredHeight == blockHeight {
+				entry.ExpiredHeight = 0
+			}
+			if entry.MissedHeight == blockHeight {
+				entry.MissedHeight = 0
+			}
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if err := bucket.Delete(missedKey[:]); err != nil {
+		return err
+	}
+
+	// Revert the tickets spent by the block and remove the tickets it
+	// purchased.
+	for _, stx := range block.STransactions() {
+		tx := stx.MsgTx()
+		if stake.IsSStx(tx) {
+			entry, err := idx.fetchEntry(dbTx, stx.Hash())
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				for _, addrKey := range ticketAddrKeys(entry) {
+					key := ticketIndexKeyForAddr(addrKey, blockHeight,
+						stx.Hash())
+					if err := bucket.Delete(key[:]); err != nil {
+						return err
+					}
+				}
+			}
+			key := ticketIndexKeyForTicket(stx.Hash())
+			if err := bucket.Delete(key[:]); err != nil {
+				return err
+			}
+			continue
+		}
+
+		ticket, _, ok := spentTicket(tx, isTreasuryEnabled)
+		if !ok {
+			continue
+		}
+		err := idx.updateEntry(dbTx, ticket, func(entry *TicketIndexEntry) {
+			entry.Voted = false
+			entry.Revoked = false
+			entry.SpendingTx = chainhash.Hash{}
+			entry.SpendingHeight = 0
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Entry returns the history of the provided ticket from the ticket index.
+// When there is no entry for the provided ticket, nil will be returned for
+// the both the entry and the error.
+//
+// This function is safe for concurrent access.
+func (idx *TicketIndex) Entry(ticket *chainhash.Hash) (*TicketIndexEntry, error) {
+	var entry *TicketIndexEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var err error
+		entry, err = idx.fetchEntry(dbTx, ticket)
+		return err
+	})
+	return entry, err
+}
+
+// TicketsForAddress returns the hashes of all tickets in the main chain that
+// involve the provided address as either the voting address or one of the
+// reward addresses, including tickets that were already spent, missed, or
+// expired.  The tickets are ordered by the height of their purchase.
+//
+// This function is safe for concurrent access.
+func (idx *TicketIndex) TicketsForAddress(addr dcrutil.Address) ([]chainhash.Hash, error) {
+	addrKey, err := addrToKey(addr)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, 1+addrKeySize)
+	prefix[0] = ticketAddrKeyPrefix
+	copy(prefix[1:], addrKey[:])
+
+	var tickets []chainhash.Hash
+	err = idx.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(ticketIndexKey).Cursor()
+		for ok := cursor.Seek(prefix); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if !bytes.HasPrefix(key, prefix) {
+				break
+			}
+			if len(key) != ticketAddrKeySize {
+				continue
+			}
+			var ticket chainhash.Hash
+			copy(ticket[:], key[ticketAddrKeySize-chainhash.HashSize:])
+			tickets = append(tickets, ticket)
+		}
+		return nil
+	})
+	return tickets, err
+}
+
+// NewTicketIndex returns a new instance of an indexer that is used to create a
+// mapping of every ticket purchased in the blockchain to its history, such as
+// the vote or revocation that spent it and whether it was missed or expired,
+// along with a mapping of the addresses involved in tickets to the tickets.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewTicketIndex(db database.DB, chainParams *chaincfg.Params) *TicketIndex {
+	return &TicketIndex{db: db, chainParams: chainParams}
+}
+
+// DropTicketIndex drops the ticket index from the provided database if it
+// exists.
+func DropTicketIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, ticketIndexKey, ticketIndexName)
+}
+
+// DropIndex drops the ticket index from the provided database if it exists.
+func (*TicketIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropTicketIndex(ctx, db)
+}
@@ -0,0 +1,331 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrec"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// testStakeScript returns the script created by the provided stake script
+// function for the pay-to-pubkey-hash address derived from the given id.
+func testStakeScript(t *testing.T, payTo func(dcrutil.Address) ([]byte, error), id byte) []byte {
+	t.Helper()
+
+	script, err := payTo(testP2PKHAddr(t, id))
+	if err != nil {
+		t.Fatalf("unable to create stake script: %v", err)
+	}
+	return script
+}
+
+// newTestTicket returns a ticket purchase for the provided price that grants
+// voting rights to the pay-to-pubkey-hash address derived from the voting id
+// and commits the rewards to the one derived from the reward id.  The seed
+// ensures tickets with the same addresses have different hashes.
+func newTestTicket(t *testing.T, seed byte, price int64, votingID, rewardID byte) *wire.MsgTx {
+	t.Helper()
+
+	// The commitment consists of the 20-byte pubkey hash, the 8-byte amount
+	// without the pay-to-script-hash flag, and 2 bytes of fee limits.
+	var commitment [30]byte
+	copy(commitment[:], testPubKeyHash(rewardID))
+	binary.LittleEndian.PutUint64(commitment[20:], uint64(price))
+	spend := wire.OutPoint{Hash: chainhash.Hash{seed}}
+	return newTestTx([]wire.OutPoint{spend},
+		wire.NewTxOut(price, testStakeScript(t, txscript.PayToSStx, votingID)),
+		wire.NewTxOut(0, testNullDataScript(t, commitment[:])),
+		wire.NewTxOut(0, testStakeScript(t, txscript.PayToSStxChange,
+			rewardID)))
+}
+
+// newTestVote returns a vote that spends the provided ticket and votes on the
+// passed block.
+func newTestVote(t *testing.T, ticket *wire.MsgTx, votedOn *dcrutil.Block) *wire.MsgTx {
+	t.Helper()
+
+	var reference [36]byte
+	copy(reference[:], votedOn.Hash()[:])
+	binary.LittleEndian.PutUint32(reference[32:], uint32(votedOn.Height()))
+	stakeBase := *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex,
+		wire.TxTreeRegular)
+	return newTestTx([]wire.OutPoint{stakeBase,
+		testOutPoint(ticket, 0, wire.TxTreeStake)},
+		wire.NewTxOut(0, testNullDataScript(t, reference[:])),
+		wire.NewTxOut(0, testNullDataScript(t, []byte{0x01, 0x00})),
+		wire.NewTxOut(ticket.TxOut[0].Value,
+			testStakeScript(t, txscript.PayToSSGen, 0x10)))
+}
+
+// newTestRevocation returns a revocation that spends the provided ticket.
+func newTestRevocation(t *testing.T, ticket *wire.MsgTx) *wire.MsgTx {
+	t.Helper()
+
+	return newTestTx([]wire.OutPoint{testOutPoint(ticket, 0, wire.TxTreeStake)},
+		wire.NewTxOut(ticket.TxOut[0].Value,
+			testStakeScript(t, txscript.PayToSSRtx, 0x11)))
+}
+
+// putTestTicketUndoData stores the provided ticket undo data for the block at
+// the given height in the stake database bucket the ticket index loads it from
+// using the same format as the stake database.
+func putTestTicketUndoData(t *testing.T, db database.DB, height int64, undoData stake.UndoTicketDataSlice) {
+	t.Helper()
+
+	bucketName := []byte("stakeblockundo")
+	err := db.Update(func(dbTx database.Tx) error {
+		bucket, err := dbTx.Metadata().CreateBucketIfNotExists(bucketName)
+		if err != nil {
+			return err
+		}
+		var serialized []byte
+		for _, undo := range undoData {
+			var flags byte
+			if undo.Missed {
+				flags |= 1 << 0
+			}
+			if undo.Revoked {
+				flags |= 1 << 1
+			}
+			if undo.Spent {
+				flags |= 1 << 2
+			}
+			if undo.Expired {
+				flags |= 1 << 3
+			}
+			serialized = append(serialized, undo.TicketHash[:]...)
+			serialized = append(serialized, 0, 0, 0, 0, flags)
+			binary.LittleEndian.PutUint32(serialized[len(serialized)-5:],
+				undo.TicketHeight)
+		}
+		var key [4]byte
+		binary.LittleEndian.PutUint32(key[:], uint32(height))
+		return bucket.Put(key[:], serialized)
+	})
+	if err != nil {
+		t.Fatalf("unable to store ticket undo data: %v", err)
+	}
+}
+
+// TestTicketIndexSerialization ensures serializing and deserializing ticket
+// index keys and entries works as expected.
+func TestTicketIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegNetParams()
+	pkHash := bytes.Repeat([]byte{0x01}, 20)
+	scriptHash := bytes.Repeat([]byte{0x02}, 20)
+	votingAddr, err := dcrutil.NewAddressPubKeyHash(pkHash, params,
+		dcrec.STEcdsaSecp256k1)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+	rewardAddr, err := dcrutil.NewAddressScriptHashFromHash(scriptHash, params)
+	if err != nil {
+		t.Fatalf("unexpected error creating address: %v", err)
+	}
+
+	// Ensure the address keys are ordered by purchase height.
+	ticket := chainhash.Hash{0x03}
+	addrKey, err := addrToKey(votingAddr)
+	if err != nil {
+		t.Fatalf("unexpected error creating address key: %v", err)
+	}
+	key1 := ticketIndexKeyForAddr(addrKey, 0x0102, &ticket)
+	key2 := ticketIndexKeyForAddr(addrKey, 0x0201, &ticket)
+	if bytes.Compare(key1[:], key2[:]) >= 0 {
+		t.Fatalf("address keys are not ordered by height: %x >= %x", key1,
+			key2)
+	}
+
+	// Ensure entries round trip with and without addresses.
+	tests := []TicketIndexEntry{{
+		PurchaseBlock:   chainhash.Hash{0x04},
+		PurchaseHeight:  123456,
+		Price:           20000000000,
+		VotingAddress:   votingAddr,
+		RewardAddresses: []dcrutil.Address{votingAddr, rewardAddr},
+		Revoked:         true,
+		SpendingTx:      chainhash.Hash{0x05},
+		SpendingHeight:  131000,
+		MissedHeight:    130000,
+	}, {
+		PurchaseBlock:   chainhash.Hash{0x06},
+		PurchaseHeight:  10,
+		Price:           100000000,
+		RewardAddresses: []dcrutil.Address{},
+		Voted:           true,
+		SpendingTx:      chainhash.Hash{0x07},
+		SpendingHeight:  100,
+	}}
+	for i, entry := range tests {
+		serialized := serializeTicketIndexEntry(&entry)
+		wantSize := ticketEntryMinSize +
+			len(entry.RewardAddresses)*addrKeySize
+		if len(serialized) != wantSize {
+			t.Fatalf("#%d: unexpected serialized size -- got %d, want %d", i,
+				len(serialized), wantSize)
+		}
+		var gotEntry TicketIndexEntry
+		err := deserializeTicketIndexEntry(serialized, &gotEntry, params)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error deserializing entry: %v", i, err)
+		}
+		if !reflect.DeepEqual(gotEntry, entry) {
+			t.Fatalf("#%d: mismatched entry -- got %+v, want %+v", i,
+				gotEntry, entry)
+		}
+
+		// Ensure truncated entries are rejected.
+		err = deserializeTicketIndexEntry(serialized[:wantSize-1], &gotEntry,
+			params)
+		if !isDeserializeErr(err) {
+			t.Fatalf("#%d: unexpected error for truncated entry -- got %v, "+
+				"want errDeserialize", i, err)
+		}
+	}
+}
+
+// TestTicketIndexConnectDisconnect ensures connecting and disconnecting blocks
+// to and from the ticket index maintains the expected entries for purchased,
+// voted, revoked, missed, and expired tickets along with the addresses involved
+// in them.
+func TestTicketIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegNetParams()
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewTicketIndex(db, params)
+	})
+	defer teardown()
+	idx := h.idx.(*TicketIndex)
+
+	// assertEntry ensures the ticket index entry for the provided ticket
+	// matches the given entry or that there is no entry when it is nil.
+	assertEntry := func(stage string, ticket *wire.MsgTx, want *TicketIndexEntry) {
+		t.Helper()
+
+		ticketHash := ticket.TxHash()
+		entry, err := idx.Entry(&ticketHash)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching entry: %v", stage, err)
+		}
+		if !reflect.DeepEqual(entry, want) {
+			t.Fatalf("%s: mismatched entry for %v -- got %+v, want %+v",
+				stage, ticketHash, entry, want)
+		}
+	}
+
+	// assertTickets ensures the tickets reported for the address derived from
+	// the provided id are the given ones.
+	assertTickets := func(stage string, id byte, want ...*wire.MsgTx) {
+		t.Helper()
+
+		addr := testP2PKHAddr(t, id)
+		tickets, err := idx.TicketsForAddress(addr)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching tickets: %v", stage, err)
+		}
+		var wantHashes []chainhash.Hash
+		for _, ticket := range want {
+			wantHashes = append(wantHashes, ticket.TxHash())
+		}
+		if !reflect.DeepEqual(tickets, wantHashes) {
+			t.Fatalf("%s: mismatched tickets for %v -- got %v, want %v",
+				stage, addr, tickets, wantHashes)
+		}
+	}
+
+	const approve = dcrutil.BlockValid
+
+	// Block 1 purchases four tickets.  The third ticket uses the same
+	// address for voting and the rewards.
+	ticket1 := newTestTicket(t, 1, 100, 1, 2)
+	ticket2 := newTestTicket(t, 2, 200, 3, 4)
+	ticket3 := newTestTicket(t, 3, 300, 5, 5)
+	ticket4 := newTestTicket(t, 4, 400, 6, 1)
+	block1 := newTestBlock(1, approve, []*wire.MsgTx{newTestTx(nil)},
+		[]*wire.MsgTx{ticket1, ticket2, ticket3, ticket4})
+	putTestTicketUndoData(t, h.db, 1, nil)
+	h.connect(block1, false)
+	purchased := func(ticket *wire.MsgTx, votingID, rewardID byte) *TicketIndexEntry {
+		return &TicketIndexEntry{
+			PurchaseBlock:   *block1.Hash(),
+			PurchaseHeight:  1,
+			Price:           ticket.TxOut[0].Value,
+			VotingAddress:   testP2PKHAddr(t, votingID),
+			RewardAddresses: []dcrutil.Address{testP2PKHAddr(t, rewardID)},
+		}
+	}
+	entry1 := purchased(ticket1, 1, 2)
+	entry2 := purchased(ticket2, 3, 4)
+	entry3 := purchased(ticket3, 5, 5)
+	entry4 := purchased(ticket4, 6, 1)
+	assertEntry("block 1", ticket1, entry1)
+	assertEntry("block 1", ticket2, entry2)
+	assertEntry("block 1", ticket3, entry3)
+	assertEntry("block 1", ticket4, entry4)
+	assertTickets("block 1", 1, ticket1, ticket4)
+	assertTickets("block 1", 5, ticket3)
+
+	// Block 2 votes with the first ticket and revokes the second one while
+	// the third ticket is missed and the fourth one expires.  The undo data
+	// of the revoked ticket must not mark it missed by the block.
+	vote := newTestVote(t, ticket1, block1)
+	revocation := newTestRevocation(t, ticket2)
+	block2 := newTestBlock(2, approve, []*wire.MsgTx{newTestTx(nil)},
+		[]*wire.MsgTx{vote, revocation})
+	putTestTicketUndoData(t, h.db, 2, stake.UndoTicketDataSlice{{
+		TicketHash:   ticket1.TxHash(),
+		TicketHeight: 1,
+		Spent:        true,
+	}, {
+		TicketHash:   ticket2.TxHash(),
+		TicketHeight: 1,
+		Missed:       true,
+		Revoked:      true,
+	}, {
+		TicketHash:   ticket3.TxHash(),
+		TicketHeight: 1,
+		Missed:       true,
+	}, {
+		TicketHash:   ticket4.TxHash(),
+		TicketHeight: 1,
+		Missed:       true,
+		Expired:      true,
+	}})
+	h.connect(block2, false)
+	voted, revoked := *entry1, *entry2
+	voted.Voted = true
+	voted.SpendingTx = vote.TxHash()
+	voted.SpendingHeight = 2
+	revoked.Revoked = true
+	revoked.SpendingTx = revocation.TxHash()
+	revoked.SpendingHeight = 2
+	missed, expired := *entry3, *entry4
+	missed.MissedHeight = 2
+	expired.ExpiredHeight = 2
+	assertEntry("block 2", ticket1, &voted)
+	assertEntry("block 2", ticket2, &revoked)
+	assertEntry("block 2", ticket3, &missed)
+	assertEntry("block 2", ticket4, &expired)
+	assertTickets("block 2", 1, ticket1, ticket4)
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnectAll()
+	assertEntry("disconnect block 1", ticket1, nil)
+	assertTickets("disconnect block 1", 1)
+}
@@ -0,0 +1,522 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+const (
+	// treasuryIndexName is the human-readable name for the index.
+	treasuryIndexName = "treasury index"
+
+	// treasuryIndexVersion is the current version of the treasury index.
+	treasuryIndexVersion = 1
+
+	// treasuryBlockKeyPrefix and treasuryTxKeyPrefix are the prefixes of the
+	// block and transaction keys in the treasury index, respectively.
+	treasuryBlockKeyPrefix = 'b'
+	treasuryTxKeyPrefix    = 't'
+
+	// treasuryBlockKeySize is the size of a block key.  It consists of 1
+	// byte prefix + 4 bytes block height.
+	treasuryBlockKeySize = 1 + 4
+
+	// treasuryTxKeySize is the size of a transaction key.  It consists of 1
+	// byte prefix + 4 bytes block height + 4 bytes transaction index.
+	treasuryTxKeySize = 1 + 4 + 4
+
+	// treasuryBlockEntrySize is the size of a block entry.  It consists of
+	// 32 bytes block hash + 8 bytes balance + 8 bytes net value.
+	treasuryBlockEntrySize = chainhash.HashSize + 8 + 8
+
+	// treasuryTxEntrySize is the size of a transaction entry.  It consists
+	// of 1 byte type + 32 bytes transaction hash + 8 bytes amount + 8 bytes
+	// fee.
+	treasuryTxEntrySize = 1 + chainhash.HashSize + 8 + 8
+)
+
+var (
+	// treasuryIndexKey is the key of the treasury index and the db bucket
+	// used to house it.
+	treasuryIndexKey = []byte("treasuryidx")
+)
+
+// -----------------------------------------------------------------------------
+// The treasury index consists of an entry for every block in the main chain
+// once the treasury agenda is active that records the treasury balance as of
+// the block along with an entry for every treasury add, treasury base, and
+// treasury spend in those blocks.
+//
+// All entries are housed in a single flat bucket and are distinguished by a
+// one byte key prefix.  Heights and transaction indexes in keys are serialized
+// as big endian so the transaction entries are ordered by their position in
+// the chain.
+//
+// The serialized format for the block entries is:
+//
+//   'b'<block height> = <block hash><balance><net value>
+//
+//   Field              Type              Size
+//   block height       uint32            4 bytes (big endian)
+//   -----
+//   block hash         chainhash.Hash    32 bytes
+//   balance            int64             8 bytes
+//   net value          int64             8 bytes
+//   -----
+//   Total: 48 bytes
+//
+// The net value is the sum of the values the treasury transactions in the
+// block add to the treasury balance once they mature.
+//
+// The serialized format for the transaction entries is:
+//
+//   't'<block height><tx index> = <type><tx hash><amount><fee>
+//
+//   Field              Type              Size
+//   block height       uint32            4 bytes (big endian)
+//   tx index           uint32            4 bytes (big endian)
+//   -----
+//   type               uint8             1 byte
+//   tx hash            chainhash.Hash    32 bytes
+//   amount             int64             8 bytes
+//   fee                int64             8 bytes
+//   -----
+//   Total: 49 bytes
+// -----------------------------------------------------------------------------
+
+// TreasuryTxType identifies the type of a treasury transaction.
+type TreasuryTxType uint8
+
+// These constants define the types of treasury transactions.
+const (
+	// TreasuryTxTypeTAdd identifies a treasury add.
+	TreasuryTxTypeTAdd TreasuryTxType = 1
+
+	// TreasuryTxTypeTBase identifies a treasury base.
+	TreasuryTxTypeTBase TreasuryTxType = 2
+
+	// TreasuryTxTypeTSpend identifies a treasury spend.
+	TreasuryTxTypeTSpend TreasuryTxType = 3
+)
+
+// String returns the treasury transaction type as a human-readable string.
+func (t TreasuryTxType) String() string {
+	switch t {
+	case TreasuryTxTypeTAdd:
+		return "tadd"
+	case TreasuryTxTypeTBase:
+		return "treasurybase"
+	case TreasuryTxTypeTSpend:
+		return "tspend"
+	}
+	return fmt.Sprintf("unknown treasury transaction type (%d)", uint8(t))
+}
+
+// TreasuryHistoryEntry houses information about a treasury transaction in the
+// main chain.
+type TreasuryHistoryEntry struct {
+	// Type is the type of the treasury transaction.
+	Type TreasuryTxType
+
+	// TxHash is the hash of the treasury transaction.
+	TxHash chainhash.Hash
+
+	// BlockHash and BlockHeight identify the block that contains the
+	// treasury transaction.
+	BlockHash   chainhash.Hash
+	BlockHeight int64
+
+	// Amount is the amount in atoms the transaction adds to the treasury.  It
+	// is negative for treasury spends and excludes the fee.
+	Amount int64
+
+	// Fee is the fee in atoms paid by a treasury spend from the treasury.  It
+	// is zero for other types.
+	Fee int64
+
+	// Balance is the treasury balance in atoms as of the block that contains
+	// the transaction.
+	Balance int64
+}
+
+// treasuryBlockEntry houses the treasury state of a block in the main chain.
+type treasuryBlockEntry struct {
+	hash     chainhash.Hash
+	balance  int64
+	netValue int64
+}
+
+// treasuryIndexKeyForBlock returns the key in the treasury index for the entry
+// of the block at the provided height.
+func treasuryIndexKeyForBlock(height int64) [treasuryBlockKeySize]byte {
+	var key [treasuryBlockKeySize]byte
+	key[0] = treasuryBlockKeyPrefix
+	binary.BigEndian.PutUint32(key[1:], uint32(height))
+	return key
+}
+
+// treasuryIndexKeyForTx returns the key in the treasury index for the entry of
+// the transaction at the provided index in the stake tree of the block at the
+// provided height.
+func treasuryIndexKeyForTx(height int64, txIdx uint32) [treasuryTxKeySize]byte {
+	var key [treasuryTxKeySize]byte
+	key[0] = treasuryTxKeyPrefix
+	binary.BigEndian.PutUint32(key[1:], uint32(height))
+	binary.BigEndian.PutUint32(key[5:], txIdx)
+	return key
+}
+
+// serializeTreasuryBlockEntry serializes the provided block entry into the
+// format described in detail above.
+func serializeTreasuryBlockEntry(entry *treasuryBlockEntry) []byte {
+	serialized := make([]byte, treasuryBlockEntrySize)
+	offset := copy(serialized, entry.hash[:])
+	byteOrder.PutUint64(serialized[offset:], uint64(entry.balance))
+	offset += 8
+	byteOrder.PutUint64(serialized[offset:], uint64(entry.netValue))
+	return serialized
+}
+
+// deserializeTreasuryBlockEntry decodes the passed serialized byte slice into
+// the provided block entry according to the format described in detail above.
+func deserializeTreasuryBlockEntry(serialized []byte, entry *treasuryBlockEntry) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < treasuryBlockEntrySize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	offset := copy(entry.hash[:], serialized)
+	entry.balance = int64(byteOrder.Uint64(serialized[offset:]))
+	offset += 8
+	entry.netValue = int64(byteOrder.Uint64(serialized[offset:]))
+	return nil
+}
+
+// serializeTreasuryTxEntry serializes the type, hash, amount, and fee of the
+// provided entry into the format described in detail above.
+func serializeTreasuryTxEntry(entry *TreasuryHistoryEntry) []byte {
+	serialized := make([]byte, treasuryTxEntrySize)
+	serialized[0] = uint8(entry.Type)
+	offset := 1 + copy(serialized[1:], entry.TxHash[:])
+	byteOrder.PutUint64(serialized[offset:], uint64(entry.Amount))
+	offset += 8
+	byteOrder.PutUint64(serialized[offset:], uint64(entry.Fee))
+	return serialized
+}
+
+// deserializeTreasuryTxEntry decodes the passed serialized byte slice into the
+// type, hash, amount, and fee of the provided entry according to the format
+// described in detail above.
+func deserializeTreasuryTxEntry(serialized []byte, entry *TreasuryHistoryEntry) error {
+	// Ensure there are enough bytes to decode.
+	if len(serialized) < treasuryTxEntrySize {
+		return errDeserialize("unexpected end of data")
+	}
+
+	entry.Type = TreasuryTxType(serialized[0])
+	offset := 1 + copy(entry.TxHash[:], serialized[1:])
+	entry.Amount = int64(byteOrder.Uint64(serialized[offset:]))
+	offset += 8
+	entry.Fee = int64(byteOrder.Uint64(serialized[offset:]))
+	return nil
+}
+
+// dbFetchTreasuryBlockEntry uses an existing database transaction to fetch the
+// block entry for the provided height from the treasury index.  When there is
+// no entry for the height, nil will be returned for the both the entry and the
+// error.
+func dbFetchTreasuryBlockEntry(dbTx database.Tx, height int64) (*treasuryBlockEntry, error) {
+	key := treasuryIndexKeyForBlock(height)
+	serialized := dbTx.Metadata().Bucket(treasuryIndexKey).Get(key[:])
+	if len(serialized) == 0 {
+		return nil, nil
+	}
+
+	var entry treasuryBlockEntry
+	if err := deserializeTreasuryBlockEntry(serialized, &entry); err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt treasury index block entry "+
+				"for height %d: %v", height, err),
+		}
+	}
+	return &entry, nil
+}
+
+// TreasuryIndex implements a treasury transaction history index.  That is to
+// say, it supports querying the treasury adds, treasury bases, and treasury
+// spends in the main chain over a range of heights along with the treasury
+// balance as of the blocks that contain them.
+type TreasuryIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the TreasuryIndex type implements the Indexer interface.
+var _ Indexer = (*TreasuryIndex)(nil)
+
+// Ensure the TreasuryIndex type implements the IndexDropper interface.
+var _ IndexDropper = (*TreasuryIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) Key() []byte {
+	return treasuryIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) Name() string {
+	return treasuryIndexName
+}
+
+// Version returns the current version of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) Version() uint32 {
+	return treasuryIndexVersion
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the treasury
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(treasuryIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds an entry for the treasury
+// balance as of the passed block along with an entry for every treasury
+// transaction in it once the treasury agenda is active.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) ConnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	// There is no treasury prior to the activation of the treasury agenda.
+	if !isTreasuryEnabled {
+		return nil
+	}
+
+	// The balance as of the block is the balance as of its parent plus the
+	// net value of the treasury transactions that mature in the block.  This
+	// mirrors the calculation done by the chain.  Note that there are no
+	// entries prior to the activation of the treasury agenda, which means the
+	// balance and net values are zero.
+	height := block.Height()
+	var balance int64
+	parentEntry, err := dbFetchTreasuryBlockEntry(dbTx, height-1)
+	if err != nil {
+		return err
+	}
+	if parentEntry != nil {
+		balance = parentEntry.balance
+	}
+	maturity := int64(idx.chainParams.CoinbaseMaturity)
+	if height >= maturity {
+		maturingEntry, err := dbFetchTreasuryBlockEntry(dbTx, height-maturity)
+		if err != nil {
+			return err
+		}
+		if maturingEntry != nil {
+			balance += maturingEntry.netValue
+		}
+	}
+
+	// Add an entry for every treasury transaction in the block while
+	// accumulating the net value they add to the treasury.
+	bucket := dbTx.Metadata().Bucket(treasuryIndexKey)
+	var netValue int64
+	for txIdx, stx := range block.STransactions() {
+		tx := stx.MsgTx()
+		entry := TreasuryHistoryEntry{TxHash: *stx.Hash()}
+		switch {
+		case stake.IsTAdd(tx):
+			// Note that the second output, if it exists, is change.
+			entry.Type = TreasuryTxTypeTAdd
+			entry.Amount = tx.TxOut[0].Value
+
+		case stake.IsTreasuryBase(tx):
+			entry.Type = TreasuryTxTypeTBase
+			entry.Amount = tx.TxOut[0].Value
+
+		case stake.IsTSpend(tx):
+			// The first output is an OP_RETURN and the remaining outputs
+			// pay out of the treasury.  The fee is the difference
+			// between the amount taken from the treasury and the outputs.
+			entry.Type = TreasuryTxTypeTSpend
+			var totalOut int64
+			for _, txOut := range tx.TxOut[1:] {
+				totalOut += txOut.Value
+			}
+			entry.Amount = -totalOut
+			entry.Fee = tx.TxIn[0].ValueIn - totalOut
+
+		default:
+			continue
+		}
+
+		key := treasuryIndexKeyForTx(height, uint32(txIdx))
+		err := bucket.Put(key[:], serializeTreasuryTxEntry(&entry))
+		if err != nil {
+			return err
+		}
+		netValue += entry.Amount - entry.Fee
+	}
+
+	blockEntry := treasuryBlockEntry{
+		hash:     *block.Hash(),
+		balance:  balance,
+		netValue: netValue,
+	}
+	key := treasuryIndexKeyForBlock(height)
+	return bucket.Put(key[:], serializeTreasuryBlockEntry(&blockEntry))
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the entries for the
+// passed block and the treasury transactions it contains.
+//
+// This is part of the Indexer interface.
+func (idx *TreasuryIndex) DisconnectBlock(dbTx database.Tx, block, parent *dcrutil.Block, _ PrevScripter, isTreasuryEnabled bool) error {
+	if !isTreasuryEnabled {
+		return nil
+	}
+
+	bucket := dbTx.Metadata().Bucket(treasuryIndexKey)
+	height := block.Height()
+	for txIdx := range block.STransactions() {
+		key := treasuryIndexKeyForTx(height, uint32(txIdx))
+		if err := bucket.Delete(key[:]); err != nil {
+			return err
+		}
+	}
+	key := treasuryIndexKeyForBlock(height)
+	return bucket.Delete(key[:])
+}
+
+// Entries returns the treasury transactions in the main chain from the
+// treasury index that are in blocks with heights in the provided inclusive
+// range ordered by their position in the chain.
+//
+// The transactions may be filtered by type by specifying the types to include.
+// All types are included when no types are specified.  The provided number of
+// matching transactions are skipped and at most the provided count of them are
+// returned.
+//
+// This function is safe for concurrent access.
+func (idx *TreasuryIndex) Entries(startHeight, endHeight int64, txTypes []TreasuryTxType, skip, count int) ([]TreasuryHistoryEntry, error) {
+	if startHeight < 0 || endHeight < startHeight || count <= 0 {
+		return nil, nil
+	}
+
+	var entries []TreasuryHistoryEntry
+	err := idx.db.View(func(dbTx database.Tx) error {
+		var blockEntry *treasuryBlockEntry
+		var blockHeight int64
+		startKey := treasuryIndexKeyForTx(startHeight, 0)
+		cursor := dbTx.Metadata().Bucket(treasuryIndexKey).Cursor()
+		for ok := cursor.Seek(startKey[:]); ok; ok = cursor.Next() {
+			key := cursor.Key()
+			if len(key) != treasuryTxKeySize || key[0] != treasuryTxKeyPrefix {
+				break
+			}
+			height := int64(binary.BigEndian.Uint32(key[1:]))
+			if height > endHeight {
+				break
+			}
+
+			var entry TreasuryHistoryEntry
+			err := deserializeTreasuryTxEntry(cursor.Value(), &entry)
+			if err != nil {
+				return database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt treasury index "+
+						"entry for height %d: %v", height, err),
+				}
+			}
+			if len(txTypes) > 0 {
+				var include bool
+				for _, txType := range txTypes {
+					include = include || entry.Type == txType
+				}
+				if !include {
+					continue
+				}
+			}
+			if skip > 0 {
+				skip--
+				continue
+			}
+
+			// Load the block details for the transaction.  The block entry
+			// is reused for subsequent transactions in the same block.
+			if blockEntry == nil || blockHeight != height {
+				blockEntry, err = dbFetchTreasuryBlockEntry(dbTx, height)
+				if err != nil {
+					return err
+				}
+				if blockEntry == nil {
+					str := fmt.Sprintf("missing treasury index block "+
+						"entry for height %d", height)
+					return AssertError(str)
+				}
+				blockHeight = height
+			}
+			entry.BlockHash = blockEntry.hash
+			entry.BlockHeight = height
+			entry.Balance = blockEntry.balance
+			entries = append(entries, entry)
+			if len(entries) >= count {
+				break
+			}
+		}
+		return nil
+	})
+	return entries, err
+}
+
+// NewTreasuryIndex returns a new instance of an indexer that is used to create
+// a history of the treasury adds, treasury bases, and treasury spends in the
+// blockchain along with the treasury balance as of each block.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewTreasuryIndex(db database.DB, chainParams *chaincfg.Params) *TreasuryIndex {
+	return &TreasuryIndex{db: db, chainParams: chainParams}
+}
+
+// DropTreasuryIndex drops the treasury index from the provided database if it
+// exists.
+func DropTreasuryIndex(ctx context.Context, db database.DB) error {
+	return dropFlatIndex(ctx, db, treasuryIndexKey, treasuryIndexName)
+}
+
+// DropIndex drops the treasury index from the provided database if it exists.
+func (*TreasuryIndex) DropIndex(ctx context.Context, db database.DB) error {
+	return DropTreasuryIndex(ctx, db)
+}
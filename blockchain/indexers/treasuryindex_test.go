@@ -0,0 +1,268 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/txscript/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// newTestTAdd returns a treasury add of the provided amount.
+func newTestTAdd(t *testing.T, seed byte, amount int64) *wire.MsgTx {
+	t.Helper()
+
+	spend := wire.OutPoint{Hash: chainhash.Hash{seed}}
+	changeScript, err := txscript.PayToSStxChange(testP2PKHAddr(t, seed))
+	if err != nil {
+		t.Fatalf("unable to create change script: %v", err)
+	}
+	tx := newTestTx([]wire.OutPoint{spend},
+		wire.NewTxOut(amount, []byte{txscript.OP_TADD}),
+		wire.NewTxOut(1, changeScript))
+	tx.Version = wire.TxVersionTreasury
+	return tx
+}
+
+// newTestTreasuryBase returns a treasury base of the provided amount for the
+// block at the given height.
+func newTestTreasuryBase(t *testing.T, height uint32, amount int64) *wire.MsgTx {
+	t.Helper()
+
+	var data [12]byte
+	data[0] = byte(height)
+	tx := newTestTx(nil, wire.NewTxOut(amount, []byte{txscript.OP_TADD}),
+		wire.NewTxOut(0, testNullDataScript(t, data[:])))
+	tx.Version = wire.TxVersionTreasury
+	return tx
+}
+
+// newTestTSpend returns a treasury spend that takes the provided amount from
+// the treasury and pays the given amounts to pay-to-pubkey-hash addresses.
+func newTestTSpend(t *testing.T, valueIn int64, amounts ...int64) *wire.MsgTx {
+	t.Helper()
+
+	// The signature script consists of a push of a 64-byte signature, a push
+	// of a 33-byte compressed public key, and an OP_TSPEND.
+	sigScript := make([]byte, 0, 100)
+	sigScript = append(sigScript, txscript.OP_DATA_64)
+	sigScript = append(sigScript, make([]byte, 64)...)
+	sigScript = append(sigScript, txscript.OP_DATA_33, 0x02)
+	sigScript = append(sigScript, make([]byte, 32)...)
+	sigScript = append(sigScript, txscript.OP_TSPEND)
+
+	tx := wire.NewMsgTx()
+	tx.Version = wire.TxVersionTreasury
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{},
+			wire.MaxPrevOutIndex, wire.TxTreeRegular),
+		ValueIn:         valueIn,
+		SignatureScript: sigScript,
+	})
+	tx.AddTxOut(wire.NewTxOut(0, testNullDataScript(t, make([]byte, 32))))
+	for i, amount := range amounts {
+		script := append([]byte{txscript.OP_TGEN}, testP2PKHScript(byte(i))...)
+		tx.AddTxOut(wire.NewTxOut(amount, script))
+	}
+	return tx
+}
+
+// TestTreasuryIndexSerialization ensures serializing and deserializing
+// treasury index keys and entries works as expected.
+func TestTreasuryIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	// Ensure transaction keys are ordered by height and then by index.
+	key1 := treasuryIndexKeyForTx(0x0102, 0x0201)
+	key2 := treasuryIndexKeyForTx(0x0201, 0x0102)
+	key3 := treasuryIndexKeyForTx(0x0201, 0x0103)
+	if bytes.Compare(key1[:], key2[:]) >= 0 {
+		t.Fatalf("tx keys are not ordered by height: %x >= %x", key1, key2)
+	}
+	if bytes.Compare(key2[:], key3[:]) >= 0 {
+		t.Fatalf("tx keys are not ordered by index: %x >= %x", key2, key3)
+	}
+
+	// Ensure block entries round trip and truncated entries are rejected.
+	blockEntry := treasuryBlockEntry{
+		hash:     chainhash.Hash{0x01},
+		balance:  1234567890,
+		netValue: -98765,
+	}
+	serialized := serializeTreasuryBlockEntry(&blockEntry)
+	if len(serialized) != treasuryBlockEntrySize {
+		t.Fatalf("unexpected serialized block entry size -- got %d, want %d",
+			len(serialized), treasuryBlockEntrySize)
+	}
+	var gotBlockEntry treasuryBlockEntry
+	err := deserializeTreasuryBlockEntry(serialized, &gotBlockEntry)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing block entry: %v", err)
+	}
+	if gotBlockEntry != blockEntry {
+		t.Fatalf("mismatched block entry -- got %+v, want %+v", gotBlockEntry,
+			blockEntry)
+	}
+	err = deserializeTreasuryBlockEntry(serialized[:treasuryBlockEntrySize-1],
+		&gotBlockEntry)
+	if !isDeserializeErr(err) {
+		t.Fatalf("unexpected error for truncated block entry -- got %v, want "+
+			"errDeserialize", err)
+	}
+
+	// Ensure transaction entries round trip and truncated entries are
+	// rejected.  Note that only the type, hash, amount, and fee are stored.
+	tests := []TreasuryHistoryEntry{{
+		Type:   TreasuryTxTypeTAdd,
+		TxHash: chainhash.Hash{0x02},
+		Amount: 100000000,
+	}, {
+		Type:   TreasuryTxTypeTBase,
+		TxHash: chainhash.Hash{0x03},
+		Amount: 32000000,
+	}, {
+		Type:   TreasuryTxTypeTSpend,
+		TxHash: chainhash.Hash{0x04},
+		Amount: -500000000,
+		Fee:    2550,
+	}}
+	for i, entry := range tests {
+		serialized := serializeTreasuryTxEntry(&entry)
+		if len(serialized) != treasuryTxEntrySize {
+			t.Fatalf("#%d: unexpected serialized size -- got %d, want %d", i,
+				len(serialized), treasuryTxEntrySize)
+		}
+		var gotEntry TreasuryHistoryEntry
+		err := deserializeTreasuryTxEntry(serialized, &gotEntry)
+		if err != nil {
+			t.Fatalf("#%d: unexpected error deserializing entry: %v", i, err)
+		}
+		if gotEntry != entry {
+			t.Fatalf("#%d: mismatched entry -- got %+v, want %+v", i,
+				gotEntry, entry)
+		}
+		err = deserializeTreasuryTxEntry(serialized[:treasuryTxEntrySize-1],
+			&gotEntry)
+		if !isDeserializeErr(err) {
+			t.Fatalf("#%d: unexpected error for truncated entry -- got %v, "+
+				"want errDeserialize", i, err)
+		}
+	}
+}
+
+// TestTreasuryIndexConnectDisconnect ensures connecting and disconnecting
+// blocks to and from the treasury index maintains the expected transaction
+// entries and balances, including when the values added by earlier blocks
+// mature.
+func TestTreasuryIndexConnectDisconnect(t *testing.T) {
+	t.Parallel()
+
+	params := chaincfg.RegNetParams()
+	h, teardown := newTestIndexHarness(t, func(db database.DB) Indexer {
+		return NewTreasuryIndex(db, params)
+	})
+	defer teardown()
+	idx := h.idx.(*TreasuryIndex)
+
+	// assertEntries ensures the entries of the provided types in the index
+	// are the given ones.
+	assertEntries := func(stage string, txTypes []TreasuryTxType, want ...TreasuryHistoryEntry) {
+		t.Helper()
+
+		entries, err := idx.Entries(0, 100, txTypes, 0, 100)
+		if err != nil {
+			t.Fatalf("%s: unexpected error fetching entries: %v", stage, err)
+		}
+		if len(want) == 0 {
+			want = nil
+		}
+		if !reflect.DeepEqual(entries, want) {
+			t.Fatalf("%s: mismatched entries -- got %+v, want %+v", stage,
+				entries, want)
+		}
+	}
+
+	// Blocks prior to the activation of the treasury agenda must not be
+	// indexed.
+	const approve = dcrutil.BlockValid
+	block1 := newTestBlock(1, approve, []*wire.MsgTx{newTestTx(nil)},
+		[]*wire.MsgTx{newTestTAdd(t, 1, 1000)})
+	h.connect(block1, false)
+	if got := dumpTestIndexBucket(t, h.db, idx); len(got) != 0 {
+		t.Fatalf("block 1: unexpected entries %x", got)
+	}
+	h.disconnect()
+
+	// Block 1 contains a treasury base, a treasury add, a treasury spend
+	// that pays a fee, and a ticket which must not be indexed.
+	tbase := newTestTreasuryBase(t, 1, 100)
+	tadd := newTestTAdd(t, 2, 50)
+	tspend := newTestTSpend(t, 35, 10, 20)
+	block1 = newTestBlock(1, approve, []*wire.MsgTx{newTestTx(nil)},
+		[]*wire.MsgTx{tbase, tadd, newTestTicket(t, 3, 10, 1, 2), tspend})
+	h.connect(block1, true)
+	tbaseEntry := TreasuryHistoryEntry{
+		Type:        TreasuryTxTypeTBase,
+		TxHash:      tbase.TxHash(),
+		BlockHash:   *block1.Hash(),
+		BlockHeight: 1,
+		Amount:      100,
+	}
+	taddEntry := TreasuryHistoryEntry{
+		Type:        TreasuryTxTypeTAdd,
+		TxHash:      tadd.TxHash(),
+		BlockHash:   *block1.Hash(),
+		BlockHeight: 1,
+		Amount:      50,
+	}
+	tspendEntry := TreasuryHistoryEntry{
+		Type:        TreasuryTxTypeTSpend,
+		TxHash:      tspend.TxHash(),
+		BlockHash:   *block1.Hash(),
+		BlockHeight: 1,
+		Amount:      -30,
+		Fee:         5,
+	}
+	assertEntries("block 1", nil, tbaseEntry, taddEntry, tspendEntry)
+	assertEntries("block 1", []TreasuryTxType{TreasuryTxTypeTSpend},
+		tspendEntry)
+
+	// Connect blocks without treasury transactions until the values added by
+	// block 1 mature.
+	maturity := uint32(params.CoinbaseMaturity)
+	for height := uint32(2); height <= maturity; height++ {
+		block := newTestBlock(height, approve, []*wire.MsgTx{newTestTx(nil)},
+			nil)
+		h.connect(block, true)
+	}
+
+	// The balance as of the block that matures the values added by block 1
+	// must include their net value.
+	matureTAdd := newTestTAdd(t, 4, 7)
+	matureBlock := newTestBlock(maturity+1, approve,
+		[]*wire.MsgTx{newTestTx(nil)}, []*wire.MsgTx{matureTAdd})
+	h.connect(matureBlock, true)
+	matureEntry := TreasuryHistoryEntry{
+		Type:        TreasuryTxTypeTAdd,
+		TxHash:      matureTAdd.TxHash(),
+		BlockHash:   *matureBlock.Hash(),
+		BlockHeight: int64(maturity + 1),
+		Amount:      7,
+		Balance:     100 + 50 - 35,
+	}
+	assertEntries("mature block", []TreasuryTxType{TreasuryTxTypeTAdd},
+		taddEntry, matureEntry)
+
+	// Disconnecting the blocks must restore the exact prior state.
+	h.disconnectAll()
+	assertEntries("disconnect block 1", nil)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/v4/indexers"
+	"github.com/decred/dcrd/chaincfg/v3"
+)
+
+// TestIndexManagerSync ensures the index manager catches up indexes that are
+// behind the main chain in the background, keeps them synced as new blocks are
+// connected, and follows reorganizations that happen both while the indexes
+// are synced and while they are still catching up.
+func TestIndexManagerSync(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "indexmanagersynctest")
+	defer teardownFunc()
+
+	// Replace the chain instance with one that uses an index manager with
+	// the address index listed before the transaction index it depends on
+	// to ensure dependent indexes are never connected ahead of their
+	// dependencies regardless of their order.
+	if err := g.chain.ShutdownUtxoCache(); err != nil {
+		t.Fatalf("unexpected error shutting down utxo cache: %v", err)
+	}
+	db := g.chain.db
+	txIndex := indexers.NewTxIndex(db)
+	addrIndex := indexers.NewAddrIndex(db, params)
+	indexManager := indexers.NewManager(db, []indexers.Indexer{addrIndex,
+		txIndex}, params)
+	chain, err := New(context.Background(), &Config{
+		DB:           db,
+		ChainParams:  params,
+		TimeSource:   NewMedianTime(),
+		IndexManager: indexManager,
+	})
+	if err != nil {
+		t.Fatalf("failed to create chain instance: %v", err)
+	}
+	g.chain = chain
+
+	// ---------------------------------------------------------------------
+	// Create some convenience functions to improve test readability.
+	// ---------------------------------------------------------------------
+
+	// startManager runs the index manager in the background and returns a
+	// function that stops it and waits for it to exit.
+	startManager := func() func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			indexManager.Run(ctx)
+			close(done)
+		}()
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+
+	// assertIndexesAtTip waits for all indexes to reach the block with the
+	// provided name and ensures it is their tip.
+	assertIndexesAtTip := func(tipName string) {
+		t.Helper()
+
+		tip := g.BlockByName(tipName)
+		tipHash := tip.BlockHash()
+		height := int64(tip.Header.Height)
+		ctx, cancel := context.WithTimeout(context.Background(),
+			10*time.Second)
+		defer cancel()
+		for _, indexer := range []indexers.Indexer{addrIndex, txIndex} {
+			err := indexManager.WaitForHeight(ctx, indexer.Name(), height)
+			if err != nil {
+				t.Fatalf("%s did not reach block %q: %v", indexer.Name(),
+					tipName, err)
+			}
+		}
+		for _, info := range indexManager.IndexInfo() {
+			if info.Hash != tipHash || info.Height != height || !info.Synced {
+				t.Fatalf("unexpected %s state -- got %+v, want tip %s "+
+					"(hash %s, height %d) and synced", info.Name, info,
+					tipName, tipHash, height)
+			}
+		}
+	}
+
+	// assertTxIndexed ensures the coinbase of the block with the provided
+	// name is indexed in that block when the flag is set or not indexed at
+	// all otherwise.
+	assertTxIndexed := func(blockName string, indexed bool) {
+		t.Helper()
+
+		block := g.BlockByName(blockName)
+		txHash := block.Transactions[0].TxHash()
+		entry, err := txIndex.Entry(&txHash)
+		if err != nil {
+			t.Fatalf("unexpected error fetching tx index entry: %v", err)
+		}
+		switch {
+		case indexed && entry == nil:
+			t.Fatalf("coinbase of block %q is not indexed", blockName)
+		case indexed && *entry.BlockRegion.Hash != block.BlockHash():
+			t.Fatalf("coinbase of block %q indexed in block %s", blockName,
+				entry.BlockRegion.Hash)
+		case !indexed && entry != nil:
+			t.Fatalf("coinbase of disconnected block %q is still indexed",
+				blockName)
+		}
+	}
+
+	// ---------------------------------------------------------------------
+	// Generate blocks while the index manager is not running and ensure
+	// the indexes are caught up once it is started.
+	//
+	//   genesis -> bfb -> b0 -> ... -> b9
+	// ---------------------------------------------------------------------
+
+	g.CreateBlockOne("bfb", 0)
+	g.AcceptTipBlock()
+	for i := 0; i < 10; i++ {
+		g.NextBlock(fmt.Sprintf("b%d", i), nil, nil)
+		g.AcceptTipBlock()
+	}
+	for _, info := range indexManager.IndexInfo() {
+		if info.Height != 0 {
+			t.Fatalf("unexpected %s state before running -- got %+v",
+				info.Name, info)
+		}
+	}
+
+	stopManager := startManager()
+	assertIndexesAtTip("b9")
+	assertTxIndexed("b9", true)
+
+	// ---------------------------------------------------------------------
+	// Reorganize to a longer side chain while the indexes are synced and
+	// ensure they follow it.
+	//
+	//   ... -> b7 -> b8 -> b9
+	//            \-> b8a -> b9a -> b10a
+	// ---------------------------------------------------------------------
+
+	g.SetTip("b7")
+	g.NextBlock("b8a", nil, nil)
+	g.AcceptedToSideChainWithExpectedTip("b9")
+	g.NextBlock("b9a", nil, nil)
+	g.AcceptedToSideChainWithExpectedTip("b9")
+	g.NextBlock("b10a", nil, nil)
+	g.AcceptTipBlock()
+	assertIndexesAtTip("b10a")
+	assertTxIndexed("b8", false)
+	assertTxIndexed("b9", false)
+	assertTxIndexed("b10a", true)
+
+	// ---------------------------------------------------------------------
+	// Connect more blocks while the manager is stopped so the indexes fall
+	// behind and then reorganize away from the blocks the indexes have not
+	// yet indexed as well as the block they have.
+	//
+	//   ... -> b9a -> b10a -> b11a -> b12a -> b13a
+	//             \-> b10b -> b11b -> b12b -> b13b -> b14b
+	// ---------------------------------------------------------------------
+
+	stopManager()
+	for i := 11; i < 14; i++ {
+		g.NextBlock(fmt.Sprintf("b%da", i), nil, nil)
+		g.AcceptTipBlock()
+	}
+	g.SetTip("b9a")
+	for i := 10; i < 14; i++ {
+		g.NextBlock(fmt.Sprintf("b%db", i), nil, nil)
+		g.AcceptedToSideChainWithExpectedTip("b13a")
+	}
+	g.NextBlock("b14b", nil, nil)
+	g.AcceptTipBlock()
+
+	// The indexes must have been rewound to the fork point since the block
+	// they indexed was disconnected.
+	for _, info := range indexManager.IndexInfo() {
+		forkPoint := g.BlockByName("b9a")
+		if info.Hash != forkPoint.BlockHash() {
+			t.Fatalf("unexpected %s state after reorg -- got %+v, want "+
+				"tip %s", info.Name, info, forkPoint.BlockHash())
+		}
+	}
+
+	stopManager = startManager()
+	defer stopManager()
+	assertIndexesAtTip("b14b")
+	assertTxIndexed("b10a", false)
+	assertTxIndexed("b13a", false)
+	assertTxIndexed("b10b", true)
+	assertTxIndexed("b14b", true)
+}
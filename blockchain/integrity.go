@@ -0,0 +1,464 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+const (
+	// rebuildProgressInterval is the number of blocks between progress
+	// messages while rebuilding the utxo set.
+	rebuildProgressInterval = 10000
+)
+
+// IntegrityIssueKind identifies the part of the chain state an integrity issue
+// applies to.
+type IntegrityIssueKind string
+
+// These constants define the parts of the chain state that are verified.
+const (
+	// IntegrityIssueBlock indicates the data for a block in the main chain
+	// could not be loaded.
+	IntegrityIssueBlock IntegrityIssueKind = "block"
+
+	// IntegrityIssueSpendJournal indicates the spend journal for a block in
+	// the main chain is missing or does not match the block.
+	IntegrityIssueSpendJournal IntegrityIssueKind = "spendjournal"
+
+	// IntegrityIssueUtxo indicates an entry in the utxo set is invalid or
+	// does not match the transaction it refers to.
+	IntegrityIssueUtxo IntegrityIssueKind = "utxo"
+
+	// IntegrityIssueUtxoStats indicates the stored utxo set statistics do not
+	// match the utxo set.
+	IntegrityIssueUtxoStats IntegrityIssueKind = "utxostats"
+)
+
+// IntegrityIssue describes a problem found while verifying the integrity of the
+// chain state.
+type IntegrityIssue struct {
+	// Kind is the part of the chain state the issue applies to.
+	Kind IntegrityIssueKind
+
+	// Hash is the hash of the block the issue applies to or, for utxo
+	// entries, the hash of the transaction.
+	Hash chainhash.Hash
+
+	// Height is the height of the block the issue applies to.  It is -1
+	// when it is unknown.
+	Height int64
+
+	// Description is a human-readable description of the issue.
+	Description string
+
+	// Repaired indicates whether or not the issue was repaired.
+	Repaired bool
+}
+
+// IntegrityResult houses the results of verifying the integrity of the chain
+// state.
+type IntegrityResult struct {
+	// NumBlocks is the number of blocks in the main chain that were checked.
+	NumBlocks uint64
+
+	// NumUnavailable is the number of blocks in the main chain that were not
+	// checked because their data is not available due to pruning or because
+	// the chain was initialized from a utxo set snapshot.
+	NumUnavailable uint64
+
+	// NumUtxoEntries is the number of entries in the utxo set.
+	NumUtxoEntries uint64
+
+	// Rebuilt indicates whether or not the utxo set, spend journals, and
+	// utxo set statistics were rebuilt from the blocks in the main chain.
+	Rebuilt bool
+
+	// Issues contains all of the issues that were found.
+	Issues []IntegrityIssue
+}
+
+// verifySpendJournal checks the spend journal stored for the provided block
+// and returns a description of the first problem found or an empty string
+// when it is valid.
+func verifySpendJournal(dbTx database.Tx, block *dcrutil.Block, isTreasuryEnabled bool) string {
+	// Blocks that do not spend any outputs might not have a spend journal.
+	numSpent := countSpentOutputs(block, isTreasuryEnabled)
+	spendBucket := dbTx.Metadata().Bucket(spendJournalBucketName)
+	if len(spendBucket.Get(block.Hash()[:])) == 0 {
+		if numSpent == 0 {
+			return ""
+		}
+		return "spend journal does not exist"
+	}
+
+	stxos, err := dbFetchSpendJournalEntry(dbTx, block, isTreasuryEnabled)
+	if err != nil {
+		return fmt.Sprintf("failed to load spend journal: %v", err)
+	}
+	if len(stxos) != numSpent {
+		return fmt.Sprintf("spend journal has %d entries instead of %d",
+			len(stxos), numSpent)
+	}
+	return ""
+}
+
+// verifyUtxoEntry checks the provided utxo entry against the transaction it
+// refers to in the provided block and returns a description of the first
+// problem found or an empty string when it is valid.
+func verifyUtxoEntry(block *dcrutil.Block, txHash *chainhash.Hash, entry *UtxoEntry) string {
+	txns, treeName := block.Transactions(), "regular"
+	if entry.TransactionType() != stake.TxTypeRegular {
+		txns, treeName = block.STransactions(), "stake"
+	}
+	txIndex := entry.BlockIndex()
+	if txIndex >= uint32(len(txns)) {
+		return fmt.Sprintf("utxo entry refers to transaction %d of the %s "+
+			"tree which only has %d transactions", txIndex, treeName,
+			len(txns))
+	}
+	tx := txns[txIndex]
+	if *tx.Hash() != *txHash {
+		return fmt.Sprintf("utxo entry refers to transaction %d of the %s "+
+			"tree which is %s", txIndex, treeName, tx.Hash())
+	}
+	msgTx := tx.MsgTx()
+	if entry.TxVersion() != msgTx.Version {
+		return fmt.Sprintf("utxo entry has transaction version %d instead "+
+			"of %d", entry.TxVersion(), msgTx.Version)
+	}
+	for outputIndex, output := range entry.sparseOutputs {
+		if output.spent {
+			continue
+		}
+		if outputIndex >= uint32(len(msgTx.TxOut)) {
+			return fmt.Sprintf("utxo entry has output %d but the "+
+				"transaction only has %d outputs", outputIndex,
+				len(msgTx.TxOut))
+		}
+		txOut := msgTx.TxOut[outputIndex]
+		if entry.AmountByIndex(outputIndex) != txOut.Value ||
+			entry.ScriptVersionByIndex(outputIndex) != txOut.Version ||
+			!bytes.Equal(entry.PkScriptByIndex(outputIndex), txOut.PkScript) {
+
+			return fmt.Sprintf("utxo entry output %d does not match the "+
+				"transaction", outputIndex)
+		}
+	}
+	return ""
+}
+
+// VerifyIntegrity checks the chain state in the database against the blocks in
+// the main chain.  In particular, it ensures that every block in the main chain
+// with available data can be loaded, that the spend journal for each of those
+// blocks matches the block, that every entry in the utxo set refers to an
+// existing transaction in the main chain with matching outputs, and that the
+// stored utxo set statistics match the utxo set.
+//
+// When repair is set and issues with the spend journals, utxo set, or utxo set
+// statistics are found, all of them are rebuilt by replaying every block in the
+// main chain.  This requires the data for all of the blocks in the main chain,
+// so it is not possible when blocks have been pruned or the chain was
+// initialized from a utxo set snapshot.  Issues with loading blocks are never
+// repaired since the blocks must be downloaded again.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) VerifyIntegrity(ctx context.Context, repair bool) (*IntegrityResult, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	// Ensure the utxo set in the database is current so it can be checked
+	// directly.
+	tip := b.bestChain.Tip()
+	if err := b.utxoCache.maybeFlush(&tip.hash, tip.height, true); err != nil {
+		return nil, err
+	}
+
+	var result IntegrityResult
+	addIssue := func(kind IntegrityIssueKind, hash *chainhash.Hash, height int64, desc string) {
+		log.Warnf("Integrity issue (%s) for %s: %s", kind, hash, desc)
+		result.Issues = append(result.Issues, IntegrityIssue{
+			Kind:        kind,
+			Hash:        *hash,
+			Height:      height,
+			Description: desc,
+		})
+	}
+
+	// Load every entry in the utxo set to ensure they can be deserialized
+	// while grouping them by the height of the block that contains the
+	// transaction they refer to.
+	log.Info("Verifying the utxo set...")
+	utxoHeights := make(map[int64][]chainhash.Hash)
+	err := b.db.View(func(dbTx database.Tx) error {
+		cursor := dbTx.Metadata().Bucket(utxoSetBucketName).Cursor()
+		for ok := cursor.First(); ok; ok = cursor.Next() {
+			if interruptRequested(ctx) {
+				return errInterruptRequested
+			}
+
+			result.NumUtxoEntries++
+			var txHash chainhash.Hash
+			copy(txHash[:], cursor.Key())
+			entry, err := deserializeUtxoEntry(cursor.Value())
+			if err != nil {
+				desc := fmt.Sprintf("failed to deserialize utxo "+
+					"entry: %v", err)
+				addIssue(IntegrityIssueUtxo, &txHash, -1, desc)
+				continue
+			}
+			height := entry.BlockHeight()
+			if height < 1 || height > tip.height {
+				desc := fmt.Sprintf("utxo entry refers to invalid "+
+					"height %d", height)
+				addIssue(IntegrityIssueUtxo, &txHash, height, desc)
+				continue
+			}
+			utxoHeights[height] = append(utxoHeights[height], txHash)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Check every block in the main chain that has its data available along
+	// with its spend journal and the utxo entries that refer to it.
+	log.Infof("Verifying blocks and spend journals through height %d...",
+		tip.height)
+	b.prunedHeightLock.RLock()
+	prunedHeight := b.prunedHeight
+	b.prunedHeightLock.RUnlock()
+	for node := b.bestChain.NodeByHeight(1); node != nil; node =
+		b.bestChain.Next(node) {
+
+		if interruptRequested(ctx) {
+			return nil, errInterruptRequested
+		}
+
+		if node.height <= prunedHeight || !node.status.HaveData() {
+			result.NumUnavailable++
+			continue
+		}
+		block, err := b.fetchMainChainBlockByNode(node)
+		if err != nil {
+			desc := fmt.Sprintf("failed to load block: %v", err)
+			addIssue(IntegrityIssueBlock, &node.hash, node.height, desc)
+			continue
+		}
+		result.NumBlocks++
+		isTreasuryEnabled, err := b.isTreasuryAgendaActive(node.parent)
+		if err != nil {
+			return nil, err
+		}
+
+		err = b.db.View(func(dbTx database.Tx) error {
+			desc := verifySpendJournal(dbTx, block, isTreasuryEnabled)
+			if desc != "" {
+				addIssue(IntegrityIssueSpendJournal, &node.hash,
+					node.height, desc)
+			}
+
+			for i := range utxoHeights[node.height] {
+				txHash := &utxoHeights[node.height][i]
+				entry, err := dbFetchUtxoEntry(dbTx, txHash)
+				if err != nil {
+					return err
+				}
+				desc := verifyUtxoEntry(block, txHash, entry)
+				if desc != "" {
+					addIssue(IntegrityIssueUtxo, txHash,
+						node.height, desc)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		delete(utxoHeights, node.height)
+
+		if node.height%rebuildProgressInterval == 0 {
+			log.Infof("Verified blocks through height %d", node.height)
+		}
+	}
+
+	// Ensure the stored utxo set statistics for the current tip match the
+	// utxo set.  They can't be calculated when there are utxo entries that
+	// can't be deserialized, but those were already reported above.
+	log.Info("Verifying the utxo set statistics...")
+	var stats, storedStats *utxoSetStats
+	var calcErr error
+	err = b.db.View(func(dbTx database.Tx) error {
+		var err error
+		storedStats, err = dbFetchUtxoSetStats(dbTx, &tip.hash)
+		if err != nil {
+			desc := fmt.Sprintf("failed to load utxo set statistics: %v",
+				err)
+			addIssue(IntegrityIssueUtxoStats, &tip.hash, tip.height, desc)
+		}
+		stats, calcErr = dbCalcUtxoSetStats(ctx, dbTx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if calcErr != nil && !database.IsError(calcErr, database.ErrCorruption) {
+		return nil, calcErr
+	}
+	if stats != nil && storedStats != nil && *stats != *storedStats {
+		addIssue(IntegrityIssueUtxoStats, &tip.hash, tip.height,
+			"utxo set statistics do not match the utxo set")
+	}
+
+	if !repair {
+		return &result, nil
+	}
+
+	// The utxo set can only be rebuilt when all of the blocks in the main
+	// chain are available.
+	var needsRebuild, blocksUnavailable bool
+	for i := range result.Issues {
+		if result.Issues[i].Kind == IntegrityIssueBlock {
+			blocksUnavailable = true
+		} else {
+			needsRebuild = true
+		}
+	}
+	if !needsRebuild {
+		return &result, nil
+	}
+	if blocksUnavailable || result.NumUnavailable > 0 {
+		log.Warnf("Unable to rebuild the utxo set since the data for some " +
+			"blocks in the main chain is not available")
+		return &result, nil
+	}
+	if err := b.rebuildUtxoSet(ctx); err != nil {
+		return nil, err
+	}
+	result.Rebuilt = true
+	for i := range result.Issues {
+		result.Issues[i].Repaired = true
+	}
+
+	return &result, nil
+}
+
+// rebuildUtxoSet rebuilds the utxo set, the spend journals, and the utxo set
+// statistics from scratch by replaying every block in the main chain.  Only the
+// statistics for the current tip are available afterwards.
+//
+// The data for all of the blocks in the main chain must be available.
+//
+// This function MUST be called with the chain lock held (for writes).
+func (b *BlockChain) rebuildUtxoSet(ctx context.Context) error {
+	// Remove the existing utxo set and statistics and mark the utxo set as
+	// representing the genesis block.  An interrupted rebuild will resume
+	// from the most recent flush on the next start just like recovering from
+	// an unclean shutdown.
+	log.Info("Removing the existing utxo set...")
+	err := incrementalFlatDrop(ctx, b.db, utxoSetBucketName, "utxo set")
+	if err != nil {
+		return err
+	}
+	err = incrementalFlatDrop(ctx, b.db, utxoSetStatsBucketName,
+		"utxo set statistics")
+	if err != nil {
+		return err
+	}
+	genesis := b.bestChain.Genesis()
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSetState(dbTx, &genesis.hash, genesis.height)
+	})
+	if err != nil {
+		return err
+	}
+	c := b.utxoCache
+	c.reset(&genesis.hash, genesis.height)
+
+	tip := b.bestChain.Tip()
+	log.Infof("Rebuilding the utxo set and spend journals through height "+
+		"%d...", tip.height)
+	for node := b.bestChain.Next(genesis); node != nil; node =
+		b.bestChain.Next(node) {
+
+		if interruptRequested(ctx) {
+			return errInterruptRequested
+		}
+
+		block, err := b.fetchMainChainBlockByNode(node)
+		if err != nil {
+			return err
+		}
+		parent, err := b.fetchMainChainBlockByNode(node.parent)
+		if err != nil {
+			return err
+		}
+		isTreasuryEnabled, err := b.isTreasuryAgendaActive(node.parent)
+		if err != nil {
+			return err
+		}
+
+		// Replay the block against a view of the utxo set as of its
+		// parent, store the resulting spend journal, and add the
+		// modified entries to the cache.
+		view := NewUtxoViewpoint(b)
+		view.SetBestHash(&node.parent.hash)
+		stxos := make([]spentTxOut, 0, countSpentOutputs(block,
+			isTreasuryEnabled))
+		err = view.connectBlock(b.db, block, parent, &stxos,
+			isTreasuryEnabled)
+		if err != nil {
+			return err
+		}
+		err = b.db.Update(func(dbTx database.Tx) error {
+			return dbPutSpendJournalEntry(dbTx, &node.hash, stxos)
+		})
+		if err != nil {
+			return err
+		}
+		c.commit(view)
+		if err := c.maybeFlush(&node.hash, node.height, false); err != nil {
+			return err
+		}
+
+		if node.height%rebuildProgressInterval == 0 {
+			log.Infof("Rebuilt utxo set through height %d", node.height)
+		}
+	}
+	if err := c.maybeFlush(&tip.hash, tip.height, true); err != nil {
+		return err
+	}
+
+	// Calculate and store the statistics of the rebuilt utxo set.
+	log.Info("Calculating utxo set statistics...")
+	var stats *utxoSetStats
+	err = b.db.View(func(dbTx database.Tx) error {
+		var err error
+		stats, err = dbCalcUtxoSetStats(ctx, dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSetStats(dbTx, &tip.hash, stats)
+	})
+	if err != nil {
+		return err
+	}
+	b.utxoStats = stats
+
+	log.Infof("Rebuilt the utxo set and spend journals through block %v "+
+		"(height %d)", tip.hash, tip.height)
+	return nil
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestVerifyIntegrity ensures verifying the integrity of the chain state
+// detects corrupted spend journals, utxo entries, and utxo set statistics and
+// that repairing rebuilds them to match the originals.
+func TestVerifyIntegrity(t *testing.T) {
+	const (
+		// voteBitNo and voteBitYes represent no and yes votes, respectively, on
+		// whether or not to approve the previous block.
+		voteBitNo  = 0x0000
+		voteBitYes = 0x0001
+	)
+
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "integritytest")
+	defer teardownFunc()
+
+	// Generate enough blocks to have tickets voting followed by a block that
+	// spends from the regular tree and a block that disapproves it.
+	g.AdvanceToStakeValidationHeight()
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("b0", &outs[0], outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+	b0Hash := g.Tip().BlockHash()
+	outs = g.OldestCoinbaseOuts()
+	g.NextBlock("b1", nil, outs[1:], func(b *wire.MsgBlock) {
+		b.Header.VoteBits &^= voteBitYes
+		for i := 0; i < 5; i++ {
+			g.ReplaceVoteBitsN(i, voteBitNo)(b)
+		}
+	})
+	g.AssertTipDisapprovesPrevious()
+	g.AcceptTipBlock()
+	g.NextBlock("b2", nil, nil)
+	g.AcceptTipBlock()
+	tipHeight := int64(g.Tip().Header.Height)
+
+	// verify verifies the integrity of the chain state and ensures the
+	// expected number of issues were found.
+	verify := func(repair bool, wantIssues int) *IntegrityResult {
+		t.Helper()
+
+		result, err := g.chain.VerifyIntegrity(context.Background(), repair)
+		if err != nil {
+			t.Fatalf("unexpected error verifying integrity: %v", err)
+		}
+		if result.NumBlocks != uint64(tipHeight) {
+			t.Fatalf("unexpected number of blocks checked -- got %d, want "+
+				"%d", result.NumBlocks, tipHeight)
+		}
+		if len(result.Issues) != wantIssues {
+			t.Fatalf("unexpected number of issues -- got %d, want %d: %+v",
+				len(result.Issues), wantIssues, result.Issues)
+		}
+		return result
+	}
+
+	// Ensure there are no issues with the intact chain state.
+	result := verify(false, 0)
+	if result.NumUtxoEntries == 0 {
+		t.Fatal("no utxo entries were checked")
+	}
+
+	// Save the current utxo set, spend journal for b0, and statistics so
+	// they can be compared against the rebuilt versions.
+	wantStats := *g.chain.utxoStats
+	wantUtxos := make(map[chainhash.Hash][]byte)
+	var wantJournal []byte
+	err := g.chain.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		wantJournal = meta.Bucket(spendJournalBucketName).Get(b0Hash[:])
+		wantJournal = append([]byte(nil), wantJournal...)
+		return meta.Bucket(utxoSetBucketName).ForEach(func(k, v []byte) error {
+			var hash chainhash.Hash
+			copy(hash[:], k)
+			wantUtxos[hash] = append([]byte(nil), v...)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("unexpected error loading chain state: %v", err)
+	}
+	if len(wantJournal) == 0 {
+		t.Fatal("spend journal for b0 does not exist")
+	}
+
+	// Remove the spend journal for b0, replace a utxo entry with the entry
+	// for a different transaction, and remove another utxo entry.
+	var corruptHash, copiedHash, removedHash chainhash.Hash
+	var numSelected int
+	for hash := range wantUtxos {
+		switch numSelected {
+		case 0:
+			corruptHash = hash
+		case 1:
+			copiedHash = hash
+		case 2:
+			removedHash = hash
+		}
+		numSelected++
+	}
+	if numSelected < 3 {
+		t.Fatalf("not enough utxo entries -- got %d, want 3", numSelected)
+	}
+	err = g.chain.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		err := meta.Bucket(spendJournalBucketName).Delete(b0Hash[:])
+		if err != nil {
+			return err
+		}
+		utxoBucket := meta.Bucket(utxoSetBucketName)
+		err = utxoBucket.Put(corruptHash[:], wantUtxos[copiedHash])
+		if err != nil {
+			return err
+		}
+		return utxoBucket.Delete(removedHash[:])
+	})
+	if err != nil {
+		t.Fatalf("unexpected error corrupting chain state: %v", err)
+	}
+
+	// Ensure the issues are detected without being repaired.
+	wantIssues := map[IntegrityIssueKind]chainhash.Hash{
+		IntegrityIssueUtxo:         corruptHash,
+		IntegrityIssueSpendJournal: b0Hash,
+		IntegrityIssueUtxoStats:    g.Tip().BlockHash(),
+	}
+	result = verify(false, len(wantIssues))
+	for i, issue := range result.Issues {
+		if issue.Hash != wantIssues[issue.Kind] || issue.Repaired {
+			t.Fatalf("unexpected issue #%d: %+v", i, issue)
+		}
+	}
+
+	// Ensure repairing rebuilds the chain state.
+	result = verify(true, len(wantIssues))
+	if !result.Rebuilt {
+		t.Fatal("chain state was not rebuilt")
+	}
+	for i, issue := range result.Issues {
+		if !issue.Repaired {
+			t.Fatalf("issue #%d was not repaired: %+v", i, issue)
+		}
+	}
+	verify(false, 0)
+
+	// Ensure the rebuilt chain state matches the original.
+	if *g.chain.utxoStats != wantStats {
+		t.Fatalf("mismatched utxo stats -- got %+v, want %+v",
+			*g.chain.utxoStats, wantStats)
+	}
+	err = g.chain.db.View(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		gotJournal := meta.Bucket(spendJournalBucketName).Get(b0Hash[:])
+		if !bytes.Equal(gotJournal, wantJournal) {
+			t.Errorf("mismatched spend journal for b0 -- got %x, want %x",
+				gotJournal, wantJournal)
+		}
+		var numUtxos int
+		err := meta.Bucket(utxoSetBucketName).ForEach(func(k, v []byte) error {
+			var hash chainhash.Hash
+			copy(hash[:], k)
+			if !bytes.Equal(v, wantUtxos[hash]) {
+				t.Errorf("mismatched utxo entry for %v -- got %x, want "+
+					"%x", hash, v, wantUtxos[hash])
+			}
+			numUtxos++
+			return nil
+		})
+		if numUtxos != len(wantUtxos) {
+			t.Errorf("unexpected number of utxo entries -- got %d, want "+
+				"%d", numUtxos, len(wantUtxos))
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatalf("unexpected error loading chain state: %v", err)
+	}
+
+	// Ensure blocks can still be connected to the rebuilt chain state.
+	g.NextBlock("b3", nil, nil)
+	g.AcceptTipBlock()
+}
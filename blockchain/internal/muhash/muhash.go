@@ -0,0 +1,157 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+// Package muhash implements the MuHash3072 incremental multiset hash.
+//
+// A multiset hash commits to an unordered collection of elements in a way that
+// allows elements to be added and removed in any order in constant time while
+// always producing the same result for the same final collection.  It is
+// therefore suitable for maintaining a commitment to a large set, such as the
+// set of unspent transaction outputs, that is updated incrementally.
+//
+// Each element is hashed to an integer in the multiplicative group of integers
+// modulo the prime 2^3072 - 1103717 and the state is the product of all added
+// elements divided by the product of all removed elements.  Elements are mapped
+// to the group by expanding their BLAKE-256 hash to 384 bytes by hashing it
+// along with a counter.
+package muhash
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/crypto/blake256"
+)
+
+// SerializedSize is the size of a serialized MuHash3072 state.
+const SerializedSize = 384
+
+var (
+	// prime is the modulus of the group, 2^3072 - 1103717, which is the
+	// largest 3072-bit safe prime.
+	prime = func() *big.Int {
+		p := new(big.Int).Lsh(big.NewInt(1), SerializedSize*8)
+		return p.Sub(p, big.NewInt(1103717))
+	}()
+
+	// one is the identity element of the group.
+	one = big.NewInt(1)
+)
+
+// MuHash houses the state of a MuHash3072 multiset hash.  The zero value is
+// NOT usable.  Use New or Deserialize to create one.
+type MuHash struct {
+	numerator   big.Int
+	denominator big.Int
+	scratch     big.Int
+}
+
+// New returns a MuHash that commits to the empty set.
+func New() *MuHash {
+	var h MuHash
+	h.numerator.Set(one)
+	h.denominator.Set(one)
+	return &h
+}
+
+// toNum maps the provided data to an element of the group.
+func toNum(data []byte, num *big.Int) {
+	var buf [SerializedSize]byte
+	var seed [blake256.Size + 4]byte
+	digest := blake256.Sum256(data)
+	copy(seed[:], digest[:])
+	for i := 0; i < SerializedSize/blake256.Size; i++ {
+		binary.LittleEndian.PutUint32(seed[blake256.Size:], uint32(i))
+		chunk := blake256.Sum256(seed[:])
+		copy(buf[i*blake256.Size:], chunk[:])
+	}
+
+	// The expanded bytes are treated as a little-endian integer while
+	// big.Int works with big-endian, so reverse them.
+	for i, j := 0, len(buf)-1; i < j; i, j = i+1, j-1 {
+		buf[i], buf[j] = buf[j], buf[i]
+	}
+	num.SetBytes(buf[:])
+	if num.Cmp(prime) >= 0 {
+		num.Sub(num, prime)
+	}
+}
+
+// Add adds the provided data to the set the hash commits to.
+func (h *MuHash) Add(data []byte) {
+	toNum(data, &h.scratch)
+	h.numerator.Mul(&h.numerator, &h.scratch)
+	h.numerator.Mod(&h.numerator, prime)
+}
+
+// Remove removes the provided data from the set the hash commits to.  The data
+// is not required to have been added first, in which case the hash commits to
+// a set with a negative count for it that adding it later cancels out.
+func (h *MuHash) Remove(data []byte) {
+	toNum(data, &h.scratch)
+	h.denominator.Mul(&h.denominator, &h.scratch)
+	h.denominator.Mod(&h.denominator, prime)
+}
+
+// Combine updates the hash to commit to the union of the set it commits to and
+// the set the provided hash commits to.
+func (h *MuHash) Combine(other *MuHash) {
+	h.numerator.Mul(&h.numerator, &other.numerator)
+	h.numerator.Mod(&h.numerator, prime)
+	h.denominator.Mul(&h.denominator, &other.denominator)
+	h.denominator.Mod(&h.denominator, prime)
+}
+
+// normalize divides the numerator by the denominator so the state is
+// represented by the numerator alone.
+func (h *MuHash) normalize() {
+	if h.denominator.Cmp(one) == 0 {
+		return
+	}
+	h.scratch.ModInverse(&h.denominator, prime)
+	h.numerator.Mul(&h.numerator, &h.scratch)
+	h.numerator.Mod(&h.numerator, prime)
+	h.denominator.Set(one)
+}
+
+// Serialize returns the serialized state of the hash.  The state is a
+// little-endian encoding of the element of the group it commits to.
+func (h *MuHash) Serialize() [SerializedSize]byte {
+	h.normalize()
+	var serialized [SerializedSize]byte
+	b := h.numerator.Bytes()
+	for i := range b {
+		serialized[i] = b[len(b)-1-i]
+	}
+	return serialized
+}
+
+// Deserialize returns a MuHash with the provided serialized state.
+func Deserialize(serialized []byte) (*MuHash, error) {
+	if len(serialized) != SerializedSize {
+		return nil, fmt.Errorf("serialized muhash state is %d bytes "+
+			"instead of %d", len(serialized), SerializedSize)
+	}
+
+	var buf [SerializedSize]byte
+	for i := range serialized {
+		buf[SerializedSize-1-i] = serialized[i]
+	}
+	h := New()
+	h.numerator.SetBytes(buf[:])
+	if h.numerator.Sign() == 0 || h.numerator.Cmp(prime) >= 0 {
+		return nil, fmt.Errorf("serialized muhash state is not an element " +
+			"of the group")
+	}
+	return h, nil
+}
+
+// Digest returns the BLAKE-256 hash of the serialized state of the hash, which
+// is a compact commitment to the set.
+func (h *MuHash) Digest() chainhash.Hash {
+	serialized := h.Serialize()
+	return chainhash.Hash(blake256.Sum256(serialized[:]))
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package muhash
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestMuHash ensures the hash commits to the same set regardless of the order
+// elements are added and removed in and that it serializes and deserializes
+// correctly.
+func TestMuHash(t *testing.T) {
+	elements := [][]byte{
+		[]byte("element 0"),
+		[]byte("element 1"),
+		[]byte("element 2"),
+		[]byte("element 3"),
+	}
+
+	// Ensure the empty set produces the same digest as a set that had
+	// elements added and then removed.
+	empty := New()
+	h := New()
+	for _, e := range elements {
+		h.Add(e)
+	}
+	for i := len(elements) - 1; i >= 0; i-- {
+		h.Remove(elements[i])
+	}
+	if h.Digest() != empty.Digest() {
+		t.Fatalf("set with all elements removed does not match empty set")
+	}
+
+	// Ensure the order elements are added in does not matter.
+	h1, h2 := New(), New()
+	for i := range elements {
+		h1.Add(elements[i])
+		h2.Add(elements[len(elements)-1-i])
+	}
+	if h1.Digest() != h2.Digest() {
+		t.Fatalf("set depends on the order elements are added in")
+	}
+	if h1.Digest() == empty.Digest() {
+		t.Fatalf("non-empty set matches empty set")
+	}
+
+	// Ensure removing an element before adding it cancels out and that
+	// removing an element differs from the set without it.
+	h3 := New()
+	h3.Remove(elements[3])
+	for _, e := range elements {
+		h3.Add(e)
+	}
+	h4 := New()
+	for _, e := range elements[:3] {
+		h4.Add(e)
+	}
+	if h3.Digest() != h4.Digest() {
+		t.Fatalf("removing element before adding it did not cancel out")
+	}
+	if h3.Digest() == h1.Digest() {
+		t.Fatalf("set with removed element matches set with it")
+	}
+
+	// Ensure combining sets produces the union.
+	h5, h6 := New(), New()
+	h5.Add(elements[0])
+	h5.Add(elements[1])
+	h6.Add(elements[2])
+	h6.Add(elements[3])
+	h6.Remove(elements[3])
+	h5.Combine(h6)
+	if h5.Digest() != h4.Digest() {
+		t.Fatalf("combined set does not match union")
+	}
+
+	// Ensure the serialized state round trips.
+	serialized := h3.Serialize()
+	h7, err := Deserialize(serialized[:])
+	if err != nil {
+		t.Fatalf("unexpected error deserializing: %v", err)
+	}
+	reserialized := h7.Serialize()
+	if !bytes.Equal(serialized[:], reserialized[:]) {
+		t.Fatalf("serialized state does not round trip")
+	}
+	h7.Add(elements[3])
+	if h7.Digest() != h1.Digest() {
+		t.Fatalf("deserialized state does not match original set")
+	}
+
+	// Ensure invalid serialized states are rejected.
+	if _, err := Deserialize(serialized[1:]); err == nil {
+		t.Fatalf("deserialized state with wrong size")
+	}
+	var zero [SerializedSize]byte
+	if _, err := Deserialize(zero[:]); err == nil {
+		t.Fatalf("deserialized state that is not a group element")
+	}
+}
@@ -6,7 +6,19 @@ package blockchain
 
 import (
 	"math"
+	"sync"
 	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+)
+
+const (
+	// minPruneRetentionBlocks is the minimum number of blocks prior to the
+	// current tip that always have their data retained when pruning block
+	// data.  It is in addition to the blocks required to validate new
+	// blocks and provides a buffer for reorgs and serving recent blocks.
+	minPruneRetentionBlocks = 288
 )
 
 // poissonConfidenceSecs returns the number of seconds it will take to produce
@@ -88,3 +100,168 @@ func (c *chainPruner) pruneChainIfNeeded() {
 	c.lastPruneTime = now
 	c.chain.pruneStakeNodes()
 }
+
+// pruneRetentionDepth returns the number of blocks prior to the current tip
+// that must have their data retained when pruning block data.  This ensures
+// the data for all blocks needed to validate new blocks, such as those inside
+// the treasury spend voting window, remains available.
+func (b *BlockChain) pruneRetentionDepth() int64 {
+	params := b.chainParams
+	depth := int64(params.TicketMaturity)
+	tspendWindow := int64(params.TreasuryVoteInterval *
+		params.TreasuryVoteIntervalMultiplier)
+	if tspendWindow > depth {
+		depth = tspendWindow
+	}
+	return depth + minPruneRetentionBlocks
+}
+
+// pruneBlockData removes the data for the oldest blocks that are deep enough
+// in the main chain relative to the provided node as needed to keep the total
+// size of all stored block data near the configured prune target.  It returns
+// the new height of the most recently pruned block or -1 when no blocks were
+// pruned.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) pruneBlockData(dbTx database.Tx, node *blockNode) (int64, error) {
+	// Retain all blocks while the history that leads to a loaded utxo set
+	// snapshot is being validated since the historical blocks are stored as
+	// they are validated and are needed to validate later ones.
+	if b.historyValidation != nil {
+		return -1, nil
+	}
+
+	// Retain all blocks while utxo set snapshots are being written since
+	// they include blocks from the database view they were started with.
+	if b.snapshotDumps > 0 {
+		return -1, nil
+	}
+
+	// Retain all blocks while pruning is suspended, such as while a database
+	// backup is in progress.
+	if b.pruneSuspensions > 0 {
+		return -1, nil
+	}
+
+	pruneHeight := node.height - b.pruneRetentionDepth()
+
+	// Retain the blocks needed to recover the utxo set by replaying the
+	// blocks after the most recent utxo cache flush.
+	_, lastFlushHeight := b.utxoCache.lastFlushed()
+	if pruneHeight > lastFlushHeight {
+		pruneHeight = lastFlushHeight
+	}
+
+	// Retain the blocks the optional indexes have not indexed yet since they
+	// are synced asynchronously and need the blocks in order to catch up.
+	if b.indexManager != nil {
+		lowestTip, err := b.indexManager.LowestTipHeight(dbTx)
+		if err != nil {
+			return -1, err
+		}
+		if lowestTip >= 0 && pruneHeight > lowestTip {
+			pruneHeight = lowestTip
+		}
+	}
+	if pruneHeight <= 0 {
+		return -1, nil
+	}
+
+	pruner, ok := dbTx.(database.BlockPruner)
+	if !ok {
+		str := "database backend does not support pruning block data"
+		return -1, AssertError(str)
+	}
+	pruned, err := pruner.PruneBlocks(b.pruneTarget, uint32(pruneHeight))
+	if err != nil {
+		return -1, err
+	}
+	if len(pruned) == 0 {
+		return -1, nil
+	}
+
+	// Determine the height of the most recent block that was pruned.
+	b.prunedHeightLock.RLock()
+	prunedHeight := b.prunedHeight
+	b.prunedHeightLock.RUnlock()
+	for i := range pruned {
+		prunedNode := b.index.LookupNode(&pruned[i])
+		if prunedNode != nil && prunedNode.height > prunedHeight {
+			prunedHeight = prunedNode.height
+		}
+	}
+	if err := dbPutPrunedHeight(dbTx, prunedHeight); err != nil {
+		return -1, err
+	}
+
+	log.Debugf("Pruned data for %d blocks (pruned height %d)", len(pruned),
+		prunedHeight)
+	return prunedHeight, nil
+}
+
+// SuspendBlockPruning prevents block data from being pruned until the returned
+// function is invoked.  It is intended to be used by operations that read all
+// stored block data from a database transaction that outlives the processing of
+// new blocks, such as backing up the database, since pruning removes block data
+// out from under them otherwise.  The returned function must be invoked exactly
+// once.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) SuspendBlockPruning() func() {
+	b.chainLock.Lock()
+	b.pruneSuspensions++
+	b.chainLock.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			b.chainLock.Lock()
+			b.pruneSuspensions--
+			b.chainLock.Unlock()
+		})
+	}
+}
+
+// maybePrunedBlockError returns an error with the kind ErrBlockPruned when the
+// provided error from loading the data for the provided node indicates the
+// block does not exist and its data was removed due to pruning.  Otherwise,
+// the provided error is returned unmodified.
+func (b *BlockChain) maybePrunedBlockError(node *blockNode, err error) error {
+	if !database.IsError(err, database.ErrBlockNotFound) {
+		return err
+	}
+
+	b.prunedHeightLock.RLock()
+	prunedHeight := b.prunedHeight
+	b.prunedHeightLock.RUnlock()
+	if node.height > prunedHeight {
+		return err
+	}
+	return blockPrunedError(&node.hash)
+}
+
+// IsBlockPruned returns whether or not the data for the block with the given
+// hash was previously stored and has since been removed due to pruning.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) IsBlockPruned(hash *chainhash.Hash) bool {
+	node := b.index.LookupNode(hash)
+	if node == nil || !b.index.NodeStatus(node).HaveData() {
+		return false
+	}
+
+	b.prunedHeightLock.RLock()
+	prunedHeight := b.prunedHeight
+	b.prunedHeightLock.RUnlock()
+	if node.height > prunedHeight {
+		return false
+	}
+
+	var exists bool
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		exists, err = dbTx.HasBlock(hash)
+		return err
+	})
+	return err == nil && !exists
+}
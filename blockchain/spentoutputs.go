@@ -0,0 +1,164 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+// SpentOutput describes a previous transaction output that is spent by a
+// transaction input in a block along with the input that spends it.
+type SpentOutput struct {
+	// SpendingTx is the hash of the transaction that spends the output.
+	SpendingTx chainhash.Hash
+
+	// SpendingTree is the transaction tree of the spending transaction.
+	SpendingTree int8
+
+	// InputIndex is the index of the spending transaction input.
+	InputIndex uint32
+
+	// PrevOut is the previous transaction output that is spent.
+	PrevOut wire.OutPoint
+
+	// Amount is the amount of the spent output in atoms.
+	Amount int64
+
+	// ScriptVersion and PkScript are the version and public key script of the
+	// spent output.
+	ScriptVersion uint16
+	PkScript      []byte
+
+	// BlockHeight and BlockIndex are the height of the block that contains
+	// the transaction which created the spent output and the index of that
+	// transaction within it.
+	BlockHeight int64
+	BlockIndex  uint32
+}
+
+// FetchSpentOutputs returns the previous transaction outputs spent by the
+// block with the given hash in the main chain by using its spend journal.
+//
+// The outputs are returned in the order the block spends them, which means
+// the outputs spent by the stake transaction tree come first followed by those
+// spent by the regular transaction tree.  Inputs that do not spend a previous
+// output, such as the coinbase, stakebases, treasurybases, and treasury spends,
+// are not included.
+//
+// An error that wraps ErrUnknownBlock will be returned when the block is not
+// known, ErrBlockPruned when its data has been pruned, and ErrNoSpendJournal
+// when its spend journal is not available.  An error is also returned when the
+// block is not part of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchSpentOutputs(hash *chainhash.Hash) ([]SpentOutput, error) {
+	node := b.index.LookupNode(hash)
+	if node == nil || !b.index.NodeStatus(node).HaveData() {
+		return nil, unknownBlockError(hash)
+	}
+
+	// The spend journal serialization depends on whether or not the treasury
+	// agenda is active for the block.
+	var isTreasuryEnabled bool
+	if node.parent != nil {
+		b.chainLock.Lock()
+		var err error
+		isTreasuryEnabled, err = b.isTreasuryAgendaActive(node.parent)
+		b.chainLock.Unlock()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Spend journals only exist for blocks in the main chain, so hold the
+	// chain lock to prevent the block from being disconnected while it is
+	// loaded.
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	block, err := b.fetchMainChainBlockByNode(node)
+	if err != nil {
+		return nil, err
+	}
+	numSpent := countSpentOutputs(block, isTreasuryEnabled)
+	if numSpent == 0 {
+		return nil, nil
+	}
+
+	var stxos []spentTxOut
+	err = b.db.View(func(dbTx database.Tx) error {
+		// The spend journal is not available for blocks prior to a loaded
+		// utxo set snapshot until the history has been validated.
+		spendBucket := dbTx.Metadata().Bucket(spendJournalBucketName)
+		if spendBucket.Get(hash[:]) == nil {
+			str := fmt.Sprintf("no spend journal available for block %s",
+				hash)
+			return contextError(ErrNoSpendJournal, str)
+		}
+
+		var err error
+		stxos, err = dbFetchSpendJournalEntry(dbTx, block, isTreasuryEnabled)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(stxos) != numSpent {
+		str := fmt.Sprintf("spend journal for block %s has %d entries "+
+			"instead of %d", hash, len(stxos), numSpent)
+		return nil, AssertError(str)
+	}
+
+	// Match the spent outputs with the inputs that spend them in the same
+	// order they are stored in the spend journal.
+	spent := make([]SpentOutput, 0, numSpent)
+	addSpent := func(tx *wire.MsgTx, tree int8, txInIdx int) {
+		txIn := tx.TxIn[txInIdx]
+		stxo := &stxos[len(spent)]
+		pkScript := stxo.pkScript
+		if stxo.compressed {
+			pkScript = decompressScript(pkScript, currentCompressionVersion)
+		}
+		spent = append(spent, SpentOutput{
+			SpendingTx:    tx.TxHash(),
+			SpendingTree:  tree,
+			InputIndex:    uint32(txInIdx),
+			PrevOut:       txIn.PreviousOutPoint,
+			Amount:        stxo.amount,
+			ScriptVersion: stxo.scriptVersion,
+			PkScript:      pkScript,
+			BlockHeight:   int64(stxo.height),
+			BlockIndex:    stxo.index,
+		})
+	}
+	msgBlock := block.MsgBlock()
+	for i, tx := range msgBlock.STransactions {
+		// Ignore treasury base and tspends since they have no inputs.
+		if isTreasuryEnabled && (i == 0 || stake.IsTSpend(tx)) {
+			continue
+		}
+
+		isVote := stake.IsSSGen(tx, isTreasuryEnabled)
+		for txInIdx := range tx.TxIn {
+			// Ignore stakebase since it has no input.
+			if isVote && txInIdx == 0 {
+				continue
+			}
+			addSpent(tx, wire.TxTreeStake, txInIdx)
+		}
+	}
+	for _, tx := range msgBlock.Transactions[1:] {
+		for txInIdx := range tx.TxIn {
+			addSpent(tx, wire.TxTreeRegular, txInIdx)
+		}
+	}
+
+	return spent, nil
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestFetchSpentOutputs ensures the previous outputs spent by blocks are
+// returned from the spend journal in the expected order with the expected
+// details.
+func TestFetchSpentOutputs(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "spentoutputstest")
+	defer teardownFunc()
+
+	// Generate enough blocks to have tickets voting followed by a block that
+	// spends from the regular tree and a side chain block.
+	g.AdvanceToStakeValidationHeight()
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("b0", &outs[0], outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+	g.NextBlock("b1", nil, nil)
+	g.AcceptTipBlock()
+	g.SetTip("b0")
+	g.NextBlock("b1a", nil, nil)
+	g.AcceptedToSideChainWithExpectedTip("b1")
+
+	// Ensure the spent outputs for b0 match the outputs referenced by its
+	// inputs in the order they are spent.
+	b0 := g.BlockByName("b0")
+	b0Hash := b0.Header.BlockHash()
+	spent, err := g.chain.FetchSpentOutputs(&b0Hash)
+	if err != nil {
+		t.Fatalf("unexpected error fetching spent outputs: %v", err)
+	}
+	var wantIdx int
+	checkInput := func(tx *wire.MsgTx, tree int8, txInIdx int) {
+		t.Helper()
+
+		if wantIdx >= len(spent) {
+			t.Fatalf("missing spent output for input %d of %v", txInIdx,
+				tx.TxHash())
+		}
+		got := &spent[wantIdx]
+		wantIdx++
+		txIn := tx.TxIn[txInIdx]
+		if got.SpendingTx != tx.TxHash() || got.SpendingTree != tree ||
+			got.InputIndex != uint32(txInIdx) ||
+			got.PrevOut != txIn.PreviousOutPoint {
+
+			t.Fatalf("mismatched spender for input %d of %v: %+v", txInIdx,
+				tx.TxHash(), got)
+		}
+		if got.Amount != txIn.ValueIn ||
+			got.BlockHeight != int64(txIn.BlockHeight) ||
+			got.BlockIndex != txIn.BlockIndex {
+
+			t.Fatalf("mismatched spent output details for input %d of %v: "+
+				"%+v", txInIdx, tx.TxHash(), got)
+		}
+
+		// Ensure the script matches the one in the block that created it.
+		prevBlock, err := g.chain.BlockByHeight(got.BlockHeight)
+		if err != nil {
+			t.Fatalf("unexpected error fetching block: %v", err)
+		}
+		prevTxns := prevBlock.MsgBlock().Transactions
+		if got.PrevOut.Tree == wire.TxTreeStake {
+			prevTxns = prevBlock.MsgBlock().STransactions
+		}
+		prevOut := prevTxns[got.BlockIndex].TxOut[got.PrevOut.Index]
+		if !bytes.Equal(got.PkScript, prevOut.PkScript) ||
+			got.ScriptVersion != prevOut.Version {
+
+			t.Fatalf("mismatched script for input %d of %v -- got %x, want "+
+				"%x", txInIdx, tx.TxHash(), got.PkScript, prevOut.PkScript)
+		}
+	}
+	var numVotes, numRegular int
+	for _, tx := range b0.STransactions {
+		isVote := len(tx.TxIn) == 2 && tx.TxIn[0].PreviousOutPoint.Hash ==
+			(chainhash.Hash{})
+		for txInIdx := range tx.TxIn {
+			if isVote && txInIdx == 0 {
+				continue
+			}
+			checkInput(tx, wire.TxTreeStake, txInIdx)
+		}
+		if isVote {
+			numVotes++
+		}
+	}
+	for _, tx := range b0.Transactions[1:] {
+		for txInIdx := range tx.TxIn {
+			checkInput(tx, wire.TxTreeRegular, txInIdx)
+			numRegular++
+		}
+	}
+	if wantIdx != len(spent) {
+		t.Fatalf("unexpected number of spent outputs -- got %d, want %d",
+			len(spent), wantIdx)
+	}
+	if numVotes == 0 || numRegular == 0 {
+		t.Fatalf("block does not spend from both trees -- votes %d, regular "+
+			"inputs %d", numVotes, numRegular)
+	}
+
+	// Ensure blocks that are not in the main chain and unknown blocks are
+	// rejected.
+	b1a := g.BlockByName("b1a")
+	b1aHash := b1a.Header.BlockHash()
+	_, err = g.chain.FetchSpentOutputs(&b1aHash)
+	if err == nil {
+		t.Fatal("did not receive error for side chain block")
+	}
+	_, err = g.chain.FetchSpentOutputs(&chainhash.Hash{0x01})
+	if !errors.Is(err, ErrUnknownBlock) {
+		t.Fatalf("unexpected error for unknown block -- got %v, want %v",
+			err, ErrUnknownBlock)
+	}
+}
@@ -474,6 +474,20 @@ func deserializeBlockUndoData(b []byte) ([]UndoTicketData, error) {
 	return utds, nil
 }
 
+// SerializeBlockUndoData serializes an entire list of ticket data according
+// to the same format used to store block undo data in the database.  Since
+// each entry houses the hash, height, and state flags of a ticket, the format
+// is also suitable for serializing the tickets in the ticket buckets.
+func SerializeBlockUndoData(utds []UndoTicketData) []byte {
+	return serializeBlockUndoData(utds)
+}
+
+// DeserializeBlockUndoData deserializes a list of ticket data that was
+// serialized with SerializeBlockUndoData.
+func DeserializeBlockUndoData(b []byte) ([]UndoTicketData, error) {
+	return deserializeBlockUndoData(b)
+}
+
 // DbFetchBlockUndoData fetches block undo data from the database.
 func DbFetchBlockUndoData(dbTx database.Tx, height uint32) ([]UndoTicketData, error) {
 	meta := dbTx.Metadata()
@@ -563,6 +577,18 @@ func deserializeTicketHashes(b []byte) (TicketHashes, error) {
 	return ths, nil
 }
 
+// SerializeTicketHashes serializes a list of ticket hashes according to the
+// same format used to store them in the database.
+func SerializeTicketHashes(ths TicketHashes) []byte {
+	return serializeTicketHashes(ths)
+}
+
+// DeserializeTicketHashes deserializes a list of ticket hashes that was
+// serialized with SerializeTicketHashes.
+func DeserializeTicketHashes(b []byte) (TicketHashes, error) {
+	return deserializeTicketHashes(b)
+}
+
 // DbFetchNewTickets fetches new tickets for a mainchain block from the database.
 func DbFetchNewTickets(dbTx database.Tx, height uint32) (TicketHashes, error) {
 	meta := dbTx.Metadata()
@@ -0,0 +1,341 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package stake
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/blockchain/stake/v3/internal/dbnamespace"
+	"github.com/decred/dcrd/blockchain/stake/v3/internal/ticketdb"
+	"github.com/decred/dcrd/blockchain/stake/v3/internal/tickettreap"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+// maxSnapshotFieldSize is the maximum size of an individual serialized field
+// in a ticket database snapshot.  It is well above the size required to house
+// every ticket in the ticket buckets and protects against massive allocations
+// due to malformed data.
+const maxSnapshotFieldSize = 1 << 28
+
+// -----------------------------------------------------------------------------
+// A ticket database snapshot houses the state of the ticket database as of a
+// specific block along with the block undo and new ticket data for the blocks
+// prior to it that are needed to disconnect them.
+//
+// The serialized format is:
+//
+//   <height><next winners><live><missed><revoked><num heights>[<height data>]
+//
+//   Field          Type      Size
+//   height         uint32    4 bytes
+//   next winners   field     variable
+//   live           field     variable
+//   missed         field     variable
+//   revoked        field     variable
+//   num heights    uint32    4 bytes
+//   height data
+//     height       uint32    4 bytes
+//     undo data    field     variable
+//     new tickets  field     variable
+//
+// Each field is serialized as its length encoded as a uint32 followed by the
+// data.  The next winners and new tickets fields use the ticket hashes format
+// while the remaining fields use the block undo data format of the ticket
+// database.  All integers are encoded in little endian.
+// -----------------------------------------------------------------------------
+
+// writeSnapshotUint32 writes the provided value to the writer.
+func writeSnapshotUint32(w io.Writer, val uint32) error {
+	var buf [4]byte
+	dbnamespace.ByteOrder.PutUint32(buf[:], val)
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// readSnapshotUint32 reads a value written with writeSnapshotUint32 from the
+// reader.
+func readSnapshotUint32(r io.Reader) (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return dbnamespace.ByteOrder.Uint32(buf[:]), nil
+}
+
+// writeSnapshotField writes the length of the provided data followed by the
+// data itself to the writer.
+func writeSnapshotField(w io.Writer, data []byte) error {
+	if err := writeSnapshotUint32(w, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readSnapshotField reads a field written with writeSnapshotField from the
+// reader.
+func readSnapshotField(r io.Reader) ([]byte, error) {
+	size, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxSnapshotFieldSize {
+		str := fmt.Sprintf("snapshot field size of %d exceeds the max "+
+			"allowed size of %d", size, maxSnapshotFieldSize)
+		return nil, stakeRuleError(ErrDatabaseCorrupt, str)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// treapTicketData returns the tickets in the provided treap along with their
+// heights and state flags ordered by their hash.
+func treapTicketData(t *tickettreap.Immutable) []ticketdb.UndoTicketData {
+	tickets := make([]ticketdb.UndoTicketData, 0, t.Len())
+	t.ForEach(func(k tickettreap.Key, v *tickettreap.Value) bool {
+		tickets = append(tickets, ticketdb.UndoTicketData{
+			TicketHash:   chainhash.Hash(k),
+			TicketHeight: v.Height,
+			Missed:       v.Missed,
+			Revoked:      v.Revoked,
+			Spent:        v.Spent,
+			Expired:      v.Expired,
+		})
+		return true
+	})
+	return tickets
+}
+
+// bestStateWinners returns the next winners for the provided node as they are
+// stored in the best state of the ticket database.
+func bestStateWinners(node *Node) []chainhash.Hash {
+	nextWinners := make([]chainhash.Hash, int(node.params.VotesPerBlock()))
+	if node.height >= uint32(node.params.StakeValidationBeginHeight()-1) {
+		copy(nextWinners, node.nextWinners)
+	}
+	return nextWinners
+}
+
+// GenesisNode returns a new stake node for the genesis block.  It is useful
+// for reconstructing the stake node for a block from the genesis block when
+// the ticket database does not contain the data required to do so.
+func GenesisNode(params StakeParams) *Node {
+	return genesisNode(params)
+}
+
+// WriteSnapshot serializes the ticket database state for the provided node,
+// which must be the current best node of the ticket database, to the writer.
+// The block undo and new ticket data for all heights starting with the provided
+// start height through the height of the node are also included so the blocks
+// are able to be disconnected once the snapshot is loaded.
+func WriteSnapshot(w io.Writer, dbTx database.Tx, node *Node, startHeight uint32) error {
+	state, err := ticketdb.DbFetchBestState(dbTx)
+	if err != nil {
+		return err
+	}
+	if state.Height != node.height {
+		str := fmt.Sprintf("stake node height %d does not match ticket "+
+			"database height %d", node.height, state.Height)
+		return stakeRuleError(ErrDatabaseCorrupt, str)
+	}
+	if startHeight > node.height {
+		startHeight = node.height
+	}
+
+	// Serialize the height, next winners, and the live, missed, and revoked
+	// tickets.
+	if err := writeSnapshotUint32(w, node.height); err != nil {
+		return err
+	}
+	winners := ticketdb.SerializeTicketHashes(bestStateWinners(node))
+	if err := writeSnapshotField(w, winners); err != nil {
+		return err
+	}
+	treaps := []*tickettreap.Immutable{node.liveTickets, node.missedTickets,
+		node.revokedTickets}
+	for _, treap := range treaps {
+		tickets := ticketdb.SerializeBlockUndoData(treapTicketData(treap))
+		if err := writeSnapshotField(w, tickets); err != nil {
+			return err
+		}
+	}
+
+	// Serialize the block undo and new ticket data for each height.
+	numHeights := node.height - startHeight + 1
+	if err := writeSnapshotUint32(w, numHeights); err != nil {
+		return err
+	}
+	for height := startHeight; height <= node.height; height++ {
+		undoData, err := ticketdb.DbFetchBlockUndoData(dbTx, height)
+		if err != nil {
+			return err
+		}
+		newTickets, err := ticketdb.DbFetchNewTickets(dbTx, height)
+		if err != nil {
+			return err
+		}
+
+		if err := writeSnapshotUint32(w, height); err != nil {
+			return err
+		}
+		err = writeSnapshotField(w, ticketdb.SerializeBlockUndoData(undoData))
+		if err != nil {
+			return err
+		}
+		err = writeSnapshotField(w, ticketdb.SerializeTicketHashes(newTickets))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadSnapshot replaces the ticket database with the state serialized by
+// WriteSnapshot and returns the best node for the block with the provided hash
+// and header the snapshot was created at.  The database must already have been
+// initialized with InitDatabaseState.
+func LoadSnapshot(r io.Reader, dbTx database.Tx, params StakeParams, hash chainhash.Hash, header wire.BlockHeader) (*Node, error) {
+	height, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if height != header.Height {
+		str := fmt.Sprintf("snapshot height %d does not match block height "+
+			"%d", height, header.Height)
+		return nil, stakeRuleError(ErrDatabaseCorrupt, str)
+	}
+	serializedWinners, err := readSnapshotField(r)
+	if err != nil {
+		return nil, err
+	}
+	nextWinners, err := ticketdb.DeserializeTicketHashes(serializedWinners)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace the existing ticket database with a new one.
+	if err := ticketdb.DbRemoveAllBuckets(dbTx); err != nil {
+		return nil, err
+	}
+	if err := ticketdb.DbCreate(dbTx); err != nil {
+		return nil, err
+	}
+
+	// Load the live, missed, and revoked tickets into their buckets.
+	buckets := [][]byte{dbnamespace.LiveTicketsBucketName,
+		dbnamespace.MissedTicketsBucketName,
+		dbnamespace.RevokedTicketsBucketName}
+	var numTickets [3]int
+	for i, bucket := range buckets {
+		serialized, err := readSnapshotField(r)
+		if err != nil {
+			return nil, err
+		}
+		tickets, err := ticketdb.DeserializeBlockUndoData(serialized)
+		if err != nil {
+			return nil, err
+		}
+		for j := range tickets {
+			ticket := &tickets[j]
+			err := ticketdb.DbPutTicket(dbTx, bucket, &ticket.TicketHash,
+				ticket.TicketHeight, ticket.Missed, ticket.Revoked,
+				ticket.Spent, ticket.Expired)
+			if err != nil {
+				return nil, err
+			}
+		}
+		numTickets[i] = len(tickets)
+	}
+
+	// Load the block undo and new ticket data for each height.
+	numHeights, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	for i := uint32(0); i < numHeights; i++ {
+		dataHeight, err := readSnapshotUint32(r)
+		if err != nil {
+			return nil, err
+		}
+		if dataHeight > height {
+			str := fmt.Sprintf("snapshot contains data for height %d "+
+				"which is after the snapshot height %d", dataHeight,
+				height)
+			return nil, stakeRuleError(ErrDatabaseCorrupt, str)
+		}
+		serialized, err := readSnapshotField(r)
+		if err != nil {
+			return nil, err
+		}
+		undoData, err := ticketdb.DeserializeBlockUndoData(serialized)
+		if err != nil {
+			return nil, err
+		}
+		serialized, err = readSnapshotField(r)
+		if err != nil {
+			return nil, err
+		}
+		newTickets, err := ticketdb.DeserializeTicketHashes(serialized)
+		if err != nil {
+			return nil, err
+		}
+
+		err = ticketdb.DbPutBlockUndoData(dbTx, dataHeight, undoData)
+		if err != nil {
+			return nil, err
+		}
+		err = ticketdb.DbPutNewTickets(dbTx, dataHeight, newTickets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Write the best state and load the best node from the database which
+	// also ensures the state is consistent.
+	err = ticketdb.DbPutBestState(dbTx, ticketdb.BestChainState{
+		Hash:        hash,
+		Height:      height,
+		Live:        uint32(numTickets[0]),
+		Missed:      uint64(numTickets[1]),
+		Revoked:     uint64(numTickets[2]),
+		PerBlock:    params.VotesPerBlock(),
+		NextWinners: nextWinners,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return LoadBestNode(dbTx, height, hash, header, params)
+}
+
+// WriteHistoricalNode writes the block undo and new ticket data for the
+// provided node, which must be an ancestor of the current best node of the
+// ticket database, to the database.  This allows the data for blocks prior to
+// a loaded snapshot to be populated once their history has been validated.
+func WriteHistoricalNode(dbTx database.Tx, node *Node) error {
+	state, err := ticketdb.DbFetchBestState(dbTx)
+	if err != nil {
+		return err
+	}
+	if node.height >= state.Height {
+		str := fmt.Sprintf("stake node height %d is not prior to the "+
+			"ticket database height %d", node.height, state.Height)
+		return stakeRuleError(ErrDatabaseCorrupt, str)
+	}
+
+	err = ticketdb.DbPutBlockUndoData(dbTx, node.height,
+		node.databaseUndoUpdate)
+	if err != nil {
+		return err
+	}
+	return ticketdb.DbPutNewTickets(dbTx, node.height,
+		node.databaseBlockTickets)
+}
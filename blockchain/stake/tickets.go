@@ -302,6 +302,14 @@ func InitDatabaseState(dbTx database.Tx, params StakeParams, genesisHash *chainh
 	return genesis, nil
 }
 
+// FetchBlockUndoData loads the ticket undo data for the main chain block at
+// the provided height from the database.  The undo data contains an entry for
+// every ticket whose state was changed by the block, such as tickets that
+// matured, were spent by votes, were missed, expired, or were revoked.
+func FetchBlockUndoData(dbTx database.Tx, height uint32) (UndoTicketDataSlice, error) {
+	return ticketdb.DbFetchBlockUndoData(dbTx, height)
+}
+
 // LoadBestNode is used when the blockchain is initialized, to get the initial
 // stake node from the database bucket.  The blockchain must pass the height
 // and the blockHash to confirm that the ticket database is on the same
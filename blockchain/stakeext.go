@@ -7,7 +7,6 @@ package blockchain
 
 import (
 	"github.com/decred/dcrd/chaincfg/chainhash"
-	"github.com/decred/dcrd/database/v2"
 	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrd/txscript/v3"
 )
@@ -107,31 +106,28 @@ func (b *BlockChain) TicketsWithAddress(address dcrutil.Address, isTreasuryEnabl
 
 	tickets := sn.LiveTickets()
 
-	var ticketsWithAddr []chainhash.Hash
-	err := b.db.View(func(dbTx database.Tx) error {
-		for _, hash := range tickets {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
-			if err != nil {
-				return err
-			}
-
-			_, addrs, _, err :=
-				txscript.ExtractPkScriptAddrs(utxo.ScriptVersionByIndex(0),
-					utxo.PkScriptByIndex(0), b.chainParams,
-					isTreasuryEnabled)
-			if err != nil {
-				return err
-			}
-			if addrs[0].Address() == address.Address() {
-				ticketsWithAddr = append(ticketsWithAddr, hash)
-			}
-		}
-		return nil
-	})
+	// Load the ticket utxos via the utxo cache since the utxo set in the
+	// database might not include the most recent changes.
+	utxos, err := b.fetchLiveTicketUtxos(tickets)
 	if err != nil {
 		return nil, err
 	}
 
+	var ticketsWithAddr []chainhash.Hash
+	for _, hash := range tickets {
+		utxo := utxos[hash]
+		_, addrs, _, err :=
+			txscript.ExtractPkScriptAddrs(utxo.ScriptVersionByIndex(0),
+				utxo.PkScriptByIndex(0), b.chainParams,
+				isTreasuryEnabled)
+		if err != nil {
+			return nil, err
+		}
+		if addrs[0].Address() == address.Address() {
+			ticketsWithAddr = append(ticketsWithAddr, hash)
+		}
+	}
+
 	return ticketsWithAddr, nil
 }
 
@@ -220,20 +216,31 @@ func (b *BlockChain) TicketPoolValue() (dcrutil.Amount, error) {
 	sn := b.bestChain.Tip().stakeNode
 	b.chainLock.RUnlock()
 
-	var amt int64
-	err := b.db.View(func(dbTx database.Tx) error {
-		for _, hash := range sn.LiveTickets() {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
-			if err != nil {
-				return err
-			}
-
-			amt += utxo.sparseOutputs[0].amount
-		}
-		return nil
-	})
+	tickets := sn.LiveTickets()
+	utxos, err := b.fetchLiveTicketUtxos(tickets)
 	if err != nil {
 		return 0, err
 	}
+
+	var amt int64
+	for _, hash := range tickets {
+		amt += utxos[hash].sparseOutputs[0].amount
+	}
 	return dcrutil.Amount(amt), nil
 }
+
+// fetchLiveTicketUtxos loads the utxo entries for the provided live tickets
+// from the point of view of the end of the main chain.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) fetchLiveTicketUtxos(tickets []chainhash.Hash) (map[chainhash.Hash]*UtxoEntry, error) {
+	filteredSet := make(viewFilteredSet, len(tickets))
+	for _, hash := range tickets {
+		filteredSet[hash] = struct{}{}
+	}
+	utxos := make(map[chainhash.Hash]*UtxoEntry, len(tickets))
+	if err := b.utxoCache.fetchEntries(filteredSet, utxos); err != nil {
+		return nil, err
+	}
+	return utxos, nil
+}
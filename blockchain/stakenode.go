@@ -26,34 +26,47 @@ func (b *BlockChain) maybeFetchNewTickets(node *blockNode) error {
 		return nil
 	}
 
+	tickets, err := b.fetchNewTickets(node)
+	if err != nil {
+		return err
+	}
+	node.newTickets = tickets
+	return nil
+}
+
+// fetchNewTickets returns the list of newly maturing tickets for a given node
+// by traversing backwards through its parents until it finds the block that
+// contains the original tickets to mature.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) fetchNewTickets(node *blockNode) ([]chainhash.Hash, error) {
 	// No tickets in the live ticket pool are possible before stake enabled
 	// height.
 	if node.height < b.chainParams.StakeEnabledHeight {
-		node.newTickets = []chainhash.Hash{}
-		return nil
+		return []chainhash.Hash{}, nil
 	}
 
 	// Calculate block number for where new tickets matured from and retrieve
 	// its block from DB.
 	matureNode := node.RelativeAncestor(int64(b.chainParams.TicketMaturity))
 	if matureNode == nil {
-		return fmt.Errorf("unable to obtain ancestor %d blocks prior to %s "+
-			"(height %d)", b.chainParams.TicketMaturity, node.hash, node.height)
+		return nil, fmt.Errorf("unable to obtain ancestor %d blocks prior "+
+			"to %s (height %d)", b.chainParams.TicketMaturity, node.hash,
+			node.height)
 	}
 	matureBlock, err := b.fetchBlockByNode(matureNode)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Extract any ticket purchases from the block and cache them.
+	// Extract any ticket purchases from the block.
 	tickets := []chainhash.Hash{}
 	for _, stx := range matureBlock.MsgBlock().STransactions {
 		if stake.IsSStx(stx) {
 			tickets = append(tickets, stx.TxHash())
 		}
 	}
-	node.newTickets = tickets
-	return nil
+	return tickets, nil
 }
 
 // maybeFetchTicketInfo loads and populates prunable ticket information in the
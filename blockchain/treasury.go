@@ -414,9 +414,9 @@ func (b *BlockChain) calculateTreasuryBalance(dbTx database.Tx, node *blockNode)
 	return ts.balance + netValue
 }
 
-// dbPutTreasuryBalance inserts the current balance and the future treasury
-// add/spend into the database.
-func (b *BlockChain) dbPutTreasuryBalance(dbTx database.Tx, block *dcrutil.Block, node *blockNode) error {
+// calcTreasuryState calculates the treasury state for the provided block which
+// consists of the current balance and the future treasury add/spend values.
+func (b *BlockChain) calcTreasuryState(dbTx database.Tx, block *dcrutil.Block, node *blockNode) treasuryState {
 	// Calculate balance as of this node
 	balance := b.calculateTreasuryBalance(dbTx, node)
 	msgBlock := block.MsgBlock()
@@ -475,6 +475,13 @@ func (b *BlockChain) dbPutTreasuryBalance(dbTx database.Tx, block *dcrutil.Block
 		}
 	}
 
+	return ts
+}
+
+// dbPutTreasuryBalance inserts the current balance and the future treasury
+// add/spend into the database.
+func (b *BlockChain) dbPutTreasuryBalance(dbTx database.Tx, block *dcrutil.Block, node *blockNode) error {
+	ts := b.calcTreasuryState(dbTx, block, node)
 	hash := block.Hash()
 	return dbPutTreasuryBalance(dbTx, *hash, ts)
 }
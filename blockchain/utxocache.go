@@ -0,0 +1,546 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+)
+
+const (
+	// periodicUtxoFlushInterval is the maximum amount of time the utxo cache
+	// is allowed to go without being flushed to the database.  It bounds the
+	// number of blocks that need to be replayed to recover the utxo set
+	// after an unclean shutdown.
+	periodicUtxoFlushInterval = time.Minute * 2
+
+	// utxoEntryOverhead is the approximate number of bytes of memory used by
+	// a utxo entry in the cache excluding its outputs.  It consists of the
+	// key, the entry struct, and the overhead of the map that houses the
+	// outputs.
+	utxoEntryOverhead = chainhash.HashSize + 64 + 48
+
+	// utxoOutputOverhead is the approximate number of bytes of memory used
+	// by each output of a utxo entry in the cache excluding its public key
+	// script.
+	utxoOutputOverhead = 56
+)
+
+// -----------------------------------------------------------------------------
+// The utxo set state consists of the hash and height of the block the utxo set
+// stored in the database represents.  The utxo cache only writes the utxo set
+// to the database periodically, so it typically lags behind the best chain
+// state and is used to recover the utxo set after an unclean shutdown.
+//
+// The serialized format is:
+//
+//   <block hash><block height>
+//
+//   Field             Type              Size
+//   block hash        chainhash.Hash    chainhash.HashSize
+//   block height      uint32            4 bytes
+// -----------------------------------------------------------------------------
+
+// utxoSetStateSize is the size of a serialized utxo set state.
+const utxoSetStateSize = chainhash.HashSize + 4
+
+// dbPutUtxoSetState uses an existing database transaction to update the hash
+// and height of the block the utxo set in the database represents.
+func dbPutUtxoSetState(dbTx database.Tx, hash *chainhash.Hash, height int64) error {
+	var serialized [utxoSetStateSize]byte
+	copy(serialized[:], hash[:])
+	byteOrder.PutUint32(serialized[chainhash.HashSize:], uint32(height))
+	return dbTx.Metadata().Put(utxoSetStateKeyName, serialized[:])
+}
+
+// dbFetchUtxoSetState uses an existing database transaction to fetch the hash
+// and height of the block the utxo set in the database represents.  A nil hash
+// is returned when the state does not exist, which is the case for databases
+// that were created before the utxo cache existed and therefore always have
+// the utxo set in sync with the best chain state.
+func dbFetchUtxoSetState(dbTx database.Tx) (*chainhash.Hash, int64, error) {
+	serialized := dbTx.Metadata().Get(utxoSetStateKeyName)
+	if serialized == nil {
+		return nil, 0, nil
+	}
+	if len(serialized) != utxoSetStateSize {
+		return nil, 0, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set state: "+
+				"got %d bytes, want %d", len(serialized),
+				utxoSetStateSize),
+		}
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], serialized[:chainhash.HashSize])
+	height := int64(byteOrder.Uint32(serialized[chainhash.HashSize:]))
+	return &hash, height, nil
+}
+
+// utxoEntrySize returns the approximate number of bytes of memory the provided
+// entry consumes in the utxo cache.
+func utxoEntrySize(entry *UtxoEntry) uint64 {
+	if entry == nil {
+		return utxoEntryOverhead
+	}
+
+	size := uint64(utxoEntryOverhead + len(entry.stakeExtra))
+	for _, output := range entry.sparseOutputs {
+		size += utxoOutputOverhead + uint64(len(output.pkScript))
+	}
+	return size
+}
+
+// UtxoCacheStats houses statistics about the utxo cache.
+type UtxoCacheStats struct {
+	Entries uint64 // Number of entries in the cache.
+	Size    uint64 // Approximate size of the cache in bytes.
+	MaxSize uint64 // Maximum size of the cache in bytes.
+	Hits    uint64 // Number of lookups served by the cache.
+	Misses  uint64 // Number of lookups that required a database fetch.
+}
+
+// utxoCache is a write-back cache of unspent transaction outputs that sits
+// between utxo viewpoints and the utxo set in the database.  Entries that are
+// modified by connected blocks are only written to the database when the cache
+// is flushed, which happens when it grows beyond its maximum size, when it has
+// not been flushed for a period of time, and on shutdown.
+//
+// Every flush also records the block the utxo set in the database represents
+// so that the cache can be rebuilt by replaying the blocks after it in the
+// event of an unclean shutdown.
+type utxoCache struct {
+	db      database.DB
+	maxSize uint64
+
+	// cacheLock protects all of the fields below.
+	//
+	// entries houses the cached entries.  A nil entry indicates the
+	// transaction is fully spent and the entry is only kept until the
+	// removal is flushed to the database.
+	//
+	// dirty tracks the entries that have been modified since the last
+	// flush and thus need to be written to the database.
+	cacheLock       sync.Mutex
+	entries         map[chainhash.Hash]*UtxoEntry
+	dirty           map[chainhash.Hash]struct{}
+	totalSize       uint64
+	hits            uint64
+	misses          uint64
+	lastFlushHash   chainhash.Hash
+	lastFlushHeight int64
+	lastFlushTime   time.Time
+}
+
+// newUtxoCache returns a new utxo cache backed by the provided database that
+// flushes once the approximate size of its entries exceeds the provided max
+// size in bytes.  A max size of zero results in the cache being flushed after
+// every block.
+func newUtxoCache(db database.DB, maxSize uint64) *utxoCache {
+	return &utxoCache{
+		db:            db,
+		maxSize:       maxSize,
+		entries:       make(map[chainhash.Hash]*UtxoEntry),
+		dirty:         make(map[chainhash.Hash]struct{}),
+		lastFlushTime: time.Now(),
+	}
+}
+
+// addEntry adds the provided entry to the cache, replacing any existing entry
+// for the same hash, and updates the total size accordingly.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) addEntry(hash *chainhash.Hash, entry *UtxoEntry, dirty bool) {
+	if existing, ok := c.entries[*hash]; ok {
+		c.totalSize -= utxoEntrySize(existing)
+	}
+	c.entries[*hash] = entry
+	c.totalSize += utxoEntrySize(entry)
+	if dirty {
+		c.dirty[*hash] = struct{}{}
+	}
+}
+
+// removeEntry removes the entry for the provided hash from the cache.  It must
+// not be called for dirty entries.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) removeEntry(hash *chainhash.Hash) {
+	if existing, ok := c.entries[*hash]; ok {
+		c.totalSize -= utxoEntrySize(existing)
+		delete(c.entries, *hash)
+	}
+}
+
+// loadEntries loads the entries for the provided set of transactions into the
+// provided map from the cache, falling back to the database for any that are
+// not cached.  The cache hit and miss statistics are only updated when the
+// update stats flag is set.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) loadEntries(filteredSet viewFilteredSet, entries map[chainhash.Hash]*UtxoEntry, updateStats bool) error {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+
+	// Serve as many of the entries as possible from the cache and keep
+	// track of the ones that need to be loaded from the database.
+	var missing []chainhash.Hash
+	var hits, misses uint64
+	for hash := range filteredSet {
+		if entry, ok := c.entries[hash]; ok {
+			hits++
+			entries[hash] = entry.Clone()
+			continue
+		}
+		misses++
+		missing = append(missing, hash)
+	}
+	if updateStats {
+		c.hits += hits
+		c.misses += misses
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	// Load the missing entries from the database and add them to the
+	// cache.  Missing entries are not cached since there is no need to
+	// remove them from the database.
+	return c.db.View(func(dbTx database.Tx) error {
+		for i := range missing {
+			hash := &missing[i]
+			entry, err := dbFetchUtxoEntry(dbTx, hash)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				c.addEntry(hash, entry, false)
+			}
+			entries[*hash] = entry.Clone()
+		}
+		return nil
+	})
+}
+
+// fetchEntries loads the entries for the provided set of transactions into the
+// provided map from the cache, falling back to the database for any that are
+// not cached.  The entries are cloned so the caller is free to modify them.
+// Transactions that are fully spent, or otherwise don't exist, result in a nil
+// entry.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntries(filteredSet viewFilteredSet, entries map[chainhash.Hash]*UtxoEntry) error {
+	return c.loadEntries(filteredSet, entries, true)
+}
+
+// peekEntries is identical to fetchEntries except it does not update the cache
+// hit and miss statistics.  It is used for internal bookkeeping lookups, such
+// as maintaining the utxo set statistics, which would otherwise skew the
+// statistics that are reported for the cache.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) peekEntries(filteredSet viewFilteredSet, entries map[chainhash.Hash]*UtxoEntry) error {
+	return c.loadEntries(filteredSet, entries, false)
+}
+
+// fetchEntry returns a copy of the entry for the provided transaction hash
+// from the cache, falling back to the database when it is not cached.  Both
+// the entry and the error will be nil when the transaction is fully spent or
+// otherwise does not exist.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntry(hash *chainhash.Hash) (*UtxoEntry, error) {
+	entries := make(map[chainhash.Hash]*UtxoEntry, 1)
+	filteredSet := viewFilteredSet{*hash: struct{}{}}
+	if err := c.fetchEntries(filteredSet, entries); err != nil {
+		return nil, err
+	}
+	return entries[*hash], nil
+}
+
+// commit updates the cache with all of the entries in the provided view that
+// have been modified and marks them as needing to be written to the database
+// on the next flush.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) commit(view *UtxoViewpoint) {
+	c.cacheLock.Lock()
+	for txHash, entry := range view.entries {
+		if entry == nil || !entry.modified {
+			continue
+		}
+
+		hash := txHash
+		if entry.IsFullySpent() {
+			c.addEntry(&hash, nil, true)
+			continue
+		}
+		c.addEntry(&hash, entry.Clone(), true)
+	}
+	c.cacheLock.Unlock()
+}
+
+// evict removes all of the entries in the provided view that have been
+// modified from the cache.  It is used after the modifications have been
+// written directly to the database, so it must only be called when the cache
+// does not have any entries that need to be flushed, such as immediately after
+// a flush.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) evict(view *UtxoViewpoint, bestHash *chainhash.Hash, bestHeight int64) {
+	c.cacheLock.Lock()
+	for txHash, entry := range view.entries {
+		if entry == nil || !entry.modified {
+			continue
+		}
+		hash := txHash
+		c.removeEntry(&hash)
+	}
+	c.lastFlushHash = *bestHash
+	c.lastFlushHeight = bestHeight
+	c.cacheLock.Unlock()
+}
+
+// flush writes all entries that have been modified since the last flush to the
+// database along with the provided best block the utxo set represents as of
+// the flush.  The entries are evicted from the cache when its size exceeds the
+// maximum allowed size.
+//
+// This function MUST be called with the cache lock held.
+func (c *utxoCache) flush(bestHash *chainhash.Hash, bestHeight int64) error {
+	err := c.db.Update(func(dbTx database.Tx) error {
+		utxoBucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+		for txHash := range c.dirty {
+			hash := txHash
+
+			// Remove the utxo entry if it is now fully spent.
+			entry := c.entries[hash]
+			if entry == nil {
+				if err := utxoBucket.Delete(hash[:]); err != nil {
+					return err
+				}
+				continue
+			}
+
+			// At this point the utxo entry is not fully spent, so
+			// store its serialization in the database.
+			serialized, err := serializeUtxoEntry(entry)
+			if err != nil {
+				return err
+			}
+			if err := utxoBucket.Put(hash[:], serialized); err != nil {
+				return err
+			}
+		}
+
+		return dbPutUtxoSetState(dbTx, bestHash, bestHeight)
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Debugf("Flushed %d utxo cache entries (%d bytes) at block %s "+
+		"(height %d)", len(c.dirty), c.totalSize, bestHash, bestHeight)
+
+	// Fully spent entries no longer need to be kept now that their removal
+	// has been written to the database.
+	for txHash := range c.dirty {
+		hash := txHash
+		if entry := c.entries[hash]; entry == nil {
+			c.removeEntry(&hash)
+		}
+	}
+	c.dirty = make(map[chainhash.Hash]struct{})
+
+	// Evict all entries when the cache has grown beyond its max size.
+	if c.totalSize > c.maxSize {
+		c.entries = make(map[chainhash.Hash]*UtxoEntry)
+		c.totalSize = 0
+	}
+
+	c.lastFlushHash = *bestHash
+	c.lastFlushHeight = bestHeight
+	c.lastFlushTime = time.Now()
+	return nil
+}
+
+// maybeFlush flushes the cache to the database when it has grown beyond its
+// maximum size, when it has not been flushed for longer than the periodic
+// flush interval, or when the force flag is set.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) maybeFlush(bestHash *chainhash.Hash, bestHeight int64, force bool) error {
+	c.cacheLock.Lock()
+	defer c.cacheLock.Unlock()
+
+	// Nothing to do when the utxo set in the database is already current.
+	if c.lastFlushHash == *bestHash && len(c.dirty) == 0 {
+		return nil
+	}
+
+	needsFlush := force || c.totalSize > c.maxSize ||
+		time.Since(c.lastFlushTime) >= periodicUtxoFlushInterval
+	if !needsFlush {
+		return nil
+	}
+	return c.flush(bestHash, bestHeight)
+}
+
+// lastFlushed returns the hash and height of the block the utxo set in the
+// database represents as of the most recent flush.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) lastFlushed() (chainhash.Hash, int64) {
+	c.cacheLock.Lock()
+	hash, height := c.lastFlushHash, c.lastFlushHeight
+	c.cacheLock.Unlock()
+	return hash, height
+}
+
+// reset discards all cached entries, including those that have not been
+// flushed, and sets the block the utxo set in the database represents to the
+// provided one.  It is used when the utxo set in the database is rebuilt.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) reset(bestHash *chainhash.Hash, bestHeight int64) {
+	c.cacheLock.Lock()
+	c.entries = make(map[chainhash.Hash]*UtxoEntry)
+	c.dirty = make(map[chainhash.Hash]struct{})
+	c.totalSize = 0
+	c.lastFlushHash = *bestHash
+	c.lastFlushHeight = bestHeight
+	c.lastFlushTime = time.Now()
+	c.cacheLock.Unlock()
+}
+
+// stats returns statistics about the current state of the cache.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) stats() UtxoCacheStats {
+	c.cacheLock.Lock()
+	stats := UtxoCacheStats{
+		Entries: uint64(len(c.entries)),
+		Size:    c.totalSize,
+		MaxSize: c.maxSize,
+		Hits:    c.hits,
+		Misses:  c.misses,
+	}
+	c.cacheLock.Unlock()
+	return stats
+}
+
+// initUtxoCache loads the state of the utxo set in the database and, when it
+// lags behind the best chain due to an unclean shutdown, recovers the utxo set
+// by replaying all of the blocks after it.
+func (b *BlockChain) initUtxoCache(ctx context.Context) error {
+	var stateHash *chainhash.Hash
+	var stateHeight int64
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		stateHash, stateHeight, err = dbFetchUtxoSetState(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// The utxo set is current when there is no state since databases that
+	// were created prior to the cache always update the utxo set along with
+	// the best chain state.  Store the state in that case so the utxo set
+	// can be recovered should the node not be shut down cleanly later.
+	tip := b.bestChain.Tip()
+	c := b.utxoCache
+	if stateHash == nil {
+		err := b.db.Update(func(dbTx database.Tx) error {
+			return dbPutUtxoSetState(dbTx, &tip.hash, tip.height)
+		})
+		if err != nil {
+			return err
+		}
+		stateHash, stateHeight = &tip.hash, tip.height
+	}
+	if *stateHash == tip.hash {
+		c.lastFlushHash = tip.hash
+		c.lastFlushHeight = tip.height
+		return nil
+	}
+	c.lastFlushHash = *stateHash
+	c.lastFlushHeight = stateHeight
+
+	// The utxo set is flushed prior to disconnecting blocks, so the block it
+	// represents must be an ancestor of the current tip.
+	stateNode := b.index.LookupNode(stateHash)
+	if stateNode == nil || !b.bestChain.Contains(stateNode) {
+		return AssertError(fmt.Sprintf("initUtxoCache: utxo set state "+
+			"%s (height %d) is not in the main chain", stateHash,
+			stateHeight))
+	}
+
+	log.Infof("Recovering utxo set from height %d to %d...",
+		stateNode.height, tip.height)
+	for node := b.bestChain.Next(stateNode); node != nil; node =
+		b.bestChain.Next(node) {
+
+		if interruptRequested(ctx) {
+			return errInterruptRequested
+		}
+
+		block, err := b.fetchMainChainBlockByNode(node)
+		if err != nil {
+			return err
+		}
+		parent, err := b.fetchMainChainBlockByNode(node.parent)
+		if err != nil {
+			return err
+		}
+		isTreasuryEnabled, err := b.isTreasuryAgendaActive(node.parent)
+		if err != nil {
+			return err
+		}
+
+		// Replay the block against a view of the utxo set as of its
+		// parent and add the modified entries to the cache.
+		view := NewUtxoViewpoint(b)
+		view.SetBestHash(&node.parent.hash)
+		err = view.connectBlock(b.db, block, parent, nil,
+			isTreasuryEnabled)
+		if err != nil {
+			return err
+		}
+		c.commit(view)
+		if err := c.maybeFlush(&node.hash, node.height, false); err != nil {
+			return err
+		}
+	}
+
+	return c.maybeFlush(&tip.hash, tip.height, true)
+}
+
+// UtxoCacheStats returns statistics about the current state of the utxo cache.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) UtxoCacheStats() UtxoCacheStats {
+	return b.utxoCache.stats()
+}
+
+// ShutdownUtxoCache flushes all modified entries in the utxo cache to the
+// database.  It should be called on shutdown once blocks are no longer being
+// processed so the utxo set does not need to be recovered on the next start.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ShutdownUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	log.Infof("Flushing utxo cache to the database...")
+	tip := b.bestChain.Tip()
+	return b.utxoCache.maybeFlush(&tip.hash, tip.height, true)
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+)
+
+// TestUtxoCache ensures the utxo cache defers writing the utxo set to the
+// database until it is flushed, evicts entries once it exceeds its max size,
+// and that the utxo set is recovered as expected when the chain is created
+// after an unclean shutdown.
+func TestUtxoCache(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "utxocachetest")
+	defer teardownFunc()
+
+	// Use a cache that is large enough to never be flushed due to its size.
+	const maxCacheSize = 1 << 30
+	g.chain.utxoCache.maxSize = maxCacheSize
+
+	// ---------------------------------------------------------------------
+	// Create some convenience functions to improve test readability.
+	// ---------------------------------------------------------------------
+
+	// fetchDbState returns the utxo set state stored in the database.
+	fetchDbState := func() (*chainhash.Hash, int64) {
+		t.Helper()
+
+		var hash *chainhash.Hash
+		var height int64
+		err := g.chain.db.View(func(dbTx database.Tx) error {
+			var err error
+			hash, height, err = dbFetchUtxoSetState(dbTx)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error fetching utxo set state: %v", err)
+		}
+		if hash == nil {
+			t.Fatal("utxo set state does not exist")
+		}
+		return hash, height
+	}
+
+	// fetchDbEntry returns the utxo entry for the provided hash directly from
+	// the database.
+	fetchDbEntry := func(hash *chainhash.Hash) *UtxoEntry {
+		t.Helper()
+
+		var entry *UtxoEntry
+		err := g.chain.db.View(func(dbTx database.Tx) error {
+			var err error
+			entry, err = dbFetchUtxoEntry(dbTx, hash)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error fetching utxo entry: %v", err)
+		}
+		return entry
+	}
+
+	// tipCoinbaseHash returns the hash of the coinbase of the current tip
+	// block of the generator.
+	tipCoinbaseHash := func() chainhash.Hash {
+		return g.Tip().Transactions[0].TxHash()
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure the utxo set in the database is not updated while the cache
+	// has not been flushed.
+	// ---------------------------------------------------------------------
+
+	g.AdvanceToStakeValidationHeight()
+
+	stats := g.chain.UtxoCacheStats()
+	if stats.Entries == 0 || stats.Size == 0 {
+		t.Fatalf("unexpected empty utxo cache: %+v", stats)
+	}
+	if stats.MaxSize != maxCacheSize {
+		t.Fatalf("unexpected max cache size: got %d, want %d",
+			stats.MaxSize, maxCacheSize)
+	}
+	if stats.Hits+stats.Misses == 0 {
+		t.Fatalf("unexpected utxo cache stats with no lookups: %+v", stats)
+	}
+
+	stateHash, stateHeight := fetchDbState()
+	if *stateHash != params.GenesisHash || stateHeight != 0 {
+		t.Fatalf("unexpected utxo set state: got %v (height %d), want %v "+
+			"(height 0)", stateHash, stateHeight, params.GenesisHash)
+	}
+
+	cbHash := tipCoinbaseHash()
+	if entry := fetchDbEntry(&cbHash); entry != nil {
+		t.Fatalf("unexpected utxo entry for %v in database", cbHash)
+	}
+	entry, err := g.chain.FetchUtxoEntry(&cbHash)
+	if err != nil {
+		t.Fatalf("unexpected error fetching utxo entry: %v", err)
+	}
+	if entry == nil {
+		t.Fatalf("missing utxo entry for %v in cache", cbHash)
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure the utxo set is recovered when a new chain instance is created
+	// without the cache having been flushed.
+	// ---------------------------------------------------------------------
+
+	chain, err := New(context.Background(), &Config{
+		DB:               g.chain.db,
+		ChainParams:      g.chain.chainParams,
+		TimeSource:       NewMedianTime(),
+		UtxoCacheMaxSize: maxCacheSize,
+	})
+	if err != nil {
+		t.Fatalf("failed to create chain instance: %v", err)
+	}
+	g.chain = chain
+
+	tip := g.chain.bestChain.Tip()
+	stateHash, stateHeight = fetchDbState()
+	if *stateHash != tip.hash || stateHeight != tip.height {
+		t.Fatalf("unexpected utxo set state: got %v (height %d), want %v "+
+			"(height %d)", stateHash, stateHeight, tip.hash, tip.height)
+	}
+	if entry := fetchDbEntry(&cbHash); entry == nil {
+		t.Fatalf("missing utxo entry for %v in database", cbHash)
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure shutting down the cache flushes it to the database.
+	// ---------------------------------------------------------------------
+
+	for i := 0; i < 3; i++ {
+		outs := g.OldestCoinbaseOuts()
+		g.NextBlock(fmt.Sprintf("bsd%d", i), nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		g.AcceptTipBlock()
+	}
+
+	cbHash = tipCoinbaseHash()
+	if entry := fetchDbEntry(&cbHash); entry != nil {
+		t.Fatalf("unexpected utxo entry for %v in database", cbHash)
+	}
+	if err := g.chain.ShutdownUtxoCache(); err != nil {
+		t.Fatalf("unexpected error shutting down utxo cache: %v", err)
+	}
+	tip = g.chain.bestChain.Tip()
+	stateHash, stateHeight = fetchDbState()
+	if *stateHash != tip.hash || stateHeight != tip.height {
+		t.Fatalf("unexpected utxo set state: got %v (height %d), want %v "+
+			"(height %d)", stateHash, stateHeight, tip.hash, tip.height)
+	}
+	if entry := fetchDbEntry(&cbHash); entry == nil {
+		t.Fatalf("missing utxo entry for %v in database", cbHash)
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure all entries are evicted once the cache exceeds its max size.
+	// ---------------------------------------------------------------------
+
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("bevict", nil, outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+
+	g.chain.utxoCache.maxSize = 1
+	tip = g.chain.bestChain.Tip()
+	err = g.chain.utxoCache.maybeFlush(&tip.hash, tip.height, false)
+	if err != nil {
+		t.Fatalf("unexpected error flushing utxo cache: %v", err)
+	}
+	stats = g.chain.UtxoCacheStats()
+	if stats.Entries != 0 || stats.Size != 0 {
+		t.Fatalf("unexpected non-empty utxo cache: %+v", stats)
+	}
+
+	// Ensure entries that are no longer cached are loaded from the database.
+	cbHash = tipCoinbaseHash()
+	entry, err = g.chain.FetchUtxoEntry(&cbHash)
+	if err != nil {
+		t.Fatalf("unexpected error fetching utxo entry: %v", err)
+	}
+	if entry == nil {
+		t.Fatalf("missing utxo entry for %v", cbHash)
+	}
+	if got := g.chain.UtxoCacheStats(); got.Misses != stats.Misses+1 {
+		t.Fatalf("unexpected utxo cache misses: got %d, want %d",
+			got.Misses, stats.Misses+1)
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure the lookups done to maintain the utxo set statistics do not
+	// affect the reported cache hits and misses.
+	// ---------------------------------------------------------------------
+
+	stats = g.chain.UtxoCacheStats()
+	outs = g.OldestCoinbaseOuts()
+	g.NextBlock("bstats", nil, outs[1:])
+	g.SaveTipCoinbaseOuts()
+	blk := g.Tip()
+	view := NewUtxoViewpoint(g.chain)
+	for _, tx := range blk.Transactions {
+		txHash := tx.TxHash()
+		view.entries[txHash] = &UtxoEntry{modified: true}
+	}
+	if _, err := g.chain.utxoStatsAfterView(view); err != nil {
+		t.Fatalf("unexpected error calculating utxo stats: %v", err)
+	}
+	if got := g.chain.UtxoCacheStats(); got.Hits != stats.Hits ||
+		got.Misses != stats.Misses {
+
+		t.Fatalf("unexpected utxo cache stats change: got %d hits and "+
+			"%d misses, want %d hits and %d misses", got.Hits,
+			got.Misses, stats.Hits, stats.Misses)
+	}
+}
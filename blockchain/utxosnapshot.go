@@ -0,0 +1,1593 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/stake/v3"
+	"github.com/decred/dcrd/blockchain/standalone/v2"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/crypto/blake256"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// utxoSnapshotVersion is the current version of the utxo set snapshot
+	// serialization format.
+	utxoSnapshotVersion = 1
+
+	// maxSnapshotFieldSize is the maximum allowed size of an individual
+	// variable length field in a utxo set snapshot such as a block, spend
+	// journal entry, or utxo entry.  It protects against massive allocations
+	// due to malformed data.
+	maxSnapshotFieldSize = 1 << 25
+
+	// snapshotLoadBatchSize is the number of items such as block index
+	// entries and utxo entries that are written to the database in a single
+	// transaction while loading a utxo set snapshot.
+	snapshotLoadBatchSize = 10000
+
+	// historyProgressLogInterval is the minimum amount of time between log
+	// messages about the progress of the background validation of the
+	// history that leads to a loaded utxo set snapshot.
+	historyProgressLogInterval = 10 * time.Second
+)
+
+var (
+	// utxoSnapshotMagic is the magic bytes that identify a utxo set
+	// snapshot.
+	utxoSnapshotMagic = [4]byte{'d', 'u', 't', 'x'}
+
+	// utxoSnapshotStateKeyName is the name of the db key used to store the
+	// state of a loaded utxo set snapshot and the background validation of
+	// the history that leads to it.
+	utxoSnapshotStateKeyName = []byte("utxosnapshot")
+
+	// historicalUtxoSetBucketName is the name of the db bucket used to house
+	// the utxo set that is built while validating the history that leads to
+	// a loaded utxo set snapshot.
+	historicalUtxoSetBucketName = []byte("historicalutxoset")
+)
+
+// -----------------------------------------------------------------------------
+// A utxo set snapshot houses all of the state required to bootstrap the chain
+// at a specific block without processing the blocks that lead to it.
+//
+// The serialized format is:
+//
+//   <header><totals><block index><retained blocks><treasury><tspends><utxos>
+//   <stake>
+//
+//   Field             Type                Size
+//   header
+//     magic           [4]byte             4 bytes
+//     version         uint32              4 bytes
+//     network         wire.CurrencyNet    4 bytes
+//     block height    uint32              4 bytes
+//     block hash      chainhash.Hash      chainhash.HashSize
+//   totals
+//     total txns      uint64              8 bytes
+//     total subsidy   int64               8 bytes
+//   block index
+//     num entries     VLQ                 variable
+//     entries         []varbytes          variable
+//   retained blocks
+//     first height    uint32              4 bytes
+//     per block       block, spend journal entry, and gcs filter varbytes
+//   treasury
+//     num entries     VLQ                 variable
+//     per entry       block hash and treasury state varbytes
+//   tspends
+//     num entries     VLQ                 variable
+//     per entry       tx hash and tspend varbytes
+//   utxos
+//     num entries     VLQ                 variable
+//     per entry       tx hash and utxo entry varbytes
+//   stake             ticket database snapshot (see stake.WriteSnapshot)
+//
+// The block index contains an entry for every block in the main chain up to
+// and including the snapshot block while the retained blocks are those that
+// are needed to validate new blocks and handle reorganizations.  Every entry
+// uses the same serialization as the associated database bucket.  In
+// particular, the utxo entries use the compressed format described in
+// chainio.go.  All integers are encoded in little endian.
+//
+// The hash of a snapshot is the BLAKE-256 hash of its entire serialization and
+// is what is committed to in the chain parameters.
+// -----------------------------------------------------------------------------
+
+// utxoSnapshotHeader houses the information in the header of a serialized utxo
+// set snapshot.
+type utxoSnapshotHeader struct {
+	height int64
+	hash   chainhash.Hash
+}
+
+// writeSnapshotUint32 writes the provided value to the writer.
+func writeSnapshotUint32(w io.Writer, val uint32) error {
+	var buf [4]byte
+	byteOrder.PutUint32(buf[:], val)
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// writeSnapshotUint64 writes the provided value to the writer.
+func writeSnapshotUint64(w io.Writer, val uint64) error {
+	var buf [8]byte
+	byteOrder.PutUint64(buf[:], val)
+	_, err := w.Write(buf[:])
+	return err
+}
+
+// readSnapshotUint32 reads a value written with writeSnapshotUint32 from the
+// reader.
+func readSnapshotUint32(r io.Reader) (uint32, error) {
+	var buf [4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return byteOrder.Uint32(buf[:]), nil
+}
+
+// readSnapshotUint64 reads a value written with writeSnapshotUint64 from the
+// reader.
+func readSnapshotUint64(r io.Reader) (uint64, error) {
+	var buf [8]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return 0, err
+	}
+	return byteOrder.Uint64(buf[:]), nil
+}
+
+// readSnapshotField reads a variable length field from the reader.
+func readSnapshotField(r io.Reader, fieldName string) ([]byte, error) {
+	return wire.ReadVarBytes(r, 0, maxSnapshotFieldSize, fieldName)
+}
+
+// writeUtxoSnapshotHeader writes the header for a utxo set snapshot of the
+// block with the provided height and hash to the writer.
+func writeUtxoSnapshotHeader(w io.Writer, net wire.CurrencyNet, height int64, hash *chainhash.Hash) error {
+	if _, err := w.Write(utxoSnapshotMagic[:]); err != nil {
+		return err
+	}
+	if err := writeSnapshotUint32(w, utxoSnapshotVersion); err != nil {
+		return err
+	}
+	if err := writeSnapshotUint32(w, uint32(net)); err != nil {
+		return err
+	}
+	if err := writeSnapshotUint32(w, uint32(height)); err != nil {
+		return err
+	}
+	_, err := w.Write(hash[:])
+	return err
+}
+
+// readUtxoSnapshotHeader reads the header of a utxo set snapshot from the
+// reader and ensures it is for the provided network.
+func readUtxoSnapshotHeader(r io.Reader, net wire.CurrencyNet) (*utxoSnapshotHeader, error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, err
+	}
+	if magic != utxoSnapshotMagic {
+		str := "the provided data is not a utxo set snapshot"
+		return nil, contextError(ErrInvalidSnapshot, str)
+	}
+	version, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if version != utxoSnapshotVersion {
+		str := fmt.Sprintf("unsupported utxo set snapshot version %d",
+			version)
+		return nil, contextError(ErrInvalidSnapshot, str)
+	}
+	snapshotNet, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if wire.CurrencyNet(snapshotNet) != net {
+		str := fmt.Sprintf("utxo set snapshot is for network %v instead "+
+			"of %v", wire.CurrencyNet(snapshotNet), net)
+		return nil, contextError(ErrInvalidSnapshot, str)
+	}
+	height, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	header := utxoSnapshotHeader{height: int64(height)}
+	if _, err := io.ReadFull(r, header.hash[:]); err != nil {
+		return nil, err
+	}
+	return &header, nil
+}
+
+// writeUtxoSetHashEntry adds the provided serialized utxo entry for the given
+// transaction hash to the provided utxo set hasher.
+func writeUtxoSetHashEntry(hasher hash.Hash, key, serialized []byte) {
+	hasher.Write(key)
+	wire.WriteVarBytes(hasher, 0, serialized)
+}
+
+// calcUtxoSetHash returns the hash of the utxo set housed in the bucket with
+// the provided name.  It commits to every serialized utxo entry in the order
+// of their transaction hashes.
+func calcUtxoSetHash(dbTx database.Tx, bucketName []byte) chainhash.Hash {
+	hasher := blake256.New()
+	cursor := dbTx.Metadata().Bucket(bucketName).Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		writeUtxoSetHashEntry(hasher, cursor.Key(), cursor.Value())
+	}
+	var hash chainhash.Hash
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// calcStakeStateHash returns a hash that commits to the live, missed, and
+// revoked tickets, the winning tickets, and the final state of the provided
+// stake node.
+func calcStakeStateHash(node *stake.Node) chainhash.Hash {
+	hasher := blake256.New()
+	writeHashes := func(hashes []chainhash.Hash) {
+		writeSnapshotUint32(hasher, uint32(len(hashes)))
+		for i := range hashes {
+			hasher.Write(hashes[i][:])
+		}
+	}
+	writeHashes(node.LiveTickets())
+	writeHashes(node.MissedTickets())
+	revoked := node.RevokedTickets()
+	writeSnapshotUint32(hasher, uint32(len(revoked)))
+	for _, hash := range revoked {
+		hasher.Write(hash[:])
+	}
+	writeHashes(node.Winners())
+	finalState := node.FinalState()
+	hasher.Write(finalState[:])
+
+	var hash chainhash.Hash
+	copy(hash[:], hasher.Sum(nil))
+	return hash
+}
+
+// -----------------------------------------------------------------------------
+// The utxo snapshot state houses the details of a loaded utxo set snapshot
+// along with the progress of the background validation of the history that
+// leads to it.
+//
+// The serialized format is:
+//
+//   <block hash><block height><utxo set hash><stake hash><total txns>
+//   <total subsidy><status><validated height><validated txns>
+//   <validated subsidy>
+//
+//   Field               Type             Size
+//   block hash          chainhash.Hash   chainhash.HashSize
+//   block height        uint32           4 bytes
+//   utxo set hash       chainhash.Hash   chainhash.HashSize
+//   stake hash          chainhash.Hash   chainhash.HashSize
+//   total txns          uint64           8 bytes
+//   total subsidy       int64            8 bytes
+//   status              uint8            1 byte
+//   validated height    uint32           4 bytes
+//   validated txns      uint64           8 bytes
+//   validated subsidy   int64            8 bytes
+// -----------------------------------------------------------------------------
+
+// snapshotStatus identifies the state of a loaded utxo set snapshot.
+type snapshotStatus uint8
+
+const (
+	// snapshotStatusLoading indicates the snapshot is in the process of
+	// being loaded into the database.
+	snapshotStatusLoading snapshotStatus = iota
+
+	// snapshotStatusValidating indicates the snapshot is loaded and the
+	// history that leads to it is being validated.
+	snapshotStatusValidating
+
+	// snapshotStatusValidated indicates the history that leads to the
+	// snapshot has been validated and produces the state in the snapshot.
+	snapshotStatusValidated
+
+	// snapshotStatusInvalid indicates the history that leads to the snapshot
+	// is invalid or does not produce the state in the snapshot.
+	snapshotStatusInvalid
+)
+
+// utxoSnapshotStateSize is the size of a serialized utxo snapshot state.
+const utxoSnapshotStateSize = chainhash.HashSize*3 + 4 + 8 + 8 + 1 + 4 + 8 + 8
+
+// utxoSnapshotState represents the state of a loaded utxo set snapshot.
+type utxoSnapshotState struct {
+	hash         chainhash.Hash
+	height       int64
+	utxoSetHash  chainhash.Hash
+	stakeHash    chainhash.Hash
+	totalTxns    uint64
+	totalSubsidy int64
+	status       snapshotStatus
+
+	// These fields track the progress of the background validation of the
+	// history that leads to the snapshot.
+	validatedHeight  int64
+	validatedTxns    uint64
+	validatedSubsidy int64
+}
+
+// serializeUtxoSnapshotState returns the serialization of the provided utxo
+// snapshot state.
+func serializeUtxoSnapshotState(state *utxoSnapshotState) []byte {
+	serialized := make([]byte, utxoSnapshotStateSize)
+	offset := copy(serialized, state.hash[:])
+	byteOrder.PutUint32(serialized[offset:], uint32(state.height))
+	offset += 4
+	offset += copy(serialized[offset:], state.utxoSetHash[:])
+	offset += copy(serialized[offset:], state.stakeHash[:])
+	byteOrder.PutUint64(serialized[offset:], state.totalTxns)
+	offset += 8
+	byteOrder.PutUint64(serialized[offset:], uint64(state.totalSubsidy))
+	offset += 8
+	serialized[offset] = byte(state.status)
+	offset++
+	byteOrder.PutUint32(serialized[offset:], uint32(state.validatedHeight))
+	offset += 4
+	byteOrder.PutUint64(serialized[offset:], state.validatedTxns)
+	offset += 8
+	byteOrder.PutUint64(serialized[offset:], uint64(state.validatedSubsidy))
+	return serialized
+}
+
+// deserializeUtxoSnapshotState deserializes the provided serialized utxo
+// snapshot state.
+func deserializeUtxoSnapshotState(serialized []byte) (*utxoSnapshotState, error) {
+	if len(serialized) != utxoSnapshotStateSize {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo snapshot state size; "+
+				"want %d, got %d", utxoSnapshotStateSize, len(serialized)),
+		}
+	}
+
+	var state utxoSnapshotState
+	offset := copy(state.hash[:], serialized)
+	state.height = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	offset += copy(state.utxoSetHash[:], serialized[offset:])
+	offset += copy(state.stakeHash[:], serialized[offset:])
+	state.totalTxns = byteOrder.Uint64(serialized[offset:])
+	offset += 8
+	state.totalSubsidy = int64(byteOrder.Uint64(serialized[offset:]))
+	offset += 8
+	state.status = snapshotStatus(serialized[offset])
+	offset++
+	state.validatedHeight = int64(byteOrder.Uint32(serialized[offset:]))
+	offset += 4
+	state.validatedTxns = byteOrder.Uint64(serialized[offset:])
+	offset += 8
+	state.validatedSubsidy = int64(byteOrder.Uint64(serialized[offset:]))
+	return &state, nil
+}
+
+// dbPutUtxoSnapshotState uses an existing database transaction to store the
+// provided utxo snapshot state.
+func dbPutUtxoSnapshotState(dbTx database.Tx, state *utxoSnapshotState) error {
+	serialized := serializeUtxoSnapshotState(state)
+	return dbTx.Metadata().Put(utxoSnapshotStateKeyName, serialized)
+}
+
+// dbFetchUtxoSnapshotState uses an existing database transaction to fetch the
+// utxo snapshot state.  It returns nil when the chain was not bootstrapped from
+// a utxo set snapshot.
+func dbFetchUtxoSnapshotState(dbTx database.Tx) (*utxoSnapshotState, error) {
+	serialized := dbTx.Metadata().Get(utxoSnapshotStateKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	return deserializeUtxoSnapshotState(serialized)
+}
+
+// UtxoSnapshotInfo houses information about a utxo set snapshot.
+type UtxoSnapshotInfo struct {
+	// Hash and Height identify the block the snapshot was created at.
+	Hash   chainhash.Hash
+	Height int64
+
+	// SnapshotHash is the hash of the entire serialized snapshot.  It is
+	// the value that is committed to in the chain parameters.
+	SnapshotHash chainhash.Hash
+
+	// NumUtxoEntries is the number of transactions with unspent outputs in
+	// the snapshot.
+	NumUtxoEntries uint64
+}
+
+// utxoSnapshotSource houses the chain state a utxo set snapshot is created
+// from.  It is captured while the chain lock is held so the snapshot is able to
+// be written afterwards without it.
+type utxoSnapshotSource struct {
+	// dbTx is a read-only database transaction that was started while the
+	// utxo set in the database represented the tip.
+	dbTx database.Tx
+
+	// tip is the block the snapshot is created at and stakeNode is its
+	// stake node.  The stake node is captured separately since it may be
+	// pruned from the block node once the lock is released.
+	tip       *blockNode
+	stakeNode *stake.Node
+
+	// totalTxns and totalSubsidy are the totals for the best chain state as
+	// of the tip.
+	totalTxns    uint64
+	totalSubsidy int64
+}
+
+// writeUtxoSnapshot serializes a utxo set snapshot of the main chain tip of the
+// provided source to the writer and returns the number of utxo entries in it.
+//
+// Since the main chain is determined by walking the ancestors of the tip and
+// all data is loaded from the database transaction of the source, this
+// function does not require the chain lock to be held.
+func (b *BlockChain) writeUtxoSnapshot(w io.Writer, src *utxoSnapshotSource) (uint64, error) {
+	dbTx, tip := src.dbTx, src.tip
+	err := writeUtxoSnapshotHeader(w, b.chainParams.Net, tip.height, &tip.hash)
+	if err != nil {
+		return 0, err
+	}
+
+	// Serialize the totals for the best chain state.
+	if err := writeSnapshotUint64(w, src.totalTxns); err != nil {
+		return 0, err
+	}
+	if err := writeSnapshotUint64(w, uint64(src.totalSubsidy)); err != nil {
+		return 0, err
+	}
+
+	// Serialize the block index entries for the main chain.  The status of
+	// every entry is set to indicate the block is stored and validated since
+	// that will be the case once the history has been validated.
+	meta := dbTx.Metadata()
+	blockIndexBucket := meta.Bucket(blockIndexBucketName)
+	if err := wire.WriteVarInt(w, 0, uint64(tip.height+1)); err != nil {
+		return 0, err
+	}
+	for height := int64(0); height <= tip.height; height++ {
+		node := tip.Ancestor(height)
+		key := blockIndexKey(&node.hash, uint32(height))
+		entry, err := deserializeBlockIndexEntry(blockIndexBucket.Get(key))
+		if err != nil {
+			return 0, err
+		}
+		entry.status = statusDataStored | statusValidated
+		serialized, err := serializeBlockIndexEntry(entry)
+		if err != nil {
+			return 0, err
+		}
+		if err := wire.WriteVarBytes(w, 0, serialized); err != nil {
+			return 0, err
+		}
+	}
+
+	// Serialize the blocks along with their spend journal entries and filters
+	// that are needed to validate new blocks and handle reorganizations.
+	firstRetained := tip.height - b.pruneRetentionDepth() + 1
+	if firstRetained < 1 {
+		firstRetained = 1
+	}
+	if err := writeSnapshotUint32(w, uint32(firstRetained)); err != nil {
+		return 0, err
+	}
+	spendBucket := meta.Bucket(spendJournalBucketName)
+	filterBucket := meta.Bucket(gcsFilterBucketName)
+	for height := firstRetained; height <= tip.height; height++ {
+		node := tip.Ancestor(height)
+		blockBytes, err := dbTx.FetchBlock(&node.hash)
+		if err != nil {
+			return 0, b.maybePrunedBlockError(node, err)
+		}
+		fields := [][]byte{blockBytes, spendBucket.Get(node.hash[:]),
+			filterBucket.Get(node.hash[:])}
+		for _, field := range fields {
+			if err := wire.WriteVarBytes(w, 0, field); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	// Serialize the treasury state for all blocks in the main chain that
+	// have one.
+	treasuryBucket := meta.Bucket(treasuryBucketName)
+	var treasuryNodes []*blockNode
+	for node := tip; node != nil; node = node.parent {
+		if treasuryBucket.Get(node.hash[:]) != nil {
+			treasuryNodes = append(treasuryNodes, node)
+		}
+	}
+	err = wire.WriteVarInt(w, 0, uint64(len(treasuryNodes)))
+	if err != nil {
+		return 0, err
+	}
+	for i := len(treasuryNodes) - 1; i >= 0; i-- {
+		hash := &treasuryNodes[i].hash
+		if _, err := w.Write(hash[:]); err != nil {
+			return 0, err
+		}
+		err := wire.WriteVarBytes(w, 0, treasuryBucket.Get(hash[:]))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	// Serialize the treasury spends that are included in blocks in the main
+	// chain while excluding any side chain blocks.
+	type tspendEntry struct {
+		txHash     chainhash.Hash
+		serialized []byte
+	}
+	var tspends []tspendEntry
+	cursor := meta.Bucket(treasuryTSpendBucketName).Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		blockHashes, err := deserializeTSpend(cursor.Value())
+		if err != nil {
+			return 0, err
+		}
+		mainChainHashes := make([]chainhash.Hash, 0, len(blockHashes))
+		for i := range blockHashes {
+			node := b.index.LookupNode(&blockHashes[i])
+			if node != nil && tip.Ancestor(node.height) == node {
+				mainChainHashes = append(mainChainHashes, blockHashes[i])
+			}
+		}
+		if len(mainChainHashes) == 0 {
+			continue
+		}
+		serialized, err := serializeTSpend(mainChainHashes)
+		if err != nil {
+			return 0, err
+		}
+		entry := tspendEntry{serialized: serialized}
+		copy(entry.txHash[:], cursor.Key())
+		tspends = append(tspends, entry)
+	}
+	if err := wire.WriteVarInt(w, 0, uint64(len(tspends))); err != nil {
+		return 0, err
+	}
+	for i := range tspends {
+		if _, err := w.Write(tspends[i].txHash[:]); err != nil {
+			return 0, err
+		}
+		if err := wire.WriteVarBytes(w, 0, tspends[i].serialized); err != nil {
+			return 0, err
+		}
+	}
+
+	// Serialize the utxo set in the order of the transaction hashes.
+	utxoBucket := meta.Bucket(utxoSetBucketName)
+	var numUtxoEntries uint64
+	cursor = utxoBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		numUtxoEntries++
+	}
+	if err := wire.WriteVarInt(w, 0, numUtxoEntries); err != nil {
+		return 0, err
+	}
+	cursor = utxoBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		if _, err := w.Write(cursor.Key()); err != nil {
+			return 0, err
+		}
+		if err := wire.WriteVarBytes(w, 0, cursor.Value()); err != nil {
+			return 0, err
+		}
+	}
+
+	// Serialize the ticket database state.
+	err = stake.WriteSnapshot(w, dbTx, src.stakeNode, uint32(firstRetained))
+	if err != nil {
+		return 0, err
+	}
+
+	return numUtxoEntries, nil
+}
+
+// beginUtxoSnapshot flushes the utxo cache so the utxo set in the database
+// represents the current tip and then captures the state a utxo set snapshot of
+// the tip is created from, including a read-only database transaction.  The
+// chain lock is only held while the state is captured so block processing is
+// able to continue while the snapshot is written.
+//
+// The number of snapshot dumps in progress is incremented on success which
+// prevents block data from being pruned while the snapshot is written.  The
+// caller is responsible for rolling back the transaction and decrementing the
+// count once it is finished with the source.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) beginUtxoSnapshot() (*utxoSnapshotSource, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	// The history is required to create a snapshot that is able to be
+	// verified by others.
+	if b.historyValidation != nil {
+		str := "unable to create a utxo set snapshot until the history " +
+			"that leads to the loaded utxo set snapshot is validated"
+		return nil, contextError(ErrHistoryNotValidated, str)
+	}
+
+	// Ensure the utxo set in the database represents the current tip.
+	tip := b.bestChain.Tip()
+	if err := b.utxoCache.maybeFlush(&tip.hash, tip.height, true); err != nil {
+		return nil, err
+	}
+
+	stakeNode, err := b.fetchStakeNode(tip)
+	if err != nil {
+		return nil, err
+	}
+	dbTx, err := b.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	b.stateLock.RLock()
+	totalTxns := b.stateSnapshot.TotalTxns
+	totalSubsidy := b.stateSnapshot.TotalSubsidy
+	b.stateLock.RUnlock()
+
+	b.snapshotDumps++
+	return &utxoSnapshotSource{
+		dbTx:         dbTx,
+		tip:          tip,
+		stakeNode:    stakeNode,
+		totalTxns:    totalTxns,
+		totalSubsidy: totalSubsidy,
+	}, nil
+}
+
+// DumpUtxoSnapshot serializes a snapshot of the utxo set along with the ticket
+// database state and the other chain state required to bootstrap the chain as
+// of the current tip to the writer.  The returned hash of the snapshot may be
+// committed to in the chain parameters so that other nodes are able to load it
+// via LoadUtxoSnapshot.
+//
+// The chain lock is only held while the utxo cache is flushed and a consistent
+// view of the database is obtained, so blocks continue to be processed while
+// the snapshot is written.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) DumpUtxoSnapshot(w io.Writer) (*UtxoSnapshotInfo, error) {
+	src, err := b.beginUtxoSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// The transaction is read only, so rolling it back only releases
+		// the database snapshot it holds.
+		_ = src.dbTx.Rollback()
+
+		b.chainLock.Lock()
+		b.snapshotDumps--
+		b.chainLock.Unlock()
+	}()
+
+	tip := src.tip
+	hasher := blake256.New()
+	bw := bufio.NewWriter(io.MultiWriter(w, hasher))
+	numUtxoEntries, err := b.writeUtxoSnapshot(bw, src)
+	if err != nil {
+		return nil, err
+	}
+	if err := bw.Flush(); err != nil {
+		return nil, err
+	}
+
+	info := &UtxoSnapshotInfo{
+		Hash:           tip.hash,
+		Height:         tip.height,
+		NumUtxoEntries: numUtxoEntries,
+	}
+	copy(info.SnapshotHash[:], hasher.Sum(nil))
+	log.Infof("Created utxo set snapshot %v of block %v (height %d) with %d "+
+		"utxo entries", info.SnapshotHash, tip.hash, tip.height,
+		numUtxoEntries)
+	return info, nil
+}
+
+// snapshotLoader houses the state used when loading a utxo set snapshot into
+// the database.
+type snapshotLoader struct {
+	db     database.DB
+	params *chaincfg.Params
+	r      io.Reader
+	header *utxoSnapshotHeader
+	state  utxoSnapshotState
+
+	// hashes are the hashes of the blocks in the main chain indexed by
+	// height.
+	hashes []chainhash.Hash
+
+	// tipHeader and workSum are the header and total cumulative work of the
+	// snapshot block.
+	tipHeader wire.BlockHeader
+	workSum   *big.Int
+
+	// firstRetained is the height of the first block in the snapshot with
+	// its data included.
+	firstRetained int64
+
+	// numUtxoEntries is the number of utxo entries the chain parameters
+	// commit to the snapshot containing.
+	numUtxoEntries uint64
+}
+
+// invalidSnapshotError returns an error with the kind ErrInvalidSnapshot and
+// the provided description.
+func invalidSnapshotError(format string, args ...interface{}) error {
+	return contextError(ErrInvalidSnapshot, fmt.Sprintf(format, args...))
+}
+
+// forEachBatch invokes the provided function the given number of times across
+// database transactions that each include up to the provided batch size of
+// invocations.
+func (l *snapshotLoader) forEachBatch(ctx context.Context, count, batchSize uint64, fn func(dbTx database.Tx, i uint64) error) error {
+	for start := uint64(0); start < count; start += batchSize {
+		if interruptRequested(ctx) {
+			return errInterruptRequested
+		}
+
+		end := start + batchSize
+		if end > count {
+			end = count
+		}
+		err := l.db.Update(func(dbTx database.Tx) error {
+			for i := start; i < end; i++ {
+				if err := fn(dbTx, i); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadBlockIndex loads the block index entries from the snapshot into the
+// database while ensuring they form a chain of headers that satisfy their
+// claimed proof of work and lead to the snapshot block.
+func (l *snapshotLoader) loadBlockIndex(ctx context.Context) error {
+	numEntries, err := wire.ReadVarInt(l.r, 0)
+	if err != nil {
+		return err
+	}
+	if numEntries != uint64(l.header.height+1) {
+		return invalidSnapshotError("snapshot contains %d block index "+
+			"entries instead of %d", numEntries, l.header.height+1)
+	}
+
+	l.hashes = make([]chainhash.Hash, numEntries)
+	l.workSum = new(big.Int)
+	return l.forEachBatch(ctx, numEntries, snapshotLoadBatchSize,
+		func(dbTx database.Tx, i uint64) error {
+			serialized, err := readSnapshotField(l.r, "block index entry")
+			if err != nil {
+				return err
+			}
+			entry, err := deserializeBlockIndexEntry(serialized)
+			if err != nil {
+				return err
+			}
+			header := &entry.header
+			hash := header.BlockHash()
+			if i == 0 {
+				if hash != l.params.GenesisHash {
+					return invalidSnapshotError("snapshot block index " +
+						"does not start with the genesis block")
+				}
+			} else {
+				if header.PrevBlock != l.hashes[i-1] ||
+					uint64(header.Height) != i {
+
+					return invalidSnapshotError("snapshot block index "+
+						"entry for block %v does not connect at height "+
+						"%d", hash, i)
+				}
+				err := checkProofOfWork(header, l.params.PowLimit, BFNone)
+				if err != nil {
+					return err
+				}
+			}
+			l.hashes[i] = hash
+			l.workSum.Add(l.workSum, standalone.CalcWork(header.Bits))
+			l.tipHeader = *header
+
+			// The genesis block was already added when the chain state
+			// was created.
+			if i == 0 {
+				return nil
+			}
+			entry.status = statusDataStored | statusValidated
+			serialized, err = serializeBlockIndexEntry(entry)
+			if err != nil {
+				return err
+			}
+			bucket := dbTx.Metadata().Bucket(blockIndexBucketName)
+			return bucket.Put(blockIndexKey(&hash, uint32(i)), serialized)
+		})
+}
+
+// loadRetainedBlocks loads the blocks along with their spend journal entries
+// and filters from the snapshot into the database.
+func (l *snapshotLoader) loadRetainedBlocks(ctx context.Context) error {
+	firstRetained, err := readSnapshotUint32(l.r)
+	if err != nil {
+		return err
+	}
+	if firstRetained < 1 || int64(firstRetained) > l.header.height {
+		return invalidSnapshotError("snapshot first retained block height "+
+			"%d is not in the range [1, %d]", firstRetained,
+			l.header.height)
+	}
+	l.firstRetained = int64(firstRetained)
+
+	numBlocks := uint64(l.header.height - l.firstRetained + 1)
+	return l.forEachBatch(ctx, numBlocks, 100,
+		func(dbTx database.Tx, i uint64) error {
+			height := l.firstRetained + int64(i)
+			blockBytes, err := readSnapshotField(l.r, "block")
+			if err != nil {
+				return err
+			}
+			block, err := dcrutil.NewBlockFromBytes(blockBytes)
+			if err != nil {
+				return invalidSnapshotError("snapshot block at height %d "+
+					"is malformed: %v", height, err)
+			}
+			hash := block.Hash()
+			if *hash != l.hashes[height] {
+				return invalidSnapshotError("snapshot block %v does not "+
+					"match block %v at height %d", hash, l.hashes[height],
+					height)
+			}
+			journal, err := readSnapshotField(l.r, "spend journal entry")
+			if err != nil {
+				return err
+			}
+			filter, err := readSnapshotField(l.r, "gcs filter")
+			if err != nil {
+				return err
+			}
+
+			if err := dbTx.StoreBlock(block); err != nil {
+				return err
+			}
+			meta := dbTx.Metadata()
+			err = meta.Bucket(spendJournalBucketName).Put(hash[:], journal)
+			if err != nil {
+				return err
+			}
+			return meta.Bucket(gcsFilterBucketName).Put(hash[:], filter)
+		})
+}
+
+// loadTreasury loads the treasury state and treasury spend entries from the
+// snapshot into the database.
+func (l *snapshotLoader) loadTreasury(ctx context.Context) error {
+	buckets := []struct {
+		name        []byte
+		fieldName   string
+		deserialize func([]byte) error
+	}{{
+		name:      treasuryBucketName,
+		fieldName: "treasury state",
+		deserialize: func(serialized []byte) error {
+			_, err := deserializeTreasuryState(serialized)
+			return err
+		},
+	}, {
+		name:      treasuryTSpendBucketName,
+		fieldName: "treasury spend",
+		deserialize: func(serialized []byte) error {
+			_, err := deserializeTSpend(serialized)
+			return err
+		},
+	}}
+	for _, bucket := range buckets {
+		numEntries, err := wire.ReadVarInt(l.r, 0)
+		if err != nil {
+			return err
+		}
+		err = l.forEachBatch(ctx, numEntries, snapshotLoadBatchSize,
+			func(dbTx database.Tx, i uint64) error {
+				var key chainhash.Hash
+				if _, err := io.ReadFull(l.r, key[:]); err != nil {
+					return err
+				}
+				serialized, err := readSnapshotField(l.r, bucket.fieldName)
+				if err != nil {
+					return err
+				}
+				if err := bucket.deserialize(serialized); err != nil {
+					return invalidSnapshotError("snapshot %s entry %v is "+
+						"malformed: %v", bucket.fieldName, key, err)
+				}
+				b := dbTx.Metadata().Bucket(bucket.name)
+				return b.Put(key[:], serialized)
+			})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadUtxoSet loads the utxo set from the snapshot into the database while
+// calculating its hash.
+func (l *snapshotLoader) loadUtxoSet(ctx context.Context) error {
+	numEntries, err := wire.ReadVarInt(l.r, 0)
+	if err != nil {
+		return err
+	}
+	if numEntries != l.numUtxoEntries {
+		return invalidSnapshotError("utxo set snapshot contains %d utxo "+
+			"entries instead of the expected %d", numEntries,
+			l.numUtxoEntries)
+	}
+
+	hasher := blake256.New()
+	var prevKey chainhash.Hash
+	err = l.forEachBatch(ctx, numEntries, snapshotLoadBatchSize,
+		func(dbTx database.Tx, i uint64) error {
+			var key chainhash.Hash
+			if _, err := io.ReadFull(l.r, key[:]); err != nil {
+				return err
+			}
+			if i > 0 && bytes.Compare(key[:], prevKey[:]) <= 0 {
+				return invalidSnapshotError("snapshot utxo entries are " +
+					"not in order")
+			}
+			prevKey = key
+			serialized, err := readSnapshotField(l.r, "utxo entry")
+			if err != nil {
+				return err
+			}
+			if _, err := deserializeUtxoEntry(serialized); err != nil {
+				return invalidSnapshotError("snapshot utxo entry %v is "+
+					"malformed: %v", key, err)
+			}
+
+			writeUtxoSetHashEntry(hasher, key[:], serialized)
+			bucket := dbTx.Metadata().Bucket(utxoSetBucketName)
+			return bucket.Put(key[:], serialized)
+		})
+	if err != nil {
+		return err
+	}
+	copy(l.state.utxoSetHash[:], hasher.Sum(nil))
+	return nil
+}
+
+// SnapshotHistoryPending returns whether or not the chain state in the
+// provided database was bootstrapped from a utxo set snapshot for which the
+// history that leads to it has not yet been validated.
+func SnapshotHistoryPending(db database.DB) (bool, error) {
+	var pending bool
+	err := db.View(func(dbTx database.Tx) error {
+		state, err := dbFetchUtxoSnapshotState(dbTx)
+		if err != nil {
+			return err
+		}
+		pending = state != nil && state.status != snapshotStatusValidated
+		return nil
+	})
+	return pending, err
+}
+
+// LoadUtxoSnapshot initializes the chain state in the provided database from
+// the utxo set snapshot in the provided reader.  The snapshot must be one that
+// is committed to in the provided chain parameters.  The history that leads to
+// the snapshot is validated in the background once the chain is created with
+// New.
+//
+// Nothing is done when the database already contains the chain state from the
+// same snapshot, so it is safe to call this function on every start.  An error
+// is returned when the database contains any other chain state.
+func LoadUtxoSnapshot(ctx context.Context, db database.DB, params *chaincfg.Params, r io.ReadSeeker) error {
+	header, err := readUtxoSnapshotHeader(r, params.Net)
+	if err != nil {
+		return err
+	}
+
+	// Determine the state of the database.
+	var dbInfo *databaseInfo
+	var existingState *utxoSnapshotState
+	err = db.View(func(dbTx database.Tx) error {
+		var err error
+		dbInfo, err = dbFetchDatabaseInfo(dbTx)
+		if err != nil {
+			return err
+		}
+		existingState, err = dbFetchUtxoSnapshotState(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if dbInfo != nil {
+		if existingState != nil && existingState.hash == header.hash &&
+			existingState.status != snapshotStatusLoading {
+
+			return nil
+		}
+		if existingState != nil &&
+			existingState.status == snapshotStatusLoading {
+
+			return invalidSnapshotError("a previous attempt to load a utxo " +
+				"set snapshot did not complete -- the database must be " +
+				"removed before trying again")
+		}
+		return invalidSnapshotError("unable to load a utxo set snapshot " +
+			"into a database that already contains chain state")
+	}
+
+	// Ensure the snapshot is committed to in the chain parameters.
+	var assumeUtxo *chaincfg.AssumeUtxo
+	for i := range params.AssumeUtxo {
+		au := &params.AssumeUtxo[i]
+		if au.Height == header.height && *au.Hash == header.hash {
+			assumeUtxo = au
+			break
+		}
+	}
+	if assumeUtxo == nil {
+		return invalidSnapshotError("utxo set snapshot of block %v (height "+
+			"%d) is not known to be valid for network %s", header.hash,
+			header.height, params.Name)
+	}
+
+	// Ensure the hash of the entire snapshot matches the committed hash prior
+	// to modifying the database.
+	log.Infof("Verifying utxo set snapshot of block %v (height %d)...",
+		header.hash, header.height)
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hasher := blake256.New()
+	if _, err := io.Copy(hasher, r); err != nil {
+		return err
+	}
+	var snapshotHash chainhash.Hash
+	copy(snapshotHash[:], hasher.Sum(nil))
+	if snapshotHash != *assumeUtxo.SnapshotHash {
+		return invalidSnapshotError("utxo set snapshot hash %v does not "+
+			"match the expected hash %v", snapshotHash,
+			assumeUtxo.SnapshotHash)
+	}
+
+	// Create the initial chain state and note that a snapshot is being
+	// loaded so that a partially loaded snapshot is detected.
+	log.Infof("Loading utxo set snapshot...")
+	chain := &BlockChain{db: db, chainParams: params}
+	if err := chain.createChainState(); err != nil {
+		return err
+	}
+	l := &snapshotLoader{
+		db:     db,
+		params: params,
+		header: header,
+		state: utxoSnapshotState{
+			hash:   header.hash,
+			height: header.height,
+			status: snapshotStatusLoading,
+		},
+		numUtxoEntries: assumeUtxo.NumUtxoEntries,
+	}
+	err = db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSnapshotState(dbTx, &l.state)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Read the snapshot again while hashing it to ensure the data that is
+	// loaded is the same data that was verified.
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	hasher.Reset()
+	br := bufio.NewReaderSize(io.TeeReader(r, hasher), 1<<20)
+	l.r = br
+	if _, err := readUtxoSnapshotHeader(br, params.Net); err != nil {
+		return err
+	}
+	totalTxns, err := readSnapshotUint64(br)
+	if err != nil {
+		return err
+	}
+	totalSubsidy, err := readSnapshotUint64(br)
+	if err != nil {
+		return err
+	}
+	l.state.totalTxns = totalTxns
+	l.state.totalSubsidy = int64(totalSubsidy)
+	if err := l.loadBlockIndex(ctx); err != nil {
+		return err
+	}
+	if err := l.loadRetainedBlocks(ctx); err != nil {
+		return err
+	}
+	if err := l.loadTreasury(ctx); err != nil {
+		return err
+	}
+	if err := l.loadUtxoSet(ctx); err != nil {
+		return err
+	}
+
+	// Load the ticket database state and store the remaining chain state.
+	return db.Update(func(dbTx database.Tx) error {
+		stakeNode, err := stake.LoadSnapshot(br, dbTx, params, header.hash,
+			l.tipHeader)
+		if err != nil {
+			return err
+		}
+		if _, err := br.ReadByte(); !errors.Is(err, io.EOF) {
+			return invalidSnapshotError("utxo set snapshot contains " +
+				"trailing data")
+		}
+		copy(snapshotHash[:], hasher.Sum(nil))
+		if snapshotHash != *assumeUtxo.SnapshotHash {
+			return invalidSnapshotError("utxo set snapshot changed while " +
+				"it was being loaded")
+		}
+
+		serialized := serializeBestChainState(bestChainState{
+			hash:         header.hash,
+			height:       uint32(header.height),
+			totalTxns:    l.state.totalTxns,
+			totalSubsidy: l.state.totalSubsidy,
+			workSum:      l.workSum,
+		})
+		if err := dbTx.Metadata().Put(chainStateKeyName, serialized); err != nil {
+			return err
+		}
+		err = dbPutUtxoSetState(dbTx, &header.hash, header.height)
+		if err != nil {
+			return err
+		}
+		if l.firstRetained > 1 {
+			err := dbPutPrunedHeight(dbTx, l.firstRetained-1)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Create the bucket that houses the utxo set that is built while
+		// validating the history.  It starts out empty since the genesis
+		// block coinbase is not spendable.
+		_, err = dbTx.Metadata().CreateBucket(historicalUtxoSetBucketName)
+		if err != nil {
+			return err
+		}
+
+		genesisBlock := params.GenesisBlock
+		l.state.stakeHash = calcStakeStateHash(stakeNode)
+		l.state.status = snapshotStatusValidating
+		l.state.validatedTxns = uint64(len(genesisBlock.Transactions))
+		if err := dbPutUtxoSnapshotState(dbTx, &l.state); err != nil {
+			return err
+		}
+
+		log.Infof("Loaded utxo set snapshot of block %v (height %d)",
+			header.hash, header.height)
+		return nil
+	})
+}
+
+// historyValidation houses the state of the background validation of the
+// history that leads to a loaded utxo set snapshot.
+type historyValidation struct {
+	// snapshotNode is the block node for the snapshot block.
+	snapshotNode *blockNode
+
+	// state is the current state of the snapshot and the validation.
+	state utxoSnapshotState
+
+	// stakeNode is the stake node for the most recently validated block.
+	stakeNode *stake.Node
+
+	// lastLogTime is the last time progress was logged.
+	lastLogTime time.Time
+}
+
+// isHistoricalBlock returns whether or not the block with the provided height
+// and hash is part of the history that leads to a loaded utxo set snapshot that
+// has not yet been validated.
+//
+// This function MUST be called with the chain state lock held (for reads).
+func (b *BlockChain) isHistoricalBlock(height int64, hash *chainhash.Hash) bool {
+	hv := b.historyValidation
+	if hv == nil || height > hv.snapshotNode.height {
+		return false
+	}
+	node := hv.snapshotNode.Ancestor(height)
+	return node != nil && node.hash == *hash
+}
+
+// initHistoryValidation loads the state of the background validation of the
+// history that leads to a loaded utxo set snapshot and rebuilds the stake node
+// for the most recently validated block when the validation is not complete.
+func (b *BlockChain) initHistoryValidation(ctx context.Context) error {
+	var state *utxoSnapshotState
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchUtxoSnapshotState(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if state == nil || state.status == snapshotStatusValidated {
+		return nil
+	}
+	switch state.status {
+	case snapshotStatusLoading:
+		str := "the database contains a partially loaded utxo set " +
+			"snapshot -- it must be removed before trying again"
+		return contextError(ErrInvalidSnapshot, str)
+
+	case snapshotStatusInvalid:
+		str := fmt.Sprintf("the history that leads to the loaded utxo set "+
+			"snapshot of block %v (height %d) is invalid -- the database "+
+			"must be removed", state.hash, state.height)
+		return contextError(ErrSnapshotMismatch, str)
+	}
+
+	snapshotNode := b.index.LookupNode(&state.hash)
+	if snapshotNode == nil || !b.bestChain.Contains(snapshotNode) {
+		return AssertError(fmt.Sprintf("initHistoryValidation: utxo set "+
+			"snapshot block %s is not in the main chain", state.hash))
+	}
+
+	// Rebuild the stake node for the most recently validated block by
+	// replaying the stake details of all of the blocks that lead to it.
+	if state.validatedHeight > 0 {
+		log.Infof("Rebuilding ticket state for validated history through "+
+			"height %d...", state.validatedHeight)
+	}
+	stakeNode := stake.GenesisNode(b.chainParams)
+	for height := int64(1); height <= state.validatedHeight; height++ {
+		if interruptRequested(ctx) {
+			return errInterruptRequested
+		}
+
+		node := snapshotNode.Ancestor(height)
+		block, err := b.fetchBlockByNode(node)
+		if err != nil {
+			return err
+		}
+		newTickets, err := b.fetchNewTickets(node)
+		if err != nil {
+			return err
+		}
+		spent := stake.FindSpentTicketsInBlock(block.MsgBlock())
+		stakeNode, err = stakeNode.ConnectNode(node.lotteryIV(),
+			spent.VotedTickets, spent.RevokedTickets, newTickets)
+		if err != nil {
+			return err
+		}
+	}
+
+	b.historyValidation = &historyValidation{
+		snapshotNode: snapshotNode,
+		state:        *state,
+		stakeNode:    stakeNode,
+		lastLogTime:  time.Now(),
+	}
+	log.Infof("Validating the history that leads to the utxo set snapshot "+
+		"of block %v (height %d) in the background (validated through "+
+		"height %d)", state.hash, state.height, state.validatedHeight)
+	return nil
+}
+
+// NextHistoricalBlocks returns the hashes of up to the provided maximum number
+// of blocks that are needed to continue validating the history that leads to a
+// loaded utxo set snapshot in the order they must be processed.  It returns nil
+// when there is no history to validate.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) NextHistoricalBlocks(maxBlocks int) []chainhash.Hash {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	hv := b.historyValidation
+	if hv == nil || hv.state.status != snapshotStatusValidating {
+		return nil
+	}
+	startHeight := hv.state.validatedHeight + 1
+	numBlocks := hv.snapshotNode.height - startHeight + 1
+	if numBlocks > int64(maxBlocks) {
+		numBlocks = int64(maxBlocks)
+	}
+	hashes := make([]chainhash.Hash, numBlocks)
+	node := hv.snapshotNode.Ancestor(startHeight + numBlocks - 1)
+	for i := numBlocks - 1; i >= 0; i-- {
+		hashes[i] = node.hash
+		node = node.parent
+	}
+	return hashes
+}
+
+// markSnapshotInvalid marks the loaded utxo set snapshot as invalid due to the
+// provided error and returns an error with the kind ErrSnapshotMismatch.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) markSnapshotInvalid(node *blockNode, cause error) error {
+	hv := b.historyValidation
+	state := hv.state
+	state.status = snapshotStatusInvalid
+	err := b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSnapshotState(dbTx, &state)
+	})
+	if err != nil {
+		return err
+	}
+	hv.state = state
+
+	str := fmt.Sprintf("the history that leads to the utxo set snapshot of "+
+		"block %v (height %d) is invalid at block %v (height %d): %v",
+		state.hash, state.height, node.hash, node.height, cause)
+	return ruleError(ErrSnapshotMismatch, str)
+}
+
+// connectHistoricalBlock fully validates the provided block, which must be the
+// next block in the history that leads to a loaded utxo set snapshot, and
+// updates the state built while validating the history accordingly.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) connectHistoricalBlock(node *blockNode, block *dcrutil.Block) error {
+	// Ensure the block data matches its header.  Errors here indicate the
+	// block data is corrupt as opposed to the history being invalid.
+	err := checkBlockSanity(block, b.timeSource, BFNone, b.chainParams)
+	if err != nil {
+		return err
+	}
+
+	hv := b.historyValidation
+	prevNode := node.parent
+	parent, err := b.fetchBlockByNode(prevNode)
+	if err != nil {
+		return err
+	}
+
+	// Perform the same contextual checks that are performed when connecting a
+	// block to the main chain.  The stake node of the previous block is
+	// temporarily replaced with the one built while validating the history
+	// so that the checks do not rely on the ticket state from the snapshot.
+	origStakeNode := prevNode.stakeNode
+	prevNode.stakeNode = hv.stakeNode
+	defer func() {
+		prevNode.stakeNode = origStakeNode
+	}()
+	isTreasuryEnabled, err := b.isTreasuryAgendaActive(prevNode)
+	if err != nil {
+		return err
+	}
+	view := NewUtxoViewpoint(b)
+	view.utxoBucket = historicalUtxoSetBucketName
+	view.SetBestHash(&prevNode.hash)
+	var stxos []spentTxOut
+	var hdrCommitments headerCommitmentData
+	err = b.checkBlockPositional(block, prevNode, BFNone)
+	if err == nil {
+		err = b.checkBlockContext(block, prevNode, BFNone)
+	}
+	if err == nil {
+		err = b.checkConnectBlock(node, block, parent, view, &stxos,
+			&hdrCommitments)
+	}
+	var rErr RuleError
+	if errors.As(err, &rErr) {
+		return b.markSnapshotInvalid(node, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Calculate the stake node for the block and ensure the votes in the
+	// block index match the block.
+	newTickets, err := b.fetchNewTickets(node)
+	if err != nil {
+		return err
+	}
+	spent := stake.FindSpentTicketsInBlock(block.MsgBlock())
+	stakeNode, err := hv.stakeNode.ConnectNode(node.lotteryIV(),
+		spent.VotedTickets, spent.RevokedTickets, newTickets)
+	if err != nil {
+		return b.markSnapshotInvalid(node, err)
+	}
+	if len(spent.Votes) != len(node.votes) {
+		return b.markSnapshotInvalid(node, errors.New("block index votes "+
+			"do not match the block"))
+	}
+	for i := range spent.Votes {
+		if spent.Votes[i] != node.votes[i] {
+			return b.markSnapshotInvalid(node, errors.New("block index "+
+				"votes do not match the block"))
+		}
+	}
+
+	// Update the validation state and ensure the state built from the history
+	// matches the snapshot once the snapshot block is reached.
+	state := hv.state
+	state.validatedHeight = node.height
+	state.validatedTxns += uint64(len(block.Transactions()) +
+		len(block.STransactions()))
+	state.validatedSubsidy += calculateAddedSubsidy(block, parent,
+		isTreasuryEnabled)
+	errMismatch := errors.New("mismatch")
+	var mismatch string
+	err = b.db.Update(func(dbTx database.Tx) error {
+		// Ensure the treasury state from the snapshot matches the state
+		// calculated from the block.
+		if isTreasuryEnabled {
+			ts := b.calcTreasuryState(dbTx, block, node)
+			serialized, err := serializeTreasuryState(ts)
+			if err != nil {
+				return err
+			}
+			bucket := dbTx.Metadata().Bucket(treasuryBucketName)
+			if !bytes.Equal(bucket.Get(node.hash[:]), serialized) {
+				mismatch = "treasury state does not match the snapshot"
+				return errMismatch
+			}
+		}
+
+		if err := dbMaybeStoreBlock(dbTx, block); err != nil {
+			return err
+		}
+		err := dbPutSpendJournalEntry(dbTx, block.Hash(), stxos)
+		if err != nil {
+			return err
+		}
+		err = dbPutGCSFilter(dbTx, block.Hash(), hdrCommitments.filter)
+		if err != nil {
+			return err
+		}
+		err = dbPutUtxoViewToBucket(dbTx, historicalUtxoSetBucketName, view)
+		if err != nil {
+			return err
+		}
+		if node != hv.snapshotNode {
+			if err := stake.WriteHistoricalNode(dbTx, stakeNode); err != nil {
+				return err
+			}
+			return dbPutUtxoSnapshotState(dbTx, &state)
+		}
+
+		// The snapshot block has been reached, so ensure the state built
+		// from the history matches the snapshot.
+		switch {
+		case calcUtxoSetHash(dbTx, historicalUtxoSetBucketName) !=
+			state.utxoSetHash:
+			mismatch = "utxo set does not match the snapshot"
+		case calcStakeStateHash(stakeNode) != state.stakeHash:
+			mismatch = "ticket state does not match the snapshot"
+		case state.validatedTxns != state.totalTxns:
+			mismatch = fmt.Sprintf("total transactions of %d do not match "+
+				"the snapshot total of %d", state.validatedTxns,
+				state.totalTxns)
+		case state.validatedSubsidy != state.totalSubsidy:
+			mismatch = fmt.Sprintf("total subsidy of %d does not match "+
+				"the snapshot total of %d", state.validatedSubsidy,
+				state.totalSubsidy)
+		}
+		if mismatch != "" {
+			return errMismatch
+		}
+		err = dbTx.Metadata().DeleteBucket(historicalUtxoSetBucketName)
+		if err != nil {
+			return err
+		}
+		state.status = snapshotStatusValidated
+		return dbPutUtxoSnapshotState(dbTx, &state)
+	})
+	if errors.Is(err, errMismatch) {
+		return b.markSnapshotInvalid(node, errors.New(mismatch))
+	}
+	if err != nil {
+		return err
+	}
+	hv.state = state
+	hv.stakeNode = stakeNode
+
+	if state.status == snapshotStatusValidated {
+		b.historyValidation = nil
+		log.Infof("Validated the history that leads to the utxo set "+
+			"snapshot of block %v (height %d)", state.hash, state.height)
+		return nil
+	}
+	if now := time.Now(); now.Sub(hv.lastLogTime) >= historyProgressLogInterval {
+		log.Infof("Validated utxo set snapshot history through height %d "+
+			"(%d remaining)", state.validatedHeight,
+			state.height-state.validatedHeight)
+		hv.lastLogTime = now
+	}
+	return nil
+}
+
+// ProcessHistoricalBlock fully validates the provided block, which must be the
+// next block returned by NextHistoricalBlocks, as a part of validating the
+// history that leads to a loaded utxo set snapshot.  Any subsequent blocks in
+// the history that are already available locally, such as those that were
+// included in the snapshot, are also validated.
+//
+// An error with the kind ErrSnapshotMismatch is returned when the history is
+// invalid or does not produce the state in the snapshot.  The chain state
+// derived from the snapshot can not be trusted in that case.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProcessHistoricalBlock(block *dcrutil.Block) error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	hv := b.historyValidation
+	if hv == nil {
+		return nil
+	}
+	if hv.state.status == snapshotStatusInvalid {
+		str := fmt.Sprintf("the history that leads to the utxo set snapshot "+
+			"of block %v (height %d) is invalid", hv.state.hash,
+			hv.state.height)
+		return ruleError(ErrSnapshotMismatch, str)
+	}
+	node := hv.snapshotNode.Ancestor(hv.state.validatedHeight + 1)
+	if *block.Hash() != node.hash {
+		return AssertError(fmt.Sprintf("ProcessHistoricalBlock: block %s is "+
+			"not the next historical block %s (height %d)", block.Hash(),
+			node.hash, node.height))
+	}
+	if err := b.connectHistoricalBlock(node, block); err != nil {
+		return err
+	}
+
+	// Continue with any subsequent blocks whose data is already available.
+	for b.historyValidation != nil {
+		node := hv.snapshotNode.Ancestor(hv.state.validatedHeight + 1)
+		var exists bool
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			exists, err = dbTx.HasBlock(&node.hash)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if !exists {
+			break
+		}
+		block, err := b.fetchBlockByNode(node)
+		if err != nil {
+			return err
+		}
+		if err := b.connectHistoricalBlock(node, block); err != nil {
+			return err
+		}
+	}
+	return nil
+}
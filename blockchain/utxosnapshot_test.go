@@ -0,0 +1,455 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/dcrutil/v3"
+)
+
+// TestUtxoSnapshot ensures a utxo set snapshot is able to be dumped from one
+// chain instance and loaded into another, that the loaded chain is usable
+// immediately, and that the history that leads to the snapshot is validated as
+// expected.
+func TestUtxoSnapshot(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "utxosnapshottest")
+	defer teardownFunc()
+
+	// ---------------------------------------------------------------------
+	// Create some convenience functions to improve test readability.
+	// ---------------------------------------------------------------------
+
+	// dumpSnapshot dumps a utxo set snapshot of the provided chain.
+	dumpSnapshot := func(chain *BlockChain) ([]byte, *UtxoSnapshotInfo) {
+		t.Helper()
+
+		var buf bytes.Buffer
+		info, err := chain.DumpUtxoSnapshot(&buf)
+		if err != nil {
+			t.Fatalf("unexpected error dumping utxo set snapshot: %v", err)
+		}
+		return buf.Bytes(), info
+	}
+
+	// createDb creates a new database that is removed when the test
+	// completes.
+	createDb := func(dbName string) database.DB {
+		t.Helper()
+
+		dbPath, err := ioutil.TempDir("", dbName)
+		if err != nil {
+			t.Fatalf("unable to create test db path: %v", err)
+		}
+		db, err := database.Create(testDbType, dbPath, blockDataNet)
+		if err != nil {
+			os.RemoveAll(dbPath)
+			t.Fatalf("error creating db: %v", err)
+		}
+		t.Cleanup(func() {
+			db.Close()
+			os.RemoveAll(dbPath)
+		})
+		return db
+	}
+
+	// newChain creates a new chain instance backed by the provided database
+	// with the provided params.
+	newChain := func(db database.DB, params *chaincfg.Params) (*BlockChain, error) {
+		return New(context.Background(), &Config{
+			DB:          db,
+			ChainParams: params,
+			TimeSource:  NewMedianTime(),
+		})
+	}
+
+	// validateHistory feeds the blocks requested by the provided chain in
+	// order until the history that leads to the loaded snapshot is
+	// validated or an error occurs.
+	validateHistory := func(chain *BlockChain) error {
+		t.Helper()
+
+		for {
+			hashes := chain.NextHistoricalBlocks(1)
+			if len(hashes) == 0 {
+				return nil
+			}
+			msgBlock := g.BlockByHash(&hashes[0])
+			if msgBlock == nil {
+				t.Fatalf("unknown historical block %v", hashes[0])
+			}
+			err := chain.ProcessHistoricalBlock(dcrutil.NewBlock(msgBlock))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// ---------------------------------------------------------------------
+	// Generate enough blocks to have tickets voting and some blocks that are
+	// deep enough for their data to be excluded from the snapshot and dump
+	// a snapshot.
+	// ---------------------------------------------------------------------
+
+	g.AdvanceToStakeValidationHeight()
+	targetHeight := uint32(g.chain.pruneRetentionDepth() + 16)
+	for i := 0; g.Tip().Header.Height < targetHeight; i++ {
+		outs := g.OldestCoinbaseOuts()
+		g.NextBlock(fmt.Sprintf("bsnap%d", i), nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		g.AcceptTipBlock()
+	}
+	snapshot, info := dumpSnapshot(g.chain)
+	if info.Hash != g.Tip().BlockHash() ||
+		info.Height != int64(g.Tip().Header.Height) {
+
+		t.Fatalf("unexpected snapshot block %v (height %d)", info.Hash,
+			info.Height)
+	}
+	if info.NumUtxoEntries == 0 {
+		t.Fatal("unexpected empty utxo set snapshot")
+	}
+
+	// Ensure dumping the snapshot again produces the same snapshot.
+	snapshot2, info2 := dumpSnapshot(g.chain)
+	if !bytes.Equal(snapshot, snapshot2) || *info != *info2 {
+		t.Fatal("dumping the same utxo set snapshot produced a different " +
+			"result")
+	}
+
+	// Create params that commit to the snapshot.
+	snapshotParams := *g.chain.chainParams
+	snapshotParams.AssumeUtxo = []chaincfg.AssumeUtxo{{
+		Height:         info.Height,
+		Hash:           &info.Hash,
+		SnapshotHash:   &info.SnapshotHash,
+		NumUtxoEntries: info.NumUtxoEntries,
+	}}
+
+	// ---------------------------------------------------------------------
+	// Ensure snapshots that are not committed to are rejected.
+	// ---------------------------------------------------------------------
+
+	badParams := snapshotParams
+	badParams.AssumeUtxo = []chaincfg.AssumeUtxo{{
+		Height:         info.Height,
+		Hash:           &info.Hash,
+		SnapshotHash:   &chainhash.Hash{0x01},
+		NumUtxoEntries: info.NumUtxoEntries,
+	}}
+	db := createDb("utxosnapshottestbad")
+	err := LoadUtxoSnapshot(context.Background(), db, &badParams,
+		bytes.NewReader(snapshot))
+	if !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("unexpected error loading snapshot with bad hash -- got "+
+			"%v, want %v", err, ErrInvalidSnapshot)
+	}
+	badParams.AssumeUtxo = []chaincfg.AssumeUtxo{{
+		Height:         info.Height,
+		Hash:           &info.Hash,
+		SnapshotHash:   &info.SnapshotHash,
+		NumUtxoEntries: info.NumUtxoEntries + 1,
+	}}
+	err = LoadUtxoSnapshot(context.Background(), db, &badParams,
+		bytes.NewReader(snapshot))
+	if !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("unexpected error loading snapshot with bad entry count "+
+			"-- got %v, want %v", err, ErrInvalidSnapshot)
+	}
+	err = LoadUtxoSnapshot(context.Background(), db, params,
+		bytes.NewReader(snapshot))
+	if !errors.Is(err, ErrInvalidSnapshot) {
+		t.Fatalf("unexpected error loading snapshot without commitment -- "+
+			"got %v, want %v", err, ErrInvalidSnapshot)
+	}
+
+	// ---------------------------------------------------------------------
+	// Load the snapshot into a new database and ensure the resulting chain
+	// is usable before the history is validated.
+	// ---------------------------------------------------------------------
+
+	db = createDb("utxosnapshottestload")
+	err = LoadUtxoSnapshot(context.Background(), db, &snapshotParams,
+		bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("unexpected error loading snapshot: %v", err)
+	}
+
+	// Ensure loading the same snapshot again is a noop.
+	err = LoadUtxoSnapshot(context.Background(), db, &snapshotParams,
+		bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("unexpected error reloading snapshot: %v", err)
+	}
+	pending, err := SnapshotHistoryPending(db)
+	if err != nil {
+		t.Fatalf("unexpected error checking pending history: %v", err)
+	}
+	if !pending {
+		t.Fatal("snapshot history is not pending after load")
+	}
+
+	origChain := g.chain
+	loadedChain, err := newChain(db, &snapshotParams)
+	if err != nil {
+		t.Fatalf("failed to create chain from snapshot: %v", err)
+	}
+	if tip := loadedChain.BestSnapshot(); tip.Hash != info.Hash {
+		t.Fatalf("unexpected loaded chain tip -- got %v, want %v", tip.Hash,
+			info.Hash)
+	}
+	_, err = loadedChain.DumpUtxoSnapshot(ioutil.Discard)
+	if !errors.Is(err, ErrHistoryNotValidated) {
+		t.Fatalf("unexpected error dumping unvalidated snapshot -- got %v, "+
+			"want %v", err, ErrHistoryNotValidated)
+	}
+
+	// Ensure new blocks are accepted by both chains.
+	g.chain = loadedChain
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("bnew", nil, outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+	_, err = origChain.ProcessBlock(dcrutil.NewBlock(g.Tip()), BFNone)
+	if err != nil {
+		t.Fatalf("unexpected error processing block: %v", err)
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure the history is validated across restarts and the chain then
+	// produces the same snapshot as the chain it was created from.
+	// ---------------------------------------------------------------------
+
+	hashes := loadedChain.NextHistoricalBlocks(1)
+	if len(hashes) != 1 || hashes[0] != g.BlockByName("bfb").BlockHash() {
+		t.Fatalf("unexpected first historical block %v", hashes)
+	}
+	for i := 0; i < 10; i++ {
+		hashes := loadedChain.NextHistoricalBlocks(1)
+		block := dcrutil.NewBlock(g.BlockByHash(&hashes[0]))
+		if err := loadedChain.ProcessHistoricalBlock(block); err != nil {
+			t.Fatalf("unexpected error processing historical block: %v",
+				err)
+		}
+	}
+	loadedChain, err = newChain(db, &snapshotParams)
+	if err != nil {
+		t.Fatalf("failed to recreate chain from snapshot: %v", err)
+	}
+	hashes = loadedChain.NextHistoricalBlocks(1)
+	if len(hashes) != 1 || hashes[0] != g.BlockByName("bm9").BlockHash() {
+		t.Fatalf("unexpected historical block after restart %v", hashes)
+	}
+	if err := validateHistory(loadedChain); err != nil {
+		t.Fatalf("unexpected error validating history: %v", err)
+	}
+	pending, err = SnapshotHistoryPending(db)
+	if err != nil {
+		t.Fatalf("unexpected error checking pending history: %v", err)
+	}
+	if pending {
+		t.Fatal("snapshot history is still pending after validation")
+	}
+
+	want, _ := dumpSnapshot(origChain)
+	got, _ := dumpSnapshot(loadedChain)
+	if !bytes.Equal(got, want) {
+		t.Fatal("snapshot of validated chain does not match original chain")
+	}
+
+	// ---------------------------------------------------------------------
+	// Ensure history that does not produce the snapshot state is detected
+	// and prevents the chain from being created again.
+	// ---------------------------------------------------------------------
+
+	db = createDb("utxosnapshottestmismatch")
+	err = LoadUtxoSnapshot(context.Background(), db, &snapshotParams,
+		bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("unexpected error loading snapshot: %v", err)
+	}
+	loadedChain, err = newChain(db, &snapshotParams)
+	if err != nil {
+		t.Fatalf("failed to create chain from snapshot: %v", err)
+	}
+	loadedChain.historyValidation.state.utxoSetHash = chainhash.Hash{0x01}
+	err = validateHistory(loadedChain)
+	if !errors.Is(err, ErrSnapshotMismatch) {
+		t.Fatalf("unexpected error validating mismatched history -- got "+
+			"%v, want %v", err, ErrSnapshotMismatch)
+	}
+	_, err = newChain(db, &snapshotParams)
+	if !errors.Is(err, ErrSnapshotMismatch) {
+		t.Fatalf("unexpected error creating chain with invalid snapshot "+
+			"-- got %v, want %v", err, ErrSnapshotMismatch)
+	}
+}
+
+// TestLoadRegNetUtxoSnapshot ensures the utxo set snapshot committed to by the
+// regression test network parameters is able to be loaded with them and that
+// the resulting chain awaits validation of the history that leads to it.
+func TestLoadRegNetUtxoSnapshot(t *testing.T) {
+	// The snapshot was dumped from the chain generated by TestUtxoSnapshot.
+	filename := filepath.Join("testdata", "regnetsnapshot320.bz2")
+	fi, err := os.Open(filename)
+	if err != nil {
+		t.Fatalf("unable to open %s: %v", filename, err)
+	}
+	defer fi.Close()
+	snapshot, err := ioutil.ReadAll(bzip2.NewReader(fi))
+	if err != nil {
+		t.Fatalf("unable to read %s: %v", filename, err)
+	}
+
+	params := chaincfg.RegNetParams()
+	if len(params.AssumeUtxo) != 1 {
+		t.Fatalf("unexpected number of regnet snapshot commitments -- got "+
+			"%d, want 1", len(params.AssumeUtxo))
+	}
+	assumeUtxo := &params.AssumeUtxo[0]
+
+	// Load the snapshot into a new database with the network params.
+	dbPath, err := ioutil.TempDir("", "regnetsnapshottest")
+	if err != nil {
+		t.Fatalf("unable to create test db path: %v", err)
+	}
+	defer os.RemoveAll(dbPath)
+	db, err := database.Create(testDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("error creating db: %v", err)
+	}
+	defer db.Close()
+	err = LoadUtxoSnapshot(context.Background(), db, params,
+		bytes.NewReader(snapshot))
+	if err != nil {
+		t.Fatalf("unexpected error loading snapshot: %v", err)
+	}
+
+	// Ensure the chain created from the snapshot has the committed block as
+	// its tip and requests the history starting from the first block.
+	chain, err := New(context.Background(), &Config{
+		DB:          db,
+		ChainParams: params,
+		TimeSource:  NewMedianTime(),
+	})
+	if err != nil {
+		t.Fatalf("failed to create chain from snapshot: %v", err)
+	}
+	tip := chain.BestSnapshot()
+	if tip.Hash != *assumeUtxo.Hash || tip.Height != assumeUtxo.Height {
+		t.Fatalf("unexpected loaded chain tip -- got %v (height %d), want "+
+			"%v (height %d)", tip.Hash, tip.Height, assumeUtxo.Hash,
+			assumeUtxo.Height)
+	}
+	pending, err := SnapshotHistoryPending(db)
+	if err != nil {
+		t.Fatalf("unexpected error checking pending history: %v", err)
+	}
+	if !pending {
+		t.Fatal("snapshot history is not pending after load")
+	}
+	hashes := chain.NextHistoricalBlocks(1)
+	if len(hashes) != 1 {
+		t.Fatalf("unexpected historical blocks %v", hashes)
+	}
+	node := chain.index.LookupNode(&hashes[0])
+	if node == nil || node.height != 1 {
+		t.Fatalf("unexpected first historical block %v", hashes[0])
+	}
+}
+
+// hookWriter is an io.Writer that invokes a hook the first time it is written
+// to before writing to the underlying writer.
+type hookWriter struct {
+	w    io.Writer
+	hook func()
+}
+
+// Write invokes the hook on the first call and writes the provided bytes to
+// the underlying writer.
+func (w *hookWriter) Write(p []byte) (int, error) {
+	if w.hook != nil {
+		w.hook()
+		w.hook = nil
+	}
+	return w.w.Write(p)
+}
+
+// TestUtxoSnapshotConcurrentBlocks ensures blocks are able to be processed while
+// a utxo set snapshot is being written and that the snapshot reflects the state
+// as of when it was started.
+func TestUtxoSnapshotConcurrentBlocks(t *testing.T) {
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "utxosnapshotconctest")
+	defer teardownFunc()
+
+	g.AdvanceToStakeValidationHeight()
+	for i := 0; i < 4; i++ {
+		outs := g.OldestCoinbaseOuts()
+		g.NextBlock(fmt.Sprintf("bsnap%d", i), nil, outs[1:])
+		g.SaveTipCoinbaseOuts()
+		g.AcceptTipBlock()
+	}
+	var want bytes.Buffer
+	wantInfo, err := g.chain.DumpUtxoSnapshot(&want)
+	if err != nil {
+		t.Fatalf("unexpected error dumping utxo set snapshot: %v", err)
+	}
+
+	// Process a new block once the snapshot starts being written.  The
+	// block must be processed before the snapshot is finished since the
+	// chain lock is not held while it is written.
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("bconc", nil, outs[1:])
+	g.SaveTipCoinbaseOuts()
+	block := dcrutil.NewBlock(g.Tip())
+	var got bytes.Buffer
+	w := &hookWriter{w: &got, hook: func() {
+		processed := make(chan error, 1)
+		go func() {
+			_, err := g.chain.ProcessBlock(block, BFNone)
+			processed <- err
+		}()
+		select {
+		case err := <-processed:
+			if err != nil {
+				t.Errorf("unexpected error processing block: %v", err)
+			}
+		case <-time.After(time.Second * 10):
+			t.Error("block processing blocked while writing snapshot")
+		}
+	}}
+	gotInfo, err := g.chain.DumpUtxoSnapshot(w)
+	if err != nil {
+		t.Fatalf("unexpected error dumping utxo set snapshot: %v", err)
+	}
+	if *gotInfo != *wantInfo {
+		t.Fatalf("unexpected snapshot info -- got %+v, want %+v", gotInfo,
+			wantInfo)
+	}
+	if !bytes.Equal(got.Bytes(), want.Bytes()) {
+		t.Fatal("snapshot does not reflect the state when it was started")
+	}
+	if tip := g.chain.BestSnapshot(); tip.Hash != *block.Hash() {
+		t.Fatalf("unexpected tip after processing block -- got %v, want %v",
+			tip.Hash, block.Hash())
+	}
+}
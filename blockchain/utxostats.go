@@ -0,0 +1,502 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/decred/dcrd/blockchain/v4/internal/muhash"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+)
+
+const (
+	// utxoStatsRetentionBlocks is the number of blocks prior to the current
+	// tip to retain the utxo set statistics for.  It is roughly two weeks
+	// worth of blocks on the main network.
+	utxoStatsRetentionBlocks = 4032
+
+	// utxoStatsProgressInterval is the number of utxo entries that are
+	// processed between progress messages when the utxo set statistics are
+	// calculated from the entire utxo set.
+	utxoStatsProgressInterval = 500000
+)
+
+var (
+	// utxoSetStatsBucketName is the name of the db bucket used to house the
+	// statistics of the utxo set as of each recent block in the main chain.
+	utxoSetStatsBucketName = []byte("utxosetstats")
+)
+
+// -----------------------------------------------------------------------------
+// The utxo set statistics consist of the number of unspent outputs, the number
+// of transactions with unspent outputs, the total serialized size of the utxo
+// entries, the total amount of all unspent outputs, and the state of a
+// MuHash3072 multiset hash that commits to all of the unspent outputs.  They
+// are keyed by the hash of the block the utxo set they describe represents.
+//
+// Since the hash commits to the unspent outputs as an unordered set, it is
+// updated incrementally as blocks are connected and disconnected by removing
+// the spent outputs and adding the new ones.  Each unspent output is added to
+// the hash as the following serialization:
+//
+//   <tx hash><output index><tx version><block height><block index><flags>
+//   <amount><script version><script len><script>
+//
+//   Field             Type              Size
+//   tx hash           chainhash.Hash    chainhash.HashSize
+//   output index      uint32            4 bytes
+//   tx version        uint16            2 bytes
+//   block height      uint32            4 bytes
+//   block index       uint32            4 bytes
+//   flags             byte              1 byte
+//   amount            uint64            8 bytes
+//   script version    uint16            2 bytes
+//   script len        uint32            4 bytes
+//   script            []byte            variable
+//
+// The flags are encoded the same way as they are for utxo entries and the
+// script is always the full uncompressed script.
+//
+// The serialized format of the statistics is:
+//
+//   <num utxos><num transactions><size><total amount><muhash state>
+//
+//   Field             Type              Size
+//   num utxos         uint64            8 bytes
+//   num transactions  uint64            8 bytes
+//   size              uint64            8 bytes
+//   total amount      uint64            8 bytes
+//   muhash state      [384]byte         384 bytes
+// -----------------------------------------------------------------------------
+
+// utxoSetStatsSize is the size of serialized utxo set statistics.
+const utxoSetStatsSize = 32 + muhash.SerializedSize
+
+// utxoSetStats houses the statistics of the utxo set as of a given block.
+type utxoSetStats struct {
+	utxos        int64
+	transactions int64
+	size         int64
+	total        int64
+	hash         [muhash.SerializedSize]byte
+}
+
+// serializeUtxoSetStats returns the provided utxo set statistics serialized to
+// a format that is suitable for long-term storage.
+func serializeUtxoSetStats(stats *utxoSetStats) []byte {
+	serialized := make([]byte, utxoSetStatsSize)
+	byteOrder.PutUint64(serialized[0:8], uint64(stats.utxos))
+	byteOrder.PutUint64(serialized[8:16], uint64(stats.transactions))
+	byteOrder.PutUint64(serialized[16:24], uint64(stats.size))
+	byteOrder.PutUint64(serialized[24:32], uint64(stats.total))
+	copy(serialized[32:], stats.hash[:])
+	return serialized
+}
+
+// deserializeUtxoSetStats decodes utxo set statistics from the provided
+// serialized bytes.
+func deserializeUtxoSetStats(serialized []byte) (*utxoSetStats, error) {
+	if len(serialized) != utxoSetStatsSize {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set stats: got %d "+
+				"bytes, want %d", len(serialized), utxoSetStatsSize),
+		}
+	}
+
+	var stats utxoSetStats
+	stats.utxos = int64(byteOrder.Uint64(serialized[0:8]))
+	stats.transactions = int64(byteOrder.Uint64(serialized[8:16]))
+	stats.size = int64(byteOrder.Uint64(serialized[16:24]))
+	stats.total = int64(byteOrder.Uint64(serialized[24:32]))
+	copy(stats.hash[:], serialized[32:])
+	return &stats, nil
+}
+
+// dbPutUtxoSetStats uses an existing database transaction to store the
+// provided utxo set statistics as of the block with the provided hash.
+func dbPutUtxoSetStats(dbTx database.Tx, hash *chainhash.Hash, stats *utxoSetStats) error {
+	bucket := dbTx.Metadata().Bucket(utxoSetStatsBucketName)
+	return bucket.Put(hash[:], serializeUtxoSetStats(stats))
+}
+
+// dbRemoveUtxoSetStats uses an existing database transaction to remove the utxo
+// set statistics as of the block with the provided hash.
+func dbRemoveUtxoSetStats(dbTx database.Tx, hash *chainhash.Hash) error {
+	bucket := dbTx.Metadata().Bucket(utxoSetStatsBucketName)
+	return bucket.Delete(hash[:])
+}
+
+// dbFetchUtxoSetStats uses an existing database transaction to fetch the utxo
+// set statistics as of the block with the provided hash.  Nil is returned when
+// there are no statistics for the block.
+func dbFetchUtxoSetStats(dbTx database.Tx, hash *chainhash.Hash) (*utxoSetStats, error) {
+	bucket := dbTx.Metadata().Bucket(utxoSetStatsBucketName)
+	serialized := bucket.Get(hash[:])
+	if serialized == nil {
+		return nil, nil
+	}
+	return deserializeUtxoSetStats(serialized)
+}
+
+// serializeUtxoOutput returns the serialization of the output at the provided
+// index of the utxo entry for the transaction with the provided hash that is
+// added to the utxo set hash.  The format is described in detail above.
+func serializeUtxoOutput(txHash *chainhash.Hash, outputIndex uint32, entry *UtxoEntry, output *utxoOutput) []byte {
+	pkScript := output.pkScript
+	if output.compressed {
+		pkScript = decompressScript(pkScript, currentCompressionVersion)
+	}
+
+	flags := encodeFlags(entry.isCoinBase, entry.hasExpiry, entry.txType,
+		false)
+	const fixedSize = chainhash.HashSize + 4 + 2 + 4 + 4 + 1 + 8 + 2 + 4
+	serialized := make([]byte, fixedSize+len(pkScript))
+	offset := copy(serialized, txHash[:])
+	byteOrder.PutUint32(serialized[offset:], outputIndex)
+	offset += 4
+	byteOrder.PutUint16(serialized[offset:], entry.txVersion)
+	offset += 2
+	byteOrder.PutUint32(serialized[offset:], entry.height)
+	offset += 4
+	byteOrder.PutUint32(serialized[offset:], entry.index)
+	offset += 4
+	serialized[offset] = flags
+	offset++
+	byteOrder.PutUint64(serialized[offset:], uint64(output.amount))
+	offset += 8
+	byteOrder.PutUint16(serialized[offset:], output.scriptVersion)
+	offset += 2
+	byteOrder.PutUint32(serialized[offset:], uint32(len(pkScript)))
+	offset += 4
+	copy(serialized[offset:], pkScript)
+	return serialized
+}
+
+// unspentOutputs returns the serializations of all of the unspent outputs of
+// the provided utxo entry keyed by their output index.
+func unspentOutputs(txHash *chainhash.Hash, entry *UtxoEntry) map[uint32][]byte {
+	if entry == nil {
+		return nil
+	}
+	outputs := make(map[uint32][]byte, len(entry.sparseOutputs))
+	for outputIndex, output := range entry.sparseOutputs {
+		if output.spent {
+			continue
+		}
+		outputs[outputIndex] = serializeUtxoOutput(txHash, outputIndex,
+			entry, output)
+	}
+	return outputs
+}
+
+// utxoEntrySerializeSize returns the number of bytes the provided utxo entry
+// serializes to once all of its spent outputs are pruned.  The spent outputs
+// are excluded since the serialized size of entries in memory otherwise depends
+// on which spent outputs happen to still be tracked.
+func utxoEntrySerializeSize(entry *UtxoEntry) (int, error) {
+	pruned := *entry
+	pruned.sparseOutputs = make(map[uint32]*utxoOutput, len(entry.sparseOutputs))
+	for outputIndex, output := range entry.sparseOutputs {
+		if !output.spent {
+			pruned.sparseOutputs[outputIndex] = output
+		}
+	}
+	serialized, err := serializeUtxoEntry(&pruned)
+	if err != nil {
+		return 0, err
+	}
+	return len(serialized), nil
+}
+
+// applyEntryChange updates the provided statistics and utxo set hash to
+// reflect the utxo entry for the transaction with the provided hash changing
+// from the old entry to the new one.  Either entry may be nil to indicate the
+// transaction does not have any unspent outputs.
+func applyEntryChange(stats *utxoSetStats, hash *muhash.MuHash, txHash *chainhash.Hash, oldEntry, newEntry *UtxoEntry) error {
+	if oldEntry != nil && oldEntry.IsFullySpent() {
+		oldEntry = nil
+	}
+	if newEntry != nil && newEntry.IsFullySpent() {
+		newEntry = nil
+	}
+
+	// Update the number of transactions and serialized size of the utxo set
+	// to account for the change to the entry.
+	if oldEntry != nil {
+		size, err := utxoEntrySerializeSize(oldEntry)
+		if err != nil {
+			return err
+		}
+		stats.transactions--
+		stats.size -= int64(size)
+	}
+	if newEntry != nil {
+		size, err := utxoEntrySerializeSize(newEntry)
+		if err != nil {
+			return err
+		}
+		stats.transactions++
+		stats.size += int64(size)
+	}
+
+	// Add the outputs that were created and remove the outputs that were
+	// spent.  Outputs that did not change are skipped.
+	oldOutputs := unspentOutputs(txHash, oldEntry)
+	for outputIndex, serialized := range unspentOutputs(txHash, newEntry) {
+		if old, ok := oldOutputs[outputIndex]; ok {
+			delete(oldOutputs, outputIndex)
+			if string(old) == string(serialized) {
+				continue
+			}
+			stats.utxos--
+			stats.total -= oldEntry.sparseOutputs[outputIndex].amount
+			hash.Remove(old)
+		}
+		stats.utxos++
+		stats.total += newEntry.sparseOutputs[outputIndex].amount
+		hash.Add(serialized)
+	}
+	for outputIndex, serialized := range oldOutputs {
+		stats.utxos--
+		stats.total -= oldEntry.sparseOutputs[outputIndex].amount
+		hash.Remove(serialized)
+	}
+
+	return nil
+}
+
+// utxoStatsAfterView returns the statistics of the utxo set that results from
+// applying all of the modified entries in the provided view to the current utxo
+// set.  It must be called prior to the view being committed to the utxo cache
+// or the database.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) utxoStatsAfterView(view *UtxoViewpoint) (*utxoSetStats, error) {
+	// Load the current state of all of the modified entries.
+	filteredSet := make(viewFilteredSet)
+	for txHash, entry := range view.entries {
+		if entry != nil && entry.modified {
+			filteredSet[txHash] = struct{}{}
+		}
+	}
+	oldEntries := make(map[chainhash.Hash]*UtxoEntry, len(filteredSet))
+	if err := b.utxoCache.peekEntries(filteredSet, oldEntries); err != nil {
+		return nil, err
+	}
+
+	// Accumulate the changes to the utxo set hash separately and combine
+	// them with the current state at the end.
+	stats := *b.utxoStats
+	delta := muhash.New()
+	for txHash := range filteredSet {
+		hash := txHash
+		err := applyEntryChange(&stats, delta, &hash, oldEntries[hash],
+			view.entries[hash])
+		if err != nil {
+			return nil, err
+		}
+	}
+	hash, err := muhash.Deserialize(stats.hash[:])
+	if err != nil {
+		return nil, AssertError(fmt.Sprintf("invalid utxo set hash: %v", err))
+	}
+	hash.Combine(delta)
+	stats.hash = hash.Serialize()
+	return &stats, nil
+}
+
+// dbCalcUtxoSetStats uses an existing database transaction to calculate the
+// statistics of the utxo set in the database by iterating every entry.
+func dbCalcUtxoSetStats(ctx context.Context, dbTx database.Tx) (*utxoSetStats, error) {
+	var stats utxoSetStats
+	hash := muhash.New()
+	cursor := dbTx.Metadata().Bucket(utxoSetBucketName).Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		if interruptRequested(ctx) {
+			return nil, errInterruptRequested
+		}
+
+		var txHash chainhash.Hash
+		copy(txHash[:], cursor.Key())
+		entry, err := deserializeUtxoEntry(cursor.Value())
+		if err != nil {
+			// Ensure any deserialization errors are returned as
+			// database corruption errors.
+			if isDeserializeErr(err) {
+				return nil, database.Error{
+					ErrorCode: database.ErrCorruption,
+					Description: fmt.Sprintf("corrupt utxo entry "+
+						"for %v: %v", txHash, err),
+				}
+			}
+			return nil, err
+		}
+		err = applyEntryChange(&stats, hash, &txHash, nil, entry)
+		if err != nil {
+			return nil, err
+		}
+
+		if stats.transactions%utxoStatsProgressInterval == 0 {
+			log.Infof("Processed %d utxo entries", stats.transactions)
+		}
+	}
+	stats.hash = hash.Serialize()
+	return &stats, nil
+}
+
+// initUtxoStats loads the statistics of the utxo set as of the current best
+// chain tip.  The statistics are calculated from the entire utxo set when they
+// are not available, which is the case for databases that were created before
+// they were tracked and for utxo sets that were loaded from a snapshot.
+//
+// The utxo set in the database must represent the current best chain tip.
+func (b *BlockChain) initUtxoStats(ctx context.Context) error {
+	tip := b.bestChain.Tip()
+	var stats *utxoSetStats
+	err := b.db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		if meta.Bucket(utxoSetStatsBucketName) == nil {
+			_, err := meta.CreateBucket(utxoSetStatsBucketName)
+			if err != nil {
+				return err
+			}
+		}
+
+		var err error
+		stats, err = dbFetchUtxoSetStats(dbTx, &tip.hash)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if stats != nil {
+		b.utxoStats = stats
+		return nil
+	}
+
+	log.Infof("Calculating utxo set statistics.  This might take a while...")
+	err = b.db.View(func(dbTx database.Tx) error {
+		var err error
+		stats, err = dbCalcUtxoSetStats(ctx, dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	err = b.db.Update(func(dbTx database.Tx) error {
+		return dbPutUtxoSetStats(dbTx, &tip.hash, stats)
+	})
+	if err != nil {
+		return err
+	}
+	log.Infof("Calculated utxo set statistics for block %v (height %d)",
+		tip.hash, tip.height)
+
+	b.utxoStats = stats
+	return nil
+}
+
+// expiredUtxoStatsNode returns the node in the main chain that the provided
+// node causes the utxo set statistics to no longer be retained for.  Nil is
+// returned when there is no such node.
+func expiredUtxoStatsNode(node *blockNode) *blockNode {
+	return node.Ancestor(node.height - utxoStatsRetentionBlocks - 1)
+}
+
+// UtxoStats represents unspent output statistics on the utxo set as of a given
+// block.
+type UtxoStats struct {
+	// BlockHash and BlockHeight identify the block the statistics are for.
+	BlockHash   chainhash.Hash
+	BlockHeight int64
+
+	// Utxos is the number of unspent outputs.
+	Utxos int64
+
+	// Transactions is the number of transactions with unspent outputs.
+	Transactions int64
+
+	// Size is the total serialized size of the utxo set in bytes.
+	Size int64
+
+	// Total is the total amount of all unspent outputs in atoms.
+	Total int64
+
+	// MuHash is the MuHash3072 digest that commits to all of the unspent
+	// outputs.
+	MuHash chainhash.Hash
+}
+
+// newUtxoStats returns the exported statistics for the provided utxo set
+// statistics as of the provided node.
+func newUtxoStats(node *blockNode, stats *utxoSetStats) (*UtxoStats, error) {
+	hash, err := muhash.Deserialize(stats.hash[:])
+	if err != nil {
+		return nil, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set hash for block "+
+				"%v: %v", node.hash, err),
+		}
+	}
+
+	return &UtxoStats{
+		BlockHash:    node.hash,
+		BlockHeight:  node.height,
+		Utxos:        stats.utxos,
+		Transactions: stats.transactions,
+		Size:         stats.size,
+		Total:        stats.total,
+		MuHash:       hash.Digest(),
+	}, nil
+}
+
+// FetchUtxoStats returns statistics on the current utxo set.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchUtxoStats() (*UtxoStats, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	return newUtxoStats(b.bestChain.Tip(), b.utxoStats)
+}
+
+// FetchUtxoStatsByHeight returns statistics on the utxo set as of the block at
+// the provided height in the main chain.  The statistics are only retained for
+// recent blocks.  An error that wraps ErrNoUtxoStats will be returned when
+// they are not available for the block.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FetchUtxoStatsByHeight(height int64) (*UtxoStats, error) {
+	b.chainLock.RLock()
+	defer b.chainLock.RUnlock()
+
+	node := b.bestChain.NodeByHeight(height)
+	if node == nil {
+		str := fmt.Sprintf("no block at height %d exists", height)
+		return nil, errNotInMainChain(str)
+	}
+	if node == b.bestChain.Tip() {
+		return newUtxoStats(node, b.utxoStats)
+	}
+
+	var stats *utxoSetStats
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		stats, err = dbFetchUtxoSetStats(dbTx, &node.hash)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if stats == nil {
+		str := fmt.Sprintf("no utxo set statistics are available for "+
+			"block %v (height %d)", node.hash, node.height)
+		return nil, contextError(ErrNoUtxoStats, str)
+	}
+	return newUtxoStats(node, stats)
+}
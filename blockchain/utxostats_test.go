@@ -0,0 +1,208 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+// TestUtxoStats ensures the utxo set statistics that are updated incrementally
+// as blocks are connected and disconnected match the statistics calculated
+// from the entire utxo set, including when blocks disapprove their parent and
+// during reorganizations, and that the statistics for recent blocks remain
+// available.
+func TestUtxoStats(t *testing.T) {
+	const (
+		// voteBitNo and voteBitYes represent no and yes votes, respectively, on
+		// whether or not to approve the previous block.
+		voteBitNo  = 0x0000
+		voteBitYes = 0x0001
+	)
+
+	// Create a test harness initialized with the genesis block as the tip.
+	params := chaincfg.RegNetParams()
+	g, teardownFunc := newChaingenHarness(t, params, "utxostatstest")
+	defer teardownFunc()
+
+	// ---------------------------------------------------------------------
+	// Create some convenience functions to improve test readability.
+	// ---------------------------------------------------------------------
+
+	// checkStats ensures the statistics of the current utxo set match those
+	// calculated from the entire utxo set.
+	checkStats := func() {
+		t.Helper()
+
+		tip := g.chain.bestChain.Tip()
+		err := g.chain.utxoCache.maybeFlush(&tip.hash, tip.height, true)
+		if err != nil {
+			t.Fatalf("unexpected error flushing utxo cache: %v", err)
+		}
+		var want *utxoSetStats
+		err = g.chain.db.View(func(dbTx database.Tx) error {
+			var err error
+			want, err = dbCalcUtxoSetStats(context.Background(), dbTx)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("unexpected error calculating utxo stats: %v", err)
+		}
+		if *g.chain.utxoStats != *want {
+			t.Fatalf("mismatched utxo stats at height %d -- got %+v, want "+
+				"%+v", tip.height, *g.chain.utxoStats, *want)
+		}
+	}
+
+	// fetchStats returns the statistics of the utxo set as of the block at
+	// the provided height.
+	fetchStats := func(height int64) *UtxoStats {
+		t.Helper()
+
+		stats, err := g.chain.FetchUtxoStatsByHeight(height)
+		if err != nil {
+			t.Fatalf("unexpected error fetching utxo stats for height "+
+				"%d: %v", height, err)
+		}
+		return stats
+	}
+
+	// ---------------------------------------------------------------------
+	// Generate enough blocks to have tickets voting and ensure the stats
+	// match along the way.
+	// ---------------------------------------------------------------------
+
+	checkStats()
+	g.AdvanceToStakeValidationHeight()
+	checkStats()
+
+	// ---------------------------------------------------------------------
+	// Create a block that spends from the regular tree and a block that
+	// disapproves it.
+	//
+	//   ... -> b0 -> b1 (disapproves b0)
+	// ---------------------------------------------------------------------
+
+	outs := g.OldestCoinbaseOuts()
+	g.NextBlock("b0", &outs[0], outs[1:])
+	g.SaveTipCoinbaseOuts()
+	g.AcceptTipBlock()
+	checkStats()
+	b0Height := int64(g.Tip().Header.Height)
+	b0Stats, err := g.chain.FetchUtxoStats()
+	if err != nil {
+		t.Fatalf("unexpected error fetching utxo stats: %v", err)
+	}
+	if b0Stats.BlockHash != g.Tip().BlockHash() ||
+		b0Stats.BlockHeight != b0Height {
+
+		t.Fatalf("unexpected utxo stats block %v (height %d)",
+			b0Stats.BlockHash, b0Stats.BlockHeight)
+	}
+
+	outs = g.OldestCoinbaseOuts()
+	g.NextBlock("b1", nil, outs[1:], func(b *wire.MsgBlock) {
+		b.Header.VoteBits &^= voteBitYes
+		for i := 0; i < 5; i++ {
+			g.ReplaceVoteBitsN(i, voteBitNo)(b)
+		}
+	})
+	g.AssertTipDisapprovesPrevious()
+	g.AcceptTipBlock()
+	checkStats()
+	if got := fetchStats(b0Height); *got != *b0Stats {
+		t.Fatalf("mismatched utxo stats for b0 -- got %+v, want %+v",
+			*got, *b0Stats)
+	}
+
+	// ---------------------------------------------------------------------
+	// Create a side chain that approves b0 and causes a reorg.
+	//
+	//   ... -> b0 -> b1
+	//             \-> b1a -> b2a
+	// ---------------------------------------------------------------------
+
+	g.SetTip("b0")
+	outs = g.OldestCoinbaseOuts()
+	g.NextBlock("b1a", &outs[0], outs[1:])
+	g.AcceptedToSideChainWithExpectedTip("b1")
+	g.NextBlock("b2a", nil, nil)
+	g.AcceptTipBlock()
+	checkStats()
+	if got := fetchStats(b0Height); *got != *b0Stats {
+		t.Fatalf("mismatched utxo stats for b0 after reorg -- got %+v, "+
+			"want %+v", *got, *b0Stats)
+	}
+
+	// Reorg back to the original chain.
+	g.SetTip("b1")
+	g.NextBlock("b2", nil, nil)
+	g.AcceptedToSideChainWithExpectedTip("b2a")
+	g.NextBlock("b3", nil, nil)
+	g.AcceptTipBlock()
+	checkStats()
+
+	// ---------------------------------------------------------------------
+	// Ensure the stats are loaded when the chain is recreated and are
+	// calculated from the entire utxo set when they are not available.
+	// ---------------------------------------------------------------------
+
+	tipStats, err := g.chain.FetchUtxoStats()
+	if err != nil {
+		t.Fatalf("unexpected error fetching utxo stats: %v", err)
+	}
+	if err := g.chain.ShutdownUtxoCache(); err != nil {
+		t.Fatalf("unexpected error shutting down utxo cache: %v", err)
+	}
+	newChain := func() *BlockChain {
+		t.Helper()
+
+		chain, err := New(context.Background(), &Config{
+			DB:          g.chain.db,
+			ChainParams: params,
+			TimeSource:  NewMedianTime(),
+		})
+		if err != nil {
+			t.Fatalf("failed to create chain instance: %v", err)
+		}
+		return chain
+	}
+	g.chain = newChain()
+	if got, _ := g.chain.FetchUtxoStats(); *got != *tipStats {
+		t.Fatalf("mismatched utxo stats after restart -- got %+v, want %+v",
+			*got, *tipStats)
+	}
+
+	err = g.chain.db.Update(func(dbTx database.Tx) error {
+		return dbRemoveUtxoSetStats(dbTx, &tipStats.BlockHash)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error removing utxo stats: %v", err)
+	}
+	g.chain = newChain()
+	if got, _ := g.chain.FetchUtxoStats(); *got != *tipStats {
+		t.Fatalf("mismatched calculated utxo stats -- got %+v, want %+v",
+			*got, *tipStats)
+	}
+
+	// Ensure requesting stats that are no longer available fails with the
+	// expected error.
+	err = g.chain.db.Update(func(dbTx database.Tx) error {
+		return dbRemoveUtxoSetStats(dbTx, &b0Stats.BlockHash)
+	})
+	if err != nil {
+		t.Fatalf("unexpected error removing utxo stats: %v", err)
+	}
+	_, err = g.chain.FetchUtxoStatsByHeight(b0Height)
+	if !errors.Is(err, ErrNoUtxoStats) {
+		t.Fatalf("unexpected error fetching removed utxo stats -- got %v, "+
+			"want %v", err, ErrNoUtxoStats)
+	}
+}
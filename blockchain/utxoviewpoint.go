@@ -251,6 +251,12 @@ type UtxoViewpoint struct {
 	entries    map[chainhash.Hash]*UtxoEntry
 	bestHash   chainhash.Hash
 	blockChain *BlockChain
+
+	// utxoBucket is the name of the database bucket that houses the utxo
+	// set the view loads entries from when it is not the main utxo set.
+	// It is used to validate the history that leads to a loaded utxo set
+	// snapshot.
+	utxoBucket []byte
 }
 
 // BestHash returns the hash of the best block in the chain the view currently
@@ -793,16 +799,30 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, filteredSet viewFilter
 
 	// Load the unspent transaction output information for the requested set
 	// of transactions from the point of view of the end of the main chain.
+	// The utxo cache is used when the view is associated with a chain since
+	// the utxo set in the database might not include the most recent
+	// changes.
 	//
 	// NOTE: Missing entries are not considered an error here and instead
 	// will result in nil entries in the view.  This is intentionally done
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
 	// utxos that the caller needs access to.
+	if view.utxoBucket == nil && view.blockChain != nil &&
+		view.blockChain.utxoCache != nil {
+
+		return view.blockChain.utxoCache.fetchEntries(filteredSet,
+			view.entries)
+	}
+	bucketName := view.utxoBucket
+	if bucketName == nil {
+		bucketName = utxoSetBucketName
+	}
 	return db.View(func(dbTx database.Tx) error {
 		for hash := range filteredSet {
 			hashCopy := hash
-			entry, err := dbFetchUtxoEntry(dbTx, &hashCopy)
+			entry, err := dbFetchUtxoEntryFromBucket(dbTx, bucketName,
+				&hashCopy)
 			if err != nil {
 				return err
 			}
@@ -922,6 +942,7 @@ func (view *UtxoViewpoint) clone() *UtxoViewpoint {
 		entries:    make(map[chainhash.Hash]*UtxoEntry),
 		bestHash:   view.bestHash,
 		blockChain: view.blockChain,
+		utxoBucket: view.utxoBucket,
 	}
 
 	for txHash, entry := range view.entries {
@@ -1037,39 +1058,5 @@ func (b *BlockChain) FetchUtxoEntry(txHash *chainhash.Hash) (*UtxoEntry, error)
 	b.chainLock.RLock()
 	defer b.chainLock.RUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, txHash)
-		return err
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return entry, nil
-}
-
-// UtxoStats represents unspent output statistics on the current utxo set.
-type UtxoStats struct {
-	Utxos          int64
-	Transactions   int64
-	Size           int64
-	Total          int64
-	SerializedHash chainhash.Hash
-}
-
-// FetchUtxoStats returns statistics on the current utxo set.
-func (b *BlockChain) FetchUtxoStats() (*UtxoStats, error) {
-	var stats *UtxoStats
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		stats, err = dbFetchUtxoStats(dbTx)
-		return err
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return stats, nil
+	return b.utxoCache.fetchEntry(txHash)
 }
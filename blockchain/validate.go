@@ -886,7 +886,13 @@ func (b *BlockChain) checkBlockHeaderPositional(header *wire.BlockHeader, prevNo
 	// checkpoint.  This prevents storage of new, otherwise valid, blocks which
 	// build off of old blocks that are likely at a much easier difficulty and
 	// therefore could be used to waste cache and disk space.
-	if b.checkpointNode != nil && blockHeight < b.checkpointNode.height {
+	//
+	// Note that blocks in the history that leads to a loaded utxo set snapshot
+	// are exempt since they are already part of the main chain and are only
+	// being validated in the background.
+	if b.checkpointNode != nil && blockHeight < b.checkpointNode.height &&
+		!b.isHistoricalBlock(blockHeight, &blockHash) {
+
 		str := fmt.Sprintf("block at height %d forks the main chain "+
 			"before the previous checkpoint at height %d",
 			blockHeight, b.checkpointNode.height)
@@ -3509,6 +3515,13 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block, parent *dcrutil.B
 	if checkpoint != nil && node.height <= checkpoint.Height {
 		runScripts = false
 	}
+
+	// Similarly, don't run scripts if this node is an ancestor of the block
+	// that is assumed to be valid and the header chain that leads to it has
+	// sufficient work.  All other consensus checks are still performed.
+	if runScripts && b.isAssumeValidAncestor(node) {
+		runScripts = false
+	}
 	var scriptFlags txscript.ScriptFlags
 	if runScripts {
 		var err error
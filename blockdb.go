@@ -6,8 +6,12 @@
 package main
 
 import (
+	"context"
 	"encoding/binary"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io/ioutil"
 	_ "net/http/pprof"
 	"os"
 	"path/filepath"
@@ -23,6 +27,10 @@ const (
 	// database type is appended to this value to form the full block
 	// database name.
 	blockDbNamePrefix = "blocks"
+
+	// dbVerifyReportName is the name of the file in the data directory the
+	// report produced by the verifydb and repairdb options is written to.
+	dbVerifyReportName = "dbverify.json"
 )
 
 // removeDB removes the database at the provided path.  The fi parameter MUST
@@ -71,7 +79,7 @@ func warnMultipleDBs() {
 	// This is intentionally not using the known db types which depend on the
 	// database types compiled into the binary since we want to detect legacy db
 	// types as well.
-	dbTypes := []string{"ffldb", "leveldb", "sqlite"}
+	dbTypes := []string{"ffldb", "bboltdb", "leveldb", "sqlite"}
 	duplicateDbPaths := make([]string, 0, len(dbTypes)-1)
 	for _, dbType := range dbTypes {
 		if dbType == cfg.DbType {
@@ -190,6 +198,170 @@ func loadBlockDB(params *chaincfg.Params) (database.DB, error) {
 	return db, nil
 }
 
+// loadUtxoSnapshot initializes the chain state in the provided database from
+// the utxo set snapshot at the path specified by the loadutxosnapshot option.
+// It does nothing when the database was already initialized from the same
+// snapshot.
+func loadUtxoSnapshot(ctx context.Context, db database.DB, params *chaincfg.Params) error {
+	file, err := os.Open(cfg.LoadUtxoSnapshot)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return blockchain.LoadUtxoSnapshot(ctx, db, params, file)
+}
+
+// dbVerifyIssue describes an issue found while verifying the integrity of the
+// block database in the report produced by the verifydb and repairdb options.
+type dbVerifyIssue struct {
+	Kind        string `json:"kind"`
+	Hash        string `json:"hash"`
+	Height      int64  `json:"height"`
+	Description string `json:"description"`
+	Repaired    bool   `json:"repaired"`
+}
+
+// dbVerifyReport is the machine-readable report produced by the verifydb and
+// repairdb options.
+type dbVerifyReport struct {
+	DbType         string          `json:"dbtype"`
+	Network        string          `json:"network"`
+	Repair         bool            `json:"repair"`
+	NumIndexed     uint64          `json:"numindexed"`
+	NumRecovered   uint64          `json:"numrecovered"`
+	NumBlocks      uint64          `json:"numblocks"`
+	NumUnavailable uint64          `json:"numunavailable"`
+	NumUtxoEntries uint64          `json:"numutxoentries"`
+	Rebuilt        bool            `json:"rebuilt"`
+	Error          string          `json:"error,omitempty"`
+	Issues         []dbVerifyIssue `json:"issues"`
+}
+
+// writeDBVerifyReport writes the provided report as JSON to the report file in
+// the data directory.
+func writeDBVerifyReport(report *dbVerifyReport) error {
+	reportPath := filepath.Join(cfg.DataDir, dbVerifyReportName)
+	serialized, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(reportPath, serialized, 0600); err != nil {
+		return err
+	}
+	dcrdLog.Infof("Wrote database integrity report to %s", reportPath)
+	return nil
+}
+
+// verifyDB verifies the integrity of the provided block database and repairs
+// the issues that are found where possible when the repair flag is set.  The
+// stored blocks are first checked against the block index by the database
+// driver, and then the chain state is checked against the blocks in the main
+// chain.  A report of the results is written to the data directory and an
+// error is returned when any issues remain unrepaired.
+func verifyDB(ctx context.Context, db database.DB, params *chaincfg.Params, repair bool) error {
+	report := dbVerifyReport{
+		DbType:  cfg.DbType,
+		Network: params.Name,
+		Repair:  repair,
+		Issues:  make([]dbVerifyIssue, 0),
+	}
+	verifyErr := func() error {
+		dcrdLog.Info("Verifying stored blocks...")
+		var result *database.VerifyResult
+		verifyFn := func(dbTx database.Tx) error {
+			verifier, ok := dbTx.(database.BlockVerifier)
+			if !ok {
+				return fmt.Errorf("database type '%s' does not "+
+					"support verification", db.Type())
+			}
+			var err error
+			result, err = verifier.VerifyBlocks(repair)
+			return err
+		}
+		var err error
+		if repair {
+			err = db.Update(verifyFn)
+		} else {
+			err = db.View(verifyFn)
+		}
+		if err != nil {
+			return err
+		}
+		report.NumIndexed = result.NumBlocks
+		report.NumRecovered = result.NumRecovered
+		for _, issue := range result.Issues {
+			dcrdLog.Warnf("Block %s: %s", issue.Hash, issue.Description)
+			report.Issues = append(report.Issues, dbVerifyIssue{
+				Kind:        "blockindex",
+				Hash:        issue.Hash.String(),
+				Height:      -1,
+				Description: issue.Description,
+				Repaired:    issue.Repaired,
+			})
+		}
+
+		// Load the chain without any of the optional features since only
+		// the chain state is needed.
+		chain, err := blockchain.New(ctx, &blockchain.Config{
+			DB:               db,
+			ChainParams:      params,
+			TimeSource:       blockchain.NewMedianTime(),
+			UtxoCacheMaxSize: uint64(cfg.UtxoCacheMaxSize) * 1024 * 1024,
+		})
+		if err != nil {
+			return err
+		}
+		chainResult, err := chain.VerifyIntegrity(ctx, repair)
+		if err != nil {
+			return err
+		}
+		report.NumBlocks = chainResult.NumBlocks
+		report.NumUnavailable = chainResult.NumUnavailable
+		report.NumUtxoEntries = chainResult.NumUtxoEntries
+		report.Rebuilt = chainResult.Rebuilt
+		for _, issue := range chainResult.Issues {
+			report.Issues = append(report.Issues, dbVerifyIssue{
+				Kind:        string(issue.Kind),
+				Hash:        issue.Hash.String(),
+				Height:      issue.Height,
+				Description: issue.Description,
+				Repaired:    issue.Repaired,
+			})
+		}
+		return nil
+	}()
+	if verifyErr != nil {
+		report.Error = verifyErr.Error()
+	}
+	if err := writeDBVerifyReport(&report); err != nil {
+		return err
+	}
+	if verifyErr != nil {
+		return verifyErr
+	}
+
+	var numUnrepaired int
+	for i := range report.Issues {
+		if !report.Issues[i].Repaired {
+			numUnrepaired++
+		}
+	}
+	dcrdLog.Infof("Verified %d indexed blocks and %d main chain blocks "+
+		"(%d unavailable) with %d utxo entries and found %d issues",
+		report.NumIndexed, report.NumBlocks, report.NumUnavailable,
+		report.NumUtxoEntries, len(report.Issues))
+	if numUnrepaired > 0 {
+		if repair {
+			return fmt.Errorf("unable to repair %d issues -- the block "+
+				"database must be resynced", numUnrepaired)
+		}
+		return fmt.Errorf("found %d issues -- use --repairdb to repair "+
+			"them", numUnrepaired)
+	}
+	return nil
+}
+
 // dumpBlockChain dumps a map of the blockchain blocks as serialized bytes.
 func dumpBlockChain(params *chaincfg.Params, b *blockchain.BlockChain) error {
 	dcrdLog.Infof("Writing the blockchain to flat file %q.  This might take a "+
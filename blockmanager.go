@@ -20,6 +20,7 @@ import (
 	"github.com/decred/dcrd/database/v2"
 	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrd/internal/mempool"
+	"github.com/decred/dcrd/internal/metrics"
 	"github.com/decred/dcrd/internal/progresslog"
 	"github.com/decred/dcrd/internal/rpcserver"
 	peerpkg "github.com/decred/dcrd/peer/v2"
@@ -28,10 +29,30 @@ import (
 )
 
 const (
-	// minInFlightBlocks is the minimum number of blocks that should be
-	// in the request queue for headers-first mode before requesting
-	// more.
-	minInFlightBlocks = 10
+	// maxBlockDownloadWindow is the maximum number of blocks past the next
+	// block to be processed that are requested at once in headers-first
+	// mode.  Blocks in the window are requested from multiple peers in
+	// parallel and held until they are able to be processed in order.
+	maxBlockDownloadWindow = 512
+
+	// maxFetchedBlocksSize is the maximum total serialized size of the
+	// blocks received out of order in headers-first mode that are held
+	// until they are able to be processed.  Once it is reached, only the
+	// blocks needed to process the held blocks are requested.
+	maxFetchedBlocksSize = 64 * 1024 * 1024
+
+	// maxBlocksInFlightPerPeer is the maximum number of blocks that are
+	// requested from a single peer at once in headers-first mode.
+	maxBlocksInFlightPerPeer = 16
+
+	// blockStallTimeout is the amount of time a block requested in
+	// headers-first mode may remain outstanding before it is requested from
+	// another peer.
+	blockStallTimeout = 15 * time.Second
+
+	// blockStallCheckInterval is the interval at which the blocks requested
+	// in headers-first mode are checked for stalls.
+	blockStallCheckInterval = 5 * time.Second
 
 	// maxOrphanBlocks is the maximum number of orphan blocks that can be
 	// queued.
@@ -48,6 +69,11 @@ const (
 	// maxRequestedTxns is the maximum number of requested transactions
 	// hashes to store in memory.
 	maxRequestedTxns = wire.MaxInvPerMsg
+
+	// maxHistoricalBlocksInFlight is the maximum number of blocks that lead
+	// to a loaded utxo set snapshot that are requested at once while
+	// validating its history in the background.
+	maxHistoricalBlocksInFlight = 128
 )
 
 // zeroHash is the zero value hash (all zeros).  It is defined as a convenience.
@@ -197,6 +223,22 @@ type headerNode struct {
 	hash   *chainhash.Hash
 }
 
+// inFlightBlock describes a block that has been requested from a peer in
+// headers-first mode.
+type inFlightBlock struct {
+	peer      *peerpkg.Peer
+	height    int64
+	requested time.Time
+}
+
+// fetchedBlock is a block that has been received in headers-first mode along
+// with the peer that sent it.  It is held until all of the blocks before it
+// have been processed.
+type fetchedBlock struct {
+	block *dcrutil.Block
+	peer  *peerpkg.Peer
+}
+
 // peerNotifier provides an interface for server peer notifications.
 type peerNotifier interface {
 	// AnnounceNewTransactions generates and relays inventory vectors and
@@ -231,6 +273,12 @@ type blockManagerConfig struct {
 	// use of checkpoints.
 	DisableCheckpoints bool
 
+	// AssumeValid is the hash of the block for which the scripts of it and
+	// all of its ancestors are assumed to be valid.  The headers that lead
+	// to it are downloaded during the initial chain sync when it is not the
+	// zero hash.
+	AssumeValid chainhash.Hash
+
 	// NoMiningStateSync indicates whether or not the block manager should
 	// perform an initial mining state synchronization with peers once they are
 	// believed to be fully synced.
@@ -244,14 +292,20 @@ type blockManagerConfig struct {
 	// MaxOrphanTxs specifies the maximum number of orphan transactions the
 	// transaction pool associated with the server supports.
 	MaxOrphanTxs int
+
+	// ProcessBlockTime, when set, is updated with the time taken by the chain
+	// to process each block.
+	ProcessBlockTime *metrics.Histogram
 }
 
 // peerSyncState stores additional information that the blockManager tracks
 // about a peer.
 type peerSyncState struct {
-	syncCandidate   bool
-	requestedTxns   map[chainhash.Hash]struct{}
-	requestedBlocks map[chainhash.Hash]struct{}
+	syncCandidate     bool
+	requestedTxns     map[chainhash.Hash]struct{}
+	requestedBlocks   map[chainhash.Hash]struct{}
+	stalledBlocks     map[chainhash.Hash]struct{}
+	numBlocksInFlight int
 }
 
 // orphanBlock represents a block for which the parent is not yet available.  It
@@ -278,11 +332,32 @@ type blockManager struct {
 	quit            chan struct{}
 	peerStates      map[*peerpkg.Peer]*peerSyncState
 
-	// The following fields are used for headers-first mode.
-	headersFirstMode bool
-	headerList       *list.List
-	startHeader      *list.Element
-	nextCheckpoint   *chaincfg.Checkpoint
+	// The following fields are used for headers-first mode.  The headers
+	// are downloaded in rounds that end at the next checkpoint, or with
+	// each batch of headers once there are no more checkpoints.  Once all
+	// of the headers for a round are downloaded, the blocks for them are
+	// requested from all of the sync candidate peers and the ones that are
+	// received out of order are held until they are able to be processed.
+	headersFirstMode     bool
+	fetchingHeaderBlocks bool
+	headerList           *list.List
+	nextCheckpoint       *chaincfg.Checkpoint
+	inFlightBlocks       map[chainhash.Hash]*inFlightBlock
+	fetchedBlocks        map[chainhash.Hash]*fetchedBlock
+	fetchedBlocksSize    int64
+
+	// assumeValidPeer is the peer the headers that lead to the assumed
+	// valid block are being downloaded from, if any.
+	assumeValidPeer *peerpkg.Peer
+
+	// These fields are used to download the blocks that lead to a loaded
+	// utxo set snapshot once the chain is current so the history is
+	// validated in the background.  The received blocks are held until they
+	// are able to be processed in order.
+	historicalPeer      *peerpkg.Peer
+	historicalRequested map[chainhash.Hash]struct{}
+	historicalBlocks    map[chainhash.Hash]*dcrutil.Block
+	historicalInvalid   bool
 
 	// These fields are related to handling of orphan blocks.  They are
 	// protected by the orphan lock.
@@ -306,16 +381,22 @@ type blockManager struct {
 // syncing from a new peer.
 func (b *blockManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight int64) {
 	b.headersFirstMode = false
+	b.fetchingHeaderBlocks = false
 	b.headerList.Init()
-	b.startHeader = nil
-
-	// When there is a next checkpoint, add an entry for the latest known
-	// block into the header pool.  This allows the next downloaded header
-	// to prove it links to the chain properly.
-	if b.nextCheckpoint != nil {
-		node := headerNode{height: newestHeight, hash: newestHash}
-		b.headerList.PushBack(&node)
+	for _, req := range b.inFlightBlocks {
+		if state, exists := b.peerStates[req.peer]; exists {
+			state.numBlocksInFlight--
+		}
 	}
+	b.inFlightBlocks = make(map[chainhash.Hash]*inFlightBlock)
+	b.fetchedBlocks = make(map[chainhash.Hash]*fetchedBlock)
+	b.fetchedBlocksSize = 0
+
+	// Add an entry for the latest known block into the header pool.  This
+	// allows the next downloaded header to prove it links to the chain
+	// properly.
+	node := headerNode{height: newestHeight, hash: newestHash}
+	b.headerList.PushBack(&node)
 }
 
 // SyncHeight returns latest known block being synced to.
@@ -411,6 +492,7 @@ func (b *blockManager) startSync() {
 		b.isCurrentMtx.Lock()
 		b.isCurrent = b.cfg.Chain.IsCurrent()
 		b.isCurrentMtx.Unlock()
+		b.fetchHistoricalBlocks()
 	}
 
 	// Start syncing from the best peer if one was selected.
@@ -439,37 +521,37 @@ func (b *blockManager) startSync() {
 			b.isCurrentMtx.Unlock()
 		}
 
-		// When the current height is less than a known checkpoint we
-		// can use block headers to learn about which blocks comprise
-		// the chain up to the checkpoint and perform less validation
-		// for them.  This is possible since each header contains the
+		// When the peer has blocks that are not yet known, use block
+		// headers to learn about which blocks comprise the chain so the
+		// blocks can be requested from all of the sync candidate peers
+		// in parallel.  This is possible since each header contains the
 		// hash of the previous header and a merkle root.  Therefore if
 		// we validate all of the received headers link together
-		// properly and the checkpoint hashes match, we can be sure the
-		// hashes for the blocks in between are accurate.  Further, once
-		// the full blocks are downloaded, the merkle root is computed
-		// and compared against the value in the header which proves the
-		// full block hasn't been tampered with.
+		// properly, we can be sure the hashes for the blocks in between
+		// are accurate.  Further, once the full blocks are downloaded,
+		// the merkle root is computed and compared against the value in
+		// the header which proves the full block hasn't been tampered
+		// with.
 		//
+		// When the current height is less than a known checkpoint, the
+		// headers are downloaded up to the checkpoint and less
+		// validation is performed for the blocks since the checkpoint
+		// hash matching proves the headers are part of the main chain.
 		// Once we have passed the final checkpoint, or checkpoints are
-		// disabled, use standard inv messages learn about the blocks
-		// and fully validate them.  Finally, regression test mode does
-		// not support the headers-first approach so do normal block
-		// downloads when in regression test mode.
-		if b.nextCheckpoint != nil &&
-			best.Height < b.nextCheckpoint.Height &&
-			!b.cfg.DisableCheckpoints {
-
-			err := bestPeer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+		// disabled, the headers are downloaded in batches up to the end
+		// of the chain and the blocks are fully validated.  Finally,
+		// use standard inv messages to learn about the blocks once the
+		// peer does not have any more headers.
+		if best.Height < bestPeer.LastBlock() {
+			b.nextCheckpoint = b.findNextHeaderCheckpoint(best.Height)
+			b.resetHeaderState(&best.Hash, best.Height)
+			err := b.fetchNextHeaders(bestPeer, locator, best.Height)
 			if err != nil {
 				bmgrLog.Errorf("Failed to push getheadermsg for the "+
 					"latest blocks: %v", err)
 				return
 			}
 			b.headersFirstMode = true
-			bmgrLog.Infof("Downloading headers for blocks %d to "+
-				"%d from peer %s", best.Height+1,
-				b.nextCheckpoint.Height, bestPeer.Addr())
 		} else {
 			err := bestPeer.PushGetBlocksMsg(locator, &zeroHash)
 			if err != nil {
@@ -477,6 +559,7 @@ func (b *blockManager) startSync() {
 					"latest blocks: %v", err)
 				return
 			}
+			b.fetchAssumeValidHeaders(bestPeer, locator)
 		}
 		b.syncPeer = bestPeer
 		b.syncHeightMtx.Lock()
@@ -487,6 +570,175 @@ func (b *blockManager) startSync() {
 	}
 }
 
+// fetchAssumeValidHeaders requests the headers that lead to the assumed valid
+// block from the provided peer when the chain needs them and they are not
+// already being downloaded.  This allows script validation to be skipped for
+// the ancestors of the assumed valid block as they are downloaded.
+func (b *blockManager) fetchAssumeValidHeaders(peer *peerpkg.Peer, locator []chainhash.Hash) {
+	if b.assumeValidPeer != nil || !b.cfg.Chain.NeedAssumeValidHeaders() {
+		return
+	}
+
+	assumeValid := b.cfg.AssumeValid
+	err := peer.PushGetHeadersMsg(locator, &assumeValid)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getheaders message to peer %s: %v",
+			peer.Addr(), err)
+		return
+	}
+	b.assumeValidPeer = peer
+	bmgrLog.Infof("Downloading headers to assumed valid block %v from "+
+		"peer %s", assumeValid, peer.Addr())
+}
+
+// handleAssumeValidHeaders processes the headers that lead to the assumed
+// valid block and requests the next batch from the peer until the assumed
+// valid block is reached.
+func (b *blockManager) handleAssumeValidHeaders(peer *peerpkg.Peer, headers []*wire.BlockHeader) {
+	// The peer does not know about the assumed valid block when it does
+	// not have any more headers.  The headers will be requested from the
+	// next sync peer.
+	if len(headers) == 0 {
+		bmgrLog.Debugf("Peer %s did not provide headers to assumed valid "+
+			"block %v", peer.Addr(), b.cfg.AssumeValid)
+		b.assumeValidPeer = nil
+		return
+	}
+
+	done, err := b.processAssumeValidHeaders(headers)
+	if err != nil {
+		bmgrLog.Warnf("Received invalid headers to assumed valid block "+
+			"from peer %s: %v -- disconnecting", peer.Addr(), err)
+		b.assumeValidPeer = nil
+		peer.Disconnect()
+		return
+	}
+	if done {
+		b.assumeValidPeer = nil
+		return
+	}
+
+	// Request the next batch of headers starting from the latest one.
+	finalHash := headers[len(headers)-1].BlockHash()
+	locator := []chainhash.Hash{finalHash}
+	assumeValid := b.cfg.AssumeValid
+	err = peer.PushGetHeadersMsg(locator, &assumeValid)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getheaders message to peer %s: %v",
+			peer.Addr(), err)
+		b.assumeValidPeer = nil
+	}
+}
+
+// processAssumeValidHeaders passes the provided headers that are expected to
+// lead to the assumed valid block to the chain and returns whether or not the
+// assumed valid block has been reached.
+func (b *blockManager) processAssumeValidHeaders(headers []*wire.BlockHeader) (bool, error) {
+	blockHeaders := make([]wire.BlockHeader, 0, len(headers))
+	for _, header := range headers {
+		blockHeaders = append(blockHeaders, *header)
+	}
+	return b.cfg.Chain.ProcessAssumeValidHeaders(blockHeaders)
+}
+
+// resetHistoricalState discards the state used to download the blocks that lead
+// to a loaded utxo set snapshot so they are requested again.
+func (b *blockManager) resetHistoricalState() {
+	b.historicalPeer = nil
+	b.historicalRequested = make(map[chainhash.Hash]struct{})
+	b.historicalBlocks = make(map[chainhash.Hash]*dcrutil.Block)
+}
+
+// fetchHistoricalBlocks requests the next batch of blocks that lead to a loaded
+// utxo set snapshot when the chain is current and there are no outstanding
+// requests.  The sync peer is preferred, but any full node peer is used when
+// there is no sync peer.
+func (b *blockManager) fetchHistoricalBlocks() {
+	if b.historicalInvalid || len(b.historicalRequested) > 0 ||
+		!b.IsCurrent() {
+
+		return
+	}
+
+	hashes := b.cfg.Chain.NextHistoricalBlocks(maxHistoricalBlocksInFlight)
+	if len(hashes) == 0 {
+		return
+	}
+	peer := b.historicalPeer
+	if peer == nil || !peer.Connected() {
+		peer = b.syncPeer
+	}
+	if peer == nil {
+		for p := range b.peerStates {
+			if b.isSyncCandidate(p) {
+				peer = p
+				break
+			}
+		}
+	}
+	if peer == nil {
+		return
+	}
+
+	b.historicalBlocks = make(map[chainhash.Hash]*dcrutil.Block)
+	gdmsg := wire.NewMsgGetDataSizeHint(uint(len(hashes)))
+	for i := range hashes {
+		iv := wire.NewInvVect(wire.InvTypeBlock, &hashes[i])
+		if err := gdmsg.AddInvVect(iv); err != nil {
+			bmgrLog.Warnf("Failed to add invvect while fetching historical "+
+				"blocks: %v", err)
+			break
+		}
+		b.historicalRequested[hashes[i]] = struct{}{}
+	}
+	if b.historicalPeer != peer {
+		bmgrLog.Infof("Downloading the history that leads to the utxo set "+
+			"snapshot from peer %s", peer.Addr())
+	}
+	b.historicalPeer = peer
+	peer.QueueMessage(gdmsg, nil)
+}
+
+// handleHistoricalBlock processes a requested block that leads to a loaded utxo
+// set snapshot along with any previously received blocks that follow it in
+// order and requests the next batch once all of the requested blocks have been
+// received.
+func (b *blockManager) handleHistoricalBlock(peer *peerpkg.Peer, block *dcrutil.Block) {
+	delete(b.historicalRequested, *block.Hash())
+	b.historicalBlocks[*block.Hash()] = block
+	for {
+		next := b.cfg.Chain.NextHistoricalBlocks(1)
+		if len(next) == 0 {
+			break
+		}
+		block, ok := b.historicalBlocks[next[0]]
+		if !ok {
+			break
+		}
+		delete(b.historicalBlocks, next[0])
+
+		err := b.cfg.Chain.ProcessHistoricalBlock(block)
+		if errors.Is(err, blockchain.ErrSnapshotMismatch) {
+			bmgrLog.Criticalf("Loaded utxo set snapshot is invalid: %v -- "+
+				"the database must be removed and the chain synced again",
+				err)
+			b.historicalInvalid = true
+			b.resetHistoricalState()
+			return
+		}
+		if err != nil {
+			bmgrLog.Errorf("Failed to process historical block %v from %s: "+
+				"%v", block.Hash(), peer, err)
+			b.resetHistoricalState()
+			return
+		}
+	}
+
+	if len(b.historicalRequested) == 0 {
+		b.fetchHistoricalBlocks()
+	}
+}
+
 // isSyncCandidate returns whether or not the peer is a candidate to consider
 // syncing from.
 func (b *blockManager) isSyncCandidate(peer *peerpkg.Peer) bool {
@@ -563,11 +815,16 @@ func (b *blockManager) handleNewPeerMsg(peer *peerpkg.Peer) {
 		syncCandidate:   isSyncCandidate,
 		requestedTxns:   make(map[chainhash.Hash]struct{}),
 		requestedBlocks: make(map[chainhash.Hash]struct{}),
+		stalledBlocks:   make(map[chainhash.Hash]struct{}),
 	}
 
-	// Start syncing by choosing the best candidate if needed.
+	// Start syncing by choosing the best candidate if needed.  Otherwise,
+	// make use of the new peer to download the blocks for the headers when
+	// in headers-first mode.
 	if isSyncCandidate && b.syncPeer == nil {
 		b.startSync()
+	} else if isSyncCandidate && b.headersFirstMode {
+		b.fetchHeaderBlocks()
 	}
 
 	// Grab the mining state from this peer once synced when enabled.
@@ -604,9 +861,20 @@ func (b *blockManager) handleDonePeerMsg(peer *peerpkg.Peer) {
 		delete(b.requestedBlocks, blockHash)
 	}
 
+	// Remove the blocks requested from the peer in headers-first mode so
+	// they are requested from the remaining peers.
+	for blockHash, req := range b.inFlightBlocks {
+		if req.peer == peer {
+			delete(b.inFlightBlocks, blockHash)
+		}
+	}
+
 	// Attempt to find a new peer to sync from if the quitting peer is the
 	// sync peer.  Also, reset the headers-first state if in headers-first
 	// mode so
+	if b.assumeValidPeer == peer {
+		b.assumeValidPeer = nil
+	}
 	if b.syncPeer == peer {
 		b.syncPeer = nil
 		if b.headersFirstMode {
@@ -614,6 +882,15 @@ func (b *blockManager) handleDonePeerMsg(peer *peerpkg.Peer) {
 			b.resetHeaderState(&best.Hash, best.Height)
 		}
 		b.startSync()
+	} else if b.headersFirstMode {
+		b.fetchHeaderBlocks()
+	}
+
+	// Request the history that leads to a loaded utxo set snapshot from
+	// another peer when the quitting peer was providing it.
+	if b.historicalPeer == peer {
+		b.resetHistoricalState()
+		b.fetchHistoricalBlocks()
 	}
 }
 
@@ -920,7 +1197,7 @@ func (b *blockManager) processOrphans(hash *chainhash.Hash, flags blockchain.Beh
 			i--
 
 			// Potentially accept the block into the block chain.
-			_, err := b.cfg.Chain.ProcessBlock(orphan.block, flags)
+			_, err := b.timedProcessBlock(orphan.block, flags)
 			if err != nil {
 				return err
 			}
@@ -933,6 +1210,18 @@ func (b *blockManager) processOrphans(hash *chainhash.Hash, flags blockchain.Beh
 	return nil
 }
 
+// timedProcessBlock processes the provided block using the internal chain
+// instance and records the time it took in the configured process block time
+// metric, if any.
+func (b *blockManager) timedProcessBlock(block *dcrutil.Block, flags blockchain.BehaviorFlags) (int64, error) {
+	start := time.Now()
+	forkLen, err := b.cfg.Chain.ProcessBlock(block, flags)
+	if b.cfg.ProcessBlockTime != nil {
+		b.cfg.ProcessBlockTime.Observe(time.Since(start).Seconds())
+	}
+	return forkLen, err
+}
+
 // processBlockAndOrphans processes the provided block using the internal chain
 // instance while keeping track of orphan blocks and also processing any orphans
 // that depend on the passed block to potentially accept as well.
@@ -950,7 +1239,7 @@ func (b *blockManager) processBlockAndOrphans(block *dcrutil.Block, flags blockc
 	// Also, keep track of orphan blocks in the block manager when the error
 	// returned indicates the block is an orphan.
 	blockHash := block.Hash()
-	forkLen, err := b.cfg.Chain.ProcessBlock(block, flags)
+	forkLen, err := b.timedProcessBlock(block, flags)
 	if errors.Is(err, blockchain.ErrMissingParent) {
 		bmgrLog.Infof("Adding orphan block %v with parent %v", blockHash,
 			block.MsgBlock().Header.PrevBlock)
@@ -992,37 +1281,35 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		return
 	}
 
-	// If we didn't ask for this block then the peer is misbehaving.
+	// Blocks that lead to a loaded utxo set snapshot are handled separately
+	// since they are processed independently of the main chain.
 	blockHash := bmsg.block.Hash()
-	if _, exists := state.requestedBlocks[*blockHash]; !exists {
+	if peer == b.historicalPeer {
+		if _, exists := b.historicalRequested[*blockHash]; exists {
+			b.handleHistoricalBlock(peer, bmsg.block)
+			return
+		}
+	}
+
+	// If we didn't ask for this block then the peer is misbehaving.  Note
+	// that blocks that stalled in headers-first mode are still accepted from
+	// the peer they were originally requested from even though they have
+	// since been requested from another peer.
+	_, requested := state.requestedBlocks[*blockHash]
+	_, stalled := state.stalledBlocks[*blockHash]
+	if !requested && !stalled {
 		bmgrLog.Warnf("Got unrequested block %v from %s -- "+
 			"disconnecting", blockHash, bmsg.peer.Addr())
 		bmsg.peer.Disconnect()
 		return
 	}
+	delete(state.stalledBlocks, *blockHash)
 
-	// When in headers-first mode, if the block matches the hash of the
-	// first header in the list of headers that are being fetched, it's
-	// eligible for less validation since the headers have already been
-	// verified to link together and are valid up to the next checkpoint.
-	// Also, remove the list entry for all blocks except the checkpoint
-	// since it is needed to verify the next round of headers links
-	// properly.
-	isCheckpointBlock := false
-	behaviorFlags := blockchain.BFNone
+	// Blocks for the headers that are being fetched in headers-first mode are
+	// held until all of the blocks before them have been processed.
 	if b.headersFirstMode {
-		firstNodeEl := b.headerList.Front()
-		if firstNodeEl != nil {
-			firstNode := firstNodeEl.Value.(*headerNode)
-			if blockHash.IsEqual(firstNode.hash) {
-				behaviorFlags |= blockchain.BFFastAdd
-				if firstNode.hash.IsEqual(b.nextCheckpoint.Hash) {
-					isCheckpointBlock = true
-				} else {
-					b.headerList.Remove(firstNodeEl)
-				}
-			}
-		}
+		b.handleHeaderBlock(peer, state, bmsg.block)
+		return
 	}
 
 	// Remove block from request maps. Either chain will know about it and
@@ -1032,8 +1319,21 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	delete(b.requestedBlocks, *blockHash)
 
 	// Process the block to include validation, best chain selection, orphan
-	// handling, etc.
-	forkLen, isOrphan, err := b.processBlockAndOrphans(bmsg.block, behaviorFlags)
+	// handling, etc.  Nothing more to do aside from downloading the history
+	// that leads to a loaded utxo set snapshot once the chain is current.
+	b.processPeerBlock(peer, bmsg.block, blockchain.BFNone)
+	b.fetchHistoricalBlocks()
+}
+
+// processPeerBlock processes the provided block received from the provided
+// peer to include validation, best chain selection, orphan handling, etc. and
+// updates the chain state accordingly.  A reject message is sent to the peer
+// when the block is rejected.
+//
+// The error from processing the block, if any, is returned.
+func (b *blockManager) processPeerBlock(peer *peerpkg.Peer, block *dcrutil.Block, flags blockchain.BehaviorFlags) error {
+	blockHash := block.Hash()
+	forkLen, isOrphan, err := b.processBlockAndOrphans(block, flags)
 	if err != nil {
 		// When the error is a rule error, it means the block was simply
 		// rejected as opposed to something actually going wrong, so log
@@ -1057,7 +1357,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		// send it.
 		code, reason := errToWireRejectCode(err)
 		peer.PushRejectMsg(wire.CmdBlock, code, reason, blockHash, false)
-		return
+		return err
 	}
 
 	// Request the parents for the orphan block from the peer that sent it.
@@ -1079,7 +1379,7 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	} else {
 		// When the block is not an orphan, log information about it and
 		// update the chain state.
-		b.progressLogger.LogBlockHeight(bmsg.block.MsgBlock(), b.SyncHeight())
+		b.progressLogger.LogBlockHeight(block.MsgBlock(), b.SyncHeight())
 
 		if onMainChain {
 			// Notify stake difficulty subscribers and prune invalidated
@@ -1113,61 +1413,185 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	// the block was accepted to the main chain, update the heights of other
 	// peers whose invs may have been ignored when actively syncing while the
 	// chain was not yet current or lost the lock announcement race.
-	blockHeight := int64(bmsg.block.MsgBlock().Header.Height)
+	blockHeight := int64(block.MsgBlock().Header.Height)
 	peer.UpdateLastBlockHeight(blockHeight)
 	if isOrphan || (onMainChain && b.IsCurrent()) {
-		go b.cfg.PeerNotifier.UpdatePeerHeights(blockHash, blockHeight,
-			bmsg.peer)
+		go b.cfg.PeerNotifier.UpdatePeerHeights(blockHash, blockHeight, peer)
 	}
 
-	// Nothing more to do if we aren't in headers-first mode.
-	if !b.headersFirstMode {
-		return
-	}
+	return nil
+}
 
-	// This is headers-first mode, so if the block is not a checkpoint
-	// request more blocks using the header list when the request queue is
-	// getting short.
-	if !isCheckpointBlock {
-		if b.startHeader != nil &&
-			len(state.requestedBlocks) < minInFlightBlocks {
-			b.fetchHeaderBlocks()
+// handleHeaderBlock handles a requested block for one of the headers that are
+// being fetched in headers-first mode.  The block is held until all of the
+// blocks before it have been processed, at which point all of the blocks that
+// are able to be processed in order are processed and more blocks are
+// requested to keep the download window full.
+func (b *blockManager) handleHeaderBlock(peer *peerpkg.Peer, state *peerSyncState, block *dcrutil.Block) {
+	blockHash := block.Hash()
+	delete(state.requestedBlocks, *blockHash)
+	delete(b.requestedBlocks, *blockHash)
+	req, exists := b.inFlightBlocks[*blockHash]
+	if !exists {
+		// Ignore the block when it is no longer needed.  This happens when
+		// a stalled block that was requested from another peer is received
+		// from both peers.
+		_, fetched := b.fetchedBlocks[*blockHash]
+		if fetched || b.cfg.Chain.HaveBlock(blockHash) {
+			bmgrLog.Debugf("Ignoring block %v from %s that is no longer "+
+				"needed", blockHash, peer)
+			return
 		}
+
+		// Otherwise, the block is not for one of the headers, so process
+		// it normally.
+		b.processPeerBlock(peer, block, blockchain.BFNone)
 		return
 	}
+	// The block might have stalled and been requested from another peer
+	// since it was originally requested from this one, so also update the
+	// state of the peer it is currently in flight from as needed.  That peer
+	// is still allowed to deliver the block, in which case it is ignored.
+	if reqState, exists := b.peerStates[req.peer]; exists {
+		reqState.numBlocksInFlight--
+		if req.peer != peer {
+			delete(reqState.requestedBlocks, *blockHash)
+			limitAdd(reqState.stalledBlocks, *blockHash, maxRequestedBlocks)
+		}
+	}
+	delete(b.inFlightBlocks, *blockHash)
+	b.fetchedBlocks[*blockHash] = &fetchedBlock{block: block, peer: peer}
+	b.fetchedBlocksSize += int64(block.MsgBlock().SerializeSize())
 
-	// This is headers-first mode and the block is a checkpoint.  When
-	// there is a next checkpoint, get the next round of headers by asking
-	// for headers starting from the block after this one up to the next
-	// checkpoint.
-	prevHeight := b.nextCheckpoint.Height
-	prevHash := b.nextCheckpoint.Hash
-	b.nextCheckpoint = b.findNextHeaderCheckpoint(prevHeight)
-	if b.nextCheckpoint != nil {
-		locator := []chainhash.Hash{*prevHash}
-		err := peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+	b.processFetchedBlocks()
+	if b.headersFirstMode {
+		b.fetchHeaderBlocks()
+	}
+}
+
+// processFetchedBlocks processes the blocks that have been received in
+// headers-first mode in the order of the headers until reaching one that has
+// not been received yet.  The blocks up to the next checkpoint are eligible for
+// less validation since the headers have already been verified to link
+// together and are valid up to the checkpoint.
+//
+// The entry for each processed block is removed from the list of headers
+// except the final one since it is needed to verify the next round of headers
+// links properly.
+func (b *blockManager) processFetchedBlocks() {
+	for {
+		e := b.headerList.Front()
+		if e == nil {
+			return
+		}
+		node := e.Value.(*headerNode)
+		fetched, exists := b.fetchedBlocks[*node.hash]
+		if !exists {
+			return
+		}
+		delete(b.fetchedBlocks, *node.hash)
+		b.fetchedBlocksSize -= int64(fetched.block.MsgBlock().SerializeSize())
+
+		// A block that is rejected does not match its header when the
+		// header has been verified against a checkpoint, so disconnect
+		// the peer that sent it so the block is requested from another
+		// one.  Otherwise, the sync peer that provided the header might
+		// be on an invalid chain, so disconnect it as well in order to
+		// restart the sync from another peer.
+		flags := blockchain.BFNone
+		if b.nextCheckpoint != nil {
+			flags |= blockchain.BFFastAdd
+		}
+		err := b.processPeerBlock(fetched.peer, fetched.block, flags)
 		if err != nil {
-			bmgrLog.Warnf("Failed to send getheaders message to "+
-				"peer %s: %v", peer.Addr(), err)
+			var rErr blockchain.RuleError
+			if errors.As(err, &rErr) {
+				if b.nextCheckpoint == nil && b.syncPeer != nil &&
+					b.syncPeer != fetched.peer {
+
+					bmgrLog.Warnf("Peer %s provided the header for "+
+						"invalid block %v -- disconnecting",
+						b.syncPeer.Addr(), node.hash)
+					b.syncPeer.Disconnect()
+				}
+				bmgrLog.Warnf("Peer %s sent invalid block %v for a "+
+					"downloaded header -- disconnecting",
+					fetched.peer.Addr(), node.hash)
+				fetched.peer.Disconnect()
+			}
 			return
 		}
-		bmgrLog.Infof("Downloading headers for blocks %d to %d from peer %s",
-			prevHeight+1, b.nextCheckpoint.Height, b.syncPeer.Addr())
+
+		if e != b.headerList.Back() {
+			b.headerList.Remove(e)
+			continue
+		}
+		b.handleFinalHeaderBlock(node)
 		return
 	}
+}
 
-	// This is headers-first mode, the block is a checkpoint, and there are
-	// no more checkpoints, so switch to normal mode by requesting blocks
-	// from the block after this one up to the end of the chain (zero hash).
+// fetchNextHeaders requests the headers after the provided block locator, which
+// starts from the block at the provided height, from the provided peer in
+// headers-first mode.  The headers are requested up to the next checkpoint
+// when there is one and up to the end of the chain otherwise.
+func (b *blockManager) fetchNextHeaders(peer *peerpkg.Peer, locator []chainhash.Hash, height int64) error {
+	if b.nextCheckpoint == nil {
+		err := peer.PushGetHeadersMsg(locator, &zeroHash)
+		if err != nil {
+			return err
+		}
+		bmgrLog.Debugf("Downloading headers for blocks after %d from peer "+
+			"%s", height, peer.Addr())
+		return nil
+	}
+
+	err := peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+	if err != nil {
+		return err
+	}
+	bmgrLog.Infof("Downloading headers for blocks %d to %d from peer %s",
+		height+1, b.nextCheckpoint.Height, peer.Addr())
+	return nil
+}
+
+// handleFinalHeaderBlock requests the next round of headers from the sync peer
+// once the block for the final header in the list has been processed in
+// headers-first mode.  The final header is either the next checkpoint or, when
+// there are no more checkpoints, the final header of the most recent batch of
+// headers.
+func (b *blockManager) handleFinalHeaderBlock(node *headerNode) {
+	b.fetchingHeaderBlocks = false
+	if b.nextCheckpoint != nil && node.hash.IsEqual(b.nextCheckpoint.Hash) {
+		b.nextCheckpoint = b.findNextHeaderCheckpoint(node.height)
+		if b.nextCheckpoint == nil {
+			bmgrLog.Infof("Reached the final checkpoint -- fully " +
+				"validating blocks")
+		}
+	}
+
+	locator := []chainhash.Hash{*node.hash}
+	err := b.fetchNextHeaders(b.syncPeer, locator, node.height)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getheaders message to peer %s: %v",
+			b.syncPeer.Addr(), err)
+	}
+}
+
+// handleHeadersDone switches to normal mode by requesting blocks from the block
+// for the final header up to the end of the chain (zero hash) from the sync
+// peer once it does not have any more headers and all of the blocks for the
+// headers have been processed in headers-first mode.
+func (b *blockManager) handleHeadersDone() {
+	finalNode := b.headerList.Front().Value.(*headerNode)
 	b.headersFirstMode = false
 	b.headerList.Init()
-	bmgrLog.Infof("Reached the final checkpoint -- switching to normal mode")
-	locator := []chainhash.Hash{*blockHash}
-	err = bmsg.peer.PushGetBlocksMsg(locator, &zeroHash)
+	bmgrLog.Infof("Received all block headers -- switching to normal mode")
+	locator := []chainhash.Hash{*finalNode.hash}
+	err := b.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
 	if err != nil {
 		bmgrLog.Warnf("Failed to send getblocks message to peer %s: %v",
-			peer.Addr(), err)
-		return
+			b.syncPeer.Addr(), err)
 	}
 }
 
@@ -1191,27 +1615,95 @@ func (b *blockManager) proactivelyEvictSigCacheEntries(bestHeight int64) {
 	b.cfg.SigCache.EvictEntries(block.MsgBlock())
 }
 
-// fetchHeaderBlocks creates and sends a request to the syncPeer for the next
-// list of blocks to be downloaded based on the current list of headers.
+// blockDownloadPeer returns the sync candidate peer with the fewest blocks in
+// flight that is able to provide the block at the provided height in
+// headers-first mode, excluding the provided peer.  It returns nil when all of
+// the peers that have the block already have the maximum number of blocks in
+// flight.
+func (b *blockManager) blockDownloadPeer(height int64, exclude *peerpkg.Peer) *peerpkg.Peer {
+	var bestPeer *peerpkg.Peer
+	var bestState *peerSyncState
+	for peer, state := range b.peerStates {
+		if peer == exclude || !state.syncCandidate || !peer.Connected() ||
+			peer.LastBlock() < height ||
+			state.numBlocksInFlight >= maxBlocksInFlightPerPeer {
+
+			continue
+		}
+		if bestPeer == nil ||
+			state.numBlocksInFlight < bestState.numBlocksInFlight {
+
+			bestPeer, bestState = peer, state
+		}
+	}
+	return bestPeer
+}
+
+// requestHeaderBlock adds a request for the block with the provided hash and
+// height to the getdata message for the provided peer in the provided map and
+// tracks it as in flight.
+func (b *blockManager) requestHeaderBlock(peer *peerpkg.Peer, hash *chainhash.Hash, height int64, gdmsgs map[*peerpkg.Peer]*wire.MsgGetData) {
+	gdmsg, exists := gdmsgs[peer]
+	if !exists {
+		gdmsg = wire.NewMsgGetDataSizeHint(maxBlocksInFlightPerPeer)
+		gdmsgs[peer] = gdmsg
+	}
+	iv := wire.NewInvVect(wire.InvTypeBlock, hash)
+	if err := gdmsg.AddInvVect(iv); err != nil {
+		bmgrLog.Warnf("Failed to add invvect while fetching block "+
+			"headers: %v", err)
+		return
+	}
+
+	state := b.peerStates[peer]
+	state.requestedBlocks[*hash] = struct{}{}
+	state.numBlocksInFlight++
+	b.requestedBlocks[*hash] = struct{}{}
+	b.inFlightBlocks[*hash] = &inFlightBlock{
+		peer:      peer,
+		height:    height,
+		requested: time.Now(),
+	}
+}
+
+// fetchHeaderBlocks requests the blocks for the headers in the download window,
+// which starts at the next block to be processed, that have not already been
+// requested or received.  The requests are spread across all of the sync
+// candidate peers that are able to provide the blocks so a single slow peer
+// does not throttle the download.
+//
+// Nothing is requested while the headers for the current round are still
+// being downloaded.  Also, once the blocks that are held until they are able
+// to be processed reach the maximum allowed size, only the blocks before the
+// first held block are requested so memory usage remains bounded.
 func (b *blockManager) fetchHeaderBlocks() {
-	// Nothing to do if there is no start header.
-	if b.startHeader == nil {
-		bmgrLog.Warnf("fetchHeaderBlocks called with no start header")
+	if !b.fetchingHeaderBlocks {
 		return
 	}
 
-	// Build up a getdata request for the list of blocks the headers
-	// describe.  The size hint will be limited to wire.MaxInvPerMsg by
-	// the function, so no need to double check it here.
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(b.headerList.Len()))
-	numRequested := 0
-	for e := b.startHeader; e != nil; e = e.Next() {
+	gdmsgs := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	fetchedFull := b.fetchedBlocksSize >= maxFetchedBlocksSize
+	var windowLen int
+	for e := b.headerList.Front(); e != nil; e = e.Next() {
+		if windowLen >= maxBlockDownloadWindow {
+			break
+		}
+		windowLen++
+
 		node, ok := e.Value.(*headerNode)
 		if !ok {
 			bmgrLog.Warn("Header list node type is not a headerNode")
 			continue
 		}
-
+		if _, exists := b.fetchedBlocks[*node.hash]; exists {
+			if fetchedFull {
+				break
+			}
+			continue
+		}
+		if _, exists := b.inFlightBlocks[*node.hash]; exists {
+			continue
+		}
 		iv := wire.NewInvVect(wire.InvTypeBlock, node.hash)
 		haveInv, err := b.haveInventory(iv)
 		if err != nil {
@@ -1220,24 +1712,60 @@ func (b *blockManager) fetchHeaderBlocks() {
 				"fetch: %v", err)
 			continue
 		}
-		if !haveInv {
-			b.requestedBlocks[*node.hash] = struct{}{}
-			syncPeerState := b.peerStates[b.syncPeer]
-			syncPeerState.requestedBlocks[*node.hash] = struct{}{}
-			err = gdmsg.AddInvVect(iv)
-			if err != nil {
-				bmgrLog.Warnf("Failed to add invvect while fetching "+
-					"block headers: %v", err)
-			}
-			numRequested++
+		if haveInv {
+			continue
 		}
-		b.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg {
+
+		// Stop once all of the peers that are able to provide the block
+		// already have the maximum number of blocks in flight.
+		peer := b.blockDownloadPeer(node.height, nil)
+		if peer == nil {
 			break
 		}
+		b.requestHeaderBlock(peer, node.hash, node.height, gdmsgs)
 	}
-	if len(gdmsg.InvList) > 0 {
-		b.syncPeer.QueueMessage(gdmsg, nil)
+	for peer, gdmsg := range gdmsgs {
+		if len(gdmsg.InvList) > 0 {
+			peer.QueueMessage(gdmsg, nil)
+		}
+	}
+}
+
+// handleStalledBlocks requests the blocks that have been outstanding for longer
+// than the stall timeout in headers-first mode from another peer.  The request
+// is removed from the stalled peer, although it is still allowed to deliver the
+// block, in which case whichever copy arrives second is ignored.
+func (b *blockManager) handleStalledBlocks() {
+	if !b.headersFirstMode {
+		return
+	}
+
+	now := time.Now()
+	gdmsgs := make(map[*peerpkg.Peer]*wire.MsgGetData)
+	for blockHash, req := range b.inFlightBlocks {
+		if now.Sub(req.requested) < blockStallTimeout {
+			continue
+		}
+		peer := b.blockDownloadPeer(req.height, req.peer)
+		if peer == nil {
+			continue
+		}
+
+		bmgrLog.Debugf("Block %v (height %d) from peer %s stalled -- "+
+			"requesting it from peer %s", blockHash, req.height,
+			req.peer.Addr(), peer.Addr())
+		if state, exists := b.peerStates[req.peer]; exists {
+			delete(state.requestedBlocks, blockHash)
+			limitAdd(state.stalledBlocks, blockHash, maxRequestedBlocks)
+			state.numBlocksInFlight--
+		}
+		hash := blockHash
+		b.requestHeaderBlock(peer, &hash, req.height, gdmsgs)
+	}
+	for peer, gdmsg := range gdmsgs {
+		if len(gdmsg.InvList) > 0 {
+			peer.QueueMessage(gdmsg, nil)
+		}
 	}
 }
 
@@ -1253,22 +1781,48 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 	// The remote peer is misbehaving if we didn't request headers.
 	msg := hmsg.headers
 	numHeaders := len(msg.Headers)
-	if !b.headersFirstMode {
+	if !b.headersFirstMode && peer == b.assumeValidPeer {
+		b.handleAssumeValidHeaders(peer, msg.Headers)
+		return
+	}
+	if !b.headersFirstMode || peer != b.syncPeer || b.fetchingHeaderBlocks {
 		bmgrLog.Warnf("Got %d unrequested headers from %s -- "+
 			"disconnecting", numHeaders, peer.Addr())
 		peer.Disconnect()
 		return
 	}
 
-	// Nothing to do for an empty headers message.
+	// The peer does not have any more headers when there are none, so switch
+	// to normal mode once all of the blocks for the headers have been
+	// processed when there are no more checkpoints.
 	if numHeaders == 0 {
+		if b.nextCheckpoint == nil {
+			b.handleHeadersDone()
+		}
 		return
 	}
 
+	// The current best block is not in the chain of the peer when the first
+	// header does not build on it, so rebase the list of headers on the
+	// block in the main chain it builds on instead in order to reorganize
+	// to the chain of the peer.
+	if b.headerList.Len() == 1 {
+		prevHash := msg.Headers[0].PrevBlock
+		prevNode := b.headerList.Front().Value.(*headerNode)
+		if !prevNode.hash.IsEqual(&prevHash) {
+			height, err := b.cfg.Chain.BlockHeightByHash(&prevHash)
+			if err == nil {
+				prevNode.hash = &prevHash
+				prevNode.height = height
+			}
+		}
+	}
+
 	// Process all of the received headers ensuring each one connects to the
 	// previous and that checkpoints match.
 	receivedCheckpoint := false
 	var finalHash *chainhash.Hash
+	var numAccepted int
 	for _, blockHeader := range msg.Headers {
 		blockHash := blockHeader.BlockHash()
 		finalHash = &blockHash
@@ -1288,10 +1842,8 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		prevNode := prevNodeEl.Value.(*headerNode)
 		if prevNode.hash.IsEqual(&blockHeader.PrevBlock) {
 			node.height = prevNode.height + 1
-			e := b.headerList.PushBack(&node)
-			if b.startHeader == nil {
-				b.startHeader = e
-			}
+			b.headerList.PushBack(&node)
+			numAccepted++
 		} else {
 			bmgrLog.Warnf("Received block header that does not "+
 				"properly connect to the chain from peer %s "+
@@ -1301,7 +1853,7 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		}
 
 		// Verify the header at the next checkpoint height matches.
-		if node.height == b.nextCheckpoint.Height {
+		if b.nextCheckpoint != nil && node.height == b.nextCheckpoint.Height {
 			if node.hash.IsEqual(b.nextCheckpoint.Hash) {
 				receivedCheckpoint = true
 				bmgrLog.Infof("Verified downloaded block "+
@@ -1320,9 +1872,22 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		}
 	}
 
-	// When this header is a checkpoint, switch to fetching the blocks for
-	// all of the headers since the last checkpoint.
-	if receivedCheckpoint {
+	// Make use of the headers to determine the ancestors of the assumed
+	// valid block when they are needed.
+	if b.cfg.Chain.NeedAssumeValidHeaders() {
+		_, err := b.processAssumeValidHeaders(msg.Headers[:numAccepted])
+		if err != nil {
+			bmgrLog.Warnf("Received invalid headers from peer %s: %v -- "+
+				"disconnecting", peer.Addr(), err)
+			peer.Disconnect()
+			return
+		}
+	}
+
+	// When this header is a checkpoint, or there are no more checkpoints,
+	// switch to fetching the blocks for all of the headers since the last
+	// round.
+	if receivedCheckpoint || b.nextCheckpoint == nil {
 		// Since the first entry of the list is always the final block
 		// that is already in the database and is only used to ensure
 		// the next header links properly, it must be removed before
@@ -1331,6 +1896,7 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		bmgrLog.Infof("Received %v block headers: Fetching blocks",
 			b.headerList.Len())
 		b.progressLogger.SetLastLogTime(time.Now())
+		b.fetchingHeaderBlocks = true
 		b.fetchHeaderBlocks()
 		return
 	}
@@ -1355,6 +1921,7 @@ func (b *blockManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 		bmgrLog.Warnf("Received notfound message from unknown peer %s", peer)
 		return
 	}
+	var fetchHeaderBlocks bool
 	for _, inv := range nfmsg.notFound.InvList {
 		// verify the hash was actually announced by the peer
 		// before deleting from the global requested maps.
@@ -1364,6 +1931,26 @@ func (b *blockManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 				delete(state.requestedBlocks, inv.Hash)
 				delete(b.requestedBlocks, inv.Hash)
 			}
+
+			// Release blocks for the headers that are being fetched in
+			// headers-first mode so they are requested from another peer.
+			if req, exists := b.inFlightBlocks[inv.Hash]; exists &&
+				req.peer == peer {
+
+				state.numBlocksInFlight--
+				delete(b.inFlightBlocks, inv.Hash)
+				fetchHeaderBlocks = b.headersFirstMode
+			}
+
+			// Try another peer for the history that leads to a loaded
+			// utxo set snapshot when the peer does not have it.
+			if peer == b.historicalPeer {
+				if _, exists := b.historicalRequested[inv.Hash]; exists {
+					bmgrLog.Debugf("Peer %s does not have historical "+
+						"block %v", peer.Addr(), inv.Hash)
+					b.resetHistoricalState()
+				}
+			}
 		case wire.InvTypeTx:
 			if _, exists := state.requestedTxns[inv.Hash]; exists {
 				delete(state.requestedTxns, inv.Hash)
@@ -1371,6 +1958,9 @@ func (b *blockManager) handleNotFoundMsg(nfmsg *notFoundMsg) {
 			}
 		}
 	}
+	if fetchHeaderBlocks {
+		b.fetchHeaderBlocks()
+	}
 }
 
 // haveInventory returns whether or not the inventory represented by the passed
@@ -1617,6 +2207,9 @@ func limitAdd(m map[chainhash.Hash]struct{}, hash chainhash.Hash, limit int) {
 // important because the block manager controls which blocks are needed and how
 // the fetching should proceed.
 func (b *blockManager) blockHandler() {
+	stallTicker := time.NewTicker(blockStallCheckInterval)
+	defer stallTicker.Stop()
+
 out:
 	for {
 		select {
@@ -1737,6 +2330,9 @@ out:
 				bmgrLog.Warnf("Invalid message type in block handler: %T", msg)
 			}
 
+		case <-stallTicker.C:
+			b.handleStalledBlocks()
+
 		case <-b.quit:
 			break out
 		}
@@ -2022,11 +2618,14 @@ func newBlockManager(config *blockManagerConfig) (*blockManager, error) {
 		progressLogger:  progresslog.New("Processed", bmgrLog),
 		msgChan:         make(chan interface{}, config.MaxPeers*3),
 		headerList:      list.New(),
+		inFlightBlocks:  make(map[chainhash.Hash]*inFlightBlock),
+		fetchedBlocks:   make(map[chainhash.Hash]*fetchedBlock),
 		quit:            make(chan struct{}),
 		orphans:         make(map[chainhash.Hash]*orphanBlock),
 		prevOrphans:     make(map[chainhash.Hash][]*orphanBlock),
 		isCurrent:       config.Chain.IsCurrent(),
 	}
+	bm.resetHistoricalState()
 
 	best := bm.cfg.Chain.BestSnapshot()
 	if !bm.cfg.DisableCheckpoints {
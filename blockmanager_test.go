@@ -0,0 +1,536 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/decred/dcrd/blockchain/v4"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	_ "github.com/decred/dcrd/database/v2/ffldb"
+	"github.com/decred/dcrd/dcrutil/v3"
+	peerpkg "github.com/decred/dcrd/peer/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+// testConn wraps a connection created by net.Pipe to provide a remote address
+// that is accepted by peers.
+type testConn struct {
+	net.Conn
+	raddr net.Addr
+}
+
+// RemoteAddr returns the remote address for the connection.
+func (c testConn) RemoteAddr() net.Addr {
+	return c.raddr
+}
+
+// newTestSyncPeer returns a peer that is connected to a mock remote peer which
+// claims to be a full node with blocks up to the provided height.  The remote
+// peer completes the version handshake and then discards all messages without
+// responding to them.  It also returns a teardown function the caller should
+// invoke when done testing to disconnect the peer.
+func newTestSyncPeer(t *testing.T, id int, lastBlock int64) (*peerpkg.Peer, func()) {
+	t.Helper()
+
+	params := chaincfg.RegNetParams()
+	verack := make(chan struct{}, 1)
+	peerCfg := &peerpkg.Config{
+		NewestBlock: func() (*chainhash.Hash, int64, error) {
+			return &params.GenesisHash, 0, nil
+		},
+		Listeners: peerpkg.MessageListeners{
+			OnVerAck: func(p *peerpkg.Peer, msg *wire.MsgVerAck) {
+				verack <- struct{}{}
+			},
+		},
+		UserAgentName:    "peer",
+		UserAgentVersion: "1.0",
+		Net:              params.Net,
+		Services:         wire.SFNodeNetwork,
+	}
+
+	ip := net.ParseIP(fmt.Sprintf("10.0.0.%d", id))
+	addr := &net.TCPAddr{IP: ip, Port: 9108}
+	localConn, remoteConn := net.Pipe()
+	go func() {
+		defer remoteConn.Close()
+
+		pver, dcrnet := wire.ProtocolVersion, params.Net
+		if _, _, err := wire.ReadMessage(remoteConn, pver, dcrnet); err != nil {
+			return
+		}
+		na := wire.NewNetAddressIPPort(ip, 9108, wire.SFNodeNetwork)
+		version := wire.NewMsgVersion(na, na, uint64(id), int32(lastBlock))
+		version.Services = wire.SFNodeNetwork
+		msgs := []wire.Message{version, wire.NewMsgVerAck()}
+		for _, msg := range msgs {
+			if err := wire.WriteMessage(remoteConn, msg, pver, dcrnet); err != nil {
+				return
+			}
+		}
+		for {
+			_, _, err := wire.ReadMessage(remoteConn, pver, dcrnet)
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	peer, err := peerpkg.NewOutboundPeer(peerCfg, addr.String())
+	if err != nil {
+		t.Fatalf("unable to create peer: %v", err)
+	}
+	peer.AssociateConnection(testConn{localConn, addr})
+	select {
+	case <-verack:
+	case <-time.After(5 * time.Second):
+		peer.Disconnect()
+		t.Fatalf("timeout waiting for peer %d to connect", id)
+	}
+	return peer, peer.Disconnect
+}
+
+// newTestBlockManager returns a block manager for a chain on the regression
+// test network that only contains the genesis block along with a teardown
+// function the caller should invoke when done testing to clean up.  The block
+// manager is not started, so tests invoke its handlers directly.
+func newTestBlockManager(t *testing.T) (*blockManager, func()) {
+	t.Helper()
+
+	dbPath, err := ioutil.TempDir("", "blockmanager")
+	if err != nil {
+		t.Fatalf("unable to create test db path: %v", err)
+	}
+	params := chaincfg.RegNetParams()
+	db, err := database.Create("ffldb", dbPath, params.Net)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("error creating db: %v", err)
+	}
+	teardown := func() {
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+
+	chain, err := blockchain.New(context.Background(), &blockchain.Config{
+		DB:          db,
+		ChainParams: params,
+		TimeSource:  blockchain.NewMedianTime(),
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("failed to create chain instance: %v", err)
+	}
+	bm, err := newBlockManager(&blockManagerConfig{
+		Chain:             chain,
+		ChainParams:       params,
+		NoMiningStateSync: true,
+		MaxPeers:          8,
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("failed to create block manager: %v", err)
+	}
+	return bm, teardown
+}
+
+// newTestHeaders returns the provided number of headers that link together
+// starting from the genesis block of the regression test network.
+func newTestHeaders(numHeaders int) []*wire.BlockHeader {
+	prevHash := chaincfg.RegNetParams().GenesisHash
+	headers := make([]*wire.BlockHeader, 0, numHeaders)
+	for i := 0; i < numHeaders; i++ {
+		header := &wire.BlockHeader{
+			PrevBlock: prevHash,
+			Height:    uint32(i + 1),
+		}
+		headers = append(headers, header)
+		prevHash = header.BlockHash()
+	}
+	return headers
+}
+
+// startTestHeaderSync adds the provided peers to the block manager, which
+// starts a headers-first sync from the first one, and then provides the passed
+// headers from it so the blocks for them are requested.
+func startTestHeaderSync(t *testing.T, bm *blockManager, peers []*peerpkg.Peer, headers []*wire.BlockHeader) {
+	t.Helper()
+
+	for _, peer := range peers {
+		bm.handleNewPeerMsg(peer)
+	}
+	if !bm.headersFirstMode || bm.syncPeer != peers[0] {
+		t.Fatalf("headers-first sync not started from the first peer -- "+
+			"headers-first mode %v, sync peer %v", bm.headersFirstMode,
+			bm.syncPeer)
+	}
+	if len(bm.inFlightBlocks) != 0 {
+		t.Fatalf("blocks requested before the headers are received: %d",
+			len(bm.inFlightBlocks))
+	}
+
+	msg := wire.NewMsgHeaders()
+	for _, header := range headers {
+		if err := msg.AddBlockHeader(header); err != nil {
+			t.Fatalf("unable to add header: %v", err)
+		}
+	}
+	bm.handleHeadersMsg(&headersMsg{headers: msg, peer: peers[0]})
+	if !bm.fetchingHeaderBlocks {
+		t.Fatal("blocks for the headers are not being fetched")
+	}
+}
+
+// assertInFlightBlocks ensures the state of the blocks in flight in the block
+// manager is consistent and that the blocks in flight from each of the
+// provided peers are the expected number.
+func assertInFlightBlocks(t *testing.T, bm *blockManager, want map[*peerpkg.Peer]int) {
+	t.Helper()
+
+	counts := make(map[*peerpkg.Peer]int)
+	for hash, req := range bm.inFlightBlocks {
+		counts[req.peer]++
+		state, exists := bm.peerStates[req.peer]
+		if !exists {
+			t.Fatalf("block %v in flight from unknown peer %v", hash,
+				req.peer)
+		}
+		if _, exists := state.requestedBlocks[hash]; !exists {
+			t.Fatalf("block %v in flight from peer %v is not requested "+
+				"from it", hash, req.peer)
+		}
+		if _, exists := bm.requestedBlocks[hash]; !exists {
+			t.Fatalf("block %v in flight from peer %v is not in the "+
+				"global requested blocks", hash, req.peer)
+		}
+	}
+	for peer, state := range bm.peerStates {
+		if counts[peer] != want[peer] {
+			t.Fatalf("unexpected blocks in flight from peer %v -- got %d, "+
+				"want %d", peer, counts[peer], want[peer])
+		}
+		if state.numBlocksInFlight != counts[peer] {
+			t.Fatalf("mismatched blocks in flight count for peer %v -- "+
+				"got %d, want %d", peer, state.numBlocksInFlight,
+				counts[peer])
+		}
+	}
+}
+
+// TestBlockManagerDownloadWindow ensures the blocks for the headers downloaded
+// in headers-first mode are requested from all of the sync candidate peers
+// while respecting the per-peer and overall download window limits.
+func TestBlockManagerDownloadWindow(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	// Start a headers-first sync with a single peer and ensure only the
+	// maximum number of blocks per peer are requested from it starting with
+	// the first header.
+	const numHeaders = maxBlockDownloadWindow + 100
+	headers := newTestHeaders(numHeaders)
+	syncPeer, teardownPeer := newTestSyncPeer(t, 1, numHeaders)
+	defer teardownPeer()
+	startTestHeaderSync(t, bm, []*peerpkg.Peer{syncPeer}, headers)
+	assertInFlightBlocks(t, bm, map[*peerpkg.Peer]int{
+		syncPeer: maxBlocksInFlightPerPeer,
+	})
+	for i := 0; i < maxBlocksInFlightPerPeer; i++ {
+		hash := headers[i].BlockHash()
+		if _, exists := bm.inFlightBlocks[hash]; !exists {
+			t.Fatalf("block %d is not in flight", i+1)
+		}
+	}
+
+	// Add enough peers to more than fill the download window and ensure the
+	// blocks in flight are limited to the window and are spread across all
+	// of the peers.
+	numPeers := maxBlockDownloadWindow/maxBlocksInFlightPerPeer + 2
+	want := map[*peerpkg.Peer]int{syncPeer: maxBlocksInFlightPerPeer}
+	for i := 1; i < numPeers; i++ {
+		peer, teardownPeer := newTestSyncPeer(t, i+1, numHeaders)
+		defer teardownPeer()
+		bm.handleNewPeerMsg(peer)
+		want[peer] = maxBlocksInFlightPerPeer
+	}
+	if len(bm.inFlightBlocks) != maxBlockDownloadWindow {
+		t.Fatalf("unexpected number of blocks in flight -- got %d, want %d",
+			len(bm.inFlightBlocks), maxBlockDownloadWindow)
+	}
+	for hash, req := range bm.inFlightBlocks {
+		if req.height > maxBlockDownloadWindow {
+			t.Fatalf("block %v at height %d outside of the download "+
+				"window is in flight", hash, req.height)
+		}
+		if bm.peerStates[req.peer].numBlocksInFlight > maxBlocksInFlightPerPeer {
+			t.Fatalf("too many blocks in flight from peer %v", req.peer)
+		}
+	}
+
+	// Ensure a block that is received out of order is held and does not
+	// cause any more blocks to be requested since it is still part of the
+	// download window.
+	block := dcrutil.NewBlock(&wire.MsgBlock{Header: *headers[1]})
+	req := bm.inFlightBlocks[*block.Hash()]
+	bm.handleBlockMsg(&blockMsg{block: block, peer: req.peer})
+	if _, exists := bm.fetchedBlocks[*block.Hash()]; !exists {
+		t.Fatal("block received out of order is not held")
+	}
+	if _, exists := bm.inFlightBlocks[*block.Hash()]; exists {
+		t.Fatal("received block is still in flight")
+	}
+	if len(bm.inFlightBlocks) != maxBlockDownloadWindow-1 {
+		t.Fatalf("unexpected number of blocks in flight -- got %d, want %d",
+			len(bm.inFlightBlocks), maxBlockDownloadWindow-1)
+	}
+	if !req.peer.Connected() {
+		t.Fatal("peer disconnected after sending a requested block")
+	}
+}
+
+// TestBlockManagerFetchedBlocksLimit ensures the blocks received out of order
+// in headers-first mode are tracked by size and that only the blocks needed to
+// process the held blocks are requested once they reach the maximum size.
+func TestBlockManagerFetchedBlocksLimit(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	// Start a headers-first sync with a single peer so only the maximum
+	// number of blocks per peer are in flight.
+	const numHeaders = 40
+	headers := newTestHeaders(numHeaders)
+	syncPeer, teardownPeer := newTestSyncPeer(t, 1, numHeaders)
+	defer teardownPeer()
+	startTestHeaderSync(t, bm, []*peerpkg.Peer{syncPeer}, headers)
+	assertInFlightBlocks(t, bm, map[*peerpkg.Peer]int{
+		syncPeer: maxBlocksInFlightPerPeer,
+	})
+
+	// Ensure a block received out of order is held, counts towards the size
+	// of the held blocks, and causes the next block to be requested.
+	block := dcrutil.NewBlock(&wire.MsgBlock{Header: *headers[4]})
+	bm.handleBlockMsg(&blockMsg{block: block, peer: syncPeer})
+	if _, exists := bm.fetchedBlocks[*block.Hash()]; !exists {
+		t.Fatal("block received out of order is not held")
+	}
+	blockSize := int64(block.MsgBlock().SerializeSize())
+	if bm.fetchedBlocksSize != blockSize {
+		t.Fatalf("unexpected held blocks size -- got %d, want %d",
+			bm.fetchedBlocksSize, blockSize)
+	}
+	assertInFlightBlocks(t, bm, map[*peerpkg.Peer]int{
+		syncPeer: maxBlocksInFlightPerPeer,
+	})
+
+	// Simulate the held blocks reaching the maximum size and ensure that
+	// receiving another block out of order does not cause any blocks after
+	// the first held block to be requested.
+	bm.fetchedBlocksSize += maxFetchedBlocksSize
+	block = dcrutil.NewBlock(&wire.MsgBlock{Header: *headers[9]})
+	bm.handleBlockMsg(&blockMsg{block: block, peer: syncPeer})
+	assertInFlightBlocks(t, bm, map[*peerpkg.Peer]int{
+		syncPeer: maxBlocksInFlightPerPeer - 1,
+	})
+	for hash, req := range bm.inFlightBlocks {
+		if req.height > numHeaders/2 {
+			t.Fatalf("block %v at height %d after the held blocks is "+
+				"in flight", hash, req.height)
+		}
+	}
+
+	// Ensure blocks are requested again once the held blocks are below the
+	// maximum size.
+	bm.fetchedBlocksSize -= maxFetchedBlocksSize
+	bm.fetchHeaderBlocks()
+	assertInFlightBlocks(t, bm, map[*peerpkg.Peer]int{
+		syncPeer: maxBlocksInFlightPerPeer,
+	})
+}
+
+// TestBlockManagerStalledBlocks ensures blocks that have been in flight for
+// longer than the stall timeout in headers-first mode are requested from
+// another peer and that the request is removed from the stalled peer while
+// still allowing it to deliver the block.
+func TestBlockManagerStalledBlocks(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	// Start a headers-first sync with three peers that each have room for
+	// more blocks in flight.
+	const numHeaders = 30
+	var peers []*peerpkg.Peer
+	want := make(map[*peerpkg.Peer]int)
+	for i := 0; i < 3; i++ {
+		peer, teardownPeer := newTestSyncPeer(t, i+1, numHeaders)
+		defer teardownPeer()
+		peers = append(peers, peer)
+		want[peer] = numHeaders / 3
+	}
+	headers := newTestHeaders(numHeaders)
+	startTestHeaderSync(t, bm, peers, headers)
+	assertInFlightBlocks(t, bm, want)
+
+	// Ensure blocks that have not exceeded the stall timeout are not
+	// requested again.
+	bm.handleStalledBlocks()
+	assertInFlightBlocks(t, bm, want)
+
+	// Stall the final block in flight from the second peer, which is not the
+	// next block to be processed, and ensure it is requested from another
+	// peer.
+	stalledPeer := peers[1]
+	var stalledHash chainhash.Hash
+	var stalledHeight int64
+	for hash, req := range bm.inFlightBlocks {
+		if req.peer == stalledPeer && req.height > stalledHeight {
+			stalledHash, stalledHeight = hash, req.height
+		}
+	}
+	bm.inFlightBlocks[stalledHash].requested = time.Now().Add(-blockStallTimeout)
+	bm.handleStalledBlocks()
+	newPeer := bm.inFlightBlocks[stalledHash].peer
+	if newPeer == stalledPeer {
+		t.Fatal("stalled block was not requested from another peer")
+	}
+	want[stalledPeer]--
+	want[newPeer]++
+	assertInFlightBlocks(t, bm, want)
+	stalledState := bm.peerStates[stalledPeer]
+	if _, exists := stalledState.requestedBlocks[stalledHash]; exists {
+		t.Fatal("stalled block is still requested from the stalled peer")
+	}
+	if _, exists := stalledState.stalledBlocks[stalledHash]; !exists {
+		t.Fatal("stalled block is not tracked for the stalled peer")
+	}
+
+	// Ensure the stalled peer is still allowed to deliver the block, that
+	// it is held until the blocks before it are processed, and that the
+	// request is removed from the peer it was requested from after the
+	// stall while still allowing that peer to deliver it too.
+	block := dcrutil.NewBlock(&wire.MsgBlock{Header: *headers[stalledHeight-1]})
+	bm.handleBlockMsg(&blockMsg{block: block, peer: stalledPeer})
+	if !stalledPeer.Connected() {
+		t.Fatal("stalled peer disconnected after delivering the block")
+	}
+	if _, exists := stalledState.stalledBlocks[stalledHash]; exists {
+		t.Fatal("delivered block is still tracked for the stalled peer")
+	}
+	if _, exists := bm.fetchedBlocks[stalledHash]; !exists {
+		t.Fatal("block delivered by the stalled peer is not held")
+	}
+	want[newPeer]--
+	assertInFlightBlocks(t, bm, want)
+	newState := bm.peerStates[newPeer]
+	if _, exists := newState.requestedBlocks[stalledHash]; exists {
+		t.Fatal("delivered block is still requested from the other peer")
+	}
+	if _, exists := newState.stalledBlocks[stalledHash]; !exists {
+		t.Fatal("delivered block is not tracked for the other peer")
+	}
+
+	// Ensure the duplicate from the peer the block was requested from after
+	// the stall is ignored.
+	bm.handleBlockMsg(&blockMsg{block: block, peer: newPeer})
+	if !newPeer.Connected() {
+		t.Fatal("peer disconnected after delivering a duplicate block")
+	}
+	if _, exists := newState.stalledBlocks[stalledHash]; exists {
+		t.Fatal("duplicate block is still tracked for the other peer")
+	}
+	if _, exists := bm.fetchedBlocks[stalledHash]; !exists {
+		t.Fatal("held block discarded after receiving a duplicate")
+	}
+	assertInFlightBlocks(t, bm, want)
+}
+
+// TestBlockManagerPeerDisconnect ensures the blocks in flight from a peer that
+// disconnects in headers-first mode are requested from the remaining peers and
+// that the headers-first state is reset when the sync peer disconnects.
+func TestBlockManagerPeerDisconnect(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	// Start a headers-first sync with three peers that each have room for
+	// more blocks in flight.
+	const numHeaders = 30
+	var peers []*peerpkg.Peer
+	want := make(map[*peerpkg.Peer]int)
+	for i := 0; i < 3; i++ {
+		peer, teardownPeer := newTestSyncPeer(t, i+1, numHeaders)
+		defer teardownPeer()
+		peers = append(peers, peer)
+		want[peer] = numHeaders / 3
+	}
+	headers := newTestHeaders(numHeaders)
+	startTestHeaderSync(t, bm, peers, headers)
+	assertInFlightBlocks(t, bm, want)
+
+	// Stall a block in flight from the second peer so it is requested from
+	// another peer.
+	donePeer := peers[1]
+	var stalledHash chainhash.Hash
+	for hash, req := range bm.inFlightBlocks {
+		if req.peer == donePeer {
+			stalledHash = hash
+			break
+		}
+	}
+	bm.inFlightBlocks[stalledHash].requested = time.Now().Add(-blockStallTimeout)
+	bm.handleStalledBlocks()
+
+	// Disconnect the second peer and ensure all of the blocks that were in
+	// flight from it are requested from the remaining peers without
+	// affecting the block that stalled.
+	donePeer.Disconnect()
+	bm.handleDonePeerMsg(donePeer)
+	if len(bm.inFlightBlocks) != numHeaders {
+		t.Fatalf("unexpected number of blocks in flight -- got %d, want %d",
+			len(bm.inFlightBlocks), numHeaders)
+	}
+	for _, header := range headers {
+		hash := header.BlockHash()
+		req, exists := bm.inFlightBlocks[hash]
+		if !exists {
+			t.Fatalf("block %v is not in flight", hash)
+		}
+		if req.peer == donePeer {
+			t.Fatalf("block %v is in flight from the disconnected peer",
+				hash)
+		}
+	}
+	assertInFlightBlocks(t, bm, map[*peerpkg.Peer]int{
+		peers[0]: bm.peerStates[peers[0]].numBlocksInFlight,
+		peers[2]: bm.peerStates[peers[2]].numBlocksInFlight,
+	})
+
+	// Disconnect the sync peer and ensure the headers-first state is reset
+	// and the sync restarts from the remaining peer.
+	syncPeer := peers[0]
+	syncPeer.Disconnect()
+	bm.handleDonePeerMsg(syncPeer)
+	if bm.syncPeer != peers[2] || !bm.headersFirstMode {
+		t.Fatalf("headers-first sync not restarted from the remaining "+
+			"peer -- headers-first mode %v, sync peer %v",
+			bm.headersFirstMode, bm.syncPeer)
+	}
+	if bm.fetchingHeaderBlocks || bm.headerList.Len() != 1 {
+		t.Fatalf("headers-first state not reset -- fetching blocks %v, "+
+			"headers %d", bm.fetchingHeaderBlocks, bm.headerList.Len())
+	}
+	assertInFlightBlocks(t, bm, nil)
+	if len(bm.fetchedBlocks) != 0 {
+		t.Fatalf("unexpected held blocks after reset: %d",
+			len(bm.fetchedBlocks))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	_ "crypto/sha512" // Needed for RegisterHash in init
+	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
@@ -142,3 +143,83 @@ func NewTLSCertPair(curve elliptic.Curve, organization string, validUntil time.T
 
 	return certBuf.Bytes(), keyBuf.Bytes(), nil
 }
+
+// NewTLSClientCertPair returns a new PEM-encoded x.509 certificate pair with
+// new ECDSA keys that is suitable for TLS client authentication.  The
+// certificate subject has the provided common name and organization and it is
+// signed by the provided PEM-encoded certificate authority certificate and
+// key, such as a pair created by NewTLSCertPair.
+func NewTLSClientCertPair(curve elliptic.Curve, commonName, organization string, validUntil time.Time, caCert, caKey []byte) (cert, key []byte, err error) {
+	now := time.Now()
+	if validUntil.Before(now) {
+		return nil, nil, errors.New("validUntil would create an already-expired certificate")
+	}
+	if commonName == "" {
+		return nil, nil, errors.New("client certificates require a common name")
+	}
+
+	caPair, err := tls.X509KeyPair(caCert, caKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load certificate authority: %v", err)
+	}
+	ca, err := x509.ParseCertificate(caPair.Certificate[0])
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse certificate authority: %v", err)
+	}
+	if !ca.IsCA || ca.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, nil, errors.New("certificate authority cannot sign other certificates")
+	}
+	if validUntil.After(ca.NotAfter) {
+		validUntil = ca.NotAfter
+	}
+
+	priv, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate serial number: %s", err)
+	}
+
+	template := x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{organization},
+			CommonName:   commonName,
+		},
+		NotBefore: now.Add(-time.Hour * 24),
+		NotAfter:  validUntil,
+
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, ca,
+		&priv.PublicKey, caPair.PrivateKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create certificate: %v", err)
+	}
+
+	certBuf := &bytes.Buffer{}
+	err = pem.Encode(certBuf, &pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode certificate: %v", err)
+	}
+
+	keybytes, err := x509.MarshalECPrivateKey(priv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal private key: %v", err)
+	}
+
+	keyBuf := &bytes.Buffer{}
+	err = pem.Encode(keyBuf, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keybytes})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encode private key: %v", err)
+	}
+
+	return certBuf.Bytes(), keyBuf.Bytes(), nil
+}
@@ -7,6 +7,7 @@ package certgen_test
 
 import (
 	"crypto/elliptic"
+	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
 	"net"
@@ -123,3 +124,75 @@ func TestNewTLSCertPair(t *testing.T) {
 		t.Fatal("generated cert does not have valid basic constraints")
 	}
 }
+
+// TestNewTLSClientCertPair ensures the NewTLSClientCertPair function creates
+// client certificates that are signed by the provided certificate authority.
+func TestNewTLSClientCertPair(t *testing.T) {
+	validUntil := time.Unix(time.Now().Add(365*24*time.Hour).Unix(), 0)
+	caCert, caKey, err := certgen.NewTLSCertPair(elliptic.P256(), "test ca",
+		validUntil.Add(time.Hour), nil)
+	if err != nil {
+		t.Fatalf("unable to create certificate authority: %v", err)
+	}
+
+	cn, org := "automation", "test client cert"
+	cert, key, err := certgen.NewTLSClientCertPair(elliptic.P256(), cn, org,
+		validUntil, caCert, caKey)
+	if err != nil {
+		t.Fatalf("failed with unexpected error: %v", err)
+	}
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		t.Fatalf("certificate and key do not match: %v", err)
+	}
+
+	pemCert, _ := pem.Decode(cert)
+	if pemCert == nil {
+		t.Fatal("pem.Decode was unable to decode the certificate")
+	}
+	x509Cert, err := x509.ParseCertificate(pemCert.Bytes)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	if x509Cert.Subject.CommonName != cn {
+		t.Fatalf("unexpected common name -- got %q, want %q",
+			x509Cert.Subject.CommonName, cn)
+	}
+	if x509Cert.IsCA {
+		t.Fatal("client certificate is a certificate authority")
+	}
+	if !x509Cert.NotAfter.Equal(validUntil) {
+		t.Fatalf("unexpected expiry -- got %v, want %v",
+			x509Cert.NotAfter, validUntil)
+	}
+
+	// Ensure the certificate verifies for client authentication against the
+	// certificate authority.
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caCert) {
+		t.Fatal("unable to add certificate authority to pool")
+	}
+	_, err = x509Cert.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	if err != nil {
+		t.Fatalf("client certificate does not verify: %v", err)
+	}
+
+	// Ensure invalid parameters are rejected.
+	_, _, err = certgen.NewTLSClientCertPair(elliptic.P256(), "", org,
+		validUntil, caCert, caKey)
+	if err == nil {
+		t.Fatal("did not receive error for empty common name")
+	}
+	_, _, err = certgen.NewTLSClientCertPair(elliptic.P256(), cn, org,
+		validUntil, caCert, key)
+	if err == nil {
+		t.Fatal("did not receive error for mismatched certificate authority")
+	}
+	_, _, err = certgen.NewTLSClientCertPair(elliptic.P256(), cn, org,
+		validUntil, cert, key)
+	if err == nil {
+		t.Fatal("did not receive error for non certificate authority")
+	}
+}
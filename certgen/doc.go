@@ -10,6 +10,9 @@ This package contains functions for creating self-signed TLS certificate from
 random new key pairs, typically used for encrypting RPC and websocket
 communications.
 
+Client certificates for TLS client authentication that are signed by such a
+certificate acting as a local certificate authority may also be created.
+
 ECDSA certificates are supported on all Go versions.  Beginning with Go 1.13,
 this package additionally includes support for Ed25519 certificates.
 */
@@ -151,6 +151,22 @@ func MainNetParams() *Params {
 		// Height: 487720
 		MinKnownChainWork: hexToBigInt("00000000000000000000000000000000000000000013e6909b5a73128d52fc6f"),
 
+		// AssumeValid is the hash of a block that has been externally
+		// verified to be valid.  This is intended to be updated periodically
+		// with new releases.
+		//
+		// Block 00000000000000000b639b99ec8b3097ed3dac076c455d30139db0d5958d4c4a
+		// Height: 487720
+		AssumeValid: *newHashFromStr("00000000000000000b639b99ec8b3097ed3dac076c455d30139db0d5958d4c4a"),
+
+		// AssumeUtxo houses the utxo set snapshots that are known to be
+		// valid.  This is intended to be updated periodically with new
+		// releases.
+		//
+		// No snapshots are committed to for the main network yet, so utxo set
+		// snapshots may not be loaded on it until a release adds one.
+		AssumeUtxo: nil,
+
 		// The miner confirmation window is defined as:
 		//   target proof of work timespan / target proof of work spacing
 		RuleChangeActivationQuorum:     4032, // 10 % of RuleChangeActivationInterval * TicketsPerBlock
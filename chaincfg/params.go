@@ -31,6 +31,24 @@ type Checkpoint struct {
 	Hash   *chainhash.Hash
 }
 
+// AssumeUtxo identifies a snapshot of the unspent transaction output set and
+// ticket database state as of a specific block that has been externally
+// verified to be valid.  A node may be bootstrapped from such a snapshot
+// instead of validating the entire chain before the history is validated in
+// the background.
+type AssumeUtxo struct {
+	// Height and Hash identify the block the snapshot was created at.
+	Height int64
+	Hash   *chainhash.Hash
+
+	// SnapshotHash is the hash of the entire serialized snapshot.
+	SnapshotHash *chainhash.Hash
+
+	// NumUtxoEntries is the number of transactions with unspent outputs in
+	// the snapshot.
+	NumUtxoEntries uint64
+}
+
 // Vote describes a voting instance.  It is self-describing so that the UI can
 // be directly implemented using the fields.  Mask determines which bits can be
 // used.  Bits are enumerated and must be consecutive.  Each vote requires one
@@ -322,6 +340,20 @@ type Params struct {
 	// with new releases.  It may be nil for networks that do not require it.
 	MinKnownChainWork *big.Int
 
+	// AssumeValid is the hash of a block that has been externally verified to
+	// be valid.  It allows the script validation of its ancestors to be
+	// skipped during the initial chain sync when the block is part of a
+	// header chain with at least MinKnownChainWork.  All other consensus
+	// rules are still enforced.
+	//
+	// It may be the zero hash to require full validation of all blocks.
+	AssumeValid chainhash.Hash
+
+	// AssumeUtxo houses the utxo set snapshots that are known to be valid and
+	// therefore may be loaded in order to bootstrap a node.  It may be empty
+	// for networks that do not provide any snapshots.
+	AssumeUtxo []AssumeUtxo
+
 	// These fields are related to voting on consensus rule changes as
 	// defined by BIP0009.
 	//
@@ -118,6 +118,25 @@ func RegNetParams() *Params {
 		// Not set for regression test network since its chain is dynamic.
 		MinKnownChainWork: nil,
 
+		// AssumeValid is the hash of a block that has been externally
+		// verified to be valid.
+		//
+		// Not set for regression test network since its chain is dynamic.
+		AssumeValid: chainhash.Hash{},
+
+		// AssumeUtxo houses the utxo set snapshots that are known to be
+		// valid.
+		//
+		// The regression test network commits to a snapshot of a chain
+		// generated by the blockchain package tests so the snapshot
+		// loading path is able to be exercised with the network params.
+		AssumeUtxo: []AssumeUtxo{{
+			Height:         320,
+			Hash:           newHashFromStr("6ad56bc406daf0ed93ed86e304ee6ed65bd2613a9c27ddec815154749278d85c"),
+			SnapshotHash:   newHashFromStr("f98c680316edc7a9d2395e464f6600931e9eb0344afb43b4759d65c605f83c85"),
+			NumUtxoEntries: 2405,
+		}},
+
 		// Consensus rule change deployments.
 		//
 		// The miner confirmation window is defined as:
@@ -117,6 +117,18 @@ func SimNetParams() *Params {
 		// Not set for simnet test network since its chain is dynamic.
 		MinKnownChainWork: nil,
 
+		// AssumeValid is the hash of a block that has been externally
+		// verified to be valid.
+		//
+		// Not set for simnet test network since its chain is dynamic.
+		AssumeValid: chainhash.Hash{},
+
+		// AssumeUtxo houses the utxo set snapshots that are known to be
+		// valid.
+		//
+		// Not set for simnet test network since its chain is dynamic.
+		AssumeUtxo: nil,
+
 		// Consensus rule change deployments.
 		//
 		// The miner confirmation window is defined as:
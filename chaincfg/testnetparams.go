@@ -124,6 +124,22 @@ func TestNet3Params() *Params {
 		// Height: 519845
 		MinKnownChainWork: hexToBigInt("000000000000000000000000000000000000000000000000e41955f181d00f59"),
 
+		// AssumeValid is the hash of a block that has been externally
+		// verified to be valid.  This is intended to be updated periodically
+		// with new releases.
+		//
+		// Block 000000097a93845014d7865997154cc186be0435742e0d3c37c019ff118493fa
+		// Height: 519845
+		AssumeValid: *newHashFromStr("000000097a93845014d7865997154cc186be0435742e0d3c37c019ff118493fa"),
+
+		// AssumeUtxo houses the utxo set snapshots that are known to be
+		// valid.  This is intended to be updated periodically with new
+		// releases.
+		//
+		// No snapshots are committed to for the test network yet, so utxo set
+		// snapshots may not be loaded on it until a release adds one.
+		AssumeUtxo: nil,
+
 		// Consensus rule change deployments.
 		//
 		// The miner confirmation window is defined as:
@@ -37,6 +37,7 @@ type importResults struct {
 type blockImporter struct {
 	db                database.DB
 	chain             *blockchain.BlockChain
+	indexManager      *indexers.Manager
 	r                 io.ReadSeeker
 	processQueue      chan []byte
 	doneChan          chan bool
@@ -285,10 +286,35 @@ func (bi *blockImporter) Import() chan *importResults {
 	go bi.readHandler()
 	go bi.processHandler()
 
-	// Wait for the import to finish in a separate goroutine and signal
-	// the status handler when done.
+	// Sync the optional indexes with the imported blocks in the background.
+	// The sync context is cancelled when syncing stops due to an error so the
+	// import does not wait for indexes that will never catch up.
+	ctx, cancel := context.WithCancel(context.Background())
+	syncCtx, syncStopped := context.WithCancel(ctx)
+	if bi.indexManager != nil {
+		go func() {
+			bi.indexManager.Run(ctx)
+			syncStopped()
+		}()
+	}
+
+	// Wait for the import to finish and the indexes to catch up to the
+	// imported blocks in a separate goroutine and signal the status handler
+	// when done.
 	go func() {
 		bi.wg.Wait()
+		if bi.indexManager != nil {
+			bestHeight := bi.chain.BestSnapshot().Height
+			for _, info := range bi.indexManager.IndexInfo() {
+				err := bi.indexManager.WaitForHeight(syncCtx, info.Name,
+					bestHeight)
+				if err != nil {
+					break
+				}
+			}
+		}
+		syncStopped()
+		cancel()
 		bi.doneChan <- true
 	}()
 
@@ -333,8 +359,10 @@ func newBlockImporter(db database.DB, r io.ReadSeeker) (*blockImporter, error) {
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager indexers.IndexManager
+	var manager *indexers.Manager
 	if len(indexes) > 0 {
-		indexManager = indexers.NewManager(db, indexes, activeNetParams)
+		manager = indexers.NewManager(db, indexes, activeNetParams)
+		indexManager = manager
 	}
 
 	chain, err := blockchain.New(context.Background(),
@@ -357,6 +385,7 @@ func newBlockImporter(db database.DB, r io.ReadSeeker) (*blockImporter, error) {
 		errChan:      make(chan error),
 		quit:         make(chan struct{}),
 		chain:        chain,
+		indexManager: manager,
 		lastLogTime:  time.Now(),
 		startTime:    time.Now(),
 	}, nil
@@ -37,14 +37,16 @@ func usage(parser *flags.Parser) {
 }
 
 type config struct {
-	CA    string   `short:"C" description:"sign generated certificate using CA cert (requires -K)"`
-	CAKey string   `short:"K" description:"key of CA certificate"`
-	Hosts []string `short:"H" description:"hostname or IP certificate is valid for; may be specified multiple times"`
-	Local bool     `short:"L" description:"append localhost, 127.0.0.1, and ::1 to hosts if not already specified"`
-	Org   string   `short:"o" description:"organization"`
-	Algo  string   `short:"a" description:"key algorithm (one of: P-256, P-384, P-521, Ed25519)"`
-	Years int      `short:"y" description:"years certificate is valid for"`
-	Force bool     `short:"f" description:"overwrite existing certs/keys"`
+	CA     string   `short:"C" description:"sign generated certificate using CA cert (requires -K)"`
+	CAKey  string   `short:"K" description:"key of CA certificate"`
+	Client bool     `short:"c" description:"generate a client certificate for TLS client authentication (requires -C and -K)"`
+	Name   string   `short:"n" description:"common name of the certificate subject (default: first host, or organization when there are no hosts)"`
+	Hosts  []string `short:"H" description:"hostname or IP certificate is valid for; may be specified multiple times"`
+	Local  bool     `short:"L" description:"append localhost, 127.0.0.1, and ::1 to hosts if not already specified"`
+	Org    string   `short:"o" description:"organization"`
+	Algo   string   `short:"a" description:"key algorithm (one of: P-256, P-384, P-521, Ed25519)"`
+	Years  int      `short:"y" description:"years certificate is valid for"`
+	Force  bool     `short:"f" description:"overwrite existing certs/keys"`
 }
 
 func main() {
@@ -90,6 +92,9 @@ func main() {
 	if cfg.CA == "" != (cfg.CAKey == "") {
 		fatalf("-C and -K must be used together\n")
 	}
+	if cfg.Client && cfg.CA == "" {
+		fatalf("-c requires -C and -K\n")
+	}
 
 	if cfg.Local {
 		var localhost, v4Loopback, v6Loopback bool
@@ -121,7 +126,8 @@ func main() {
 		fatalf("%s\n", err)
 	}
 	if cfg.CA == "" {
-		cert, err = generateAuthority(pub, priv, cfg.Hosts, cfg.Org, cfg.Years)
+		cert, err = generateAuthority(pub, priv, cfg.Hosts, cfg.Name, cfg.Org,
+			cfg.Years)
 		if err != nil {
 			fatalf("generate certificate authority: %v\n", err)
 		}
@@ -136,8 +142,8 @@ func main() {
 		ca, _ = x509.ParseCertificate(tlsCert.Certificate[0])
 		caPriv = tlsCert.PrivateKey
 
-		cert, err = createIssuedCert(pub, caPriv, ca,
-			cfg.Hosts, cfg.Org, cfg.Years)
+		cert, err = createIssuedCert(pub, caPriv, ca, cfg.Hosts, cfg.Name,
+			cfg.Org, cfg.Years, cfg.Client)
 		if err != nil {
 			fatalf("issue certificate: %v\n", err)
 		}
@@ -206,7 +212,7 @@ type Cert struct {
 	Cert     *x509.Certificate
 }
 
-func newTemplate(hosts []string, org string, validUntil time.Time) (*x509.Certificate, error) {
+func newTemplate(hosts []string, cn, org string, validUntil time.Time) (*x509.Certificate, error) {
 	now := time.Now()
 	if validUntil.After(endOfTime) {
 		validUntil = endOfTime
@@ -218,9 +224,11 @@ func newTemplate(hosts []string, org string, validUntil time.Time) (*x509.Certif
 	if err != nil {
 		return nil, err
 	}
-	cn := org
-	if len(hosts) > 0 {
-		cn = hosts[0]
+	if cn == "" {
+		cn = org
+		if len(hosts) > 0 {
+			cn = hosts[0]
+		}
 	}
 
 	var hostnames []string
@@ -247,9 +255,9 @@ func newTemplate(hosts []string, org string, validUntil time.Time) (*x509.Certif
 	return template, nil
 }
 
-func generateAuthority(pub, priv interface{}, hosts []string, org string, years int) (*Cert, error) {
+func generateAuthority(pub, priv interface{}, hosts []string, cn, org string, years int) (*Cert, error) {
 	validUntil := time.Now().Add(time.Hour * 24 * 365 * time.Duration(years))
-	template, err := newTemplate(hosts, org, validUntil)
+	template, err := newTemplate(hosts, cn, org, validUntil)
 	if err != nil {
 		return nil, err
 	}
@@ -281,7 +289,7 @@ func generateAuthority(pub, priv interface{}, hosts []string, org string, years
 }
 
 func createIssuedCert(pub, caPriv interface{}, ca *x509.Certificate,
-	hosts []string, org string, years int) (*Cert, error) {
+	hosts []string, cn, org string, years int, client bool) (*Cert, error) {
 
 	if ca.KeyUsage&x509.KeyUsageCertSign == 0 {
 		return nil, fmt.Errorf("parent certificate cannot sign other certificates")
@@ -290,12 +298,15 @@ func createIssuedCert(pub, caPriv interface{}, ca *x509.Certificate,
 	if validUntil.After(ca.NotAfter) {
 		validUntil = ca.NotAfter
 	}
-	template, err := newTemplate(hosts, org, validUntil)
+	template, err := newTemplate(hosts, cn, org, validUntil)
 	if err != nil {
 		return nil, err
 	}
 	template.KeyUsage = x509.KeyUsageDigitalSignature
 	template.BasicConstraintsValid = true
+	if client {
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+	}
 
 	cert, err := x509.CreateCertificate(rand.Reader, template, ca, pub, caPriv)
 	if err != nil {
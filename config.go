@@ -23,8 +23,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/connmgr/v3"
 	"github.com/decred/dcrd/database/v2"
+	_ "github.com/decred/dcrd/database/v2/bboltdb"
 	_ "github.com/decred/dcrd/database/v2/ffldb"
 	"github.com/decred/dcrd/dcrutil/v3"
 	"github.com/decred/dcrd/internal/mempool"
@@ -51,6 +53,7 @@ const (
 	defaultMaxRPCClients        = 10
 	defaultMaxRPCWebsockets     = 25
 	defaultMaxRPCConcurrentReqs = 20
+	defaultRPCCookieFilename    = ".cookie"
 
 	// Defaults for P2P network options.
 	defaultMaxSameIP       = 5
@@ -75,6 +78,13 @@ const (
 	defaultNoMiningStateSync   = false
 	defaultAllowUnsyncedMining = false
 
+	// Defaults for chain related options.
+	defaultPrune            = 0
+	pruneMinSizeMiB         = 1024
+	defaultUtxoCacheMaxSize = 150
+	minUtxoCacheMaxSize     = 25
+	maxUtxoCacheMaxSize     = 32768
+
 	// Defaults for indexing options.
 	defaultTxIndex           = false
 	defaultAddrIndex         = false
@@ -119,10 +129,11 @@ type config struct {
 	DataDir         string `short:"b" long:"datadir" description:"Directory to store data"`
 	LogDir          string `long:"logdir" description:"Directory to log output"`
 	NoFileLogging   bool   `long:"nofilelogging" description:"Disable file logging"`
-	DbType          string `long:"dbtype" description:"Database backend to use for the block chain"`
+	DbType          string `long:"dbtype" description:"Database backend to use for the block chain {ffldb, bboltdb}"`
 	Profile         string `long:"profile" description:"Enable HTTP profiling on given [addr:]port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile      string `long:"cpuprofile" description:"Write CPU profile to the specified file"`
 	MemProfile      string `long:"memprofile" description:"Write mem profile to the specified file"`
+	MetricsListen   string `long:"metricslisten" description:"Serve Prometheus metrics over HTTP at /metrics on the given [addr:]port -- NOTE: The metrics are not authenticated, so it should only be exposed to trusted networks"`
 	TestNet         bool   `long:"testnet" description:"Use the test network"`
 	SimNet          bool   `long:"simnet" description:"Use the simulation test network"`
 	RegNet          bool   `long:"regnet" description:"Use the regression test network"`
@@ -130,20 +141,26 @@ type config struct {
 	SigCacheMaxSize uint   `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
 
 	// RPC server options and policy.
-	DisableRPC           bool     `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass or rpclimituser/rpclimitpass is specified"`
+	DisableRPC           bool     `long:"norpc" description:"Disable built-in RPC server -- NOTE: The RPC server is disabled by default if no rpcuser/rpcpass, rpclimituser/rpclimitpass, rpcusersfile, rpcadminclient, or rpclimitclient is specified and rpccookie is not set"`
 	RPCListeners         []string `long:"rpclisten" description:"Add an interface/port to listen for RPC connections (default port: 9109, testnet: 19109)"`
 	RPCUser              string   `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	RPCPass              string   `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCLimitUser         string   `long:"rpclimituser" description:"Username for limited RPC connections"`
 	RPCLimitPass         string   `long:"rpclimitpass" default-mask:"-" description:"Password for limited RPC connections"`
+	RPCUsersFile         string   `long:"rpcusersfile" description:"File containing additional RPC users along with the bcrypt or argon2 hashes of their passwords and the methods each of them may call"`
+	RPCCookie            bool     `long:"rpccookie" description:"Write a random full-access password to the .cookie file in the data directory on start up for local RPC clients to authenticate with"`
 	RPCCert              string   `long:"rpccert" description:"File containing the certificate file"`
 	RPCKey               string   `long:"rpckey" description:"File containing the certificate key"`
 	TLSCurve             string   `long:"tlscurve" description:"Curve to use when generating TLS keypairs"`
 	AltDNSNames          []string `long:"altdnsnames" description:"Specify additional DNS names to use when generating the RPC server certificate" env:"DCRD_ALT_DNSNAMES" env-delim:","`
 	DisableTLS           bool     `long:"notls" description:"Disable TLS for the RPC server -- NOTE: This is only allowed if the RPC server is bound to localhost"`
+	RPCClientCAFile      string   `long:"rpcclientcafile" description:"File containing the certificate authorities used to verify the TLS client certificates that are required for all RPC connections when specified"`
+	RPCAdminClients      []string `long:"rpcadminclient" description:"Subject common name of a TLS client certificate signed by an rpcclientcafile authority that authenticates as the admin RPC user; may be specified multiple times"`
+	RPCLimitClients      []string `long:"rpclimitclient" description:"Subject common name of a TLS client certificate signed by an rpcclientcafile authority that authenticates as the limited RPC user; may be specified multiple times"`
 	RPCMaxClients        int      `long:"rpcmaxclients" description:"Max number of RPC clients for standard connections"`
 	RPCMaxWebsockets     int      `long:"rpcmaxwebsockets" description:"Max number of RPC websocket connections"`
 	RPCMaxConcurrentReqs int      `long:"rpcmaxconcurrentreqs" description:"Max number of concurrent RPC requests that may be processed concurrently"`
+	EnableREST           bool     `long:"rest" description:"Serve the read-only REST interface under /rest/ on the RPC listeners -- NOTE: The REST interface is not authenticated"`
 
 	// P2P proxy and Tor settings.
 	Proxy          string `long:"proxy" description:"Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)"`
@@ -181,6 +198,12 @@ type config struct {
 	// Chain related options.
 	DisableCheckpoints bool   `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing"`
 	DumpBlockchain     string `long:"dumpblockchain" description:"Write blockchain as a flat file of blocks for use with addblock, to the specified filename"`
+	Prune              uint64 `long:"prune" description:"Reduce storage requirements by removing old block data to keep the total size of stored blocks near the specified target in MiB.  Pruned nodes do not serve historical blocks and are incompatible with --txindex, --addrindex, --spendindex, --ticketindex, --treasuryindex, --scripthashindex, --addrutxoindex, --blockstatsindex, and --nulldataindex.  Minimum 1024 MiB (0 to disable)"`
+	UtxoCacheMaxSize   uint   `long:"utxocachemaxsize" description:"The maximum size in MiB of the cache of unspent transaction outputs that is written to the database in batches.  Valid range is 25 to 32768 MiB"`
+	AssumeValid        string `long:"assumevalid" description:"Hash of a block for which the scripts of it and all of its ancestors are assumed to be valid when syncing.  All other consensus rules are still enforced.  Use 0 to validate all scripts (default: network specific)"`
+	LoadUtxoSnapshot   string `long:"loadutxosnapshot" description:"Initialize a new chain from the utxo set snapshot at the specified path instead of syncing from the genesis block.  The snapshot must be one that is committed to by the active network.  The history that leads to the snapshot is validated in the background.  Only networks that commit to snapshots are supported, which currently is only regnet"`
+	VerifyDB           bool   `long:"verifydb" description:"Verify the integrity of the block database on start up, write a report to dbverify.json in the data directory, and then exit"`
+	RepairDB           bool   `long:"repairdb" description:"Verify the integrity of the block database on start up, repair the issues that are found where possible, write a report to dbverify.json in the data directory, and then exit"`
 
 	// Relay and mempool policy.
 	MinRelayTxFee    float64 `long:"minrelaytxfee" description:"The minimum transaction fee in DCR/kB to be considered a non-zero fee"`
@@ -204,14 +227,29 @@ type config struct {
 	AllowUnsyncedMining bool     `long:"allowunsyncedmining" description:"Allow block templates to be generated even when the chain is not considered synced on networks other than the main network.  This is automatically enabled when the simnet option is set.  Don't do this unless you know what you're doing"`
 
 	// Indexing options.
-	TxIndex             bool `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
-	DropTxIndex         bool `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits"`
-	AddrIndex           bool `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
-	DropAddrIndex       bool `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits"`
-	NoExistsAddrIndex   bool `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used"`
-	DropExistsAddrIndex bool `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits"`
-	NoCFilters          bool `long:"nocfilters" description:"(Deprecated) Disable compact filtering (CF) support"`
-	DropCFIndex         bool `long:"dropcfindex" description:"(Deprecated) Deletes the index used for compact filtering (CF) support from the database on start up and then exits"`
+	TxIndex             bool   `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
+	DropTxIndex         bool   `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits"`
+	AddrIndex           bool   `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
+	DropAddrIndex       bool   `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits"`
+	SpendIndex          bool   `long:"spendindex" description:"Maintain a full outpoint-based spend index which makes the getspendinginfo RPC available"`
+	DropSpendIndex      bool   `long:"dropspendindex" description:"Deletes the outpoint-based spend index from the database on start up and then exits"`
+	TicketIndex         bool   `long:"ticketindex" description:"Maintain a full ticket history index which makes the getticketinfo RPC and historical tickets in the ticketsforaddress RPC available"`
+	DropTicketIndex     bool   `long:"dropticketindex" description:"Deletes the ticket history index from the database on start up and then exits"`
+	TreasuryIndex       bool   `long:"treasuryindex" description:"Maintain a full treasury transaction history index which makes the gettreasuryhistory RPC available"`
+	DropTreasuryIndex   bool   `long:"droptreasuryindex" description:"Deletes the treasury transaction history index from the database on start up and then exits"`
+	ScriptHashIndex     bool   `long:"scripthashindex" description:"Maintain a full script hash index which makes the getscripthashhistory and getscripthashbalance RPCs available"`
+	DropScriptHashIndex bool   `long:"dropscripthashindex" description:"Deletes the script hash index from the database on start up and then exits"`
+	AddrUtxoIndex       bool   `long:"addrutxoindex" description:"Maintain a full address-based unspent transaction output index which makes the getaddressbalance and getaddressutxos RPCs available (implies --addrindex)"`
+	DropAddrUtxoIndex   bool   `long:"dropaddrutxoindex" description:"Deletes the address-based unspent transaction output index from the database on start up and then exits"`
+	BlockStatsIndex     bool   `long:"blockstatsindex" description:"Maintain a full block statistics index which makes the getblockstats RPC available"`
+	DropBlockStatsIndex bool   `long:"dropblockstatsindex" description:"Deletes the block statistics index from the database on start up and then exits"`
+	NullDataIndex       bool   `long:"nulldataindex" description:"Maintain a full null data (OP_RETURN) payload index which makes the searchnulldata RPC available"`
+	DropNullDataIndex   bool   `long:"dropnulldataindex" description:"Deletes the null data payload index from the database on start up and then exits"`
+	NoExistsAddrIndex   bool   `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used"`
+	DropExistsAddrIndex bool   `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits"`
+	NoCFilters          bool   `long:"nocfilters" description:"(Deprecated) Disable compact filtering (CF) support"`
+	DropCFIndex         bool   `long:"dropcfindex" description:"(Deprecated) Deletes the index used for compact filtering (CF) support from the database on start up and then exits"`
+	Reindex             string `long:"reindex" description:"Rewind the index with the provided name to the provided height on start up so the blocks after it are indexed again -- Specified as <name>:<height> where name is the name of the option used to enable the index or the index name reported by the getindexinfo RPC, for example: 'addrindex:500000' -- The indexes that depend on it are rewound as well"`
 
 	// IPC options.
 	PipeRx         uint `long:"piperx" description:"File descriptor of read end pipe to enable parent -> child process communication"`
@@ -224,6 +262,10 @@ type config struct {
 	oniondial     func(context.Context, string, string) (net.Conn, error)
 	dial          func(context.Context, string, string) (net.Conn, error)
 	miningAddrs   []dcrutil.Address
+	assumeValid   chainhash.Hash
+	reindexName   string
+	reindexHeight int64
+	rpcCookiePath string
 	minRelayTxFee dcrutil.Amount
 	whitelists    []*net.IPNet
 	ipv4NetInfo   types.NetworksResult
@@ -581,6 +623,10 @@ func loadConfig(appName string) (*config, []string, error) {
 		BanDuration:  defaultBanDuration,
 		BanThreshold: defaultBanThreshold,
 
+		// Chain related options.
+		Prune:            defaultPrune,
+		UtxoCacheMaxSize: defaultUtxoCacheMaxSize,
+
 		// Relay and mempool policy.
 		MinRelayTxFee:    mempool.DefaultMinRelayTxFee.ToCoin(),
 		FreeTxRelayLimit: defaultFreeTxRelayLimit,
@@ -858,6 +904,20 @@ func loadConfig(appName string) (*config, []string, error) {
 		}
 	}
 
+	// Validate format of the metrics listen address, which can be an
+	// address:port, or just a port in which case it listens on localhost.
+	if cfg.MetricsListen != "" {
+		if _, err := strconv.Atoi(cfg.MetricsListen); err == nil {
+			cfg.MetricsListen = net.JoinHostPort("127.0.0.1",
+				cfg.MetricsListen)
+		}
+		if _, _, err := net.SplitHostPort(cfg.MetricsListen); err != nil {
+			str := "%s: metricslisten: %w"
+			err := fmt.Errorf(str, funcName, err)
+			return nil, nil, err
+		}
+	}
+
 	// Don't allow ban durations that are too short.
 	if cfg.BanDuration < time.Second {
 		str := "%s: the banduration option may not be less than 1s -- parsed [%v]"
@@ -954,11 +1014,32 @@ func loadConfig(appName string) (*config, []string, error) {
 		return nil, nil, err
 	}
 
-	// The RPC server is disabled if no username or password is provided.
-	if (cfg.RPCUser == "" || cfg.RPCPass == "") &&
-		(cfg.RPCLimitUser == "" || cfg.RPCLimitPass == "") {
+	// The RPC server is disabled if no credentials of any kind are provided
+	// and cookie authentication is not enabled.
+	hasRPCCredentials := (cfg.RPCUser != "" && cfg.RPCPass != "") ||
+		(cfg.RPCLimitUser != "" && cfg.RPCLimitPass != "") ||
+		cfg.RPCUsersFile != "" || len(cfg.RPCAdminClients) > 0 ||
+		len(cfg.RPCLimitClients) > 0
+	if !hasRPCCredentials && !cfg.RPCCookie {
 		cfg.DisableRPC = true
 	}
+	if cfg.RPCCookie && !cfg.DisableRPC {
+		cfg.rpcCookiePath = filepath.Join(cfg.DataDir,
+			defaultRPCCookieFilename)
+	}
+	cfg.RPCUsersFile = cleanAndExpandPath(cfg.RPCUsersFile)
+	cfg.RPCClientCAFile = cleanAndExpandPath(cfg.RPCClientCAFile)
+
+	// Client certificates may only be mapped to RPC users when they are
+	// verified against a certificate authority.
+	if cfg.RPCClientCAFile == "" && (len(cfg.RPCAdminClients) > 0 ||
+		len(cfg.RPCLimitClients) > 0) {
+
+		str := "%s: the --rpcadminclient and --rpclimitclient options " +
+			"require --rpcclientcafile"
+		err := fmt.Errorf(str, funcName)
+		return nil, nil, err
+	}
 
 	// Default RPC to listen on localhost only.
 	if !cfg.DisableRPC && len(cfg.RPCListeners) == 0 {
@@ -1013,6 +1094,95 @@ func loadConfig(appName string) (*config, []string, error) {
 	cfg.BlockPrioritySize = minUint32(cfg.BlockPrioritySize, cfg.BlockMaxSize)
 	cfg.BlockMinSize = minUint32(cfg.BlockMinSize, cfg.BlockMaxSize)
 
+	// Ensure the prune target is large enough to hold the block data required
+	// to validate new blocks.
+	if cfg.Prune != 0 && cfg.Prune < pruneMinSizeMiB {
+		str := "%s: the prune option may not be less than %d MiB -- " +
+			"parsed [%d]"
+		err := fmt.Errorf(str, funcName, pruneMinSizeMiB, cfg.Prune)
+		return nil, nil, err
+	}
+
+	// Ensure the utxo cache max size is within the allowed range.
+	if cfg.UtxoCacheMaxSize < minUtxoCacheMaxSize ||
+		cfg.UtxoCacheMaxSize > maxUtxoCacheMaxSize {
+
+		str := "%s: the utxocachemaxsize option must be in range " +
+			"[%d, %d] MiB -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, minUtxoCacheMaxSize,
+			maxUtxoCacheMaxSize, cfg.UtxoCacheMaxSize)
+		return nil, nil, err
+	}
+
+	// Expand the path to the utxo set snapshot to load when specified.
+	if cfg.LoadUtxoSnapshot != "" {
+		cfg.LoadUtxoSnapshot = cleanAndExpandPath(cfg.LoadUtxoSnapshot)
+	}
+
+	// Loading a utxo set snapshot requires the active network to commit to
+	// at least one snapshot.
+	if cfg.LoadUtxoSnapshot != "" && len(cfg.params.AssumeUtxo) == 0 {
+		err := fmt.Errorf("%s: the --loadutxosnapshot option is not "+
+			"supported on %s since it does not commit to any utxo set "+
+			"snapshots", funcName, cfg.params.Name)
+		return nil, nil, err
+	}
+
+	// --verifydb and --repairdb do not mix with --loadutxosnapshot.
+	if (cfg.VerifyDB || cfg.RepairDB) && cfg.LoadUtxoSnapshot != "" {
+		err := fmt.Errorf("%s: the --verifydb and --repairdb options may "+
+			"not be activated at the same time as --loadutxosnapshot",
+			funcName)
+		return nil, nil, err
+	}
+
+	// --prune does not mix with indexes that require historical block data.
+	if cfg.Prune != 0 && cfg.TxIndex {
+		err := fmt.Errorf("%s: the --prune and --txindex options may "+
+			"not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.AddrIndex {
+		err := fmt.Errorf("%s: the --prune and --addrindex options may "+
+			"not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.SpendIndex {
+		err := fmt.Errorf("%s: the --prune and --spendindex options may "+
+			"not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.TicketIndex {
+		err := fmt.Errorf("%s: the --prune and --ticketindex options may "+
+			"not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.TreasuryIndex {
+		err := fmt.Errorf("%s: the --prune and --treasuryindex options may "+
+			"not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.ScriptHashIndex {
+		err := fmt.Errorf("%s: the --prune and --scripthashindex options "+
+			"may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.AddrUtxoIndex {
+		err := fmt.Errorf("%s: the --prune and --addrutxoindex options "+
+			"may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.BlockStatsIndex {
+		err := fmt.Errorf("%s: the --prune and --blockstatsindex options "+
+			"may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+	if cfg.Prune != 0 && cfg.NullDataIndex {
+		err := fmt.Errorf("%s: the --prune and --nulldataindex options "+
+			"may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
 	// --txindex and --droptxindex do not mix.
 	if cfg.TxIndex && cfg.DropTxIndex {
 		err := fmt.Errorf("%s: the --txindex and --droptxindex "+
@@ -1038,6 +1208,69 @@ func loadConfig(appName string) (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --spendindex and --dropspendindex do not mix.
+	if cfg.SpendIndex && cfg.DropSpendIndex {
+		err := fmt.Errorf("%s: the --spendindex and --dropspendindex "+
+			"options may not be activated at the same time",
+			funcName)
+		return nil, nil, err
+	}
+
+	// --ticketindex and --dropticketindex do not mix.
+	if cfg.TicketIndex && cfg.DropTicketIndex {
+		err := fmt.Errorf("%s: the --ticketindex and --dropticketindex "+
+			"options may not be activated at the same time",
+			funcName)
+		return nil, nil, err
+	}
+
+	// --treasuryindex and --droptreasuryindex do not mix.
+	if cfg.TreasuryIndex && cfg.DropTreasuryIndex {
+		err := fmt.Errorf("%s: the --treasuryindex and --droptreasuryindex "+
+			"options may not be activated at the same time",
+			funcName)
+		return nil, nil, err
+	}
+
+	// --scripthashindex and --dropscripthashindex do not mix.
+	if cfg.ScriptHashIndex && cfg.DropScriptHashIndex {
+		err := fmt.Errorf("%s: the --scripthashindex and "+
+			"--dropscripthashindex options may not be activated at the same "+
+			"time", funcName)
+		return nil, nil, err
+	}
+
+	// --addrutxoindex and --dropaddrutxoindex do not mix.
+	if cfg.AddrUtxoIndex && cfg.DropAddrUtxoIndex {
+		err := fmt.Errorf("%s: the --addrutxoindex and --dropaddrutxoindex "+
+			"options may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
+	// --addrutxoindex and --dropaddrindex do not mix.
+	if cfg.AddrUtxoIndex && cfg.DropAddrIndex {
+		err := fmt.Errorf("%s: the --addrutxoindex and --dropaddrindex "+
+			"options may not be activated at the same time "+
+			"because the address utxo index relies on the address "+
+			"index", funcName)
+		return nil, nil, err
+	}
+
+	// --blockstatsindex and --dropblockstatsindex do not mix.
+	if cfg.BlockStatsIndex && cfg.DropBlockStatsIndex {
+		err := fmt.Errorf("%s: the --blockstatsindex and "+
+			"--dropblockstatsindex options may not be activated at the same "+
+			"time", funcName)
+		return nil, nil, err
+	}
+
+	// --nulldataindex and --dropnulldataindex do not mix.
+	if cfg.NullDataIndex && cfg.DropNullDataIndex {
+		err := fmt.Errorf("%s: the --nulldataindex and --dropnulldataindex "+
+			"options may not be activated at the same time", funcName)
+		return nil, nil, err
+	}
+
 	// !--noexistsaddrindex and --dropexistsaddrindex do not mix.
 	if !cfg.NoExistsAddrIndex && cfg.DropExistsAddrIndex {
 		err := fmt.Errorf("dropexistsaddrindex cannot be activated when " +
@@ -1063,6 +1296,42 @@ func loadConfig(appName string) (*config, []string, error) {
 		cfg.miningAddrs = append(cfg.miningAddrs, addr)
 	}
 
+	// Parse the assumed valid block hash when it is specified.  Otherwise, use
+	// the one for the active network.  A value of 0 disables it.
+	cfg.assumeValid = cfg.params.AssumeValid
+	switch cfg.AssumeValid {
+	case "":
+	case "0":
+		cfg.assumeValid = chainhash.Hash{}
+	default:
+		hash, err := chainhash.NewHashFromStr(cfg.AssumeValid)
+		if err != nil {
+			str := "%s: assumevalid block hash '%s' failed to decode: %w"
+			err := fmt.Errorf(str, funcName, cfg.AssumeValid, err)
+			return nil, nil, err
+		}
+		cfg.assumeValid = *hash
+	}
+
+	// Parse the name and height of the index to rewind when it is specified.
+	// The height follows the final colon since index names may contain them.
+	if cfg.Reindex != "" {
+		sep := strings.LastIndex(cfg.Reindex, ":")
+		if sep <= 0 {
+			str := "%s: reindex value '%s' must be of the form <name>:<height>"
+			err := fmt.Errorf(str, funcName, cfg.Reindex)
+			return nil, nil, err
+		}
+		height, err := strconv.ParseInt(cfg.Reindex[sep+1:], 10, 64)
+		if err != nil || height < 0 {
+			str := "%s: reindex height '%s' is not a valid block height"
+			err := fmt.Errorf(str, funcName, cfg.Reindex[sep+1:])
+			return nil, nil, err
+		}
+		cfg.reindexName = cfg.Reindex[:sep]
+		cfg.reindexHeight = height
+	}
+
 	// Ensure there is at least one mining address when the generate flag is
 	// set.
 	if cfg.Generate && len(cfg.miningAddrs) == 0 {
@@ -1120,6 +1389,15 @@ func loadConfig(appName string) (*config, []string, error) {
 		}
 	}
 
+	// Client certificates are part of the TLS handshake, so they can't be
+	// required when TLS is disabled.
+	if !cfg.DisableRPC && cfg.DisableTLS && cfg.RPCClientCAFile != "" {
+		str := "%s: the --rpcclientcafile option may not be used with " +
+			"--notls"
+		err := fmt.Errorf(str, funcName)
+		return nil, nil, err
+	}
+
 	// Add default port to all added peer addresses if needed and remove
 	// duplicate addresses.
 	cfg.AddPeers = normalizeAddresses(cfg.AddPeers,
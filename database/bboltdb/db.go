@@ -0,0 +1,1676 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bboltdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/wire"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// dbFileName is the name of the file that houses the database within
+	// the database path.
+	dbFileName = "data.db"
+
+	// blockHdrSize is the size of a block header.  This is simply the
+	// constant from wire and is only provided here for convenience since
+	// wire.MaxBlockHeaderPayload is quite long.
+	blockHdrSize = wire.MaxBlockHeaderPayload
+
+	// openTimeout is the maximum amount of time to wait to obtain the lock
+	// on the database file when opening it.
+	openTimeout = time.Second * 5
+
+	// initialMmapSize is the initial size of the memory map of the
+	// database file.  Growing the memory map requires waiting for all open
+	// read-only transactions to finish, so starting with a reasonably large
+	// map avoids stalling writers behind long-running readers while the
+	// database is small.
+	initialMmapSize = 256 * 1024 * 1024
+)
+
+var (
+	// byteOrder is the preferred byte order used for the values stored in
+	// the database.  Big endian is used for keys that need to be sortable.
+	byteOrder = binary.LittleEndian
+
+	// metadataBucketName is the name of the top-level bucket that houses
+	// all metadata exposed via the database.Tx interface.
+	metadataBucketName = []byte("metadata")
+
+	// blockIdxBucketName is the name of the top-level bucket that maps each
+	// stored block hash to the sequence number it is stored under.
+	//
+	// The serialized block index entry format is:
+	//   <block hash> -> <sequence number>
+	//
+	//   Field            Type              Size
+	//   block hash       chainhash.Hash    chainhash.HashSize
+	//   sequence number  uint64 big endian 8
+	blockIdxBucketName = []byte("blockidx")
+
+	// blocksBucketName is the name of the top-level bucket that houses the
+	// serialized blocks keyed by the sequence number they were stored with
+	// so they are ordered from oldest to newest.
+	//
+	// The serialized block entry format is:
+	//   <sequence number> -> <block hash><serialized block>
+	//
+	//   Field             Type              Size
+	//   sequence number   uint64 big endian 8
+	//   block hash        chainhash.Hash    chainhash.HashSize
+	//   serialized block  []byte            variable
+	blocksBucketName = []byte("blocks")
+
+	// stateBucketName is the name of the top-level bucket that houses the
+	// internal state of the driver.
+	stateBucketName = []byte("state")
+
+	// networkKeyName is the key in the state bucket that stores the block
+	// network the database was created for as a little-endian uint32.
+	networkKeyName = []byte("network")
+
+	// blocksSizeKeyName is the key in the state bucket that stores the total
+	// size of all stored serialized blocks as a little-endian uint64.
+	blocksSizeKeyName = []byte("blockssize")
+)
+
+// Common error strings.
+const (
+	// errDbNotOpenStr is the text to use for the database.ErrDbNotOpen
+	// error code.
+	errDbNotOpenStr = "database is not open"
+
+	// errTxClosedStr is the text to use for the database.ErrTxClosed error
+	// code.
+	errTxClosedStr = "database tx is closed"
+)
+
+// makeDbErr creates a database.Error given a set of arguments.
+func makeDbErr(c database.ErrorCode, desc string, err error) database.Error {
+	return database.Error{ErrorCode: c, Description: desc, Err: err}
+}
+
+// convertErr converts the passed bbolt error into a database error with an
+// equivalent error code and the passed description.  It also sets the passed
+// error as the underlying error.
+func convertErr(desc string, boltErr error) database.Error {
+	// Use the driver-specific error code by default.  The code below will
+	// update this with the converted error if it's recognized.
+	var code = database.ErrDriverSpecific
+
+	switch {
+	// Database corruption errors.
+	case errors.Is(boltErr, bolt.ErrInvalid):
+		code = database.ErrCorruption
+	case errors.Is(boltErr, bolt.ErrChecksum):
+		code = database.ErrCorruption
+	case errors.Is(boltErr, bolt.ErrVersionMismatch):
+		code = database.ErrCorruption
+
+	// Database open/create errors.
+	case errors.Is(boltErr, bolt.ErrDatabaseNotOpen):
+		code = database.ErrDbNotOpen
+	case errors.Is(boltErr, bolt.ErrTimeout):
+		code = database.ErrDbAlreadyOpen
+
+	// Transaction errors.
+	case errors.Is(boltErr, bolt.ErrTxClosed):
+		code = database.ErrTxClosed
+	case errors.Is(boltErr, bolt.ErrTxNotWritable):
+		code = database.ErrTxNotWritable
+
+	// Bucket and key errors.
+	case errors.Is(boltErr, bolt.ErrBucketNotFound):
+		code = database.ErrBucketNotFound
+	case errors.Is(boltErr, bolt.ErrBucketExists):
+		code = database.ErrBucketExists
+	case errors.Is(boltErr, bolt.ErrBucketNameRequired):
+		code = database.ErrBucketNameRequired
+	case errors.Is(boltErr, bolt.ErrKeyRequired):
+		code = database.ErrKeyRequired
+	case errors.Is(boltErr, bolt.ErrKeyTooLarge):
+		code = database.ErrKeyTooLarge
+	case errors.Is(boltErr, bolt.ErrValueTooLarge):
+		code = database.ErrValueTooLarge
+	case errors.Is(boltErr, bolt.ErrIncompatibleValue):
+		code = database.ErrIncompatibleValue
+	}
+
+	return database.Error{ErrorCode: code, Description: desc, Err: boltErr}
+}
+
+// copySlice returns a copy of the passed slice.  All data returned to callers
+// is copied out of the memory-mapped database file since it is only valid
+// until the transaction ends and callers are not always careful to avoid
+// holding on to it for longer.
+func copySlice(slice []byte) []byte {
+	ret := make([]byte, len(slice))
+	copy(ret, slice)
+	return ret
+}
+
+// cursor is an internal type used to represent a cursor over key/value pairs
+// and nested buckets of a bucket and implements the database.Cursor interface.
+type cursor struct {
+	bucket     *bucket
+	boltCursor *bolt.Cursor
+	key        []byte
+	value      []byte
+}
+
+// Enforce cursor implements the database.Cursor interface.
+var _ database.Cursor = (*cursor)(nil)
+
+// Bucket returns the bucket the cursor was created for.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Bucket() database.Bucket {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return nil
+	}
+
+	return c.bucket
+}
+
+// Delete removes the current key/value pair the cursor is at without
+// invalidating the cursor.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrIncompatibleValue if attempted when the cursor points to a nested
+//     bucket
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Delete() error {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !c.bucket.tx.writable {
+		str := "deleting a value requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Error if the cursor is exhausted.
+	if c.key == nil {
+		str := "cursor is exhausted"
+		return makeDbErr(database.ErrIncompatibleValue, str, nil)
+	}
+
+	// Do not allow buckets to be deleted via the cursor.
+	if c.value == nil {
+		str := "buckets may not be deleted from a cursor"
+		return makeDbErr(database.ErrIncompatibleValue, str, nil)
+	}
+
+	if err := c.boltCursor.Delete(); err != nil {
+		str := fmt.Sprintf("failed to delete key %q", c.key)
+		return convertErr(str, err)
+	}
+	return nil
+}
+
+// setPosition updates the current key and value of the cursor to the provided
+// pair returned from the underlying cursor and returns whether or not the pair
+// exists.
+func (c *cursor) setPosition(k, v []byte) bool {
+	c.key, c.value = k, v
+	return k != nil
+}
+
+// First positions the cursor at the first key/value pair and returns whether or
+// not the pair exists.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) First() bool {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return false
+	}
+
+	return c.setPosition(c.boltCursor.First())
+}
+
+// Last positions the cursor at the last key/value pair and returns whether or
+// not the pair exists.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Last() bool {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return false
+	}
+
+	return c.setPosition(c.boltCursor.Last())
+}
+
+// Next moves the cursor one key/value pair forward and returns whether or not
+// the pair exists.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Next() bool {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return false
+	}
+
+	// Nothing to return if cursor is exhausted.
+	if c.key == nil {
+		return false
+	}
+
+	return c.setPosition(c.boltCursor.Next())
+}
+
+// Prev moves the cursor one key/value pair backward and returns whether or not
+// the pair exists.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Prev() bool {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return false
+	}
+
+	// Nothing to return if cursor is exhausted.
+	if c.key == nil {
+		return false
+	}
+
+	return c.setPosition(c.boltCursor.Prev())
+}
+
+// Seek positions the cursor at the first key/value pair that is greater than or
+// equal to the passed seek key.  Returns false if no suitable key was found.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Seek(seek []byte) bool {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return false
+	}
+
+	return c.setPosition(c.boltCursor.Seek(seek))
+}
+
+// Key returns the current key the cursor is pointing to.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Key() []byte {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return nil
+	}
+
+	// Nothing to return if cursor is exhausted.
+	if c.key == nil {
+		return nil
+	}
+
+	return copySlice(c.key)
+}
+
+// Value returns the current value the cursor is pointing to.  This will be nil
+// for nested buckets.
+//
+// This function is part of the database.Cursor interface implementation.
+func (c *cursor) Value() []byte {
+	// Ensure transaction state is valid.
+	if err := c.bucket.tx.checkClosed(); err != nil {
+		return nil
+	}
+
+	// Nothing to return if cursor is exhausted or is pointing to a nested
+	// bucket.
+	if c.value == nil {
+		return nil
+	}
+
+	return copySlice(c.value)
+}
+
+// bucket is an internal type used to represent a collection of key/value pairs
+// and implements the database.Bucket interface.
+type bucket struct {
+	tx         *transaction
+	boltBucket *bolt.Bucket
+}
+
+// Enforce bucket implements the database.Bucket interface.
+var _ database.Bucket = (*bucket)(nil)
+
+// Bucket retrieves a nested bucket with the given key.  Returns nil if
+// the bucket does not exist.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) Bucket(key []byte) database.Bucket {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return nil
+	}
+
+	childBucket := b.boltBucket.Bucket(key)
+	if childBucket == nil {
+		return nil
+	}
+	return &bucket{tx: b.tx, boltBucket: childBucket}
+}
+
+// CreateBucket creates and returns a new nested bucket with the given key.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBucketExists if the bucket already exists
+//   - ErrBucketNameRequired if the key is empty
+//   - ErrIncompatibleValue if the key is otherwise invalid for the particular
+//     implementation
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) CreateBucket(key []byte) (database.Bucket, error) {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable.
+	if !b.tx.writable {
+		str := "create bucket requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Ensure a key was provided.
+	if len(key) == 0 {
+		str := "create bucket requires a key"
+		return nil, makeDbErr(database.ErrBucketNameRequired, str, nil)
+	}
+
+	childBucket, err := b.boltBucket.CreateBucket(key)
+	if err != nil {
+		str := fmt.Sprintf("failed to create bucket with key %q", key)
+		return nil, convertErr(str, err)
+	}
+	return &bucket{tx: b.tx, boltBucket: childBucket}, nil
+}
+
+// CreateBucketIfNotExists creates and returns a new nested bucket with the
+// given key if it does not already exist.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBucketNameRequired if the key is empty
+//   - ErrIncompatibleValue if the key is otherwise invalid for the particular
+//     implementation
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) CreateBucketIfNotExists(key []byte) (database.Bucket, error) {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable.
+	if !b.tx.writable {
+		str := "create bucket requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Return existing bucket if it already exists, otherwise create it.
+	if bucket := b.Bucket(key); bucket != nil {
+		return bucket, nil
+	}
+	return b.CreateBucket(key)
+}
+
+// DeleteBucket removes a nested bucket with the given key.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBucketNotFound if the specified bucket does not exist
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) DeleteBucket(key []byte) error {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !b.tx.writable {
+		str := "delete bucket requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	if err := b.boltBucket.DeleteBucket(key); err != nil {
+		str := fmt.Sprintf("failed to delete bucket %q", key)
+		return convertErr(str, err)
+	}
+	return nil
+}
+
+// Cursor returns a new cursor, allowing for iteration over the bucket's
+// key/value pairs and nested buckets in forward or backward order.
+//
+// You must seek to a position using the First, Last, or Seek functions before
+// calling the Next, Prev, Key, or Value functions.  Failure to do so will
+// result in the same return values as an exhausted cursor, which is false for
+// the Prev and Next functions and nil for Key and Value functions.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) Cursor() database.Cursor {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return &cursor{bucket: b}
+	}
+
+	return &cursor{bucket: b, boltCursor: b.boltBucket.Cursor()}
+}
+
+// ForEach invokes the passed function with every key/value pair in the bucket.
+// This does not include nested buckets or the key/value pairs within those
+// nested buckets.
+//
+// WARNING: It is not safe to mutate data while iterating with this method.
+// Doing so may cause the underlying cursor to be invalidated and return
+// unexpected keys and/or values.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxClosed if the transaction has already been closed
+//
+// NOTE: The values returned by this function are only valid during a
+// transaction.  Attempting to access them after a transaction has ended will
+// likely result in an access violation.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) ForEach(fn func(k, v []byte) error) error {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Invoke the callback for each key/value pair while skipping nested
+	// buckets, which have nil values.  Return the error returned from the
+	// callback when it is non-nil.
+	c := b.boltBucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		if err := fn(copySlice(k), copySlice(v)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ForEachBucket invokes the passed function with the key of every nested bucket
+// in the current bucket.  This does not include any nested buckets within those
+// nested buckets.
+//
+// WARNING: It is not safe to mutate data while iterating with this method.
+// Doing so may cause the underlying cursor to be invalidated and return
+// unexpected keys.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxClosed if the transaction has already been closed
+//
+// NOTE: The values returned by this function are only valid during a
+// transaction.  Attempting to access them after a transaction has ended will
+// likely result in an access violation.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) ForEachBucket(fn func(k []byte) error) error {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Invoke the callback for each nested bucket, which have nil values.
+	// Return the error returned from the callback when it is non-nil.
+	c := b.boltBucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if v != nil {
+			continue
+		}
+		if err := fn(copySlice(k)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Writable returns whether or not the bucket is writable.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) Writable() bool {
+	return b.tx.writable
+}
+
+// Put saves the specified key/value pair to the bucket.  Keys that do not
+// already exist are added and keys that already exist are overwritten.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrKeyRequired if the key is empty
+//   - ErrIncompatibleValue if the key is the same as an existing bucket
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) Put(key, value []byte) error {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !b.tx.writable {
+		str := "setting a key requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Ensure a key was provided.
+	if len(key) == 0 {
+		str := "put requires a key"
+		return makeDbErr(database.ErrKeyRequired, str, nil)
+	}
+
+	// Nested buckets are identified by nil values, so store an empty value
+	// instead of nil to ensure keys without a value assigned are still
+	// treated as normal entries.
+	if value == nil {
+		value = []byte{}
+	}
+
+	if err := b.boltBucket.Put(key, value); err != nil {
+		str := fmt.Sprintf("failed to put key %q", key)
+		return convertErr(str, err)
+	}
+	return nil
+}
+
+// Get returns the value for the given key.  Returns nil if the key does not
+// exist in this bucket.  An empty slice is returned for keys that exist but
+// have no value assigned.
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) Get(key []byte) []byte {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return nil
+	}
+
+	// Nothing to return if there is no key.
+	if len(key) == 0 {
+		return nil
+	}
+
+	value := b.boltBucket.Get(key)
+	if value == nil {
+		return nil
+	}
+	return copySlice(value)
+}
+
+// Delete removes the specified key from the bucket.  Deleting a key that does
+// not exist does not return an error.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrKeyRequired if the key is empty
+//   - ErrIncompatibleValue if the key is the same as an existing bucket
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Bucket interface implementation.
+func (b *bucket) Delete(key []byte) error {
+	// Ensure transaction state is valid.
+	if err := b.tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !b.tx.writable {
+		str := "deleting a value requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Nothing to do if there is no key.
+	if len(key) == 0 {
+		return nil
+	}
+
+	if err := b.boltBucket.Delete(key); err != nil {
+		str := fmt.Sprintf("failed to delete key %q", key)
+		return convertErr(str, err)
+	}
+	return nil
+}
+
+// transaction represents a database transaction.  It can either be read-only or
+// read-write and implements the database.Tx interface.  The transaction
+// provides a root bucket against which all read and writes occur.
+type transaction struct {
+	managed        bool         // Is the transaction managed?
+	closed         bool         // Is the transaction closed?
+	writable       bool         // Is the transaction writable?
+	db             *db          // DB instance the tx was created from.
+	boltTx         *bolt.Tx     // Underlying bbolt transaction.
+	metaBucket     *bucket      // The root metadata bucket.
+	blockIdxBucket *bolt.Bucket // The block index bucket.
+	blocksBucket   *bolt.Bucket // The serialized blocks bucket.
+	stateBucket    *bolt.Bucket // The internal state bucket.
+}
+
+// Enforce transaction implements the database.Tx interface.
+var _ database.Tx = (*transaction)(nil)
+
+// Enforce transaction implements the database.BlockPruner interface.
+var _ database.BlockPruner = (*transaction)(nil)
+
+// Enforce transaction implements the database.Backupper interface.
+var _ database.Backupper = (*transaction)(nil)
+
+// Enforce transaction implements the database.BlockVerifier interface.
+var _ database.BlockVerifier = (*transaction)(nil)
+
+// checkClosed returns an error if the database or transaction is closed.
+func (tx *transaction) checkClosed() error {
+	// The transaction is no longer valid if it has been closed.
+	if tx.closed {
+		return makeDbErr(database.ErrTxClosed, errTxClosedStr, nil)
+	}
+
+	return nil
+}
+
+// Metadata returns the top-most bucket for all metadata storage.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) Metadata() database.Bucket {
+	return tx.metaBucket
+}
+
+// blocksSize returns the total size of all stored serialized blocks.
+func (tx *transaction) blocksSize() uint64 {
+	serialized := tx.stateBucket.Get(blocksSizeKeyName)
+	if len(serialized) != 8 {
+		return 0
+	}
+	return byteOrder.Uint64(serialized)
+}
+
+// putBlocksSize stores the provided total size of all stored serialized
+// blocks.
+//
+// NOTE: This function must only be called on a writable transaction.  Since it
+// is an internal helper function, it does not check.
+func (tx *transaction) putBlocksSize(size uint64) error {
+	var serialized [8]byte
+	byteOrder.PutUint64(serialized[:], size)
+	if err := tx.stateBucket.Put(blocksSizeKeyName, serialized[:]); err != nil {
+		return convertErr("failed to store total block size", err)
+	}
+	return nil
+}
+
+// StoreBlock stores the provided block into the database.  There are no checks
+// to ensure the block connects to a previous block, contains double spends, or
+// any additional functionality such as transaction indexing.  It simply stores
+// the block in the database.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockExists when the block hash already exists
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) StoreBlock(block database.BlockSerializer) error {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "store block requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Reject the block if it already exists.
+	blockHash := block.Hash()
+	if tx.blockIdxBucket.Get(blockHash[:]) != nil {
+		str := fmt.Sprintf("block %s already exists", blockHash)
+		return makeDbErr(database.ErrBlockExists, str, nil)
+	}
+
+	blockBytes, err := block.Bytes()
+	if err != nil {
+		str := fmt.Sprintf("failed to get serialized bytes for block %s",
+			blockHash)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+
+	// Store the block under the next sequence number so blocks are ordered
+	// from oldest to newest and add it to the block index.
+	seq, err := tx.blocksBucket.NextSequence()
+	if err != nil {
+		str := fmt.Sprintf("failed to store block %s", blockHash)
+		return convertErr(str, err)
+	}
+	var seqKey [8]byte
+	binary.BigEndian.PutUint64(seqKey[:], seq)
+	entry := make([]byte, chainhash.HashSize+len(blockBytes))
+	copy(entry, blockHash[:])
+	copy(entry[chainhash.HashSize:], blockBytes)
+	if err := tx.blocksBucket.Put(seqKey[:], entry); err != nil {
+		str := fmt.Sprintf("failed to store block %s", blockHash)
+		return convertErr(str, err)
+	}
+	if err := tx.blockIdxBucket.Put(blockHash[:], seqKey[:]); err != nil {
+		str := fmt.Sprintf("failed to index block %s", blockHash)
+		return convertErr(str, err)
+	}
+	log.Tracef("Stored block %s with sequence number %d", blockHash, seq)
+
+	return tx.putBlocksSize(tx.blocksSize() + uint64(len(blockBytes)))
+}
+
+// HasBlock returns whether or not a block with the given hash exists in the
+// database.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) HasBlock(hash *chainhash.Hash) (bool, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return false, err
+	}
+
+	return tx.blockIdxBucket.Get(hash[:]) != nil, nil
+}
+
+// HasBlocks returns whether or not the blocks with the provided hashes
+// exist in the database.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) HasBlocks(hashes []chainhash.Hash) ([]bool, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	results := make([]bool, len(hashes))
+	for i := range hashes {
+		results[i] = tx.blockIdxBucket.Get(hashes[i][:]) != nil
+	}
+
+	return results, nil
+}
+
+// fetchRawBlock returns the serialized block stored for the provided hash
+// directly from the memory-mapped database file.  It will return
+// ErrBlockNotFound if there is no entry.
+func (tx *transaction) fetchRawBlock(hash *chainhash.Hash) ([]byte, error) {
+	seqKey := tx.blockIdxBucket.Get(hash[:])
+	if seqKey == nil {
+		str := fmt.Sprintf("block %s does not exist", hash)
+		return nil, makeDbErr(database.ErrBlockNotFound, str, nil)
+	}
+
+	entry := tx.blocksBucket.Get(seqKey)
+	if len(entry) < chainhash.HashSize+blockHdrSize {
+		str := fmt.Sprintf("block %s entry is missing or truncated", hash)
+		return nil, makeDbErr(database.ErrCorruption, str, nil)
+	}
+
+	return entry[chainhash.HashSize:], nil
+}
+
+// FetchBlockHeader returns the raw serialized bytes for the block header
+// identified by the given hash.  The raw bytes are in the format returned by
+// Serialize on a wire.BlockHeader.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) FetchBlockHeader(hash *chainhash.Hash) ([]byte, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	blockBytes, err := tx.fetchRawBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return copySlice(blockBytes[:blockHdrSize]), nil
+}
+
+// FetchBlockHeaders returns the raw serialized bytes for the block headers
+// identified by the given hashes.  The raw bytes are in the format returned by
+// Serialize on a wire.BlockHeader.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the any of the requested block hashes do not exist
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) FetchBlockHeaders(hashes []chainhash.Hash) ([][]byte, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	headers := make([][]byte, len(hashes))
+	for i := range hashes {
+		blockBytes, err := tx.fetchRawBlock(&hashes[i])
+		if err != nil {
+			return nil, err
+		}
+		headers[i] = copySlice(blockBytes[:blockHdrSize])
+	}
+
+	return headers, nil
+}
+
+// FetchBlock returns the raw serialized bytes for the block identified by the
+// given hash.  The raw bytes are in the format returned by Serialize on a
+// wire.MsgBlock.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) FetchBlock(hash *chainhash.Hash) ([]byte, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	blockBytes, err := tx.fetchRawBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+	return copySlice(blockBytes), nil
+}
+
+// FetchBlocks returns the raw serialized bytes for the blocks identified by the
+// given hashes.  The raw bytes are in the format returned by Serialize on a
+// wire.MsgBlock.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if any of the requested block hashed do not exist
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) FetchBlocks(hashes []chainhash.Hash) ([][]byte, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	blocks := make([][]byte, len(hashes))
+	for i := range hashes {
+		blockBytes, err := tx.fetchRawBlock(&hashes[i])
+		if err != nil {
+			return nil, err
+		}
+		blocks[i] = copySlice(blockBytes)
+	}
+
+	return blocks, nil
+}
+
+// fetchRegion returns a copy of the raw serialized bytes for the given block
+// region after ensuring the region is within the bounds of the block.
+func (tx *transaction) fetchRegion(region *database.BlockRegion) ([]byte, error) {
+	blockBytes, err := tx.fetchRawBlock(region.Hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ensure the region is within the bounds of the block.
+	blockLen := uint32(len(blockBytes))
+	endOffset := region.Offset + region.Len
+	if endOffset < region.Offset || endOffset > blockLen {
+		str := fmt.Sprintf("block %s region offset %d, length %d "+
+			"exceeds block length of %d", region.Hash,
+			region.Offset, region.Len, blockLen)
+		return nil, makeDbErr(database.ErrBlockRegionInvalid, str, nil)
+	}
+
+	return copySlice(blockBytes[region.Offset:endOffset]), nil
+}
+
+// FetchBlockRegion returns the raw serialized bytes for the given block region.
+//
+// For example, it is possible to directly extract transactions and/or scripts
+// from a block with this function.  Since the blocks are stored in the
+// memory-mapped database file, only the requested region is copied.
+//
+// The raw bytes are in the format returned by Serialize on a wire.MsgBlock and
+// the Offset field in the provided BlockRegion is zero-based and relative to
+// the start of the block (byte 0).
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrBlockRegionInvalid if the region exceeds the bounds of the associated
+//     block
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) FetchBlockRegion(region *database.BlockRegion) ([]byte, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	return tx.fetchRegion(region)
+}
+
+// FetchBlockRegions returns the raw serialized bytes for the given block
+// regions.
+//
+// For example, it is possible to directly extract transactions and/or scripts
+// from various blocks with this function.  Since the blocks are stored in the
+// memory-mapped database file, only the requested regions are copied.
+//
+// The raw bytes are in the format returned by Serialize on a wire.MsgBlock and
+// the Offset fields in the provided BlockRegions are zero-based and relative to
+// the start of the block (byte 0).
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if any of the request block hashes do not exist
+//   - ErrBlockRegionInvalid if one or more region exceed the bounds of the
+//     associated block
+//   - ErrTxClosed if the transaction has already been closed
+//   - ErrCorruption if the database has somehow become corrupted
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) FetchBlockRegions(regions []database.BlockRegion) ([][]byte, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	blockRegions := make([][]byte, len(regions))
+	for i := range regions {
+		regionBytes, err := tx.fetchRegion(&regions[i])
+		if err != nil {
+			return nil, err
+		}
+		blockRegions[i] = regionBytes
+	}
+
+	return blockRegions, nil
+}
+
+// PruneBlocks removes the oldest stored blocks until the total size of all
+// stored serialized blocks is at or below the provided target size in bytes.
+// Blocks are removed one at a time in the order they were stored and pruning
+// stops at the first block with a height at or after the provided prune height.
+//
+// The hashes of all blocks that were removed are returned.  The space used by
+// the removed blocks is reused for future writes, however, the database file
+// itself does not shrink.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.BlockPruner interface implementation.
+func (tx *transaction) PruneBlocks(targetSize uint64, pruneHeight uint32) ([]chainhash.Hash, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "prune blocks requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	totalSize := tx.blocksSize()
+	if totalSize <= targetSize {
+		return nil, nil
+	}
+
+	// Find the oldest blocks that need to be removed to reach the target
+	// size.  They are removed after iterating since deleting entries while
+	// iterating invalidates the cursor.
+	var header wire.BlockHeader
+	var prunedHashes []chainhash.Hash
+	var prunedKeys [][]byte
+	c := tx.blocksBucket.Cursor()
+	for k, v := c.First(); k != nil && totalSize > targetSize; k, v = c.Next() {
+		if len(v) < chainhash.HashSize+blockHdrSize {
+			str := fmt.Sprintf("block entry %x is truncated", k)
+			return nil, makeDbErr(database.ErrCorruption, str, nil)
+		}
+		blockBytes := v[chainhash.HashSize:]
+		if err := header.FromBytes(blockBytes[:blockHdrSize]); err != nil {
+			str := fmt.Sprintf("failed to deserialize header for "+
+				"block entry %x", k)
+			return nil, makeDbErr(database.ErrCorruption, str, err)
+		}
+		if header.Height >= pruneHeight {
+			break
+		}
+
+		var hash chainhash.Hash
+		copy(hash[:], v[:chainhash.HashSize])
+		prunedHashes = append(prunedHashes, hash)
+		prunedKeys = append(prunedKeys, copySlice(k))
+		totalSize -= uint64(len(blockBytes))
+	}
+	if len(prunedHashes) == 0 {
+		return nil, nil
+	}
+
+	// Remove the blocks and their block index entries.
+	for i := range prunedHashes {
+		if err := tx.blocksBucket.Delete(prunedKeys[i]); err != nil {
+			str := fmt.Sprintf("failed to remove block %s",
+				prunedHashes[i])
+			return nil, convertErr(str, err)
+		}
+		if err := tx.blockIdxBucket.Delete(prunedHashes[i][:]); err != nil {
+			str := fmt.Sprintf("failed to remove block index entry "+
+				"for %s", prunedHashes[i])
+			return nil, convertErr(str, err)
+		}
+	}
+	if err := tx.putBlocksSize(totalSize); err != nil {
+		return nil, err
+	}
+	log.Debugf("Pruned %d blocks", len(prunedHashes))
+
+	return prunedHashes, nil
+}
+
+// Backup writes a consistent copy of the entire database as of when the
+// transaction was started to a new database at the provided path.
+//
+// Since the metadata and blocks are both stored in the same bbolt database, the
+// copy is simply a copy of the database file as seen by the underlying bbolt
+// transaction.  Changes made by the transaction are only written to the file
+// when it is committed, so they are not included.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrDbExists if a database already exists at the provided path
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Backupper interface implementation.
+func (tx *transaction) Backup(destPath string) error {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	dbFilePath := filepath.Join(destPath, dbFileName)
+	if fileExists(dbFilePath) {
+		str := fmt.Sprintf("database %q already exists", dbFilePath)
+		return makeDbErr(database.ErrDbExists, str, nil)
+	}
+	if err := os.MkdirAll(destPath, 0700); err != nil {
+		str := fmt.Sprintf("failed to create directory %q: %v", destPath,
+			err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+
+	if err := tx.boltTx.CopyFile(dbFilePath, 0600); err != nil {
+		_ = os.Remove(dbFilePath)
+		str := fmt.Sprintf("failed to copy database to %q", dbFilePath)
+		return convertErr(str, err)
+	}
+	return nil
+}
+
+// verifyBlockEntry checks the block entry stored under the provided sequence
+// number key against the provided block hash and returns a description of the
+// first problem found or an empty string when the entry is valid.
+func verifyBlockEntry(hash *chainhash.Hash, seqKey, entry []byte) string {
+	if entry == nil {
+		return fmt.Sprintf("block entry %x does not exist", seqKey)
+	}
+	if len(entry) < chainhash.HashSize+blockHdrSize {
+		return fmt.Sprintf("block entry %x is truncated", seqKey)
+	}
+	if !bytes.Equal(entry[:chainhash.HashSize], hash[:]) {
+		return fmt.Sprintf("block entry %x is for block %x", seqKey,
+			entry[:chainhash.HashSize])
+	}
+	var header wire.BlockHeader
+	blockBytes := entry[chainhash.HashSize:]
+	if err := header.FromBytes(blockBytes[:blockHdrSize]); err != nil {
+		return fmt.Sprintf("failed to deserialize header for block entry "+
+			"%x: %v", seqKey, err)
+	}
+	if blockHash := header.BlockHash(); blockHash != *hash {
+		return fmt.Sprintf("block entry %x has hash %s", seqKey, blockHash)
+	}
+	return ""
+}
+
+// VerifyBlocks checks that every block in the block index refers to a stored
+// block with the same hash, that every stored block is referenced by the block
+// index, and that the tracked total size of all stored blocks is accurate.
+//
+// When repair is set, invalid block index entries are removed, valid stored
+// blocks that are not referenced by the block index are added back to it,
+// invalid stored blocks that are not referenced are removed, and the total
+// size is corrected.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxNotWritable if repair is requested against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.BlockVerifier interface
+// implementation.
+func (tx *transaction) VerifyBlocks(repair bool) (*database.VerifyResult, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable when repairing.
+	if repair && !tx.writable {
+		str := "repairing blocks requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Check every entry in the block index while keeping track of the
+	// sequence numbers of the valid entries and the keys of the invalid ones
+	// so they can be removed after iterating.
+	var result database.VerifyResult
+	var badIdxKeys [][]byte
+	indexed := make(map[string]struct{})
+	c := tx.blockIdxBucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		result.NumBlocks++
+		var hash chainhash.Hash
+		copy(hash[:], k)
+		var desc string
+		switch {
+		case len(k) != chainhash.HashSize:
+			desc = fmt.Sprintf("block index key has an invalid length "+
+				"of %d", len(k))
+		case len(v) != 8:
+			desc = fmt.Sprintf("block index entry has an invalid "+
+				"length of %d", len(v))
+		default:
+			desc = verifyBlockEntry(&hash, v, tx.blocksBucket.Get(v))
+		}
+		if desc == "" {
+			indexed[string(v)] = struct{}{}
+			continue
+		}
+		result.Issues = append(result.Issues, database.BlockIssue{
+			Hash:        hash,
+			Description: desc,
+		})
+		badIdxKeys = append(badIdxKeys, copySlice(k))
+	}
+
+	// Check every stored block that is not referenced by a valid block index
+	// entry while calculating the total size of all stored blocks.
+	type unindexedBlock struct {
+		seqKey []byte
+		hash   chainhash.Hash
+		size   uint64
+		valid  bool
+	}
+	var unindexed []unindexedBlock
+	var totalSize uint64
+	c = tx.blocksBucket.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if _, ok := indexed[string(k)]; ok {
+			totalSize += uint64(len(v) - chainhash.HashSize)
+			continue
+		}
+
+		var hash chainhash.Hash
+		if len(v) >= chainhash.HashSize {
+			copy(hash[:], v)
+		}
+		var size uint64
+		valid := verifyBlockEntry(&hash, k, v) == ""
+		if valid {
+			size = uint64(len(v) - chainhash.HashSize)
+			totalSize += size
+		}
+		desc := fmt.Sprintf("block entry %x is not referenced by the block "+
+			"index", k)
+		if !valid {
+			desc = fmt.Sprintf("block entry %x is invalid and not "+
+				"referenced by the block index", k)
+		}
+		result.Issues = append(result.Issues, database.BlockIssue{
+			Hash:        hash,
+			Description: desc,
+		})
+		unindexed = append(unindexed, unindexedBlock{
+			seqKey: copySlice(k),
+			hash:   hash,
+			size:   size,
+			valid:  valid,
+		})
+	}
+	if !repair {
+		if blocksSize := tx.blocksSize(); totalSize != blocksSize {
+			log.Warnf("Total size of stored blocks is %d instead of %d",
+				blocksSize, totalSize)
+		}
+		return &result, nil
+	}
+
+	// Remove the invalid block index entries and either add the unindexed
+	// blocks back to the index or remove them when they are invalid or a
+	// duplicate of an indexed block.
+	for _, k := range badIdxKeys {
+		if err := tx.blockIdxBucket.Delete(k); err != nil {
+			str := fmt.Sprintf("failed to remove block index entry %x", k)
+			return nil, convertErr(str, err)
+		}
+	}
+	for _, block := range unindexed {
+		if !block.valid || tx.blockIdxBucket.Get(block.hash[:]) != nil {
+			totalSize -= block.size
+			if err := tx.blocksBucket.Delete(block.seqKey); err != nil {
+				str := fmt.Sprintf("failed to remove block entry %x",
+					block.seqKey)
+				return nil, convertErr(str, err)
+			}
+			continue
+		}
+		err := tx.blockIdxBucket.Put(block.hash[:], block.seqKey)
+		if err != nil {
+			str := fmt.Sprintf("failed to index block %s", block.hash)
+			return nil, convertErr(str, err)
+		}
+		result.NumRecovered++
+	}
+	if totalSize != tx.blocksSize() {
+		if err := tx.putBlocksSize(totalSize); err != nil {
+			return nil, err
+		}
+	}
+	for i := range result.Issues {
+		result.Issues[i].Repaired = true
+	}
+
+	return &result, nil
+}
+
+// close marks the transaction closed, rolls back the underlying bbolt
+// transaction if it was not committed, and releases the transaction read lock.
+func (tx *transaction) close() {
+	tx.closed = true
+
+	// The underlying transaction no longer refers to the database once it
+	// has been committed or rolled back.
+	if tx.boltTx.DB() != nil {
+		_ = tx.boltTx.Rollback()
+	}
+
+	// Release the database read lock.
+	tx.db.closeLock.RUnlock()
+}
+
+// Commit commits all changes that have been made to the root metadata bucket
+// and all of its sub-buckets along with the stored and pruned blocks to the
+// database file.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) Commit() error {
+	// Prevent commits on managed transactions.
+	if tx.managed {
+		tx.close()
+		panic("managed transaction commit not allowed")
+	}
+
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Regardless of whether the commit succeeds, the transaction is closed
+	// on return.
+	defer tx.close()
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "Commit requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	if err := tx.boltTx.Commit(); err != nil {
+		return convertErr("failed to commit transaction", err)
+	}
+	return nil
+}
+
+// Rollback undoes all changes that have been made to the root bucket and all of
+// its sub-buckets.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) Rollback() error {
+	// Prevent rollbacks on managed transactions.
+	if tx.managed {
+		tx.close()
+		panic("managed transaction rollback not allowed")
+	}
+
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	tx.close()
+	return nil
+}
+
+// db represents a collection of namespaces which are persisted and implements
+// the database.DB interface.  All database access is performed through
+// transactions which are obtained through the specific Namespace.
+type db struct {
+	closeLock sync.RWMutex // Make database close block while txns active.
+	closed    bool         // Is the database closed?
+	boltDB    *bolt.DB     // Underlying bbolt database.
+}
+
+// Enforce db implements the database.DB interface.
+var _ database.DB = (*db)(nil)
+
+// Type returns the database driver type the current database instance was
+// created with.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) Type() string {
+	return dbType
+}
+
+// begin is the implementation function for the Begin database method.  See its
+// documentation for more details.
+//
+// This function is only separate because it returns the internal transaction
+// which is used by the managed transaction code while the database method
+// returns the interface.
+func (db *db) begin(writable bool) (*transaction, error) {
+	// Whenever a new transaction is started, grab a read lock against the
+	// database to ensure Close will wait for the transaction to finish.
+	// This lock will not be released until the transaction is closed (via
+	// Rollback or Commit).
+	db.closeLock.RLock()
+	if db.closed {
+		db.closeLock.RUnlock()
+		return nil, makeDbErr(database.ErrDbNotOpen, errDbNotOpenStr,
+			nil)
+	}
+
+	// Start the underlying transaction.  Note that bbolt itself ensures
+	// only a single writable transaction is active at a time.
+	boltTx, err := db.boltDB.Begin(writable)
+	if err != nil {
+		db.closeLock.RUnlock()
+		return nil, convertErr("failed to begin transaction", err)
+	}
+
+	// The top-level buckets are always created when the database is opened.
+	tx := &transaction{
+		writable:       writable,
+		db:             db,
+		boltTx:         boltTx,
+		blockIdxBucket: boltTx.Bucket(blockIdxBucketName),
+		blocksBucket:   boltTx.Bucket(blocksBucketName),
+		stateBucket:    boltTx.Bucket(stateBucketName),
+	}
+	tx.metaBucket = &bucket{tx: tx, boltBucket: boltTx.Bucket(metadataBucketName)}
+	return tx, nil
+}
+
+// Begin starts a transaction which is either read-only or read-write depending
+// on the specified flag.  Multiple read-only transactions can be started
+// simultaneously while only a single read-write transaction can be started at a
+// time.  The call will block when starting a read-write transaction when one is
+// already open.
+//
+// NOTE: The transaction must be closed by calling Rollback or Commit on it when
+// it is no longer needed.  Failure to do so will prevent the database from
+// closing and growing the database file.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) Begin(writable bool) (database.Tx, error) {
+	return db.begin(writable)
+}
+
+// rollbackOnPanic rolls the passed transaction back if the code in the calling
+// function panics.  This is needed since the mutex on a transaction must be
+// released and a panic in called code would prevent that from happening.
+//
+// NOTE: This can only be handled manually for managed transactions since they
+// control the life-cycle of the transaction.  As the documentation on Begin
+// calls out, callers opting to use manual transactions will have to ensure the
+// transaction is rolled back on panic if it desires that functionality as well
+// or the database will fail to close since the read-lock will never be
+// released.
+func rollbackOnPanic(tx *transaction) {
+	if err := recover(); err != nil {
+		tx.managed = false
+		_ = tx.Rollback()
+		panic(err)
+	}
+}
+
+// View invokes the passed function in the context of a managed read-only
+// transaction with the root bucket for the namespace.  Any errors returned from
+// the user-supplied function are returned from this function.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) View(fn func(database.Tx) error) error {
+	// Start a read-only transaction.
+	tx, err := db.begin(false)
+	if err != nil {
+		return err
+	}
+
+	// Since the user-provided function might panic, ensure the transaction
+	// releases all mutexes and resources.  There is no guarantee the caller
+	// won't use recover and keep going.  Thus, the database must still be
+	// in a usable state on panics due to caller issues.
+	defer rollbackOnPanic(tx)
+
+	tx.managed = true
+	err = fn(tx)
+	tx.managed = false
+	if err != nil {
+		// The error is ignored here because nothing was written yet
+		// and regardless of a rollback failure, the tx is closed now
+		// anyways.
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Rollback()
+}
+
+// Update invokes the passed function in the context of a managed read-write
+// transaction with the root bucket for the namespace.  Any errors returned from
+// the user-supplied function will cause the transaction to be rolled back and
+// are returned from this function.  Otherwise, the transaction is committed
+// when the user-supplied function returns a nil error.
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) Update(fn func(database.Tx) error) error {
+	// Start a read-write transaction.
+	tx, err := db.begin(true)
+	if err != nil {
+		return err
+	}
+
+	// Since the user-provided function might panic, ensure the transaction
+	// releases all mutexes and resources.  There is no guarantee the caller
+	// won't use recover and keep going.  Thus, the database must still be
+	// in a usable state on panics due to caller issues.
+	defer rollbackOnPanic(tx)
+
+	tx.managed = true
+	err = fn(tx)
+	tx.managed = false
+	if err != nil {
+		// The error is ignored here because nothing was written yet
+		// and regardless of a rollback failure, the tx is closed now
+		// anyways.
+		_ = tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Close cleanly shuts down the database and syncs all data.  It will block
+// until all database transactions have been finalized (rolled back or
+// committed).
+//
+// This function is part of the database.DB interface implementation.
+func (db *db) Close() error {
+	// Since all transactions have a read lock on this mutex, this will
+	// cause Close to wait for all readers to complete.
+	db.closeLock.Lock()
+	defer db.closeLock.Unlock()
+
+	if db.closed {
+		return makeDbErr(database.ErrDbNotOpen, errDbNotOpenStr, nil)
+	}
+	db.closed = true
+
+	if err := db.boltDB.Close(); err != nil {
+		return convertErr("failed to close database", err)
+	}
+	return nil
+}
+
+// fileExists reports whether the named file or directory exists.
+func fileExists(name string) bool {
+	if _, err := os.Stat(name); err != nil {
+		if os.IsNotExist(err) {
+			return false
+		}
+	}
+	return true
+}
+
+// initDB creates the top-level buckets used by the package when they do not
+// already exist and ensures the database is for the provided block network.
+func initDB(boltDB *bolt.DB, network wire.CurrencyNet) error {
+	return boltDB.Update(func(boltTx *bolt.Tx) error {
+		bucketNames := [][]byte{metadataBucketName, blockIdxBucketName,
+			blocksBucketName, stateBucketName}
+		for _, bucketName := range bucketNames {
+			_, err := boltTx.CreateBucketIfNotExists(bucketName)
+			if err != nil {
+				str := fmt.Sprintf("failed to create bucket %q",
+					bucketName)
+				return convertErr(str, err)
+			}
+		}
+
+		// Store the block network when the database is new and ensure it
+		// matches otherwise.
+		stateBucket := boltTx.Bucket(stateBucketName)
+		serializedNet := stateBucket.Get(networkKeyName)
+		if serializedNet == nil {
+			var buf [4]byte
+			byteOrder.PutUint32(buf[:], uint32(network))
+			if err := stateBucket.Put(networkKeyName, buf[:]); err != nil {
+				return convertErr("failed to store block network", err)
+			}
+			return nil
+		}
+		if len(serializedNet) != 4 {
+			str := "stored block network is corrupt"
+			return makeDbErr(database.ErrCorruption, str, nil)
+		}
+		if dbNet := wire.CurrencyNet(byteOrder.Uint32(serializedNet)); dbNet != network {
+			str := fmt.Sprintf("database is for block network %v "+
+				"instead of %v", dbNet, network)
+			return makeDbErr(database.ErrDriverSpecific, str, nil)
+		}
+		return nil
+	})
+}
+
+// openDB opens the database at the provided path.  database.ErrDbDoesNotExist
+// is returned if the database doesn't exist and the create flag is not set
+// while database.ErrDbExists is returned if the database already exists and
+// the create flag is set.
+func openDB(dbPath string, network wire.CurrencyNet, create bool) (database.DB, error) {
+	dbFilePath := filepath.Join(dbPath, dbFileName)
+	dbExists := fileExists(dbFilePath)
+	if !create && !dbExists {
+		str := fmt.Sprintf("database %q does not exist", dbFilePath)
+		return nil, makeDbErr(database.ErrDbDoesNotExist, str, nil)
+	}
+	if create && dbExists {
+		str := fmt.Sprintf("database %q already exists", dbFilePath)
+		return nil, makeDbErr(database.ErrDbExists, str, nil)
+	}
+
+	// Ensure the full path to the database exists.
+	if !dbExists {
+		// The error can be ignored here since the call to bolt.Open
+		// will fail if the directory couldn't be created.
+		_ = os.MkdirAll(dbPath, 0700)
+	}
+
+	opts := bolt.Options{
+		Timeout:         openTimeout,
+		InitialMmapSize: initialMmapSize,
+	}
+	boltDB, err := bolt.Open(dbFilePath, 0600, &opts)
+	if err != nil {
+		str := fmt.Sprintf("failed to open database %q", dbFilePath)
+		return nil, convertErr(str, err)
+	}
+	if err := initDB(boltDB, network); err != nil {
+		_ = boltDB.Close()
+		return nil, err
+	}
+
+	return &db{boltDB: boltDB}, nil
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package bboltdb implements a driver for the database package that uses a single
+bbolt file for both the backing metadata and block storage.
+
+This driver is an alternative to the ffldb driver which keeps all data in one
+embedded key/value store with copy-on-write B+trees.  Every transaction that is
+committed is durably written to disk, so the database never needs to be
+reconciled after an unclean shutdown, at the cost of more expensive commits and
+a database file that does not shrink when blocks are pruned.
+
+Usage
+
+This package is a driver to the database package and provides the database type
+of "bboltdb".  The parameters the Open and Create functions take are the
+database path as a string and the block network:
+
+	db, err := database.Open("bboltdb", "path/to/database", wire.MainNet)
+	if err != nil {
+		// Handle error
+	}
+
+	db, err := database.Create("bboltdb", "path/to/database", wire.MainNet)
+	if err != nil {
+		// Handle error
+	}
+*/
+package bboltdb
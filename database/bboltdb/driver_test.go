@@ -0,0 +1,781 @@
+// Copyright (c) 2015-2016 The btcsuite developers
+// Copyright (c) 2016-2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package bboltdb_test
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/chaincfg/v3"
+	"github.com/decred/dcrd/database/v2"
+	_ "github.com/decred/dcrd/database/v2/bboltdb"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/wire"
+	bolt "go.etcd.io/bbolt"
+)
+
+// dbType is the database type name for this driver.
+const dbType = "bboltdb"
+
+var (
+	// blockDataNet is the expected network in the test block data.
+	blockDataNet = wire.SimNet
+
+	// blockDataFile is the path to a file containing the first 168 blocks
+	// of the simulation network.
+	blockDataFile = filepath.Join("..", "testdata", "blocks0to168.bz2")
+)
+
+// loadBlocks loads the blocks contained in the testdata directory and returns
+// a slice of them.
+func loadBlocks(t *testing.T, dataFile string, network wire.CurrencyNet) ([]*dcrutil.Block, error) {
+	// Open the file that contains the blocks for reading.
+	fi, err := os.Open(dataFile)
+	if err != nil {
+		t.Errorf("failed to open file %v, err %v", dataFile, err)
+		return nil, err
+	}
+	defer func() {
+		if err := fi.Close(); err != nil {
+			t.Errorf("failed to close file %v %v", dataFile,
+				err)
+		}
+	}()
+
+	bcStream := bzip2.NewReader(fi)
+
+	// Create a buffer of the read file.
+	bcBuf := new(bytes.Buffer)
+	bcBuf.ReadFrom(bcStream)
+
+	// Create decoder from the buffer and a map to store the data.
+	bcDecoder := gob.NewDecoder(bcBuf)
+	blockChain := make(map[int64][]byte)
+
+	// Decode the blockchain into the map.
+	if err := bcDecoder.Decode(&blockChain); err != nil {
+		t.Errorf("error decoding test blockchain: %v", err.Error())
+	}
+
+	// Fetch blocks 1 to 168 and perform various tests.
+	blocks := make([]*dcrutil.Block, 169)
+	for i := 0; i <= 168; i++ {
+		bl, err := dcrutil.NewBlockFromBytes(blockChain[int64(i)])
+		if err != nil {
+			t.Errorf("NewBlockFromBytes error: %v", err.Error())
+		}
+
+		blocks[i] = bl
+	}
+
+	return blocks, nil
+}
+
+// checkDbError ensures the passed error is a database.Error with an error code
+// that matches the passed error code.
+func checkDbError(t *testing.T, testName string, gotErr error, wantErrCode database.ErrorCode) bool {
+	var dbErr database.Error
+	if !errors.As(gotErr, &dbErr) {
+		t.Errorf("%s: unexpected error type - got %T, want %T",
+			testName, gotErr, database.Error{})
+		return false
+	}
+	if dbErr.ErrorCode != wantErrCode {
+		t.Errorf("%s: unexpected error code - got %s (%s), want %s",
+			testName, dbErr.ErrorCode, dbErr.Description,
+			wantErrCode)
+		return false
+	}
+
+	return true
+}
+
+// TestCreateOpenFail ensures that errors related to creating and opening a
+// database are handled properly.
+func TestCreateOpenFail(t *testing.T) {
+	t.Parallel()
+
+	// Ensure that attempting to open a database that doesn't exist returns
+	// the expected error.
+	wantErrCode := database.ErrDbDoesNotExist
+	_, err := database.Open(dbType, "noexist", blockDataNet)
+	if !checkDbError(t, "Open", err, wantErrCode) {
+		return
+	}
+
+	// Ensure that attempting to open a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr := fmt.Errorf("invalid arguments to %s.Open -- expected "+
+		"database path and block network", dbType)
+	_, err = database.Open(dbType, 1, 2, 3)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with an invalid type for
+	// the first parameter returns the expected error.
+	wantErr = fmt.Errorf("first argument to %s.Open is invalid -- "+
+		"expected database path string", dbType)
+	_, err = database.Open(dbType, 1, blockDataNet)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to open a database with an invalid type for
+	// the second parameter returns the expected error.
+	wantErr = fmt.Errorf("second argument to %s.Open is invalid -- "+
+		"expected block network", dbType)
+	_, err = database.Open(dbType, "noexist", "invalid")
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Open: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with the wrong number of
+	// parameters returns the expected error.
+	wantErr = fmt.Errorf("invalid arguments to %s.Create -- expected "+
+		"database path and block network", dbType)
+	_, err = database.Create(dbType, 1, 2, 3)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with an invalid type for
+	// the first parameter returns the expected error.
+	wantErr = fmt.Errorf("first argument to %s.Create is invalid -- "+
+		"expected database path string", dbType)
+	_, err = database.Create(dbType, 1, blockDataNet)
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure that attempting to create a database with an invalid type for
+	// the second parameter returns the expected error.
+	wantErr = fmt.Errorf("second argument to %s.Create is invalid -- "+
+		"expected block network", dbType)
+	_, err = database.Create(dbType, "noexist", "invalid")
+	if err.Error() != wantErr.Error() {
+		t.Errorf("Create: did not receive expected error - got %v, "+
+			"want %v", err, wantErr)
+		return
+	}
+
+	// Ensure operations against a closed database return the expected
+	// error.
+	dbPath := filepath.Join(os.TempDir(), "bboltdb-createfail")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Create: unexpected error: %v", err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	db.Close()
+
+	// Ensure that attempting to create a database that already exists
+	// returns the expected error.
+	wantErrCode = database.ErrDbExists
+	_, err = database.Create(dbType, dbPath, blockDataNet)
+	if !checkDbError(t, "Create existing", err, wantErrCode) {
+		return
+	}
+
+	// Ensure that attempting to open a database for a different network
+	// returns the expected error.
+	wantErrCode = database.ErrDriverSpecific
+	_, err = database.Open(dbType, dbPath, wire.MainNet)
+	if !checkDbError(t, "Open wrong network", err, wantErrCode) {
+		return
+	}
+
+	wantErrCode = database.ErrDbNotOpen
+	err = db.View(func(tx database.Tx) error {
+		return nil
+	})
+	if !checkDbError(t, "View", err, wantErrCode) {
+		return
+	}
+
+	wantErrCode = database.ErrDbNotOpen
+	err = db.Update(func(tx database.Tx) error {
+		return nil
+	})
+	if !checkDbError(t, "Update", err, wantErrCode) {
+		return
+	}
+
+	wantErrCode = database.ErrDbNotOpen
+	_, err = db.Begin(false)
+	if !checkDbError(t, "Begin(false)", err, wantErrCode) {
+		return
+	}
+
+	wantErrCode = database.ErrDbNotOpen
+	_, err = db.Begin(true)
+	if !checkDbError(t, "Begin(true)", err, wantErrCode) {
+		return
+	}
+
+	wantErrCode = database.ErrDbNotOpen
+	err = db.Close()
+	if !checkDbError(t, "Close", err, wantErrCode) {
+		return
+	}
+}
+
+// TestPersistence ensures that values stored are still valid after closing and
+// reopening the database.
+func TestPersistence(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "bboltdb-persistencetest")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	// Create a bucket, put some values into it, and store a block so they
+	// can be tested for existence on re-open.
+	bucket1Key := []byte("bucket1")
+	storeValues := map[string]string{
+		"b1key1": "foo1",
+		"b1key2": "foo2",
+		"b1key3": "foo3",
+	}
+	mainNetParams := chaincfg.MainNetParams()
+	genesisBlock := dcrutil.NewBlock(mainNetParams.GenesisBlock)
+	genesisHash := &mainNetParams.GenesisHash
+	err = db.Update(func(tx database.Tx) error {
+		metadataBucket := tx.Metadata()
+		if metadataBucket == nil {
+			return fmt.Errorf("Metadata: unexpected nil bucket")
+		}
+
+		bucket1, err := metadataBucket.CreateBucket(bucket1Key)
+		if err != nil {
+			return fmt.Errorf("CreateBucket: unexpected error: %w",
+				err)
+		}
+
+		for k, v := range storeValues {
+			err := bucket1.Put([]byte(k), []byte(v))
+			if err != nil {
+				return fmt.Errorf("Put: unexpected error: %w",
+					err)
+			}
+		}
+
+		if err := tx.StoreBlock(genesisBlock); err != nil {
+			return fmt.Errorf("StoreBlock: unexpected error: %w",
+				err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Update: unexpected error: %v", err)
+		return
+	}
+
+	// Close and reopen the database to ensure the values persist.
+	db.Close()
+	db, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("failed to open test database (%s) %v", dbType, err)
+		return
+	}
+	defer db.Close()
+
+	// Ensure the values previously stored in the 3rd namespace still exist
+	// and are correct.
+	err = db.View(func(tx database.Tx) error {
+		metadataBucket := tx.Metadata()
+		if metadataBucket == nil {
+			return fmt.Errorf("Metadata: unexpected nil bucket")
+		}
+
+		bucket1 := metadataBucket.Bucket(bucket1Key)
+		if bucket1 == nil {
+			return fmt.Errorf("bucket1: unexpected nil bucket")
+		}
+
+		for k, v := range storeValues {
+			gotVal := bucket1.Get([]byte(k))
+			if !reflect.DeepEqual(gotVal, []byte(v)) {
+				return fmt.Errorf("Get: key '%s' does not "+
+					"match expected value - got %s, want %s",
+					k, gotVal, v)
+			}
+		}
+
+		genesisBlockBytes, _ := genesisBlock.Bytes()
+		gotBytes, err := tx.FetchBlock(genesisHash)
+		if err != nil {
+			return fmt.Errorf("FetchBlock: unexpected error: %w",
+				err)
+		}
+		if !reflect.DeepEqual(gotBytes, genesisBlockBytes) {
+			return fmt.Errorf("FetchBlock: stored block mismatch")
+		}
+
+		return nil
+	})
+	if err != nil {
+		t.Errorf("View: unexpected error: %v", err)
+		return
+	}
+}
+
+// TestPruneBlocks ensures that pruning blocks removes the oldest blocks while
+// respecting the target size and prune height and that the resulting state
+// persists across database restarts.
+func TestPruneBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "bboltdb-prunetest")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer func() { db.Close() }()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to load test blocks: %v", err)
+	}
+
+	// Store all of the test blocks while keeping track of their total size.
+	const pruneHeight = 100
+	var totalSize uint64
+	for i, block := range blocks {
+		err := db.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock #%d: unexpected error: %v", i, err)
+		}
+		blockBytes, _ := block.Bytes()
+		totalSize += uint64(len(blockBytes))
+	}
+
+	// Ensure nothing is pruned when the target size is the size of all
+	// stored blocks.
+	err = db.Update(func(tx database.Tx) error {
+		hashes, err := tx.(database.BlockPruner).PruneBlocks(totalSize, pruneHeight)
+		if err != nil {
+			return err
+		}
+		if len(hashes) != 0 {
+			return fmt.Errorf("unexpected pruned blocks: %d",
+				len(hashes))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("PruneBlocks: unexpected error: %v", err)
+	}
+
+	// Ensure only the oldest block is pruned when the target size is one
+	// byte less than the size of all stored blocks.
+	var pruned []chainhash.Hash
+	err = db.Update(func(tx database.Tx) error {
+		var err error
+		pruned, err = tx.(database.BlockPruner).PruneBlocks(totalSize-1, pruneHeight)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("PruneBlocks: unexpected error: %v", err)
+	}
+	if len(pruned) != 1 || pruned[0] != *blocks[0].Hash() {
+		t.Fatalf("PruneBlocks: unexpected pruned blocks %v", pruned)
+	}
+
+	// Prune as much as possible.  Only blocks prior to the prune height are
+	// eligible.
+	err = db.Update(func(tx database.Tx) error {
+		hashes, err := tx.(database.BlockPruner).PruneBlocks(0, pruneHeight)
+		pruned = append(pruned, hashes...)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("PruneBlocks: unexpected error: %v", err)
+	}
+	if len(pruned) != pruneHeight {
+		t.Fatalf("PruneBlocks: unexpected number of pruned blocks - "+
+			"got %d, want %d", len(pruned), pruneHeight)
+	}
+
+	// checkBlocks ensures only the blocks prior to the prune height are
+	// missing.
+	checkBlocks := func(db database.DB) {
+		t.Helper()
+		err := db.View(func(tx database.Tx) error {
+			for _, block := range blocks {
+				hash := block.Hash()
+				height := block.MsgBlock().Header.Height
+				_, err := tx.FetchBlock(hash)
+				if height < pruneHeight {
+					if !database.IsError(err, database.ErrBlockNotFound) {
+						return fmt.Errorf("FetchBlock(%d): "+
+							"unexpected error: %v", height,
+							err)
+					}
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("FetchBlock(%d): "+
+						"unexpected error: %v", height, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkBlocks(db)
+
+	// Close and reopen the database to ensure the pruned state persists.
+	db.Close()
+	db, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("failed to open test database (%s) %v", dbType, err)
+	}
+	checkBlocks(db)
+}
+
+// TestBackup ensures backing up a database produces a consistent copy of it as
+// of when the transaction was started, even when blocks are stored and pruned
+// in the meantime.
+func TestBackup(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "bboltdb-backuptest")
+	backupPath := filepath.Join(os.TempDir(), "bboltdb-backuptest-backup")
+	_ = os.RemoveAll(dbPath)
+	_ = os.RemoveAll(backupPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer os.RemoveAll(backupPath)
+	defer func() { db.Close() }()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to load test blocks: %v", err)
+	}
+
+	// Store the first half of the test blocks along with some metadata and
+	// then prune the oldest ones.
+	const pruneHeight = 50
+	numBackedUp := len(blocks) / 2
+	key, value := []byte("backupkey"), []byte("backupvalue")
+	for i, block := range blocks[:numBackedUp] {
+		err := db.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock #%d: unexpected error: %v", i, err)
+		}
+	}
+	var pruned []chainhash.Hash
+	err = db.Update(func(tx database.Tx) error {
+		if err := tx.Metadata().Put(key, value); err != nil {
+			return err
+		}
+		var err error
+		pruned, err = tx.(database.BlockPruner).PruneBlocks(0, pruneHeight)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+
+	// Start the transaction the backup is made from and then store the
+	// remaining blocks and modify the metadata so they are not part of the
+	// backup.
+	tx, err := db.Begin(false)
+	if err != nil {
+		t.Fatalf("Begin: unexpected error: %v", err)
+	}
+	for i, block := range blocks[numBackedUp:] {
+		err := db.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock #%d: unexpected error: %v",
+				numBackedUp+i, err)
+		}
+	}
+	err = db.Update(func(tx database.Tx) error {
+		return tx.Metadata().Delete(key)
+	})
+	if err != nil {
+		t.Fatalf("Update: unexpected error: %v", err)
+	}
+	if len(pruned) == 0 {
+		t.Fatal("PruneBlocks: no blocks were pruned")
+	}
+
+	if err := tx.(database.Backupper).Backup(backupPath); err != nil {
+		t.Fatalf("Backup: unexpected error: %v", err)
+	}
+
+	// Ensure backing up to a path that already has a database fails.
+	err = tx.(database.Backupper).Backup(backupPath)
+	if !database.IsError(err, database.ErrDbExists) {
+		t.Fatalf("Backup: unexpected error -- got %v, want %v", err,
+			database.ErrDbExists)
+	}
+
+	// Ensure backing up from a closed transaction fails.
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: unexpected error: %v", err)
+	}
+	err = tx.(database.Backupper).Backup(filepath.Join(backupPath, "closed"))
+	if !database.IsError(err, database.ErrTxClosed) {
+		t.Fatalf("Backup: unexpected error -- got %v, want %v", err,
+			database.ErrTxClosed)
+	}
+
+	// Ensure the backup only contains the metadata and blocks as of when
+	// the transaction was started.
+	backupDB, err := database.Open(dbType, backupPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("failed to open backup database (%s) %v", dbType, err)
+	}
+	defer backupDB.Close()
+	prunedSet := make(map[chainhash.Hash]struct{}, len(pruned))
+	for _, hash := range pruned {
+		prunedSet[hash] = struct{}{}
+	}
+	err = backupDB.View(func(tx database.Tx) error {
+		if got := tx.Metadata().Get(key); !reflect.DeepEqual(got, value) {
+			return fmt.Errorf("Get: unexpected value -- got %s, "+
+				"want %s", got, value)
+		}
+		for i, block := range blocks {
+			_, isPruned := prunedSet[*block.Hash()]
+			gotBytes, err := tx.FetchBlock(block.Hash())
+			if i >= numBackedUp || isPruned {
+				if !database.IsError(err, database.ErrBlockNotFound) {
+					return fmt.Errorf("FetchBlock #%d: "+
+						"unexpected error: %v", i, err)
+				}
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("FetchBlock #%d: unexpected "+
+					"error: %v", i, err)
+			}
+			wantBytes, _ := block.Bytes()
+			if !reflect.DeepEqual(gotBytes, wantBytes) {
+				return fmt.Errorf("FetchBlock #%d: stored block "+
+					"mismatch", i)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure blocks can be stored in the backup.
+	err = backupDB.Update(func(tx database.Tx) error {
+		return tx.StoreBlock(blocks[numBackedUp])
+	})
+	if err != nil {
+		t.Fatalf("StoreBlock: unexpected error: %v", err)
+	}
+}
+
+// TestVerifyBlocks ensures that verifying the stored blocks detects invalid and
+// missing block index entries and that repairing removes the invalid blocks
+// and recovers valid blocks that are not in the block index.
+func TestVerifyBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "bboltdb-verifytest")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer func() { db.Close() }()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to load test blocks: %v", err)
+	}
+	for i, block := range blocks {
+		err := db.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Fatalf("StoreBlock #%d: unexpected error: %v", i, err)
+		}
+	}
+
+	// verify runs a verification of the stored blocks in a transaction that
+	// is committed when repairing.
+	verify := func(repair bool) *database.VerifyResult {
+		t.Helper()
+		var result *database.VerifyResult
+		fn := func(tx database.Tx) error {
+			var err error
+			result, err = tx.(database.BlockVerifier).VerifyBlocks(repair)
+			return err
+		}
+		if repair {
+			err = db.Update(fn)
+		} else {
+			err = db.View(fn)
+		}
+		if err != nil {
+			t.Fatalf("VerifyBlocks: unexpected error: %v", err)
+		}
+		return result
+	}
+
+	// Ensure there are no issues with the intact database.
+	result := verify(false)
+	if result.NumBlocks != uint64(len(blocks)) || len(result.Issues) != 0 {
+		t.Fatalf("VerifyBlocks: unexpected result -- got %d blocks and "+
+			"%d issues, want %d blocks and no issues", result.NumBlocks,
+			len(result.Issues), len(blocks))
+	}
+
+	// Ensure repairing with a read-only transaction fails.
+	err = db.View(func(tx database.Tx) error {
+		_, err := tx.(database.BlockVerifier).VerifyBlocks(true)
+		return err
+	})
+	if !database.IsError(err, database.ErrTxNotWritable) {
+		t.Fatalf("VerifyBlocks: unexpected error -- got %v, want %v", err,
+			database.ErrTxNotWritable)
+	}
+
+	// Remove the block index entry for one block and corrupt the header of
+	// another directly in the underlying database.
+	missing, corrupted := blocks[1].Hash(), blocks[2].Hash()
+	db.Close()
+	boltDB, err := bolt.Open(filepath.Join(dbPath, "data.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("failed to open bbolt database: %v", err)
+	}
+	err = boltDB.Update(func(tx *bolt.Tx) error {
+		blockIdx := tx.Bucket([]byte("blockidx"))
+		if err := blockIdx.Delete(missing[:]); err != nil {
+			return err
+		}
+		seqKey := blockIdx.Get(corrupted[:])
+		blocksBucket := tx.Bucket([]byte("blocks"))
+		entry := append([]byte(nil), blocksBucket.Get(seqKey)...)
+		entry[chainhash.HashSize+4] ^= 0xff
+		return blocksBucket.Put(seqKey, entry)
+	})
+	if err != nil {
+		t.Fatalf("failed to modify bbolt database: %v", err)
+	}
+	if err := boltDB.Close(); err != nil {
+		t.Fatalf("failed to close bbolt database: %v", err)
+	}
+	db, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to open test database (%s) %v", dbType, err)
+	}
+
+	// Ensure the invalid block index entry along with both of the stored
+	// blocks that are no longer referenced by a valid entry are detected.
+	result = verify(false)
+	if len(result.Issues) != 3 {
+		t.Fatalf("VerifyBlocks: unexpected issues %+v", result.Issues)
+	}
+	for _, issue := range result.Issues {
+		if issue.Hash != *missing && issue.Hash != *corrupted {
+			t.Fatalf("VerifyBlocks: unexpected issue for block %s",
+				issue.Hash)
+		}
+		if issue.Repaired {
+			t.Fatalf("VerifyBlocks: issue for block %s marked repaired",
+				issue.Hash)
+		}
+	}
+
+	// Ensure repairing recovers the block that was missing from the index
+	// and removes the corrupted block.
+	result = verify(true)
+	if len(result.Issues) != 3 || result.NumRecovered != 1 {
+		t.Fatalf("VerifyBlocks: unexpected repair result %+v", result)
+	}
+	for _, issue := range result.Issues {
+		if !issue.Repaired {
+			t.Fatalf("VerifyBlocks: issue for block %s not repaired",
+				issue.Hash)
+		}
+	}
+	err = db.View(func(tx database.Tx) error {
+		if _, err := tx.FetchBlock(missing); err != nil {
+			return fmt.Errorf("FetchBlock: unexpected error: %v", err)
+		}
+		exists, err := tx.HasBlock(corrupted)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("HasBlock: corrupted block %s still "+
+				"exists", corrupted)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = verify(false)
+	if result.NumBlocks != uint64(len(blocks)-1) || len(result.Issues) != 0 {
+		t.Fatalf("VerifyBlocks: unexpected result -- got %d blocks and "+
+			"%d issues, want %d blocks and no issues", result.NumBlocks,
+			len(result.Issues), len(blocks)-1)
+	}
+
+	// Ensure the corrupted block can be stored again.
+	err = db.Update(func(tx database.Tx) error {
+		return tx.StoreBlock(blocks[2])
+	})
+	if err != nil {
+		t.Fatalf("StoreBlock: unexpected error: %v", err)
+	}
+}
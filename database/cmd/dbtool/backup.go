@@ -0,0 +1,70 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/decred/dcrd/database/v2"
+)
+
+// backupCmd defines the configuration options for the backup command.
+type backupCmd struct{}
+
+var (
+	// backupCfg defines the configuration options for the command.
+	backupCfg = backupCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *backupCmd) Execute(args []string) error {
+	// Setup the global config options and ensure they are valid.
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+
+	if len(args) < 1 {
+		return errors.New("required destination path parameter not " +
+			"specified")
+	}
+	destPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Open the existing block database.  Unlike the other commands, a new
+	// database is not created when it does not exist since there would be
+	// nothing to back up.
+	dbPath := filepath.Join(cfg.DataDir, blockDbNamePrefix+"_"+cfg.DbType)
+	log.Infof("Loading block database from '%s'", dbPath)
+	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx database.Tx) error {
+		log.Infof("Backing up block database to '%s'", destPath)
+		backupper, ok := tx.(database.Backupper)
+		if !ok {
+			return fmt.Errorf("database type '%s' does not support "+
+				"backups", cfg.DbType)
+		}
+		startTime := time.Now()
+		if err := backupper.Backup(destPath); err != nil {
+			return err
+		}
+		log.Infof("Backed up block database in %v", time.Since(startTime))
+		return nil
+	})
+}
+
+// Usage overrides the usage display for the command.
+func (cmd *backupCmd) Usage() string {
+	return "<destination-path>"
+}
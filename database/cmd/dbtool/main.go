@@ -92,6 +92,28 @@ func realMain() error {
 	parser.AddCommand("fetchblockregion",
 		"Fetch the specified block region from the database", "",
 		&blockRegionCfg)
+	parser.AddCommand("backup",
+		"Write a consistent copy of the block database to a path",
+		"Write a consistent copy of the block database to the "+
+			"provided path.  The database must not be in use by a "+
+			"running dcrd instance.  Use the backupdb RPC to back up "+
+			"the database of a running instance instead.", &backupCfg)
+	parser.AddCommand("restore",
+		"Restore the block database from a backup",
+		"Restore the block database from a backup made with either "+
+			"the backup command or the backupdb RPC.  The block "+
+			"database must not already exist.", &restoreCfg)
+	parser.AddCommand("verify",
+		"Verify the integrity of the stored blocks",
+		"Verify that every block in the block index can be loaded "+
+			"and is consistent with the index and write a JSON "+
+			"report of any issues found.  The --repair option "+
+			"removes invalid block index entries and adds back any "+
+			"valid stored blocks that are missing from the index.  "+
+			"The chain state, such as the spend journals and utxo "+
+			"set, is verified and repaired with the --verifydb and "+
+			"--repairdb options of dcrd.  The database must not be "+
+			"in use by a running dcrd instance.", &verifyCfg)
 
 	// Parse command line and invoke the Execute function for the specified
 	// command.
@@ -0,0 +1,74 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/decred/dcrd/database/v2"
+)
+
+// restoreCmd defines the configuration options for the restore command.
+type restoreCmd struct{}
+
+var (
+	// restoreCfg defines the configuration options for the command.
+	restoreCfg = restoreCmd{}
+)
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *restoreCmd) Execute(args []string) error {
+	// Setup the global config options and ensure they are valid.
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+
+	if len(args) < 1 {
+		return errors.New("required backup path parameter not specified")
+	}
+	backupPath, err := filepath.Abs(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Refuse to overwrite an existing block database.
+	dbPath := filepath.Join(cfg.DataDir, blockDbNamePrefix+"_"+cfg.DbType)
+	if fileExists(dbPath) {
+		return fmt.Errorf("block database '%s' already exists -- move "+
+			"or remove it before restoring a backup", dbPath)
+	}
+
+	// Open the backup which also ensures it is for the active network and
+	// reconciles it as needed.
+	log.Infof("Loading backup from '%s'", backupPath)
+	db, err := database.Open(cfg.DbType, backupPath, activeNetParams.Net)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.View(func(tx database.Tx) error {
+		log.Infof("Restoring block database to '%s'", dbPath)
+		backupper, ok := tx.(database.Backupper)
+		if !ok {
+			return fmt.Errorf("database type '%s' does not support "+
+				"backups", cfg.DbType)
+		}
+		startTime := time.Now()
+		if err := backupper.Backup(dbPath); err != nil {
+			return err
+		}
+		log.Infof("Restored block database in %v", time.Since(startTime))
+		return nil
+	})
+}
+
+// Usage overrides the usage display for the command.
+func (cmd *restoreCmd) Usage() string {
+	return "<backup-path>"
+}
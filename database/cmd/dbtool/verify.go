@@ -0,0 +1,139 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/decred/dcrd/database/v2"
+)
+
+// verifyCmd defines the configuration options for the verify command.
+type verifyCmd struct {
+	Repair bool   `long:"repair" description:"Repair the issues that are found"`
+	Report string `short:"r" long:"report" description:"File to write the JSON report to -- Use - for stdout"`
+}
+
+var (
+	// verifyCfg defines the configuration options for the command.
+	verifyCfg = verifyCmd{
+		Report: "dbverify.json",
+	}
+)
+
+// verifyIssue describes a problem found with a stored block in the report.
+type verifyIssue struct {
+	Hash        string `json:"hash"`
+	Description string `json:"description"`
+	Repaired    bool   `json:"repaired"`
+}
+
+// verifyReport is the machine-readable report written by the verify command.
+type verifyReport struct {
+	DbType       string        `json:"dbtype"`
+	Network      string        `json:"network"`
+	Repair       bool          `json:"repair"`
+	NumBlocks    uint64        `json:"numblocks"`
+	NumRecovered uint64        `json:"numrecovered"`
+	Issues       []verifyIssue `json:"issues"`
+}
+
+// writeReport writes the provided report as JSON to the configured report file
+// or stdout.
+func (cmd *verifyCmd) writeReport(report *verifyReport) error {
+	var w io.Writer = os.Stdout
+	if cmd.Report != "-" {
+		f, err := os.Create(cmd.Report)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// Execute is the main entry point for the command.  It's invoked by the parser.
+func (cmd *verifyCmd) Execute(args []string) error {
+	// Setup the global config options and ensure they are valid.
+	if err := setupGlobalConfig(); err != nil {
+		return err
+	}
+
+	// Open the existing block database.  A new database is not created when
+	// it does not exist since there would be nothing to verify.
+	dbPath := filepath.Join(cfg.DataDir, blockDbNamePrefix+"_"+cfg.DbType)
+	log.Infof("Loading block database from '%s'", dbPath)
+	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	log.Info("Verifying stored blocks")
+	startTime := time.Now()
+	var result *database.VerifyResult
+	verifyFn := func(tx database.Tx) error {
+		verifier, ok := tx.(database.BlockVerifier)
+		if !ok {
+			return fmt.Errorf("database type '%s' does not support "+
+				"verification", cfg.DbType)
+		}
+		var err error
+		result, err = verifier.VerifyBlocks(cmd.Repair)
+		return err
+	}
+	if cmd.Repair {
+		err = db.Update(verifyFn)
+	} else {
+		err = db.View(verifyFn)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("Verified %d blocks in %v", result.NumBlocks,
+		time.Since(startTime))
+
+	report := verifyReport{
+		DbType:       cfg.DbType,
+		Network:      activeNetParams.Name,
+		Repair:       cmd.Repair,
+		NumBlocks:    result.NumBlocks,
+		NumRecovered: result.NumRecovered,
+		Issues:       make([]verifyIssue, 0, len(result.Issues)),
+	}
+	var numUnrepaired int
+	for _, issue := range result.Issues {
+		log.Warnf("Block %s: %s", issue.Hash, issue.Description)
+		if !issue.Repaired {
+			numUnrepaired++
+		}
+		report.Issues = append(report.Issues, verifyIssue{
+			Hash:        issue.Hash.String(),
+			Description: issue.Description,
+			Repaired:    issue.Repaired,
+		})
+	}
+	if cmd.Repair {
+		log.Infof("Repaired %d issues and recovered %d blocks",
+			len(result.Issues), result.NumRecovered)
+	}
+	if err := cmd.writeReport(&report); err != nil {
+		return err
+	}
+
+	if numUnrepaired > 0 {
+		return fmt.Errorf("found %d issues -- use --repair to repair them",
+			numUnrepaired)
+	}
+	return nil
+}
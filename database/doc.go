@@ -13,6 +13,9 @@ The default backend, ffldb, has a strong focus on speed, efficiency, and
 robustness.  It makes use leveldb for the metadata, flat files for block
 storage, and strict checksums in key areas to ensure data integrity.
 
+An alternative backend, bboltdb, stores both the metadata and blocks in a single
+bbolt database file.
+
 A quick overview of the features database provides are as follows:
 
  - Key/value metadata store
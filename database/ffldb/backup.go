@@ -0,0 +1,211 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ffldb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/decred/dcrd/database/v2"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/filter"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// backupBatchSize is the maximum number of bytes of metadata that are
+	// accumulated in a batch before it is written to the backup metadata
+	// database.
+	backupBatchSize = 16 * 1024 * 1024
+)
+
+// backupMetadata writes all of the metadata in the transaction snapshot to a
+// newly created leveldb database at the provided path.  It returns the write
+// cursor position stored in the metadata along with the oldest block file
+// referenced by the block index.
+func (tx *transaction) backupMetadata(metadataDbPath string) (uint32, uint32, uint32, error) {
+	opts := opt.Options{
+		ErrorIfExist: true,
+		Strict:       opt.DefaultStrict,
+		Compression:  opt.NoCompression,
+		Filter:       filter.NewBloomFilter(10),
+	}
+	ldb, err := leveldb.OpenFile(metadataDbPath, &opts)
+	if err != nil {
+		return 0, 0, 0, convertErr(err.Error(), err)
+	}
+	defer ldb.Close()
+
+	// Copy every key in the snapshot while keeping track of the oldest
+	// block file referenced by the block index and the write cursor.
+	var writeRow []byte
+	var oldestFileNum uint32
+	var haveBlocks bool
+	writeLocKey := bucketizedKey(metadataBucketID, writeLocKeyName)
+	batch := new(leveldb.Batch)
+	var batchSize int
+	iter := tx.snapshot.NewIterator(&util.Range{})
+	defer iter.Release()
+	for ok := iter.First(); ok; ok = iter.Next() {
+		key, value := iter.Key(), iter.Value()
+		switch {
+		case bytes.Equal(key, writeLocKey):
+			writeRow = copySlice(value)
+
+		case bytes.HasPrefix(key, blockIdxBucketID[:]) &&
+			len(key) > len(blockIdxBucketID) && len(value) >= blockLocSize:
+
+			loc := deserializeBlockLoc(value)
+			if !haveBlocks || loc.blockFileNum < oldestFileNum {
+				oldestFileNum = loc.blockFileNum
+				haveBlocks = true
+			}
+		}
+
+		batch.Put(key, value)
+		batchSize += len(key) + len(value)
+		if batchSize >= backupBatchSize {
+			if err := ldb.Write(batch, nil); err != nil {
+				return 0, 0, 0, convertErr(err.Error(), err)
+			}
+			batch.Reset()
+			batchSize = 0
+		}
+	}
+	if err := iter.Error(); err != nil {
+		return 0, 0, 0, convertErr(err.Error(), err)
+	}
+	if err := ldb.Write(batch, nil); err != nil {
+		return 0, 0, 0, convertErr(err.Error(), err)
+	}
+
+	if len(writeRow) != 12 {
+		str := "write cursor does not exist"
+		return 0, 0, 0, makeDbErr(database.ErrCorruption, str, nil)
+	}
+	curFileNum, curOffset, err := deserializeWriteRow(writeRow)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if !haveBlocks {
+		oldestFileNum = curFileNum
+	}
+	return curFileNum, curOffset, oldestFileNum, nil
+}
+
+// backupBlockFile copies the provided flat block file to the backup database at
+// the provided path.  Only the first size bytes are copied unless it is
+// negative, in which case the entire file is copied.
+func (tx *transaction) backupBlockFile(destPath string, fileNum uint32, size int64) error {
+	srcPath := blockFilePath(tx.db.store.basePath, fileNum)
+	src, err := os.Open(srcPath)
+	if err != nil {
+		// Block files are only ever removed by pruning, which means the
+		// file was pruned after the transaction was started.
+		if os.IsNotExist(err) {
+			str := fmt.Sprintf("block file %d was pruned while the "+
+				"backup was in progress", fileNum)
+			return makeDbErr(database.ErrDriverSpecific, str, err)
+		}
+		str := fmt.Sprintf("failed to open file %q: %v", srcPath, err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	defer src.Close()
+
+	dstPath := blockFilePath(destPath, fileNum)
+	dst, err := os.OpenFile(dstPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+	if err != nil {
+		str := fmt.Sprintf("failed to create file %q: %v", dstPath, err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	if size < 0 {
+		_, err = io.Copy(dst, src)
+	} else {
+		_, err = io.CopyN(dst, src, size)
+	}
+	if err != nil {
+		dst.Close()
+		str := fmt.Sprintf("failed to copy block file %d: %v", fileNum, err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	if err := dst.Sync(); err != nil {
+		dst.Close()
+		str := fmt.Sprintf("failed to sync file %q: %v", dstPath, err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	if err := dst.Close(); err != nil {
+		str := fmt.Sprintf("failed to close file %q: %v", dstPath, err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	return nil
+}
+
+// Backup writes a consistent copy of the entire database as of when the
+// transaction was started to a new database at the provided path.
+//
+// The metadata is copied from the transaction snapshot and the flat block files
+// are copied up to the write cursor stored in it.  Blocks that are written after
+// the transaction was started are always appended beyond that point and block
+// files are never modified otherwise, so the copy is consistent even though the
+// files might be written concurrently.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrDbExists if a database already exists at the provided path
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.Backupper interface implementation.
+func (tx *transaction) Backup(destPath string) error {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	metadataDbPath := filepath.Join(destPath, metadataDbName)
+	if fileExists(metadataDbPath) {
+		str := fmt.Sprintf("database %q already exists", metadataDbPath)
+		return makeDbErr(database.ErrDbExists, str, nil)
+	}
+	if err := os.MkdirAll(destPath, 0700); err != nil {
+		str := fmt.Sprintf("failed to create directory %q: %v", destPath,
+			err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+
+	curFileNum, curOffset, oldestFileNum, err := tx.backupMetadata(metadataDbPath)
+	if err != nil {
+		_ = os.RemoveAll(metadataDbPath)
+		return err
+	}
+	log.Debugf("Backing up block files %d through %d to %q", oldestFileNum,
+		curFileNum, destPath)
+
+	// Copy all of the block files that have not been pruned.  All files
+	// prior to the current write file are complete, while the current one
+	// is only copied up to the write cursor.  The current write file does
+	// not exist yet when nothing has been written to it.
+	for fileNum := oldestFileNum; fileNum <= curFileNum; fileNum++ {
+		size := int64(-1)
+		if fileNum == curFileNum {
+			size = int64(curOffset)
+			if size == 0 {
+				break
+			}
+		}
+		err := tx.backupBlockFile(destPath, fileNum, size)
+		if err != nil {
+			_ = os.RemoveAll(metadataDbPath)
+			for i := oldestFileNum; i <= fileNum; i++ {
+				_ = os.Remove(blockFilePath(destPath, i))
+			}
+			return err
+		}
+	}
+
+	return nil
+}
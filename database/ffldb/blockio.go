@@ -14,6 +14,7 @@ import (
 	"fmt"
 	"hash/crc32"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
@@ -172,6 +173,11 @@ type blockStore struct {
 	// new blocks are written to.
 	writeCursor *writeCursor
 
+	// oldestFileNum is the number of the oldest block file that has not
+	// been pruned.  It is only modified during write transactions, so it
+	// is effectively protected by the database write lock.
+	oldestFileNum uint32
+
 	// These functions are set to openFile, openWriteFile, and deleteFile by
 	// default, but are exposed here to allow the whitebox tests to replace
 	// them when working with mock files.
@@ -311,6 +317,55 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 	return nil
 }
 
+// pruneFile closes the block file for the passed flat file number if it is
+// open and then removes it.  It must not be called for the current write file.
+//
+// NOTE: This function MUST only be called during a write transaction since
+// the caller is responsible for ensuring the block index no longer references
+// any blocks in the file.
+func (s *blockStore) pruneFile(fileNum uint32) error {
+	// Remove the file from the open files map and least recently used list
+	// under the appropriate locks and close it once any readers are done
+	// with it.
+	s.obfMutex.Lock()
+	if blockFile, ok := s.openBlockFiles[fileNum]; ok {
+		s.lruMutex.Lock()
+		if elem, ok := s.fileNumToLRUElem[fileNum]; ok {
+			s.openBlocksLRU.Remove(elem)
+			delete(s.fileNumToLRUElem, fileNum)
+		}
+		s.lruMutex.Unlock()
+		delete(s.openBlockFiles, fileNum)
+
+		blockFile.Lock()
+		_ = blockFile.file.Close()
+		blockFile.Unlock()
+	}
+	s.obfMutex.Unlock()
+
+	return s.deleteFileFunc(fileNum)
+}
+
+// fileSize returns the size of the block file for the passed flat file number.
+// The size of the current write file is taken from the write cursor since it
+// might not have been synced to disk yet.
+func (s *blockStore) fileSize(fileNum uint32) (uint64, error) {
+	wc := s.writeCursor
+	wc.RLock()
+	if fileNum == wc.curFileNum {
+		size := uint64(wc.curOffset)
+		wc.RUnlock()
+		return size, nil
+	}
+	wc.RUnlock()
+
+	st, err := os.Stat(blockFilePath(s.basePath, fileNum))
+	if err != nil {
+		return 0, makeDbErr(database.ErrDriverSpecific, err.Error(), err)
+	}
+	return uint64(st.Size()), nil
+}
+
 // blockFile attempts to return an existing file handle for the passed flat file
 // number if it is already open as well as marking it as most recently used.  It
 // will also open the file when it's not already open subject to the rules
@@ -716,15 +771,49 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 	}
 }
 
-// scanBlockFiles searches the database directory for all flat block files to
-// find the end of the most recent file.  This position is considered the
-// current write cursor which is also stored in the metadata.  Thus, it is used
-// to detect unexpected shutdowns in the middle of writes so the block files
-// can be reconciled.
-func scanBlockFiles(dbPath string) (int, uint32) {
+// scanOldestBlockFile searches the database directory for the flat block file
+// with the lowest file number.  This is typically the first file, however, it
+// will be a later file when older files have been pruned.  Zero is returned
+// when there are no block files.
+func scanOldestBlockFile(dbPath string) uint32 {
+	entries, err := ioutil.ReadDir(dbPath)
+	if err != nil {
+		return 0
+	}
+
+	var oldest uint32
+	var found bool
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		// Ignore any files that do not exactly match the naming scheme
+		// used for block files.
+		var fileNum uint32
+		name := entry.Name()
+		_, err := fmt.Sscanf(name, blockFilenameTemplate, &fileNum)
+		if err != nil || fmt.Sprintf(blockFilenameTemplate, fileNum) != name {
+			continue
+		}
+		if !found || fileNum < oldest {
+			oldest = fileNum
+			found = true
+		}
+	}
+
+	return oldest
+}
+
+// scanBlockFiles searches the database directory for all flat block files
+// starting with the provided oldest file number to find the end of the most
+// recent file.  This position is considered the current write cursor which is
+// also stored in the metadata.  Thus, it is used to detect unexpected
+// shutdowns in the middle of writes so the block files can be reconciled.
+func scanBlockFiles(dbPath string, oldestFileNum uint32) (int, uint32) {
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
+	for i := int(oldestFileNum); ; i++ {
 		filePath := blockFilePath(dbPath, uint32(i))
 		st, err := os.Stat(filePath)
 		if err != nil {
@@ -745,10 +834,12 @@ func scanBlockFiles(dbPath string) (int, uint32) {
 func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 	// Look for the end of the latest block to file to determine what the
 	// write cursor position is from the viewpoint of the block files on
-	// disk.
-	fileNum, fileOff := scanBlockFiles(basePath)
+	// disk.  Files prior to the oldest one might have been pruned, so
+	// start the scan from it.
+	oldestFileNum := scanOldestBlockFile(basePath)
+	fileNum, fileOff := scanBlockFiles(basePath, oldestFileNum)
 	if fileNum == -1 {
-		fileNum = 0
+		fileNum = int(oldestFileNum)
 		fileOff = 0
 	}
 
@@ -765,6 +856,7 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 			curFileNum: uint32(fileNum),
 			curOffset:  fileOff,
 		},
+		oldestFileNum: oldestFileNum,
 	}
 	store.openFileFunc = store.openFile
 	store.openWriteFileFunc = store.openWriteFile
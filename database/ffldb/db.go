@@ -967,6 +967,10 @@ type transaction struct {
 	pendingBlocks    map[chainhash.Hash]int
 	pendingBlockData []pendingBlock
 
+	// Block files that need to be removed on commit due to pruning.  They
+	// are always contiguous and start at the oldest block file.
+	pendingFileDeletes []uint32
+
 	// Keys that need to be stored or deleted on commit.
 	pendingKeys   *treap.Mutable
 	pendingRemove *treap.Mutable
@@ -981,6 +985,15 @@ type transaction struct {
 // Enforce transaction implements the database.Tx interface.
 var _ database.Tx = (*transaction)(nil)
 
+// Enforce transaction implements the database.BlockPruner interface.
+var _ database.BlockPruner = (*transaction)(nil)
+
+// Enforce transaction implements the database.Backupper interface.
+var _ database.Backupper = (*transaction)(nil)
+
+// Enforce transaction implements the database.BlockVerifier interface.
+var _ database.BlockVerifier = (*transaction)(nil)
+
 // removeActiveIter removes the passed iterator from the list of active
 // iterators against the pending keys treap.
 func (tx *transaction) removeActiveIter(iter *treap.Iterator) {
@@ -1627,6 +1640,122 @@ func (tx *transaction) FetchBlockRegions(regions []database.BlockRegion) ([][]by
 	return blockRegions, nil
 }
 
+// prunedFile houses the hashes of the blocks stored in a block file along with
+// the maximum block height in the file.  It is used when pruning blocks.
+type prunedFile struct {
+	hashes    []chainhash.Hash
+	maxHeight uint32
+}
+
+// PruneBlocks removes the oldest stored blocks until the total size of all
+// block files is at or below the provided target size in bytes.  Blocks are
+// removed a full block file at a time and only files that exclusively contain
+// blocks with a height less than the provided prune height are eligible.  The
+// current write file is never removed.
+//
+// The hashes of all blocks that were removed are returned.  The entries are
+// removed from the block index immediately while the files themselves are
+// removed when the transaction is committed.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.BlockPruner interface implementation.
+func (tx *transaction) PruneBlocks(targetSize uint64, pruneHeight uint32) ([]chainhash.Hash, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "prune blocks requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Determine the total size of all block files that have not already
+	// been pruned and the size of each of them.
+	store := tx.db.store
+	store.writeCursor.RLock()
+	curFileNum := store.writeCursor.curFileNum
+	store.writeCursor.RUnlock()
+	firstFileNum := store.oldestFileNum + uint32(len(tx.pendingFileDeletes))
+	var totalSize uint64
+	fileSizes := make([]uint64, 0, curFileNum-firstFileNum+1)
+	for fileNum := firstFileNum; fileNum <= curFileNum; fileNum++ {
+		size, err := store.fileSize(fileNum)
+		if err != nil {
+			return nil, err
+		}
+		fileSizes = append(fileSizes, size)
+		totalSize += size
+	}
+
+	// Determine which files need to be removed to reach the target size.
+	// The current write file is never a candidate.
+	candidates := make(map[uint32]*prunedFile)
+	lastCandidate := firstFileNum
+	for i := 0; totalSize > targetSize && firstFileNum+uint32(i) < curFileNum; i++ {
+		lastCandidate = firstFileNum + uint32(i)
+		candidates[lastCandidate] = &prunedFile{}
+		totalSize -= fileSizes[i]
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// Find all blocks stored in the candidate files along with the maximum
+	// height of the blocks in each file.
+	var header wire.BlockHeader
+	err := tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+		loc := deserializeBlockLoc(v)
+		file, ok := candidates[loc.blockFileNum]
+		if !ok {
+			return nil
+		}
+		if err := header.FromBytes(v[blockHdrOffset:]); err != nil {
+			str := fmt.Sprintf("failed to deserialize header for "+
+				"block %x", k)
+			return makeDbErr(database.ErrCorruption, str, err)
+		}
+		var hash chainhash.Hash
+		copy(hash[:], k)
+		file.hashes = append(file.hashes, hash)
+		if header.Height > file.maxHeight {
+			file.maxHeight = header.Height
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove the block index entries for all blocks in the candidate files
+	// in order until a file that contains blocks at or after the prune
+	// height is encountered.
+	var prunedHashes []chainhash.Hash
+	for fileNum := firstFileNum; fileNum <= lastCandidate; fileNum++ {
+		file := candidates[fileNum]
+		if len(file.hashes) > 0 && file.maxHeight >= pruneHeight {
+			break
+		}
+
+		for i := range file.hashes {
+			err := tx.blockIdxBucket.Delete(file.hashes[i][:])
+			if err != nil {
+				return nil, err
+			}
+		}
+		prunedHashes = append(prunedHashes, file.hashes...)
+		tx.pendingFileDeletes = append(tx.pendingFileDeletes, fileNum)
+		log.Debugf("Pruning block file %d with %d blocks", fileNum,
+			len(file.hashes))
+	}
+
+	return prunedHashes, nil
+}
+
 // close marks the transaction closed then releases any pending data, the
 // underlying snapshot, the transaction read lock, and the write lock when the
 // transaction is writable.
@@ -1637,6 +1766,9 @@ func (tx *transaction) close() {
 	tx.pendingBlocks = nil
 	tx.pendingBlockData = nil
 
+	// Clear pending block files that would have been removed on commit.
+	tx.pendingFileDeletes = nil
+
 	// Clear pending keys that would have been written or deleted on commit.
 	tx.pendingKeys = nil
 	tx.pendingRemove = nil
@@ -1724,7 +1856,44 @@ func (tx *transaction) writePendingAndCommit() error {
 
 	// Atomically update the database cache.  The cache automatically
 	// handles flushing to the underlying persistent storage database.
-	return tx.db.cache.commitTx(tx)
+	if err := tx.db.cache.commitTx(tx); err != nil {
+		return err
+	}
+
+	// Remove any block files that were pruned.
+	return tx.removePrunedFiles()
+}
+
+// removePrunedFiles removes the block files that were pruned during the
+// transaction.  The database cache is flushed first so the removed block index
+// entries are persisted before the files are removed.  This ensures the block
+// index never refers to a removed file in the event of an unexpected shutdown.
+//
+// Failure to remove a file is only logged since the blocks it contains are no
+// longer referenced, and the file will be retried on the next prune.
+//
+// This function MUST only be called after the transaction has been committed to
+// the database cache.
+func (tx *transaction) removePrunedFiles() error {
+	if len(tx.pendingFileDeletes) == 0 {
+		return nil
+	}
+
+	if err := tx.db.cache.flush(); err != nil {
+		return err
+	}
+
+	store := tx.db.store
+	for _, fileNum := range tx.pendingFileDeletes {
+		if err := store.pruneFile(fileNum); err != nil {
+			log.Warnf("Failed to remove pruned block file %d: %v",
+				fileNum, err)
+			break
+		}
+		store.oldestFileNum = fileNum + 1
+	}
+	tx.pendingFileDeletes = nil
+	return nil
 }
 
 // Commit commits all changes that have been made to the root metadata bucket
@@ -13,8 +13,10 @@ import (
 	"runtime"
 	"testing"
 
+	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/database/v2"
+	_ "github.com/decred/dcrd/database/v2/bboltdb"
 	"github.com/decred/dcrd/database/v2/ffldb"
 	"github.com/decred/dcrd/dcrutil/v3"
 )
@@ -288,3 +290,471 @@ func TestInterface(t *testing.T) {
 		testInterface(t, db)
 	})
 }
+
+// TestBboltInterface performs all interface tests for the bbolt database
+// driver.  They are run from this package since the interface tests are not
+// able to be shared with the bboltdb package.
+func TestBboltInterface(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	const bboltDbType = "bboltdb"
+	dbPath := filepath.Join(os.TempDir(), "ffldb-bboltinterfacetest")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(bboltDbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("failed to create test database (%s) %v", bboltDbType,
+			err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	// Ensure the driver type is the expected value.
+	gotDbType := db.Type()
+	if gotDbType != bboltDbType {
+		t.Errorf("Type: unexpected driver type - got %v, want %v",
+			gotDbType, bboltDbType)
+		return
+	}
+
+	// Run all of the interface tests against the database.
+	runtime.GOMAXPROCS(runtime.NumCPU())
+	testInterface(t, db)
+}
+
+// TestPruneBlocks ensures that pruning blocks removes the oldest block files
+// while respecting the target size and prune height and that the resulting
+// state persists across database restarts.
+func TestPruneBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-prunetest-v2")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer func() { db.Close() }()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to load test blocks: %v", err)
+	}
+
+	// Store all of the test blocks with a small maximum file size to force
+	// multiple flat files.
+	const pruneHeight = 100
+	var pruned []chainhash.Hash
+	ffldb.TstRunWithMaxBlockFileSize(db, 2048, func() {
+		for i, block := range blocks {
+			err := db.Update(func(tx database.Tx) error {
+				return tx.StoreBlock(block)
+			})
+			if err != nil {
+				t.Fatalf("StoreBlock #%d: unexpected error: %v", i,
+					err)
+			}
+		}
+
+		// Ensure nothing is pruned when the target size is larger
+		// than the size of all block files.
+		err = db.Update(func(tx database.Tx) error {
+			hashes, err := tx.(database.BlockPruner).PruneBlocks(1<<32, pruneHeight)
+			if err != nil {
+				return err
+			}
+			if len(hashes) != 0 {
+				return fmt.Errorf("unexpected pruned blocks: %d",
+					len(hashes))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("PruneBlocks: unexpected error: %v", err)
+		}
+
+		// Prune as much as possible.  Only blocks prior to the prune
+		// height are eligible.
+		err = db.Update(func(tx database.Tx) error {
+			var err error
+			pruned, err = tx.(database.BlockPruner).PruneBlocks(0, pruneHeight)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("PruneBlocks: unexpected error: %v", err)
+		}
+	})
+	if len(pruned) == 0 {
+		t.Fatal("PruneBlocks: no blocks were pruned")
+	}
+
+	// The first block file must have been removed.
+	if _, err := os.Stat(filepath.Join(dbPath, "000000000.fdb")); err == nil {
+		t.Fatal("first block file still exists after pruning")
+	}
+
+	// checkBlocks ensures only the pruned blocks are missing and that none
+	// of them are at or after the prune height.
+	prunedSet := make(map[chainhash.Hash]struct{}, len(pruned))
+	for _, hash := range pruned {
+		prunedSet[hash] = struct{}{}
+	}
+	checkBlocks := func(db database.DB) {
+		t.Helper()
+		err := db.View(func(tx database.Tx) error {
+			for _, block := range blocks {
+				hash := block.Hash()
+				height := block.MsgBlock().Header.Height
+				_, wantPruned := prunedSet[*hash]
+				if wantPruned && height >= pruneHeight {
+					return fmt.Errorf("pruned block %s at "+
+						"height %d", hash, height)
+				}
+				_, err := tx.FetchBlock(hash)
+				if wantPruned {
+					if !database.IsError(err, database.ErrBlockNotFound) {
+						return fmt.Errorf("FetchBlock(%d): "+
+							"unexpected error: %v", height,
+							err)
+					}
+					continue
+				}
+				if err != nil {
+					return fmt.Errorf("FetchBlock(%d): "+
+						"unexpected error: %v", height, err)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	checkBlocks(db)
+
+	// Close and reopen the database to ensure the pruned state persists and
+	// the remaining block files are found.
+	db.Close()
+	db, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("failed to open test database (%s) %v", dbType, err)
+	}
+	checkBlocks(db)
+}
+
+// TestBackup ensures backing up a database produces a consistent copy of it as
+// of when the transaction was started, even when blocks are stored and pruned
+// in the meantime.
+func TestBackup(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-backuptest-v2")
+	backupPath := filepath.Join(os.TempDir(), "ffldb-backuptest-v2-backup")
+	_ = os.RemoveAll(dbPath)
+	_ = os.RemoveAll(backupPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer os.RemoveAll(backupPath)
+	defer func() { db.Close() }()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to load test blocks: %v", err)
+	}
+
+	// Store the first half of the test blocks along with some metadata
+	// with a small maximum file size to force multiple flat files and then
+	// prune the oldest ones.
+	const pruneHeight = 50
+	numBackedUp := len(blocks) / 2
+	key, value := []byte("backupkey"), []byte("backupvalue")
+	var pruned []chainhash.Hash
+	var tx database.Tx
+	ffldb.TstRunWithMaxBlockFileSize(db, 2048, func() {
+		for i, block := range blocks[:numBackedUp] {
+			err := db.Update(func(tx database.Tx) error {
+				return tx.StoreBlock(block)
+			})
+			if err != nil {
+				t.Fatalf("StoreBlock #%d: unexpected error: %v", i,
+					err)
+			}
+		}
+		err = db.Update(func(tx database.Tx) error {
+			if err := tx.Metadata().Put(key, value); err != nil {
+				return err
+			}
+			var err error
+			pruned, err = tx.(database.BlockPruner).PruneBlocks(0, pruneHeight)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("Update: unexpected error: %v", err)
+		}
+
+		// Start the transaction the backup is made from and then store
+		// the remaining blocks and modify the metadata so they are not
+		// part of the backup.
+		tx, err = db.Begin(false)
+		if err != nil {
+			t.Fatalf("Begin: unexpected error: %v", err)
+		}
+		for i, block := range blocks[numBackedUp:] {
+			err := db.Update(func(tx database.Tx) error {
+				return tx.StoreBlock(block)
+			})
+			if err != nil {
+				t.Fatalf("StoreBlock #%d: unexpected error: %v",
+					numBackedUp+i, err)
+			}
+		}
+		err = db.Update(func(tx database.Tx) error {
+			return tx.Metadata().Delete(key)
+		})
+		if err != nil {
+			t.Fatalf("Update: unexpected error: %v", err)
+		}
+	})
+	if len(pruned) == 0 {
+		t.Fatal("PruneBlocks: no blocks were pruned")
+	}
+
+	if err := tx.(database.Backupper).Backup(backupPath); err != nil {
+		t.Fatalf("Backup: unexpected error: %v", err)
+	}
+
+	// Ensure backing up to a path that already has a database fails.
+	err = tx.(database.Backupper).Backup(backupPath)
+	if !database.IsError(err, database.ErrDbExists) {
+		t.Fatalf("Backup: unexpected error -- got %v, want %v", err,
+			database.ErrDbExists)
+	}
+
+	// Ensure backing up from a closed transaction fails.
+	if err := tx.Rollback(); err != nil {
+		t.Fatalf("Rollback: unexpected error: %v", err)
+	}
+	err = tx.(database.Backupper).Backup(filepath.Join(backupPath, "closed"))
+	if !database.IsError(err, database.ErrTxClosed) {
+		t.Fatalf("Backup: unexpected error -- got %v, want %v", err,
+			database.ErrTxClosed)
+	}
+
+	// Ensure the backup only contains the metadata and blocks as of when
+	// the transaction was started.
+	backupDB, err := database.Open(dbType, backupPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("failed to open backup database (%s) %v", dbType, err)
+	}
+	defer backupDB.Close()
+	prunedSet := make(map[chainhash.Hash]struct{}, len(pruned))
+	for _, hash := range pruned {
+		prunedSet[hash] = struct{}{}
+	}
+	err = backupDB.View(func(tx database.Tx) error {
+		if got := tx.Metadata().Get(key); !reflect.DeepEqual(got, value) {
+			return fmt.Errorf("Get: unexpected value -- got %s, "+
+				"want %s", got, value)
+		}
+		for i, block := range blocks {
+			_, isPruned := prunedSet[*block.Hash()]
+			gotBytes, err := tx.FetchBlock(block.Hash())
+			if i >= numBackedUp || isPruned {
+				if !database.IsError(err, database.ErrBlockNotFound) {
+					return fmt.Errorf("FetchBlock #%d: "+
+						"unexpected error: %v", i, err)
+				}
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("FetchBlock #%d: unexpected "+
+					"error: %v", i, err)
+			}
+			wantBytes, _ := block.Bytes()
+			if !reflect.DeepEqual(gotBytes, wantBytes) {
+				return fmt.Errorf("FetchBlock #%d: stored block "+
+					"mismatch", i)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ensure blocks can be stored in the backup.
+	err = backupDB.Update(func(tx database.Tx) error {
+		return tx.StoreBlock(blocks[numBackedUp])
+	})
+	if err != nil {
+		t.Fatalf("StoreBlock: unexpected error: %v", err)
+	}
+}
+
+// flipFileByte inverts the bits of the byte at the provided offset in the file
+// at the provided path.
+func flipFileByte(t *testing.T, path string, offset int64) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatalf("failed to open %q: %v", path, err)
+	}
+	defer f.Close()
+	var b [1]byte
+	if _, err := f.ReadAt(b[:], offset); err != nil {
+		t.Fatalf("failed to read %q: %v", path, err)
+	}
+	b[0] = ^b[0]
+	if _, err := f.WriteAt(b[:], offset); err != nil {
+		t.Fatalf("failed to write %q: %v", path, err)
+	}
+}
+
+// TestVerifyBlocks ensures that verifying the stored blocks detects corrupted
+// block data and that repairing removes the invalid block index entries and
+// recovers valid blocks that are not in the block index.
+func TestVerifyBlocks(t *testing.T) {
+	t.Parallel()
+
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-verifytest-v2")
+	_ = os.RemoveAll(dbPath)
+	db, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to create test database (%s) %v", dbType, err)
+	}
+	defer os.RemoveAll(dbPath)
+	defer db.Close()
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Fatalf("Failed to load test blocks: %v", err)
+	}
+
+	// Store all of the test blocks with a small maximum file size to force
+	// multiple flat files.
+	ffldb.TstRunWithMaxBlockFileSize(db, 2048, func() {
+		for i, block := range blocks {
+			err := db.Update(func(tx database.Tx) error {
+				return tx.StoreBlock(block)
+			})
+			if err != nil {
+				t.Fatalf("StoreBlock #%d: unexpected error: %v", i,
+					err)
+			}
+		}
+	})
+
+	// verify runs a verification of the stored blocks in a transaction that
+	// is committed when repairing.
+	verify := func(repair bool) *database.VerifyResult {
+		t.Helper()
+		var result *database.VerifyResult
+		fn := func(tx database.Tx) error {
+			var err error
+			result, err = tx.(database.BlockVerifier).VerifyBlocks(repair)
+			return err
+		}
+		if repair {
+			err = db.Update(fn)
+		} else {
+			err = db.View(fn)
+		}
+		if err != nil {
+			t.Fatalf("VerifyBlocks: unexpected error: %v", err)
+		}
+		if result.NumBlocks == 0 {
+			t.Fatal("VerifyBlocks: no blocks were checked")
+		}
+		return result
+	}
+
+	// Ensure there are no issues with the intact database.
+	result := verify(false)
+	if result.NumBlocks != uint64(len(blocks)) || len(result.Issues) != 0 {
+		t.Fatalf("VerifyBlocks: unexpected result -- got %d blocks and "+
+			"%d issues, want %d blocks and no issues", result.NumBlocks,
+			len(result.Issues), len(blocks))
+	}
+
+	// Ensure repairing with a read-only transaction fails.
+	err = db.View(func(tx database.Tx) error {
+		_, err := tx.(database.BlockVerifier).VerifyBlocks(true)
+		return err
+	})
+	if !database.IsError(err, database.ErrTxNotWritable) {
+		t.Fatalf("VerifyBlocks: unexpected error -- got %v, want %v", err,
+			database.ErrTxNotWritable)
+	}
+
+	// Corrupt the header of the first block in the second flat file and
+	// ensure it is detected.
+	blockFile := filepath.Join(dbPath, fmt.Sprintf("%09d.fdb", 1))
+	flipFileByte(t, blockFile, 8+4)
+	result = verify(false)
+	if len(result.Issues) == 0 {
+		t.Fatal("VerifyBlocks: corrupted block was not detected")
+	}
+	for _, issue := range result.Issues {
+		if issue.Repaired {
+			t.Fatalf("VerifyBlocks: issue for block %s marked repaired",
+				issue.Hash)
+		}
+	}
+	corrupted := result.Issues[0].Hash
+
+	// Ensure repairing removes the corrupted block from the index.
+	result = verify(true)
+	if len(result.Issues) != 1 || !result.Issues[0].Repaired ||
+		result.NumRecovered != 0 {
+
+		t.Fatalf("VerifyBlocks: unexpected repair result %+v", result)
+	}
+	err = db.View(func(tx database.Tx) error {
+		exists, err := tx.HasBlock(&corrupted)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return fmt.Errorf("HasBlock: corrupted block %s still "+
+				"exists", corrupted)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	result = verify(false)
+	if len(result.Issues) != 0 {
+		t.Fatalf("VerifyBlocks: unexpected issues after repair %+v",
+			result.Issues)
+	}
+
+	// Restore the block data and ensure repairing recovers the block.
+	flipFileByte(t, blockFile, 8+4)
+	result = verify(true)
+	if len(result.Issues) != 0 || result.NumRecovered != 1 {
+		t.Fatalf("VerifyBlocks: unexpected repair result %+v", result)
+	}
+	err = db.View(func(tx database.Tx) error {
+		_, err := tx.FetchBlock(&corrupted)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("FetchBlock: unexpected error: %v", err)
+	}
+	result = verify(false)
+	if result.NumBlocks != uint64(len(blocks)) || len(result.Issues) != 0 {
+		t.Fatalf("VerifyBlocks: unexpected result -- got %d blocks and "+
+			"%d issues, want %d blocks and no issues", result.NumBlocks,
+			len(result.Issues), len(blocks))
+	}
+}
@@ -3,13 +3,10 @@
 // Use of this source code is governed by an ISC
 // license that can be found in the LICENSE file.
 
-// This file intended to be copied into each backend driver directory.  Each
-// driver should have their own driver_test.go file which creates a database and
-// invokes the testInterface function in this file to ensure the driver properly
-// implements the interface.
-//
-// NOTE: When copying this file into the backend driver folder, the package name
-// will need to be changed accordingly.
+// This file houses the interface tests that every backend driver must pass.
+// Since test files are not able to be shared between packages, driver_test.go
+// invokes the testInterface function in this file for each of the backend
+// drivers to ensure they properly implement the interface.
 
 package ffldb_test
 
@@ -1496,6 +1493,18 @@ func testBlockIOTxInterface(tc *testContext) bool {
 			}
 		}
 
+		// Ensure attempting to prune blocks with a read-only
+		// transaction fails with the expected error when the backend
+		// supports pruning.
+		if pruner, ok := tx.(database.BlockPruner); ok {
+			_, err := pruner.PruneBlocks(0, 0)
+			if !checkDbError(tc.t, "PruneBlocks on ro tx", err,
+				wantErrCode) {
+
+				return errSubTestFail
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -1899,6 +1908,16 @@ func testClosedTxInterface(tc *testContext, tx database.Tx) bool {
 		return false
 	}
 
+	// Ensure PruneBlocks returns expected error when the backend supports
+	// pruning.
+	if pruner, ok := tx.(database.BlockPruner); ok {
+		testName = "PruneBlocks on closed tx"
+		_, err = pruner.PruneBlocks(0, 0)
+		if !checkDbError(tc.t, testName, err, wantErrCode) {
+			return false
+		}
+	}
+
 	// ---------------
 	// Commit/Rollback
 	// ---------------
@@ -0,0 +1,233 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package ffldb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/database/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// minBlockRecordLen is the minimum length of a block record in a flat
+	// file.  It consists of the network, the block length, a block header,
+	// and the checksum.
+	minBlockRecordLen = 8 + blockHdrSize + 4
+)
+
+// verifyBlockRow checks the provided block index entry against the block data
+// stored in the flat files and returns a description of the first problem found
+// or an empty string when the entry is valid.
+func (tx *transaction) verifyBlockRow(k, v []byte) string {
+	if len(k) != chainhash.HashSize {
+		return fmt.Sprintf("block index key has an invalid length of %d",
+			len(k))
+	}
+	if len(v) != blockLocSize+blockHdrSize {
+		return fmt.Sprintf("block index entry has an invalid length of %d",
+			len(v))
+	}
+	loc := deserializeBlockLoc(v)
+	if loc.blockLen < minBlockRecordLen {
+		return fmt.Sprintf("block location in file %d, offset %d has an "+
+			"invalid length of %d", loc.blockFileNum, loc.fileOffset,
+			loc.blockLen)
+	}
+
+	var hash chainhash.Hash
+	copy(hash[:], k)
+	blockBytes, err := tx.db.store.readBlock(&hash, loc)
+	if err != nil {
+		return err.Error()
+	}
+	if !bytes.Equal(blockBytes[:blockHdrSize], v[blockHdrOffset:]) {
+		return fmt.Sprintf("block header in file %d, offset %d does not "+
+			"match the block index", loc.blockFileNum, loc.fileOffset)
+	}
+	var header wire.BlockHeader
+	if err := header.FromBytes(blockBytes[:blockHdrSize]); err != nil {
+		return fmt.Sprintf("failed to deserialize block header: %v", err)
+	}
+	if blockHash := header.BlockHash(); blockHash != hash {
+		return fmt.Sprintf("block in file %d, offset %d has hash %s",
+			loc.blockFileNum, loc.fileOffset, blockHash)
+	}
+	return ""
+}
+
+// scanBlockFile calls the provided function with the location and header of
+// every valid block record in the provided flat block file up to the provided
+// end offset.  Scanning stops at the first invalid record since the locations
+// of any records that follow it can't be determined.
+func (tx *transaction) scanBlockFile(fileNum, endOffset uint32, fn func(hash *chainhash.Hash, loc blockLocation, hdr []byte) error) error {
+	filePath := blockFilePath(tx.db.store.basePath, fileNum)
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			log.Warnf("Block file %d does not exist", fileNum)
+			return nil
+		}
+		str := fmt.Sprintf("failed to open file %q: %v", filePath, err)
+		return makeDbErr(database.ErrDriverSpecific, str, err)
+	}
+	defer file.Close()
+
+	var offset uint32
+	var header wire.BlockHeader
+	var recordHdr [8]byte
+	r := bufio.NewReaderSize(file, 1024*1024)
+	for offset < endOffset {
+		if _, err := io.ReadFull(r, recordHdr[:]); err != nil {
+			break
+		}
+		serializedNet := byteOrder.Uint32(recordHdr[0:4])
+		blockLen := byteOrder.Uint32(recordHdr[4:8])
+		recordLen := uint64(blockLen) + 12
+		if serializedNet != uint32(tx.db.store.network) ||
+			blockLen > wire.MaxBlockPayload ||
+			recordLen < minBlockRecordLen ||
+			uint64(offset)+recordLen > uint64(endOffset) {
+
+			log.Warnf("Invalid block record in file %d at offset %d",
+				fileNum, offset)
+			break
+		}
+
+		record := make([]byte, recordLen)
+		copy(record, recordHdr[:])
+		if _, err := io.ReadFull(r, record[8:]); err != nil {
+			break
+		}
+		serializedChecksum := binary.BigEndian.Uint32(record[recordLen-4:])
+		calculatedChecksum := crc32.Checksum(record[:recordLen-4], castagnoli)
+		if serializedChecksum != calculatedChecksum {
+			log.Warnf("Block record in file %d at offset %d has an "+
+				"invalid checksum", fileNum, offset)
+			break
+		}
+
+		hdr := record[8 : 8+blockHdrSize]
+		if err := header.FromBytes(hdr); err != nil {
+			break
+		}
+		hash := header.BlockHash()
+		loc := blockLocation{
+			blockFileNum: fileNum,
+			fileOffset:   offset,
+			blockLen:     uint32(recordLen),
+		}
+		if err := fn(&hash, loc, hdr); err != nil {
+			return err
+		}
+		offset += uint32(recordLen)
+	}
+	return nil
+}
+
+// VerifyBlocks checks that every block in the block index can be loaded from
+// the flat block files and that its stored header and hash are consistent with
+// the index.
+//
+// When repair is set, the index entries for blocks that can not be loaded are
+// removed and all of the flat block files that have not been pruned are scanned
+// for valid blocks which are added back to the index when they are missing from
+// it.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrTxNotWritable if repair is requested against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// This function is part of the database.BlockVerifier interface
+// implementation.
+func (tx *transaction) VerifyBlocks(repair bool) (*database.VerifyResult, error) {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return nil, err
+	}
+
+	// Ensure the transaction is writable when repairing.
+	if repair && !tx.writable {
+		str := "repairing blocks requires a writable database transaction"
+		return nil, makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	// Check every entry in the block index while keeping track of the
+	// invalid entries so they can be removed after iterating.
+	var result database.VerifyResult
+	var badKeys [][]byte
+	err := tx.blockIdxBucket.ForEach(func(k, v []byte) error {
+		result.NumBlocks++
+		desc := tx.verifyBlockRow(k, v)
+		if desc == "" {
+			return nil
+		}
+		var hash chainhash.Hash
+		copy(hash[:], k)
+		result.Issues = append(result.Issues, database.BlockIssue{
+			Hash:        hash,
+			Description: desc,
+		})
+		badKeys = append(badKeys, copySlice(k))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !repair {
+		return &result, nil
+	}
+
+	for _, k := range badKeys {
+		if err := tx.blockIdxBucket.Delete(k); err != nil {
+			return nil, err
+		}
+	}
+
+	// Scan all of the block files that have not been pruned up to the write
+	// cursor and add any valid blocks that are not in the index.  This also
+	// replaces the index entries that were removed above when a valid copy
+	// of the block is found.
+	store := tx.db.store
+	store.writeCursor.RLock()
+	curFileNum := store.writeCursor.curFileNum
+	curOffset := store.writeCursor.curOffset
+	store.writeCursor.RUnlock()
+	firstFileNum := store.oldestFileNum + uint32(len(tx.pendingFileDeletes))
+	for fileNum := firstFileNum; fileNum <= curFileNum; fileNum++ {
+		endOffset := store.maxBlockFileSize
+		if fileNum == curFileNum {
+			endOffset = curOffset
+		}
+		err := tx.scanBlockFile(fileNum, endOffset, func(hash *chainhash.Hash, loc blockLocation, hdr []byte) error {
+			if tx.blockIdxBucket.Get(hash[:]) != nil {
+				return nil
+			}
+			blockRow := serializeBlockRow(loc, hdr)
+			if err := tx.blockIdxBucket.Put(hash[:], blockRow); err != nil {
+				return err
+			}
+			result.NumRecovered++
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// The index no longer contains any invalid entries.
+	for i := range result.Issues {
+		result.Issues[i].Repaired = true
+	}
+
+	return &result, nil
+}
@@ -10,4 +10,6 @@ require (
 	github.com/decred/slog v1.1.0
 	github.com/jessevdk/go-flags v1.4.0
 	github.com/syndtr/goleveldb v1.0.1-0.20200815110645-5c35d600f0ca
+	go.etcd.io/bbolt v1.3.6
+	golang.org/x/sys v0.0.0-20201119102817-f84b799fce68 // indirect
 )
@@ -215,6 +215,34 @@ type BlockSerializer interface {
 	Bytes() ([]byte, error)
 }
 
+// BlockIssue describes a problem found with a stored block while verifying the
+// integrity of the block storage.
+type BlockIssue struct {
+	// Hash is the hash of the block the issue applies to.
+	Hash chainhash.Hash
+
+	// Description is a human-readable description of the issue.
+	Description string
+
+	// Repaired indicates whether or not the issue was repaired.
+	Repaired bool
+}
+
+// VerifyResult houses the results of verifying the integrity of the block
+// storage.
+type VerifyResult struct {
+	// NumBlocks is the number of indexed blocks that were checked.
+	NumBlocks uint64
+
+	// NumRecovered is the number of blocks that were found in the underlying
+	// storage without a valid index entry and were added back to the index.
+	// It is always zero when repair was not requested.
+	NumRecovered uint64
+
+	// Issues contains all of the issues that were found.
+	Issues []BlockIssue
+}
+
 // Tx represents a database transaction.  It can either by read-only or
 // read-write.  The transaction provides a metadata bucket against which all
 // read and writes occur.
@@ -420,6 +448,80 @@ type Tx interface {
 	Rollback() error
 }
 
+// BlockPruner is an optional interface that database transactions may implement
+// to support removing the data for old blocks.  Callers should type assert a
+// Tx to this interface to determine if the backend supports pruning.
+type BlockPruner interface {
+	// PruneBlocks removes the oldest stored blocks until the total size of
+	// all stored blocks is at or below the provided target size in bytes.
+	// Only blocks with a height less than the provided prune height are
+	// eligible for removal.  Depending on the backend implementation,
+	// blocks might be removed in groups, so the total size might remain
+	// above the target when removing the next group would involve
+	// removing blocks that are not eligible.
+	//
+	// The hashes of all blocks that were removed are returned.  The blocks
+	// are no longer available from the point of view of the transaction
+	// and, once it is committed, from any transactions that are started
+	// after the commit finishes.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrTxNotWritable if attempted against a read-only transaction
+	//   - ErrTxClosed if the transaction has already been closed
+	//
+	// Other errors are possible depending on the implementation.
+	PruneBlocks(targetSize uint64, pruneHeight uint32) ([]chainhash.Hash, error)
+}
+
+// Backupper is an optional interface that database transactions may implement
+// to support writing a copy of the database.  Callers should type assert a Tx
+// to this interface to determine if the backend supports backups.
+type Backupper interface {
+	// Backup writes a consistent copy of the entire database, including
+	// both the metadata and the stored blocks, to a new database at the
+	// provided path.  The copy reflects the state of the database as of
+	// when the transaction was started and does not include any changes
+	// made by the transaction itself.  The new database is created with the
+	// same driver and may be opened with Open once the call returns.
+	//
+	// Since the copy is made from the transaction snapshot, it may be
+	// called on a read-only transaction while other transactions continue
+	// to modify the database.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrDbExists if a database already exists at the provided path
+	//   - ErrTxClosed if the transaction has already been closed
+	//
+	// Other errors are possible depending on the implementation.
+	Backup(destPath string) error
+}
+
+// BlockVerifier is an optional interface that database transactions may
+// implement to support verifying and repairing the integrity of the stored
+// blocks.  Callers should type assert a Tx to this interface to determine if
+// the backend supports verification.
+type BlockVerifier interface {
+	// VerifyBlocks checks that every block in the block index can be loaded
+	// from the underlying storage and that its stored data is consistent
+	// with the index.
+	//
+	// When repair is set, index entries for blocks that can not be loaded
+	// are removed and valid blocks that exist in the underlying storage
+	// without an index entry are added back to the index.  The repairs are
+	// only saved once the transaction is committed.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrTxNotWritable if repair is requested against a read-only
+	//     transaction
+	//   - ErrTxClosed if the transaction has already been closed
+	//
+	// Other errors are possible depending on the implementation.
+	VerifyBlocks(repair bool) (*VerifyResult, error)
+}
+
 // DB provides a generic interface that is used to store blocks and related
 // metadata.  This interface is intended to be agnostic to the actual mechanism
 // used for backend data storage.  The RegisterDriver function can be used to
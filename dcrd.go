@@ -169,6 +169,62 @@ func dcrdMain() error {
 
 		return nil
 	}
+	if cfg.DropSpendIndex {
+		if err := indexers.DropSpendIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropTicketIndex {
+		if err := indexers.DropTicketIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropTreasuryIndex {
+		if err := indexers.DropTreasuryIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropScriptHashIndex {
+		if err := indexers.DropScriptHashIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropAddrUtxoIndex {
+		if err := indexers.DropAddrUtxoIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropBlockStatsIndex {
+		if err := indexers.DropBlockStatsIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropNullDataIndex {
+		if err := indexers.DropNullDataIndex(ctx, db); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropExistsAddrIndex {
 		if err := indexers.DropExistsAddrIndex(ctx, db); err != nil {
 			dcrdLog.Errorf("%v", err)
@@ -186,6 +242,30 @@ func dcrdMain() error {
 		return nil
 	}
 
+	// Verify and optionally repair the block database and exit if requested.
+	if cfg.VerifyDB || cfg.RepairDB {
+		if err := verifyDB(ctx, db, cfg.params.Params, cfg.RepairDB); err != nil {
+			dcrdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+
+	// Initialize the chain state from a utxo set snapshot if requested.
+	if cfg.LoadUtxoSnapshot != "" {
+		err := loadUtxoSnapshot(ctx, db, cfg.params.Params)
+		if err != nil {
+			dcrdLog.Errorf("Unable to load utxo set snapshot: %v", err)
+			return err
+		}
+	}
+
+	// Return now if a shutdown signal was triggered.
+	if shutdownRequested(ctx) {
+		return nil
+	}
+
 	// Create server.
 	lifetimeNotifier.notifyStartupEvent(lifetimeEventP2PServer)
 	svr, err := newServer(ctx, cfg.Listeners, db, cfg.params.Params,
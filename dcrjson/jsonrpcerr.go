@@ -75,6 +75,7 @@ const (
 	ErrRPCNoNewestBlockInfo RPCErrorCode = -5
 	ErrRPCInvalidTxVout     RPCErrorCode = -5
 	ErrRPCNoTreasury        RPCErrorCode = -5
+	ErrRPCBlockPruned       RPCErrorCode = -41
 	ErrRPCRawTxString       RPCErrorCode = -32602
 	ErrRPCDecodeHexString   RPCErrorCode = -22
 	ErrRPCDuplicateTx       RPCErrorCode = -40
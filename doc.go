@@ -29,11 +29,15 @@ Application Options:
       --logdir=                Directory to log output
       --nofilelogging=         Disable file logging
       --dbtype=                Database backend to use for the block chain
-                               (default: ffldb)
+                               {ffldb, bboltdb} (default: ffldb)
       --profile=               Enable HTTP profiling on given [addr:]port --
                                NOTE: port must be between 1024 and 65536
       --cpuprofile=            Write CPU profile to the specified file
       --memprofile=            Write mem profile to the specified file
+      --metricslisten=         Serve Prometheus metrics over HTTP at /metrics
+                               on the given [addr:]port -- NOTE: The metrics
+                               are not authenticated, so it should only be
+                               exposed to trusted networks
       --testnet                Use the test network
       --simnet                 Use the simulation test network
       --regnet                 Use the regression test network
@@ -47,14 +51,21 @@ Application Options:
                                verification cache (default: 100000)
       --norpc                  Disable built-in RPC server -- NOTE: The RPC
                                server is disabled by default if no
-                               rpcuser/rpcpass or rpclimituser/rpclimitpass is
-                               specified
+                               rpcuser/rpcpass, rpclimituser/rpclimitpass,
+                               rpcusersfile, rpcadminclient, or rpclimitclient
+                               is specified and rpccookie is not set
       --rpclisten=             Add an interface/port to listen for RPC
                                connections (default port: 9109, testnet: 19109)
   -u, --rpcuser=               Username for RPC connections
   -P, --rpcpass=               Password for RPC connections
       --rpclimituser=          Username for limited RPC connections
       --rpclimitpass=          Password for limited RPC connections
+      --rpcusersfile=          File containing additional RPC users along with
+                               the bcrypt or argon2 hashes of their passwords
+                               and the methods each of them may call
+      --rpccookie              Write a random full-access password to the
+                               .cookie file in the data directory on start up
+                               for local RPC clients to authenticate with
       --rpccert=               File containing the certificate file
       --rpckey=                File containing the certificate key
       --tlscurve=              Curve to use when generating the TLS keypair
@@ -65,12 +76,26 @@ Application Options:
       --notls                  Disable TLS for the RPC server -- NOTE: This is
                                only allowed if the RPC server is bound to
                                localhost
+      --rpcclientcafile=       File containing the certificate authorities used
+                               to verify the TLS client certificates that are
+                               required for all RPC connections when specified
+      --rpcadminclient=        Subject common name of a TLS client certificate
+                               signed by an rpcclientcafile authority that
+                               authenticates as the admin RPC user; may be
+                               specified multiple times
+      --rpclimitclient=        Subject common name of a TLS client certificate
+                               signed by an rpcclientcafile authority that
+                               authenticates as the limited RPC user; may be
+                               specified multiple times
       --rpcmaxclients=         Max number of RPC clients for standard
                                connections (default: 10)
       --rpcmaxwebsockets=      Max number of RPC websocket connections (default:
                                25)
       --rpcmaxconcurrentreqs=  Max number of concurrent RPC requests that may be
                                processed concurrently (default: 20)
+      --rest                   Serve the read-only REST interface under /rest/
+                               on the RPC listeners -- NOTE: The REST interface
+                               is not authenticated
       --proxy=                 Connect via SOCKS5 proxy (eg. 127.0.0.1:9050)
       --proxyuser=             Username for proxy server
       --proxypass=             Password for proxy server
@@ -119,6 +144,39 @@ Application Options:
                                unless you know what you're doing
       --dumpblockchain=        Write blockchain as a flat file of blocks for use
                                with addblock, to the specified filename
+      --prune=                 Reduce storage requirements by removing old block
+                               data to keep the total size of stored blocks near
+                               the specified target in MiB.  Pruned nodes do not
+                               serve historical blocks and are incompatible with
+                               --txindex, --addrindex, --spendindex,
+                               --ticketindex, --treasuryindex,
+                               --scripthashindex, --addrutxoindex,
+                               --blockstatsindex, and --nulldataindex.  Minimum
+                               1024 MiB (0 to disable)
+      --utxocachemaxsize=      The maximum size in MiB of the cache of unspent
+                               transaction outputs that is written to the
+                               database in batches.  Valid range is 25 to 32768
+                               MiB (default: 150)
+      --assumevalid=           Hash of a block for which the scripts of it and
+                               all of its ancestors are assumed to be valid when
+                               syncing.  All other consensus rules are still
+                               enforced.  Use 0 to validate all scripts
+                               (default: network specific)
+      --loadutxosnapshot=      Initialize a new chain from the utxo set snapshot
+                               at the specified path instead of syncing from the
+                               genesis block.  The snapshot must be one that is
+                               committed to by the active network.  The history
+                               that leads to the snapshot is validated in the
+                               background.  Only networks that commit to
+                               snapshots are supported, which currently is
+                               only regnet
+      --verifydb               Verify the integrity of the block database on
+                               start up, write a report to dbverify.json in the
+                               data directory, and then exit
+      --repairdb               Verify the integrity of the block database on
+                               start up, repair the issues that are found where
+                               possible, write a report to dbverify.json in the
+                               data directory, and then exit
       --minrelaytxfee=         The minimum transaction fee in DCR/kB to be
                                considered a non-zero fee (default: 0.0001)
       --limitfreerelay=        Limit relay of transactions with no transaction
@@ -170,6 +228,41 @@ Application Options:
                                available
       --dropaddrindex          Deletes the address-based transaction index from
                                the database on start up and then exits
+      --spendindex             Maintain a full outpoint-based spend index which
+                               makes the getspendinginfo RPC available
+      --dropspendindex         Deletes the outpoint-based spend index from the
+                               database on start up and then exits
+      --ticketindex            Maintain a full ticket history index which makes
+                               the getticketinfo RPC and historical tickets in
+                               the ticketsforaddress RPC available
+      --dropticketindex        Deletes the ticket history index from the
+                               database on start up and then exits
+      --treasuryindex          Maintain a full treasury transaction history
+                               index which makes the gettreasuryhistory RPC
+                               available
+      --droptreasuryindex      Deletes the treasury transaction history index
+                               from the database on start up and then exits
+      --scripthashindex        Maintain a full script hash index which makes
+                               the getscripthashhistory and getscripthashbalance
+                               RPCs available
+      --dropscripthashindex    Deletes the script hash index from the database
+                               on start up and then exits
+      --addrutxoindex          Maintain a full address-based unspent
+                               transaction output index which makes the
+                               getaddressbalance and getaddressutxos RPCs
+                               available (implies --addrindex)
+      --dropaddrutxoindex      Deletes the address-based unspent transaction
+                               output index from the database on start up and
+                               then exits
+      --blockstatsindex        Maintain a full block statistics index which
+                               makes the getblockstats RPC available
+      --dropblockstatsindex    Deletes the block statistics index from the
+                               database on start up and then exits
+      --nulldataindex          Maintain a full null data (OP_RETURN) payload
+                               index which makes the searchnulldata RPC
+                               available
+      --dropnulldataindex      Deletes the null data payload index from the
+                               database on start up and then exits
       --noexistsaddrindex      Disable the exists address index, which tracks
                                whether or not an address has even been used
       --dropexistsaddrindex    Deletes the exists address index from the
@@ -179,6 +272,14 @@ Application Options:
       --dropcfindex            (Deprecated) Deletes the index used for compact
                                filtering (CF) support from the database on start
                                up and then exits
+      --reindex=               Rewind the index with the provided name to the
+                               provided height on start up so the blocks after
+                               it are indexed again -- Specified as
+                               <name>:<height> where name is the name of the
+                               option used to enable the index or the index
+                               name reported by the getindexinfo RPC, for
+                               example: 'addrindex:500000' -- The indexes that
+                               depend on it are rewound as well
       --piperx=                File descriptor of read end pipe to enable parent
                                -> child process communication
       --pipetx=                File descriptor of write end pipe to enable
@@ -756,6 +756,33 @@ func (stats *Estimator) IsEnabled() bool {
 	return enabled
 }
 
+// EstimatorState houses information about the current state of an estimator.
+type EstimatorState struct {
+	// BestHeight is the height of the most recently processed block or -1
+	// when the estimator is not enabled.
+	BestHeight int64
+
+	// MemPoolTxns is the number of mempool transactions being tracked.
+	MemPoolTxns int
+
+	// MaxConfirms is the maximum confirmation range tracked by the estimator.
+	MaxConfirms int32
+}
+
+// State returns information about the current state of the estimator.
+//
+// This function is safe to be called from multiple goroutines.
+func (stats *Estimator) State() EstimatorState {
+	stats.lock.RLock()
+	state := EstimatorState{
+		BestHeight:  stats.bestHeight,
+		MemPoolTxns: len(stats.memPoolTxs),
+		MaxConfirms: stats.maxConfirms,
+	}
+	stats.lock.RUnlock()
+	return state
+}
+
 // AddMemPoolTransaction adds a mempool transaction to the estimator in order to
 // account for it in the estimations. It assumes that this transaction is
 // entering the mempool at the currently recorded best chain hash, using the
@@ -614,6 +614,18 @@ func (mp *TxPool) IsTransactionInPool(hash *chainhash.Hash) bool {
 	return inPool
 }
 
+// CheckSpend returns the transaction in the main pool that spends the passed
+// outpoint or nil when there is none.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) CheckSpend(op wire.OutPoint) *dcrutil.Tx {
+	mp.mtx.RLock()
+	txR := mp.outpoints[op]
+	mp.mtx.RUnlock()
+
+	return txR
+}
+
 // isOrphanInPool returns whether or not the passed transaction already exists
 // in the orphan pool.
 //
@@ -2042,6 +2054,17 @@ func (mp *TxPool) Count() int {
 	return count
 }
 
+// OrphanCount returns the number of transactions in the orphan pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) OrphanCount() int {
+	mp.mtx.RLock()
+	count := len(mp.orphans)
+	mp.mtx.RUnlock()
+
+	return count
+}
+
 // TxHashes returns a slice of hashes for all of the transactions in the memory
 // pool.
 //
@@ -917,6 +917,10 @@ func TestSimpleOrphanChain(t *testing.T) {
 		// transaction pool, and is reported as available.
 		testPoolMembership(tc, tx, true, false)
 	}
+	if got := harness.txPool.OrphanCount(); got != int(maxOrphans) {
+		t.Fatalf("OrphanCount: unexpected count -- got %d, want %d", got,
+			maxOrphans)
+	}
 
 	// Add the transaction which completes the orphan chain and ensure they
 	// all get accepted.  Notice the accept orphans flag is also false here
@@ -938,6 +942,13 @@ func TestSimpleOrphanChain(t *testing.T) {
 		// now in the transaction pool, and is reported as available.
 		testPoolMembership(tc, tx, false, true)
 	}
+	if got := harness.txPool.OrphanCount(); got != 0 {
+		t.Fatalf("OrphanCount: unexpected count -- got %d, want 0", got)
+	}
+	if got := harness.txPool.Count(); got != len(chainedTxns) {
+		t.Fatalf("Count: unexpected count -- got %d, want %d", got,
+			len(chainedTxns))
+	}
 }
 
 // TestTicketPurchaseOrphan ensures that ticket purchases are orphaned when
@@ -2126,6 +2137,12 @@ func TestMempoolDoubleSpend(t *testing.T) {
 	}
 	testPoolMembership(tc, tx, false, true)
 
+	// Ensure the transaction is reported as the spender of the output.
+	spender := harness.txPool.CheckSpend(spendableOuts[0].outPoint)
+	if spender == nil || *spender.Hash() != *tx.Hash() {
+		t.Fatalf("CheckSpend: unexpected spender %v", spender)
+	}
+
 	// Create a second transaction, spending the same outputs. Create with
 	// 2 outputs so that it is a different transaction than the original
 	// one.
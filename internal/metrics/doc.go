@@ -0,0 +1,21 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package metrics provides counters, gauges, and histograms that are exported in
+the Prometheus text exposition format.
+
+A Registry houses the metrics and serves them over HTTP so they may be scraped
+by Prometheus or any other monitoring system that understands the format.
+Metrics that are updated as events occur, such as request latencies, are
+created with the constructors that return Counter, Gauge, Histogram,
+CounterVec, and HistogramVec instances.  Metrics whose values are already
+tracked elsewhere, such as the current best block height, are registered with
+the function-based constructors which obtain the current value each time the
+metrics are scraped.
+
+This package intentionally only implements the small subset of the format that
+is needed by the node in order to avoid additional dependencies.
+*/
+package metrics
@@ -0,0 +1,547 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// contentType is the content type of the Prometheus text exposition format.
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultLatencyBuckets are the upper bounds, in seconds, of the histogram
+// buckets that are suitable for most latencies measured by the node.
+var DefaultLatencyBuckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05,
+	0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// metric describes a metric that is able to write itself, including its help
+// and type metadata, in the Prometheus text exposition format.
+type metric interface {
+	// name returns the name of the metric.
+	name() string
+
+	// write writes the metric to the provided buffer.
+	write(buf *bytes.Buffer)
+}
+
+// desc houses the name, help text, and type shared by all metrics.
+type desc struct {
+	metricName string
+	help       string
+	metricType string
+}
+
+// name returns the name of the metric.
+//
+// This is part of the metric interface.
+func (d *desc) name() string {
+	return d.metricName
+}
+
+// writeHeader writes the help and type metadata of the metric to the provided
+// buffer.
+func (d *desc) writeHeader(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "# HELP %s %s\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(buf, "# TYPE %s %s\n", d.metricName, d.metricType)
+}
+
+// helpReplacer escapes the characters that have special meaning in help text.
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+// escapeHelp returns the provided help text escaped for the text exposition
+// format.
+func escapeHelp(help string) string {
+	return helpReplacer.Replace(help)
+}
+
+// labelReplacer escapes the characters that have special meaning in label
+// values.
+var labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// formatLabel returns the provided label name and value formatted as a label
+// pair for the text exposition format.
+func formatLabel(name, value string) string {
+	return name + `="` + labelReplacer.Replace(value) + `"`
+}
+
+// formatFloat returns the provided value formatted for the text exposition
+// format.
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// writeSample writes a single sample with the provided name, optional label
+// pairs, and value to the provided buffer.
+func writeSample(buf *bytes.Buffer, name string, labels []string, v float64) {
+	buf.WriteString(name)
+	if len(labels) > 0 {
+		buf.WriteByte('{')
+		buf.WriteString(strings.Join(labels, ","))
+		buf.WriteByte('}')
+	}
+	buf.WriteByte(' ')
+	buf.WriteString(formatFloat(v))
+	buf.WriteByte('\n')
+}
+
+// atomicFloat is a float64 that may be updated atomically.
+type atomicFloat struct {
+	bits uint64
+}
+
+// add atomically adds the provided value.
+func (f *atomicFloat) add(v float64) {
+	for {
+		old := atomic.LoadUint64(&f.bits)
+		updated := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(&f.bits, old, updated) {
+			return
+		}
+	}
+}
+
+// set atomically sets the value.
+func (f *atomicFloat) set(v float64) {
+	atomic.StoreUint64(&f.bits, math.Float64bits(v))
+}
+
+// load atomically loads the value.
+func (f *atomicFloat) load() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&f.bits))
+}
+
+// Counter is a metric whose value only ever increases.
+//
+// It is safe for concurrent access.
+type Counter struct {
+	desc
+	value atomicFloat
+}
+
+// Inc increments the counter by one.
+func (c *Counter) Inc() {
+	c.value.add(1)
+}
+
+// Add increases the counter by the provided value.  Negative values are
+// ignored since counters never decrease.
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		return
+	}
+	c.value.add(v)
+}
+
+// Value returns the current value of the counter.
+func (c *Counter) Value() float64 {
+	return c.value.load()
+}
+
+// write writes the counter to the provided buffer.
+//
+// This is part of the metric interface.
+func (c *Counter) write(buf *bytes.Buffer) {
+	c.writeHeader(buf)
+	writeSample(buf, c.metricName, nil, c.value.load())
+}
+
+// Gauge is a metric whose value may arbitrarily increase and decrease.
+//
+// It is safe for concurrent access.
+type Gauge struct {
+	desc
+	value atomicFloat
+}
+
+// Set sets the gauge to the provided value.
+func (g *Gauge) Set(v float64) {
+	g.value.set(v)
+}
+
+// Add adds the provided value, which may be negative, to the gauge.
+func (g *Gauge) Add(v float64) {
+	g.value.add(v)
+}
+
+// Value returns the current value of the gauge.
+func (g *Gauge) Value() float64 {
+	return g.value.load()
+}
+
+// write writes the gauge to the provided buffer.
+//
+// This is part of the metric interface.
+func (g *Gauge) write(buf *bytes.Buffer) {
+	g.writeHeader(buf)
+	writeSample(buf, g.metricName, nil, g.value.load())
+}
+
+// histogramData houses the observations of a histogram.
+type histogramData struct {
+	mtx     sync.Mutex
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// newHistogramData returns histogram data with the provided bucket upper
+// bounds, which must be sorted in increasing order.
+func newHistogramData(buckets []float64) *histogramData {
+	return &histogramData{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+}
+
+// observe adds the provided value to the histogram.
+func (h *histogramData) observe(v float64) {
+	idx := sort.SearchFloat64s(h.buckets, v)
+	h.mtx.Lock()
+	if idx < len(h.counts) {
+		h.counts[idx]++
+	}
+	h.sum += v
+	h.count++
+	h.mtx.Unlock()
+}
+
+// write writes the cumulative buckets, sum, and count of the histogram along
+// with the provided label pairs to the provided buffer.
+func (h *histogramData) write(buf *bytes.Buffer, name string, labels []string) {
+	h.mtx.Lock()
+	counts := make([]uint64, len(h.counts))
+	copy(counts, h.counts)
+	sum, count := h.sum, h.count
+	h.mtx.Unlock()
+
+	bucketLabels := make([]string, len(labels)+1)
+	copy(bucketLabels, labels)
+	var cumulative uint64
+	for i, bound := range h.buckets {
+		cumulative += counts[i]
+		bucketLabels[len(labels)] = formatLabel("le", formatFloat(bound))
+		writeSample(buf, name+"_bucket", bucketLabels, float64(cumulative))
+	}
+	bucketLabels[len(labels)] = formatLabel("le", "+Inf")
+	writeSample(buf, name+"_bucket", bucketLabels, float64(count))
+	writeSample(buf, name+"_sum", labels, sum)
+	writeSample(buf, name+"_count", labels, float64(count))
+}
+
+// Histogram is a metric that samples observations, such as latencies, and
+// counts them in configurable buckets along with their total sum and count.
+//
+// It is safe for concurrent access.
+type Histogram struct {
+	desc
+	data *histogramData
+}
+
+// Observe adds the provided value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	h.data.observe(v)
+}
+
+// write writes the histogram to the provided buffer.
+//
+// This is part of the metric interface.
+func (h *Histogram) write(buf *bytes.Buffer) {
+	h.writeHeader(buf)
+	h.data.write(buf, h.metricName, nil)
+}
+
+// CounterVec is a collection of counters that share a name and are
+// distinguished by the value of a single label.
+//
+// It is safe for concurrent access.
+type CounterVec struct {
+	desc
+	label    string
+	mtx      sync.Mutex
+	counters map[string]*atomicFloat
+}
+
+// Inc increments the counter for the provided label value by one.
+func (v *CounterVec) Inc(labelValue string) {
+	v.mtx.Lock()
+	counter, ok := v.counters[labelValue]
+	if !ok {
+		counter = new(atomicFloat)
+		v.counters[labelValue] = counter
+	}
+	v.mtx.Unlock()
+	counter.add(1)
+}
+
+// write writes the counters to the provided buffer sorted by label value.
+//
+// This is part of the metric interface.
+func (v *CounterVec) write(buf *bytes.Buffer) {
+	v.mtx.Lock()
+	values := make(map[string]float64, len(v.counters))
+	for labelValue, counter := range v.counters {
+		values[labelValue] = counter.load()
+	}
+	v.mtx.Unlock()
+
+	v.writeHeader(buf)
+	writeLabeledSamples(buf, v.metricName, v.label, values)
+}
+
+// writeLabeledSamples writes a sample for each of the provided label values to
+// the provided buffer sorted by label value.
+func writeLabeledSamples(buf *bytes.Buffer, name, label string, values map[string]float64) {
+	labelValues := make([]string, 0, len(values))
+	for labelValue := range values {
+		labelValues = append(labelValues, labelValue)
+	}
+	sort.Strings(labelValues)
+	for _, labelValue := range labelValues {
+		labels := []string{formatLabel(label, labelValue)}
+		writeSample(buf, name, labels, values[labelValue])
+	}
+}
+
+// HistogramVec is a collection of histograms that share a name and buckets and
+// are distinguished by the value of a single label.
+//
+// It is safe for concurrent access.
+type HistogramVec struct {
+	desc
+	label      string
+	buckets    []float64
+	mtx        sync.Mutex
+	histograms map[string]*histogramData
+}
+
+// Observe adds the provided value to the histogram for the provided label
+// value.
+func (v *HistogramVec) Observe(labelValue string, value float64) {
+	v.mtx.Lock()
+	data, ok := v.histograms[labelValue]
+	if !ok {
+		data = newHistogramData(v.buckets)
+		v.histograms[labelValue] = data
+	}
+	v.mtx.Unlock()
+	data.observe(value)
+}
+
+// write writes the histograms to the provided buffer sorted by label value.
+//
+// This is part of the metric interface.
+func (v *HistogramVec) write(buf *bytes.Buffer) {
+	v.mtx.Lock()
+	labelValues := make([]string, 0, len(v.histograms))
+	for labelValue := range v.histograms {
+		labelValues = append(labelValues, labelValue)
+	}
+	histograms := make([]*histogramData, 0, len(labelValues))
+	sort.Strings(labelValues)
+	for _, labelValue := range labelValues {
+		histograms = append(histograms, v.histograms[labelValue])
+	}
+	v.mtx.Unlock()
+
+	v.writeHeader(buf)
+	for i, data := range histograms {
+		labels := []string{formatLabel(v.label, labelValues[i])}
+		data.write(buf, v.metricName, labels)
+	}
+}
+
+// funcMetric is a counter or gauge whose value is provided by a function that
+// is invoked each time the metric is written.
+type funcMetric struct {
+	desc
+	fn func() float64
+}
+
+// write writes the current value returned by the function to the provided
+// buffer.
+//
+// This is part of the metric interface.
+func (m *funcMetric) write(buf *bytes.Buffer) {
+	m.writeHeader(buf)
+	writeSample(buf, m.metricName, nil, m.fn())
+}
+
+// funcVecMetric is a gauge whose values, which are distinguished by the value
+// of a single label, are provided by a function that is invoked each time the
+// metric is written.
+type funcVecMetric struct {
+	desc
+	label string
+	fn    func() map[string]float64
+}
+
+// write writes the current values returned by the function to the provided
+// buffer sorted by label value.
+//
+// This is part of the metric interface.
+func (m *funcVecMetric) write(buf *bytes.Buffer) {
+	m.writeHeader(buf)
+	writeLabeledSamples(buf, m.metricName, m.label, m.fn())
+}
+
+// Registry houses a set of metrics and writes them in the Prometheus text
+// exposition format.
+//
+// It is safe for concurrent access.
+type Registry struct {
+	mtx     sync.Mutex
+	metrics []metric
+	names   map[string]struct{}
+}
+
+// NewRegistry returns a new empty registry.
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]struct{})}
+}
+
+// register adds the provided metric to the registry.  It panics when a metric
+// with the same name is already registered since that is a programming error.
+func (r *Registry) register(m metric) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if _, ok := r.names[m.name()]; ok {
+		panic(fmt.Sprintf("metric %q is already registered", m.name()))
+	}
+	r.names[m.name()] = struct{}{}
+	r.metrics = append(r.metrics, m)
+}
+
+// NewCounter registers and returns a new counter with the provided name and
+// help text.
+func (r *Registry) NewCounter(name, help string) *Counter {
+	c := &Counter{desc: desc{name, help, "counter"}}
+	r.register(c)
+	return c
+}
+
+// NewGauge registers and returns a new gauge with the provided name and help
+// text.
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	g := &Gauge{desc: desc{name, help, "gauge"}}
+	r.register(g)
+	return g
+}
+
+// NewHistogram registers and returns a new histogram with the provided name,
+// help text, and bucket upper bounds, which must be sorted in increasing
+// order.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	h := &Histogram{
+		desc: desc{name, help, "histogram"},
+		data: newHistogramData(buckets),
+	}
+	r.register(h)
+	return h
+}
+
+// NewCounterVec registers and returns a new collection of counters with the
+// provided name and help text that are distinguished by the value of the
+// provided label.
+func (r *Registry) NewCounterVec(name, help, label string) *CounterVec {
+	v := &CounterVec{
+		desc:     desc{name, help, "counter"},
+		label:    label,
+		counters: make(map[string]*atomicFloat),
+	}
+	r.register(v)
+	return v
+}
+
+// NewHistogramVec registers and returns a new collection of histograms with
+// the provided name, help text, and bucket upper bounds, which must be sorted
+// in increasing order, that are distinguished by the value of the provided
+// label.
+func (r *Registry) NewHistogramVec(name, help, label string, buckets []float64) *HistogramVec {
+	v := &HistogramVec{
+		desc:       desc{name, help, "histogram"},
+		label:      label,
+		buckets:    buckets,
+		histograms: make(map[string]*histogramData),
+	}
+	r.register(v)
+	return v
+}
+
+// NewCounterFunc registers a new counter with the provided name and help text
+// whose value is obtained by invoking the provided function each time the
+// metrics are written.  The function must be safe for concurrent access.
+func (r *Registry) NewCounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{name, help, "counter"}, fn: fn})
+}
+
+// NewGaugeFunc registers a new gauge with the provided name and help text
+// whose value is obtained by invoking the provided function each time the
+// metrics are written.  The function must be safe for concurrent access.
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{desc: desc{name, help, "gauge"}, fn: fn})
+}
+
+// NewGaugeVecFunc registers a new collection of gauges with the provided name
+// and help text that are distinguished by the value of the provided label.  The
+// values of the gauges keyed by label value are obtained by invoking the
+// provided function each time the metrics are written.  The function must be
+// safe for concurrent access.
+func (r *Registry) NewGaugeVecFunc(name, help, label string, fn func() map[string]float64) {
+	r.register(&funcVecMetric{
+		desc:  desc{name, help, "gauge"},
+		label: label,
+		fn:    fn,
+	})
+}
+
+// WriteTo writes all registered metrics in the order they were registered to
+// the provided writer in the Prometheus text exposition format.
+//
+// This is part of the io.WriterTo interface.
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mtx.Lock()
+	metrics := make([]metric, len(r.metrics))
+	copy(metrics, r.metrics)
+	r.mtx.Unlock()
+
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		m.write(&buf)
+	}
+	return buf.WriteTo(w)
+}
+
+// ServeHTTP writes all registered metrics in the Prometheus text exposition
+// format in response to the provided request.
+//
+// This is part of the http.Handler interface.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed),
+			http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	r.WriteTo(w)
+}
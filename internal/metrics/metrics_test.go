@@ -0,0 +1,144 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package metrics
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestRegistryWriteTo ensures all of the metric types are written in the
+// Prometheus text exposition format as expected.
+func TestRegistryWriteTo(t *testing.T) {
+	r := NewRegistry()
+	counter := r.NewCounter("test_counter_total", "A counter.")
+	gauge := r.NewGauge("test_gauge", "A gauge with \\ and\nnewline.")
+	histogram := r.NewHistogram("test_seconds", "A histogram.",
+		[]float64{0.1, 1})
+	counterVec := r.NewCounterVec("test_requests_total", "A counter vec.",
+		"method")
+	histogramVec := r.NewHistogramVec("test_request_seconds",
+		"A histogram vec.", "method", []float64{0.5})
+	r.NewCounterFunc("test_func_total", "A counter func.", func() float64 {
+		return 42
+	})
+	r.NewGaugeFunc("test_func_gauge", "A gauge func.", func() float64 {
+		return math.Inf(1)
+	})
+	r.NewGaugeVecFunc("test_func_vec", "A gauge vec func.", "target",
+		func() map[string]float64 {
+			return map[string]float64{"b": 2, "a\"": 1}
+		})
+
+	counter.Inc()
+	counter.Add(2.5)
+	counter.Add(-1)
+	gauge.Set(10)
+	gauge.Add(-12)
+	histogram.Observe(0.05)
+	histogram.Observe(0.1)
+	histogram.Observe(0.5)
+	histogram.Observe(5)
+	counterVec.Inc("getinfo")
+	counterVec.Inc("getbestblock")
+	counterVec.Inc("getinfo")
+	histogramVec.Observe("getinfo", 0.25)
+	histogramVec.Observe("getbestblock", 1)
+
+	want := `# HELP test_counter_total A counter.
+# TYPE test_counter_total counter
+test_counter_total 3.5
+# HELP test_gauge A gauge with \\ and\nnewline.
+# TYPE test_gauge gauge
+test_gauge -2
+# HELP test_seconds A histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{le="0.1"} 2
+test_seconds_bucket{le="1"} 3
+test_seconds_bucket{le="+Inf"} 4
+test_seconds_sum 5.65
+test_seconds_count 4
+# HELP test_requests_total A counter vec.
+# TYPE test_requests_total counter
+test_requests_total{method="getbestblock"} 1
+test_requests_total{method="getinfo"} 2
+# HELP test_request_seconds A histogram vec.
+# TYPE test_request_seconds histogram
+test_request_seconds_bucket{method="getbestblock",le="0.5"} 0
+test_request_seconds_bucket{method="getbestblock",le="+Inf"} 1
+test_request_seconds_sum{method="getbestblock"} 1
+test_request_seconds_count{method="getbestblock"} 1
+test_request_seconds_bucket{method="getinfo",le="0.5"} 1
+test_request_seconds_bucket{method="getinfo",le="+Inf"} 1
+test_request_seconds_sum{method="getinfo"} 0.25
+test_request_seconds_count{method="getinfo"} 1
+# HELP test_func_total A counter func.
+# TYPE test_func_total counter
+test_func_total 42
+# HELP test_func_gauge A gauge func.
+# TYPE test_func_gauge gauge
+test_func_gauge +Inf
+# HELP test_func_vec A gauge vec func.
+# TYPE test_func_vec gauge
+test_func_vec{target="a\""} 1
+test_func_vec{target="b"} 2
+`
+	var buf bytes.Buffer
+	if _, err := r.WriteTo(&buf); err != nil {
+		t.Fatalf("unexpected error writing metrics: %v", err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("unexpected output -- got:\n%s\nwant:\n%s", got, want)
+	}
+	if counter.Value() != 3.5 || gauge.Value() != -2 {
+		t.Fatalf("unexpected values -- got counter %v, gauge %v",
+			counter.Value(), gauge.Value())
+	}
+}
+
+// TestRegistryServeHTTP ensures the registry serves the metrics over HTTP with
+// the expected content type and rejects unsupported methods.
+func TestRegistryServeHTTP(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("test_counter_total", "A counter.").Inc()
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code -- got %d, want %d", rec.Code,
+			http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != contentType {
+		t.Fatalf("unexpected content type -- got %q, want %q", got,
+			contentType)
+	}
+	if !strings.Contains(rec.Body.String(), "test_counter_total 1\n") {
+		t.Fatalf("unexpected body: %s", rec.Body.String())
+	}
+
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/metrics", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Fatalf("unexpected status code -- got %d, want %d", rec.Code,
+			http.StatusMethodNotAllowed)
+	}
+}
+
+// TestRegistryDuplicate ensures registering multiple metrics with the same
+// name panics.
+func TestRegistryDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("did not panic for duplicate metric")
+		}
+	}()
+	r := NewRegistry()
+	r.NewCounter("test_total", "A counter.")
+	r.NewGauge("test_total", "A gauge.")
+}
@@ -317,6 +317,54 @@ type BgBlkTmplGenerator struct {
 	// concurrent access by cancelTemplateMtx.
 	cancelTemplateMtx sync.Mutex
 	cancelTemplate    func()
+
+	// regenStats tracks statistics about the templates that have been
+	// generated.  It is protected for concurrent access by regenStatsMtx.
+	regenStatsMtx sync.Mutex
+	regenStats    TemplateRegenStats
+}
+
+// TemplateRegenStats houses statistics about the block templates generated by
+// the background block template generator.
+type TemplateRegenStats struct {
+	// Generated is the total number of templates that have been generated,
+	// including those that failed or were cancelled in favor of a newer one.
+	Generated uint64
+
+	// Failed is the number of templates that failed to generate.
+	Failed uint64
+
+	// TotalDuration is the cumulative time spent generating templates.
+	TotalDuration time.Duration
+
+	// LastDuration is the time spent generating the most recent template.
+	LastDuration time.Duration
+}
+
+// RegenStats returns statistics about the block templates that have been
+// generated.
+//
+// This function is safe for concurrent access.
+func (g *BgBlkTmplGenerator) RegenStats() TemplateRegenStats {
+	g.regenStatsMtx.Lock()
+	stats := g.regenStats
+	g.regenStatsMtx.Unlock()
+	return stats
+}
+
+// recordRegen updates the template generation statistics with a template that
+// took the provided amount of time to generate and whether or not it failed.
+//
+// This function is safe for concurrent access.
+func (g *BgBlkTmplGenerator) recordRegen(elapsed time.Duration, failed bool) {
+	g.regenStatsMtx.Lock()
+	g.regenStats.Generated++
+	if failed {
+		g.regenStats.Failed++
+	}
+	g.regenStats.TotalDuration += elapsed
+	g.regenStats.LastDuration = elapsed
+	g.regenStatsMtx.Unlock()
 }
 
 // NewBgBlkTmplGenerator initializes a background block template generator with
@@ -718,7 +766,9 @@ func (g *BgBlkTmplGenerator) genTemplateAsync(ctx context.Context, reason Templa
 		// pays to it.
 		prng := rand.New(rand.NewSource(time.Now().Unix()))
 		payToAddr := g.miningAddrs[prng.Intn(len(g.miningAddrs))]
+		start := time.Now()
 		template, err := g.tg.NewBlockTemplate(payToAddr)
+		g.recordRegen(time.Since(start), err != nil)
 		// NOTE: err is handled below.
 		if err != nil {
 			log.Tracef("NewBlockTemplate: %v", err)
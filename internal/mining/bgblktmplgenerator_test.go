@@ -156,3 +156,25 @@ func TestWaitGroup(t *testing.T) {
 		t.Run(tc.name, tc.test)
 	}
 }
+
+// TestRegenStats ensures the template generation statistics are tracked as
+// expected.
+func TestRegenStats(t *testing.T) {
+	var g BgBlkTmplGenerator
+	if stats := g.RegenStats(); stats != (TemplateRegenStats{}) {
+		t.Fatalf("unexpected initial stats: %+v", stats)
+	}
+
+	g.recordRegen(2*time.Second, false)
+	g.recordRegen(time.Second, true)
+	g.recordRegen(3*time.Second, false)
+	want := TemplateRegenStats{
+		Generated:     3,
+		Failed:        1,
+		TotalDuration: 6 * time.Second,
+		LastDuration:  3 * time.Second,
+	}
+	if stats := g.RegenStats(); stats != want {
+		t.Fatalf("unexpected stats -- got %+v, want %+v", stats, want)
+	}
+}
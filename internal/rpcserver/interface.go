@@ -6,6 +6,7 @@ package rpcserver
 
 import (
 	"context"
+	"io"
 	"math/big"
 	"net"
 	"time"
@@ -338,6 +339,11 @@ type Chain interface {
 	// The end height will be limited to the current main chain height.
 	HeightRange(startHeight, endHeight int64) ([]chainhash.Hash, error)
 
+	// IsBlockPruned returns whether or not the data for the block with the
+	// given hash was previously stored and has since been removed due to
+	// pruning.
+	IsBlockPruned(hash *chainhash.Hash) bool
+
 	// IsCurrent returns whether or not the chain believes it is current.  Several
 	// factors are used to guess, but the key factors that allow the chain to
 	// believe it is current are:
@@ -387,6 +393,10 @@ type Chain interface {
 	// passed block hash.
 	StateLastChangedHeight(hash *chainhash.Hash, version uint32, deploymentID string) (int64, error)
 
+	// SuspendBlockPruning prevents block data from being pruned until the
+	// returned function is invoked.
+	SuspendBlockPruning() func()
+
 	// TicketPoolValue returns the current value of all the locked funds in the
 	// ticket pool.
 	TicketPoolValue() (dcrutil.Amount, error)
@@ -414,6 +424,27 @@ type Chain interface {
 	// TSpendCountVotes returns the votes for the specified tspend up to
 	// the specified block.
 	TSpendCountVotes(*chainhash.Hash, *dcrutil.Tx) (int64, int64, error)
+
+	// UtxoCacheStats returns statistics about the current state of the utxo
+	// cache.
+	UtxoCacheStats() blockchain.UtxoCacheStats
+
+	// DumpUtxoSnapshot serializes a snapshot of the utxo set along with the
+	// ticket database state and the other chain state required to bootstrap
+	// the chain as of the current tip to the writer.
+	DumpUtxoSnapshot(w io.Writer) (*blockchain.UtxoSnapshotInfo, error)
+
+	// FetchUtxoStatsByHeight returns statistics on the utxo set as of the
+	// block at the provided height in the main chain.  The statistics are
+	// only retained for recent blocks.  An error that wraps
+	// blockchain.ErrNoUtxoStats is returned when they are not available.
+	FetchUtxoStatsByHeight(height int64) (*blockchain.UtxoStats, error)
+
+	// FetchSpentOutputs returns the previous transaction outputs spent by the
+	// block with the given hash in the main chain in the order the block
+	// spends them.  An error that wraps blockchain.ErrNoSpendJournal is
+	// returned when the spend journal for the block is not available.
+	FetchSpentOutputs(hash *chainhash.Hash) ([]blockchain.SpentOutput, error)
 }
 
 // Clock represents a clock for use with the RPC server. The purpose of this
@@ -561,6 +592,19 @@ type FiltererV2 interface {
 	// An error of type blockchain.ErrNoFilter must be returned when the filter
 	// for the given block hash does not exist.
 	FilterByBlockHash(hash *chainhash.Hash) (*gcs.FilterV2, error)
+
+	// LocateCFiltersV2 returns the version 2 GCS filters, along with the
+	// header commitment inclusion proofs for them, for the contiguous range of
+	// blocks in the main chain that starts with the given start block hash and
+	// ends with the given end block hash (inclusive).  The filters must be
+	// ordered by the height of their associated blocks.
+	//
+	// An error of type blockchain.ErrUnknownBlock must be returned when either
+	// of the blocks is not in the main chain and an error of type
+	// blockchain.ErrInvalidBlockRange must be returned when the end block is
+	// before the start block or the range contains more than the provided
+	// maximum number of blocks.
+	LocateCFiltersV2(startHash, endHash *chainhash.Hash, maxFilters uint32) ([]blockchain.FilterV2WithProof, error)
 }
 
 // ExistsAddresser represents a source of exists address methods for the RPC
@@ -612,6 +656,10 @@ type TxMempooler interface {
 	// TSpendHashes returns the hashes of the treasury spend transactions
 	// currently in the mempool.
 	TSpendHashes() []chainhash.Hash
+
+	// CheckSpend returns the transaction in the main pool that spends the
+	// passed outpoint or nil when there is none.
+	CheckSpend(op wire.OutPoint) *dcrutil.Tx
 }
 
 // AddrIndexer provides an interface for retrieving transactions for a given
@@ -636,6 +684,9 @@ type AddrIndexer interface {
 	// unconfirmed (memory-only) address index that involve the passed address.
 	// Unsupported address types are ignored and will result in no results.
 	UnconfirmedTxnsForAddress(addr dcrutil.Address) []*dcrutil.Tx
+
+	// Name returns the human-readable name of the index.
+	Name() string
 }
 
 // TxIndexer provides an interface for retrieving details for a given
@@ -649,6 +700,170 @@ type TxIndexer interface {
 	// the raw transaction bytes.  When there is no entry for the provided hash, nil
 	// must be returned for the both the entry and the error.
 	Entry(hash *chainhash.Hash) (*indexers.TxIndexEntry, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// SpendIndexer provides an interface for retrieving the transaction that spends
+// a given outpoint.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type SpendIndexer interface {
+	// Entry returns details about the transaction in the main chain that
+	// spends the provided outpoint from the spend index.  When there is no
+	// entry for the provided outpoint, nil must be returned for the both the
+	// entry and the error.
+	Entry(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// TicketIndexer provides an interface for retrieving the history of tickets
+// and the tickets that involve a given address.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type TicketIndexer interface {
+	// Entry returns the history of the provided ticket from the ticket index.
+	// When there is no entry for the provided ticket, nil must be returned
+	// for the both the entry and the error.
+	Entry(ticket *chainhash.Hash) (*indexers.TicketIndexEntry, error)
+
+	// TicketsForAddress returns the hashes of all tickets in the main chain
+	// that involve the provided address as either the voting address or one
+	// of the reward addresses ordered by the height of their purchase.
+	TicketsForAddress(addr dcrutil.Address) ([]chainhash.Hash, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// TreasuryIndexer provides an interface for retrieving the history of treasury
+// transactions in the main chain.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type TreasuryIndexer interface {
+	// Entries returns the treasury transactions in blocks with heights in the
+	// provided inclusive range ordered by their position in the chain.  Only
+	// the provided types are included unless no types are provided, in which
+	// case all types are included.  The provided number of matching
+	// transactions are skipped and at most count of them are returned.
+	Entries(startHeight, endHeight int64, txTypes []indexers.TreasuryTxType, skip, count int) ([]indexers.TreasuryHistoryEntry, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// AddrUtxoIndexer provides an interface for retrieving the unspent outputs in
+// the main chain that pay to a given address.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type AddrUtxoIndexer interface {
+	// UtxosForAddress returns all unspent outputs in the main chain that pay
+	// to the provided address.  Unsupported address types are ignored and
+	// will result in no results.
+	UtxosForAddress(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error)
+
+	// BalanceForAddress returns the total amount in atoms of the unspent
+	// outputs in the main chain that pay to the provided address along with
+	// the number of them.
+	BalanceForAddress(addr dcrutil.Address) (int64, uint32, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// BlockStatsIndexer provides an interface for retrieving statistics about the
+// blocks in the main chain.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type BlockStatsIndexer interface {
+	// Stats returns the statistics for the block at the provided height in
+	// the main chain.  When there is no entry for the provided height, nil
+	// must be returned for both the stats and the error.
+	Stats(height int64) (*indexers.BlockStats, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// NullDataIndexer provides an interface for searching the null data outputs in
+// the main chain by a prefix of their payloads.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type NullDataIndexer interface {
+	// Search returns the null data outputs with payloads that start with the
+	// provided prefix in blocks with heights in the provided inclusive range
+	// ordered by their payloads and then by their position in the chain.  The
+	// provided number of matching outputs are skipped and at most count of
+	// them are returned.
+	Search(prefix []byte, startHeight, endHeight int64, skip, count int) ([]indexers.NullDataEntry, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// ScriptHashIndexer provides an interface for retrieving the transactions and
+// unspent outputs that involve the hash of a public key script.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type ScriptHashIndexer interface {
+	// History returns the transactions in the main chain that involve the
+	// provided script hash ordered by their position in the chain.  The
+	// provided number of transactions are skipped and at most count of them
+	// are returned.
+	History(scriptHash *chainhash.Hash, skip, count int) ([]indexers.ScriptHashHistoryEntry, error)
+
+	// Balance returns the total amount in atoms of the unspent outputs in the
+	// main chain that pay to the provided script hash along with the number of
+	// them.
+	Balance(scriptHash *chainhash.Hash) (int64, uint32, error)
+
+	// Name returns the human-readable name of the index.
+	Name() string
+}
+
+// IndexSyncer provides an interface for querying the sync state of the
+// optional indexes, waiting for them to index blocks in the main chain, and
+// rewinding them so blocks are indexed again.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type IndexSyncer interface {
+	// IndexInfo returns the sync state of each of the enabled indexes.
+	IndexInfo() []indexers.IndexInfo
+
+	// WaitForHeight blocks until the enabled index with the provided name
+	// has indexed the main chain block at the provided height or the
+	// provided context is done, in which case the error from the context
+	// must be returned.
+	WaitForHeight(ctx context.Context, name string, height int64) error
+
+	// Reindex rewinds the enabled index with the provided name, along with
+	// the enabled indexes that depend on it, to the provided height so the
+	// main chain blocks after it are indexed again.  The name may also be
+	// the name of the option used to enable the index, such as addrindex.
+	Reindex(ctx context.Context, name string, height int64) error
+}
+
+// RequestObserver provides an interface for observing the requests processed
+// by the RPC server, such as to collect metrics about them.
+//
+// The interface contract requires that all of these methods are safe for
+// concurrent access.
+type RequestObserver interface {
+	// ObserveRequest is invoked after a request for the provided method has
+	// been processed with the time it took to process it and whether or not
+	// it resulted in an error.
+	ObserveRequest(method string, elapsed time.Duration, failed bool)
 }
 
 // NtfnManager provides an interface for processing and sending chain
@@ -0,0 +1,354 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrjson/v3"
+	"github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/dcrd/wire"
+)
+
+const (
+	// restPathPrefix is the path prefix under which the REST interface is
+	// served.
+	restPathPrefix = "/rest/"
+
+	// maxRESTHeaders is the maximum number of block headers that may be
+	// requested at once via the REST interface.
+	maxRESTHeaders = wire.MaxBlockHeadersPerMsg
+)
+
+// restFormat identifies the encoding of a REST response.
+type restFormat int
+
+const (
+	// restFormatJSON encodes the response as the JSON result of the
+	// equivalent RPC.
+	restFormatJSON restFormat = iota
+
+	// restFormatHex encodes the response as the hex-encoded serialized data.
+	restFormatHex
+
+	// restFormatBinary encodes the response as the raw serialized data.
+	restFormatBinary
+)
+
+// restFormatExts maps the file extensions that may be appended to the final
+// path component of a REST request to the response format they select.
+var restFormatExts = map[string]restFormat{
+	"json": restFormatJSON,
+	"hex":  restFormatHex,
+	"bin":  restFormatBinary,
+}
+
+// restHandler describes a callback function used to handle a REST request.
+// The params are the slash-separated path components following the endpoint
+// name with any format extension removed from the final one.
+//
+// The handler returns either a result that is JSON-encoded when the JSON format
+// is requested or the hex-encoded serialized data for the other formats.
+type restHandler func(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error)
+
+// restEndpoint describes a REST endpoint along with the number of path
+// parameters it requires and whether or not it supports the serialized data
+// formats in addition to JSON.
+type restEndpoint struct {
+	handler    restHandler
+	numParams  int
+	serialized bool
+}
+
+// restEndpoints maps the endpoint names served by the REST interface to their
+// definitions.
+var restEndpoints = map[string]restEndpoint{
+	"block":     {handler: handleRESTBlock, numParams: 1, serialized: true},
+	"headers":   {handler: handleRESTHeaders, numParams: 2, serialized: true},
+	"tx":        {handler: handleRESTTx, numParams: 1, serialized: true},
+	"chaininfo": {handler: handleRESTChainInfo},
+	"mempool":   {handler: handleRESTMempool, numParams: 1},
+	"cfilterv2": {handler: handleRESTCFilterV2, numParams: 1, serialized: true},
+}
+
+// restError is an error that is returned to a REST client along with the HTTP
+// status code to respond with.
+type restError struct {
+	status  int
+	message string
+}
+
+// Error satisfies the error interface and returns a human-readable error.
+func (e *restError) Error() string {
+	return e.message
+}
+
+// restErrorf returns a REST error with the given HTTP status code and formatted
+// message.
+func restErrorf(status int, format string, args ...interface{}) *restError {
+	return &restError{status: status, message: fmt.Sprintf(format, args...)}
+}
+
+// restErrorReply returns the HTTP status code and message to respond with for
+// the provided error returned from a REST handler.
+func restErrorReply(err error) (int, string) {
+	var rErr *restError
+	if errors.As(err, &rErr) {
+		return rErr.status, rErr.message
+	}
+	var rpcErr *dcrjson.RPCError
+	if errors.As(err, &rpcErr) {
+		// Note that the block not found and no transaction info codes are
+		// the same.
+		switch rpcErr.Code {
+		case dcrjson.ErrRPCBlockNotFound:
+			return http.StatusNotFound, rpcErr.Message
+		case dcrjson.ErrRPCBlockPruned:
+			return http.StatusGone, rpcErr.Message
+		case dcrjson.ErrRPCDecodeHexString, dcrjson.ErrRPCInvalidParameter:
+			return http.StatusBadRequest, rpcErr.Message
+		}
+		return http.StatusInternalServerError, rpcErr.Message
+	}
+	return http.StatusInternalServerError, err.Error()
+}
+
+// parseRESTPath splits the provided REST request path into the endpoint name,
+// its parameters, and the requested response format.  The format defaults to
+// JSON when the final path component does not have a format extension.
+func parseRESTPath(path string) (string, []string, restFormat, error) {
+	components := strings.Split(strings.TrimPrefix(path, restPathPrefix), "/")
+	last := components[len(components)-1]
+	format := restFormatJSON
+	if i := strings.LastIndexByte(last, '.'); i != -1 {
+		var ok bool
+		format, ok = restFormatExts[last[i+1:]]
+		if !ok {
+			return "", nil, 0, restErrorf(http.StatusNotFound,
+				"unsupported format %q", last[i+1:])
+		}
+		components[len(components)-1] = last[:i]
+	}
+	return components[0], components[1:], format, nil
+}
+
+// restHashParam decodes the provided hash parameter of a REST request.
+func restHashParam(param string) (*chainhash.Hash, error) {
+	hash, err := chainhash.NewHashFromStr(param)
+	if err != nil || len(param) != chainhash.MaxHashStringSize {
+		return nil, restErrorf(http.StatusBadRequest, "invalid hash: %q",
+			param)
+	}
+	return hash, nil
+}
+
+// handleRESTBlock implements the /rest/block/<hash> endpoint.
+func handleRESTBlock(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error) {
+	hash, err := restHashParam(params[0])
+	if err != nil {
+		return nil, err
+	}
+	verbose := format == restFormatJSON
+	cmd := types.NewGetBlockCmd(hash.String(), &verbose, &verbose)
+	return handleGetBlock(ctx, s, cmd)
+}
+
+// handleRESTHeaders implements the /rest/headers/<count>/<hash> endpoint.  It
+// returns up to the requested number of main chain headers starting with the
+// header of the provided block.  Only the header of the provided block is
+// returned when it is not part of the main chain.
+func handleRESTHeaders(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error) {
+	count, err := strconv.Atoi(params[0])
+	if err != nil || count < 1 || count > maxRESTHeaders {
+		return nil, restErrorf(http.StatusBadRequest, "header count must "+
+			"be between 1 and %d: %q", maxRESTHeaders, params[0])
+	}
+	hash, err := restHashParam(params[1])
+	if err != nil {
+		return nil, err
+	}
+
+	chain := s.cfg.Chain
+	header, err := chain.HeaderByHash(hash)
+	if err != nil {
+		return nil, rpcBlockNotFoundError(*hash)
+	}
+	hashes := []chainhash.Hash{*hash}
+	if chain.MainChainHasBlock(hash) {
+		startHeight := int64(header.Height)
+		hashes, err = chain.HeightRange(startHeight, startHeight+int64(count))
+		if err != nil {
+			context := "Failed to fetch block hashes"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+	}
+
+	// Reuse the getblockheader handler to produce the results so they are
+	// identical to those of the RPC.
+	verbose := format == restFormatJSON
+	results := make([]interface{}, 0, len(hashes))
+	var serialized strings.Builder
+	for i := range hashes {
+		cmd := types.NewGetBlockHeaderCmd(hashes[i].String(), &verbose)
+		result, err := handleGetBlockHeader(ctx, s, cmd)
+		if err != nil {
+			return nil, err
+		}
+		if !verbose {
+			serialized.WriteString(result.(string))
+			continue
+		}
+		results = append(results, result)
+	}
+	if !verbose {
+		return serialized.String(), nil
+	}
+	return results, nil
+}
+
+// handleRESTTx implements the /rest/tx/<hash> endpoint.
+func handleRESTTx(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error) {
+	hash, err := restHashParam(params[0])
+	if err != nil {
+		return nil, err
+	}
+
+	// Avoid logging an internal error for every request for a transaction
+	// that is not in the mempool when the transaction index is not enabled
+	// since the requests are not authenticated.
+	if s.cfg.TxIndexer == nil {
+		if _, err := s.cfg.TxMempooler.FetchTransaction(hash); err != nil {
+			return nil, restErrorf(http.StatusNotFound, "transaction %v is "+
+				"not in the mempool and the transaction index is not "+
+				"enabled", hash)
+		}
+	}
+
+	var verbose int
+	if format == restFormatJSON {
+		verbose = 1
+	}
+	cmd := types.NewGetRawTransactionCmd(hash.String(), &verbose)
+	return handleGetRawTransaction(ctx, s, cmd)
+}
+
+// handleRESTChainInfo implements the /rest/chaininfo endpoint.
+func handleRESTChainInfo(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error) {
+	return handleGetBlockchainInfo(ctx, s, types.NewGetBlockChainInfoCmd())
+}
+
+// handleRESTMempool implements the /rest/mempool/info endpoint.
+func handleRESTMempool(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error) {
+	if params[0] != "info" {
+		return nil, restErrorf(http.StatusNotFound, "unknown endpoint")
+	}
+	return handleGetMempoolInfo(ctx, s, types.NewGetMempoolInfoCmd())
+}
+
+// handleRESTCFilterV2 implements the /rest/cfilterv2/<hash> endpoint.
+func handleRESTCFilterV2(ctx context.Context, s *Server, params []string, format restFormat) (interface{}, error) {
+	hash, err := restHashParam(params[0])
+	if err != nil {
+		return nil, err
+	}
+	result, err := handleGetCFilterV2(ctx, s,
+		types.NewGetCFilterV2Cmd(hash.String()))
+	if err != nil {
+		return nil, err
+	}
+	if format != restFormatJSON {
+		return result.(*types.GetCFilterV2Result).Data, nil
+	}
+	return result, nil
+}
+
+// handleREST serves a read-only REST request.  Unlike JSON-RPC requests, REST
+// requests are not authenticated, so only information that is already
+// publicly available on the network is served.
+func (s *Server) handleREST(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	// Limit the number of connections to max allowed.
+	if s.limitConnections(w, r.RemoteAddr) {
+		return
+	}
+	s.incrementClients()
+	defer s.decrementClients()
+
+	result, format, err := s.processRESTRequest(ctx, r.URL.Path)
+	if err != nil {
+		status, message := restErrorReply(err)
+		log.Debugf("REST request %s from %s failed: %v", r.URL.Path,
+			r.RemoteAddr, err)
+		http.Error(w, message, status)
+		return
+	}
+
+	var body []byte
+	switch format {
+	case restFormatJSON:
+		body, err = json.Marshal(result)
+		if err != nil {
+			log.Errorf("Failed to marshal REST reply: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+
+	case restFormatHex:
+		body = []byte(result.(string) + "\n")
+		w.Header().Set("Content-Type", "text/plain")
+
+	case restFormatBinary:
+		body, err = hex.DecodeString(result.(string))
+		if err != nil {
+			log.Errorf("Failed to decode REST reply: %v", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := w.Write(body); err != nil {
+		log.Errorf("Failed to write REST reply: %v", err)
+	}
+}
+
+// processRESTRequest parses the provided REST request path and invokes the
+// handler for the requested endpoint.  It returns the result along with the
+// format it is to be encoded with.
+func (s *Server) processRESTRequest(ctx context.Context, path string) (interface{}, restFormat, error) {
+	name, params, format, err := parseRESTPath(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	endpoint, ok := restEndpoints[name]
+	if !ok || len(params) != endpoint.numParams {
+		return nil, 0, restErrorf(http.StatusNotFound, "unknown endpoint")
+	}
+	if format != restFormatJSON && !endpoint.serialized {
+		return nil, 0, restErrorf(http.StatusNotFound, "unsupported format")
+	}
+	result, err := endpoint.handler(ctx, s, params, format)
+	if err != nil {
+		return nil, 0, err
+	}
+	return result, format, nil
+}
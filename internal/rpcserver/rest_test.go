@@ -0,0 +1,286 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/decred/dcrd/blockchain/v4"
+	"github.com/decred/dcrd/chaincfg/chainhash"
+	"github.com/decred/dcrd/dcrutil/v3"
+	"github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+)
+
+// TestREST ensures the REST interface serves the expected responses and status
+// codes for both valid and invalid requests.
+func TestREST(t *testing.T) {
+	t.Parallel()
+
+	blk := dcrutil.NewBlock(&block432100)
+	blkHash := blk.Hash().String()
+	blkBytes, err := blk.Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error serializing block: %v", err)
+	}
+	var headerBuf bytes.Buffer
+	if err := block432100.Header.Serialize(&headerBuf); err != nil {
+		t.Fatalf("unexpected error serializing header: %v", err)
+	}
+	tx := dcrutil.NewTx(block432100.Transactions[0])
+	txBytes, err := tx.MsgTx().Bytes()
+	if err != nil {
+		t.Fatalf("unexpected error serializing tx: %v", err)
+	}
+	filter := defaultMockFiltererV2().filterByBlockHash.Bytes()
+
+	cfg := defaultMockConfig(defaultChainParams)
+	cfg.EnableREST = true
+	cfg.RPCMaxClients = 10
+	chain := defaultMockRPCChain()
+	chain.heightRangeFn = func(startHeight, endHeight int64) ([]chainhash.Hash, error) {
+		return []chainhash.Hash{*blk.Hash(), *blk.Hash()}, nil
+	}
+	cfg.Chain = chain
+	cfg.TxMempooler = &testTxMempooler{fetchTransaction: tx}
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	handler := s.route(context.Background()).Handler
+
+	// Create a server with a chain that does not have any blocks and no
+	// transaction index.
+	missingCfg := defaultMockConfig(defaultChainParams)
+	missingCfg.EnableREST = true
+	missingCfg.RPCMaxClients = 10
+	missingChain := defaultMockRPCChain()
+	missingChain.blockByHashErr = errors.New("block not found")
+	missingChain.headerByHashErr = errors.New("block not found")
+	missingCfg.Chain = missingChain
+	missingCfg.TxIndexer = nil
+	missingS, err := New(missingCfg)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	missingHandler := missingS.route(context.Background()).Handler
+
+	// Create a server with a chain that has pruned the block data.
+	prunedCfg := defaultMockConfig(defaultChainParams)
+	prunedCfg.EnableREST = true
+	prunedCfg.RPCMaxClients = 10
+	prunedChain := defaultMockRPCChain()
+	prunedChain.blockByHashErr = blockchain.ErrBlockPruned
+	prunedCfg.Chain = prunedChain
+	prunedS, err := New(prunedCfg)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	prunedHandler := prunedS.route(context.Background()).Handler
+
+	tests := []struct {
+		name       string
+		handler    http.Handler
+		method     string
+		path       string
+		wantStatus int
+		wantType   string
+		wantBody   []byte
+	}{{
+		name:       "block bin",
+		path:       "/rest/block/" + blkHash + ".bin",
+		wantStatus: http.StatusOK,
+		wantType:   "application/octet-stream",
+		wantBody:   blkBytes,
+	}, {
+		name:       "block hex",
+		path:       "/rest/block/" + blkHash + ".hex",
+		wantStatus: http.StatusOK,
+		wantType:   "text/plain",
+		wantBody:   []byte(hex.EncodeToString(blkBytes) + "\n"),
+	}, {
+		name:       "block json",
+		path:       "/rest/block/" + blkHash + ".json",
+		wantStatus: http.StatusOK,
+		wantType:   "application/json",
+	}, {
+		name:       "block head",
+		method:     http.MethodHead,
+		path:       "/rest/block/" + blkHash + ".bin",
+		wantStatus: http.StatusOK,
+		wantType:   "application/octet-stream",
+		wantBody:   []byte{},
+	}, {
+		name:       "headers bin",
+		path:       "/rest/headers/2/" + blkHash + ".bin",
+		wantStatus: http.StatusOK,
+		wantType:   "application/octet-stream",
+		wantBody: append(append([]byte{}, headerBuf.Bytes()...),
+			headerBuf.Bytes()...),
+	}, {
+		name:       "headers json",
+		path:       "/rest/headers/2/" + blkHash,
+		wantStatus: http.StatusOK,
+		wantType:   "application/json",
+	}, {
+		name:       "tx bin",
+		path:       "/rest/tx/" + tx.Hash().String() + ".bin",
+		wantStatus: http.StatusOK,
+		wantType:   "application/octet-stream",
+		wantBody:   txBytes,
+	}, {
+		name:       "chaininfo",
+		path:       "/rest/chaininfo",
+		wantStatus: http.StatusOK,
+		wantType:   "application/json",
+	}, {
+		name:       "mempool info",
+		path:       "/rest/mempool/info.json",
+		wantStatus: http.StatusOK,
+		wantType:   "application/json",
+		wantBody:   []byte(`{"size":0,"bytes":0}`),
+	}, {
+		name:       "cfilterv2 bin",
+		path:       "/rest/cfilterv2/" + blkHash + ".bin",
+		wantStatus: http.StatusOK,
+		wantType:   "application/octet-stream",
+		wantBody:   filter,
+	}, {
+		name:       "invalid hash",
+		path:       "/rest/block/1234.bin",
+		wantStatus: http.StatusBadRequest,
+	}, {
+		name:       "header count too high",
+		path:       "/rest/headers/2001/" + blkHash + ".bin",
+		wantStatus: http.StatusBadRequest,
+	}, {
+		name:       "header count zero",
+		path:       "/rest/headers/0/" + blkHash + ".bin",
+		wantStatus: http.StatusBadRequest,
+	}, {
+		name:       "unknown format",
+		path:       "/rest/block/" + blkHash + ".xml",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "unsupported format",
+		path:       "/rest/chaininfo.bin",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "unknown endpoint",
+		path:       "/rest/utxos/" + blkHash,
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "unknown mempool endpoint",
+		path:       "/rest/mempool/contents",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "missing params",
+		path:       "/rest/block",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "post",
+		method:     http.MethodPost,
+		path:       "/rest/chaininfo",
+		wantStatus: http.StatusMethodNotAllowed,
+	}, {
+		name:       "block not found",
+		handler:    missingHandler,
+		path:       "/rest/block/" + blkHash + ".bin",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "headers not found",
+		handler:    missingHandler,
+		path:       "/rest/headers/1/" + blkHash + ".bin",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "tx not found without txindex",
+		handler:    missingHandler,
+		path:       "/rest/tx/" + tx.Hash().String() + ".bin",
+		wantStatus: http.StatusNotFound,
+	}, {
+		name:       "block pruned",
+		handler:    prunedHandler,
+		path:       "/rest/block/" + blkHash + ".bin",
+		wantStatus: http.StatusGone,
+	}}
+
+	for _, test := range tests {
+		h := test.handler
+		if h == nil {
+			h = handler
+		}
+		method := test.method
+		if method == "" {
+			method = http.MethodGet
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, test.path, nil))
+		if rec.Code != test.wantStatus {
+			t.Errorf("%q: unexpected status code -- got %d, want %d (%s)",
+				test.name, rec.Code, test.wantStatus, rec.Body.String())
+			continue
+		}
+		if test.wantType != "" {
+			if got := rec.Header().Get("Content-Type"); got != test.wantType {
+				t.Errorf("%q: unexpected content type -- got %q, want %q",
+					test.name, got, test.wantType)
+				continue
+			}
+		}
+		if test.wantBody != nil && !bytes.Equal(rec.Body.Bytes(), test.wantBody) {
+			t.Errorf("%q: unexpected body -- got %x, want %x", test.name,
+				rec.Body.Bytes(), test.wantBody)
+		}
+	}
+
+	// Ensure the JSON results decode as the result types of the equivalent RPCs.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/rest/headers/2/"+blkHash+".json", nil))
+	var headers []types.GetBlockHeaderVerboseResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &headers); err != nil {
+		t.Fatalf("unexpected error decoding headers: %v", err)
+	}
+	if len(headers) != 2 || headers[0].Hash != blkHash {
+		t.Fatalf("unexpected headers result: %+v", headers)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet,
+		"/rest/block/"+blkHash+".json", nil))
+	var block types.GetBlockVerboseResult
+	if err := json.Unmarshal(rec.Body.Bytes(), &block); err != nil {
+		t.Fatalf("unexpected error decoding block: %v", err)
+	}
+	if block.Hash != blkHash || len(block.RawTx) != len(blk.Transactions()) {
+		t.Fatalf("unexpected block result: hash %s, %d txns", block.Hash,
+			len(block.RawTx))
+	}
+}
+
+// TestRESTDisabled ensures the REST interface is not served without
+// authentication when it is not enabled.
+func TestRESTDisabled(t *testing.T) {
+	t.Parallel()
+
+	cfg := defaultMockConfig(defaultChainParams)
+	cfg.RPCMaxClients = 10
+	s, err := New(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	rec := httptest.NewRecorder()
+	s.route(context.Background()).Handler.ServeHTTP(rec,
+		httptest.NewRequest(http.MethodGet, "/rest/chaininfo", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected status code -- got %d, want %d", rec.Code,
+			http.StatusUnauthorized)
+	}
+}
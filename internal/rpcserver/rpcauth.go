@@ -0,0 +1,641 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// allMethodsEntry is the method entry in a users file that authorizes a
+	// user to call all methods, which is the same access the admin user has.
+	allMethodsEntry = "*"
+
+	// groupEntryPrefix is the prefix of the method entries in a users file
+	// that refer to a group of methods rather than a single method.
+	groupEntryPrefix = "@"
+
+	// limitedGroupName is the name of the builtin group that houses the
+	// methods the limited user is authorized to call.
+	limitedGroupName = "limited"
+
+	// cookieUsername is the username of the user whose randomly generated
+	// credentials are written to the cookie file.
+	cookieUsername = "__cookie__"
+
+	// cookiePassLen is the number of random bytes used for the password of
+	// the cookie user.
+	cookiePassLen = 32
+
+	// maxBcryptCost is the maximum cost of the bcrypt password hashes that
+	// are accepted.  It limits the time a single authentication attempt is
+	// able to consume.
+	maxBcryptCost = 14
+
+	// maxArgon2Memory, maxArgon2Time, and maxArgon2Threads are the maximum
+	// memory in KiB, number of passes, and parallelism of the argon2
+	// password hashes that are accepted.  They limit the memory and time a
+	// single authentication attempt is able to consume.
+	maxArgon2Memory  = 256 * 1024
+	maxArgon2Time    = 16
+	maxArgon2Threads = 16
+
+	// maxArgon2SaltLen and maxArgon2KeyLen are the maximum lengths of the
+	// salt and key of the argon2 password hashes that are accepted.
+	maxArgon2SaltLen = 64
+	maxArgon2KeyLen  = 64
+
+	// maxConcurrentHashChecks is the maximum number of password hash checks
+	// that are performed at the same time.  Additional authentication
+	// attempts wait for one of the in progress checks to complete.
+	maxConcurrentHashChecks = 2
+
+	// authFailureBaseDelay and authFailureMaxDelay are the initial and
+	// maximum amount of time password hash checks are refused for a remote
+	// host after a failed attempt.  The delay doubles with every consecutive
+	// failure.
+	authFailureBaseDelay = time.Second
+	authFailureMaxDelay  = time.Minute
+
+	// maxAuthFailureHosts is the maximum number of remote hosts that failed
+	// authentication attempts are tracked for.
+	maxAuthFailureHosts = 4096
+)
+
+// rpcUser houses the name of an authenticated RPC user along with the methods
+// it is authorized to call.
+type rpcUser struct {
+	name    string
+	isAdmin bool
+	methods map[string]struct{}
+}
+
+// authorized returns whether or not the user is authorized to call the
+// provided method.
+func (u *rpcUser) authorized(method string) bool {
+	if u.isAdmin {
+		return true
+	}
+	_, ok := u.methods[method]
+	return ok
+}
+
+// hashedUser houses an RPC user provided by a users file along with the hash
+// of its password.
+type hashedUser struct {
+	rpcUser
+	passwordHash string
+}
+
+// User houses the credentials of an additional RPC user, such as one loaded
+// from a users file, along with the methods it is authorized to call.
+type User struct {
+	// Name is the username of the user.
+	Name string
+
+	// PasswordHash is the bcrypt or argon2 hash of the password of the user
+	// in modular crypt format.
+	PasswordHash string
+
+	// Methods are the names of the methods the user is authorized to call.
+	// The user is authorized to call all methods when it contains "*".
+	Methods []string
+}
+
+// usersFile describes the format of a users file.
+//
+// Groups are optional named sets of methods which may be referenced from the
+// methods of a user by prefixing the group name with "@".  The builtin
+// "limited" group houses the methods the limited user is authorized to call.
+type usersFile struct {
+	Groups map[string][]string `json:"groups"`
+	Users  []struct {
+		Username     string   `json:"username"`
+		PasswordHash string   `json:"passwordhash"`
+		Methods      []string `json:"methods"`
+	} `json:"users"`
+}
+
+// isKnownMethod returns whether or not the provided method is handled by the
+// RPC server.
+func isKnownMethod(method string) bool {
+	if _, ok := rpcHandlers[types.Method(method)]; ok {
+		return true
+	}
+	_, ok := wsHandlers[types.Method(method)]
+	return ok
+}
+
+// ParseUsers parses the RPC users, the hashes of their passwords, and the
+// methods they are authorized to call from the provided JSON-encoded users
+// file.  References to groups of methods are expanded into the methods they
+// contain.
+func ParseUsers(data []byte) ([]User, error) {
+	var file usersFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	// Ensure the groups only contain known methods.
+	groups := make(map[string][]string, len(file.Groups)+1)
+	for name, methods := range file.Groups {
+		if name == limitedGroupName {
+			return nil, fmt.Errorf("group %q is builtin and may not be "+
+				"redefined", name)
+		}
+		for _, method := range methods {
+			if !isKnownMethod(method) {
+				return nil, fmt.Errorf("group %q contains unknown method %q",
+					name, method)
+			}
+		}
+		groups[name] = methods
+	}
+	limited := make([]string, 0, len(rpcLimited))
+	for method := range rpcLimited {
+		limited = append(limited, method)
+	}
+	groups[limitedGroupName] = limited
+
+	users := make([]User, 0, len(file.Users))
+	seen := make(map[string]struct{}, len(file.Users))
+	for _, fileUser := range file.Users {
+		name := fileUser.Username
+		if name == "" {
+			return nil, errors.New("user with empty username")
+		}
+		if _, ok := seen[name]; ok {
+			return nil, fmt.Errorf("duplicate user %q", name)
+		}
+		seen[name] = struct{}{}
+		if err := validatePasswordHash(fileUser.PasswordHash); err != nil {
+			return nil, fmt.Errorf("user %q: %w", name, err)
+		}
+
+		// Expand the groups and ensure the remaining entries are known
+		// methods.
+		var methods []string
+		for _, entry := range fileUser.Methods {
+			switch {
+			case entry == allMethodsEntry:
+				methods = append(methods, entry)
+
+			case strings.HasPrefix(entry, groupEntryPrefix):
+				groupName := entry[len(groupEntryPrefix):]
+				group, ok := groups[groupName]
+				if !ok {
+					return nil, fmt.Errorf("user %q refers to unknown "+
+						"group %q", name, groupName)
+				}
+				methods = append(methods, group...)
+
+			case isKnownMethod(entry):
+				methods = append(methods, entry)
+
+			default:
+				return nil, fmt.Errorf("user %q refers to unknown method %q",
+					name, entry)
+			}
+		}
+		if len(methods) == 0 {
+			return nil, fmt.Errorf("user %q is not authorized to call any "+
+				"methods", name)
+		}
+
+		users = append(users, User{
+			Name:         name,
+			PasswordHash: fileUser.PasswordHash,
+			Methods:      methods,
+		})
+	}
+
+	return users, nil
+}
+
+// LoadUsersFile loads the RPC users, the hashes of their passwords, and the
+// methods they are authorized to call from the JSON-encoded users file at the
+// provided path.  See ParseUsers for more details.
+func LoadUsersFile(path string) ([]User, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	users, err := ParseUsers(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid RPC users file %s: %w", path, err)
+	}
+	return users, nil
+}
+
+// writeCookieFile generates a random password for the cookie user and writes
+// the credentials to the file at the provided path in the form
+// <username>:<password> so local RPC clients can authenticate by reading it.
+// The file is only readable by the current user.  The generated password is
+// returned.
+func writeCookieFile(path string) (string, error) {
+	var passBytes [cookiePassLen]byte
+	if _, err := rand.Read(passBytes[:]); err != nil {
+		return "", err
+	}
+	pass := hex.EncodeToString(passBytes[:])
+
+	// Write the credentials to a temporary file that is then renamed over the
+	// cookie file so an existing cookie file with different permissions is
+	// replaced and clients never observe a partially written file.
+	tmpPath := path + ".tmp"
+	os.Remove(tmpPath)
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return "", err
+	}
+	_, err = file.WriteString(cookieUsername + ":" + pass)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	return pass, nil
+}
+
+// argon2Hash houses the parameters, salt, and key of an argon2 password hash.
+type argon2Hash struct {
+	variant string
+	memory  uint32
+	time    uint32
+	threads uint8
+	salt    []byte
+	key     []byte
+}
+
+// parseArgon2Hash parses an argon2i or argon2id password hash in the modular
+// crypt format, such as:
+//
+//	$argon2id$v=19$m=65536,t=3,p=4$<base64 salt>$<base64 key>
+func parseArgon2Hash(hash string) (*argon2Hash, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[0] != "" {
+		return nil, errors.New("malformed argon2 password hash")
+	}
+
+	h := argon2Hash{variant: parts[1]}
+	if h.variant != "argon2i" && h.variant != "argon2id" {
+		return nil, fmt.Errorf("unsupported argon2 variant %q", h.variant)
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return nil, fmt.Errorf("malformed argon2 version: %w", err)
+	}
+	if version != argon2.Version {
+		return nil, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+	_, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &h.memory, &h.time,
+		&h.threads)
+	if err != nil {
+		return nil, fmt.Errorf("malformed argon2 parameters: %w", err)
+	}
+	if h.time == 0 || h.threads == 0 {
+		return nil, errors.New("argon2 time and parallelism must not be zero")
+	}
+	if h.memory > maxArgon2Memory || h.time > maxArgon2Time ||
+		h.threads > maxArgon2Threads {
+
+		return nil, fmt.Errorf("argon2 parameters exceed the maximum of "+
+			"m=%d,t=%d,p=%d", maxArgon2Memory, maxArgon2Time,
+			maxArgon2Threads)
+	}
+	h.salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return nil, fmt.Errorf("malformed argon2 salt: %w", err)
+	}
+	if len(h.salt) > maxArgon2SaltLen {
+		return nil, fmt.Errorf("argon2 salt exceeds the maximum length of "+
+			"%d bytes", maxArgon2SaltLen)
+	}
+	h.key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return nil, fmt.Errorf("malformed argon2 key: %w", err)
+	}
+	if len(h.key) == 0 {
+		return nil, errors.New("empty argon2 key")
+	}
+	if len(h.key) > maxArgon2KeyLen {
+		return nil, fmt.Errorf("argon2 key exceeds the maximum length of "+
+			"%d bytes", maxArgon2KeyLen)
+	}
+	return &h, nil
+}
+
+// deriveKey returns the key derived from the provided password and salt using
+// the variant and parameters of the hash.
+func (h *argon2Hash) deriveKey(password, salt []byte, keyLen uint32) []byte {
+	if h.variant == "argon2i" {
+		return argon2.Key(password, salt, h.time, h.memory, h.threads,
+			keyLen)
+	}
+	return argon2.IDKey(password, salt, h.time, h.memory, h.threads, keyLen)
+}
+
+// String returns the hash in the modular crypt format.
+func (h *argon2Hash) String() string {
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", h.variant,
+		argon2.Version, h.memory, h.time, h.threads,
+		base64.RawStdEncoding.EncodeToString(h.salt),
+		base64.RawStdEncoding.EncodeToString(h.key))
+}
+
+// isBcryptHash returns whether or not the provided password hash is a bcrypt
+// hash based on its prefix.
+func isBcryptHash(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+// validatePasswordHash returns an error when the provided password hash is not
+// a well-formed bcrypt or argon2 hash or its parameters exceed the maximums
+// that are accepted.
+func validatePasswordHash(hash string) error {
+	if isBcryptHash(hash) {
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return err
+		}
+		if cost > maxBcryptCost {
+			return fmt.Errorf("bcrypt cost %d exceeds the maximum of %d",
+				cost, maxBcryptCost)
+		}
+		return nil
+	}
+	if strings.HasPrefix(hash, "$argon2") {
+		_, err := parseArgon2Hash(hash)
+		return err
+	}
+	return errors.New("password hash is not a bcrypt or argon2 hash")
+}
+
+// checkPasswordHash returns whether or not the provided password matches the
+// provided bcrypt or argon2 password hash.  Hashes with parameters that exceed
+// the maximums that are accepted never match.
+func checkPasswordHash(hash, password string) bool {
+	if err := validatePasswordHash(hash); err != nil {
+		return false
+	}
+	if isBcryptHash(hash) {
+		err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+		return err == nil
+	}
+
+	h, err := parseArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+	key := h.deriveKey([]byte(password), h.salt, uint32(len(h.key)))
+	return subtle.ConstantTimeCompare(key, h.key) == 1
+}
+
+// dummyPasswordHash returns the hash of a random password that uses the same
+// algorithm and parameters as the provided password hash.  Checking passwords
+// for unknown users against it takes about as long as checking them for a
+// known user, which prevents enumerating the usernames via timing.
+func dummyPasswordHash(hash string) (string, error) {
+	var password [32]byte
+	if _, err := rand.Read(password[:]); err != nil {
+		return "", err
+	}
+	if isBcryptHash(hash) {
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return "", err
+		}
+		dummy, err := bcrypt.GenerateFromPassword(password[:], cost)
+		return string(dummy), err
+	}
+
+	h, err := parseArgon2Hash(hash)
+	if err != nil {
+		return "", err
+	}
+	salt := make([]byte, len(h.salt))
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	dummy := *h
+	dummy.salt = salt
+	dummy.key = h.deriveKey(password[:], salt, uint32(len(h.key)))
+	return dummy.String(), nil
+}
+
+// parseBasicAuth parses the username and password from the provided HTTP
+// Basic authentication header.
+func parseBasicAuth(auth string) (string, string, bool) {
+	const prefix = "Basic "
+	if len(auth) < len(prefix) || !strings.EqualFold(auth[:len(prefix)],
+		prefix) {
+
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(auth[len(prefix):])
+	if err != nil {
+		return "", "", false
+	}
+	creds := string(decoded)
+	sep := strings.IndexByte(creds, ':')
+	if sep < 0 {
+		return "", "", false
+	}
+	return creds[:sep], creds[sep+1:], true
+}
+
+// authFailure tracks the consecutive failed password hash checks of a remote
+// host along with the time password hash checks are refused until.
+type authFailure struct {
+	count      uint32
+	retryAfter time.Time
+}
+
+// remoteHost returns the host portion of the provided remote address.  The
+// address is returned unmodified when it does not include a port.
+func remoteHost(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// hashCheckAllowed returns whether or not password hash checks are currently
+// allowed for the provided remote host based on its recent failures.
+//
+// This function is safe for concurrent access.
+func (s *Server) hashCheckAllowed(host string) bool {
+	s.authFailuresMtx.Lock()
+	failure, ok := s.authFailures[host]
+	s.authFailuresMtx.Unlock()
+	return !ok || !time.Now().Before(failure.retryAfter)
+}
+
+// recordAuthFailure records a failed password hash check for the provided
+// remote host and refuses further checks for it for an exponentially
+// increasing amount of time.
+//
+// This function is safe for concurrent access.
+func (s *Server) recordAuthFailure(host string) {
+	now := time.Now()
+	s.authFailuresMtx.Lock()
+	defer s.authFailuresMtx.Unlock()
+
+	failure, ok := s.authFailures[host]
+	if !ok {
+		// Make room for the new host by removing the hosts that are no
+		// longer being refused when the max number of hosts are tracked.
+		if len(s.authFailures) >= maxAuthFailureHosts {
+			for h, f := range s.authFailures {
+				if !now.Before(f.retryAfter) {
+					delete(s.authFailures, h)
+				}
+			}
+			if len(s.authFailures) >= maxAuthFailureHosts {
+				return
+			}
+		}
+		failure = &authFailure{}
+		s.authFailures[host] = failure
+	}
+	delay := authFailureMaxDelay
+	if failure.count < 16 {
+		delay = authFailureBaseDelay << failure.count
+		if delay > authFailureMaxDelay {
+			delay = authFailureMaxDelay
+		}
+	}
+	failure.count++
+	failure.retryAfter = now.Add(delay)
+}
+
+// clearAuthFailures removes the failed password hash checks recorded for the
+// provided remote host.
+//
+// This function is safe for concurrent access.
+func (s *Server) clearAuthFailures(host string) {
+	s.authFailuresMtx.Lock()
+	delete(s.authFailures, host)
+	s.authFailuresMtx.Unlock()
+}
+
+// authenticate returns the RPC user identified by the provided HTTP Basic
+// authentication header sent from the provided remote address or nil when it
+// does not match the credentials of any user.
+//
+// The credentials of the admin, limited, and cookie users are checked in
+// constant time while the credentials of the additional users are checked
+// against the hash of their password.  Since checking password hashes is
+// intentionally expensive, the MAC of the header is cached once it matches so
+// later requests with the same credentials are fast.
+//
+// Password hash checks are also protected against abuse.  Unknown usernames are
+// checked against a dummy hash so they take as long as known ones, at most
+// maxConcurrentHashChecks checks run at the same time, and checks for a remote
+// host are refused for an increasing amount of time after each failure.
+//
+// This function is safe for concurrent access.
+func (s *Server) authenticate(auth, remoteAddr string) *rpcUser {
+	var mac [sha256.Size]byte
+	s.authMAC(mac[:0], []byte(auth))
+	cmp := subtle.ConstantTimeCompare(mac[:], s.authsha[:])
+	limitcmp := subtle.ConstantTimeCompare(mac[:], s.limitauthsha[:])
+	cookiecmp := subtle.ConstantTimeCompare(mac[:], s.cookieauthsha[:])
+	switch {
+	case cmp == 1:
+		return s.adminUser
+	case limitcmp == 1:
+		return s.limitUser
+	case cookiecmp == 1 && s.cookieUser != nil:
+		return s.cookieUser
+	case len(s.users) == 0:
+		return nil
+	}
+
+	s.authCacheMtx.Lock()
+	user, ok := s.authCache[mac]
+	s.authCacheMtx.Unlock()
+	if ok {
+		return user
+	}
+
+	name, password, ok := parseBasicAuth(auth)
+	if !ok {
+		return nil
+	}
+	host := remoteHost(remoteAddr)
+	if !s.hashCheckAllowed(host) {
+		log.Debugf("Refusing RPC password check from %s due to recent "+
+			"failures", remoteAddr)
+		return nil
+	}
+	hashed, known := s.users[name]
+	passwordHash := s.dummyPasswordHash
+	if known {
+		passwordHash = hashed.passwordHash
+	}
+	s.hashCheckSem <- struct{}{}
+	match := checkPasswordHash(passwordHash, password)
+	<-s.hashCheckSem
+	if !known || !match {
+		s.recordAuthFailure(host)
+		return nil
+	}
+	s.clearAuthFailures(host)
+
+	s.authCacheMtx.Lock()
+	s.authCache[mac] = &hashed.rpcUser
+	s.authCacheMtx.Unlock()
+	return &hashed.rpcUser
+}
+
+// clientCertUser returns the RPC user the verified TLS client certificate of
+// the provided connection state is mapped to based on the common name of its
+// subject or nil when there is no such certificate or it is not mapped to any
+// user.
+func (s *Server) clientCertUser(state *tls.ConnectionState) *rpcUser {
+	if state == nil || len(state.VerifiedChains) == 0 ||
+		len(state.VerifiedChains[0]) == 0 {
+
+		return nil
+	}
+	return s.clientCertUsers[state.VerifiedChains[0][0].Subject.CommonName]
+}
+
+// authorized returns whether or not the provided user is authorized to call
+// the provided method and logs the calls that are denied for auditing.
+func (s *Server) authorized(user *rpcUser, method, remoteAddr string) bool {
+	if user.authorized(method) {
+		return true
+	}
+	log.Warnf("RPC user %q from %s denied access to method %q", user.name,
+		remoteAddr, method)
+	return false
+}
@@ -0,0 +1,633 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package rpcserver
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// mustBcryptHash returns the bcrypt hash of the provided password using the
+// minimum cost to keep the tests fast.
+func mustBcryptHash(t *testing.T, password string) string {
+	t.Helper()
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("unable to hash password: %v", err)
+	}
+	return string(hash)
+}
+
+// mustArgon2Hash returns the argon2 hash of the provided password in modular
+// crypt format using the provided variant and small parameters to keep the
+// tests fast.
+func mustArgon2Hash(variant, password string) string {
+	salt := []byte("0123456789abcdef")
+	const memory, time, threads = 64, 1, 1
+	var key []byte
+	switch variant {
+	case "argon2i":
+		key = argon2.Key([]byte(password), salt, time, memory, threads, 32)
+	default:
+		key = argon2.IDKey([]byte(password), salt, time, memory, threads, 32)
+	}
+	return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", variant,
+		argon2.Version, memory, time, threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key))
+}
+
+// basicAuth returns the HTTP Basic authentication header for the provided
+// username and password.
+func basicAuth(username, password string) string {
+	login := username + ":" + password
+	return "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
+}
+
+// TestCheckPasswordHash ensures validating and checking bcrypt and argon2
+// password hashes works as expected.
+func TestCheckPasswordHash(t *testing.T) {
+	t.Parallel()
+
+	hashes := []struct {
+		name string
+		hash string
+	}{{
+		name: "bcrypt",
+		hash: mustBcryptHash(t, "secret"),
+	}, {
+		name: "argon2i",
+		hash: mustArgon2Hash("argon2i", "secret"),
+	}, {
+		name: "argon2id",
+		hash: mustArgon2Hash("argon2id", "secret"),
+	}}
+	for _, test := range hashes {
+		if err := validatePasswordHash(test.hash); err != nil {
+			t.Fatalf("%q: unexpected error validating hash: %v", test.name,
+				err)
+		}
+		if !checkPasswordHash(test.hash, "secret") {
+			t.Fatalf("%q: correct password does not match", test.name)
+		}
+		if checkPasswordHash(test.hash, "wrong") {
+			t.Fatalf("%q: incorrect password matches", test.name)
+		}
+	}
+
+	invalidHashes := []struct {
+		name string
+		hash string
+	}{{
+		name: "plaintext",
+		hash: "secret",
+	}, {
+		name: "empty",
+		hash: "",
+	}, {
+		name: "truncated bcrypt",
+		hash: "$2a$10$abc",
+	}, {
+		name: "unsupported argon2 variant",
+		hash: "$argon2d$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+	}, {
+		name: "unsupported argon2 version",
+		hash: "$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+	}, {
+		name: "malformed argon2 parameters",
+		hash: "$argon2id$v=19$m=64$c2FsdA$a2V5",
+	}, {
+		name: "zero argon2 time",
+		hash: "$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5",
+	}, {
+		name: "malformed argon2 salt",
+		hash: "$argon2id$v=19$m=64,t=1,p=1$!!$a2V5",
+	}, {
+		name: "empty argon2 key",
+		hash: "$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+	}, {
+		name: "argon2 memory too large",
+		hash: "$argon2id$v=19$m=4194304,t=1,p=1$c2FsdA$a2V5",
+	}, {
+		name: "argon2 time too large",
+		hash: "$argon2id$v=19$m=64,t=1000,p=1$c2FsdA$a2V5",
+	}, {
+		name: "argon2 parallelism too large",
+		hash: "$argon2id$v=19$m=64,t=1,p=255$c2FsdA$a2V5",
+	}, {
+		name: "bcrypt cost too large",
+		hash: strings.Replace(mustBcryptHash(t, "secret"), "$04$", "$31$", 1),
+	}}
+	for _, test := range invalidHashes {
+		if err := validatePasswordHash(test.hash); err == nil {
+			t.Fatalf("%q: did not receive expected error", test.name)
+		}
+		if checkPasswordHash(test.hash, "secret") {
+			t.Fatalf("%q: password matches invalid hash", test.name)
+		}
+	}
+}
+
+// TestParseUsers ensures parsing users files works as expected, including
+// expanding groups of methods and rejecting invalid files.
+func TestParseUsers(t *testing.T) {
+	t.Parallel()
+
+	hash := mustBcryptHash(t, "secret")
+	file := fmt.Sprintf(`{
+		"groups": {
+			"chain": ["getbestblock", "getblockcount"]
+		},
+		"users": [{
+			"username": "explorer",
+			"passwordhash": %q,
+			"methods": ["@chain", "notifyblocks"]
+		}, {
+			"username": "ops",
+			"passwordhash": %q,
+			"methods": ["*"]
+		}, {
+			"username": "wallet",
+			"passwordhash": %q,
+			"methods": ["@limited"]
+		}]
+	}`, hash, hash, hash)
+	users, err := ParseUsers([]byte(file))
+	if err != nil {
+		t.Fatalf("unexpected error parsing users: %v", err)
+	}
+	var limited []string
+	for method := range rpcLimited {
+		limited = append(limited, method)
+	}
+	sort.Strings(limited)
+	sort.Strings(users[2].Methods)
+	want := []User{{
+		Name:         "explorer",
+		PasswordHash: hash,
+		Methods:      []string{"getbestblock", "getblockcount", "notifyblocks"},
+	}, {
+		Name:         "ops",
+		PasswordHash: hash,
+		Methods:      []string{"*"},
+	}, {
+		Name:         "wallet",
+		PasswordHash: hash,
+		Methods:      limited,
+	}}
+	if !reflect.DeepEqual(users, want) {
+		t.Fatalf("unexpected users -- got %+v, want %+v", users, want)
+	}
+
+	tests := []struct {
+		name string
+		file string
+	}{{
+		name: "malformed json",
+		file: `{"users": [`,
+	}, {
+		name: "redefined builtin group",
+		file: `{"groups": {"limited": ["getinfo"]}}`,
+	}, {
+		name: "unknown method in group",
+		file: `{"groups": {"chain": ["nosuchmethod"]}}`,
+	}, {
+		name: "empty username",
+		file: fmt.Sprintf(`{"users": [{"passwordhash": %q, `+
+			`"methods": ["getinfo"]}]}`, hash),
+	}, {
+		name: "duplicate user",
+		file: fmt.Sprintf(`{"users": [{"username": "a", "passwordhash": %q, `+
+			`"methods": ["getinfo"]}, {"username": "a", "passwordhash": %q, `+
+			`"methods": ["getinfo"]}]}`, hash, hash),
+	}, {
+		name: "plaintext password",
+		file: `{"users": [{"username": "a", "passwordhash": "secret", ` +
+			`"methods": ["getinfo"]}]}`,
+	}, {
+		name: "unknown method",
+		file: fmt.Sprintf(`{"users": [{"username": "a", "passwordhash": %q, `+
+			`"methods": ["nosuchmethod"]}]}`, hash),
+	}, {
+		name: "unknown group",
+		file: fmt.Sprintf(`{"users": [{"username": "a", "passwordhash": %q, `+
+			`"methods": ["@nosuchgroup"]}]}`, hash),
+	}, {
+		name: "no methods",
+		file: fmt.Sprintf(`{"users": [{"username": "a", "passwordhash": %q}]}`,
+			hash),
+	}}
+	for _, test := range tests {
+		if _, err := ParseUsers([]byte(test.file)); err == nil {
+			t.Fatalf("%q: did not receive expected error", test.name)
+		}
+	}
+}
+
+// TestAuthenticate ensures authenticating the admin, limited, and additional
+// RPC users and checking the methods they are authorized to call works as
+// expected.
+func TestAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(&Config{
+		RPCUser:      "admin",
+		RPCPass:      "adminpass",
+		RPCLimitUser: "limited",
+		RPCLimitPass: "limitedpass",
+		RPCUsers: []User{{
+			Name:         "explorer",
+			PasswordHash: mustBcryptHash(t, "explorerpass"),
+			Methods:      []string{"getbestblock", "notifyblocks"},
+		}, {
+			Name:         "ops",
+			PasswordHash: mustArgon2Hash("argon2id", "opspass"),
+			Methods:      []string{"*"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		auth       string
+		wantUser   string
+		authorized []string
+		denied     []string
+	}{{
+		name:       "admin",
+		auth:       basicAuth("admin", "adminpass"),
+		wantUser:   "admin",
+		authorized: []string{"getbestblock", "stop", "reindex"},
+	}, {
+		name:       "limited",
+		auth:       basicAuth("limited", "limitedpass"),
+		wantUser:   "limited",
+		authorized: []string{"getbestblock", "sendrawtransaction"},
+		denied:     []string{"stop", "reindex"},
+	}, {
+		name:       "explorer with allowlist",
+		auth:       basicAuth("explorer", "explorerpass"),
+		wantUser:   "explorer",
+		authorized: []string{"getbestblock", "notifyblocks"},
+		denied:     []string{"getblockcount", "sendrawtransaction", "stop"},
+	}, {
+		name:       "explorer again via cache",
+		auth:       basicAuth("explorer", "explorerpass"),
+		wantUser:   "explorer",
+		authorized: []string{"getbestblock"},
+		denied:     []string{"stop"},
+	}, {
+		name:       "ops with all methods",
+		auth:       basicAuth("ops", "opspass"),
+		wantUser:   "ops",
+		authorized: []string{"getbestblock", "stop"},
+	}, {
+		name: "wrong admin password",
+		auth: basicAuth("admin", "wrong"),
+	}, {
+		name: "wrong additional user password",
+		auth: basicAuth("explorer", "wrong"),
+	}, {
+		name: "unknown user",
+		auth: basicAuth("nobody", "explorerpass"),
+	}, {
+		name: "malformed header",
+		auth: "Bearer token",
+	}}
+	for _, test := range tests {
+		user := s.authenticate(test.auth, "127.0.0.1:1")
+		if test.wantUser == "" {
+			if user != nil {
+				t.Fatalf("%q: unexpectedly authenticated as %q", test.name,
+					user.name)
+			}
+			continue
+		}
+		if user == nil || user.name != test.wantUser {
+			t.Fatalf("%q: did not authenticate as %q", test.name,
+				test.wantUser)
+		}
+		for _, method := range test.authorized {
+			if !s.authorized(user, method, "127.0.0.1:1") {
+				t.Fatalf("%q: not authorized for %q", test.name, method)
+			}
+		}
+		for _, method := range test.denied {
+			if s.authorized(user, method, "127.0.0.1:1") {
+				t.Fatalf("%q: unexpectedly authorized for %q", test.name,
+					method)
+			}
+		}
+	}
+
+	// Ensure additional users that conflict with the admin or limited user
+	// are rejected.
+	_, err = New(&Config{
+		RPCUser: "admin",
+		RPCPass: "adminpass",
+		RPCUsers: []User{{
+			Name:         "admin",
+			PasswordHash: mustBcryptHash(t, "other"),
+			Methods:      []string{"getbestblock"},
+		}},
+	})
+	if err == nil {
+		t.Fatal("did not receive error for conflicting user")
+	}
+}
+
+// TestAuthFailureBackoff ensures password hash checks are refused for a remote
+// host after failed attempts while other hosts and users that do not require
+// password hash checks are unaffected.
+func TestAuthFailureBackoff(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(&Config{
+		RPCUser: "admin",
+		RPCPass: "adminpass",
+		RPCUsers: []User{{
+			Name:         "explorer",
+			PasswordHash: mustBcryptHash(t, "explorerpass"),
+			Methods:      []string{"getbestblock"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	if err := validatePasswordHash(s.dummyPasswordHash); err != nil {
+		t.Fatalf("invalid dummy password hash: %v", err)
+	}
+
+	const attacker, other = "192.0.2.1:1000", "192.0.2.2:1000"
+	explorer := basicAuth("explorer", "explorerpass")
+
+	// Ensure a failed attempt for an unknown user causes the correct
+	// credentials to be refused from the same host, but not other hosts.
+	if s.authenticate(basicAuth("nobody", "wrong"), attacker) != nil {
+		t.Fatal("unknown user authenticated")
+	}
+	if s.authenticate(explorer, "192.0.2.1:2000") != nil {
+		t.Fatal("password checked during backoff")
+	}
+	if s.authenticate(basicAuth("admin", "adminpass"), attacker) == nil {
+		t.Fatal("admin refused during backoff")
+	}
+
+	// Ensure consecutive failures increase the delay.
+	s.authFailuresMtx.Lock()
+	failure := s.authFailures["192.0.2.1"]
+	if failure == nil || failure.count != 1 {
+		s.authFailuresMtx.Unlock()
+		t.Fatalf("unexpected failure record %+v", failure)
+	}
+	failure.retryAfter = time.Now().Add(-time.Second)
+	s.authFailuresMtx.Unlock()
+	if s.authenticate(basicAuth("explorer", "wrong"), attacker) != nil {
+		t.Fatal("incorrect password authenticated")
+	}
+	s.authFailuresMtx.Lock()
+	delay := time.Until(failure.retryAfter)
+	count := failure.count
+	s.authFailuresMtx.Unlock()
+	if count != 2 || delay <= authFailureBaseDelay {
+		t.Fatalf("unexpected backoff -- count %d, delay %v", count, delay)
+	}
+
+	// Ensure the correct credentials authenticate from the same host once
+	// the backoff expires and that success clears the failures.
+	s.authFailuresMtx.Lock()
+	failure.retryAfter = time.Now().Add(-time.Second)
+	s.authFailuresMtx.Unlock()
+	user := s.authenticate(explorer, attacker)
+	if user == nil || user.name != "explorer" {
+		t.Fatal("correct password refused after backoff")
+	}
+	s.authFailuresMtx.Lock()
+	_, ok := s.authFailures["192.0.2.1"]
+	s.authFailuresMtx.Unlock()
+	if ok {
+		t.Fatal("failures not cleared after success")
+	}
+
+	// Ensure cached credentials are not subject to backoff.
+	if s.authenticate(basicAuth("explorer", "wrong"), other) != nil {
+		t.Fatal("incorrect password authenticated")
+	}
+	if s.authenticate(explorer, other) == nil {
+		t.Fatal("cached credentials refused during backoff")
+	}
+}
+
+// TestCookieAuth ensures the cookie file is written with randomly generated
+// credentials that authenticate as an admin user and that it is removed when
+// the server shuts down.
+func TestCookieAuth(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "rpccookie")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cookiePath := filepath.Join(dir, ".cookie")
+
+	s, err := New(&Config{RPCCookiePath: cookiePath})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	cookie, err := ioutil.ReadFile(cookiePath)
+	if err != nil {
+		t.Fatalf("unable to read cookie file: %v", err)
+	}
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(cookiePath)
+		if err != nil {
+			t.Fatalf("unable to stat cookie file: %v", err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0600 {
+			t.Fatalf("unexpected cookie file permissions -- got %o, want %o",
+				perm, 0600)
+		}
+	}
+	creds := strings.SplitN(string(cookie), ":", 2)
+	if len(creds) != 2 || creds[0] != cookieUsername ||
+		len(creds[1]) != cookiePassLen*2 {
+
+		t.Fatalf("malformed cookie %q", cookie)
+	}
+
+	user := s.authenticate(basicAuth(creds[0], creds[1]), "127.0.0.1:1")
+	if user == nil || user.name != cookieUsername {
+		t.Fatal("cookie credentials did not authenticate")
+	}
+	if !s.authorized(user, "stop", "127.0.0.1:1") {
+		t.Fatal("cookie user is not authorized for all methods")
+	}
+	if s.authenticate(basicAuth(cookieUsername, "wrong"), "127.0.0.1:1") != nil {
+		t.Fatal("incorrect cookie password authenticated")
+	}
+
+	// Ensure a new server generates different credentials.
+	s2, err := New(&Config{RPCCookiePath: cookiePath})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	cookie2, err := ioutil.ReadFile(cookiePath)
+	if err != nil {
+		t.Fatalf("unable to read cookie file: %v", err)
+	}
+	if string(cookie2) == string(cookie) {
+		t.Fatal("cookie credentials were reused")
+	}
+	if s2.authenticate(basicAuth(creds[0], creds[1]), "127.0.0.1:1") != nil {
+		t.Fatal("stale cookie credentials authenticated")
+	}
+
+	// Ensure the cookie file is removed on shutdown.
+	if err := s2.shutdown(); err != nil {
+		t.Fatalf("unexpected error shutting down server: %v", err)
+	}
+	if _, err := os.Stat(cookiePath); !os.IsNotExist(err) {
+		t.Fatalf("cookie file was not removed on shutdown: %v", err)
+	}
+
+	// Ensure an additional user that conflicts with the cookie user is
+	// rejected.
+	_, err = New(&Config{
+		RPCCookiePath: cookiePath,
+		RPCUsers: []User{{
+			Name:         cookieUsername,
+			PasswordHash: mustBcryptHash(t, "other"),
+			Methods:      []string{"getbestblock"},
+		}},
+	})
+	if err == nil {
+		t.Fatal("did not receive error for conflicting cookie user")
+	}
+}
+
+// TestClientCertAuth ensures connections with verified TLS client certificates
+// authenticate as the users their subjects are mapped to.
+func TestClientCertAuth(t *testing.T) {
+	t.Parallel()
+
+	s, err := New(&Config{
+		RPCUser:         "admin",
+		RPCPass:         "adminpass",
+		RPCAdminClients: []string{"automation"},
+		RPCLimitClients: []string{"monitor"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	// verifiedState returns a connection state with a verified client
+	// certificate with the provided subject common name.
+	verifiedState := func(cn string) *tls.ConnectionState {
+		cert := &x509.Certificate{Subject: pkix.Name{CommonName: cn}}
+		return &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{cert},
+			VerifiedChains:   [][]*x509.Certificate{{cert}},
+		}
+	}
+	unverified := &x509.Certificate{
+		Subject: pkix.Name{CommonName: "automation"},
+	}
+
+	tests := []struct {
+		name     string
+		state    *tls.ConnectionState
+		auth     string
+		wantUser string
+		wantErr  bool
+	}{{
+		name:     "admin client certificate",
+		state:    verifiedState("automation"),
+		wantUser: "automation",
+	}, {
+		name:     "limited client certificate",
+		state:    verifiedState("monitor"),
+		wantUser: "monitor",
+	}, {
+		name:    "unmapped client certificate",
+		state:   verifiedState("unknown"),
+		wantErr: true,
+	}, {
+		name: "unverified client certificate",
+		state: &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{unverified},
+		},
+		wantErr: true,
+	}, {
+		name:    "no tls",
+		wantErr: true,
+	}, {
+		name:     "basic auth takes precedence",
+		state:    verifiedState("monitor"),
+		auth:     basicAuth("admin", "adminpass"),
+		wantUser: "admin",
+	}, {
+		name:    "invalid basic auth with client certificate",
+		state:   verifiedState("automation"),
+		auth:    basicAuth("admin", "wrong"),
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		r := &http.Request{Header: make(http.Header), TLS: test.state}
+		if test.auth != "" {
+			r.Header.Set("Authorization", test.auth)
+		}
+		user, err := s.checkAuth(r, true)
+		if (err != nil) != test.wantErr {
+			t.Fatalf("%q: unexpected error -- got %v, want error %v",
+				test.name, err, test.wantErr)
+		}
+		if test.wantErr {
+			continue
+		}
+		if user == nil || user.name != test.wantUser {
+			t.Fatalf("%q: did not authenticate as %q", test.name,
+				test.wantUser)
+		}
+	}
+
+	// Ensure the limited client certificate is only authorized for the
+	// methods available to the limited user.
+	user, _ := s.checkAuth(&http.Request{TLS: verifiedState("monitor")}, true)
+	if !s.authorized(user, "getbestblock", "127.0.0.1:1") {
+		t.Fatal("limited client certificate not authorized for getbestblock")
+	}
+	if s.authorized(user, "stop", "127.0.0.1:1") {
+		t.Fatal("limited client certificate authorized for stop")
+	}
+
+	// Ensure a subject mapped to both the admin and limited user is rejected.
+	_, err = New(&Config{
+		RPCAdminClients: []string{"automation"},
+		RPCLimitClients: []string{"automation"},
+	})
+	if err == nil {
+		t.Fatal("did not receive error for conflicting client certificate")
+	}
+}
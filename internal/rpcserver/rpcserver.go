@@ -11,7 +11,6 @@ import (
 	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/hex"
@@ -26,6 +25,8 @@ import (
 	"math/big"
 	"net"
 	"net/http"
+	"os"
+	"path/filepath"
 	"runtime"
 	"sort"
 	"strconv"
@@ -39,6 +40,7 @@ import (
 	"github.com/decred/dcrd/blockchain/stake/v3"
 	"github.com/decred/dcrd/blockchain/standalone/v2"
 	"github.com/decred/dcrd/blockchain/v4"
+	"github.com/decred/dcrd/blockchain/v4/indexers"
 	"github.com/decred/dcrd/chaincfg/chainhash"
 	"github.com/decred/dcrd/chaincfg/v3"
 	"github.com/decred/dcrd/database/v2"
@@ -97,6 +99,11 @@ const (
 	// merkleRootPairSize is the size in bytes of the merkle root + stake root
 	// of a block.
 	merkleRootPairSize = 64
+
+	// indexSyncTimeout is the maximum amount of time to wait for an index
+	// that has caught up to the best chain tip to index blocks that were
+	// recently connected to the main chain before querying it.
+	indexSyncTimeout = 5 * time.Second
 )
 
 var (
@@ -145,12 +152,14 @@ type commandHandler func(context.Context, *Server, interface{}) (interface{}, er
 var rpcHandlers map[types.Method]commandHandler
 var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"addnode":               handleAddNode,
+	"backupdb":              handleBackupDb,
 	"createrawsstx":         handleCreateRawSStx,
 	"createrawssrtx":        handleCreateRawSSRtx,
 	"createrawtransaction":  handleCreateRawTransaction,
 	"debuglevel":            handleDebugLevel,
 	"decoderawtransaction":  handleDecodeRawTransaction,
 	"decodescript":          handleDecodeScript,
+	"dumputxoset":           handleDumpUtxoSet,
 	"estimatefee":           handleEstimateFee,
 	"estimatesmartfee":      handleEstimateSmartFee,
 	"estimatestakediff":     handleEstimateStakeDiff,
@@ -163,6 +172,8 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"existsmissedtickets":   handleExistsMissedTickets,
 	"generate":              handleGenerate,
 	"getaddednodeinfo":      handleGetAddedNodeInfo,
+	"getaddressbalance":     handleGetAddressBalance,
+	"getaddressutxos":       handleGetAddressUtxos,
 	"getbestblock":          handleGetBestBlock,
 	"getbestblockhash":      handleGetBestBlockHash,
 	"getblock":              handleGetBlock,
@@ -170,9 +181,12 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"getblockcount":         handleGetBlockCount,
 	"getblockhash":          handleGetBlockHash,
 	"getblockheader":        handleGetBlockHeader,
+	"getblockspends":        handleGetBlockSpends,
+	"getblockstats":         handleGetBlockStats,
 	"getblocksubsidy":       handleGetBlockSubsidy,
 	"getcfilter":            handleGetCFilter,
 	"getcfilterheader":      handleGetCFilterHeader,
+	"getcfiltersv2":         handleGetCFiltersV2,
 	"getcfilterv2":          handleGetCFilterV2,
 	"getchaintips":          handleGetChainTips,
 	"getcoinsupply":         handleGetCoinSupply,
@@ -182,6 +196,7 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"getgenerate":           handleGetGenerate,
 	"gethashespersec":       handleGetHashesPerSec,
 	"getheaders":            handleGetHeaders,
+	"getindexinfo":          handleGetIndexInfo,
 	"getinfo":               handleGetInfo,
 	"getmempoolinfo":        handleGetMempoolInfo,
 	"getmininginfo":         handleGetMiningInfo,
@@ -191,11 +206,16 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"getpeerinfo":           handleGetPeerInfo,
 	"getrawmempool":         handleGetRawMempool,
 	"getrawtransaction":     handleGetRawTransaction,
+	"getscripthashbalance":  handleGetScriptHashBalance,
+	"getscripthashhistory":  handleGetScriptHashHistory,
+	"getspendinginfo":       handleGetSpendingInfo,
 	"getstakedifficulty":    handleGetStakeDifficulty,
 	"getstakeversioninfo":   handleGetStakeVersionInfo,
 	"getstakeversions":      handleGetStakeVersions,
+	"getticketinfo":         handleGetTicketInfo,
 	"getticketpoolvalue":    handleGetTicketPoolValue,
 	"gettreasurybalance":    handleGetTreasuryBalance,
+	"gettreasuryhistory":    handleGetTreasuryHistory,
 	"gettreasuryspendvotes": handleGetTreasurySpendVotes,
 	"getvoteinfo":           handleGetVoteInfo,
 	"gettxout":              handleGetTxOut,
@@ -207,6 +227,8 @@ var rpcHandlersBeforeInit = map[types.Method]commandHandler{
 	"node":                  handleNode,
 	"ping":                  handlePing,
 	"regentemplate":         handleRegenTemplate,
+	"reindex":               handleReindex,
+	"searchnulldata":        handleSearchNullData,
 	"searchrawtransactions": handleSearchRawTransactions,
 	"sendrawtransaction":    handleSendRawTransaction,
 	"setgenerate":           handleSetGenerate,
@@ -342,6 +364,8 @@ var rpcLimited = map[string]struct{}{
 	"existslivetickets":     {},
 	"existsmempooltxs":      {},
 	"existsmissedtickets":   {},
+	"getaddressbalance":     {},
+	"getaddressutxos":       {},
 	"getbestblock":          {},
 	"getbestblockhash":      {},
 	"getblock":              {},
@@ -349,29 +373,39 @@ var rpcLimited = map[string]struct{}{
 	"getblockcount":         {},
 	"getblockhash":          {},
 	"getblockheader":        {},
+	"getblockspends":        {},
+	"getblockstats":         {},
 	"getblocksubsidy":       {},
 	"getcfilter":            {},
+	"getcfiltersv2":         {},
 	"getcfilterv2":          {},
 	"getchaintips":          {},
 	"getcoinsupply":         {},
 	"getcurrentnet":         {},
 	"getdifficulty":         {},
 	"getheaders":            {},
+	"getindexinfo":          {},
 	"getinfo":               {},
 	"getnettotals":          {},
 	"getnetworkhashps":      {},
 	"getnetworkinfo":        {},
 	"getrawmempool":         {},
+	"getscripthashbalance":  {},
+	"getscripthashhistory":  {},
+	"getspendinginfo":       {},
 	"getstakedifficulty":    {},
 	"getstakeversioninfo":   {},
 	"getstakeversions":      {},
+	"getticketinfo":         {},
 	"getrawtransaction":     {},
 	"gettreasurybalance":    {},
+	"gettreasuryhistory":    {},
 	"gettxout":              {},
 	"getvoteinfo":           {},
 	"livetickets":           {},
 	"missedtickets":         {},
 	"regentemplate":         {},
+	"searchnulldata":        {},
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"submitblock":           {},
@@ -462,6 +496,14 @@ func rpcBlockNotFoundError(blockHash chainhash.Hash) *dcrjson.RPCError {
 			blockHash))
 }
 
+// rpcBlockPrunedError is a convenience function for returning a nicely
+// formatted RPC error which indicates that the data for the provided block is
+// no longer available because it has been pruned.
+func rpcBlockPrunedError(blockHash chainhash.Hash) *dcrjson.RPCError {
+	return dcrjson.NewRPCError(dcrjson.ErrRPCBlockPruned,
+		fmt.Sprintf("Block not available (pruned data): %v", blockHash))
+}
+
 // rpcMiscError is a convenience function for returning a nicely formatted RPC
 // error which indicates there is an unquantifiable error.  Use this sparingly;
 // misc return codes are a cop out.
@@ -635,6 +677,49 @@ func (s *Server) messageToHex(msg wire.Message) (string, error) {
 	return hex.EncodeToString(buf.Bytes()), nil
 }
 
+// handleBackupDb implements the backupdb command.
+func handleBackupDb(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.BackupDbCmd)
+
+	// Relative paths are relative to the data directory and existing
+	// databases are never overwritten.
+	path := c.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.cfg.DataDir, path)
+	}
+
+	// The backup is made from a read-only transaction, so the database
+	// remains available for use while it is in progress.  Block data is not
+	// pruned in the meantime since the backup includes all stored blocks.
+	resumePruning := s.cfg.Chain.SuspendBlockPruning()
+	defer resumePruning()
+	var unsupported bool
+	err := s.cfg.DB.View(func(dbTx database.Tx) error {
+		backupper, ok := dbTx.(database.Backupper)
+		if !ok {
+			unsupported = true
+			return nil
+		}
+		return backupper.Backup(path)
+	})
+	if unsupported {
+		return nil, ErrRPCUnimplemented
+	}
+	if err != nil {
+		if database.IsError(err, database.ErrDbExists) {
+			return nil, rpcInvalidError("Database %q already exists",
+				path)
+		}
+		context := "Failed to back up database"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	return &types.BackupDbResult{
+		Path:   path,
+		DbType: s.cfg.DB.Type(),
+	}, nil
+}
+
 // handleCreateRawTransaction handles createrawtransaction commands.
 func handleCreateRawTransaction(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.CreateRawTransactionCmd)
@@ -1080,6 +1165,58 @@ func handleDebugLevel(_ context.Context, s *Server, cmd interface{}) (interface{
 	return "Done.", nil
 }
 
+// handleDumpUtxoSet implements the dumputxoset command.
+func handleDumpUtxoSet(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.DumpUtxoSetCmd)
+
+	// Relative paths are relative to the data directory and existing files
+	// are never overwritten.
+	path := c.Path
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.cfg.DataDir, path)
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, rpcInvalidError("File %q already exists", path)
+	}
+
+	// Write the snapshot to a temporary file that is only moved to the
+	// requested path once it is complete.
+	tmpPath := path + ".incomplete"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, rpcInvalidError("Unable to create file %q: %v", tmpPath,
+			err)
+	}
+	info, err := s.cfg.Chain.DumpUtxoSnapshot(file)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		if errors.Is(err, blockchain.ErrHistoryNotValidated) {
+			return nil, rpcMiscError(err.Error())
+		}
+		context := "Failed to dump utxo set"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		context := "Failed to rename utxo set snapshot"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	return &types.DumpUtxoSetResult{
+		BlockHash:      info.Hash.String(),
+		Height:         info.Height,
+		Path:           path,
+		NumUtxoEntries: info.NumUtxoEntries,
+		SnapshotHash:   info.SnapshotHash.String(),
+	}, nil
+}
+
 // createVinList returns a slice of JSON objects for the inputs of the passed
 // transaction.
 func createVinList(mtx *wire.MsgTx, isTreasuryEnabled bool) []types.Vin {
@@ -1835,6 +1972,215 @@ func handleGetAddedNodeInfo(_ context.Context, s *Server, cmd interface{}) (inte
 	return results, nil
 }
 
+// pkhAddress returns the encoded public key hash form of the provided address
+// when it is a public key address and its normal encoding otherwise.  This
+// allows outputs that pay to either form to be treated as paying to the same
+// address in the same way the address index does.
+func pkhAddress(addr dcrutil.Address) string {
+	type pkhAddresser interface {
+		AddressPubKeyHash() *dcrutil.AddressPubKeyHash
+	}
+	if pkAddr, ok := addr.(pkhAddresser); ok {
+		return pkAddr.AddressPubKeyHash().Address()
+	}
+	return addr.Address()
+}
+
+// fetchMempoolUtxosForAddress returns the outputs created by the unconfirmed
+// transactions in the mempool that pay to the provided address along with the
+// set of all outpoints spent by those transactions.  The returned outputs
+// include those that are spent by other unconfirmed transactions.
+func fetchMempoolUtxosForAddress(s *Server, addr dcrutil.Address, isTreasuryEnabled bool) ([]indexers.AddrUtxoEntry, map[wire.OutPoint]struct{}) {
+	// Respond with no entries when the address index is not available since
+	// it tracks the unconfirmed transactions.
+	if s.cfg.AddrIndexer == nil {
+		return nil, nil
+	}
+	mpTxns := s.cfg.AddrIndexer.UnconfirmedTxnsForAddress(addr)
+	if len(mpTxns) == 0 {
+		return nil, nil
+	}
+
+	wantAddr := pkhAddress(addr)
+	var created []indexers.AddrUtxoEntry
+	spent := make(map[wire.OutPoint]struct{})
+	for _, tx := range mpTxns {
+		msgTx := tx.MsgTx()
+		for _, txIn := range msgTx.TxIn {
+			spent[txIn.PreviousOutPoint] = struct{}{}
+		}
+		tree := wire.TxTreeRegular
+		txType := stake.DetermineTxType(msgTx, isTreasuryEnabled)
+		if txType != stake.TxTypeRegular {
+			tree = wire.TxTreeStake
+		}
+		for txOutIdx, txOut := range msgTx.TxOut {
+			if txscript.IsUnspendable(txOut.Value, txOut.PkScript) {
+				continue
+			}
+
+			// Ignore the error here since an error means the script
+			// couldn't parse and there are no addresses in it anyways.
+			_, addrs, _, _ := txscript.ExtractPkScriptAddrs(txOut.Version,
+				txOut.PkScript, s.cfg.ChainParams, isTreasuryEnabled)
+			for _, outAddr := range addrs {
+				if pkhAddress(outAddr) != wantAddr {
+					continue
+				}
+				created = append(created, indexers.AddrUtxoEntry{
+					OutPoint: wire.OutPoint{
+						Hash:  *tx.Hash(),
+						Index: uint32(txOutIdx),
+						Tree:  tree,
+					},
+					Amount:        txOut.Value,
+					ScriptVersion: txOut.Version,
+					PkScript:      txOut.PkScript,
+				})
+				break
+			}
+		}
+	}
+	return created, spent
+}
+
+// handleGetAddressBalance implements the getaddressbalance command.
+func handleGetAddressBalance(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetAddressBalanceCmd)
+
+	if s.cfg.AddrUtxoIndexer == nil {
+		return nil, rpcInternalError("The address utxo index must be "+
+			"enabled to query address balances (specify "+
+			"--addrutxoindex)", "Configuration")
+	}
+
+	// Attempt to decode the supplied address.  This also ensures the network
+	// encoded with the address matches the network the server is currently on.
+	addr, err := dcrutil.DecodeAddress(c.Address, s.cfg.ChainParams)
+	if err != nil {
+		return nil, rpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+
+	// Ensure the index has indexed the current best chain tip so
+	// transactions that were recently removed from the mempool due to being
+	// included in a block are not missed.
+	err = waitForIndexSync(ctx, s, s.cfg.AddrUtxoIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	confirmed, _, err := s.cfg.AddrUtxoIndexer.BalanceForAddress(addr)
+	if err != nil {
+		context := "Failed to retrieve address balance"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	// The unconfirmed balance is the net change to the confirmed balance
+	// from the unconfirmed transactions in the mempool.  Outputs that are
+	// both created and spent by unconfirmed transactions cancel out.
+	best := s.cfg.Chain.BestSnapshot()
+	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&best.Hash)
+	if err != nil {
+		return nil, err
+	}
+	created, spent := fetchMempoolUtxosForAddress(s, addr, isTreasuryEnabled)
+	var unconfirmed int64
+	for i := range created {
+		unconfirmed += created[i].Amount
+		if _, ok := spent[created[i].OutPoint]; ok {
+			unconfirmed -= created[i].Amount
+		}
+	}
+	if len(spent) > 0 {
+		entries, err := s.cfg.AddrUtxoIndexer.UtxosForAddress(addr)
+		if err != nil {
+			context := "Failed to retrieve address utxos"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		for i := range entries {
+			if _, ok := spent[entries[i].OutPoint]; ok {
+				unconfirmed -= entries[i].Amount
+			}
+		}
+	}
+
+	return &types.GetAddressBalanceResult{
+		Confirmed:   confirmed,
+		Unconfirmed: unconfirmed,
+	}, nil
+}
+
+// handleGetAddressUtxos implements the getaddressutxos command.
+func handleGetAddressUtxos(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetAddressUtxosCmd)
+
+	if s.cfg.AddrUtxoIndexer == nil {
+		return nil, rpcInternalError("The address utxo index must be "+
+			"enabled to query address utxos (specify --addrutxoindex)",
+			"Configuration")
+	}
+
+	// Attempt to decode the supplied address.  This also ensures the network
+	// encoded with the address matches the network the server is currently on.
+	addr, err := dcrutil.DecodeAddress(c.Address, s.cfg.ChainParams)
+	if err != nil {
+		return nil, rpcAddressKeyError("Could not decode address: %v",
+			err)
+	}
+
+	// Ensure the index has indexed the current best chain tip so
+	// transactions that were recently removed from the mempool due to being
+	// included in a block are not missed.
+	err = waitForIndexSync(ctx, s, s.cfg.AddrUtxoIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.cfg.AddrUtxoIndexer.UtxosForAddress(addr)
+	if err != nil {
+		context := "Failed to retrieve address utxos"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	// Merge the outputs created and spent by unconfirmed transactions in the
+	// mempool when requested.
+	best := s.cfg.Chain.BestSnapshot()
+	var created []indexers.AddrUtxoEntry
+	var spent map[wire.OutPoint]struct{}
+	if c.IncludeMempool == nil || *c.IncludeMempool {
+		isTreasuryEnabled, err := s.isTreasuryAgendaActive(&best.Hash)
+		if err != nil {
+			return nil, err
+		}
+		created, spent = fetchMempoolUtxosForAddress(s, addr,
+			isTreasuryEnabled)
+	}
+
+	results := make([]types.GetAddressUtxosResult, 0,
+		len(entries)+len(created))
+	addResult := func(entry *indexers.AddrUtxoEntry, confirmations int64) {
+		if _, ok := spent[entry.OutPoint]; ok {
+			return
+		}
+		results = append(results, types.GetAddressUtxosResult{
+			TxID:          entry.OutPoint.Hash.String(),
+			Vout:          entry.OutPoint.Index,
+			Tree:          entry.OutPoint.Tree,
+			Amount:        entry.Amount,
+			ScriptVersion: entry.ScriptVersion,
+			ScriptPubKey:  hex.EncodeToString(entry.PkScript),
+			Height:        entry.BlockHeight,
+			Confirmations: confirmations,
+		})
+	}
+	for i := range entries {
+		addResult(&entries[i], 1+best.Height-entries[i].BlockHeight)
+	}
+	for i := range created {
+		addResult(&created[i], 0)
+	}
+	return results, nil
+}
+
 // handleGetBestBlock implements the getbestblock command.
 func handleGetBestBlock(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	// All other "get block" commands give either the height, the hash, or
@@ -1886,6 +2232,9 @@ func handleGetBlock(_ context.Context, s *Server, cmd interface{}) (interface{},
 	chain := s.cfg.Chain
 	blk, err := chain.BlockByHash(hash)
 	if err != nil {
+		if errors.Is(err, blockchain.ErrBlockPruned) {
+			return nil, rpcBlockPrunedError(*hash)
+		}
 		return nil, &dcrjson.RPCError{
 			Code:    dcrjson.ErrRPCBlockNotFound,
 			Message: fmt.Sprintf("Block not found: %v", hash),
@@ -2083,6 +2432,13 @@ func handleGetBlockchainInfo(_ context.Context, s *Server, cmd interface{}) (int
 		}
 	}
 
+	// Gather the utxo cache statistics.
+	cacheStats := chain.UtxoCacheStats()
+	var hitRatio float64
+	if lookups := cacheStats.Hits + cacheStats.Misses; lookups > 0 {
+		hitRatio = float64(cacheStats.Hits) / float64(lookups)
+	}
+
 	// Generate rpc response.
 	response := types.GetBlockChainInfoResult{
 		Chain:                params.Name,
@@ -2097,6 +2453,14 @@ func handleGetBlockchainInfo(_ context.Context, s *Server, cmd interface{}) (int
 		DifficultyRatio:      getDifficultyRatio(best.Bits, params),
 		MaxBlockSize:         maxBlockSize,
 		Deployments:          dInfo,
+		UtxoCache: types.UtxoCacheInfo{
+			Entries:  cacheStats.Entries,
+			Size:     cacheStats.Size,
+			MaxSize:  cacheStats.MaxSize,
+			Hits:     cacheStats.Hits,
+			Misses:   cacheStats.Misses,
+			HitRatio: hitRatio,
+		},
 	}
 
 	return response, nil
@@ -2208,6 +2572,247 @@ func handleGetBlockHeader(_ context.Context, s *Server, cmd interface{}) (interf
 	return blockHeaderReply, nil
 }
 
+// handleGetBlockSpends implements the getblockspends command.
+func handleGetBlockSpends(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetBlockSpendsCmd)
+
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+
+	// Spend journals are only available for blocks in the main chain.
+	chain := s.cfg.Chain
+	height, err := chain.BlockHeightByHash(hash)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found in main chain: %v", hash),
+		}
+	}
+	blockHeader, err := chain.HeaderByHash(hash)
+	if err != nil {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", hash),
+		}
+	}
+	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&blockHeader.PrevBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	spent, err := chain.FetchSpentOutputs(hash)
+	if err != nil {
+		switch {
+		case errors.Is(err, blockchain.ErrBlockPruned):
+			return nil, rpcBlockPrunedError(*hash)
+		case errors.Is(err, blockchain.ErrUnknownBlock):
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCBlockNotFound,
+				Message: fmt.Sprintf("Block not found: %v", hash),
+			}
+		case errors.Is(err, blockchain.ErrNoSpendJournal):
+			return nil, rpcMiscError(fmt.Sprintf("Spent outputs are not "+
+				"available for block %v", hash))
+		}
+		context := "Failed to fetch spent outputs"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	// Group the spent outputs by the transaction that spends them.  They are
+	// ordered by the spending transactions, so all outputs spent by a given
+	// transaction are next to each other.
+	result := &types.GetBlockSpendsResult{
+		Hash:   hash.String(),
+		Height: height,
+		Tx:     []types.BlockSpendsTx{},
+	}
+	for i := range spent {
+		so := &spent[i]
+		numTxns := len(result.Tx)
+		if numTxns == 0 || result.Tx[numTxns-1].Txid != so.SpendingTx.String() {
+			result.Tx = append(result.Tx, types.BlockSpendsTx{
+				Txid: so.SpendingTx.String(),
+				Tree: so.SpendingTree,
+			})
+			numTxns++
+		}
+
+		// Ignore the error here since an error means the script couldn't
+		// parse and there is no additional information about it anyways.
+		disbuf, _ := txscript.DisasmString(so.PkScript)
+		scriptClass, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(
+			so.ScriptVersion, so.PkScript, s.cfg.ChainParams,
+			isTreasuryEnabled)
+		addresses := make([]string, len(addrs))
+		for j, addr := range addrs {
+			addresses[j] = addr.Address()
+		}
+
+		tx := &result.Tx[numTxns-1]
+		tx.Spends = append(tx.Spends, types.BlockSpend{
+			Vin:         so.InputIndex,
+			Txid:        so.PrevOut.Hash.String(),
+			Vout:        so.PrevOut.Index,
+			Tree:        so.PrevOut.Tree,
+			AmountIn:    dcrutil.Amount(so.Amount).ToCoin(),
+			BlockHeight: so.BlockHeight,
+			BlockIndex:  so.BlockIndex,
+			Version:     so.ScriptVersion,
+			ScriptPubKey: types.ScriptPubKeyResult{
+				Asm:       disbuf,
+				Hex:       hex.EncodeToString(so.PkScript),
+				ReqSigs:   int32(reqSigs),
+				Type:      scriptClass.String(),
+				Addresses: addresses,
+			},
+		})
+	}
+
+	return result, nil
+}
+
+// blockStatsFields maps the names of the statistics supported by the
+// getblockstats command to functions that remove them from a result.
+var blockStatsFields = map[string]func(r *types.GetBlockStatsResult){
+	"hash":               func(r *types.GetBlockStatsResult) { r.Hash = nil },
+	"height":             func(r *types.GetBlockStatsResult) { r.Height = nil },
+	"time":               func(r *types.GetBlockStatsResult) { r.Time = nil },
+	"size":               func(r *types.GetBlockStatsResult) { r.Size = nil },
+	"txs":                func(r *types.GetBlockStatsResult) { r.Txs = nil },
+	"stxs":               func(r *types.GetBlockStatsResult) { r.STxs = nil },
+	"tickets":            func(r *types.GetBlockStatsResult) { r.Tickets = nil },
+	"votes":              func(r *types.GetBlockStatsResult) { r.Votes = nil },
+	"revocations":        func(r *types.GetBlockStatsResult) { r.Revocations = nil },
+	"tadds":              func(r *types.GetBlockStatsResult) { r.TAdds = nil },
+	"tspends":            func(r *types.GetBlockStatsResult) { r.TSpends = nil },
+	"totalfee":           func(r *types.GetBlockStatsResult) { r.TotalFee = nil },
+	"minfeerate":         func(r *types.GetBlockStatsResult) { r.MinFeeRate = nil },
+	"maxfeerate":         func(r *types.GetBlockStatsResult) { r.MaxFeeRate = nil },
+	"avgfeerate":         func(r *types.GetBlockStatsResult) { r.AvgFeeRate = nil },
+	"feeratepercentiles": func(r *types.GetBlockStatsResult) { r.FeeRatePercentiles = nil },
+	"taddamount":         func(r *types.GetBlockStatsResult) { r.TAddAmount = nil },
+	"treasurybaseamount": func(r *types.GetBlockStatsResult) { r.TreasuryBaseAmount = nil },
+	"tspendamount":       func(r *types.GetBlockStatsResult) { r.TSpendAmount = nil },
+	"utxoincrease":       func(r *types.GetBlockStatsResult) { r.UtxoIncrease = nil },
+}
+
+// handleGetBlockStats implements the getblockstats command.
+func handleGetBlockStats(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetBlockStatsCmd)
+
+	if s.cfg.BlockStatsIndexer == nil {
+		return nil, rpcInternalError("The block stats index must be enabled "+
+			"to query block statistics (specify --blockstatsindex)",
+			"Configuration")
+	}
+
+	// Ensure the requested statistics are supported.  All statistics are
+	// returned when none are requested.
+	var requested map[string]struct{}
+	if c.Stats != nil && len(*c.Stats) > 0 {
+		requested = make(map[string]struct{}, len(*c.Stats))
+		for _, name := range *c.Stats {
+			if _, ok := blockStatsFields[name]; !ok {
+				return nil, rpcInvalidError("Invalid statistic %q", name)
+			}
+			requested[name] = struct{}{}
+		}
+	}
+
+	// Determine the hash and height of the requested block in the main chain.
+	// The block is identified by its hash when the parameter is the size of a
+	// hash and by its height otherwise.
+	chain := s.cfg.Chain
+	var hash *chainhash.Hash
+	var height int64
+	hashOrHeight := string(c.HashOrHeight)
+	if len(hashOrHeight) == chainhash.MaxHashStringSize {
+		var err error
+		hash, err = chainhash.NewHashFromStr(hashOrHeight)
+		if err != nil {
+			return nil, rpcDecodeHexError(hashOrHeight)
+		}
+		height, err = chain.BlockHeightByHash(hash)
+		if err != nil {
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCBlockNotFound,
+				Message: fmt.Sprintf("Block not found in main chain: %v", hash),
+			}
+		}
+	} else {
+		var err error
+		height, err = strconv.ParseInt(hashOrHeight, 10, 64)
+		if err != nil {
+			return nil, rpcInvalidError("Invalid block hash or height %q",
+				hashOrHeight)
+		}
+		hash, err = chain.BlockHashByHeight(height)
+		if err != nil {
+			return nil, &dcrjson.RPCError{
+				Code: dcrjson.ErrRPCOutOfRange,
+				Message: fmt.Sprintf("Block number out of range: %v",
+					height),
+			}
+		}
+	}
+
+	err := waitForIndexSync(ctx, s, s.cfg.BlockStatsIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.cfg.BlockStatsIndexer.Stats(height)
+	if err != nil {
+		context := "Failed to retrieve block stats"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	// The block might have been removed from the main chain since its height
+	// was determined.
+	if stats == nil || stats.Hash != *hash {
+		return nil, &dcrjson.RPCError{
+			Code:    dcrjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found in main chain: %v", hash),
+		}
+	}
+
+	feeRatePercentiles := stats.FeeRatePercentiles[:]
+	result := &types.GetBlockStatsResult{
+		Hash:               dcrjson.String(stats.Hash.String()),
+		Height:             dcrjson.Int64(stats.Height),
+		Time:               dcrjson.Int64(stats.Time),
+		Size:               dcrjson.Int64(int64(stats.Size)),
+		Txs:                dcrjson.Int64(int64(stats.NumTxns)),
+		STxs:               dcrjson.Int64(int64(stats.NumSTxns)),
+		Tickets:            dcrjson.Int64(int64(stats.NumTickets)),
+		Votes:              dcrjson.Int64(int64(stats.NumVotes)),
+		Revocations:        dcrjson.Int64(int64(stats.NumRevocations)),
+		TAdds:              dcrjson.Int64(int64(stats.NumTreasuryAdds)),
+		TSpends:            dcrjson.Int64(int64(stats.NumTSpends)),
+		TotalFee:           dcrjson.Int64(stats.TotalFee),
+		MinFeeRate:         dcrjson.Int64(stats.MinFeeRate),
+		MaxFeeRate:         dcrjson.Int64(stats.MaxFeeRate),
+		AvgFeeRate:         dcrjson.Int64(stats.AvgFeeRate),
+		FeeRatePercentiles: &feeRatePercentiles,
+		TAddAmount:         dcrjson.Int64(stats.TreasuryAddAmount),
+		TreasuryBaseAmount: dcrjson.Int64(stats.TreasuryBaseAmount),
+		TSpendAmount:       dcrjson.Int64(stats.TSpendAmount),
+		UtxoIncrease:       dcrjson.Int64(stats.UtxoIncrease),
+	}
+
+	// Remove the statistics that were not requested, if any.
+	if requested != nil {
+		for name, remove := range blockStatsFields {
+			if _, ok := requested[name]; !ok {
+				remove(result)
+			}
+		}
+	}
+
+	return result, nil
+}
+
 // handleGetBlockSubsidy implements the getblocksubsidy command.
 func handleGetBlockSubsidy(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.GetBlockSubsidyCmd)
@@ -2471,6 +3076,119 @@ func handleGetCFilterV2(_ context.Context, s *Server, cmd interface{}) (interfac
 	return result, nil
 }
 
+// handleGetCFiltersV2 implements the getcfiltersv2 command.
+func handleGetCFiltersV2(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetCFiltersV2Cmd)
+	startHash, err := chainhash.NewHashFromStr(c.StartHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.StartHash)
+	}
+	endHash, err := chainhash.NewHashFromStr(c.EndHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.EndHash)
+	}
+
+	filters, err := s.cfg.FiltererV2.LocateCFiltersV2(startHash, endHash,
+		wire.MaxCFiltersV2PerBatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, blockchain.ErrUnknownBlock),
+			errors.Is(err, blockchain.ErrNoFilter):
+
+			return nil, &dcrjson.RPCError{
+				Code:    dcrjson.ErrRPCBlockNotFound,
+				Message: fmt.Sprintf("Block not found: %v", err),
+			}
+
+		case errors.Is(err, blockchain.ErrInvalidBlockRange):
+			return nil, rpcInvalidError("Invalid block range: %v", err)
+		}
+
+		context := fmt.Sprintf("Failed to load filters for blocks %s "+
+			"through %s", startHash, endHash)
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	results := make([]types.GetCFilterV2Result, 0, len(filters))
+	for i := range filters {
+		filter := &filters[i]
+		var proofHashes []string
+		for j := range filter.ProofHashes {
+			proofHashes = append(proofHashes, filter.ProofHashes[j].String())
+		}
+		results = append(results, types.GetCFilterV2Result{
+			BlockHash:   filter.BlockHash.String(),
+			Data:        hex.EncodeToString(filter.Filter.Bytes()),
+			ProofIndex:  filter.ProofIndex,
+			ProofHashes: proofHashes,
+		})
+	}
+	return results, nil
+}
+
+// waitForIndexSync waits for the index with the provided name to index the
+// current best chain tip so that queries against it are consistent with the
+// main chain.  An error is returned when the index has not caught up to the
+// best chain tip since it was initialized or it does not index the tip within
+// the sync timeout.
+func waitForIndexSync(ctx context.Context, s *Server, name string) error {
+	// Nothing to wait for when the sync state of the indexes is unknown.
+	if s.cfg.IndexSyncer == nil {
+		return nil
+	}
+
+	best := s.cfg.Chain.BestSnapshot()
+	for _, info := range s.cfg.IndexSyncer.IndexInfo() {
+		if info.Name != name {
+			continue
+		}
+		if !info.Synced {
+			str := fmt.Sprintf("The %s is still syncing (height %d of %d)",
+				name, info.Height, best.Height)
+			return rpcMiscError(str)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, indexSyncTimeout)
+		defer cancel()
+		err := s.cfg.IndexSyncer.WaitForHeight(ctx, name, best.Height)
+		if err != nil {
+			str := fmt.Sprintf("The %s did not sync to height %d: %v",
+				name, best.Height, err)
+			return rpcMiscError(str)
+		}
+		return nil
+	}
+	return nil
+}
+
+// handleGetIndexInfo implements the getindexinfo command.
+func handleGetIndexInfo(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetIndexInfoCmd)
+
+	var infos []indexers.IndexInfo
+	if s.cfg.IndexSyncer != nil {
+		infos = s.cfg.IndexSyncer.IndexInfo()
+	}
+
+	results := make([]types.GetIndexInfoResult, 0, len(infos))
+	for _, info := range infos {
+		if c.IndexName != nil && *c.IndexName != info.Name {
+			continue
+		}
+		results = append(results, types.GetIndexInfoResult{
+			Name:            info.Name,
+			Synced:          info.Synced,
+			BestBlockHash:   info.Hash.String(),
+			BestBlockHeight: info.Height,
+		})
+	}
+	if c.IndexName != nil && len(results) == 0 {
+		return nil, rpcInvalidError("The %s is not enabled", *c.IndexName)
+	}
+
+	return results, nil
+}
+
 // handleGetInfo implements the getinfo command. We only return the fields
 // that are not related to wallet functionality.
 func handleGetInfo(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
@@ -2807,7 +3525,7 @@ func handleGetRawMempool(_ context.Context, s *Server, cmd interface{}) (interfa
 }
 
 // handleGetRawTransaction implements the getrawtransaction command.
-func handleGetRawTransaction(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+func handleGetRawTransaction(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.GetRawTransactionCmd)
 
 	// Convert the provided transaction hash hex to a Hash.
@@ -2837,6 +3555,10 @@ func handleGetRawTransaction(_ context.Context, s *Server, cmd interface{}) (int
 		}
 
 		// Look up the location of the transaction.
+		err := waitForIndexSync(ctx, s, s.cfg.TxIndexer.Name())
+		if err != nil {
+			return nil, err
+		}
 		idxEntry, err := s.cfg.TxIndexer.Entry(txHash)
 		if err != nil {
 			context := "Failed to retrieve transaction location"
@@ -2855,6 +3577,9 @@ func handleGetRawTransaction(_ context.Context, s *Server, cmd interface{}) (int
 			return err
 		})
 		if err != nil {
+			if chain.IsBlockPruned(blockRegion.Hash) {
+				return nil, rpcBlockPrunedError(*blockRegion.Hash)
+			}
 			return nil, rpcNoTxInfoError(txHash)
 		}
 
@@ -2927,21 +3652,201 @@ func handleGetRawTransaction(_ context.Context, s *Server, cmd interface{}) (int
 		prevBlkHash = chain.BestSnapshot().Hash
 	}
 
-	// Determine if the treasury rules are active as of either the block the
-	// contains the transaction or the current best tip when it is in the
-	// mempool.
-	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
-	if err != nil {
-		return nil, rpcInternalError(err.Error(), "Treasury Status")
+	// Determine if the treasury rules are active as of either the block the
+	// contains the transaction or the current best tip when it is in the
+	// mempool.
+	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Treasury Status")
+	}
+
+	rawTxn, err := s.createTxRawResult(s.cfg.ChainParams, mtx, txHash.String(),
+		blkIndex, blkHeader, blkHashStr, blkHeight, confirmations,
+		isTreasuryEnabled)
+	if err != nil {
+		return nil, err
+	}
+	return *rawTxn, nil
+}
+
+// handleGetScriptHashBalance implements the getscripthashbalance command.
+func handleGetScriptHashBalance(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetScriptHashBalanceCmd)
+
+	if s.cfg.ScriptHashIndexer == nil {
+		return nil, rpcInternalError("The script hash index must be "+
+			"enabled to query script hash balances (specify "+
+			"--scripthashindex)", "Configuration")
+	}
+
+	// Convert the provided script hash hex to a Hash.
+	scriptHash, err := chainhash.NewHashFromStr(c.ScriptHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.ScriptHash)
+	}
+
+	err = waitForIndexSync(ctx, s, s.cfg.ScriptHashIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	balance, numUtxos, err := s.cfg.ScriptHashIndexer.Balance(scriptHash)
+	if err != nil {
+		context := "Failed to retrieve script hash balance"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	return &types.GetScriptHashBalanceResult{
+		Confirmed: balance,
+		UtxoCount: numUtxos,
+	}, nil
+}
+
+// handleGetScriptHashHistory implements the getscripthashhistory command.
+func handleGetScriptHashHistory(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetScriptHashHistoryCmd)
+
+	if s.cfg.ScriptHashIndexer == nil {
+		return nil, rpcInternalError("The script hash index must be "+
+			"enabled to query script hash history (specify "+
+			"--scripthashindex)", "Configuration")
+	}
+
+	// Convert the provided script hash hex to a Hash.
+	scriptHash, err := chainhash.NewHashFromStr(c.ScriptHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.ScriptHash)
+	}
+
+	// Override the default number of requested entries if needed.  Also,
+	// just return now if the number of requested entries is zero to avoid
+	// extra work.
+	numRequested := 100
+	if c.Count != nil {
+		numRequested = *c.Count
+		if numRequested < 0 {
+			numRequested = 1
+		}
+	}
+	if numRequested == 0 {
+		return nil, nil
+	}
+
+	// Limit the number of entries to the max allowed.
+	const maxCount = 10000
+	if numRequested > maxCount {
+		numRequested = maxCount
+	}
+
+	// Override the default number of entries to skip if needed.
+	var numToSkip int
+	if c.Skip != nil {
+		numToSkip = *c.Skip
+		if numToSkip < 0 {
+			numToSkip = 0
+		}
+	}
+
+	err = waitForIndexSync(ctx, s, s.cfg.ScriptHashIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.cfg.ScriptHashIndexer.History(scriptHash, numToSkip,
+		numRequested)
+	if err != nil {
+		context := "Failed to retrieve script hash history"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	best := s.cfg.Chain.BestSnapshot()
+	results := make([]types.GetScriptHashHistoryResult, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		blockHash, err := s.cfg.Chain.BlockHashByHeight(entry.BlockHeight)
+		if err != nil {
+			context := "Failed to retrieve block hash"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		results = append(results, types.GetScriptHashHistoryResult{
+			TxID:          entry.TxHash.String(),
+			BlockHash:     blockHash.String(),
+			BlockHeight:   entry.BlockHeight,
+			Confirmations: 1 + best.Height - entry.BlockHeight,
+		})
+	}
+	return results, nil
+}
+
+// handleGetSpendingInfo implements the getspendinginfo command.
+func handleGetSpendingInfo(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetSpendingInfoCmd)
+
+	if s.cfg.SpendIndexer == nil {
+		return nil, rpcInternalError("The spend index must be enabled to "+
+			"query spending transactions (specify --spendindex)",
+			"Configuration")
+	}
+
+	// Convert the provided transaction hash hex to a Hash.
+	txHash, err := chainhash.NewHashFromStr(c.Txid)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Txid)
+	}
+
+	// The outpoint may be in either transaction tree, so check both of them.
+	outpoints := [2]wire.OutPoint{
+		{Hash: *txHash, Index: c.Vout, Tree: wire.TxTreeRegular},
+		{Hash: *txHash, Index: c.Vout, Tree: wire.TxTreeStake},
+	}
+
+	// If requested, look for an unconfirmed spender in the mempool first.
+	includeMempool := true
+	if c.IncludeMempool != nil {
+		includeMempool = *c.IncludeMempool
+	}
+	if includeMempool {
+		for _, outpoint := range outpoints {
+			spender := s.cfg.TxMempooler.CheckSpend(outpoint)
+			if spender == nil {
+				continue
+			}
+			for txInIdx, txIn := range spender.MsgTx().TxIn {
+				if txIn.PreviousOutPoint == outpoint {
+					return &types.GetSpendingInfoResult{
+						Spent: true,
+						TxID:  spender.Hash().String(),
+						Vin:   uint32(txInIdx),
+					}, nil
+				}
+			}
+		}
 	}
 
-	rawTxn, err := s.createTxRawResult(s.cfg.ChainParams, mtx, txHash.String(),
-		blkIndex, blkHeader, blkHashStr, blkHeight, confirmations,
-		isTreasuryEnabled)
+	// Look for a spender in the main chain.
+	err = waitForIndexSync(ctx, s, s.cfg.SpendIndexer.Name())
 	if err != nil {
 		return nil, err
 	}
-	return *rawTxn, nil
+	for i := range outpoints {
+		entry, err := s.cfg.SpendIndexer.Entry(&outpoints[i])
+		if err != nil {
+			context := "Failed to retrieve spending transaction"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		if entry == nil {
+			continue
+		}
+		best := s.cfg.Chain.BestSnapshot()
+		return &types.GetSpendingInfoResult{
+			Spent:         true,
+			TxID:          entry.SpendingTx.String(),
+			Vin:           entry.InputIndex,
+			BlockHash:     entry.BlockHash.String(),
+			BlockHeight:   entry.BlockHeight,
+			Confirmations: 1 + best.Height - entry.BlockHeight,
+		}, nil
+	}
+
+	return &types.GetSpendingInfoResult{Spent: false}, nil
 }
 
 // handleGetStakeDifficulty implements the getstakedifficulty command.
@@ -3102,6 +4007,81 @@ func handleGetStakeVersions(_ context.Context, s *Server, cmd interface{}) (inte
 	return result, nil
 }
 
+// handleGetTicketInfo implements the getticketinfo command.
+func handleGetTicketInfo(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetTicketInfoCmd)
+
+	if s.cfg.TicketIndexer == nil {
+		return nil, rpcInternalError("The ticket index must be enabled to "+
+			"query ticket information (specify --ticketindex)",
+			"Configuration")
+	}
+
+	// Convert the provided ticket hash hex to a Hash.
+	ticket, err := chainhash.NewHashFromStr(c.Ticket)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Ticket)
+	}
+
+	err = waitForIndexSync(ctx, s, s.cfg.TicketIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.cfg.TicketIndexer.Entry(ticket)
+	if err != nil {
+		context := "Failed to retrieve ticket"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	if entry == nil {
+		return nil, rpcNoTxInfoError(ticket)
+	}
+
+	// Determine the status of the ticket.  Tickets that have not been spent,
+	// missed, or expired are either live or immature depending on whether
+	// they have reached maturity as of the current best chain tip.
+	var status string
+	switch {
+	case entry.Voted:
+		status = "voted"
+	case entry.Revoked:
+		status = "revoked"
+	case entry.ExpiredHeight != 0:
+		status = "expired"
+	case entry.MissedHeight != 0:
+		status = "missed"
+	default:
+		best := s.cfg.Chain.BestSnapshot()
+		maturity := int64(s.cfg.ChainParams.TicketMaturity)
+		status = "immature"
+		if best.Height-entry.PurchaseHeight >= maturity {
+			status = "live"
+		}
+	}
+
+	result := &types.GetTicketInfoResult{
+		Ticket:          ticket.String(),
+		Status:          status,
+		PurchaseBlock:   entry.PurchaseBlock.String(),
+		PurchaseHeight:  entry.PurchaseHeight,
+		Price:           dcrutil.Amount(entry.Price).ToCoin(),
+		RewardAddresses: make([]string, 0, len(entry.RewardAddresses)),
+		MissedHeight:    entry.MissedHeight,
+		ExpiredHeight:   entry.ExpiredHeight,
+	}
+	if entry.VotingAddress != nil {
+		result.VotingAddress = entry.VotingAddress.Address()
+	}
+	for _, addr := range entry.RewardAddresses {
+		result.RewardAddresses = append(result.RewardAddresses,
+			addr.Address())
+	}
+	if entry.Voted || entry.Revoked {
+		result.SpendingTx = entry.SpendingTx.String()
+		result.SpendingHeight = entry.SpendingHeight
+	}
+	return result, nil
+}
+
 // handleGetTicketPoolValue implements the getticketpoolvalue command.
 func handleGetTicketPoolValue(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	amt, err := s.cfg.Chain.TicketPoolValue()
@@ -3161,6 +4141,109 @@ func handleGetTreasuryBalance(_ context.Context, s *Server, cmd interface{}) (in
 	return tbr, nil
 }
 
+// handleGetTreasuryHistory implements the gettreasuryhistory command.
+func handleGetTreasuryHistory(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.GetTreasuryHistoryCmd)
+
+	if s.cfg.TreasuryIndexer == nil {
+		return nil, rpcInternalError("The treasury index must be enabled "+
+			"to query the treasury history (specify --treasuryindex)",
+			"Configuration")
+	}
+
+	// Default to the entire main chain when the start and end heights are not
+	// provided.
+	var startHeight int64
+	if c.StartHeight != nil {
+		startHeight = *c.StartHeight
+	}
+	endHeight := s.cfg.Chain.BestSnapshot().Height
+	if c.EndHeight != nil {
+		endHeight = *c.EndHeight
+	}
+	if startHeight < 0 {
+		return nil, rpcInvalidError("Start height %d must not be negative",
+			startHeight)
+	}
+	if endHeight < startHeight {
+		return nil, rpcInvalidError("End height %d must not be less than "+
+			"start height %d", endHeight, startHeight)
+	}
+
+	// Parse the treasury transaction types to filter by, if any.
+	var txTypes []indexers.TreasuryTxType
+	if c.Types != nil {
+		for _, typ := range *c.Types {
+			switch typ {
+			case indexers.TreasuryTxTypeTAdd.String():
+				txTypes = append(txTypes, indexers.TreasuryTxTypeTAdd)
+			case indexers.TreasuryTxTypeTBase.String():
+				txTypes = append(txTypes, indexers.TreasuryTxTypeTBase)
+			case indexers.TreasuryTxTypeTSpend.String():
+				txTypes = append(txTypes, indexers.TreasuryTxTypeTSpend)
+			default:
+				return nil, rpcInvalidError("Invalid treasury transaction "+
+					"type %q (must be tadd, treasurybase, or tspend)", typ)
+			}
+		}
+	}
+
+	// Override the default number of requested entries if needed.  Also,
+	// just return now if the number of requested entries is zero to avoid
+	// extra work.
+	numRequested := 100
+	if c.Count != nil {
+		numRequested = *c.Count
+		if numRequested < 0 {
+			numRequested = 1
+		}
+	}
+	if numRequested == 0 {
+		return nil, nil
+	}
+
+	// Limit the number of entries to the max allowed.
+	const maxCount = 10000
+	if numRequested > maxCount {
+		numRequested = maxCount
+	}
+
+	// Override the default number of entries to skip if needed.
+	var numToSkip int
+	if c.Skip != nil {
+		numToSkip = *c.Skip
+		if numToSkip < 0 {
+			numToSkip = 0
+		}
+	}
+
+	err := waitForIndexSync(ctx, s, s.cfg.TreasuryIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.cfg.TreasuryIndexer.Entries(startHeight, endHeight,
+		txTypes, numToSkip, numRequested)
+	if err != nil {
+		context := "Failed to retrieve treasury history"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	results := make([]types.GetTreasuryHistoryResult, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		results = append(results, types.GetTreasuryHistoryResult{
+			Type:        entry.Type.String(),
+			TxID:        entry.TxHash.String(),
+			BlockHash:   entry.BlockHash.String(),
+			BlockHeight: entry.BlockHeight,
+			Amount:      entry.Amount,
+			Fee:         entry.Fee,
+			Balance:     entry.Balance,
+		})
+	}
+	return results, nil
+}
+
 // handleGetTreasurySpendVotes implements the gettreasuryspendvotes command.
 func handleGetTreasurySpendVotes(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.GetTreasurySpendVotesCmd)
@@ -3638,22 +4721,46 @@ func handleGetTxOut(_ context.Context, s *Server, cmd interface{}) (interface{},
 	return txOutReply, nil
 }
 
-// handleGetTxOutSetInfo returns statistics on the current unspent transaction output set.
+// handleGetTxOutSetInfo returns statistics on the unspent transaction output
+// set as of the current best block or, optionally, a recent block at the
+// provided height.
 func handleGetTxOutSetInfo(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
-	best := s.cfg.Chain.BestSnapshot()
-	stats, err := s.cfg.Chain.FetchUtxoStats()
+	c := cmd.(*types.GetTxOutSetInfoCmd)
+	chain := s.cfg.Chain
+
+	var stats *blockchain.UtxoStats
+	var err error
+	if c.Height == nil {
+		stats, err = chain.FetchUtxoStats()
+	} else {
+		best := chain.BestSnapshot()
+		if *c.Height < 0 || *c.Height > best.Height {
+			return nil, &dcrjson.RPCError{
+				Code: dcrjson.ErrRPCOutOfRange,
+				Message: fmt.Sprintf("Block number out of range: %v",
+					*c.Height),
+			}
+		}
+		stats, err = chain.FetchUtxoStatsByHeight(*c.Height)
+		if errors.Is(err, blockchain.ErrNoUtxoStats) {
+			return nil, rpcMiscError(fmt.Sprintf("Unspent transaction "+
+				"output set statistics are not available for height %d",
+				*c.Height))
+		}
+	}
 	if err != nil {
-		return nil, err
+		context := "Failed to fetch utxo set statistics"
+		return nil, rpcInternalError(err.Error(), context)
 	}
 
 	return types.GetTxOutSetInfoResult{
-		Height:         best.Height,
-		BestBlock:      best.Hash.String(),
-		Transactions:   stats.Transactions,
-		TxOuts:         stats.Utxos,
-		DiskSize:       stats.Size,
-		TotalAmount:    stats.Total,
-		SerializedHash: stats.SerializedHash.String(),
+		Height:       stats.BlockHeight,
+		BestBlock:    stats.BlockHash.String(),
+		Transactions: stats.Transactions,
+		TxOuts:       stats.Utxos,
+		DiskSize:     stats.Size,
+		TotalAmount:  stats.Total,
+		MuHash:       stats.MuHash.String(),
 	}, nil
 }
 
@@ -4024,6 +5131,35 @@ func handleRegenTemplate(_ context.Context, s *Server, cmd interface{}) (interfa
 	return nil, nil
 }
 
+// handleReindex implements the reindex command.
+func handleReindex(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.ReindexCmd)
+
+	name := indexers.IndexName(c.IndexName)
+	var enabled bool
+	if s.cfg.IndexSyncer != nil {
+		for _, info := range s.cfg.IndexSyncer.IndexInfo() {
+			if info.Name == name {
+				enabled = true
+				break
+			}
+		}
+	}
+	if !enabled {
+		return nil, rpcInvalidError("The %s is not enabled", name)
+	}
+	if c.Height < 0 {
+		return nil, rpcInvalidError("Height %d must not be negative",
+			c.Height)
+	}
+
+	err := s.cfg.IndexSyncer.Reindex(ctx, name, c.Height)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Could not rewind index")
+	}
+	return nil, nil
+}
+
 // retrievedTx represents a transaction that was either loaded from the
 // transaction memory pool or from the database.  When a transaction is loaded
 // from the database, it is loaded with the raw serialized bytes while the
@@ -4314,8 +5450,108 @@ func fetchMempoolTxnsForAddress(s *Server, addr dcrutil.Address, numToSkip, numR
 	return mpTxns[numToSkip:rangeEnd], numToSkip
 }
 
+// handleSearchNullData implements the searchnulldata command.
+func handleSearchNullData(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
+	c := cmd.(*types.SearchNullDataCmd)
+
+	if s.cfg.NullDataIndexer == nil {
+		return nil, rpcInternalError("The null data index must be enabled "+
+			"to search null data outputs (specify --nulldataindex)",
+			"Configuration")
+	}
+
+	// Decode the payload prefix to search for.
+	prefix, err := hex.DecodeString(c.Prefix)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Prefix)
+	}
+	if len(prefix) == 0 {
+		return nil, rpcInvalidError("The payload prefix must not be empty")
+	}
+
+	// Default to the entire main chain when the start and end heights are not
+	// provided.
+	best := s.cfg.Chain.BestSnapshot()
+	var startHeight int64
+	if c.StartHeight != nil {
+		startHeight = *c.StartHeight
+	}
+	endHeight := best.Height
+	if c.EndHeight != nil {
+		endHeight = *c.EndHeight
+	}
+	if startHeight < 0 {
+		return nil, rpcInvalidError("Start height %d must not be negative",
+			startHeight)
+	}
+	if endHeight < startHeight {
+		return nil, rpcInvalidError("End height %d must not be less than "+
+			"start height %d", endHeight, startHeight)
+	}
+
+	// Override the default number of requested entries if needed.  Also,
+	// just return now if the number of requested entries is zero to avoid
+	// extra work.
+	numRequested := 100
+	if c.Count != nil {
+		numRequested = *c.Count
+		if numRequested < 0 {
+			numRequested = 1
+		}
+	}
+	if numRequested == 0 {
+		return nil, nil
+	}
+
+	// Limit the number of entries to the max allowed.
+	const maxCount = 10000
+	if numRequested > maxCount {
+		numRequested = maxCount
+	}
+
+	// Override the default number of entries to skip if needed.
+	var numToSkip int
+	if c.Skip != nil {
+		numToSkip = *c.Skip
+		if numToSkip < 0 {
+			numToSkip = 0
+		}
+	}
+
+	err = waitForIndexSync(ctx, s, s.cfg.NullDataIndexer.Name())
+	if err != nil {
+		return nil, err
+	}
+	entries, err := s.cfg.NullDataIndexer.Search(prefix, startHeight,
+		endHeight, numToSkip, numRequested)
+	if err != nil {
+		context := "Failed to search null data outputs"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	results := make([]types.SearchNullDataResult, 0, len(entries))
+	for i := range entries {
+		entry := &entries[i]
+		blockHash, err := s.cfg.Chain.BlockHashByHeight(entry.BlockHeight)
+		if err != nil {
+			context := "Failed to retrieve block hash"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		results = append(results, types.SearchNullDataResult{
+			TxID:          entry.TxHash.String(),
+			Vout:          entry.OutputIndex,
+			BlockHash:     blockHash.String(),
+			BlockHeight:   entry.BlockHeight,
+			BlockIndex:    entry.TxIndex,
+			Confirmations: 1 + best.Height - entry.BlockHeight,
+			Data:          hex.EncodeToString(entry.Data),
+		})
+	}
+	return results, nil
+}
+
 // handleSearchRawTransactions implements the searchrawtransactions command.
-func handleSearchRawTransactions(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+func handleSearchRawTransactions(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	// Respond with an error if the address index is not enabled.
 	if s.cfg.AddrIndexer == nil {
 		return nil, rpcInternalError("Address index must be "+
@@ -4379,6 +5615,13 @@ func handleSearchRawTransactions(_ context.Context, s *Server, cmd interface{})
 		reverse = *c.Reverse
 	}
 
+	// Ensure the address index has indexed the current best chain tip so
+	// transactions that were recently removed from the mempool due to being
+	// included in a block are not missed.
+	if err := waitForIndexSync(ctx, s, s.cfg.AddrIndexer.Name()); err != nil {
+		return nil, err
+	}
+
 	// Add transactions from mempool first if client asked for reverse
 	// order.  Otherwise, they will be added last (as needed depending on
 	// the requested counts).
@@ -5060,7 +6303,7 @@ func handleTicketFeeInfo(_ context.Context, s *Server, cmd interface{}) (interfa
 }
 
 // handleTicketsForAddress implements the ticketsforaddress command.
-func handleTicketsForAddress(_ context.Context, s *Server, cmd interface{}) (interface{}, error) {
+func handleTicketsForAddress(ctx context.Context, s *Server, cmd interface{}) (interface{}, error) {
 	c := cmd.(*types.TicketsForAddressCmd)
 
 	// Decode the provided address.  This also ensures the network encoded
@@ -5070,17 +6313,38 @@ func handleTicketsForAddress(_ context.Context, s *Server, cmd interface{}) (int
 		return nil, rpcInvalidError("Invalid address: %v", err)
 	}
 
-	// Determine if the treasury rules are active as of the current best tip.
-	chain := s.cfg.Chain
-	prevBlkHash := chain.BestSnapshot().Hash
-	isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
-	if err != nil {
-		return nil, err
-	}
+	var tickets []chainhash.Hash
+	if c.IncludeHistorical != nil && *c.IncludeHistorical {
+		// Historical tickets are only available from the ticket index.
+		if s.cfg.TicketIndexer == nil {
+			return nil, rpcInternalError("The ticket index must be "+
+				"enabled to query historical tickets (specify "+
+				"--ticketindex)", "Configuration")
+		}
+		err = waitForIndexSync(ctx, s, s.cfg.TicketIndexer.Name())
+		if err != nil {
+			return nil, err
+		}
+		tickets, err = s.cfg.TicketIndexer.TicketsForAddress(addr)
+		if err != nil {
+			return nil, rpcInternalError(err.Error(),
+				"could not obtain tickets")
+		}
+	} else {
+		// Determine if the treasury rules are active as of the current best
+		// tip.
+		chain := s.cfg.Chain
+		prevBlkHash := chain.BestSnapshot().Hash
+		isTreasuryEnabled, err := s.isTreasuryAgendaActive(&prevBlkHash)
+		if err != nil {
+			return nil, err
+		}
 
-	tickets, err := chain.TicketsWithAddress(addr, isTreasuryEnabled)
-	if err != nil {
-		return nil, rpcInternalError(err.Error(), "could not obtain tickets")
+		tickets, err = chain.TicketsWithAddress(addr, isTreasuryEnabled)
+		if err != nil {
+			return nil, rpcInternalError(err.Error(),
+				"could not obtain tickets")
+		}
 	}
 
 	ticketStrings := make([]string, len(tickets))
@@ -5404,6 +6668,18 @@ type Server struct {
 	hmacMu                 sync.Mutex
 	authsha                [sha256.Size]byte
 	limitauthsha           [sha256.Size]byte
+	cookieauthsha          [sha256.Size]byte
+	adminUser              *rpcUser
+	limitUser              *rpcUser
+	cookieUser             *rpcUser
+	clientCertUsers        map[string]*rpcUser
+	users                  map[string]*hashedUser
+	authCache              map[[sha256.Size]byte]*rpcUser
+	authCacheMtx           sync.Mutex
+	dummyPasswordHash      string
+	hashCheckSem           chan struct{}
+	authFailures           map[string]*authFailure
+	authFailuresMtx        sync.Mutex
 	ntfnMgr                NtfnManager
 	statusLines            map[int]string
 	statusLock             sync.RWMutex
@@ -5484,6 +6760,12 @@ func (s *Server) writeHTTPResponseHeaders(req *http.Request, headers http.Header
 // shutdown terminates the processes of the rpc server.
 func (s *Server) shutdown() error {
 	log.Warnf("RPC server shutting down")
+	if s.cfg.RPCCookiePath != "" {
+		err := os.Remove(s.cfg.RPCCookiePath)
+		if err != nil && !os.IsNotExist(err) {
+			log.Errorf("Unable to remove RPC cookie file: %v", err)
+		}
+	}
 	for _, listener := range s.cfg.Listeners {
 		err := listener.Close()
 		if err != nil {
@@ -5609,39 +6891,36 @@ func (s *Server) authMAC(dst, auth []byte) []byte {
 
 // checkAuth checks the HTTP Basic authentication supplied by a wallet or RPC
 // client in the HTTP request r.  If the supplied authentication does not match
-// the username and password expected, a non-nil error is returned.
-//
-// This check is time-constant.
+// the credentials of any user, a non-nil error is returned.  When no
+// authentication is supplied, the verified TLS client certificate of the
+// connection, if any, is used to identify the user instead.
 //
-// The first bool return value signifies auth success (true if successful) and
-// the second bool return value specifies whether the user can change the state
-// of the server (true) or whether the user is limited (false). The second is
-// always false if the first is.
-func (s *Server) checkAuth(r *http.Request, require bool) (bool, bool, error) {
+// The returned user identifies the authenticated user along with the methods
+// it is authorized to call.  It is nil when no authentication was supplied and
+// it is not required.
+func (s *Server) checkAuth(r *http.Request, require bool) (*rpcUser, error) {
 	authhdr := r.Header["Authorization"]
 	if len(authhdr) <= 0 {
+		if user := s.clientCertUser(r.TLS); user != nil {
+			return user, nil
+		}
 		if require {
 			log.Warnf("RPC authentication failure from %s",
 				r.RemoteAddr)
-			return false, false, errors.New("auth failure")
+			return nil, errors.New("auth failure")
 		}
 
-		return false, false, nil
+		return nil, nil
 	}
 
-	mac := make([]byte, 0, sha256.Size)
-	mac = s.authMAC(mac, []byte(authhdr[0]))
-
-	cmp := subtle.ConstantTimeCompare(mac, s.authsha[:])
-	limitcmp := subtle.ConstantTimeCompare(mac, s.limitauthsha[:])
-	if cmp|limitcmp == 0 {
-		// Request's auth doesn't match either user
+	user := s.authenticate(authhdr[0], r.RemoteAddr)
+	if user == nil {
+		// Request's auth doesn't match any user
 		log.Warnf("RPC authentication failure from %s", r.RemoteAddr)
-		return false, false, errors.New("auth failure")
+		return nil, errors.New("auth failure")
 	}
 
-	isAdmin := cmp == 1
-	return true, isAdmin, nil
+	return user, nil
 }
 
 // parsedRPCCmd represents a JSON-RPC request object that has been parsed into
@@ -5668,6 +6947,20 @@ func (s *Server) standardCmdResult(ctx context.Context, cmd *parsedRPCCmd) (inte
 	return handler(ctx, s, cmd.params)
 }
 
+// observeRequest notifies the request observer, if any, that a request for the
+// provided method which started processing at the provided time has been
+// processed.  Methods the server does not handle are reported as "unknown" so
+// clients are unable to create an unbounded number of distinct methods.
+func (s *Server) observeRequest(method string, start time.Time, failed bool) {
+	if s.cfg.RequestObserver == nil {
+		return
+	}
+	if !isKnownMethod(method) {
+		method = "unknown"
+	}
+	s.cfg.RequestObserver.ObserveRequest(method, time.Since(start), failed)
+}
+
 // parseCmd parses a JSON-RPC request object into known concrete command.  The
 // err field of the returned parsedRPCCmd struct will contain an RPC error that
 // is suitable for use in replies if the command is invalid in some way such as
@@ -5720,15 +7013,13 @@ func createMarshalledReply(rpcVersion string, id interface{}, result interface{}
 
 // processRequest determines the incoming request type (single or batched),
 // parses it and returns a marshalled response.
-func (s *Server) processRequest(ctx context.Context, request *dcrjson.Request, isAdmin bool) []byte {
+func (s *Server) processRequest(ctx context.Context, request *dcrjson.Request, user *rpcUser, remoteAddr string) []byte {
+	start := time.Now()
 	var result interface{}
 	var jsonErr error
 
-	if !isAdmin {
-		if _, ok := rpcLimited[request.Method]; !ok {
-			jsonErr = rpcInvalidError("limited user not " +
-				"authorized for this method")
-		}
+	if !s.authorized(user, request.Method, remoteAddr) {
+		jsonErr = rpcInvalidError("user not authorized for this method")
 	}
 
 	if jsonErr == nil {
@@ -5760,6 +7051,7 @@ func (s *Server) processRequest(ctx context.Context, request *dcrjson.Request, i
 			result, jsonErr = s.standardCmdResult(ctx, parsedCmd)
 		}
 	}
+	s.observeRequest(request.Method, start, jsonErr != nil)
 
 	// Marshal the response.
 	msg, err := createMarshalledReply(request.Jsonrpc, request.ID, result, jsonErr)
@@ -5771,7 +7063,7 @@ func (s *Server) processRequest(ctx context.Context, request *dcrjson.Request, i
 }
 
 // jsonRPCRead handles reading and responding to RPC messages.
-func (s *Server) jsonRPCRead(sCtx context.Context, w http.ResponseWriter, r *http.Request, isAdmin bool) {
+func (s *Server) jsonRPCRead(sCtx context.Context, w http.ResponseWriter, r *http.Request, user *rpcUser) {
 	select {
 	case <-sCtx.Done():
 		return
@@ -5853,7 +7145,7 @@ func (s *Server) jsonRPCRead(sCtx context.Context, w http.ResponseWriter, r *htt
 				log.Errorf("Failed to create reply: %v", err)
 			}
 		} else {
-			resp = s.processRequest(ctx, &req, isAdmin)
+			resp = s.processRequest(ctx, &req, user, r.RemoteAddr)
 		}
 
 		if resp != nil {
@@ -5922,7 +7214,7 @@ func (s *Server) jsonRPCRead(sCtx context.Context, w http.ResponseWriter, r *htt
 						continue
 					}
 
-					resp = s.processRequest(ctx, &req, isAdmin)
+					resp = s.processRequest(ctx, &req, user, r.RemoteAddr)
 					if resp != nil {
 						results = append(results, resp)
 					}
@@ -6017,19 +7309,19 @@ func (s *Server) route(ctx context.Context) *http.Server {
 		// Keep track of the number of connected clients.
 		s.incrementClients()
 		defer s.decrementClients()
-		_, isAdmin, err := s.checkAuth(r, true)
+		user, err := s.checkAuth(r, true)
 		if err != nil {
 			jsonAuthFail(w)
 			return
 		}
 
 		// Read and respond to the request.
-		s.jsonRPCRead(ctx, w, r, isAdmin)
+		s.jsonRPCRead(ctx, w, r, user)
 	})
 
 	// Websocket endpoint.
 	rpcServeMux.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		authenticated, isAdmin, err := s.checkAuth(r, false)
+		user, err := s.checkAuth(r, false)
 		if err != nil {
 			jsonAuthFail(w)
 			return
@@ -6064,8 +7356,15 @@ func (s *Server) route(ctx context.Context) *http.Server {
 			log.Tracef("pong payload: %s", payload)
 			return nil
 		})
-		s.WebsocketHandler(ws, r.RemoteAddr, authenticated, isAdmin)
+		s.WebsocketHandler(ws, r.RemoteAddr, user)
 	})
+
+	// Unauthenticated read-only REST endpoint.
+	if s.cfg.EnableREST {
+		rpcServeMux.HandleFunc(restPathPrefix, func(w http.ResponseWriter, r *http.Request) {
+			s.handleREST(ctx, w, r)
+		})
+	}
 	return httpServer
 }
 
@@ -6138,6 +7437,10 @@ type Config struct {
 	// use.
 	ExistsAddresser ExistsAddresser
 
+	// DataDir defines the data directory of the node.  Relative paths for
+	// files written by commands such as dumputxoset are relative to it.
+	DataDir string
+
 	// These fields allow the RPC server to interface with the local block
 	// chain data and state.
 	TimeSource    blockchain.MedianTimeSource
@@ -6177,6 +7480,38 @@ type Config struct {
 	// AddrIndexer defines the optional address indexer for the RPC server to use.
 	AddrIndexer AddrIndexer
 
+	// SpendIndexer defines the optional spend indexer for the RPC server to
+	// use.
+	SpendIndexer SpendIndexer
+
+	// TicketIndexer defines the optional ticket indexer for the RPC server to
+	// use.
+	TicketIndexer TicketIndexer
+
+	// TreasuryIndexer defines the optional treasury indexer for the RPC server
+	// to use.
+	TreasuryIndexer TreasuryIndexer
+
+	// ScriptHashIndexer defines the optional script hash indexer for the RPC
+	// server to use.
+	ScriptHashIndexer ScriptHashIndexer
+
+	// AddrUtxoIndexer defines the optional address utxo indexer for the RPC
+	// server to use.
+	AddrUtxoIndexer AddrUtxoIndexer
+
+	// BlockStatsIndexer defines the optional block stats indexer for the RPC
+	// server to use.
+	BlockStatsIndexer BlockStatsIndexer
+
+	// NullDataIndexer defines the optional null data indexer for the RPC
+	// server to use.
+	NullDataIndexer NullDataIndexer
+
+	// IndexSyncer defines the optional index sync state provider for the RPC
+	// server to use.  It is nil when no indexes are enabled.
+	IndexSyncer IndexSyncer
+
 	// NetInfo defines a slice of the available networks.
 	NetInfo []types.NetworksResult
 
@@ -6194,6 +7529,23 @@ type Config struct {
 	RPCLimitUser string
 	RPCLimitPass string
 
+	// RPCUsers defines additional users for RPC connections along with the
+	// methods each of them is authorized to call.
+	RPCUsers []User
+
+	// RPCCookiePath defines the path of the cookie file to write randomly
+	// generated admin credentials to when it is not empty.  The credentials
+	// change each time the server is created and the file is removed when
+	// the server shuts down.
+	RPCCookiePath string
+
+	// RPCAdminClients and RPCLimitClients define the subject common names of
+	// the verified TLS client certificates that authenticate connections as
+	// the admin and limited users respectively when no other credentials are
+	// supplied.
+	RPCAdminClients []string
+	RPCLimitClients []string
+
 	// RPCMaxClients defines the max number of RPC clients for standard
 	// connections.
 	RPCMaxClients int
@@ -6205,6 +7557,10 @@ type Config struct {
 	// RPCMaxWebsockets defines the max number of RPC websocket connections.
 	RPCMaxWebsockets int
 
+	// EnableREST indicates whether or not to serve the unauthenticated
+	// read-only REST interface under /rest/ alongside the RPC endpoints.
+	EnableREST bool
+
 	// TestNet represents whether or not the server is using testnet.
 	TestNet bool
 
@@ -6230,6 +7586,10 @@ type Config struct {
 
 	// FiltererV2 defines the V2 filterer for the RPC server to use.
 	FiltererV2 FiltererV2
+
+	// RequestObserver defines an optional observer that is notified of every
+	// request the RPC server processes, such as to collect metrics.
+	RequestObserver RequestObserver
 }
 
 // New returns a new instance of the Server struct.
@@ -6259,6 +7619,75 @@ func New(config *Config) (*Server, error) {
 			base64.StdEncoding.EncodeToString([]byte(login))
 		rpc.authMAC(rpc.limitauthsha[:0], []byte(auth))
 	}
+	rpc.adminUser = &rpcUser{name: config.RPCUser, isAdmin: true}
+	rpc.limitUser = &rpcUser{name: config.RPCLimitUser, methods: rpcLimited}
+	rpc.users = make(map[string]*hashedUser, len(config.RPCUsers))
+	rpc.authCache = make(map[[sha256.Size]byte]*rpcUser)
+	rpc.hashCheckSem = make(chan struct{}, maxConcurrentHashChecks)
+	rpc.authFailures = make(map[string]*authFailure)
+	for _, user := range config.RPCUsers {
+		if user.Name == config.RPCUser || user.Name == config.RPCLimitUser {
+			return nil, fmt.Errorf("RPC user %q conflicts with the admin or "+
+				"limited user", user.Name)
+		}
+		if _, ok := rpc.users[user.Name]; ok {
+			return nil, fmt.Errorf("duplicate RPC user %q", user.Name)
+		}
+		if err := validatePasswordHash(user.PasswordHash); err != nil {
+			return nil, fmt.Errorf("RPC user %q: %w", user.Name, err)
+		}
+		hashed := &hashedUser{
+			rpcUser: rpcUser{
+				name:    user.Name,
+				methods: make(map[string]struct{}, len(user.Methods)),
+			},
+			passwordHash: user.PasswordHash,
+		}
+		for _, method := range user.Methods {
+			if method == allMethodsEntry {
+				hashed.isAdmin = true
+			}
+			hashed.methods[method] = struct{}{}
+		}
+		rpc.users[user.Name] = hashed
+	}
+	if len(config.RPCUsers) > 0 {
+		// Passwords for unknown users are checked against a dummy hash
+		// with the same parameters as the first user so they take about
+		// as long to check as those of known users.
+		hash := config.RPCUsers[0].PasswordHash
+		rpc.dummyPasswordHash, err = dummyPasswordHash(hash)
+		if err != nil {
+			return nil, err
+		}
+	}
+	numClientCerts := len(config.RPCAdminClients) + len(config.RPCLimitClients)
+	rpc.clientCertUsers = make(map[string]*rpcUser, numClientCerts)
+	for _, name := range config.RPCAdminClients {
+		rpc.clientCertUsers[name] = &rpcUser{name: name, isAdmin: true}
+	}
+	for _, name := range config.RPCLimitClients {
+		if _, ok := rpc.clientCertUsers[name]; ok {
+			return nil, fmt.Errorf("RPC client certificate %q may not be "+
+				"mapped to both the admin and limited user", name)
+		}
+		rpc.clientCertUsers[name] = &rpcUser{name: name, methods: rpcLimited}
+	}
+	if config.RPCCookiePath != "" {
+		if _, ok := rpc.users[cookieUsername]; ok {
+			return nil, fmt.Errorf("RPC user %q conflicts with the cookie "+
+				"user", cookieUsername)
+		}
+		cookiePass, err := writeCookieFile(config.RPCCookiePath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to write RPC cookie file: %w", err)
+		}
+		login := cookieUsername + ":" + cookiePass
+		auth := "Basic " +
+			base64.StdEncoding.EncodeToString([]byte(login))
+		rpc.authMAC(rpc.cookieauthsha[:0], []byte(auth))
+		rpc.cookieUser = &rpcUser{name: cookieUsername, isAdmin: true}
+	}
 	rpc.ntfnMgr = newWsNotificationManager(&rpc)
 
 	return &rpc, nil
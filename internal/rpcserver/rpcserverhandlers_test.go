@@ -12,6 +12,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/big"
@@ -20,6 +21,8 @@ import (
 	"path/filepath"
 	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -41,6 +44,7 @@ import (
 	"github.com/decred/dcrd/internal/version"
 	"github.com/decred/dcrd/peer/v2"
 	"github.com/decred/dcrd/rpc/jsonrpc/types/v2"
+	"github.com/decred/dcrd/txscript/v3"
 	"github.com/decred/dcrd/wire"
 )
 
@@ -159,6 +163,7 @@ type testRPCChain struct {
 	headerByHeight                wire.BlockHeader
 	headerByHeightErr             error
 	heightRangeFn                 func(startHeight, endHeight int64) ([]chainhash.Hash, error)
+	isBlockPruned                 bool
 	isCurrent                     bool
 	liveTickets                   []chainhash.Hash
 	liveTicketsErr                error
@@ -184,6 +189,13 @@ type testRPCChain struct {
 	tspendVotes                   tspendVotes
 	treasuryActive                bool
 	treasuryActiveErr             error
+	utxoCacheStats                blockchain.UtxoCacheStats
+	utxoSnapshot                  *blockchain.UtxoSnapshotInfo
+	utxoSnapshotErr               error
+	utxoStatsByHeight             *blockchain.UtxoStats
+	utxoStatsByHeightErr          error
+	spentOutputs                  []blockchain.SpentOutput
+	spentOutputsErr               error
 }
 
 // BestSnapshot returns a mocked blockchain.BestState.
@@ -312,6 +324,12 @@ func (c *testRPCChain) HeightRange(startHeight, endHeight int64) ([]chainhash.Ha
 	return c.heightRangeFn(startHeight, endHeight)
 }
 
+// IsBlockPruned returns a mocked bool representing whether or not the data for
+// the block with the given hash has been pruned.
+func (c *testRPCChain) IsBlockPruned(hash *chainhash.Hash) bool {
+	return c.isBlockPruned
+}
+
 // IsCurrent returns a mocked bool representing whether or not the chain
 // believes it is current.
 func (c *testRPCChain) IsCurrent() bool {
@@ -365,6 +383,12 @@ func (c *testRPCChain) StateLastChangedHeight(hash *chainhash.Hash, version uint
 	return c.stateLastChangedHeight, c.stateLastChangedHeightErr
 }
 
+// SuspendBlockPruning returns a mocked function that resumes the pruning of
+// block data.
+func (c *testRPCChain) SuspendBlockPruning() func() {
+	return func() {}
+}
+
 // TicketPoolValue returns a mocked current value of all the locked funds in the
 // ticket pool.
 func (c *testRPCChain) TicketPoolValue() (dcrutil.Amount, error) {
@@ -403,6 +427,33 @@ func (c *testRPCChain) TSpendCountVotes(*chainhash.Hash, *dcrutil.Tx) (int64, in
 	return c.tspendVotes.yes, c.tspendVotes.no, c.tspendVotes.err
 }
 
+// UtxoCacheStats returns mocked utxo cache statistics.
+func (c *testRPCChain) UtxoCacheStats() blockchain.UtxoCacheStats {
+	return c.utxoCacheStats
+}
+
+// DumpUtxoSnapshot writes mocked utxo set snapshot data to the writer and
+// returns mocked information about the snapshot.
+func (c *testRPCChain) DumpUtxoSnapshot(w io.Writer) (*blockchain.UtxoSnapshotInfo, error) {
+	if c.utxoSnapshotErr != nil {
+		return nil, c.utxoSnapshotErr
+	}
+	if _, err := w.Write([]byte("snapshot")); err != nil {
+		return nil, err
+	}
+	return c.utxoSnapshot, nil
+}
+
+// FetchUtxoStatsByHeight returns a mocked blockchain.UtxoStats.
+func (c *testRPCChain) FetchUtxoStatsByHeight(height int64) (*blockchain.UtxoStats, error) {
+	return c.utxoStatsByHeight, c.utxoStatsByHeightErr
+}
+
+// FetchSpentOutputs returns mocked previous outputs spent by a block.
+func (c *testRPCChain) FetchSpentOutputs(hash *chainhash.Hash) ([]blockchain.SpentOutput, error) {
+	return c.spentOutputs, c.spentOutputsErr
+}
+
 // testPeer provides a mock peer by implementing the Peer interface.
 type testPeer struct {
 	addr              string
@@ -577,6 +628,11 @@ func (a *testAddrIndexer) UnconfirmedTxnsForAddress(addr dcrutil.Address) []*dcr
 	return a.unconfirmedTxnsForAddress
 }
 
+// Name returns the mocked human-readable name of the address index.
+func (a *testAddrIndexer) Name() string {
+	return "address index"
+}
+
 // testTxIndexer provides a mock transaction indexer by implementing the
 // TxIndexer interface.
 type testTxIndexer struct {
@@ -589,6 +645,173 @@ func (t *testTxIndexer) Entry(hash *chainhash.Hash) (*indexers.TxIndexEntry, err
 	return t.entry(hash)
 }
 
+// Name returns the mocked human-readable name of the transaction index.
+func (t *testTxIndexer) Name() string {
+	return "transaction index"
+}
+
+// testSpendIndexer provides a mock spend indexer by implementing the
+// SpendIndexer interface.
+type testSpendIndexer struct {
+	entry func(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error)
+}
+
+// Entry returns mocked details about the transaction that spends the provided
+// outpoint from the spend index.
+func (s *testSpendIndexer) Entry(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error) {
+	return s.entry(outpoint)
+}
+
+// Name returns the mocked human-readable name of the spend index.
+func (s *testSpendIndexer) Name() string {
+	return "spend index"
+}
+
+// testTicketIndexer provides a mock ticket indexer by implementing the
+// TicketIndexer interface.
+type testTicketIndexer struct {
+	entry                func(ticket *chainhash.Hash) (*indexers.TicketIndexEntry, error)
+	ticketsForAddress    []chainhash.Hash
+	ticketsForAddressErr error
+}
+
+// Entry returns the mocked history of the provided ticket from the ticket
+// index.
+func (t *testTicketIndexer) Entry(ticket *chainhash.Hash) (*indexers.TicketIndexEntry, error) {
+	return t.entry(ticket)
+}
+
+// TicketsForAddress returns a mocked slice of ticket hashes that involve the
+// provided address.
+func (t *testTicketIndexer) TicketsForAddress(addr dcrutil.Address) ([]chainhash.Hash, error) {
+	return t.ticketsForAddress, t.ticketsForAddressErr
+}
+
+// Name returns the mocked human-readable name of the ticket index.
+func (t *testTicketIndexer) Name() string {
+	return "ticket index"
+}
+
+// testTreasuryIndexer provides a mock treasury indexer by implementing the
+// TreasuryIndexer interface.
+type testTreasuryIndexer struct {
+	entries func(startHeight, endHeight int64, txTypes []indexers.TreasuryTxType, skip, count int) ([]indexers.TreasuryHistoryEntry, error)
+}
+
+// Entries returns the mocked treasury transactions from the treasury index.
+func (t *testTreasuryIndexer) Entries(startHeight, endHeight int64, txTypes []indexers.TreasuryTxType, skip, count int) ([]indexers.TreasuryHistoryEntry, error) {
+	return t.entries(startHeight, endHeight, txTypes, skip, count)
+}
+
+// Name returns the mocked human-readable name of the treasury index.
+func (t *testTreasuryIndexer) Name() string {
+	return "treasury index"
+}
+
+// testScriptHashIndexer provides a mock script hash indexer by implementing
+// the ScriptHashIndexer interface.
+type testScriptHashIndexer struct {
+	history func(scriptHash *chainhash.Hash, skip, count int) ([]indexers.ScriptHashHistoryEntry, error)
+	balance func(scriptHash *chainhash.Hash) (int64, uint32, error)
+}
+
+// History returns the mocked transactions from the script hash index.
+func (s *testScriptHashIndexer) History(scriptHash *chainhash.Hash, skip, count int) ([]indexers.ScriptHashHistoryEntry, error) {
+	return s.history(scriptHash, skip, count)
+}
+
+// Balance returns the mocked balance and number of unspent outputs from the
+// script hash index.
+func (s *testScriptHashIndexer) Balance(scriptHash *chainhash.Hash) (int64, uint32, error) {
+	return s.balance(scriptHash)
+}
+
+// Name returns the mocked human-readable name of the script hash index.
+func (s *testScriptHashIndexer) Name() string {
+	return "script hash index"
+}
+
+// testAddrUtxoIndexer provides a mock address utxo indexer by implementing the
+// AddrUtxoIndexer interface.
+type testAddrUtxoIndexer struct {
+	utxosForAddress   func(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error)
+	balanceForAddress func(addr dcrutil.Address) (int64, uint32, error)
+}
+
+// UtxosForAddress returns the mocked unspent outputs for the given address
+// from the address utxo index.
+func (a *testAddrUtxoIndexer) UtxosForAddress(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error) {
+	return a.utxosForAddress(addr)
+}
+
+// BalanceForAddress returns the mocked balance and number of unspent outputs
+// for the given address from the address utxo index.
+func (a *testAddrUtxoIndexer) BalanceForAddress(addr dcrutil.Address) (int64, uint32, error) {
+	return a.balanceForAddress(addr)
+}
+
+// Name returns the mocked human-readable name of the address utxo index.
+func (a *testAddrUtxoIndexer) Name() string {
+	return "address utxo index"
+}
+
+// testBlockStatsIndexer provides a mock block stats indexer by implementing
+// the BlockStatsIndexer interface.
+type testBlockStatsIndexer struct {
+	stats func(height int64) (*indexers.BlockStats, error)
+}
+
+// Stats returns the mocked statistics for the block at the given height from
+// the block stats index.
+func (b *testBlockStatsIndexer) Stats(height int64) (*indexers.BlockStats, error) {
+	return b.stats(height)
+}
+
+// Name returns the mocked human-readable name of the block stats index.
+func (b *testBlockStatsIndexer) Name() string {
+	return "block stats index"
+}
+
+// testNullDataIndexer provides a mock null data indexer by implementing the
+// NullDataIndexer interface.
+type testNullDataIndexer struct {
+	search func(prefix []byte, startHeight, endHeight int64, skip, count int) ([]indexers.NullDataEntry, error)
+}
+
+// Search returns the mocked null data outputs from the null data index.
+func (n *testNullDataIndexer) Search(prefix []byte, startHeight, endHeight int64, skip, count int) ([]indexers.NullDataEntry, error) {
+	return n.search(prefix, startHeight, endHeight, skip, count)
+}
+
+// Name returns the mocked human-readable name of the null data index.
+func (n *testNullDataIndexer) Name() string {
+	return "null data index"
+}
+
+// testIndexSyncer provides a mock index sync state provider by implementing
+// the IndexSyncer interface.
+type testIndexSyncer struct {
+	indexInfo     []indexers.IndexInfo
+	waitForHeight func(ctx context.Context, name string, height int64) error
+	reindex       func(ctx context.Context, name string, height int64) error
+}
+
+// IndexInfo returns the mocked sync state of each of the enabled indexes.
+func (s *testIndexSyncer) IndexInfo() []indexers.IndexInfo {
+	return s.indexInfo
+}
+
+// WaitForHeight waits for a mocked index to index the main chain block at the
+// provided height.
+func (s *testIndexSyncer) WaitForHeight(ctx context.Context, name string, height int64) error {
+	return s.waitForHeight(ctx, name, height)
+}
+
+// Reindex rewinds a mocked index to the provided height.
+func (s *testIndexSyncer) Reindex(ctx context.Context, name string, height int64) error {
+	return s.reindex(ctx, name, height)
+}
+
 // testDB provides a mock database by implementing the database.DB interface.
 type testDB struct {
 	dbType   string
@@ -645,6 +868,7 @@ type testDatabaseTx struct {
 	fetchBlocksErr       error
 	fetchBlockRegion     func(region *database.BlockRegion) ([]byte, error)
 	fetchBlockRegions    func(regions []database.BlockRegion) ([][]byte, error)
+	backupErr            error
 	commitErr            error
 	rollbackErr          error
 }
@@ -708,6 +932,12 @@ func (t *testDatabaseTx) FetchBlockRegions(regions []database.BlockRegion) ([][]
 	return t.fetchBlockRegions(regions)
 }
 
+// Backup provides a mock implementation for writing a copy of the database to
+// the provided path.
+func (t *testDatabaseTx) Backup(destPath string) error {
+	return t.backupErr
+}
+
 // Commit provides a mock implementation for committing all changes that have
 // been made.
 func (t *testDatabaseTx) Commit() error {
@@ -946,6 +1176,8 @@ func (f *testFilterer) FilterHeaderByBlockHash(h *chainhash.Hash, filterType wir
 type testFiltererV2 struct {
 	filterByBlockHash    *gcs.FilterV2
 	filterByBlockHashErr error
+	locateCFiltersV2     []blockchain.FilterV2WithProof
+	locateCFiltersV2Err  error
 }
 
 // FilterByBlockHash returns a mocked version 2 GCS filter for the given block
@@ -954,6 +1186,12 @@ func (f *testFiltererV2) FilterByBlockHash(hash *chainhash.Hash) (*gcs.FilterV2,
 	return f.filterByBlockHash, f.filterByBlockHashErr
 }
 
+// LocateCFiltersV2 returns mocked version 2 GCS filters along with their header
+// commitment inclusion proofs for the given range of blocks.
+func (f *testFiltererV2) LocateCFiltersV2(startHash, endHash *chainhash.Hash, maxFilters uint32) ([]blockchain.FilterV2WithProof, error) {
+	return f.locateCFiltersV2, f.locateCFiltersV2Err
+}
+
 // testMiningState provides a mock mining state.
 type testMiningState struct {
 	allowUnsyncedMining bool
@@ -1046,6 +1284,7 @@ type testTxMempooler struct {
 	fetchTransaction    *dcrutil.Tx
 	fetchTransactionErr error
 	tspendHashes        []chainhash.Hash
+	checkSpend          *dcrutil.Tx
 }
 
 // HaveTransactions returns a mocked bool slice representing whether or not the
@@ -1083,6 +1322,11 @@ func (mp *testTxMempooler) TSpendHashes() []chainhash.Hash {
 	return mp.tspendHashes
 }
 
+// CheckSpend returns the mocked transaction that spends the passed outpoint.
+func (mp *testTxMempooler) CheckSpend(op wire.OutPoint) *dcrutil.Tx {
+	return mp.checkSpend
+}
+
 // testNtfnManager provides a mock notification manager by implementing the
 // NtfnManager interface.
 type testNtfnManager struct {
@@ -1294,36 +1538,52 @@ func hexFromFile(filename string) string {
 }
 
 type rpcTest struct {
-	name                  string
-	handler               commandHandler
-	cmd                   interface{}
-	mockChainParams       *chaincfg.Params
-	mockChain             *testRPCChain
-	mockMiningState       *testMiningState
-	mockCPUMiner          *testCPUMiner
-	mockBlockTemplater    *testBlockTemplater
-	setBlockTemplaterNil  bool
-	mockSanityChecker     *testSanityChecker
-	mockAddrManager       *testAddrManager
-	mockFeeEstimator      *testFeeEstimator
-	mockSyncManager       *testSyncManager
-	mockExistsAddresser   *testExistsAddresser
-	setExistsAddresserNil bool
-	mockAddrIndexer       *testAddrIndexer
-	setAddrIndexerNil     bool
-	mockTxIndexer         *testTxIndexer
-	setTxIndexerNil       bool
-	mockDB                *testDB
-	mockConnManager       *testConnManager
-	mockClock             *testClock
-	mockLogManager        *testLogManager
-	mockFilterer          *testFilterer
-	mockFiltererV2        *testFiltererV2
-	mockTxMempooler       *testTxMempooler
-	mockMiningAddrs       []dcrutil.Address
-	result                interface{}
-	wantErr               bool
-	errCode               dcrjson.RPCErrorCode
+	name                    string
+	handler                 commandHandler
+	cmd                     interface{}
+	mockChainParams         *chaincfg.Params
+	mockChain               *testRPCChain
+	mockMiningState         *testMiningState
+	mockCPUMiner            *testCPUMiner
+	mockBlockTemplater      *testBlockTemplater
+	setBlockTemplaterNil    bool
+	mockSanityChecker       *testSanityChecker
+	mockAddrManager         *testAddrManager
+	mockFeeEstimator        *testFeeEstimator
+	mockSyncManager         *testSyncManager
+	mockExistsAddresser     *testExistsAddresser
+	setExistsAddresserNil   bool
+	mockAddrIndexer         *testAddrIndexer
+	setAddrIndexerNil       bool
+	mockTxIndexer           *testTxIndexer
+	setTxIndexerNil         bool
+	mockSpendIndexer        *testSpendIndexer
+	setSpendIndexerNil      bool
+	mockTicketIndexer       *testTicketIndexer
+	setTicketIndexerNil     bool
+	mockTreasuryIndexer     *testTreasuryIndexer
+	setTreasuryIndexerNil   bool
+	mockScriptHashIndexer   *testScriptHashIndexer
+	setScriptHashIndexerNil bool
+	mockAddrUtxoIndexer     *testAddrUtxoIndexer
+	setAddrUtxoIndexerNil   bool
+	mockBlockStatsIndexer   *testBlockStatsIndexer
+	setBlockStatsIndexerNil bool
+	mockNullDataIndexer     *testNullDataIndexer
+	setNullDataIndexerNil   bool
+	mockIndexSyncer         *testIndexSyncer
+	setIndexSyncerNil       bool
+	mockDB                  *testDB
+	mockConnManager         *testConnManager
+	mockClock               *testClock
+	mockLogManager          *testLogManager
+	mockFilterer            *testFilterer
+	mockFiltererV2          *testFiltererV2
+	mockTxMempooler         *testTxMempooler
+	mockMiningAddrs         []dcrutil.Address
+	result                  interface{}
+	wantErr                 bool
+	errCode                 dcrjson.RPCErrorCode
 }
 
 // defaultChainParams provides a default chaincfg.Params to be used throughout
@@ -1432,11 +1692,13 @@ func defaultMockRPCChain() *testRPCChain {
 			txVersion: 1,
 		},
 		fetchUtxoStats: &blockchain.UtxoStats{
-			Utxos:          1593879,
-			Transactions:   689819,
-			Size:           36441617,
-			Total:          1154067750680149,
-			SerializedHash: *mustParseHash("fe7b32aa188800f07268b17f3bead5f3d8a1b6d18654182066436efce6effa86"),
+			BlockHash:    *blkHash,
+			BlockHeight:  blkHeight,
+			Utxos:        1593879,
+			Transactions: 689819,
+			Size:         36441617,
+			Total:        1154067750680149,
+			MuHash:       *mustParseHash("fe7b32aa188800f07268b17f3bead5f3d8a1b6d18654182066436efce6effa86"),
 		},
 		getStakeVersions: []blockchain.StakeVersions{{
 			Hash:         *blkHash,
@@ -1558,6 +1820,156 @@ func defaultMockTxIndexer() *testTxIndexer {
 	}
 }
 
+// defaultMockSpendIndexer provides a default mock spend indexer to be used
+// throughout the tests. Tests can override these defaults by calling
+// defaultMockSpendIndexer, updating fields as necessary on the returned
+// *testSpendIndexer, and then setting rpcTest.mockSpendIndexer as that
+// *testSpendIndexer.
+func defaultMockSpendIndexer() *testSpendIndexer {
+	return &testSpendIndexer{
+		entry: func(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error) {
+			return nil, nil
+		},
+	}
+}
+
+// defaultMockTicketIndexer provides a default mock ticket indexer to be used
+// throughout the tests. Tests can override these defaults by calling
+// defaultMockTicketIndexer, updating fields as necessary on the returned
+// *testTicketIndexer, and then setting rpcTest.mockTicketIndexer as that
+// *testTicketIndexer.
+func defaultMockTicketIndexer() *testTicketIndexer {
+	return &testTicketIndexer{
+		entry: func(ticket *chainhash.Hash) (*indexers.TicketIndexEntry, error) {
+			return nil, nil
+		},
+	}
+}
+
+// defaultMockTreasuryIndexer provides a default mock treasury indexer to be
+// used throughout the tests. Tests can override these defaults by calling
+// defaultMockTreasuryIndexer, updating fields as necessary on the returned
+// *testTreasuryIndexer, and then setting rpcTest.mockTreasuryIndexer as that
+// *testTreasuryIndexer.
+func defaultMockTreasuryIndexer() *testTreasuryIndexer {
+	return &testTreasuryIndexer{
+		entries: func(startHeight, endHeight int64, txTypes []indexers.TreasuryTxType, skip, count int) ([]indexers.TreasuryHistoryEntry, error) {
+			return nil, nil
+		},
+	}
+}
+
+// defaultMockScriptHashIndexer provides a default mock script hash indexer to
+// be used throughout the tests. Tests can override these defaults by calling
+// defaultMockScriptHashIndexer, updating fields as necessary on the returned
+// *testScriptHashIndexer, and then setting rpcTest.mockScriptHashIndexer as
+// that *testScriptHashIndexer.
+func defaultMockScriptHashIndexer() *testScriptHashIndexer {
+	return &testScriptHashIndexer{
+		history: func(scriptHash *chainhash.Hash, skip, count int) ([]indexers.ScriptHashHistoryEntry, error) {
+			return nil, nil
+		},
+		balance: func(scriptHash *chainhash.Hash) (int64, uint32, error) {
+			return 0, 0, nil
+		},
+	}
+}
+
+// defaultMockAddrUtxoIndexer provides a default mock address utxo indexer to
+// be used throughout the tests. Tests can override these defaults by calling
+// defaultMockAddrUtxoIndexer, updating fields as necessary on the returned
+// *testAddrUtxoIndexer, and then setting rpcTest.mockAddrUtxoIndexer as that
+// *testAddrUtxoIndexer.
+func defaultMockAddrUtxoIndexer() *testAddrUtxoIndexer {
+	return &testAddrUtxoIndexer{
+		utxosForAddress: func(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error) {
+			return nil, nil
+		},
+		balanceForAddress: func(addr dcrutil.Address) (int64, uint32, error) {
+			return 0, 0, nil
+		},
+	}
+}
+
+// defaultMockBlockStatsIndexer provides a default mock block stats indexer to
+// be used throughout the tests. Tests can override these defaults by calling
+// defaultMockBlockStatsIndexer, updating fields as necessary on the returned
+// *testBlockStatsIndexer, and then setting rpcTest.mockBlockStatsIndexer as
+// that *testBlockStatsIndexer.
+func defaultMockBlockStatsIndexer() *testBlockStatsIndexer {
+	return &testBlockStatsIndexer{
+		stats: func(height int64) (*indexers.BlockStats, error) {
+			return nil, nil
+		},
+	}
+}
+
+// defaultMockNullDataIndexer provides a default mock null data indexer to be
+// used throughout the tests. Tests can override these defaults by calling
+// defaultMockNullDataIndexer, updating fields as necessary on the returned
+// *testNullDataIndexer, and then setting rpcTest.mockNullDataIndexer as that
+// *testNullDataIndexer.
+func defaultMockNullDataIndexer() *testNullDataIndexer {
+	return &testNullDataIndexer{
+		search: func(prefix []byte, startHeight, endHeight int64, skip, count int) ([]indexers.NullDataEntry, error) {
+			return nil, nil
+		},
+	}
+}
+
+// defaultMockIndexSyncer provides a default mock index sync state provider to
+// be used throughout the tests. Tests can override these defaults by calling
+// defaultMockIndexSyncer, updating fields as necessary on the returned
+// *testIndexSyncer, and then setting rpcTest.mockIndexSyncer as that
+// *testIndexSyncer.
+func defaultMockIndexSyncer() *testIndexSyncer {
+	return &testIndexSyncer{
+		indexInfo: []indexers.IndexInfo{{
+			Name:   "transaction index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "address index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "spend index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "ticket index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "treasury index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "script hash index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "address utxo index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "block stats index",
+			Height: 1,
+			Synced: true,
+		}, {
+			Name:   "null data index",
+			Height: 1,
+			Synced: true,
+		}},
+		waitForHeight: func(ctx context.Context, name string, height int64) error {
+			return nil
+		},
+		reindex: func(ctx context.Context, name string, height int64) error {
+			return nil
+		},
+	}
+}
+
 // defaultMockDB provides a default mock database to be used throughout the
 // tests. Tests can override these defaults by calling defaultMockDB, updating
 // fields as necessary on the returned *testDB, and then setting rpcTest.mockDB
@@ -1682,6 +2094,12 @@ func defaultMockFiltererV2() *testFiltererV2 {
 	filter, _ := gcs.FromBytesV2(blockcf2.B, blockcf2.M, block432100Filter)
 	return &testFiltererV2{
 		filterByBlockHash: filter,
+		locateCFiltersV2: []blockchain.FilterV2WithProof{{
+			BlockHash:   block432100.BlockHash(),
+			Filter:      filter,
+			ProofIndex:  blockchain.HeaderCmtFilterIndex,
+			ProofHashes: []chainhash.Hash{},
+		}},
 	}
 }
 
@@ -1709,26 +2127,34 @@ func defaultMockTxMempooler() *testTxMempooler {
 // the tests.  Defaults can be overridden by tests through the rpcTest struct.
 func defaultMockConfig(chainParams *chaincfg.Params) *Config {
 	return &Config{
-		ChainParams:     chainParams,
-		Chain:           defaultMockRPCChain(),
-		SanityChecker:   defaultMockSanityChecker(),
-		BlockTemplater:  defaultMockBlockTemplater(),
-		AddrManager:     defaultMockAddrManager(),
-		FeeEstimator:    defaultMockFeeEstimator(),
-		SyncMgr:         defaultMockSyncManager(),
-		ExistsAddresser: defaultMockExistsAddresser(),
-		AddrIndexer:     defaultMockAddrIndexer(),
-		TxIndexer:       defaultMockTxIndexer(),
-		DB:              defaultMockDB(),
-		ConnMgr:         defaultMockConnManager(),
-		CPUMiner:        defaultMockCPUMiner(),
-		TxMempooler:     defaultMockTxMempooler(),
-		Clock:           &testClock{},
-		LogManager:      defaultMockLogManager(),
-		FiltererV2:      defaultMockFiltererV2(),
-		TimeSource:      blockchain.NewMedianTime(),
-		Services:        wire.SFNodeNetwork | wire.SFNodeCF,
-		SubsidyCache:    standalone.NewSubsidyCache(chainParams),
+		ChainParams:       chainParams,
+		Chain:             defaultMockRPCChain(),
+		SanityChecker:     defaultMockSanityChecker(),
+		BlockTemplater:    defaultMockBlockTemplater(),
+		AddrManager:       defaultMockAddrManager(),
+		FeeEstimator:      defaultMockFeeEstimator(),
+		SyncMgr:           defaultMockSyncManager(),
+		ExistsAddresser:   defaultMockExistsAddresser(),
+		AddrIndexer:       defaultMockAddrIndexer(),
+		TxIndexer:         defaultMockTxIndexer(),
+		SpendIndexer:      defaultMockSpendIndexer(),
+		TicketIndexer:     defaultMockTicketIndexer(),
+		TreasuryIndexer:   defaultMockTreasuryIndexer(),
+		ScriptHashIndexer: defaultMockScriptHashIndexer(),
+		AddrUtxoIndexer:   defaultMockAddrUtxoIndexer(),
+		BlockStatsIndexer: defaultMockBlockStatsIndexer(),
+		NullDataIndexer:   defaultMockNullDataIndexer(),
+		IndexSyncer:       defaultMockIndexSyncer(),
+		DB:                defaultMockDB(),
+		ConnMgr:           defaultMockConnManager(),
+		CPUMiner:          defaultMockCPUMiner(),
+		TxMempooler:       defaultMockTxMempooler(),
+		Clock:             &testClock{},
+		LogManager:        defaultMockLogManager(),
+		FiltererV2:        defaultMockFiltererV2(),
+		TimeSource:        blockchain.NewMedianTime(),
+		Services:          wire.SFNodeNetwork | wire.SFNodeCF,
+		SubsidyCache:      standalone.NewSubsidyCache(chainParams),
 		NetInfo: []types.NetworksResult{{
 			Name:                      "IPV4",
 			Limited:                   false,
@@ -1820,6 +2246,65 @@ func TestHandleAddNode(t *testing.T) {
 	}})
 }
 
+func TestHandleBackupDb(t *testing.T) {
+	t.Parallel()
+
+	dataDir := defaultMockConfig(defaultChainParams).DataDir
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleBackupDb: ok",
+		handler: handleBackupDb,
+		cmd:     &types.BackupDbCmd{Path: "/backup"},
+		mockDB: func() *testDB {
+			db := defaultMockDB()
+			db.dbType = "ffldb"
+			return db
+		}(),
+		result: &types.BackupDbResult{
+			Path:   "/backup",
+			DbType: "ffldb",
+		},
+	}, {
+		name:    "handleBackupDb: relative path",
+		handler: handleBackupDb,
+		cmd:     &types.BackupDbCmd{Path: "backup"},
+		mockDB: func() *testDB {
+			db := defaultMockDB()
+			db.dbType = "ffldb"
+			return db
+		}(),
+		result: &types.BackupDbResult{
+			Path:   filepath.Join(dataDir, "backup"),
+			DbType: "ffldb",
+		},
+	}, {
+		name:    "handleBackupDb: database already exists",
+		handler: handleBackupDb,
+		cmd:     &types.BackupDbCmd{Path: "/backup"},
+		mockDB: func() *testDB {
+			db := defaultMockDB()
+			db.viewTx = &testDatabaseTx{
+				backupErr: database.Error{ErrorCode: database.ErrDbExists},
+			}
+			return db
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleBackupDb: backup failure",
+		handler: handleBackupDb,
+		cmd:     &types.BackupDbCmd{Path: "/backup"},
+		mockDB: func() *testDB {
+			db := defaultMockDB()
+			db.viewTx = &testDatabaseTx{
+				backupErr: errors.New("backup failure"),
+			}
+			return db
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleCreateRawSStx(t *testing.T) {
 	t.Parallel()
 
@@ -2687,6 +3172,77 @@ func TestHandleDecodeScript(t *testing.T) {
 	}})
 }
 
+func TestHandleDumpUtxoSet(t *testing.T) {
+	t.Parallel()
+
+	dataDir, err := ioutil.TempDir("", "dumputxoset")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dataDir) })
+	existingPath := filepath.Join(dataDir, "existing.snapshot")
+	if err := ioutil.WriteFile(existingPath, nil, 0600); err != nil {
+		t.Fatalf("unable to create file: %v", err)
+	}
+
+	snapshotInfo := &blockchain.UtxoSnapshotInfo{
+		Hash:           block432100.BlockHash(),
+		Height:         int64(block432100.Header.Height),
+		SnapshotHash:   chainhash.Hash{0x01},
+		NumUtxoEntries: 1234,
+	}
+	okPath := filepath.Join(dataDir, "utxoset.snapshot")
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleDumpUtxoSet: ok",
+		handler: handleDumpUtxoSet,
+		cmd:     &types.DumpUtxoSetCmd{Path: okPath},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.utxoSnapshot = snapshotInfo
+			return chain
+		}(),
+		result: &types.DumpUtxoSetResult{
+			BlockHash:      snapshotInfo.Hash.String(),
+			Height:         snapshotInfo.Height,
+			Path:           okPath,
+			NumUtxoEntries: snapshotInfo.NumUtxoEntries,
+			SnapshotHash:   snapshotInfo.SnapshotHash.String(),
+		},
+	}, {
+		name:    "handleDumpUtxoSet: file already exists",
+		handler: handleDumpUtxoSet,
+		cmd:     &types.DumpUtxoSetCmd{Path: existingPath},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleDumpUtxoSet: history not validated",
+		handler: handleDumpUtxoSet,
+		cmd: &types.DumpUtxoSetCmd{
+			Path: filepath.Join(dataDir, "unvalidated.snapshot"),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.utxoSnapshotErr = blockchain.ErrHistoryNotValidated
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
+	}, {
+		name:    "handleDumpUtxoSet: dump failure",
+		handler: handleDumpUtxoSet,
+		cmd: &types.DumpUtxoSetCmd{
+			Path: filepath.Join(dataDir, "failed.snapshot"),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.utxoSnapshotErr = errors.New("dump failure")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleEstimateFee(t *testing.T) {
 	t.Parallel()
 
@@ -3452,21 +4008,260 @@ func TestHandleGetAddedNodeInfo(t *testing.T) {
 	}})
 }
 
-func TestHandleGetBestBlock(t *testing.T) {
+// addrUtxoTestData houses the data shared by the tests for the handlers that
+// query the address utxo index.
+type addrUtxoTestData struct {
+	address     string
+	confirmed   []indexers.AddrUtxoEntry
+	mempoolTxns []*dcrutil.Tx
+	utxoIndexer *testAddrUtxoIndexer
+	addrIndexer *testAddrIndexer
+}
+
+// newAddrUtxoTestData returns test data that consists of two confirmed unspent
+// outputs that pay to an address along with two unconfirmed transactions in the
+// mempool.  The first unconfirmed transaction spends the first confirmed output
+// and creates an output that pays to the address along with one that pays to a
+// different address.  The second unconfirmed transaction spends the unconfirmed
+// output that pays to the address and creates another one that pays to it.
+func newAddrUtxoTestData(t *testing.T) *addrUtxoTestData {
+	t.Helper()
+
+	pkScript := hexToBytes("76a91462e907b15cbf27d5425399ebf6f0fb50ebb88f1888ac")
+	otherPkScript := hexToBytes("76a914a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b4" +
+		"88ac")
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(0, pkScript,
+		defaultChainParams, false)
+	if err != nil || len(addrs) != 1 {
+		t.Fatalf("unable to extract test address: %v", err)
+	}
+
+	blkHeight := int64(block432100.Header.Height)
+	confirmed := []indexers.AddrUtxoEntry{{
+		OutPoint: wire.OutPoint{
+			Hash: *mustParseHash("46e0c7b2e1e5c6bb61b2e3b3e3f3c7d5a6e7c8f9e1a2" +
+				"b3c4d5e6f7a8b9c0d1e2"),
+		},
+		Amount:      100000000,
+		BlockHeight: blkHeight - 9,
+		PkScript:    pkScript,
+	}, {
+		OutPoint: wire.OutPoint{
+			Hash: *mustParseHash("d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2" +
+				"b3c4d5e6f7a8b9c0d1e2"),
+			Index: 1,
+		},
+		Amount:      200000000,
+		BlockHeight: blkHeight,
+		PkScript:    pkScript,
+	}}
+
+	tx1 := wire.NewMsgTx()
+	tx1.AddTxIn(wire.NewTxIn(&confirmed[0].OutPoint, confirmed[0].Amount, nil))
+	tx1.AddTxOut(wire.NewTxOut(50000000, pkScript))
+	tx1.AddTxOut(wire.NewTxOut(40000000, otherPkScript))
+	tx2 := wire.NewMsgTx()
+	tx2Origin := wire.NewOutPoint(&chainhash.Hash{}, 0, wire.TxTreeRegular)
+	tx2Origin.Hash = tx1.TxHash()
+	tx2.AddTxIn(wire.NewTxIn(tx2Origin, 50000000, nil))
+	tx2.AddTxOut(wire.NewTxOut(20000000, pkScript))
+
+	utxoIndexer := defaultMockAddrUtxoIndexer()
+	utxoIndexer.utxosForAddress = func(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error) {
+		return confirmed, nil
+	}
+	utxoIndexer.balanceForAddress = func(addr dcrutil.Address) (int64, uint32, error) {
+		return confirmed[0].Amount + confirmed[1].Amount, 2, nil
+	}
+	addrIndexer := defaultMockAddrIndexer()
+	addrIndexer.unconfirmedTxnsForAddress = []*dcrutil.Tx{dcrutil.NewTx(tx1),
+		dcrutil.NewTx(tx2)}
+	return &addrUtxoTestData{
+		address:     addrs[0].Address(),
+		confirmed:   confirmed,
+		mempoolTxns: addrIndexer.unconfirmedTxnsForAddress,
+		utxoIndexer: utxoIndexer,
+		addrIndexer: addrIndexer,
+	}
+}
+
+func TestHandleGetAddressBalance(t *testing.T) {
 	t.Parallel()
 
+	data := newAddrUtxoTestData(t)
 	testRPCServerHandler(t, []rpcTest{{
-		name:    "handleGetBestBlock: ok",
-		handler: handleGetBestBlock,
-		cmd:     &types.GetBestBlockCmd{},
-		result: &types.GetBestBlockResult{
-			Hash:   block432100.BlockHash().String(),
-			Height: int64(block432100.Header.Height),
+		name:    "handleGetAddressBalance: ok",
+		handler: handleGetAddressBalance,
+		cmd: &types.GetAddressBalanceCmd{
+			Address: data.address,
+		},
+		mockAddrUtxoIndexer: data.utxoIndexer,
+		mockAddrIndexer:     data.addrIndexer,
+		result: &types.GetAddressBalanceResult{
+			Confirmed:   300000000,
+			Unconfirmed: -80000000,
+		},
+	}, {
+		name:    "handleGetAddressBalance: ok no unconfirmed transactions",
+		handler: handleGetAddressBalance,
+		cmd: &types.GetAddressBalanceCmd{
+			Address: data.address,
+		},
+		mockAddrUtxoIndexer: data.utxoIndexer,
+		result: &types.GetAddressBalanceResult{
+			Confirmed:   300000000,
+			Unconfirmed: 0,
+		},
+	}, {
+		name:    "handleGetAddressBalance: address utxo index not enabled",
+		handler: handleGetAddressBalance,
+		cmd: &types.GetAddressBalanceCmd{
+			Address: data.address,
+		},
+		setAddrUtxoIndexerNil: true,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetAddressBalance: invalid address",
+		handler: handleGetAddressBalance,
+		cmd: &types.GetAddressBalanceCmd{
+			Address: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidAddressOrKey,
+	}, {
+		name:    "handleGetAddressBalance: balance error",
+		handler: handleGetAddressBalance,
+		cmd: &types.GetAddressBalanceCmd{
+			Address: data.address,
+		},
+		mockAddrUtxoIndexer: &testAddrUtxoIndexer{
+			balanceForAddress: func(addr dcrutil.Address) (int64, uint32, error) {
+				return 0, 0, errors.New("address utxo index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetAddressBalance: utxos error",
+		handler: handleGetAddressBalance,
+		cmd: &types.GetAddressBalanceCmd{
+			Address: data.address,
+		},
+		mockAddrUtxoIndexer: &testAddrUtxoIndexer{
+			utxosForAddress: func(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error) {
+				return nil, errors.New("address utxo index error")
+			},
+			balanceForAddress: data.utxoIndexer.balanceForAddress,
 		},
+		mockAddrIndexer: data.addrIndexer,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInternal.Code,
 	}})
 }
 
-func TestHandleGetBestBlockHash(t *testing.T) {
+func TestHandleGetAddressUtxos(t *testing.T) {
+	t.Parallel()
+
+	data := newAddrUtxoTestData(t)
+	pkScriptHex := hex.EncodeToString(data.confirmed[0].PkScript)
+	confirmedResults := []types.GetAddressUtxosResult{{
+		TxID:          data.confirmed[0].OutPoint.Hash.String(),
+		Vout:          0,
+		Amount:        100000000,
+		ScriptPubKey:  pkScriptHex,
+		Height:        data.confirmed[0].BlockHeight,
+		Confirmations: 10,
+	}, {
+		TxID:          data.confirmed[1].OutPoint.Hash.String(),
+		Vout:          1,
+		Amount:        200000000,
+		ScriptPubKey:  pkScriptHex,
+		Height:        data.confirmed[1].BlockHeight,
+		Confirmations: 1,
+	}}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetAddressUtxos: ok with mempool",
+		handler: handleGetAddressUtxos,
+		cmd: &types.GetAddressUtxosCmd{
+			Address:        data.address,
+			IncludeMempool: dcrjson.Bool(true),
+		},
+		mockAddrUtxoIndexer: data.utxoIndexer,
+		mockAddrIndexer:     data.addrIndexer,
+		result: []types.GetAddressUtxosResult{confirmedResults[1], {
+			TxID:          data.mempoolTxns[1].Hash().String(),
+			Vout:          0,
+			Amount:        20000000,
+			ScriptPubKey:  pkScriptHex,
+			Height:        0,
+			Confirmations: 0,
+		}},
+	}, {
+		name:    "handleGetAddressUtxos: ok without mempool",
+		handler: handleGetAddressUtxos,
+		cmd: &types.GetAddressUtxosCmd{
+			Address:        data.address,
+			IncludeMempool: dcrjson.Bool(false),
+		},
+		mockAddrUtxoIndexer: data.utxoIndexer,
+		mockAddrIndexer:     data.addrIndexer,
+		result:              confirmedResults,
+	}, {
+		name:    "handleGetAddressUtxos: ok no entries",
+		handler: handleGetAddressUtxos,
+		cmd: &types.GetAddressUtxosCmd{
+			Address: data.address,
+		},
+		result: []types.GetAddressUtxosResult{},
+	}, {
+		name:    "handleGetAddressUtxos: address utxo index not enabled",
+		handler: handleGetAddressUtxos,
+		cmd: &types.GetAddressUtxosCmd{
+			Address: data.address,
+		},
+		setAddrUtxoIndexerNil: true,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetAddressUtxos: invalid address",
+		handler: handleGetAddressUtxos,
+		cmd: &types.GetAddressUtxosCmd{
+			Address: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidAddressOrKey,
+	}, {
+		name:    "handleGetAddressUtxos: utxos error",
+		handler: handleGetAddressUtxos,
+		cmd: &types.GetAddressUtxosCmd{
+			Address: data.address,
+		},
+		mockAddrUtxoIndexer: &testAddrUtxoIndexer{
+			utxosForAddress: func(addr dcrutil.Address) ([]indexers.AddrUtxoEntry, error) {
+				return nil, errors.New("address utxo index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
+func TestHandleGetBestBlock(t *testing.T) {
+	t.Parallel()
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetBestBlock: ok",
+		handler: handleGetBestBlock,
+		cmd:     &types.GetBestBlockCmd{},
+		result: &types.GetBestBlockResult{
+			Hash:   block432100.BlockHash().String(),
+			Height: int64(block432100.Header.Height),
+		},
+	}})
+}
+
+func TestHandleGetBestBlockHash(t *testing.T) {
 	t.Parallel()
 
 	testRPCServerHandler(t, []rpcTest{{
@@ -3501,6 +4296,13 @@ func TestHandleGetBlockchainInfo(t *testing.T) {
 			chain.isCurrent = false
 			chain.maxBlockSize = 393216
 			chain.stateLastChangedHeight = int64(149248)
+			chain.utxoCacheStats = blockchain.UtxoCacheStats{
+				Entries: 120000,
+				Size:    48000000,
+				MaxSize: 157286400,
+				Hits:    300,
+				Misses:  100,
+			}
 			return chain
 		}(),
 		result: types.GetBlockChainInfoResult{
@@ -3523,6 +4325,14 @@ func TestHandleGetBlockchainInfo(t *testing.T) {
 					ExpireTime: uint64(1599264000),
 				},
 			},
+			UtxoCache: types.UtxoCacheInfo{
+				Entries:  120000,
+				Size:     48000000,
+				MaxSize:  157286400,
+				Hits:     300,
+				Misses:   100,
+				HitRatio: 0.75,
+			},
 		},
 	}, {
 		name:    "handleGetBlockchainInfo: ok with empty blockchain",
@@ -3824,6 +4634,21 @@ func TestHandleGetBlock(t *testing.T) {
 		}(),
 		wantErr: true,
 		errCode: dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetBlock: block pruned",
+		handler: handleGetBlock,
+		cmd: &types.GetBlockCmd{
+			Hash:      blkHashString,
+			Verbose:   dcrjson.Bool(false),
+			VerboseTx: dcrjson.Bool(false),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockByHashErr = blockchain.ErrBlockPruned
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCBlockPruned,
 	}, {
 		name:    "handleGetBlock: could not fetch chain work",
 		handler: handleGetBlock,
@@ -4021,6 +4846,323 @@ func TestHandleGetBlockHeader(t *testing.T) {
 	}})
 }
 
+func TestHandleGetBlockSpends(t *testing.T) {
+	t.Parallel()
+
+	// Define variables related to block432100 to be used throughout the
+	// handleGetBlockSpends tests.
+	blk := dcrutil.NewBlock(&block432100)
+	blkHashString := blk.Hash().String()
+	spendingTx := block432100.Transactions[1]
+	p2pkhScript := hexToBytes("76a9140bb407413316b66d7648f4d938d701159b34dfd688ac")
+	p2shScript := hexToBytes("a914f5916158e3e2c4551c1796708db8367207ed13bb87")
+	spentOutputs := []blockchain.SpentOutput{{
+		SpendingTx:   spendingTx.TxHash(),
+		SpendingTree: wire.TxTreeRegular,
+		InputIndex:   0,
+		PrevOut:      spendingTx.TxIn[0].PreviousOutPoint,
+		Amount:       spendingTx.TxIn[0].ValueIn,
+		PkScript:     p2pkhScript,
+		BlockHeight:  int64(spendingTx.TxIn[0].BlockHeight),
+		BlockIndex:   spendingTx.TxIn[0].BlockIndex,
+	}, {
+		SpendingTx:   spendingTx.TxHash(),
+		SpendingTree: wire.TxTreeRegular,
+		InputIndex:   1,
+		PrevOut:      spendingTx.TxIn[1].PreviousOutPoint,
+		Amount:       spendingTx.TxIn[1].ValueIn,
+		PkScript:     p2shScript,
+		BlockHeight:  int64(spendingTx.TxIn[1].BlockHeight),
+		BlockIndex:   spendingTx.TxIn[1].BlockIndex,
+	}}
+
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetBlockSpends: ok",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: blkHashString,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.spentOutputs = spentOutputs
+			return chain
+		}(),
+		result: &types.GetBlockSpendsResult{
+			Hash:   blkHashString,
+			Height: blk.Height(),
+			Tx: []types.BlockSpendsTx{{
+				Txid: spendingTx.TxHash().String(),
+				Tree: wire.TxTreeRegular,
+				Spends: []types.BlockSpend{{
+					Vin:         0,
+					Txid:        "5d1e8462bbbc305c94d82808c9a801ecd7bf30ca83cd85bb5e267d26347d0683",
+					Vout:        3,
+					Tree:        wire.TxTreeStake,
+					AmountIn:    121.11311304,
+					BlockHeight: 431843,
+					BlockIndex:  1,
+					ScriptPubKey: types.ScriptPubKeyResult{
+						Asm:       "OP_DUP OP_HASH160 0bb407413316b66d7648f4d938d701159b34dfd6 OP_EQUALVERIFY OP_CHECKSIG",
+						Hex:       "76a9140bb407413316b66d7648f4d938d701159b34dfd688ac",
+						ReqSigs:   1,
+						Type:      "pubkeyhash",
+						Addresses: []string{"DsS2nb8RpiYwhCWCLLNfDPdpdSbhnmtMkuy"},
+					},
+				}, {
+					Vin:         1,
+					Txid:        "c720b8991e3345e13858607cdbbaf8fc535a15cd36f22d42623dba56586c94d5",
+					Vout:        2,
+					Tree:        wire.TxTreeRegular,
+					AmountIn:    68.00443066,
+					BlockHeight: 432098,
+					BlockIndex:  11,
+					ScriptPubKey: types.ScriptPubKeyResult{
+						Asm:       "OP_HASH160 f5916158e3e2c4551c1796708db8367207ed13bb OP_EQUAL",
+						Hex:       "a914f5916158e3e2c4551c1796708db8367207ed13bb87",
+						ReqSigs:   1,
+						Type:      "scripthash",
+						Addresses: []string{"Dcur2mcGjmENx4DhNqDctW5wJCVyT3Qeqkx"},
+					},
+				}},
+			}},
+		},
+	}, {
+		name:    "handleGetBlockSpends: no spends",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: blkHashString,
+		},
+		result: &types.GetBlockSpendsResult{
+			Hash:   blkHashString,
+			Height: blk.Height(),
+			Tx:     []types.BlockSpendsTx{},
+		},
+	}, {
+		name:    "handleGetBlockSpends: invalid hash",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetBlockSpends: block not in main chain",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: blkHashString,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockHeightByHashErr = errors.New("not in main chain")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetBlockSpends: block pruned",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: blkHashString,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.spentOutputsErr = blockchain.ErrBlockPruned
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCBlockPruned,
+	}, {
+		name:    "handleGetBlockSpends: no spend journal",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: blkHashString,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.spentOutputsErr = blockchain.ErrNoSpendJournal
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
+	}, {
+		name:    "handleGetBlockSpends: failed to fetch spent outputs",
+		handler: handleGetBlockSpends,
+		cmd: &types.GetBlockSpendsCmd{
+			Hash: blkHashString,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.spentOutputsErr = errors.New("failed to fetch spent outputs")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
+func TestHandleGetBlockStats(t *testing.T) {
+	t.Parallel()
+
+	// Define variables related to block432100 to be used throughout the
+	// handleGetBlockStats tests.
+	blk := dcrutil.NewBlock(&block432100)
+	blkHashString := blk.Hash().String()
+	stats := &indexers.BlockStats{
+		Hash:               *blk.Hash(),
+		Height:             blk.Height(),
+		Time:               block432100.Header.Timestamp.Unix(),
+		Size:               uint32(block432100.SerializeSize()),
+		NumTxns:            uint32(len(block432100.Transactions)),
+		NumSTxns:           uint32(len(block432100.STransactions)),
+		NumTickets:         1,
+		NumVotes:           5,
+		TotalFee:           1234567,
+		MinFeeRate:         10000,
+		MaxFeeRate:         30000,
+		AvgFeeRate:         14000,
+		FeeRatePercentiles: [5]int64{10000, 10000, 10000, 20000, 20000},
+		UtxoIncrease:       -2,
+	}
+	indexer := defaultMockBlockStatsIndexer()
+	indexer.stats = func(height int64) (*indexers.BlockStats, error) {
+		if height != blk.Height() {
+			return nil, nil
+		}
+		return stats, nil
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetBlockStats: ok by hash",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHashString),
+		},
+		mockBlockStatsIndexer: indexer,
+		result: &types.GetBlockStatsResult{
+			Hash:               dcrjson.String(blkHashString),
+			Height:             dcrjson.Int64(blk.Height()),
+			Time:               dcrjson.Int64(stats.Time),
+			Size:               dcrjson.Int64(int64(stats.Size)),
+			Txs:                dcrjson.Int64(int64(stats.NumTxns)),
+			STxs:               dcrjson.Int64(int64(stats.NumSTxns)),
+			Tickets:            dcrjson.Int64(1),
+			Votes:              dcrjson.Int64(5),
+			Revocations:        dcrjson.Int64(0),
+			TAdds:              dcrjson.Int64(0),
+			TSpends:            dcrjson.Int64(0),
+			TotalFee:           dcrjson.Int64(1234567),
+			MinFeeRate:         dcrjson.Int64(10000),
+			MaxFeeRate:         dcrjson.Int64(30000),
+			AvgFeeRate:         dcrjson.Int64(14000),
+			FeeRatePercentiles: &[]int64{10000, 10000, 10000, 20000, 20000},
+			TAddAmount:         dcrjson.Int64(0),
+			TreasuryBaseAmount: dcrjson.Int64(0),
+			TSpendAmount:       dcrjson.Int64(0),
+			UtxoIncrease:       dcrjson.Int64(-2),
+		},
+	}, {
+		name:    "handleGetBlockStats: ok by height with requested stats",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(strconv.FormatInt(blk.Height(),
+				10)),
+			Stats: &[]string{"height", "totalfee", "feeratepercentiles"},
+		},
+		mockBlockStatsIndexer: indexer,
+		result: &types.GetBlockStatsResult{
+			Height:             dcrjson.Int64(blk.Height()),
+			TotalFee:           dcrjson.Int64(1234567),
+			FeeRatePercentiles: &[]int64{10000, 10000, 10000, 20000, 20000},
+		},
+	}, {
+		name:    "handleGetBlockStats: block stats index not enabled",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHashString),
+		},
+		setBlockStatsIndexerNil: true,
+		wantErr:                 true,
+		errCode:                 dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetBlockStats: invalid statistic",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHashString),
+			Stats:        &[]string{"totalfee", "invalid"},
+		},
+		mockBlockStatsIndexer: indexer,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetBlockStats: invalid hash",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(strings.Repeat("g",
+				chainhash.MaxHashStringSize)),
+		},
+		mockBlockStatsIndexer: indexer,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetBlockStats: invalid height",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "invalid",
+		},
+		mockBlockStatsIndexer: indexer,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetBlockStats: block not in main chain",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHashString),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockHeightByHashErr = errors.New("not in main chain")
+			return chain
+		}(),
+		mockBlockStatsIndexer: indexer,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetBlockStats: height out of range",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: "1000000000",
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockHashByHeightErr = errors.New("out of range")
+			return chain
+		}(),
+		mockBlockStatsIndexer: indexer,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCOutOfRange,
+	}, {
+		name:    "handleGetBlockStats: no stats for block",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHashString),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetBlockStats: failed to retrieve stats",
+		handler: handleGetBlockStats,
+		cmd: &types.GetBlockStatsCmd{
+			HashOrHeight: types.HashOrHeight(blkHashString),
+		},
+		mockBlockStatsIndexer: &testBlockStatsIndexer{
+			stats: func(height int64) (*indexers.BlockStats, error) {
+				return nil, errors.New("block stats index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleGetBlockSubsidy(t *testing.T) {
 	t.Parallel()
 
@@ -4239,6 +5381,109 @@ func TestHandleGetCFilterHeader(t *testing.T) {
 	}})
 }
 
+func TestHandleGetCFiltersV2(t *testing.T) {
+	t.Parallel()
+
+	blkHashString := block432100.BlockHash().String()
+	filter := hex.EncodeToString(defaultMockFiltererV2().filterByBlockHash.Bytes())
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetCFiltersV2: ok",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: blkHashString,
+			EndHash:   blkHashString,
+		},
+		result: []types.GetCFilterV2Result{{
+			BlockHash:   blkHashString,
+			Data:        filter,
+			ProofIndex:  blockchain.HeaderCmtFilterIndex,
+			ProofHashes: nil,
+		}},
+	}, {
+		name:    "handleGetCFiltersV2: ok with proof hashes",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: blkHashString,
+			EndHash:   blkHashString,
+		},
+		mockFiltererV2: func() *testFiltererV2 {
+			testFiltererV2 := defaultMockFiltererV2()
+			testFiltererV2.locateCFiltersV2[0].ProofHashes = []chainhash.Hash{
+				*mustParseHash("b4895fb9d0b54822550828f2ba07a68ddb1894796800917f8672e65067696347"),
+			}
+			return testFiltererV2
+		}(),
+		result: []types.GetCFilterV2Result{{
+			BlockHash:  blkHashString,
+			Data:       filter,
+			ProofIndex: blockchain.HeaderCmtFilterIndex,
+			ProofHashes: []string{
+				"b4895fb9d0b54822550828f2ba07a68ddb1894796800917f8672e65067696347",
+			},
+		}},
+	}, {
+		name:    "handleGetCFiltersV2: invalid start hash",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: "invalid",
+			EndHash:   blkHashString,
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetCFiltersV2: invalid end hash",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: blkHashString,
+			EndHash:   "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetCFiltersV2: block not found",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: blkHashString,
+			EndHash:   blkHashString,
+		},
+		mockFiltererV2: func() *testFiltererV2 {
+			testFiltererV2 := defaultMockFiltererV2()
+			testFiltererV2.locateCFiltersV2Err = blockchain.ErrUnknownBlock
+			return testFiltererV2
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCBlockNotFound,
+	}, {
+		name:    "handleGetCFiltersV2: invalid block range",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: blkHashString,
+			EndHash:   blkHashString,
+		},
+		mockFiltererV2: func() *testFiltererV2 {
+			testFiltererV2 := defaultMockFiltererV2()
+			testFiltererV2.locateCFiltersV2Err = blockchain.ErrInvalidBlockRange
+			return testFiltererV2
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetCFiltersV2: failed to load filters",
+		handler: handleGetCFiltersV2,
+		cmd: &types.GetCFiltersV2Cmd{
+			StartHash: blkHashString,
+			EndHash:   blkHashString,
+		},
+		mockFiltererV2: func() *testFiltererV2 {
+			testFiltererV2 := defaultMockFiltererV2()
+			testFiltererV2.locateCFiltersV2Err = errors.New("failed to load filters")
+			return testFiltererV2
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleGetCFilterV2(t *testing.T) {
 	t.Parallel()
 
@@ -4433,6 +5678,69 @@ func TestHandleGetHeaders(t *testing.T) {
 	}})
 }
 
+func TestHandleGetIndexInfo(t *testing.T) {
+	t.Parallel()
+
+	blk := dcrutil.NewBlock(&block432100)
+	syncer := defaultMockIndexSyncer()
+	syncer.indexInfo = []indexers.IndexInfo{{
+		Name:   "transaction index",
+		Hash:   *blk.Hash(),
+		Height: blk.Height(),
+		Synced: true,
+	}, {
+		Name:   "address index",
+		Hash:   block432100.Header.PrevBlock,
+		Height: blk.Height() - 1,
+		Synced: false,
+	}}
+	testRPCServerHandler(t, []rpcTest{{
+		name:            "handleGetIndexInfo: ok",
+		handler:         handleGetIndexInfo,
+		cmd:             &types.GetIndexInfoCmd{},
+		mockIndexSyncer: syncer,
+		result: []types.GetIndexInfoResult{{
+			Name:            "transaction index",
+			Synced:          true,
+			BestBlockHash:   blk.Hash().String(),
+			BestBlockHeight: blk.Height(),
+		}, {
+			Name:            "address index",
+			Synced:          false,
+			BestBlockHash:   block432100.Header.PrevBlock.String(),
+			BestBlockHeight: blk.Height() - 1,
+		}},
+	}, {
+		name:    "handleGetIndexInfo: ok with index name",
+		handler: handleGetIndexInfo,
+		cmd: &types.GetIndexInfoCmd{
+			IndexName: dcrjson.String("address index"),
+		},
+		mockIndexSyncer: syncer,
+		result: []types.GetIndexInfoResult{{
+			Name:            "address index",
+			Synced:          false,
+			BestBlockHash:   block432100.Header.PrevBlock.String(),
+			BestBlockHeight: blk.Height() - 1,
+		}},
+	}, {
+		name:              "handleGetIndexInfo: ok no indexes",
+		handler:           handleGetIndexInfo,
+		cmd:               &types.GetIndexInfoCmd{},
+		setIndexSyncerNil: true,
+		result:            []types.GetIndexInfoResult{},
+	}, {
+		name:    "handleGetIndexInfo: index not enabled",
+		handler: handleGetIndexInfo,
+		cmd: &types.GetIndexInfoCmd{
+			IndexName: dcrjson.String("spend index"),
+		},
+		mockIndexSyncer: syncer,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInvalidParameter,
+	}})
+}
+
 func TestHandleGetInfo(t *testing.T) {
 	t.Parallel()
 
@@ -4627,6 +5935,334 @@ func TestHandleGetPeerInfo(t *testing.T) {
 	}})
 }
 
+func TestHandleGetScriptHashBalance(t *testing.T) {
+	t.Parallel()
+
+	scriptHash := "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetScriptHashBalance: ok",
+		handler: handleGetScriptHashBalance,
+		cmd: &types.GetScriptHashBalanceCmd{
+			ScriptHash: scriptHash,
+		},
+		mockScriptHashIndexer: &testScriptHashIndexer{
+			balance: func(hash *chainhash.Hash) (int64, uint32, error) {
+				if hash.String() != scriptHash {
+					return 0, 0, fmt.Errorf("unexpected script hash %v", hash)
+				}
+				return 1234567890, 3, nil
+			},
+		},
+		result: &types.GetScriptHashBalanceResult{
+			Confirmed: 1234567890,
+			UtxoCount: 3,
+		},
+	}, {
+		name:    "handleGetScriptHashBalance: script hash index not enabled",
+		handler: handleGetScriptHashBalance,
+		cmd: &types.GetScriptHashBalanceCmd{
+			ScriptHash: scriptHash,
+		},
+		setScriptHashIndexerNil: true,
+		wantErr:                 true,
+		errCode:                 dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetScriptHashBalance: invalid script hash",
+		handler: handleGetScriptHashBalance,
+		cmd: &types.GetScriptHashBalanceCmd{
+			ScriptHash: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetScriptHashBalance: script hash index error",
+		handler: handleGetScriptHashBalance,
+		cmd: &types.GetScriptHashBalanceCmd{
+			ScriptHash: scriptHash,
+		},
+		mockScriptHashIndexer: &testScriptHashIndexer{
+			balance: func(hash *chainhash.Hash) (int64, uint32, error) {
+				return 0, 0, errors.New("script hash index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
+func TestHandleGetScriptHashHistory(t *testing.T) {
+	t.Parallel()
+
+	blkHeight := int64(block432100.Header.Height)
+	blkHash := block432100.BlockHash()
+	scriptHash := "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"
+	txHash1 := mustParseHash("46e0c7b2e1e5c6bb61b2e3b3e3f3c7d5a6e7c8f9e1a2b3c4d5e6f7a8b9c0d1e2")
+	txHash2 := mustParseHash("d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2")
+	entries := []indexers.ScriptHashHistoryEntry{{
+		TxHash:      *txHash1,
+		BlockHeight: blkHeight - 9,
+		Tree:        wire.TxTreeRegular,
+		TxIndex:     1,
+	}, {
+		TxHash:      *txHash2,
+		BlockHeight: blkHeight,
+		Tree:        wire.TxTreeStake,
+		TxIndex:     0,
+	}}
+	results := []types.GetScriptHashHistoryResult{{
+		TxID:          txHash1.String(),
+		BlockHash:     blkHash.String(),
+		BlockHeight:   blkHeight - 9,
+		Confirmations: 10,
+	}, {
+		TxID:          txHash2.String(),
+		BlockHash:     blkHash.String(),
+		BlockHeight:   blkHeight,
+		Confirmations: 1,
+	}}
+
+	// expectArgs returns a mock script hash indexer that returns the entries
+	// when it is queried with the provided arguments and an error otherwise.
+	expectArgs := func(wantSkip, wantCount int) *testScriptHashIndexer {
+		return &testScriptHashIndexer{
+			history: func(hash *chainhash.Hash, skip, count int) ([]indexers.ScriptHashHistoryEntry, error) {
+				if hash.String() != scriptHash || skip != wantSkip ||
+					count != wantCount {
+
+					return nil, fmt.Errorf("unexpected args: %v %d %d", hash,
+						skip, count)
+				}
+				return entries, nil
+			},
+		}
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetScriptHashHistory: ok defaults",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+		},
+		mockScriptHashIndexer: expectArgs(0, 100),
+		result:                results,
+	}, {
+		name:    "handleGetScriptHashHistory: ok with paging",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+			Skip:       dcrjson.Int(-1),
+			Count:      dcrjson.Int(20000),
+		},
+		mockScriptHashIndexer: expectArgs(0, 10000),
+		result:                results,
+	}, {
+		name:    "handleGetScriptHashHistory: ok zero count",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+			Count:      dcrjson.Int(0),
+		},
+		result: nil,
+	}, {
+		name:    "handleGetScriptHashHistory: ok no entries",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+		},
+		result: []types.GetScriptHashHistoryResult{},
+	}, {
+		name:    "handleGetScriptHashHistory: script hash index not enabled",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+		},
+		setScriptHashIndexerNil: true,
+		wantErr:                 true,
+		errCode:                 dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetScriptHashHistory: invalid script hash",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetScriptHashHistory: script hash index error",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+		},
+		mockScriptHashIndexer: &testScriptHashIndexer{
+			history: func(hash *chainhash.Hash, skip, count int) ([]indexers.ScriptHashHistoryEntry, error) {
+				return nil, errors.New("script hash index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetScriptHashHistory: block hash lookup error",
+		handler: handleGetScriptHashHistory,
+		cmd: &types.GetScriptHashHistoryCmd{
+			ScriptHash: scriptHash,
+		},
+		mockScriptHashIndexer: expectArgs(0, 100),
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockHashByHeightErr = errors.New("block not found")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
+func TestHandleGetSpendingInfo(t *testing.T) {
+	t.Parallel()
+
+	// Define variables related to block432100 to be used throughout the
+	// handleGetSpendingInfo tests.
+	blk := dcrutil.NewBlock(&block432100)
+	spendingTx := block432100.Transactions[1]
+	prevOut := spendingTx.TxIn[0].PreviousOutPoint
+	spentTxid := prevOut.Hash.String()
+	spendEntry := &indexers.SpendIndexEntry{
+		SpendingTx:  spendingTx.TxHash(),
+		InputIndex:  0,
+		BlockHash:   *blk.Hash(),
+		BlockHeight: blk.Height() - 1,
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetSpendingInfo: ok",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spentTxid,
+			Vout: prevOut.Index,
+		},
+		mockSpendIndexer: &testSpendIndexer{
+			entry: func(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error) {
+				if *outpoint != prevOut {
+					return nil, nil
+				}
+				return spendEntry, nil
+			},
+		},
+		result: &types.GetSpendingInfoResult{
+			Spent:         true,
+			TxID:          spendingTx.TxHash().String(),
+			Vin:           0,
+			BlockHash:     blk.Hash().String(),
+			BlockHeight:   blk.Height() - 1,
+			Confirmations: 2,
+		},
+	}, {
+		name:    "handleGetSpendingInfo: ok mempool",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spendingTx.TxIn[1].PreviousOutPoint.Hash.String(),
+			Vout: spendingTx.TxIn[1].PreviousOutPoint.Index,
+		},
+		mockTxMempooler: func() *testTxMempooler {
+			mp := defaultMockTxMempooler()
+			mp.checkSpend = dcrutil.NewTx(spendingTx)
+			return mp
+		}(),
+		result: &types.GetSpendingInfoResult{
+			Spent: true,
+			TxID:  spendingTx.TxHash().String(),
+			Vin:   1,
+		},
+	}, {
+		name:    "handleGetSpendingInfo: ok mempool excluded",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid:           spentTxid,
+			Vout:           prevOut.Index,
+			IncludeMempool: dcrjson.Bool(false),
+		},
+		mockTxMempooler: func() *testTxMempooler {
+			mp := defaultMockTxMempooler()
+			mp.checkSpend = dcrutil.NewTx(spendingTx)
+			return mp
+		}(),
+		result: &types.GetSpendingInfoResult{Spent: false},
+	}, {
+		name:    "handleGetSpendingInfo: unspent",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spentTxid,
+			Vout: prevOut.Index,
+		},
+		result: &types.GetSpendingInfoResult{Spent: false},
+	}, {
+		name:    "handleGetSpendingInfo: spend index not enabled",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spentTxid,
+			Vout: prevOut.Index,
+		},
+		setSpendIndexerNil: true,
+		wantErr:            true,
+		errCode:            dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetSpendingInfo: invalid hash",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: "invalid",
+			Vout: prevOut.Index,
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetSpendingInfo: spend index error",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spentTxid,
+			Vout: prevOut.Index,
+		},
+		mockSpendIndexer: &testSpendIndexer{
+			entry: func(outpoint *wire.OutPoint) (*indexers.SpendIndexEntry, error) {
+				return nil, errors.New("spend index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetSpendingInfo: spend index still syncing",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spentTxid,
+			Vout: prevOut.Index,
+		},
+		mockIndexSyncer: func() *testIndexSyncer {
+			syncer := defaultMockIndexSyncer()
+			syncer.indexInfo = []indexers.IndexInfo{{
+				Name:   "spend index",
+				Height: 1,
+				Synced: false,
+			}}
+			return syncer
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
+	}, {
+		name:    "handleGetSpendingInfo: spend index sync timeout",
+		handler: handleGetSpendingInfo,
+		cmd: &types.GetSpendingInfoCmd{
+			Txid: spentTxid,
+			Vout: prevOut.Index,
+		},
+		mockIndexSyncer: func() *testIndexSyncer {
+			syncer := defaultMockIndexSyncer()
+			syncer.waitForHeight = func(ctx context.Context, name string, height int64) error {
+				return context.DeadlineExceeded
+			}
+			return syncer
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
+	}})
+}
+
 func TestHandleGetStakeVersionInfo(t *testing.T) {
 	t.Parallel()
 
@@ -4805,6 +6441,137 @@ func TestHandleGetStakeVersions(t *testing.T) {
 	}})
 }
 
+func TestHandleGetTicketInfo(t *testing.T) {
+	t.Parallel()
+
+	blk := dcrutil.NewBlock(&block432100)
+	ticket := mustParseHash("822e537612dfd03b0dd2c2610083a93fde655f11c32adc7511d75e460abf4283")
+	vote := mustParseHash("8319c4e0623f0f2c73444047eff595493aa87fe195a192c086204c06a758cdee")
+	votingAddr, err := dcrutil.DecodeAddress("DsRM6qwzT3r85evKvDBJBviTgYcaLKL4ipD",
+		defaultChainParams)
+	if err != nil {
+		t.Fatalf("unexpected error decoding address: %v", err)
+	}
+	entryFn := func(entry indexers.TicketIndexEntry) *testTicketIndexer {
+		return &testTicketIndexer{
+			entry: func(hash *chainhash.Hash) (*indexers.TicketIndexEntry, error) {
+				if *hash != *ticket {
+					return nil, nil
+				}
+				return &entry, nil
+			},
+		}
+	}
+	voted := indexers.TicketIndexEntry{
+		PurchaseBlock:   block432100.Header.PrevBlock,
+		PurchaseHeight:  blk.Height() - 1000,
+		Price:           15000000000,
+		VotingAddress:   votingAddr,
+		RewardAddresses: []dcrutil.Address{votingAddr},
+		Voted:           true,
+		SpendingTx:      *vote,
+		SpendingHeight:  blk.Height(),
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleGetTicketInfo: ok voted",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: ticket.String(),
+		},
+		mockTicketIndexer: entryFn(voted),
+		result: &types.GetTicketInfoResult{
+			Ticket:          ticket.String(),
+			Status:          "voted",
+			PurchaseBlock:   block432100.Header.PrevBlock.String(),
+			PurchaseHeight:  blk.Height() - 1000,
+			Price:           150,
+			VotingAddress:   votingAddr.Address(),
+			RewardAddresses: []string{votingAddr.Address()},
+			SpendingTx:      vote.String(),
+			SpendingHeight:  blk.Height(),
+		},
+	}, {
+		name:    "handleGetTicketInfo: ok expired",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: ticket.String(),
+		},
+		mockTicketIndexer: entryFn(indexers.TicketIndexEntry{
+			PurchaseBlock:  block432100.Header.PrevBlock,
+			PurchaseHeight: blk.Height() - 42000,
+			Price:          15000000000,
+			ExpiredHeight:  blk.Height(),
+		}),
+		result: &types.GetTicketInfoResult{
+			Ticket:          ticket.String(),
+			Status:          "expired",
+			PurchaseBlock:   block432100.Header.PrevBlock.String(),
+			PurchaseHeight:  blk.Height() - 42000,
+			Price:           150,
+			RewardAddresses: []string{},
+			ExpiredHeight:   blk.Height(),
+		},
+	}, {
+		name:    "handleGetTicketInfo: ok immature",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: ticket.String(),
+		},
+		mockTicketIndexer: entryFn(indexers.TicketIndexEntry{
+			PurchaseBlock:  *blk.Hash(),
+			PurchaseHeight: blk.Height(),
+			Price:          15000000000,
+		}),
+		result: &types.GetTicketInfoResult{
+			Ticket:          ticket.String(),
+			Status:          "immature",
+			PurchaseBlock:   blk.Hash().String(),
+			PurchaseHeight:  blk.Height(),
+			Price:           150,
+			RewardAddresses: []string{},
+		},
+	}, {
+		name:    "handleGetTicketInfo: ticket index not enabled",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: ticket.String(),
+		},
+		setTicketIndexerNil: true,
+		wantErr:             true,
+		errCode:             dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetTicketInfo: invalid hash",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: "invalid",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleGetTicketInfo: unknown ticket",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: vote.String(),
+		},
+		mockTicketIndexer: entryFn(voted),
+		wantErr:           true,
+		errCode:           dcrjson.ErrRPCNoTxInfo,
+	}, {
+		name:    "handleGetTicketInfo: ticket index error",
+		handler: handleGetTicketInfo,
+		cmd: &types.GetTicketInfoCmd{
+			Ticket: ticket.String(),
+		},
+		mockTicketIndexer: &testTicketIndexer{
+			entry: func(hash *chainhash.Hash) (*indexers.TicketIndexEntry, error) {
+				return nil, errors.New("ticket index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleGetTicketPoolValue(t *testing.T) {
 	t.Parallel()
 
@@ -4911,6 +6678,142 @@ func TestHandleGetTreasuryBalance(t *testing.T) {
 	}})
 }
 
+func TestHandleGetTreasuryHistory(t *testing.T) {
+	t.Parallel()
+
+	blkHeight := int64(block432100.Header.Height)
+	blkHash := block432100.BlockHash()
+	tadd := mustParseHash("46e0c7b2e1e5c6bb61b2e3b3e3f3c7d5a6e7c8f9e1a2b3c4d5e6f7a8b9c0d1e2")
+	tspend := mustParseHash("d1e2f3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b9c0d1e2")
+	entries := []indexers.TreasuryHistoryEntry{{
+		Type:        indexers.TreasuryTxTypeTAdd,
+		TxHash:      *tadd,
+		BlockHash:   blkHash,
+		BlockHeight: blkHeight,
+		Amount:      157007970,
+		Balance:     1923209183818,
+	}, {
+		Type:        indexers.TreasuryTxTypeTSpend,
+		TxHash:      *tspend,
+		BlockHash:   blkHash,
+		BlockHeight: blkHeight,
+		Amount:      -1892808657,
+		Fee:         2550,
+		Balance:     1923209183818,
+	}}
+	results := []types.GetTreasuryHistoryResult{{
+		Type:        "tadd",
+		TxID:        tadd.String(),
+		BlockHash:   blkHash.String(),
+		BlockHeight: blkHeight,
+		Amount:      157007970,
+		Balance:     1923209183818,
+	}, {
+		Type:        "tspend",
+		TxID:        tspend.String(),
+		BlockHash:   blkHash.String(),
+		BlockHeight: blkHeight,
+		Amount:      -1892808657,
+		Fee:         2550,
+		Balance:     1923209183818,
+	}}
+
+	// expectArgs returns a mock treasury indexer that returns the entries when
+	// it is queried with the provided arguments and an error otherwise.
+	expectArgs := func(wantStart, wantEnd int64, wantTypes []indexers.TreasuryTxType, wantSkip, wantCount int) *testTreasuryIndexer {
+		return &testTreasuryIndexer{
+			entries: func(startHeight, endHeight int64, txTypes []indexers.TreasuryTxType, skip, count int) ([]indexers.TreasuryHistoryEntry, error) {
+				if startHeight != wantStart || endHeight != wantEnd ||
+					!reflect.DeepEqual(txTypes, wantTypes) ||
+					skip != wantSkip || count != wantCount {
+
+					return nil, fmt.Errorf("unexpected args: %d %d %v %d %d",
+						startHeight, endHeight, txTypes, skip, count)
+				}
+				return entries, nil
+			},
+		}
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:                "handleGetTreasuryHistory: ok defaults",
+		handler:             handleGetTreasuryHistory,
+		cmd:                 &types.GetTreasuryHistoryCmd{},
+		mockTreasuryIndexer: expectArgs(0, blkHeight, nil, 0, 100),
+		result:              results,
+	}, {
+		name:    "handleGetTreasuryHistory: ok with range, types, and paging",
+		handler: handleGetTreasuryHistory,
+		cmd: &types.GetTreasuryHistoryCmd{
+			StartHeight: dcrjson.Int64(100),
+			EndHeight:   dcrjson.Int64(200),
+			Types:       &[]string{"tadd", "tspend"},
+			Skip:        dcrjson.Int(-1),
+			Count:       dcrjson.Int(20000),
+		},
+		mockTreasuryIndexer: expectArgs(100, 200, []indexers.TreasuryTxType{
+			indexers.TreasuryTxTypeTAdd, indexers.TreasuryTxTypeTSpend,
+		}, 0, 10000),
+		result: results,
+	}, {
+		name:    "handleGetTreasuryHistory: ok zero count",
+		handler: handleGetTreasuryHistory,
+		cmd: &types.GetTreasuryHistoryCmd{
+			Count: dcrjson.Int(0),
+		},
+		result: nil,
+	}, {
+		name:    "handleGetTreasuryHistory: ok no entries",
+		handler: handleGetTreasuryHistory,
+		cmd: &types.GetTreasuryHistoryCmd{
+			Types: &[]string{"treasurybase"},
+		},
+		result: []types.GetTreasuryHistoryResult{},
+	}, {
+		name:                  "handleGetTreasuryHistory: treasury index not enabled",
+		handler:               handleGetTreasuryHistory,
+		cmd:                   &types.GetTreasuryHistoryCmd{},
+		setTreasuryIndexerNil: true,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleGetTreasuryHistory: negative start height",
+		handler: handleGetTreasuryHistory,
+		cmd: &types.GetTreasuryHistoryCmd{
+			StartHeight: dcrjson.Int64(-1),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetTreasuryHistory: end height before start height",
+		handler: handleGetTreasuryHistory,
+		cmd: &types.GetTreasuryHistoryCmd{
+			StartHeight: dcrjson.Int64(200),
+			EndHeight:   dcrjson.Int64(100),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetTreasuryHistory: invalid type",
+		handler: handleGetTreasuryHistory,
+		cmd: &types.GetTreasuryHistoryCmd{
+			Types: &[]string{"tadd", "invalid"},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleGetTreasuryHistory: treasury index error",
+		handler: handleGetTreasuryHistory,
+		cmd:     &types.GetTreasuryHistoryCmd{},
+		mockTreasuryIndexer: &testTreasuryIndexer{
+			entries: func(startHeight, endHeight int64, txTypes []indexers.TreasuryTxType, skip, count int) ([]indexers.TreasuryHistoryEntry, error) {
+				return nil, errors.New("treasury index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleGetTxOutSetInfo(t *testing.T) {
 	t.Parallel()
 
@@ -4919,14 +6822,76 @@ func TestHandleGetTxOutSetInfo(t *testing.T) {
 		handler: handleGetTxOutSetInfo,
 		cmd:     &types.GetTxOutSetInfoCmd{},
 		result: types.GetTxOutSetInfoResult{
-			Height:         int64(block432100.Header.Height),
-			BestBlock:      block432100.BlockHash().String(),
-			Transactions:   689819,
-			TxOuts:         1593879,
-			SerializedHash: "fe7b32aa188800f07268b17f3bead5f3d8a1b6d18654182066436efce6effa86",
-			DiskSize:       36441617,
-			TotalAmount:    1154067750680149,
+			Height:       int64(block432100.Header.Height),
+			BestBlock:    block432100.BlockHash().String(),
+			Transactions: 689819,
+			TxOuts:       1593879,
+			MuHash:       "fe7b32aa188800f07268b17f3bead5f3d8a1b6d18654182066436efce6effa86",
+			DiskSize:     36441617,
+			TotalAmount:  1154067750680149,
+		},
+	}, {
+		name:    "handleGetTxOutSetInfo: ok with height",
+		handler: handleGetTxOutSetInfo,
+		cmd: &types.GetTxOutSetInfoCmd{
+			Height: dcrjson.Int64(432000),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.utxoStatsByHeight = &blockchain.UtxoStats{
+				BlockHash:    *mustParseHash("000000000000000012ea1af17b1da4fb9c0e3e7a4f12cae3ba4e6ea30e4e2c85"),
+				BlockHeight:  432000,
+				Utxos:        1593100,
+				Transactions: 689500,
+				Size:         36430000,
+				Total:        1154000000000000,
+				MuHash:       *mustParseHash("2c5a5a9e39a0f1a5a2a3d9d6f0e23a1bd0a1d2cbe3c1b1c64e4bbdf2ab59d8b0"),
+			}
+			return chain
+		}(),
+		result: types.GetTxOutSetInfoResult{
+			Height:       432000,
+			BestBlock:    "000000000000000012ea1af17b1da4fb9c0e3e7a4f12cae3ba4e6ea30e4e2c85",
+			Transactions: 689500,
+			TxOuts:       1593100,
+			MuHash:       "2c5a5a9e39a0f1a5a2a3d9d6f0e23a1bd0a1d2cbe3c1b1c64e4bbdf2ab59d8b0",
+			DiskSize:     36430000,
+			TotalAmount:  1154000000000000,
+		},
+	}, {
+		name:    "handleGetTxOutSetInfo: height out of range",
+		handler: handleGetTxOutSetInfo,
+		cmd: &types.GetTxOutSetInfoCmd{
+			Height: dcrjson.Int64(int64(block432100.Header.Height) + 1),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCOutOfRange,
+	}, {
+		name:    "handleGetTxOutSetInfo: stats not available",
+		handler: handleGetTxOutSetInfo,
+		cmd: &types.GetTxOutSetInfoCmd{
+			Height: dcrjson.Int64(1),
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.utxoStatsByHeightErr = blockchain.ErrNoUtxoStats
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCMisc,
+	}, {
+		name:    "handleGetTxOutSetInfo: fetch failure",
+		handler: handleGetTxOutSetInfo,
+		cmd: &types.GetTxOutSetInfoCmd{
+			Height: dcrjson.Int64(1),
 		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.utxoStatsByHeightErr = errors.New("fetch failure")
+			return chain
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
 	}})
 }
 
@@ -5239,6 +7204,156 @@ func searchRawTransactionsResult(t *testing.T, s *Server, tx testTx,
 	return result
 }
 
+func TestHandleSearchNullData(t *testing.T) {
+	t.Parallel()
+
+	blkHeight := int64(block432100.Header.Height)
+	blkHash := block432100.BlockHash()
+	txHash := mustParseHash("46e0c7b2e1e5c6bb61b2e3b3e3f3c7d5a6e7c8f9e1a2b3c4d5e6f7a8b9c0d1e2")
+	entries := []indexers.NullDataEntry{{
+		TxHash:      *txHash,
+		BlockHeight: blkHeight,
+		TxIndex:     3,
+		OutputIndex: 1,
+		Data:        []byte{0x01, 0x02, 0x03, 0x04},
+	}}
+	results := []types.SearchNullDataResult{{
+		TxID:          txHash.String(),
+		Vout:          1,
+		BlockHash:     blkHash.String(),
+		BlockHeight:   blkHeight,
+		BlockIndex:    3,
+		Confirmations: 1,
+		Data:          "01020304",
+	}}
+
+	// expectArgs returns a mock null data indexer that returns the entries
+	// when it is queried with the provided arguments and an error otherwise.
+	expectArgs := func(wantPrefix []byte, wantStart, wantEnd int64, wantSkip, wantCount int) *testNullDataIndexer {
+		return &testNullDataIndexer{
+			search: func(prefix []byte, startHeight, endHeight int64, skip, count int) ([]indexers.NullDataEntry, error) {
+				if !bytes.Equal(prefix, wantPrefix) ||
+					startHeight != wantStart || endHeight != wantEnd ||
+					skip != wantSkip || count != wantCount {
+
+					return nil, fmt.Errorf("unexpected args: %x %d %d %d %d",
+						prefix, startHeight, endHeight, skip, count)
+				}
+				return entries, nil
+			},
+		}
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleSearchNullData: ok defaults",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "0102",
+		},
+		mockNullDataIndexer: expectArgs([]byte{0x01, 0x02}, 0, blkHeight, 0,
+			100),
+		result: results,
+	}, {
+		name:    "handleSearchNullData: ok with range and paging",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix:      "01",
+			StartHeight: dcrjson.Int64(100),
+			EndHeight:   dcrjson.Int64(200),
+			Skip:        dcrjson.Int(-1),
+			Count:       dcrjson.Int(20000),
+		},
+		mockNullDataIndexer: expectArgs([]byte{0x01}, 100, 200, 0, 10000),
+		result:              results,
+	}, {
+		name:    "handleSearchNullData: ok zero count",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "0102",
+			Count:  dcrjson.Int(0),
+		},
+		result: nil,
+	}, {
+		name:    "handleSearchNullData: ok no entries",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "0102",
+		},
+		result: []types.SearchNullDataResult{},
+	}, {
+		name:    "handleSearchNullData: null data index not enabled",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "0102",
+		},
+		setNullDataIndexerNil: true,
+		wantErr:               true,
+		errCode:               dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleSearchNullData: invalid prefix",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "010",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCDecodeHexString,
+	}, {
+		name:    "handleSearchNullData: empty prefix",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "",
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSearchNullData: negative start height",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix:      "0102",
+			StartHeight: dcrjson.Int64(-1),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSearchNullData: end height before start height",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix:      "0102",
+			StartHeight: dcrjson.Int64(200),
+			EndHeight:   dcrjson.Int64(100),
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleSearchNullData: null data index error",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "0102",
+		},
+		mockNullDataIndexer: &testNullDataIndexer{
+			search: func(prefix []byte, startHeight, endHeight int64, skip, count int) ([]indexers.NullDataEntry, error) {
+				return nil, errors.New("null data index error")
+			},
+		},
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleSearchNullData: failed to retrieve block hash",
+		handler: handleSearchNullData,
+		cmd: &types.SearchNullDataCmd{
+			Prefix: "0102",
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.blockHashByHeightErr = errors.New("out of range")
+			return chain
+		}(),
+		mockNullDataIndexer: expectArgs([]byte{0x01, 0x02}, 0, blkHeight, 0,
+			100),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleSearchRawTransactions(t *testing.T) {
 	t.Parallel()
 
@@ -6083,6 +8198,81 @@ func TestHandleRegenTemplate(t *testing.T) {
 	}})
 }
 
+func TestHandleReindex(t *testing.T) {
+	t.Parallel()
+
+	syncer := defaultMockIndexSyncer()
+	syncer.reindex = func(ctx context.Context, name string, height int64) error {
+		if name != "spend index" || height != 100 {
+			return fmt.Errorf("unexpected args: %s %d", name, height)
+		}
+		return nil
+	}
+	failingSyncer := defaultMockIndexSyncer()
+	failingSyncer.reindex = func(ctx context.Context, name string, height int64) error {
+		return errors.New("unable to rewind index")
+	}
+	testRPCServerHandler(t, []rpcTest{{
+		name:    "handleReindex: ok",
+		handler: handleReindex,
+		cmd: &types.ReindexCmd{
+			IndexName: "spend index",
+			Height:    100,
+		},
+		mockIndexSyncer: syncer,
+		result:          nil,
+	}, {
+		name:    "handleReindex: ok with short name",
+		handler: handleReindex,
+		cmd: &types.ReindexCmd{
+			IndexName: "spendindex",
+			Height:    100,
+		},
+		mockIndexSyncer: syncer,
+		result:          nil,
+	}, {
+		name:    "handleReindex: no indexes enabled",
+		handler: handleReindex,
+		cmd: &types.ReindexCmd{
+			IndexName: "spend index",
+			Height:    100,
+		},
+		setIndexSyncerNil: true,
+		wantErr:           true,
+		errCode:           dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleReindex: index not enabled",
+		handler: handleReindex,
+		cmd: &types.ReindexCmd{
+			IndexName: "unknown index",
+			Height:    100,
+		},
+		mockIndexSyncer: syncer,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleReindex: negative height",
+		handler: handleReindex,
+		cmd: &types.ReindexCmd{
+			IndexName: "spend index",
+			Height:    -1,
+		},
+		mockIndexSyncer: syncer,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInvalidParameter,
+	}, {
+		name:    "handleReindex: unable to rewind index",
+		handler: handleReindex,
+		cmd: &types.ReindexCmd{
+			IndexName: "spend index",
+			Height:    100,
+		},
+		mockIndexSyncer: failingSyncer,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCInternal.Code,
+	}})
+}
+
 func TestHandleTSpendVotes(t *testing.T) {
 	t.Parallel()
 
@@ -6314,6 +8504,46 @@ func TestTicketsForAddress(t *testing.T) {
 		result: &types.TicketsForAddressResult{
 			Tickets: hashStrings,
 		},
+	}, {
+		name:    "handleTicketsForAddress: ok historical",
+		handler: handleTicketsForAddress,
+		cmd: &types.TicketsForAddressCmd{
+			Address:           addr,
+			IncludeHistorical: dcrjson.Bool(true),
+		},
+		mockTicketIndexer: func() *testTicketIndexer {
+			ticketIndexer := defaultMockTicketIndexer()
+			ticketIndexer.ticketsForAddress = hashes
+			return ticketIndexer
+		}(),
+		result: &types.TicketsForAddressResult{
+			Tickets: hashStrings,
+		},
+	}, {
+		name:    "handleTicketsForAddress: historical without ticket index",
+		handler: handleTicketsForAddress,
+		cmd: &types.TicketsForAddressCmd{
+			Address:           addr,
+			IncludeHistorical: dcrjson.Bool(true),
+		},
+		setTicketIndexerNil: true,
+		wantErr:             true,
+		errCode:             dcrjson.ErrRPCInternal.Code,
+	}, {
+		name:    "handleTicketsForAddress: unable to fetch historical tickets",
+		handler: handleTicketsForAddress,
+		cmd: &types.TicketsForAddressCmd{
+			Address:           addr,
+			IncludeHistorical: dcrjson.Bool(true),
+		},
+		mockTicketIndexer: func() *testTicketIndexer {
+			ticketIndexer := defaultMockTicketIndexer()
+			ticketIndexer.ticketsForAddressErr =
+				errors.New("unable to fetch tickets for address")
+			return ticketIndexer
+		}(),
+		wantErr: true,
+		errCode: dcrjson.ErrRPCInternal.Code,
 	}})
 }
 
@@ -7348,6 +9578,22 @@ func TestHandleGetRawTransaction(t *testing.T) {
 		mockTxIndexer:   txIndex,
 		wantErr:         true,
 		errCode:         dcrjson.ErrRPCNoTxInfo,
+	}, {
+		name:    "handleGetRawTransaction: block pruned",
+		handler: handleGetRawTransaction,
+		cmd: &types.GetRawTransactionCmd{
+			Txid:    txid,
+			Verbose: &nonVerboseTx,
+		},
+		mockChain: func() *testRPCChain {
+			chain := defaultMockRPCChain()
+			chain.isBlockPruned = true
+			return chain
+		}(),
+		mockTxMempooler: txPool,
+		mockTxIndexer:   txIndex,
+		wantErr:         true,
+		errCode:         dcrjson.ErrRPCBlockPruned,
 	}, {
 		name:    "handleGetRawTransaction: ok, not verbose",
 		handler: handleGetRawTransaction,
@@ -7609,6 +9855,54 @@ func testRPCServerHandler(t *testing.T, tests []rpcTest) {
 			if test.setTxIndexerNil {
 				rpcserverConfig.TxIndexer = nil
 			}
+			if test.mockSpendIndexer != nil {
+				rpcserverConfig.SpendIndexer = test.mockSpendIndexer
+			}
+			if test.setSpendIndexerNil {
+				rpcserverConfig.SpendIndexer = nil
+			}
+			if test.mockTicketIndexer != nil {
+				rpcserverConfig.TicketIndexer = test.mockTicketIndexer
+			}
+			if test.setTicketIndexerNil {
+				rpcserverConfig.TicketIndexer = nil
+			}
+			if test.mockTreasuryIndexer != nil {
+				rpcserverConfig.TreasuryIndexer = test.mockTreasuryIndexer
+			}
+			if test.setTreasuryIndexerNil {
+				rpcserverConfig.TreasuryIndexer = nil
+			}
+			if test.mockScriptHashIndexer != nil {
+				rpcserverConfig.ScriptHashIndexer = test.mockScriptHashIndexer
+			}
+			if test.setScriptHashIndexerNil {
+				rpcserverConfig.ScriptHashIndexer = nil
+			}
+			if test.mockAddrUtxoIndexer != nil {
+				rpcserverConfig.AddrUtxoIndexer = test.mockAddrUtxoIndexer
+			}
+			if test.setAddrUtxoIndexerNil {
+				rpcserverConfig.AddrUtxoIndexer = nil
+			}
+			if test.mockBlockStatsIndexer != nil {
+				rpcserverConfig.BlockStatsIndexer = test.mockBlockStatsIndexer
+			}
+			if test.setBlockStatsIndexerNil {
+				rpcserverConfig.BlockStatsIndexer = nil
+			}
+			if test.mockNullDataIndexer != nil {
+				rpcserverConfig.NullDataIndexer = test.mockNullDataIndexer
+			}
+			if test.setNullDataIndexerNil {
+				rpcserverConfig.NullDataIndexer = nil
+			}
+			if test.mockIndexSyncer != nil {
+				rpcserverConfig.IndexSyncer = test.mockIndexSyncer
+			}
+			if test.setIndexSyncerNil {
+				rpcserverConfig.IndexSyncer = nil
+			}
 			if test.mockDB != nil {
 				rpcserverConfig.DB = test.mockDB
 			}
@@ -7662,7 +9956,7 @@ func testRPCServerHandler(t *testing.T, tests []rpcTest) {
 				ntfnMgr:   new(testNtfnManager),
 				workState: workState,
 			}
-			result, err := test.handler(nil, testServer, test.cmd)
+			result, err := test.handler(context.Background(), testServer, test.cmd)
 			if test.wantErr {
 				var rpcErr *dcrjson.RPCError
 				if !errors.As(err, &rpcErr) || rpcErr.Code != test.errCode {
@@ -7684,3 +9978,55 @@ func testRPCServerHandler(t *testing.T, tests []rpcTest) {
 		})
 	}
 }
+
+// testRequestObserver provides a mock request observer that records the
+// requests it observes.
+type testRequestObserver struct {
+	mtx     sync.Mutex
+	methods []string
+	failed  []bool
+}
+
+// ObserveRequest records the provided method and whether or not it failed.
+func (o *testRequestObserver) ObserveRequest(method string, elapsed time.Duration, failed bool) {
+	o.mtx.Lock()
+	o.methods = append(o.methods, method)
+	o.failed = append(o.failed, failed)
+	o.mtx.Unlock()
+}
+
+// TestRequestObserver ensures the request observer is notified of processed
+// requests and that methods the server does not handle are reported as
+// unknown.
+func TestRequestObserver(t *testing.T) {
+	t.Parallel()
+
+	observer := new(testRequestObserver)
+	s, err := New(&Config{RequestObserver: observer})
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+
+	requests := []*dcrjson.Request{{
+		Jsonrpc: "1.0",
+		ID:      1,
+		Method:  "version",
+	}, {
+		Jsonrpc: "1.0",
+		ID:      2,
+		Method:  "nosuchmethod",
+	}}
+	for _, request := range requests {
+		s.processRequest(context.Background(), request, s.adminUser,
+			"127.0.0.1:1")
+	}
+
+	wantMethods := []string{"version", "unknown"}
+	wantFailed := []bool{false, true}
+	if !reflect.DeepEqual(observer.methods, wantMethods) ||
+		!reflect.DeepEqual(observer.failed, wantFailed) {
+
+		t.Fatalf("unexpected observations -- got %v %v, want %v %v",
+			observer.methods, observer.failed, wantMethods, wantFailed)
+	}
+}
@@ -41,6 +41,15 @@ var helpDescsEnUS = map[string]string{
 	"node-target":        "Either the IP address and port of the peer to operate on, or a valid peer ID.",
 	"node-connectsubcmd": "'perm' to make the connected peer a permanent one, 'temp' to try a single connect to a peer",
 
+	// BackupDbCmd help.
+	"backupdb--synopsis": "Writes a consistent copy of the block database, including both the metadata and the stored blocks, to a new database at the provided path while the node continues to run.\n" +
+		"The copy is able to be restored with the restore command of dbtool.",
+	"backupdb-path": "The path of the directory to write the database to, relative to the data directory when it is not absolute.  It must not already contain a database",
+
+	// BackupDbResult help.
+	"backupdbresult-path":   "The absolute path of the written database",
+	"backupdbresult-dbtype": "The database backend type of the written database",
+
 	// TransactionInput help.
 	"transactioninput-amount": "The previous output amount in coins",
 	"transactioninput-txid":   "The hash of the input transaction",
@@ -163,6 +172,18 @@ var helpDescsEnUS = map[string]string{
 	"decodescript-hexscript": "Hex-encoded script",
 	"decodescript-version":   "The script version, defaults to version 0 if not set.",
 
+	// DumpUtxoSetCmd help.
+	"dumputxoset--synopsis": "Writes a snapshot of the unspent transaction output set along with the ticket database and other chain state as of the current best block to a file.\n" +
+		"The snapshot is able to be loaded by new nodes with the --loadutxosnapshot option once its hash is committed to by the network parameters.",
+	"dumputxoset-path": "The path of the file to write, relative to the data directory when it is not absolute.  The file must not already exist",
+
+	// DumpUtxoSetResult help.
+	"dumputxosetresult-blockhash":      "The hash of the block the snapshot was created at",
+	"dumputxosetresult-height":         "The height of the block the snapshot was created at",
+	"dumputxosetresult-path":           "The absolute path of the written snapshot",
+	"dumputxosetresult-numutxoentries": "The number of transactions with unspent outputs in the snapshot",
+	"dumputxosetresult-snapshothash":   "The hash of the entire snapshot that is committed to by the network parameters",
+
 	// ExistsAddressCmd help.
 	"existsaddress--synopsis": "Test for the existence of the provided address",
 	"existsaddress-address":   "The address to check",
@@ -225,6 +246,31 @@ var helpDescsEnUS = map[string]string{
 	"getbestblockresult-hash":   "Hex-encoded bytes of the best block hash",
 	"getbestblockresult-height": "Height of the best block",
 
+	// GetAddressBalanceCmd help.
+	"getaddressbalance--synopsis": "Returns the confirmed balance of an address along with the net change to it from unconfirmed transactions in the memory pool.\n" +
+		"The address utxo index must be enabled (--addrutxoindex).",
+	"getaddressbalance-address": "The address to query the balance of",
+
+	// GetAddressBalanceResult help.
+	"getaddressbalanceresult-confirmed":   "The total amount in atoms of the unspent outputs in the main chain that pay to the address",
+	"getaddressbalanceresult-unconfirmed": "The net change in atoms to the confirmed balance from unconfirmed transactions in the memory pool",
+
+	// GetAddressUtxosCmd help.
+	"getaddressutxos--synopsis": "Returns the unspent outputs that pay to an address.\n" +
+		"The address utxo index must be enabled (--addrutxoindex).",
+	"getaddressutxos-address":        "The address to query the unspent outputs of",
+	"getaddressutxos-includemempool": "Include the effects of unconfirmed transactions in the memory pool",
+
+	// GetAddressUtxosResult help.
+	"getaddressutxosresult-txid":          "The hash of the transaction that created the output",
+	"getaddressutxosresult-vout":          "The index of the output",
+	"getaddressutxosresult-tree":          "The tree of the transaction that created the output",
+	"getaddressutxosresult-amount":        "The value of the output in atoms",
+	"getaddressutxosresult-scriptversion": "The version of the public key script",
+	"getaddressutxosresult-scriptpubkey":  "Hex-encoded public key script of the output",
+	"getaddressutxosresult-height":        "The height of the block that contains the transaction that created the output (0 when unconfirmed)",
+	"getaddressutxosresult-confirmations": "The number of confirmations of the transaction that created the output",
+
 	// GetBestBlockCmd help.
 	"getbestblock--synopsis": "Get block height and hash of best block in the main chain.",
 	"getbestblock--result0":  "Get block height and hash of best block in the main chain.",
@@ -261,6 +307,7 @@ var helpDescsEnUS = map[string]string{
 	"getblockchaininforesult-deployments--desc":    "Consensus deployment agendas.",
 	"getblockchaininforesult-deployments--key":     "The consensus deployment agenda id.",
 	"getblockchaininforesult-deployments--value":   "The consensus deployment agenda information.",
+	"getblockchaininforesult-utxocache":            "The state of the cache of unspent transaction outputs.",
 
 	// AgendaInfo help.
 	"agendainfo-status":     "The deployment agenda's current status.",
@@ -268,6 +315,14 @@ var helpDescsEnUS = map[string]string{
 	"agendainfo-starttime":  "The start time of the voting period for the agenda.",
 	"agendainfo-expiretime": "The expiry time of the voting period for the agenda.",
 
+	// UtxoCacheInfo help.
+	"utxocacheinfo-entries":  "The number of entries in the cache.",
+	"utxocacheinfo-size":     "The approximate size of the cache in bytes.",
+	"utxocacheinfo-maxsize":  "The maximum size of the cache in bytes before it is flushed to the database.",
+	"utxocacheinfo-hits":     "The number of lookups that were served by the cache.",
+	"utxocacheinfo-misses":   "The number of lookups that required loading from the database.",
+	"utxocacheinfo-hitratio": "The ratio of lookups that were served by the cache.",
+
 	// TxRawResult help.
 	"txrawresult-hex":           "Hex-encoded transaction",
 	"txrawresult-txid":          "The hash of the transaction",
@@ -369,6 +424,61 @@ var helpDescsEnUS = map[string]string{
 	"getblockheaderverboseresult-extradata":         "Extra data field for the requested block",
 	"getblockheaderverboseresult-stakeversion":      "The stake version of the block",
 
+	// GetBlockSpendsCmd help.
+	"getblockspends--synopsis": "Returns the previous outputs spent by every transaction input in a block in the main chain, including their amounts, scripts, and the height of the block that created them.\n" +
+		"The outputs spent by the stake transaction tree are listed first followed by those spent by the regular transaction tree.",
+	"getblockspends-hash": "The hash of the block",
+
+	// GetBlockSpendsResult help.
+	"getblockspendsresult-hash":   "The hash of the block (same as provided)",
+	"getblockspendsresult-height": "The height of the block in the block chain",
+	"getblockspendsresult-tx":     "The transactions in the block that spend previous outputs",
+
+	// BlockSpendsTx help.
+	"blockspendstx-txid":   "The hash of the spending transaction",
+	"blockspendstx-tree":   "The tree of the spending transaction",
+	"blockspendstx-spends": "The previous outputs spent by the transaction inputs",
+
+	// BlockSpend help.
+	"blockspend-vin":          "The index of the spending transaction input",
+	"blockspend-txid":         "The hash of the transaction that created the spent output",
+	"blockspend-vout":         "The index of the spent output",
+	"blockspend-tree":         "The tree of the transaction that created the spent output",
+	"blockspend-amountin":     "The amount of the spent output in coins",
+	"blockspend-blockheight":  "The height of the block that contains the transaction that created the spent output",
+	"blockspend-blockindex":   "The index of the transaction that created the spent output within its block",
+	"blockspend-version":      "The script version of the spent output",
+	"blockspend-scriptPubKey": "The public key script of the spent output",
+
+	// GetBlockStatsCmd help.
+	"getblockstats--synopsis": "Returns statistics about a block in the main chain.\n" +
+		"The fee rates are in atoms/kB, weighted by transaction size, and exclude transactions that create new coins, namely the coinbase, treasury base, votes, and treasury spends.\n" +
+		"Requires the block stats index to be enabled (--blockstatsindex).",
+	"getblockstats-hashorheight": "The hash or height of the block",
+	"getblockstats-stats":        "The names of the statistics to return (all statistics when omitted or empty)",
+
+	// GetBlockStatsResult help.
+	"getblockstatsresult-hash":               "The hash of the block",
+	"getblockstatsresult-height":             "The height of the block",
+	"getblockstatsresult-time":               "The timestamp of the block in seconds since 1 Jan 1970 GMT",
+	"getblockstatsresult-size":               "The size of the block in bytes",
+	"getblockstatsresult-txs":                "The number of transactions in the regular tree",
+	"getblockstatsresult-stxs":               "The number of transactions in the stake tree",
+	"getblockstatsresult-tickets":            "The number of ticket purchases",
+	"getblockstatsresult-votes":              "The number of votes",
+	"getblockstatsresult-revocations":        "The number of revocations",
+	"getblockstatsresult-tadds":              "The number of treasury adds",
+	"getblockstatsresult-tspends":            "The number of treasury spends",
+	"getblockstatsresult-totalfee":           "The total fees in atoms paid by all transactions",
+	"getblockstatsresult-minfeerate":         "The minimum fee rate",
+	"getblockstatsresult-maxfeerate":         "The maximum fee rate",
+	"getblockstatsresult-avgfeerate":         "The average fee rate",
+	"getblockstatsresult-feeratepercentiles": "The fee rates at the 10th, 25th, 50th, 75th, and 90th percentiles",
+	"getblockstatsresult-taddamount":         "The total amount in atoms added to the treasury by treasury adds",
+	"getblockstatsresult-treasurybaseamount": "The amount in atoms added to the treasury by the treasury base",
+	"getblockstatsresult-tspendamount":       "The total amount in atoms paid from the treasury by treasury spends excluding fees",
+	"getblockstatsresult-utxoincrease":       "The number of spendable outputs created minus the number of outputs spent",
+
 	// GetBlockSubsidyCmd help.
 	"getblocksubsidy--synopsis": "Returns information regarding subsidy amounts.",
 	"getblocksubsidy-height":    "The block height",
@@ -394,6 +504,12 @@ var helpDescsEnUS = map[string]string{
 	"getcfilterheader-hash":       "The block hash of the filter header being queried",
 	"getcfilterheader-filtertype": "The type of committed filter to return the header commitment for",
 
+	// GetCFiltersV2Cmd help.
+	"getcfiltersv2--synopsis": "Returns the version 2 block filters for a contiguous range of blocks in the main chain along with proofs that can be used to prove each filter is committed to by its block header.\n" +
+		"The range may not contain more than 100 blocks.",
+	"getcfiltersv2-starthash": "The hash of the first block in the range",
+	"getcfiltersv2-endhash":   "The hash of the last block in the range",
+
 	// GetCFilterV2Cmd help.
 	"getcfilterv2--synopsis": "Returns the version 2 block filter for the given block along with a proof that can be used to prove the filter is committed to by the block header",
 	"getcfilterv2-blockhash": "The block hash of the filter to retrieve",
@@ -432,6 +548,21 @@ var helpDescsEnUS = map[string]string{
 	"getdifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
 	"getdifficulty--result0":  "The difficulty",
 
+	// GetSpendingInfoCmd help.
+	"getspendinginfo--synopsis": "Returns the transaction that spends a transaction output along with the block that contains it, if any.\n" +
+		"The spend index must be enabled (--spendindex).",
+	"getspendinginfo-txid":           "The hash of the transaction",
+	"getspendinginfo-vout":           "The index of the output",
+	"getspendinginfo-includemempool": "Include unconfirmed spending transactions in the mempool when true",
+
+	// GetSpendingInfoResult help.
+	"getspendinginforesult-spent":         "Whether or not the output is spent",
+	"getspendinginforesult-txid":          "The hash of the spending transaction (only when spent)",
+	"getspendinginforesult-vin":           "The index of the spending transaction input (only when spent)",
+	"getspendinginforesult-blockhash":     "The hash of the block that contains the spending transaction (only when spent in a block)",
+	"getspendinginforesult-blockheight":   "The height of the block that contains the spending transaction (only when spent in a block)",
+	"getspendinginforesult-confirmations": "The number of confirmations of the spending transaction",
+
 	// GetStakeDifficultyCmd help.
 	"getstakedifficulty--synopsis":     "Returns the proof-of-stake difficulty.",
 	"getstakedifficultyresult-current": "The current top block's stake difficulty",
@@ -536,6 +667,16 @@ var helpDescsEnUS = map[string]string{
 	"getheaders-hashstop":      "Block hash to stop including block headers for. Set to zero to get as many blocks as possible",
 	"getheadersresult-headers": "Serialized block headers of all located blocks, limited to some arbitrary maximum number of hashes (currently 2000, which matches the wire protocol headers message, but this is not guaranteed)",
 
+	// GetIndexInfoCmd help.
+	"getindexinfo--synopsis": "Returns the sync state of the enabled optional indexes.",
+	"getindexinfo-indexname": "Only return the sync state of the index with this name",
+
+	// GetIndexInfoResult help.
+	"getindexinforesult-name":            "The name of the index",
+	"getindexinforesult-synced":          "Whether or not the index has caught up to the best chain tip since it was initialized",
+	"getindexinforesult-bestblockhash":   "The hash of the most recent block that has been indexed",
+	"getindexinforesult-bestblockheight": "The height of the most recent block that has been indexed",
+
 	// GetInfoCmd help.
 	"getinfo--synopsis": "Returns a JSON object containing various state info.",
 
@@ -653,6 +794,48 @@ var helpDescsEnUS = map[string]string{
 	"getrawtransaction--condition1": "verbose=true",
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
+	// GetScriptHashBalanceCmd help.
+	"getscripthashbalance--synopsis": "Returns the confirmed balance of a public key script identified by its script hash.\n" +
+		"The script hash is the SHA256 hash of the full public key script in byte-reversed hex, which matches the Electrum protocol.\n" +
+		"The script hash index must be enabled (--scripthashindex).",
+	"getscripthashbalance-scripthash": "The script hash of the public key script",
+
+	// GetScriptHashBalanceResult help.
+	"getscripthashbalanceresult-confirmed": "The total amount in atoms of the unspent outputs in the main chain that pay to the script",
+	"getscripthashbalanceresult-utxocount": "The number of unspent outputs in the main chain that pay to the script",
+
+	// GetScriptHashHistoryCmd help.
+	"getscripthashhistory--synopsis": "Returns the transactions in the main chain that involve a public key script identified by its script hash, either by paying to it or spending from it, ordered by their position in the chain.\n" +
+		"The script hash is the SHA256 hash of the full public key script in byte-reversed hex, which matches the Electrum protocol.\n" +
+		"The script hash index must be enabled (--scripthashindex).",
+	"getscripthashhistory-scripthash": "The script hash of the public key script",
+	"getscripthashhistory-skip":       "The number of leading transactions to leave out of the final response",
+	"getscripthashhistory-count":      "The maximum number of transactions to return",
+
+	// GetScriptHashHistoryResult help.
+	"getscripthashhistoryresult-txid":          "The hash of the transaction",
+	"getscripthashhistoryresult-blockhash":     "The hash of the block that contains the transaction",
+	"getscripthashhistoryresult-blockheight":   "The height of the block that contains the transaction",
+	"getscripthashhistoryresult-confirmations": "The number of confirmations of the block that contains the transaction",
+
+	// GetTicketInfoCmd help.
+	"getticketinfo--synopsis": "Returns the history of a ticket, such as its purchase, the vote or revocation that spent it, and whether it was missed or expired.\n" +
+		"The ticket index must be enabled (--ticketindex).",
+	"getticketinfo-ticket": "The hash of the ticket",
+
+	// GetTicketInfoResult help.
+	"getticketinforesult-ticket":          "The hash of the ticket",
+	"getticketinforesult-status":          "The status of the ticket (immature, live, voted, missed, expired, or revoked)",
+	"getticketinforesult-purchaseblock":   "The hash of the block that contains the ticket purchase",
+	"getticketinforesult-purchaseheight":  "The height of the block that contains the ticket purchase",
+	"getticketinforesult-price":           "The price of the ticket in DCR",
+	"getticketinforesult-votingaddress":   "The address that is able to vote with the ticket (only when the voting script contains a supported address)",
+	"getticketinforesult-rewardaddresses": "The addresses committed to receive the rewards of the ticket",
+	"getticketinforesult-spendingtx":      "The hash of the vote or revocation that spent the ticket (only when voted or revoked)",
+	"getticketinforesult-spendingheight":  "The height of the block that contains the vote or revocation (only when voted or revoked)",
+	"getticketinforesult-missedheight":    "The height of the block that missed the ticket (only when missed)",
+	"getticketinforesult-expiredheight":   "The height of the block that expired the ticket (only when expired)",
+
 	// GetTicketPoolValue help.
 	"getticketpoolvalue--synopsis": "Return the current value of all locked funds in the ticket pool",
 	"getticketpoolvalue--result0":  "Total value of ticket pool",
@@ -670,6 +853,24 @@ var helpDescsEnUS = map[string]string{
 	"gettreasurybalance--condition0": "verbose=false",
 	"gettreasurybalance--condition1": "verbose=true",
 
+	// GetTreasuryHistoryCmd help.
+	"gettreasuryhistory--synopsis": "Returns the treasury adds, treasury bases, and treasury spends in the main chain over a range of heights along with the treasury balance as of the blocks that contain them.\n" +
+		"The treasury index must be enabled (--treasuryindex).",
+	"gettreasuryhistory-startheight": "The height of the first block to include (default: 0)",
+	"gettreasuryhistory-endheight":   "The height of the last block to include (default: current best height)",
+	"gettreasuryhistory-types":       "The types of treasury transactions to include (tadd, treasurybase, and/or tspend) (default: all types)",
+	"gettreasuryhistory-skip":        "The number of leading treasury transactions to leave out of the final response",
+	"gettreasuryhistory-count":       "The maximum number of treasury transactions to return",
+
+	// GetTreasuryHistoryResult help.
+	"gettreasuryhistoryresult-type":        "The type of the treasury transaction (tadd, treasurybase, or tspend)",
+	"gettreasuryhistoryresult-txid":        "The hash of the treasury transaction",
+	"gettreasuryhistoryresult-blockhash":   "The hash of the block that contains the treasury transaction",
+	"gettreasuryhistoryresult-blockheight": "The height of the block that contains the treasury transaction",
+	"gettreasuryhistoryresult-amount":      "The amount in atoms the transaction adds to the treasury once it matures (negative for treasury spends and excludes the fee)",
+	"gettreasuryhistoryresult-fee":         "The fee in atoms paid from the treasury (only for treasury spends)",
+	"gettreasuryhistoryresult-balance":     "The treasury balance in atoms as of the block that contains the treasury transaction",
+
 	// TreasurySpendVotes help.
 	"treasuryspendvotes-hash":      "The hash of the tspend transaction",
 	"treasuryspendvotes-expiry":    "The block height when the tspend expires",
@@ -705,15 +906,17 @@ var helpDescsEnUS = map[string]string{
 	"gettxout-includemempool": "Include the mempool when true",
 
 	// GetTxOutSetInfoCmd help.
-	"gettxoutsetinfo--synopsis": "Returns statistics on current unspent transaction output set.",
+	"gettxoutsetinfo--synopsis": "Returns statistics on the unspent transaction output set as of the current best block or a recent block.",
+	"gettxoutsetinfo-height":    "The height of a recent block in the main chain to return the statistics for instead of the current best block",
 
 	// GetTxOutSetInfoResult help.
-	"gettxoutsetinforesult-height":         "The current block height.",
-	"gettxoutsetinforesult-bestblock":      "The hex encoded hash of the best block.",
+	"gettxoutsetinforesult-height":         "The height of the block the statistics are for.",
+	"gettxoutsetinforesult-bestblock":      "The hex encoded hash of the block the statistics are for.",
 	"gettxoutsetinforesult-transactions":   "The number of unique transactions referenced by outputs.",
 	"gettxoutsetinforesult-txouts":         "The number of transaction outputs.",
-	"gettxoutsetinforesult-serializedhash": "The merklized hash of the utxo set.",
-	"gettxoutsetinforesult-disksize":       "The size of the utxo set on disk, in bytes.",
+	"gettxoutsetinforesult-serializedhash": "(DEPRECATED) No longer populated.  Use muhash instead.",
+	"gettxoutsetinforesult-muhash":         "The MuHash3072 digest that commits to all unspent transaction outputs.",
+	"gettxoutsetinforesult-disksize":       "The serialized size of the utxo set, in bytes.",
 	"gettxoutsetinforesult-totalamount":    "The total value of the utxo set.",
 
 	// GetWorkResult help.
@@ -747,6 +950,25 @@ var helpDescsEnUS = map[string]string{
 	// RebroadcastWinnerCmd help.
 	"rebroadcastwinners--synopsis": "Asks the daemon to rebroadcast the winners of the voting lottery.\n",
 
+	// SearchNullDataCmd help.
+	"searchnulldata--synopsis": "Returns the null data (OP_RETURN) outputs in the regular transaction tree of the main chain with payloads that start with the provided prefix.\n" +
+		"The coinbase is not searched.  The results are ordered by their payloads and then by their position in the chain.\n" +
+		"Requires the null data index to be enabled (--nulldataindex).",
+	"searchnulldata-prefix":      "The hex-encoded payload prefix to search for",
+	"searchnulldata-startheight": "The height of the first block to include (default: 0)",
+	"searchnulldata-endheight":   "The height of the last block to include (default: current best height)",
+	"searchnulldata-skip":        "The number of leading outputs to leave out of the final response",
+	"searchnulldata-count":       "The maximum number of outputs to return",
+
+	// SearchNullDataResult help.
+	"searchnulldataresult-txid":          "The hash of the transaction that contains the output",
+	"searchnulldataresult-vout":          "The index of the output",
+	"searchnulldataresult-blockhash":     "The hash of the block that contains the transaction",
+	"searchnulldataresult-blockheight":   "The height of the block that contains the transaction",
+	"searchnulldataresult-blockindex":    "The index of the transaction within the regular tree of the block",
+	"searchnulldataresult-confirmations": "The number of confirmations of the transaction",
+	"searchnulldataresult-data":          "The hex-encoded payload of the output",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -944,9 +1166,10 @@ var helpDescsEnUS = map[string]string{
 	"feeinfowindow-stddev":      "Standard deviation of transaction fees in the window",
 
 	// TicketsForAddress help.
-	"ticketsforaddress--synopsis":     "Request all the tickets for an address.",
-	"ticketsforaddress-address":       "Address to look for.",
-	"ticketsforaddressresult-tickets": "Tickets owned by the specified address.",
+	"ticketsforaddress--synopsis":         "Request all the tickets for an address.",
+	"ticketsforaddress-address":           "Address to look for.",
+	"ticketsforaddress-includehistorical": "Include the tickets that were already spent, missed, or expired along with the tickets that involve the address as a reward address (requires --ticketindex).",
+	"ticketsforaddressresult-tickets":     "Tickets owned by the specified address.",
 
 	// TicketsForBucket help.
 	"ticketsforbucket--synopsis":     "Request all the tickets and owners in a given bucket.",
@@ -985,6 +1208,12 @@ var helpDescsEnUS = map[string]string{
 
 	// regentemplate help
 	"regentemplate--synopsis": "Asks the node to regenerate its block mining template.",
+
+	// ReindexCmd help.
+	"reindex--synopsis": "Rewinds the enabled optional index with the provided name to the provided height so the main chain blocks after it are indexed again.\n" +
+		"The enabled indexes that depend on the index, such as the address index for the transaction index, are rewound to the same height as well while the other indexes are not modified.  The indexes are caught up to the best chain tip in the background once they have been rewound and report that they are not synced until then.",
+	"reindex-indexname": "The name of the index as reported by getindexinfo or the name of the option used to enable it, such as addrindex",
+	"reindex-height":    "The height of the most recent block that remains indexed",
 }
 
 // rpcResultTypes specifies the result types that each RPC command can return.
@@ -992,12 +1221,14 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[types.Method][]interface{}{
 	"addnode":               nil,
+	"backupdb":              {(*types.BackupDbResult)(nil)},
 	"createrawsstx":         {(*string)(nil)},
 	"createrawssrtx":        {(*string)(nil)},
 	"createrawtransaction":  {(*string)(nil)},
 	"debuglevel":            {(*string)(nil), (*string)(nil)},
 	"decoderawtransaction":  {(*types.TxRawDecodeResult)(nil)},
 	"decodescript":          {(*types.DecodeScriptResult)(nil)},
+	"dumputxoset":           {(*types.DumpUtxoSetResult)(nil)},
 	"estimatefee":           {(*float64)(nil)},
 	"estimatesmartfee":      {(*float64)(nil)},
 	"estimatestakediff":     {(*types.EstimateStakeDiffResult)(nil)},
@@ -1009,6 +1240,8 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"existslivetickets":     {(*string)(nil)},
 	"existsmempooltxs":      {(*string)(nil)},
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]types.GetAddedNodeInfoResult)(nil)},
+	"getaddressbalance":     {(*types.GetAddressBalanceResult)(nil)},
+	"getaddressutxos":       {(*[]types.GetAddressUtxosResult)(nil)},
 	"getbestblock":          {(*types.GetBestBlockResult)(nil)},
 	"generate":              {(*[]string)(nil)},
 	"getbestblockhash":      {(*string)(nil)},
@@ -1017,20 +1250,27 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"getblockcount":         {(*int64)(nil)},
 	"getblockhash":          {(*string)(nil)},
 	"getblockheader":        {(*string)(nil), (*types.GetBlockHeaderVerboseResult)(nil)},
+	"getblockspends":        {(*types.GetBlockSpendsResult)(nil)},
+	"getblockstats":         {(*types.GetBlockStatsResult)(nil)},
 	"getblocksubsidy":       {(*types.GetBlockSubsidyResult)(nil)},
 	"getcfilter":            {(*string)(nil)},
 	"getcfilterheader":      {(*string)(nil)},
+	"getcfiltersv2":         {(*[]types.GetCFilterV2Result)(nil)},
 	"getcfilterv2":          {(*types.GetCFilterV2Result)(nil)},
 	"getchaintips":          {(*[]types.GetChainTipsResult)(nil)},
 	"getconnectioncount":    {(*int32)(nil)},
 	"getcurrentnet":         {(*uint32)(nil)},
 	"getdifficulty":         {(*float64)(nil)},
+	"getscripthashbalance":  {(*types.GetScriptHashBalanceResult)(nil)},
+	"getscripthashhistory":  {(*[]types.GetScriptHashHistoryResult)(nil)},
+	"getspendinginfo":       {(*types.GetSpendingInfoResult)(nil)},
 	"getstakedifficulty":    {(*types.GetStakeDifficultyResult)(nil)},
 	"getstakeversioninfo":   {(*types.GetStakeVersionInfoResult)(nil)},
 	"getstakeversions":      {(*types.GetStakeVersionsResult)(nil)},
 	"getgenerate":           {(*bool)(nil)},
 	"gethashespersec":       {(*float64)(nil)},
 	"getheaders":            {(*types.GetHeadersResult)(nil)},
+	"getindexinfo":          {(*[]types.GetIndexInfoResult)(nil)},
 	"getinfo":               {(*types.InfoChainResult)(nil)},
 	"getmempoolinfo":        {(*types.GetMempoolInfoResult)(nil)},
 	"getmininginfo":         {(*types.GetMiningInfoResult)(nil)},
@@ -1040,8 +1280,10 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"getpeerinfo":           {(*[]types.GetPeerInfoResult)(nil)},
 	"getrawmempool":         {(*[]string)(nil), (*types.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":     {(*string)(nil), (*types.TxRawResult)(nil)},
+	"getticketinfo":         {(*types.GetTicketInfoResult)(nil)},
 	"getticketpoolvalue":    {(*float64)(nil)},
 	"gettreasurybalance":    {(*types.GetTreasuryBalanceResult)(nil)},
+	"gettreasuryhistory":    {(*[]types.GetTreasuryHistoryResult)(nil)},
 	"gettreasuryspendvotes": {(*types.GetTreasurySpendVotesResult)(nil)},
 	"gettxout":              {(*types.GetTxOutResult)(nil)},
 	"gettxoutsetinfo":       {(*types.GetTxOutSetInfoResult)(nil)},
@@ -1054,6 +1296,8 @@ var rpcResultTypes = map[types.Method][]interface{}{
 	"node":                  nil,
 	"ping":                  nil,
 	"regentemplate":         nil,
+	"reindex":               nil,
+	"searchnulldata":        {(*[]types.SearchNullDataResult)(nil)},
 	"searchrawtransactions": {(*string)(nil), (*[]types.SearchRawTransactionsResult)(nil)},
 	"sendrawtransaction":    {(*string)(nil)},
 	"setgenerate":           nil,
@@ -8,8 +8,6 @@ package rpcserver
 import (
 	"bytes"
 	"context"
-	"crypto/sha256"
-	"crypto/subtle"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
@@ -92,7 +90,10 @@ var wsHandlersBeforeInit = map[types.Method]wsCommandHandler{
 // must be run in a separate goroutine.  It should be invoked from the websocket
 // server handler which runs each new connection in a new goroutine thereby
 // satisfying the requirement.
-func (s *Server) WebsocketHandler(conn *websocket.Conn, remoteAddr string, authenticated bool, isAdmin bool) {
+//
+// The provided user identifies the client when it has already been
+// authenticated (via HTTP Basic access authentication) and is nil otherwise.
+func (s *Server) WebsocketHandler(conn *websocket.Conn, remoteAddr string, user *rpcUser) {
 	// Clear the read deadline that was set before the websocket hijacked
 	// the connection.
 	conn.SetReadDeadline(timeZeroVal)
@@ -110,7 +111,7 @@ func (s *Server) WebsocketHandler(conn *websocket.Conn, remoteAddr string, authe
 	// Create a new websocket client to handle the new websocket connection
 	// and wait for it to shutdown.  Once it has shutdown (and hence
 	// disconnected), remove it and any notifications it registered for.
-	client, err := newWebsocketClient(s, conn, remoteAddr, authenticated, isAdmin)
+	client, err := newWebsocketClient(s, conn, remoteAddr, user)
 	if err != nil {
 		log.Errorf("Failed to serve client %s: %v", remoteAddr, err)
 		conn.Close()
@@ -1392,9 +1393,10 @@ type wsClient struct {
 	// and therefore is allowed to communicated over the websocket.
 	authenticated bool
 
-	// isAdmin specifies whether a client may change the state of the server;
-	// false means its access is only to the limited set of RPC calls.
-	isAdmin bool
+	// user identifies the authenticated user of the client along with the
+	// methods it is authorized to call.  It is nil until the client has been
+	// authenticated.
+	user *rpcUser
 
 	// sessionID is a random ID generated for each client when connected.
 	// These IDs may be queried by a client using the session RPC.  A change
@@ -1528,16 +1530,13 @@ out:
 				// Check credentials.
 				login := authCmd.Username + ":" + authCmd.Passphrase
 				auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-				mac := make([]byte, 0, sha256.Size)
-				mac = c.rpcServer.authMAC(mac, []byte(auth))
-				cmp := subtle.ConstantTimeCompare(mac, c.rpcServer.authsha[:])
-				limitcmp := subtle.ConstantTimeCompare(mac, c.rpcServer.limitauthsha[:])
-				if cmp|limitcmp != 0 {
+				user := c.rpcServer.authenticate(auth, c.addr)
+				if user == nil {
 					log.Warnf("Auth failure.")
 					break out
 				}
 				c.authenticated = true
-				c.isAdmin = cmp == 1
+				c.user = user
 
 				// Marshal and send response.
 				reply, err = createMarshalledReply(cmd.jsonrpc, cmd.id, nil, nil)
@@ -1550,24 +1549,22 @@ out:
 				continue
 			}
 
-			// Check if the client is using limited RPC credentials and
-			// error when not authorized to call the supplied RPC.
-			if !c.isAdmin {
-				if _, ok := rpcLimited[req.Method]; !ok {
-					jsonErr := &dcrjson.RPCError{
-						Code:    dcrjson.ErrRPCInvalidParams.Code,
-						Message: "limited user not authorized for this method",
-					}
-					// Marshal and send response.
-					reply, err = createMarshalledReply("", req.ID, nil, jsonErr)
-					if err != nil {
-						log.Errorf("Failed to marshal parse failure "+
-							"reply: %v", err)
-						continue
-					}
-					c.SendMessage(reply, nil)
+			// Error when the user of the client is not authorized to call
+			// the supplied RPC.
+			if !c.rpcServer.authorized(c.user, req.Method, c.addr) {
+				jsonErr := &dcrjson.RPCError{
+					Code:    dcrjson.ErrRPCInvalidParams.Code,
+					Message: "user not authorized for this method",
+				}
+				// Marshal and send response.
+				reply, err = createMarshalledReply("", req.ID, nil, jsonErr)
+				if err != nil {
+					log.Errorf("Failed to marshal parse failure "+
+						"reply: %v", err)
 					continue
 				}
+				c.SendMessage(reply, nil)
+				continue
 			}
 
 			// Asynchronously handle the request.  A semaphore is used to
@@ -1741,16 +1738,14 @@ out:
 							// Check credentials.
 							login := authCmd.Username + ":" + authCmd.Passphrase
 							auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
-							authSha := sha256.Sum256([]byte(auth))
-							cmp := subtle.ConstantTimeCompare(authSha[:], c.rpcServer.authsha[:])
-							limitcmp := subtle.ConstantTimeCompare(authSha[:], c.rpcServer.limitauthsha[:])
-							if cmp != 1 && limitcmp != 1 {
+							user := c.rpcServer.authenticate(auth, c.addr)
+							if user == nil {
 								log.Warnf("Auth failure.")
 								break out
 							}
 
 							c.authenticated = true
-							c.isAdmin = cmp == 1
+							c.user = user
 
 							// Marshal and send response.
 							reply, err = createMarshalledReply(cmd.jsonrpc, cmd.id, nil, nil)
@@ -1766,27 +1761,25 @@ out:
 							continue
 						}
 
-						// Check if the client is using limited RPC credentials and
-						// error when not authorized to call the supplied RPC.
-						if !c.isAdmin {
-							if _, ok := rpcLimited[req.Method]; !ok {
-								jsonErr := &dcrjson.RPCError{
-									Code:    dcrjson.ErrRPCInvalidParams.Code,
-									Message: "limited user not authorized for this method",
-								}
-								// Marshal and send response.
-								reply, err = createMarshalledReply(req.Jsonrpc, req.ID, nil, jsonErr)
-								if err != nil {
-									log.Errorf("Failed to marshal parse failure "+
-										"reply: %v", err)
-									continue
-								}
-
-								if reply != nil {
-									results = append(results, reply)
-								}
+						// Error when the user of the client is not authorized to
+						// call the supplied RPC.
+						if !c.rpcServer.authorized(c.user, req.Method, c.addr) {
+							jsonErr := &dcrjson.RPCError{
+								Code:    dcrjson.ErrRPCInvalidParams.Code,
+								Message: "user not authorized for this method",
+							}
+							// Marshal and send response.
+							reply, err = createMarshalledReply(req.Jsonrpc, req.ID, nil, jsonErr)
+							if err != nil {
+								log.Errorf("Failed to marshal parse failure "+
+									"reply: %v", err)
 								continue
 							}
+
+							if reply != nil {
+								results = append(results, reply)
+							}
+							continue
 						}
 
 						// Lookup the websocket extension for the command, if it doesn't
@@ -1864,12 +1857,14 @@ func (c *wsClient) serviceRequest(ctx context.Context, r *parsedRPCCmd) {
 
 	// Lookup the websocket extension for the command and if it doesn't
 	// exist fallback to handling the command as a standard command.
+	start := time.Now()
 	wsHandler, ok := wsHandlers[r.method]
 	if ok {
 		result, err = wsHandler(c, r.params)
 	} else {
 		result, err = c.rpcServer.standardCmdResult(ctx, r)
 	}
+	c.rpcServer.observeRequest(string(r.method), start, err != nil)
 	reply, err := createMarshalledReply(r.jsonrpc, r.id, result, err)
 	if err != nil {
 		log.Errorf("Failed to marshal reply for <%s> "+
@@ -2094,13 +2089,14 @@ func (c *wsClient) WaitForShutdown() {
 }
 
 // newWebsocketClient returns a new websocket client given the notification
-// manager, websocket connection, remote address, and whether or not the client
-// has already been authenticated (via HTTP Basic access authentication).  The
+// manager, websocket connection, remote address, and the user of the client
+// when it has already been authenticated (via HTTP Basic access
+// authentication).  The
 // returned client is ready to start.  Once started, the client will process
 // incoming and outgoing messages in separate goroutines complete with queuing
 // and asynchronous handling for long-running operations.
 func newWebsocketClient(server *Server, conn *websocket.Conn,
-	remoteAddr string, authenticated bool, isAdmin bool) (*wsClient, error) {
+	remoteAddr string, user *rpcUser) (*wsClient, error) {
 
 	sessionID, err := wire.RandomUint64()
 	if err != nil {
@@ -2110,8 +2106,8 @@ func newWebsocketClient(server *Server, conn *websocket.Conn,
 	client := &wsClient{
 		conn:              conn,
 		addr:              remoteAddr,
-		authenticated:     authenticated,
-		isAdmin:           isAdmin,
+		authenticated:     user != nil,
+		user:              user,
 		sessionID:         sessionID,
 		rpcServer:         server,
 		serviceRequestSem: makeSemaphore(server.cfg.RPCMaxConcurrentReqs),
@@ -2476,6 +2472,9 @@ func handleRescan(wsc *wsClient, icmd interface{}) (interface{}, error) {
 	for i := range blockHashes {
 		block, err := bc.BlockByHash(&blockHashes[i])
 		if err != nil {
+			if errors.Is(err, blockchain.ErrBlockPruned) {
+				return nil, rpcBlockPrunedError(blockHashes[i])
+			}
 			return nil, &dcrjson.RPCError{
 				Code:    dcrjson.ErrRPCBlockNotFound,
 				Message: "Failed to fetch block: " + err.Error(),
@@ -0,0 +1,208 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/decred/dcrd/internal/metrics"
+)
+
+// feeEstimateTargets are the confirmation targets, in blocks, for which the fee
+// estimates are exported.  Targets beyond the maximum confirmation range
+// tracked by the fee estimator are skipped.
+var feeEstimateTargets = []int32{1, 2, 4, 8, 16, 32}
+
+// nodeMetrics houses the metrics exported by the node in the Prometheus text
+// exposition format along with the metrics that are updated as events occur.
+//
+// The metrics are always collected since doing so is inexpensive and are only
+// served when a metrics listen address is configured.
+type nodeMetrics struct {
+	registry *metrics.Registry
+
+	// processBlockTime tracks the time taken to process blocks.
+	processBlockTime *metrics.Histogram
+
+	// rpcRequestTime tracks the time taken to process RPC requests by method
+	// while rpcRequestErrors counts the requests that resulted in an error.
+	rpcRequestTime   *metrics.HistogramVec
+	rpcRequestErrors *metrics.CounterVec
+}
+
+// newNodeMetrics returns a new instance of the node metrics with the metrics
+// that are updated as events occur registered.  The metrics that are obtained
+// from the server subsystems are registered separately once they are created
+// via registerServer.
+func newNodeMetrics() *nodeMetrics {
+	registry := metrics.NewRegistry()
+	return &nodeMetrics{
+		registry: registry,
+		processBlockTime: registry.NewHistogram(
+			"dcrd_chain_process_block_seconds",
+			"Time taken to validate and process blocks.",
+			metrics.DefaultLatencyBuckets),
+		rpcRequestTime: registry.NewHistogramVec(
+			"dcrd_rpc_request_seconds",
+			"Time taken to process RPC requests by method.", "method",
+			metrics.DefaultLatencyBuckets),
+		rpcRequestErrors: registry.NewCounterVec(
+			"dcrd_rpc_request_errors_total",
+			"Number of RPC requests that resulted in an error by method.",
+			"method"),
+	}
+}
+
+// ObserveRequest records the time taken to process an RPC request for the
+// provided method and whether or not it resulted in an error.
+//
+// This is part of the rpcserver.RequestObserver interface.
+func (m *nodeMetrics) ObserveRequest(method string, elapsed time.Duration, failed bool) {
+	m.rpcRequestTime.Observe(method, elapsed.Seconds())
+	if failed {
+		m.rpcRequestErrors.Inc(method)
+	}
+}
+
+// registerServer registers the metrics that are obtained from the subsystems
+// of the provided server each time the metrics are scraped.
+func (m *nodeMetrics) registerServer(s *server) {
+	r := m.registry
+
+	// Chain metrics.
+	r.NewGaugeFunc("dcrd_chain_height",
+		"Height of the current best chain tip.", func() float64 {
+			return float64(s.chain.BestSnapshot().Height)
+		})
+	r.NewGaugeFunc("dcrd_chain_synced",
+		"Whether or not the chain is believed to be current (1) or not (0).",
+		func() float64 {
+			if s.blockManager.IsCurrent() {
+				return 1
+			}
+			return 0
+		})
+
+	// Network metrics.
+	r.NewGaugeFunc("dcrd_peers_connected", "Number of connected peers.",
+		func() float64 {
+			// The peer handler no longer services queries once the server
+			// is shutting down.
+			if atomic.LoadInt32(&s.shutdown) != 0 {
+				return 0
+			}
+			return float64(s.ConnectedCount())
+		})
+	r.NewCounterFunc("dcrd_net_received_bytes_total",
+		"Total bytes received from all peers.", func() float64 {
+			received, _ := s.NetTotals()
+			return float64(received)
+		})
+	r.NewCounterFunc("dcrd_net_sent_bytes_total",
+		"Total bytes sent to all peers.", func() float64 {
+			_, sent := s.NetTotals()
+			return float64(sent)
+		})
+
+	// Mempool metrics.
+	r.NewGaugeFunc("dcrd_mempool_transactions",
+		"Number of transactions in the mempool.", func() float64 {
+			return float64(s.txMemPool.Count())
+		})
+	r.NewGaugeFunc("dcrd_mempool_bytes",
+		"Total serialized size of the transactions in the mempool.",
+		func() float64 {
+			var size int
+			for _, desc := range s.txMemPool.TxDescs() {
+				size += desc.Tx.MsgTx().SerializeSize()
+			}
+			return float64(size)
+		})
+	r.NewGaugeFunc("dcrd_mempool_orphans",
+		"Number of orphan transactions in the mempool.", func() float64 {
+			return float64(s.txMemPool.OrphanCount())
+		})
+
+	// Background block template generator metrics.  The generator only
+	// exists when mining addresses are configured.
+	if s.bg != nil {
+		r.NewCounterFunc("dcrd_mining_templates_generated_total",
+			"Number of block templates generated, including failures.",
+			func() float64 {
+				return float64(s.bg.RegenStats().Generated)
+			})
+		r.NewCounterFunc("dcrd_mining_template_failures_total",
+			"Number of block templates that failed to generate.",
+			func() float64 {
+				return float64(s.bg.RegenStats().Failed)
+			})
+		r.NewCounterFunc("dcrd_mining_template_generation_seconds_total",
+			"Total time spent generating block templates.", func() float64 {
+				return s.bg.RegenStats().TotalDuration.Seconds()
+			})
+		r.NewGaugeFunc("dcrd_mining_template_last_generation_seconds",
+			"Time spent generating the most recent block template.",
+			func() float64 {
+				return s.bg.RegenStats().LastDuration.Seconds()
+			})
+	}
+
+	// Fee estimator metrics.
+	r.NewGaugeFunc("dcrd_fees_estimator_height",
+		"Height of the most recent block processed by the fee estimator or "+
+			"-1 when it is not enabled.", func() float64 {
+			return float64(s.feeEstimator.State().BestHeight)
+		})
+	r.NewGaugeFunc("dcrd_fees_estimator_mempool_transactions",
+		"Number of mempool transactions tracked by the fee estimator.",
+		func() float64 {
+			return float64(s.feeEstimator.State().MemPoolTxns)
+		})
+	r.NewGaugeVecFunc("dcrd_fees_estimate_atoms_per_kb",
+		"Estimated fee rate in atoms/kB for a transaction to confirm "+
+			"within the target number of blocks.", "target",
+		func() map[string]float64 {
+			maxConfirms := s.feeEstimator.State().MaxConfirms
+			estimates := make(map[string]float64, len(feeEstimateTargets))
+			for _, target := range feeEstimateTargets {
+				if target > maxConfirms {
+					break
+				}
+				fee, err := s.feeEstimator.EstimateFee(target)
+				if err != nil {
+					continue
+				}
+				estimates[strconv.Itoa(int(target))] = float64(fee)
+			}
+			return estimates
+		})
+}
+
+// serve serves the metrics over HTTP at /metrics on the provided listener until
+// the provided context is cancelled.
+func (m *nodeMetrics) serve(ctx context.Context, listener net.Listener) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.registry)
+	httpServer := &http.Server{
+		Handler:     mux,
+		ReadTimeout: time.Second * 10,
+	}
+
+	go func() {
+		<-ctx.Done()
+		httpServer.Close()
+	}()
+
+	srvrLog.Infof("Metrics server listening on %s", listener.Addr())
+	err := httpServer.Serve(listener)
+	if err != nil && err != http.ErrServerClosed {
+		srvrLog.Errorf("Metrics server failed: %v", err)
+	}
+}
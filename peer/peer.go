@@ -27,7 +27,7 @@ import (
 
 const (
 	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.InitStateVersion
+	MaxProtocolVersion = wire.BatchedCFiltersV2Version
 
 	// outputBufferSize is the number of elements the output channels use.
 	outputBufferSize = 5000
@@ -199,6 +199,13 @@ type MessageListeners struct {
 	// OnInitState is invoked when a peer receives an initstate message.
 	OnInitState func(p *Peer, msg *wire.MsgInitState)
 
+	// OnGetCFiltersV2 is invoked when a peer receives a getcfsv2 wire
+	// message.
+	OnGetCFiltersV2 func(p *Peer, msg *wire.MsgGetCFsV2)
+
+	// OnCFiltersV2 is invoked when a peer receives a cfiltersv2 wire message.
+	OnCFiltersV2 func(p *Peer, msg *wire.MsgCFiltersV2)
+
 	// OnRead is invoked when a peer receives a wire message.  It consists
 	// of the number of bytes read, the message, and whether or not an error
 	// in the read occurred.  Typically, callers will opt to use the
@@ -1487,6 +1494,16 @@ out:
 				p.cfg.Listeners.OnInitState(p, msg)
 			}
 
+		case *wire.MsgGetCFsV2:
+			if p.cfg.Listeners.OnGetCFiltersV2 != nil {
+				p.cfg.Listeners.OnGetCFiltersV2(p, msg)
+			}
+
+		case *wire.MsgCFiltersV2:
+			if p.cfg.Listeners.OnCFiltersV2 != nil {
+				p.cfg.Listeners.OnCFiltersV2(p, msg)
+			}
+
 		default:
 			log.Debugf("Received unhandled message of type %v "+
 				"from %v", rmsg.Command(), p)
@@ -9,6 +9,9 @@
 package types
 
 import (
+	"encoding/json"
+	"strconv"
+
 	"github.com/decred/dcrd/dcrjson/v3"
 )
 
@@ -60,6 +63,19 @@ func NewAddNodeCmd(addr string, subCmd AddNodeSubCmd) *AddNodeCmd {
 	}
 }
 
+// BackupDbCmd defines the backupdb JSON-RPC command.
+type BackupDbCmd struct {
+	Path string
+}
+
+// NewBackupDbCmd returns a new instance which can be used to issue a backupdb
+// JSON-RPC command.
+func NewBackupDbCmd(path string) *BackupDbCmd {
+	return &BackupDbCmd{
+		Path: path,
+	}
+}
+
 // SStxInput represents the inputs to an SStx transaction. Specifically a
 // transactionsha and output number pair, along with the output amounts.
 type SStxInput struct {
@@ -185,6 +201,19 @@ func NewDecodeScriptCmd(hexScript string) *DecodeScriptCmd {
 	}
 }
 
+// DumpUtxoSetCmd defines the dumputxoset JSON-RPC command.
+type DumpUtxoSetCmd struct {
+	Path string
+}
+
+// NewDumpUtxoSetCmd returns a new instance which can be used to issue a
+// dumputxoset JSON-RPC command.
+func NewDumpUtxoSetCmd(path string) *DumpUtxoSetCmd {
+	return &DumpUtxoSetCmd{
+		Path: path,
+	}
+}
+
 // EstimateFeeCmd defines the estimatefee JSON-RPC command.
 type EstimateFeeCmd struct {
 	NumBlocks int64
@@ -361,6 +390,37 @@ func NewGetAddedNodeInfoCmd(dns bool, node *string) *GetAddedNodeInfoCmd {
 	}
 }
 
+// GetAddressBalanceCmd defines the getaddressbalance JSON-RPC command.
+type GetAddressBalanceCmd struct {
+	Address string
+}
+
+// NewGetAddressBalanceCmd returns a new instance which can be used to issue a
+// getaddressbalance JSON-RPC command.
+func NewGetAddressBalanceCmd(address string) *GetAddressBalanceCmd {
+	return &GetAddressBalanceCmd{
+		Address: address,
+	}
+}
+
+// GetAddressUtxosCmd defines the getaddressutxos JSON-RPC command.
+type GetAddressUtxosCmd struct {
+	Address        string
+	IncludeMempool *bool `jsonrpcdefault:"true"`
+}
+
+// NewGetAddressUtxosCmd returns a new instance which can be used to issue a
+// getaddressutxos JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressUtxosCmd(address string, includeMempool *bool) *GetAddressUtxosCmd {
+	return &GetAddressUtxosCmd{
+		Address:        address,
+		IncludeMempool: includeMempool,
+	}
+}
+
 // GetBestBlockCmd defines the getbestblock JSON-RPC command.
 type GetBestBlockCmd struct{}
 
@@ -445,6 +505,59 @@ func NewGetBlockHeaderCmd(hash string, verbose *bool) *GetBlockHeaderCmd {
 	}
 }
 
+// GetBlockSpendsCmd defines the getblockspends JSON-RPC command.
+type GetBlockSpendsCmd struct {
+	Hash string
+}
+
+// NewGetBlockSpendsCmd returns a new instance which can be used to issue a
+// getblockspends JSON-RPC command.
+func NewGetBlockSpendsCmd(hash string) *GetBlockSpendsCmd {
+	return &GetBlockSpendsCmd{
+		Hash: hash,
+	}
+}
+
+// HashOrHeight defines the type used in JSON-RPC commands that accept either
+// the hash or the height of a block.  Heights are represented as decimal
+// strings.
+type HashOrHeight string
+
+// UnmarshalJSON unmarshals either a JSON string or a JSON number into the hash
+// or height.  JSON numbers are converted to decimal strings.
+func (h *HashOrHeight) UnmarshalJSON(data []byte) error {
+	var height int64
+	if err := json.Unmarshal(data, &height); err == nil {
+		*h = HashOrHeight(strconv.FormatInt(height, 10))
+		return nil
+	}
+
+	var hashOrHeight string
+	if err := json.Unmarshal(data, &hashOrHeight); err != nil {
+		return err
+	}
+	*h = HashOrHeight(hashOrHeight)
+	return nil
+}
+
+// GetBlockStatsCmd defines the getblockstats JSON-RPC command.
+type GetBlockStatsCmd struct {
+	HashOrHeight HashOrHeight
+	Stats        *[]string
+}
+
+// NewGetBlockStatsCmd returns a new instance which can be used to issue a
+// getblockstats JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetBlockStatsCmd(hashOrHeight HashOrHeight, stats *[]string) *GetBlockStatsCmd {
+	return &GetBlockStatsCmd{
+		HashOrHeight: hashOrHeight,
+		Stats:        stats,
+	}
+}
+
 // GetBlockSubsidyCmd defines the getblocksubsidy JSON-RPC command.
 type GetBlockSubsidyCmd struct {
 	Height int64
@@ -490,6 +603,21 @@ func NewGetCFilterHeaderCmd(hash string, filterType string) *GetCFilterHeaderCmd
 	}
 }
 
+// GetCFiltersV2Cmd defines the getcfiltersv2 JSON-RPC command.
+type GetCFiltersV2Cmd struct {
+	StartHash string
+	EndHash   string
+}
+
+// NewGetCFiltersV2Cmd returns a new instance which can be used to issue a
+// getcfiltersv2 JSON-RPC command.
+func NewGetCFiltersV2Cmd(startHash, endHash string) *GetCFiltersV2Cmd {
+	return &GetCFiltersV2Cmd{
+		StartHash: startHash,
+		EndHash:   endHash,
+	}
+}
+
 // GetCFilterV2Cmd defines the getcfilterv2 JSON-RPC command.
 type GetCFilterV2Cmd struct {
 	BlockHash string
@@ -566,6 +694,22 @@ func NewGetHashesPerSecCmd() *GetHashesPerSecCmd {
 	return &GetHashesPerSecCmd{}
 }
 
+// GetIndexInfoCmd defines the getindexinfo JSON-RPC command.
+type GetIndexInfoCmd struct {
+	IndexName *string
+}
+
+// NewGetIndexInfoCmd returns a new instance which can be used to issue a
+// getindexinfo JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetIndexInfoCmd(indexName *string) *GetIndexInfoCmd {
+	return &GetIndexInfoCmd{
+		IndexName: indexName,
+	}
+}
+
 // GetInfoCmd defines the getinfo JSON-RPC command.
 type GetInfoCmd struct{}
 
@@ -719,6 +863,59 @@ func NewGetRawTransactionCmd(txHash string, verbose *int) *GetRawTransactionCmd
 	}
 }
 
+// GetScriptHashBalanceCmd defines the getscripthashbalance JSON-RPC command.
+type GetScriptHashBalanceCmd struct {
+	ScriptHash string
+}
+
+// NewGetScriptHashBalanceCmd returns a new instance which can be used to issue
+// a getscripthashbalance JSON-RPC command.
+func NewGetScriptHashBalanceCmd(scriptHash string) *GetScriptHashBalanceCmd {
+	return &GetScriptHashBalanceCmd{
+		ScriptHash: scriptHash,
+	}
+}
+
+// GetScriptHashHistoryCmd defines the getscripthashhistory JSON-RPC command.
+type GetScriptHashHistoryCmd struct {
+	ScriptHash string
+	Skip       *int `jsonrpcdefault:"0"`
+	Count      *int `jsonrpcdefault:"100"`
+}
+
+// NewGetScriptHashHistoryCmd returns a new instance which can be used to issue
+// a getscripthashhistory JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetScriptHashHistoryCmd(scriptHash string, skip, count *int) *GetScriptHashHistoryCmd {
+	return &GetScriptHashHistoryCmd{
+		ScriptHash: scriptHash,
+		Skip:       skip,
+		Count:      count,
+	}
+}
+
+// GetSpendingInfoCmd defines the getspendinginfo JSON-RPC command.
+type GetSpendingInfoCmd struct {
+	Txid           string
+	Vout           uint32
+	IncludeMempool *bool `jsonrpcdefault:"true"`
+}
+
+// NewGetSpendingInfoCmd returns a new instance which can be used to issue a
+// getspendinginfo JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetSpendingInfoCmd(txHash string, vout uint32, includeMempool *bool) *GetSpendingInfoCmd {
+	return &GetSpendingInfoCmd{
+		Txid:           txHash,
+		Vout:           vout,
+		IncludeMempool: includeMempool,
+	}
+}
+
 // GetStakeDifficultyCmd is a type handling custom marshaling and
 // unmarshaling of getstakedifficulty JSON RPC commands.
 type GetStakeDifficultyCmd struct{}
@@ -759,6 +956,19 @@ func NewGetStakeVersionsCmd(hash string, count int32) *GetStakeVersionsCmd {
 	}
 }
 
+// GetTicketInfoCmd defines the getticketinfo JSON-RPC command.
+type GetTicketInfoCmd struct {
+	Ticket string
+}
+
+// NewGetTicketInfoCmd returns a new instance which can be used to issue a
+// getticketinfo JSON-RPC command.
+func NewGetTicketInfoCmd(ticket string) *GetTicketInfoCmd {
+	return &GetTicketInfoCmd{
+		Ticket: ticket,
+	}
+}
+
 // GetTicketPoolValueCmd defines the getticketpoolvalue JSON-RPC command.
 type GetTicketPoolValueCmd struct{}
 
@@ -789,10 +999,16 @@ func NewGetTxOutCmd(txHash string, vout uint32, includeMempool *bool) *GetTxOutC
 }
 
 // GetTxOutSetInfoCmd defines the gettxoutsetinfo JSON-RPC command.
-type GetTxOutSetInfoCmd struct{}
+//
+// The optional Height field requests the statistics as of the main chain block
+// at the given height instead of the current best block.
+type GetTxOutSetInfoCmd struct {
+	Height *int64
+}
 
 // NewGetTxOutSetInfoCmd returns a new instance which can be used to issue a
-// gettxoutsetinfo JSON-RPC command.
+// gettxoutsetinfo JSON-RPC command.  The optional Height field may be set on
+// the returned instance to request the statistics as of a specific height.
 func NewGetTxOutSetInfoCmd() *GetTxOutSetInfoCmd {
 	return &GetTxOutSetInfoCmd{}
 }
@@ -846,6 +1062,35 @@ func NewGetTreasurySpendVotesCmd(block *string, tspends *[]string) *GetTreasuryS
 	}
 }
 
+// GetTreasuryHistoryCmd defines the gettreasuryhistory JSON-RPC command.
+//
+// If the start height is not specified, the history starts at the genesis
+// block.  If the end height is not specified, the history ends at the current
+// best block.  If no types are specified, all types of treasury transactions
+// are returned.
+type GetTreasuryHistoryCmd struct {
+	StartHeight *int64
+	EndHeight   *int64
+	Types       *[]string
+	Skip        *int `jsonrpcdefault:"0"`
+	Count       *int `jsonrpcdefault:"100"`
+}
+
+// NewGetTreasuryHistoryCmd returns a new instance which can be used to issue a
+// gettreasuryhistory JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetTreasuryHistoryCmd(startHeight, endHeight *int64, types *[]string, skip, count *int) *GetTreasuryHistoryCmd {
+	return &GetTreasuryHistoryCmd{
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+		Types:       types,
+		Skip:        skip,
+		Count:       count,
+	}
+}
+
 // GetWorkCmd defines the getwork JSON-RPC command.
 type GetWorkCmd struct {
 	Data *string
@@ -871,6 +1116,21 @@ func NewRegenTemplateCmd() *RegenTemplateCmd {
 	return &RegenTemplateCmd{}
 }
 
+// ReindexCmd defines the reindex JSON-RPC command.
+type ReindexCmd struct {
+	IndexName string
+	Height    int64
+}
+
+// NewReindexCmd returns a new instance which can be used to issue a reindex
+// JSON-RPC command.
+func NewReindexCmd(indexName string, height int64) *ReindexCmd {
+	return &ReindexCmd{
+		IndexName: indexName,
+		Height:    height,
+	}
+}
+
 // HelpCmd defines the help JSON-RPC command.
 type HelpCmd struct {
 	Command *string
@@ -936,6 +1196,30 @@ func NewPingCmd() *PingCmd {
 	return &PingCmd{}
 }
 
+// SearchNullDataCmd defines the searchnulldata JSON-RPC command.
+type SearchNullDataCmd struct {
+	Prefix      string
+	StartHeight *int64
+	EndHeight   *int64
+	Skip        *int `jsonrpcdefault:"0"`
+	Count       *int `jsonrpcdefault:"100"`
+}
+
+// NewSearchNullDataCmd returns a new instance which can be used to issue a
+// searchnulldata JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewSearchNullDataCmd(prefix string, startHeight, endHeight *int64, skip, count *int) *SearchNullDataCmd {
+	return &SearchNullDataCmd{
+		Prefix:      prefix,
+		StartHeight: startHeight,
+		EndHeight:   endHeight,
+		Skip:        skip,
+		Count:       count,
+	}
+}
+
 // SearchRawTransactionsCmd defines the searchrawtransactions JSON-RPC command.
 type SearchRawTransactionsCmd struct {
 	Address     string
@@ -1051,13 +1335,20 @@ func NewTicketFeeInfoCmd(blocks *uint32, windows *uint32) *TicketFeeInfoCmd {
 
 // TicketsForAddressCmd defines the ticketsforaddress JSON-RPC command.
 type TicketsForAddressCmd struct {
-	Address string
+	Address           string
+	IncludeHistorical *bool `jsonrpcdefault:"false"`
 }
 
 // NewTicketsForAddressCmd returns a new instance which can be used to issue a
 // JSON-RPC tickets for address command.
-func NewTicketsForAddressCmd(addr string) *TicketsForAddressCmd {
-	return &TicketsForAddressCmd{addr}
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewTicketsForAddressCmd(addr string, includeHistorical *bool) *TicketsForAddressCmd {
+	return &TicketsForAddressCmd{
+		Address:           addr,
+		IncludeHistorical: includeHistorical,
+	}
 }
 
 // TicketVWAPCmd defines the ticketvwap JSON-RPC command.
@@ -1152,12 +1443,14 @@ func init() {
 	flags := dcrjson.UsageFlag(0)
 
 	dcrjson.MustRegister(Method("addnode"), (*AddNodeCmd)(nil), flags)
+	dcrjson.MustRegister(Method("backupdb"), (*BackupDbCmd)(nil), flags)
 	dcrjson.MustRegister(Method("createrawssrtx"), (*CreateRawSSRtxCmd)(nil), flags)
 	dcrjson.MustRegister(Method("createrawsstx"), (*CreateRawSStxCmd)(nil), flags)
 	dcrjson.MustRegister(Method("createrawtransaction"), (*CreateRawTransactionCmd)(nil), flags)
 	dcrjson.MustRegister(Method("debuglevel"), (*DebugLevelCmd)(nil), flags)
 	dcrjson.MustRegister(Method("decoderawtransaction"), (*DecodeRawTransactionCmd)(nil), flags)
 	dcrjson.MustRegister(Method("decodescript"), (*DecodeScriptCmd)(nil), flags)
+	dcrjson.MustRegister(Method("dumputxoset"), (*DumpUtxoSetCmd)(nil), flags)
 	dcrjson.MustRegister(Method("estimatefee"), (*EstimateFeeCmd)(nil), flags)
 	dcrjson.MustRegister(Method("estimatesmartfee"), (*EstimateSmartFeeCmd)(nil), flags)
 	dcrjson.MustRegister(Method("estimatestakediff"), (*EstimateStakeDiffCmd)(nil), flags)
@@ -1170,6 +1463,8 @@ func init() {
 	dcrjson.MustRegister(Method("existsmempooltxs"), (*ExistsMempoolTxsCmd)(nil), flags)
 	dcrjson.MustRegister(Method("generate"), (*GenerateCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getaddednodeinfo"), (*GetAddedNodeInfoCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getaddressbalance"), (*GetAddressBalanceCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getaddressutxos"), (*GetAddressUtxosCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getbestblock"), (*GetBestBlockCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getbestblockhash"), (*GetBestBlockHashCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getblock"), (*GetBlockCmd)(nil), flags)
@@ -1177,9 +1472,12 @@ func init() {
 	dcrjson.MustRegister(Method("getblockcount"), (*GetBlockCountCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getblockhash"), (*GetBlockHashCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getblockheader"), (*GetBlockHeaderCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getblockspends"), (*GetBlockSpendsCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getblockstats"), (*GetBlockStatsCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getblocksubsidy"), (*GetBlockSubsidyCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getcfilter"), (*GetCFilterCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getcfilterheader"), (*GetCFilterHeaderCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getcfiltersv2"), (*GetCFiltersV2Cmd)(nil), flags)
 	dcrjson.MustRegister(Method("getcfilterv2"), (*GetCFilterV2Cmd)(nil), flags)
 	dcrjson.MustRegister(Method("getchaintips"), (*GetChainTipsCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getcoinsupply"), (*GetCoinSupplyCmd)(nil), flags)
@@ -1189,6 +1487,7 @@ func init() {
 	dcrjson.MustRegister(Method("getgenerate"), (*GetGenerateCmd)(nil), flags)
 	dcrjson.MustRegister(Method("gethashespersec"), (*GetHashesPerSecCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getheaders"), (*GetHeadersCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getindexinfo"), (*GetIndexInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getinfo"), (*GetInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getmempoolinfo"), (*GetMempoolInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getmininginfo"), (*GetMiningInfoCmd)(nil), flags)
@@ -1198,11 +1497,16 @@ func init() {
 	dcrjson.MustRegister(Method("getpeerinfo"), (*GetPeerInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getrawmempool"), (*GetRawMempoolCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getrawtransaction"), (*GetRawTransactionCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getscripthashbalance"), (*GetScriptHashBalanceCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getscripthashhistory"), (*GetScriptHashHistoryCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getspendinginfo"), (*GetSpendingInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getstakedifficulty"), (*GetStakeDifficultyCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getstakeversioninfo"), (*GetStakeVersionInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getstakeversions"), (*GetStakeVersionsCmd)(nil), flags)
+	dcrjson.MustRegister(Method("getticketinfo"), (*GetTicketInfoCmd)(nil), flags)
 	dcrjson.MustRegister(Method("getticketpoolvalue"), (*GetTicketPoolValueCmd)(nil), flags)
 	dcrjson.MustRegister(Method("gettreasurybalance"), (*GetTreasuryBalanceCmd)(nil), flags)
+	dcrjson.MustRegister(Method("gettreasuryhistory"), (*GetTreasuryHistoryCmd)(nil), flags)
 	dcrjson.MustRegister(Method("gettreasuryspendvotes"), (*GetTreasurySpendVotesCmd)(nil), flags)
 	dcrjson.MustRegister(Method("gettxout"), (*GetTxOutCmd)(nil), flags)
 	dcrjson.MustRegister(Method("gettxoutsetinfo"), (*GetTxOutSetInfoCmd)(nil), flags)
@@ -1214,6 +1518,8 @@ func init() {
 	dcrjson.MustRegister(Method("node"), (*NodeCmd)(nil), flags)
 	dcrjson.MustRegister(Method("ping"), (*PingCmd)(nil), flags)
 	dcrjson.MustRegister(Method("regentemplate"), (*RegenTemplateCmd)(nil), flags)
+	dcrjson.MustRegister(Method("reindex"), (*ReindexCmd)(nil), flags)
+	dcrjson.MustRegister(Method("searchnulldata"), (*SearchNullDataCmd)(nil), flags)
 	dcrjson.MustRegister(Method("searchrawtransactions"), (*SearchRawTransactionsCmd)(nil), flags)
 	dcrjson.MustRegister(Method("sendrawtransaction"), (*SendRawTransactionCmd)(nil), flags)
 	dcrjson.MustRegister(Method("setgenerate"), (*SetGenerateCmd)(nil), flags)
@@ -41,6 +41,17 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"addnode","params":["127.0.0.1","remove"],"id":1}`,
 			unmarshalled: &AddNodeCmd{Addr: "127.0.0.1", SubCmd: ANRemove},
 		},
+		{
+			name: "backupdb",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("backupdb"), "backup")
+			},
+			staticCmd: func() interface{} {
+				return NewBackupDbCmd("backup")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"backupdb","params":["backup"],"id":1}`,
+			unmarshalled: &BackupDbCmd{Path: "backup"},
+		},
 		{
 			name: "createrawtransaction",
 			newCmd: func() (interface{}, error) {
@@ -129,6 +140,17 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"decodescript","params":["00",1],"id":1}`,
 			unmarshalled: &DecodeScriptCmd{HexScript: "00", Version: dcrjson.Uint16(1)},
 		},
+		{
+			name: "dumputxoset",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("dumputxoset"), "utxoset.snapshot")
+			},
+			staticCmd: func() interface{} {
+				return NewDumpUtxoSetCmd("utxoset.snapshot")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"dumputxoset","params":["utxoset.snapshot"],"id":1}`,
+			unmarshalled: &DumpUtxoSetCmd{Path: "utxoset.snapshot"},
+		},
 		{
 			name: "estimatefee",
 			newCmd: func() (interface{}, error) {
@@ -209,6 +231,47 @@ func TestChainSvrCmds(t *testing.T) {
 				Node: dcrjson.String("127.0.0.1"),
 			},
 		},
+		{
+			name: "getaddressbalance",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getaddressbalance"), "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu")
+			},
+			staticCmd: func() interface{} {
+				return NewGetAddressBalanceCmd("DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressbalance","params":["DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu"],"id":1}`,
+			unmarshalled: &GetAddressBalanceCmd{
+				Address: "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu",
+			},
+		},
+		{
+			name: "getaddressutxos",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getaddressutxos"), "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu")
+			},
+			staticCmd: func() interface{} {
+				return NewGetAddressUtxosCmd("DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressutxos","params":["DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu"],"id":1}`,
+			unmarshalled: &GetAddressUtxosCmd{
+				Address:        "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu",
+				IncludeMempool: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "getaddressutxos optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getaddressutxos"), "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu", false)
+			},
+			staticCmd: func() interface{} {
+				return NewGetAddressUtxosCmd("DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu", dcrjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressutxos","params":["DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu",false],"id":1}`,
+			unmarshalled: &GetAddressUtxosCmd{
+				Address:        "DsUZxxoHJSty8DCfwfartwTYbuhmVct7tJu",
+				IncludeMempool: dcrjson.Bool(false),
+			},
+		},
 		{
 			name: "getbestblock",
 			newCmd: func() (interface{}, error) {
@@ -327,6 +390,43 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: dcrjson.Bool(true),
 			},
 		},
+		{
+			name: "getblockspends",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getblockspends"), "123")
+			},
+			staticCmd: func() interface{} {
+				return NewGetBlockSpendsCmd("123")
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getblockspends","params":["123"],"id":1}`,
+			unmarshalled: &GetBlockSpendsCmd{Hash: "123"},
+		},
+		{
+			name: "getblockstats",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getblockstats"), "123")
+			},
+			staticCmd: func() interface{} {
+				return NewGetBlockStatsCmd("123", nil)
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getblockstats","params":["123"],"id":1}`,
+			unmarshalled: &GetBlockStatsCmd{HashOrHeight: "123"},
+		},
+		{
+			name: "getblockstats optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getblockstats"), "123",
+					`["totalfee","votes"]`)
+			},
+			staticCmd: func() interface{} {
+				return NewGetBlockStatsCmd("123", &[]string{"totalfee", "votes"})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getblockstats","params":["123",["totalfee","votes"]],"id":1}`,
+			unmarshalled: &GetBlockStatsCmd{
+				HashOrHeight: "123",
+				Stats:        &[]string{"totalfee", "votes"},
+			},
+		},
 		{
 			name: "getblocksubsidy",
 			newCmd: func() (interface{}, error) {
@@ -369,6 +469,20 @@ func TestChainSvrCmds(t *testing.T) {
 				FilterType: "extended",
 			},
 		},
+		{
+			name: "getcfiltersv2",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getcfiltersv2"), "123", "456")
+			},
+			staticCmd: func() interface{} {
+				return NewGetCFiltersV2Cmd("123", "456")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getcfiltersv2","params":["123","456"],"id":1}`,
+			unmarshalled: &GetCFiltersV2Cmd{
+				StartHash: "123",
+				EndHash:   "456",
+			},
+		},
 		{
 			name: "getcfilterv2",
 			newCmd: func() (interface{}, error) {
@@ -459,6 +573,32 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"getinfo","params":[],"id":1}`,
 			unmarshalled: &GetInfoCmd{},
 		},
+		{
+			name: "getindexinfo",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getindexinfo"))
+			},
+			staticCmd: func() interface{} {
+				return NewGetIndexInfoCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getindexinfo","params":[],"id":1}`,
+			unmarshalled: &GetIndexInfoCmd{
+				IndexName: nil,
+			},
+		},
+		{
+			name: "getindexinfo optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getindexinfo"), "spend index")
+			},
+			staticCmd: func() interface{} {
+				return NewGetIndexInfoCmd(dcrjson.String("spend index"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getindexinfo","params":["spend index"],"id":1}`,
+			unmarshalled: &GetIndexInfoCmd{
+				IndexName: dcrjson.String("spend index"),
+			},
+		},
 		{
 			name: "getmempoolinfo",
 			newCmd: func() (interface{}, error) {
@@ -624,6 +764,79 @@ func TestChainSvrCmds(t *testing.T) {
 				Verbose: dcrjson.Int(1),
 			},
 		},
+		{
+			name: "getscripthashbalance",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getscripthashbalance"), "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161")
+			},
+			staticCmd: func() interface{} {
+				return NewGetScriptHashBalanceCmd("8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getscripthashbalance","params":["8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"],"id":1}`,
+			unmarshalled: &GetScriptHashBalanceCmd{
+				ScriptHash: "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161",
+			},
+		},
+		{
+			name: "getscripthashhistory",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getscripthashhistory"), "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161")
+			},
+			staticCmd: func() interface{} {
+				return NewGetScriptHashHistoryCmd("8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161", nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getscripthashhistory","params":["8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161"],"id":1}`,
+			unmarshalled: &GetScriptHashHistoryCmd{
+				ScriptHash: "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161",
+				Skip:       dcrjson.Int(0),
+				Count:      dcrjson.Int(100),
+			},
+		},
+		{
+			name: "getscripthashhistory optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getscripthashhistory"), "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161", 5, 10)
+			},
+			staticCmd: func() interface{} {
+				return NewGetScriptHashHistoryCmd("8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161", dcrjson.Int(5), dcrjson.Int(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getscripthashhistory","params":["8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161",5,10],"id":1}`,
+			unmarshalled: &GetScriptHashHistoryCmd{
+				ScriptHash: "8b01df4e368ea28f8dc0423bcf7a4923e3a12d307c875e47a0cfbf90b5c39161",
+				Skip:       dcrjson.Int(5),
+				Count:      dcrjson.Int(10),
+			},
+		},
+		{
+			name: "getspendinginfo",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getspendinginfo"), "123", 1)
+			},
+			staticCmd: func() interface{} {
+				return NewGetSpendingInfoCmd("123", 1, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getspendinginfo","params":["123",1],"id":1}`,
+			unmarshalled: &GetSpendingInfoCmd{
+				Txid:           "123",
+				Vout:           1,
+				IncludeMempool: dcrjson.Bool(true),
+			},
+		},
+		{
+			name: "getspendinginfo optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getspendinginfo"), "123", 1, false)
+			},
+			staticCmd: func() interface{} {
+				return NewGetSpendingInfoCmd("123", 1, dcrjson.Bool(false))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getspendinginfo","params":["123",1,false],"id":1}`,
+			unmarshalled: &GetSpendingInfoCmd{
+				Txid:           "123",
+				Vout:           1,
+				IncludeMempool: dcrjson.Bool(false),
+			},
+		},
 		{
 			name: "getstakeversions",
 			newCmd: func() (interface{}, error) {
@@ -638,6 +851,19 @@ func TestChainSvrCmds(t *testing.T) {
 				Count: 1,
 			},
 		},
+		{
+			name: "getticketinfo",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("getticketinfo"), "123")
+			},
+			staticCmd: func() interface{} {
+				return NewGetTicketInfoCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getticketinfo","params":["123"],"id":1}`,
+			unmarshalled: &GetTicketInfoCmd{
+				Ticket: "123",
+			},
+		},
 		{
 			name: "gettxout",
 			newCmd: func() (interface{}, error) {
@@ -679,6 +905,21 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"gettxoutsetinfo","params":[],"id":1}`,
 			unmarshalled: &GetTxOutSetInfoCmd{},
 		},
+		{
+			name: "gettxoutsetinfo optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("gettxoutsetinfo"), 100)
+			},
+			staticCmd: func() interface{} {
+				cmd := NewGetTxOutSetInfoCmd()
+				cmd.Height = dcrjson.Int64(100)
+				return cmd
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettxoutsetinfo","params":[100],"id":1}`,
+			unmarshalled: &GetTxOutSetInfoCmd{
+				Height: dcrjson.Int64(100),
+			},
+		},
 		{
 			name: "getvoteinfo",
 			newCmd: func() (interface{}, error) {
@@ -692,6 +933,43 @@ func TestChainSvrCmds(t *testing.T) {
 				Version: 1,
 			},
 		},
+		{
+			name: "gettreasuryhistory",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("gettreasuryhistory"))
+			},
+			staticCmd: func() interface{} {
+				return NewGetTreasuryHistoryCmd(nil, nil, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettreasuryhistory","params":[],"id":1}`,
+			unmarshalled: &GetTreasuryHistoryCmd{
+				StartHeight: nil,
+				EndHeight:   nil,
+				Types:       nil,
+				Skip:        dcrjson.Int(0),
+				Count:       dcrjson.Int(100),
+			},
+		},
+		{
+			name: "gettreasuryhistory optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("gettreasuryhistory"), 100, 200,
+					[]string{"tadd", "tspend"}, 5, 10)
+			},
+			staticCmd: func() interface{} {
+				return NewGetTreasuryHistoryCmd(dcrjson.Int64(100),
+					dcrjson.Int64(200), &[]string{"tadd", "tspend"},
+					dcrjson.Int(5), dcrjson.Int(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"gettreasuryhistory","params":[100,200,["tadd","tspend"],5,10],"id":1}`,
+			unmarshalled: &GetTreasuryHistoryCmd{
+				StartHeight: dcrjson.Int64(100),
+				EndHeight:   dcrjson.Int64(200),
+				Types:       &[]string{"tadd", "tspend"},
+				Skip:        dcrjson.Int(5),
+				Count:       dcrjson.Int(10),
+			},
+		},
 		{
 			name: "gettreasuryspendvotes",
 			newCmd: func() (interface{}, error) {
@@ -840,6 +1118,56 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"ping","params":[],"id":1}`,
 			unmarshalled: &PingCmd{},
 		},
+		{
+			name: "reindex",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("reindex"), "spend index", 100)
+			},
+			staticCmd: func() interface{} {
+				return NewReindexCmd("spend index", 100)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"reindex","params":["spend index",100],"id":1}`,
+			unmarshalled: &ReindexCmd{
+				IndexName: "spend index",
+				Height:    100,
+			},
+		},
+		{
+			name: "searchnulldata",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("searchnulldata"), "0102")
+			},
+			staticCmd: func() interface{} {
+				return NewSearchNullDataCmd("0102", nil, nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"searchnulldata","params":["0102"],"id":1}`,
+			unmarshalled: &SearchNullDataCmd{
+				Prefix:      "0102",
+				StartHeight: nil,
+				EndHeight:   nil,
+				Skip:        dcrjson.Int(0),
+				Count:       dcrjson.Int(100),
+			},
+		},
+		{
+			name: "searchnulldata optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("searchnulldata"), "0102", 100,
+					200, 5, 10)
+			},
+			staticCmd: func() interface{} {
+				return NewSearchNullDataCmd("0102", dcrjson.Int64(100),
+					dcrjson.Int64(200), dcrjson.Int(5), dcrjson.Int(10))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"searchnulldata","params":["0102",100,200,5,10],"id":1}`,
+			unmarshalled: &SearchNullDataCmd{
+				Prefix:      "0102",
+				StartHeight: dcrjson.Int64(100),
+				EndHeight:   dcrjson.Int64(200),
+				Skip:        dcrjson.Int(5),
+				Count:       dcrjson.Int(10),
+			},
+		},
 		{
 			name: "searchrawtransactions",
 			newCmd: func() (interface{}, error) {
@@ -1081,6 +1409,34 @@ func TestChainSvrCmds(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "ticketsforaddress",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("ticketsforaddress"), "DsTest")
+			},
+			staticCmd: func() interface{} {
+				return NewTicketsForAddressCmd("DsTest", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"ticketsforaddress","params":["DsTest"],"id":1}`,
+			unmarshalled: &TicketsForAddressCmd{
+				Address:           "DsTest",
+				IncludeHistorical: dcrjson.Bool(false),
+			},
+		},
+		{
+			name: "ticketsforaddress optional",
+			newCmd: func() (interface{}, error) {
+				return dcrjson.NewCmd(Method("ticketsforaddress"), "DsTest", true)
+			},
+			staticCmd: func() interface{} {
+				return NewTicketsForAddressCmd("DsTest", dcrjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"ticketsforaddress","params":["DsTest",true],"id":1}`,
+			unmarshalled: &TicketsForAddressCmd{
+				Address:           "DsTest",
+				IncludeHistorical: dcrjson.Bool(true),
+			},
+		},
 		{
 			name: "validateaddress",
 			newCmd: func() (interface{}, error) {
@@ -1220,3 +1576,52 @@ func TestChainSvrCmds(t *testing.T) {
 		}
 	}
 }
+
+// TestHashOrHeightParams ensures block heights provided as JSON numbers to
+// commands that accept either a block hash or height are parsed as expected.
+func TestHashOrHeightParams(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		params string
+		want   HashOrHeight
+	}{{
+		name:   "height as number",
+		params: `[123]`,
+		want:   "123",
+	}, {
+		name:   "height as string",
+		params: `["123"]`,
+		want:   "123",
+	}, {
+		name:   "hash",
+		params: `["000000000000437482b6d47f82f374cde539440ddb108b0a76886f0d87d126b9"]`,
+		want:   "000000000000437482b6d47f82f374cde539440ddb108b0a76886f0d87d126b9",
+	}}
+
+	for _, test := range tests {
+		var params []json.RawMessage
+		if err := json.Unmarshal([]byte(test.params), &params); err != nil {
+			t.Fatalf("%q: unexpected error unmarshalling params: %v",
+				test.name, err)
+		}
+		cmd, err := dcrjson.ParseParams(Method("getblockstats"), params)
+		if err != nil {
+			t.Errorf("%q: unexpected error parsing params: %v", test.name,
+				err)
+			continue
+		}
+		got := cmd.(*GetBlockStatsCmd).HashOrHeight
+		if got != test.want {
+			t.Errorf("%q: unexpected hash or height -- got %q, want %q",
+				test.name, got, test.want)
+		}
+	}
+
+	// Ensure invalid types are rejected.
+	params := []json.RawMessage{json.RawMessage(`true`)}
+	if _, err := dcrjson.ParseParams(Method("getblockstats"), params); err == nil {
+		t.Error("did not receive expected error for invalid hash or height")
+	}
+}
@@ -17,6 +17,12 @@ type TxRawDecodeResult struct {
 	Vout     []Vout `json:"vout"`
 }
 
+// BackupDbResult models the data returned from the backupdb command.
+type BackupDbResult struct {
+	Path   string `json:"path"`
+	DbType string `json:"dbtype"`
+}
+
 // DecodeScriptResult models the data returned from the decodescript command.
 type DecodeScriptResult struct {
 	Asm       string   `json:"asm"`
@@ -26,6 +32,15 @@ type DecodeScriptResult struct {
 	P2sh      string   `json:"p2sh,omitempty"`
 }
 
+// DumpUtxoSetResult models the data returned from the dumputxoset command.
+type DumpUtxoSetResult struct {
+	BlockHash      string `json:"blockhash"`
+	Height         int64  `json:"height"`
+	Path           string `json:"path"`
+	NumUtxoEntries uint64 `json:"numutxoentries"`
+	SnapshotHash   string `json:"snapshothash"`
+}
+
 // EstimateSmartFeeResult models the data returned from the estimatesmartfee
 // command.
 //
@@ -62,6 +77,26 @@ type GetAddedNodeInfoResult struct {
 	Addresses *[]GetAddedNodeInfoResultAddr `json:"addresses,omitempty"`
 }
 
+// GetAddressBalanceResult models the data returned from the getaddressbalance
+// command.
+type GetAddressBalanceResult struct {
+	Confirmed   int64 `json:"confirmed"`
+	Unconfirmed int64 `json:"unconfirmed"`
+}
+
+// GetAddressUtxosResult models the data returned for a single unspent output
+// returned by the getaddressutxos command.
+type GetAddressUtxosResult struct {
+	TxID          string `json:"txid"`
+	Vout          uint32 `json:"vout"`
+	Tree          int8   `json:"tree"`
+	Amount        int64  `json:"amount"`
+	ScriptVersion uint16 `json:"scriptversion"`
+	ScriptPubKey  string `json:"scriptpubkey"`
+	Height        int64  `json:"height"`
+	Confirmations int64  `json:"confirmations"`
+}
+
 // GetBlockVerboseResult models the data from the getblock command when the
 // verbose flag is set.  When the verbose flag is not set, getblock returns a
 // hex-encoded string.  Contains Decred additions.
@@ -103,6 +138,17 @@ type AgendaInfo struct {
 	ExpireTime uint64 `json:"expiretime"`
 }
 
+// UtxoCacheInfo provides an overview of the state of the cache of unspent
+// transaction outputs.
+type UtxoCacheInfo struct {
+	Entries  uint64  `json:"entries"`
+	Size     uint64  `json:"size"`
+	MaxSize  uint64  `json:"maxsize"`
+	Hits     uint64  `json:"hits"`
+	Misses   uint64  `json:"misses"`
+	HitRatio float64 `json:"hitratio"`
+}
+
 // GetBestBlockResult models the data from the getbestblock command.
 type GetBestBlockResult struct {
 	Hash   string `json:"hash"`
@@ -124,6 +170,7 @@ type GetBlockChainInfoResult struct {
 	InitialBlockDownload bool                  `json:"initialblockdownload"`
 	MaxBlockSize         int64                 `json:"maxblocksize"`
 	Deployments          map[string]AgendaInfo `json:"deployments"`
+	UtxoCache            UtxoCacheInfo         `json:"utxocache"`
 }
 
 // GetBlockHeaderVerboseResult models the data from the getblockheader command when
@@ -155,6 +202,61 @@ type GetBlockHeaderVerboseResult struct {
 	NextHash      string  `json:"nextblockhash,omitempty"`
 }
 
+// BlockSpend models a previous output spent by a transaction input as returned
+// by the getblockspends command.
+type BlockSpend struct {
+	Vin          uint32             `json:"vin"`
+	Txid         string             `json:"txid"`
+	Vout         uint32             `json:"vout"`
+	Tree         int8               `json:"tree"`
+	AmountIn     float64            `json:"amountin"`
+	BlockHeight  int64              `json:"blockheight"`
+	BlockIndex   uint32             `json:"blockindex"`
+	Version      uint16             `json:"version"`
+	ScriptPubKey ScriptPubKeyResult `json:"scriptPubKey"`
+}
+
+// BlockSpendsTx models the previous outputs spent by a transaction as returned
+// by the getblockspends command.
+type BlockSpendsTx struct {
+	Txid   string       `json:"txid"`
+	Tree   int8         `json:"tree"`
+	Spends []BlockSpend `json:"spends"`
+}
+
+// GetBlockSpendsResult models the data returned from the getblockspends
+// command.
+type GetBlockSpendsResult struct {
+	Hash   string          `json:"hash"`
+	Height int64           `json:"height"`
+	Tx     []BlockSpendsTx `json:"tx"`
+}
+
+// GetBlockStatsResult models the data returned from the getblockstats command.
+// Only the requested statistics are populated.
+type GetBlockStatsResult struct {
+	Hash               *string  `json:"hash,omitempty"`
+	Height             *int64   `json:"height,omitempty"`
+	Time               *int64   `json:"time,omitempty"`
+	Size               *int64   `json:"size,omitempty"`
+	Txs                *int64   `json:"txs,omitempty"`
+	STxs               *int64   `json:"stxs,omitempty"`
+	Tickets            *int64   `json:"tickets,omitempty"`
+	Votes              *int64   `json:"votes,omitempty"`
+	Revocations        *int64   `json:"revocations,omitempty"`
+	TAdds              *int64   `json:"tadds,omitempty"`
+	TSpends            *int64   `json:"tspends,omitempty"`
+	TotalFee           *int64   `json:"totalfee,omitempty"`
+	MinFeeRate         *int64   `json:"minfeerate,omitempty"`
+	MaxFeeRate         *int64   `json:"maxfeerate,omitempty"`
+	AvgFeeRate         *int64   `json:"avgfeerate,omitempty"`
+	FeeRatePercentiles *[]int64 `json:"feeratepercentiles,omitempty"`
+	TAddAmount         *int64   `json:"taddamount,omitempty"`
+	TreasuryBaseAmount *int64   `json:"treasurybaseamount,omitempty"`
+	TSpendAmount       *int64   `json:"tspendamount,omitempty"`
+	UtxoIncrease       *int64   `json:"utxoincrease,omitempty"`
+}
+
 // GetBlockSubsidyResult models the data returned from the getblocksubsidy
 // command.
 type GetBlockSubsidyResult struct {
@@ -186,6 +288,15 @@ type GetHeadersResult struct {
 	Headers []string `json:"headers"`
 }
 
+// GetIndexInfoResult models the data returned by the chain server getindexinfo
+// command for each index.
+type GetIndexInfoResult struct {
+	Name            string `json:"name"`
+	Synced          bool   `json:"synced"`
+	BestBlockHash   string `json:"bestblockhash"`
+	BestBlockHeight int64  `json:"bestblockheight"`
+}
+
 // InfoChainResult models the data returned by the chain server getinfo command.
 type InfoChainResult struct {
 	Version         int32   `json:"version"`
@@ -318,6 +429,49 @@ type TxRawResult struct {
 	Blocktime     int64  `json:"blocktime,omitempty"`
 }
 
+// GetScriptHashBalanceResult models the data returned from the
+// getscripthashbalance command.
+type GetScriptHashBalanceResult struct {
+	Confirmed int64  `json:"confirmed"`
+	UtxoCount uint32 `json:"utxocount"`
+}
+
+// GetScriptHashHistoryResult models the data returned for a single transaction
+// returned by the getscripthashhistory command.
+type GetScriptHashHistoryResult struct {
+	TxID          string `json:"txid"`
+	BlockHash     string `json:"blockhash"`
+	BlockHeight   int64  `json:"blockheight"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+// GetSpendingInfoResult models the data returned from the getspendinginfo
+// command.
+type GetSpendingInfoResult struct {
+	Spent         bool   `json:"spent"`
+	TxID          string `json:"txid,omitempty"`
+	Vin           uint32 `json:"vin"`
+	BlockHash     string `json:"blockhash,omitempty"`
+	BlockHeight   int64  `json:"blockheight,omitempty"`
+	Confirmations int64  `json:"confirmations"`
+}
+
+// GetTicketInfoResult models the data returned from the getticketinfo
+// command.
+type GetTicketInfoResult struct {
+	Ticket          string   `json:"ticket"`
+	Status          string   `json:"status"`
+	PurchaseBlock   string   `json:"purchaseblock"`
+	PurchaseHeight  int64    `json:"purchaseheight"`
+	Price           float64  `json:"price"`
+	VotingAddress   string   `json:"votingaddress,omitempty"`
+	RewardAddresses []string `json:"rewardaddresses"`
+	SpendingTx      string   `json:"spendingtx,omitempty"`
+	SpendingHeight  int64    `json:"spendingheight,omitempty"`
+	MissedHeight    int64    `json:"missedheight,omitempty"`
+	ExpiredHeight   int64    `json:"expiredheight,omitempty"`
+}
+
 // GetStakeDifficultyResult models the data returned from the
 // getstakedifficulty command.
 type GetStakeDifficultyResult struct {
@@ -379,12 +533,17 @@ type GetTxOutResult struct {
 }
 
 // GetTxOutSetInfoResult models the data from the gettxoutsetinfo command.
+//
+// The SerializedHash field is deprecated and no longer populated since the
+// statistics are maintained incrementally instead of by scanning the entire
+// utxo set.  The MuHash field commits to the unspent outputs instead.
 type GetTxOutSetInfoResult struct {
 	Height         int64  `json:"height"`
 	BestBlock      string `json:"bestblock"`
 	Transactions   int64  `json:"transactions"`
 	TxOuts         int64  `json:"txouts"`
-	SerializedHash string `json:"serializedhash"`
+	SerializedHash string `json:"serializedhash,omitempty"`
+	MuHash         string `json:"muhash"`
 	DiskSize       int64  `json:"disksize"`
 	TotalAmount    int64  `json:"totalamount"`
 }
@@ -433,6 +592,18 @@ type GetTreasuryBalanceResult struct {
 	Updates []int64 `json:"updates,omitempty"`
 }
 
+// GetTreasuryHistoryResult models the data returned for a single treasury
+// transaction returned by the gettreasuryhistory command.
+type GetTreasuryHistoryResult struct {
+	Type        string `json:"type"`
+	TxID        string `json:"txid"`
+	BlockHash   string `json:"blockhash"`
+	BlockHeight int64  `json:"blockheight"`
+	Amount      int64  `json:"amount"`
+	Fee         int64  `json:"fee,omitempty"`
+	Balance     int64  `json:"balance"`
+}
+
 // TreasurySpendVotes models the data returned for a single tspend returned by
 // gettreasuryspendvotes command.
 type TreasurySpendVotes struct {
@@ -527,6 +698,18 @@ type TicketFeeInfoResult struct {
 	FeeInfoWindows []FeeInfoWindow `json:"feeinfowindows"`
 }
 
+// SearchNullDataResult models the data returned from the searchnulldata
+// command.
+type SearchNullDataResult struct {
+	TxID          string `json:"txid"`
+	Vout          uint32 `json:"vout"`
+	BlockHash     string `json:"blockhash"`
+	BlockHeight   int64  `json:"blockheight"`
+	BlockIndex    uint32 `json:"blockindex"`
+	Confirmations int64  `json:"confirmations"`
+	Data          string `json:"data"`
+}
+
 // SearchRawTransactionsResult models the data from the searchrawtransaction
 // command.
 type SearchRawTransactionsResult struct {
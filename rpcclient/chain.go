@@ -434,6 +434,89 @@ func (c *Client) GetBlockHeaderVerbose(ctx context.Context, hash *chainhash.Hash
 	return c.GetBlockHeaderVerboseAsync(ctx, hash).Receive()
 }
 
+// FutureGetBlockSpendsResult is a future promise to deliver the result of a
+// GetBlockSpendsAsync RPC invocation (or an applicable error).
+type FutureGetBlockSpendsResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// previous outputs spent by the transaction inputs in the requested block.
+func (r *FutureGetBlockSpendsResult) Receive() (*chainjson.GetBlockSpendsResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result.
+	var result chainjson.GetBlockSpendsResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetBlockSpendsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBlockSpends for the blocking version and more details.
+func (c *Client) GetBlockSpendsAsync(ctx context.Context, hash *chainhash.Hash) *FutureGetBlockSpendsResult {
+	cmd := chainjson.NewGetBlockSpendsCmd(hash.String())
+	return (*FutureGetBlockSpendsResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetBlockSpends returns the previous outputs spent by the transaction inputs
+// in the block with the given hash in the main chain, including their amounts,
+// scripts, and the height of the block that created them.
+func (c *Client) GetBlockSpends(ctx context.Context, hash *chainhash.Hash) (*chainjson.GetBlockSpendsResult, error) {
+	return c.GetBlockSpendsAsync(ctx, hash).Receive()
+}
+
+// FutureGetBlockStatsResult is a future promise to deliver the result of a
+// GetBlockStatsAsync RPC invocation (or an applicable error).
+type FutureGetBlockStatsResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// statistics about the requested block.
+func (r *FutureGetBlockStatsResult) Receive() (*chainjson.GetBlockStatsResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result as a getblockstats result object.
+	var stats chainjson.GetBlockStatsResult
+	err = json.Unmarshal(res, &stats)
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// GetBlockStatsAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetBlockStats for the blocking version and more details.
+func (c *Client) GetBlockStatsAsync(ctx context.Context, hashOrHeight chainjson.HashOrHeight, stats []string) *FutureGetBlockStatsResult {
+	var statsPtr *[]string
+	if len(stats) > 0 {
+		statsPtr = &stats
+	}
+	cmd := chainjson.NewGetBlockStatsCmd(hashOrHeight, statsPtr)
+	return (*FutureGetBlockStatsResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetBlockStats returns statistics about the block in the main chain with the
+// provided hash or height, such as its fees, fee rates, transaction counts, and
+// treasury flows.  Only the provided statistics are returned unless none are
+// provided, in which case all of them are returned.
+//
+// This requires the block stats index to be enabled on the server.
+func (c *Client) GetBlockStats(ctx context.Context, hashOrHeight chainjson.HashOrHeight, stats []string) (*chainjson.GetBlockStatsResult, error) {
+	return c.GetBlockStatsAsync(ctx, hashOrHeight, stats).Receive()
+}
+
 // FutureGetBlockSubsidyResult is a future promise to deliver the result of a
 // GetBlockSubsidyAsync RPC invocation (or an applicable error).
 type FutureGetBlockSubsidyResult cmdRes
@@ -741,6 +824,83 @@ func (c *Client) GetChainTips(ctx context.Context) ([]chainjson.GetChainTipsResu
 	return c.GetChainTipsAsync(ctx).Receive()
 }
 
+// FutureDumpUtxoSetResult is a future promise to deliver the result of a
+// DumpUtxoSetAsync RPC invocation (or an applicable error).
+type FutureDumpUtxoSetResult cmdRes
+
+// Receive waits for the response promised by the future and returns information
+// about the utxo set snapshot that was written.
+func (r *FutureDumpUtxoSetResult) Receive() (*chainjson.DumpUtxoSetResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result.
+	var result chainjson.DumpUtxoSetResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// DumpUtxoSetAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See DumpUtxoSet for the blocking version and more details.
+func (c *Client) DumpUtxoSetAsync(ctx context.Context, path string) *FutureDumpUtxoSetResult {
+	cmd := chainjson.NewDumpUtxoSetCmd(path)
+	return (*FutureDumpUtxoSetResult)(c.sendCmd(ctx, cmd))
+}
+
+// DumpUtxoSet requests the server to write a snapshot of the utxo set along
+// with the other chain state required to bootstrap the chain as of the current
+// best block to the provided path.  Relative paths are relative to the data
+// directory of the server.
+func (c *Client) DumpUtxoSet(ctx context.Context, path string) (*chainjson.DumpUtxoSetResult, error) {
+	return c.DumpUtxoSetAsync(ctx, path).Receive()
+}
+
+// FutureBackupDbResult is a future promise to deliver the result of a
+// BackupDbAsync RPC invocation (or an applicable error).
+type FutureBackupDbResult cmdRes
+
+// Receive waits for the response promised by the future and returns information
+// about the database backup that was written.
+func (r *FutureBackupDbResult) Receive() (*chainjson.BackupDbResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal the result.
+	var result chainjson.BackupDbResult
+	err = json.Unmarshal(res, &result)
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// BackupDbAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See BackupDb for the blocking version and more details.
+func (c *Client) BackupDbAsync(ctx context.Context, path string) *FutureBackupDbResult {
+	cmd := chainjson.NewBackupDbCmd(path)
+	return (*FutureBackupDbResult)(c.sendCmd(ctx, cmd))
+}
+
+// BackupDb requests the server to write a consistent copy of its block database
+// to a new database at the provided path.  Relative paths are relative to the
+// data directory of the server.
+func (c *Client) BackupDb(ctx context.Context, path string) (*chainjson.BackupDbResult, error) {
+	return c.BackupDbAsync(ctx, path).Receive()
+}
+
 // VerifyChainAsync returns an instance of a type that can be used to get the
 // result of the RPC at some future time by invoking the Receive function on the
 // returned instance.
@@ -856,6 +1016,385 @@ func (c *Client) GetTxOut(ctx context.Context, txHash *chainhash.Hash, index uin
 	return c.GetTxOutAsync(ctx, txHash, index, mempool).Receive()
 }
 
+// FutureGetAddressBalanceResult is a future promise to deliver the result of a
+// GetAddressBalanceAsync RPC invocation (or an applicable error).
+type FutureGetAddressBalanceResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// confirmed and unconfirmed balance of an address.
+func (r *FutureGetAddressBalanceResult) Receive() (*chainjson.GetAddressBalanceResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getaddressbalance result object.
+	var balance chainjson.GetAddressBalanceResult
+	err = json.Unmarshal(res, &balance)
+	if err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// GetAddressBalanceAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetAddressBalance for the blocking version and more details.
+func (c *Client) GetAddressBalanceAsync(ctx context.Context, address dcrutil.Address) *FutureGetAddressBalanceResult {
+	cmd := chainjson.NewGetAddressBalanceCmd(address.Address())
+	return (*FutureGetAddressBalanceResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetAddressBalance returns the total amount of the unspent outputs in the main
+// chain that pay to the provided address along with the net change to it from
+// unconfirmed transactions in the mempool.
+//
+// This requires the address utxo index to be enabled on the server.
+func (c *Client) GetAddressBalance(ctx context.Context, address dcrutil.Address) (*chainjson.GetAddressBalanceResult, error) {
+	return c.GetAddressBalanceAsync(ctx, address).Receive()
+}
+
+// FutureGetAddressUtxosResult is a future promise to deliver the result of a
+// GetAddressUtxosAsync RPC invocation (or an applicable error).
+type FutureGetAddressUtxosResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// unspent outputs that pay to an address.
+func (r *FutureGetAddressUtxosResult) Receive() ([]chainjson.GetAddressUtxosResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getaddressutxos result objects.
+	var utxos []chainjson.GetAddressUtxosResult
+	err = json.Unmarshal(res, &utxos)
+	if err != nil {
+		return nil, err
+	}
+
+	return utxos, nil
+}
+
+// GetAddressUtxosAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetAddressUtxos for the blocking version and more details.
+func (c *Client) GetAddressUtxosAsync(ctx context.Context, address dcrutil.Address, includeMempool bool) *FutureGetAddressUtxosResult {
+	cmd := chainjson.NewGetAddressUtxosCmd(address.Address(), &includeMempool)
+	return (*FutureGetAddressUtxosResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetAddressUtxos returns the unspent outputs that pay to the provided address,
+// including the effects of unconfirmed transactions in the mempool when
+// requested.
+//
+// This requires the address utxo index to be enabled on the server.
+func (c *Client) GetAddressUtxos(ctx context.Context, address dcrutil.Address, includeMempool bool) ([]chainjson.GetAddressUtxosResult, error) {
+	return c.GetAddressUtxosAsync(ctx, address, includeMempool).Receive()
+}
+
+// FutureGetScriptHashBalanceResult is a future promise to deliver the result
+// of a GetScriptHashBalanceAsync RPC invocation (or an applicable error).
+type FutureGetScriptHashBalanceResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// confirmed balance of a public key script.
+func (r *FutureGetScriptHashBalanceResult) Receive() (*chainjson.GetScriptHashBalanceResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getscripthashbalance result object.
+	var balance chainjson.GetScriptHashBalanceResult
+	err = json.Unmarshal(res, &balance)
+	if err != nil {
+		return nil, err
+	}
+
+	return &balance, nil
+}
+
+// GetScriptHashBalanceAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetScriptHashBalance for the blocking version and more details.
+func (c *Client) GetScriptHashBalanceAsync(ctx context.Context, scriptHash *chainhash.Hash) *FutureGetScriptHashBalanceResult {
+	hash := ""
+	if scriptHash != nil {
+		hash = scriptHash.String()
+	}
+
+	cmd := chainjson.NewGetScriptHashBalanceCmd(hash)
+	return (*FutureGetScriptHashBalanceResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetScriptHashBalance returns the total amount of the unspent outputs in the
+// main chain that pay to the public key script identified by the provided
+// script hash along with the number of them.
+//
+// This requires the script hash index to be enabled on the server.
+func (c *Client) GetScriptHashBalance(ctx context.Context, scriptHash *chainhash.Hash) (*chainjson.GetScriptHashBalanceResult, error) {
+	return c.GetScriptHashBalanceAsync(ctx, scriptHash).Receive()
+}
+
+// FutureGetScriptHashHistoryResult is a future promise to deliver the result
+// of a GetScriptHashHistoryAsync RPC invocation (or an applicable error).
+type FutureGetScriptHashHistoryResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// transactions that involve a public key script.
+func (r *FutureGetScriptHashHistoryResult) Receive() ([]chainjson.GetScriptHashHistoryResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getscripthashhistory result objects.
+	var history []chainjson.GetScriptHashHistoryResult
+	err = json.Unmarshal(res, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetScriptHashHistoryAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetScriptHashHistory for the blocking version and more details.
+func (c *Client) GetScriptHashHistoryAsync(ctx context.Context, scriptHash *chainhash.Hash, skip, count int) *FutureGetScriptHashHistoryResult {
+	hash := ""
+	if scriptHash != nil {
+		hash = scriptHash.String()
+	}
+
+	cmd := chainjson.NewGetScriptHashHistoryCmd(hash, &skip, &count)
+	return (*FutureGetScriptHashHistoryResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetScriptHashHistory returns the transactions in the main chain that involve
+// the public key script identified by the provided script hash ordered by
+// their position in the chain.  The provided number of transactions are
+// skipped and at most count of them are returned.
+//
+// This requires the script hash index to be enabled on the server.
+func (c *Client) GetScriptHashHistory(ctx context.Context, scriptHash *chainhash.Hash, skip, count int) ([]chainjson.GetScriptHashHistoryResult, error) {
+	return c.GetScriptHashHistoryAsync(ctx, scriptHash, skip, count).Receive()
+}
+
+// FutureGetSpendingInfoResult is a future promise to deliver the result of a
+// GetSpendingInfoAsync RPC invocation (or an applicable error).
+type FutureGetSpendingInfoResult cmdRes
+
+// Receive waits for the response promised by the future and returns
+// information about the transaction that spends an output.
+func (r *FutureGetSpendingInfoResult) Receive() (*chainjson.GetSpendingInfoResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getspendinginfo result object.
+	var spendingInfo chainjson.GetSpendingInfoResult
+	err = json.Unmarshal(res, &spendingInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &spendingInfo, nil
+}
+
+// GetSpendingInfoAsync returns an instance of a type that can be used to get
+// the result of the RPC at some future time by invoking the Receive function on
+// the returned instance.
+//
+// See GetSpendingInfo for the blocking version and more details.
+func (c *Client) GetSpendingInfoAsync(ctx context.Context, txHash *chainhash.Hash, index uint32, mempool bool) *FutureGetSpendingInfoResult {
+	hash := ""
+	if txHash != nil {
+		hash = txHash.String()
+	}
+
+	cmd := chainjson.NewGetSpendingInfoCmd(hash, index, &mempool)
+	return (*FutureGetSpendingInfoResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetSpendingInfo returns information about the transaction that spends the
+// provided transaction output, including unconfirmed spending transactions in
+// the mempool when requested.
+//
+// This requires the spend index to be enabled on the server.
+func (c *Client) GetSpendingInfo(ctx context.Context, txHash *chainhash.Hash, index uint32, mempool bool) (*chainjson.GetSpendingInfoResult, error) {
+	return c.GetSpendingInfoAsync(ctx, txHash, index, mempool).Receive()
+}
+
+// FutureGetTicketInfoResult is a future promise to deliver the result of a
+// GetTicketInfoAsync RPC invocation (or an applicable error).
+type FutureGetTicketInfoResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// history of a ticket.
+func (r *FutureGetTicketInfoResult) Receive() (*chainjson.GetTicketInfoResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as a getticketinfo result object.
+	var ticketInfo chainjson.GetTicketInfoResult
+	err = json.Unmarshal(res, &ticketInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ticketInfo, nil
+}
+
+// GetTicketInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetTicketInfo for the blocking version and more details.
+func (c *Client) GetTicketInfoAsync(ctx context.Context, ticket *chainhash.Hash) *FutureGetTicketInfoResult {
+	hash := ""
+	if ticket != nil {
+		hash = ticket.String()
+	}
+
+	cmd := chainjson.NewGetTicketInfoCmd(hash)
+	return (*FutureGetTicketInfoResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetTicketInfo returns the history of the provided ticket, such as its
+// purchase, the vote or revocation that spent it, and whether it was missed or
+// expired.
+//
+// This requires the ticket index to be enabled on the server.
+func (c *Client) GetTicketInfo(ctx context.Context, ticket *chainhash.Hash) (*chainjson.GetTicketInfoResult, error) {
+	return c.GetTicketInfoAsync(ctx, ticket).Receive()
+}
+
+// FutureSearchNullDataResult is a future promise to deliver the result of a
+// SearchNullDataAsync RPC invocation (or an applicable error).
+type FutureSearchNullDataResult cmdRes
+
+// Receive waits for the response promised by the future and returns the null
+// data outputs that matched the search.
+func (r *FutureSearchNullDataResult) Receive() ([]chainjson.SearchNullDataResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of searchnulldata result objects.
+	var matches []chainjson.SearchNullDataResult
+	err = json.Unmarshal(res, &matches)
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// SearchNullDataAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See SearchNullData for the blocking version and more details.
+func (c *Client) SearchNullDataAsync(ctx context.Context, prefix []byte, startHeight, endHeight *int64, skip, count int) *FutureSearchNullDataResult {
+	cmd := chainjson.NewSearchNullDataCmd(hex.EncodeToString(prefix),
+		startHeight, endHeight, &skip, &count)
+	return (*FutureSearchNullDataResult)(c.sendCmd(ctx, cmd))
+}
+
+// SearchNullData returns the null data (OP_RETURN) outputs in the main chain
+// with payloads that start with the provided prefix ordered by their payload
+// and position in the chain.  The search may optionally be restricted to a
+// range of block heights by providing the start and end heights.  The provided
+// number of matches are skipped and at most count of them are returned.
+//
+// This requires the null data index to be enabled on the server.
+func (c *Client) SearchNullData(ctx context.Context, prefix []byte, startHeight, endHeight *int64, skip, count int) ([]chainjson.SearchNullDataResult, error) {
+	return c.SearchNullDataAsync(ctx, prefix, startHeight, endHeight, skip,
+		count).Receive()
+}
+
+// FutureGetIndexInfoResult is a future promise to deliver the result of a
+// GetIndexInfoAsync RPC invocation (or an applicable error).
+type FutureGetIndexInfoResult cmdRes
+
+// Receive waits for the response promised by the future and returns the sync
+// state of the enabled optional indexes.
+func (r *FutureGetIndexInfoResult) Receive() ([]chainjson.GetIndexInfoResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getindexinfo result objects.
+	var indexInfo []chainjson.GetIndexInfoResult
+	err = json.Unmarshal(res, &indexInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	return indexInfo, nil
+}
+
+// GetIndexInfoAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetIndexInfo for the blocking version and more details.
+func (c *Client) GetIndexInfoAsync(ctx context.Context, indexName *string) *FutureGetIndexInfoResult {
+	cmd := chainjson.NewGetIndexInfoCmd(indexName)
+	return (*FutureGetIndexInfoResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetIndexInfo returns the sync state of the enabled optional indexes.  Only
+// the index with the provided name is returned when it is not nil.
+func (c *Client) GetIndexInfo(ctx context.Context, indexName *string) ([]chainjson.GetIndexInfoResult, error) {
+	return c.GetIndexInfoAsync(ctx, indexName).Receive()
+}
+
+// FutureReindexResult is a future promise to deliver the result of a
+// ReindexAsync RPC invocation (or an applicable error).
+type FutureReindexResult cmdRes
+
+// Receive waits for the response promised by the future and returns an error if
+// any occurred when rewinding the index.
+func (r *FutureReindexResult) Receive() error {
+	_, err := receiveFuture(r.ctx, r.c)
+	return err
+}
+
+// ReindexAsync returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See Reindex for the blocking version and more details.
+func (c *Client) ReindexAsync(ctx context.Context, indexName string, height int64) *FutureReindexResult {
+	cmd := chainjson.NewReindexCmd(indexName, height)
+	return (*FutureReindexResult)(c.sendCmd(ctx, cmd))
+}
+
+// Reindex rewinds the enabled optional index with the provided name to the
+// provided height so the main chain blocks after it are indexed again.  The
+// server catches the index up to the best chain tip in the background once it
+// has been rewound.
+func (c *Client) Reindex(ctx context.Context, indexName string, height int64) error {
+	return c.ReindexAsync(ctx, indexName, height).Receive()
+}
+
 // FutureRescanResult is a future promise to deliver the result of a
 // RescanAsynnc RPC invocation (or an applicable error).
 type FutureRescanResult cmdRes
@@ -997,7 +1536,8 @@ func (c *Client) GetCFilterHeader(ctx context.Context, blockHash *chainhash.Hash
 }
 
 // CFilterV2Result is the result of calling the GetCFilterV2 and
-// GetCFilterV2Async methods.
+// GetCFilterV2Async methods.  It is also the type of the individual filters in
+// the result of calling the GetCFiltersV2 and GetCFiltersV2Async methods.
 type CFilterV2Result struct {
 	BlockHash   chainhash.Hash
 	Filter      *gcs.FilterV2
@@ -1005,24 +1545,9 @@ type CFilterV2Result struct {
 	ProofHashes []chainhash.Hash
 }
 
-// FutureGetCFilterV2Result is a future promise to deliver the result of a
-// GetCFilterV2Async RPC invocation (or an applicable error).
-type FutureGetCFilterV2Result cmdRes
-
-// Receive waits for the response promised by the future and returns the
-// discovered rescan data.
-func (r *FutureGetCFilterV2Result) Receive() (*CFilterV2Result, error) {
-	res, err := receiveFuture(r.ctx, r.c)
-	if err != nil {
-		return nil, err
-	}
-
-	var filterResult chainjson.GetCFilterV2Result
-	err = json.Unmarshal(res, &filterResult)
-	if err != nil {
-		return nil, err
-	}
-
+// parseCFilterV2Result parses the provided getcfilterv2 result object into a
+// CFilterV2Result.
+func parseCFilterV2Result(filterResult *chainjson.GetCFilterV2Result) (*CFilterV2Result, error) {
 	blockHash, err := chainhash.NewHashFromStr(filterResult.BlockHash)
 	if err != nil {
 		return nil, err
@@ -1054,6 +1579,27 @@ func (r *FutureGetCFilterV2Result) Receive() (*CFilterV2Result, error) {
 	}, nil
 }
 
+// FutureGetCFilterV2Result is a future promise to deliver the result of a
+// GetCFilterV2Async RPC invocation (or an applicable error).
+type FutureGetCFilterV2Result cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// discovered rescan data.
+func (r *FutureGetCFilterV2Result) Receive() (*CFilterV2Result, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	var filterResult chainjson.GetCFilterV2Result
+	err = json.Unmarshal(res, &filterResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseCFilterV2Result(&filterResult)
+}
+
 // GetCFilterV2Async returns an instance of a type that can be used to get the
 // result of the RPC at some future time by invoking the Receive function on the
 // returned instance.
@@ -1071,6 +1617,57 @@ func (c *Client) GetCFilterV2(ctx context.Context, blockHash *chainhash.Hash) (*
 	return c.GetCFilterV2Async(ctx, blockHash).Receive()
 }
 
+// FutureGetCFiltersV2Result is a future promise to deliver the result of a
+// GetCFiltersV2Async RPC invocation (or an applicable error).
+type FutureGetCFiltersV2Result cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// filters for the requested range of blocks.
+func (r *FutureGetCFiltersV2Result) Receive() ([]*CFilterV2Result, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of getcfilterv2 result objects.
+	var filterResults []chainjson.GetCFilterV2Result
+	err = json.Unmarshal(res, &filterResults)
+	if err != nil {
+		return nil, err
+	}
+
+	filters := make([]*CFilterV2Result, 0, len(filterResults))
+	for i := range filterResults {
+		filter, err := parseCFilterV2Result(&filterResults[i])
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters, nil
+}
+
+// GetCFiltersV2Async returns an instance of a type that can be used to get the
+// result of the RPC at some future time by invoking the Receive function on the
+// returned instance.
+//
+// See GetCFiltersV2 for the blocking version and more details.
+func (c *Client) GetCFiltersV2Async(ctx context.Context, startHash, endHash *chainhash.Hash) *FutureGetCFiltersV2Result {
+	cmd := chainjson.NewGetCFiltersV2Cmd(startHash.String(), endHash.String())
+	return (*FutureGetCFiltersV2Result)(c.sendCmd(ctx, cmd))
+}
+
+// GetCFiltersV2 returns the version 2 block filters for the contiguous range of
+// blocks in the main chain from the given start block through the given end
+// block (inclusive) along with proofs that can be used to prove each filter is
+// committed to by its block header.  The filters are ordered by the height of
+// their associated blocks.  The range may not contain more than
+// wire.MaxCFiltersV2PerBatch blocks.
+func (c *Client) GetCFiltersV2(ctx context.Context, startHash, endHash *chainhash.Hash) ([]*CFilterV2Result, error) {
+	return c.GetCFiltersV2Async(ctx, startHash, endHash).Receive()
+}
+
 // FutureEstimateSmartFeeResult is a future promise to deliver the result of a
 // EstimateSmartFee RPC invocation (or an applicable error).
 type FutureEstimateSmartFeeResult cmdRes
@@ -1126,6 +1126,56 @@ func (c *Client) GetTreasuryBalance(ctx context.Context, block *chainhash.Hash,
 	return c.GetTreasuryBalanceAsync(ctx, block, verbose).Receive()
 }
 
+// FutureGetTreasuryHistoryResult is a future promise to deliver the result of
+// a GetTreasuryHistoryAsync RPC invocation (or an applicable error).
+type FutureGetTreasuryHistoryResult cmdRes
+
+// Receive waits for the response promised by the future and returns the
+// gettreasuryhistory result.
+func (r *FutureGetTreasuryHistoryResult) Receive() ([]chainjson.GetTreasuryHistoryResult, error) {
+	res, err := receiveFuture(r.ctx, r.c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Unmarshal result as an array of gettreasuryhistory result objects.
+	var history []chainjson.GetTreasuryHistoryResult
+	err = json.Unmarshal(res, &history)
+	if err != nil {
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// GetTreasuryHistoryAsync returns an instance of a type that can be used to
+// get the result of the RPC at some future time by invoking the Receive
+// function on the returned instance.
+//
+// See GetTreasuryHistory for the blocking version and more details.
+func (c *Client) GetTreasuryHistoryAsync(ctx context.Context, startHeight, endHeight int64, txTypes []string, skip, count int) *FutureGetTreasuryHistoryResult {
+	var types *[]string
+	if len(txTypes) > 0 {
+		types = &txTypes
+	}
+	cmd := chainjson.NewGetTreasuryHistoryCmd(&startHeight, &endHeight, types,
+		&skip, &count)
+	return (*FutureGetTreasuryHistoryResult)(c.sendCmd(ctx, cmd))
+}
+
+// GetTreasuryHistory returns the treasury adds, treasury bases, and treasury
+// spends in blocks with heights in the provided inclusive range along with the
+// treasury balance as of the blocks that contain them.  Only the provided types
+// ("tadd", "treasurybase", and "tspend") are returned unless no types are
+// provided, in which case all types are returned.  The skip and count
+// parameters may be used to page through the results.
+//
+// This requires the treasury index to be enabled on the server.
+func (c *Client) GetTreasuryHistory(ctx context.Context, startHeight, endHeight int64, txTypes []string, skip, count int) ([]chainjson.GetTreasuryHistoryResult, error) {
+	return c.GetTreasuryHistoryAsync(ctx, startHeight, endHeight, txTypes,
+		skip, count).Receive()
+}
+
 // FutureGetTreasurySpendVotes is a future promise to deliver the result of a
 // GetTreasurySpendVotesAsync RPC invocation (or an applicable error).
 type FutureGetTreasurySpendVotesResult cmdRes
@@ -21,6 +21,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -897,7 +898,12 @@ func (c *Client) sendPost(ctx context.Context, jReq *jsonRequest) {
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	// Configure basic access authorization.
-	httpReq.SetBasicAuth(c.config.User, c.config.Pass)
+	user, pass, err := c.config.auth()
+	if err != nil {
+		jReq.responseChan <- &response{result: nil, err: err}
+		return
+	}
+	httpReq.SetBasicAuth(user, pass)
 
 	log.Tracef("Sending command [%s] with id %d", jReq.method, jReq.id)
 	c.sendPostRequest(httpReq, jReq)
@@ -1133,6 +1139,14 @@ type ConnConfig struct {
 	// Pass is the passphrase to use to authenticate to the RPC server.
 	Pass string
 
+	// CookiePath is the path of a cookie file written by the RPC server that
+	// contains the username and passphrase to use to authenticate to the RPC
+	// server in the form <username>:<passphrase>.  It is only used when Pass
+	// is empty.  The file is read each time a connection is established or
+	// an HTTP POST request is made since the server generates new
+	// credentials each time it starts.
+	CookiePath string
+
 	// DisableTLS specifies whether transport layer security should be
 	// disabled.  It is recommended to always use TLS if the RPC server
 	// supports it as otherwise your username and password is sent across
@@ -1177,6 +1191,27 @@ type ConnConfig struct {
 	HTTPPostMode bool
 }
 
+// auth returns the username and passphrase to use to authenticate to the RPC
+// server.  They are read from the cookie file when a cookie path is configured
+// and no passphrase is provided.
+func (config *ConnConfig) auth() (string, string, error) {
+	if config.Pass != "" || config.CookiePath == "" {
+		return config.User, config.Pass, nil
+	}
+
+	cookie, err := ioutil.ReadFile(config.CookiePath)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read cookie file: %w", err)
+	}
+	creds := strings.TrimSpace(string(cookie))
+	sep := strings.IndexByte(creds, ':')
+	if sep < 0 {
+		return "", "", fmt.Errorf("malformed cookie file %s",
+			config.CookiePath)
+	}
+	return creds[:sep], creds[sep+1:], nil
+}
+
 // newHTTPClient returns a new http client that is configured according to the
 // proxy and TLS settings in the associated connection configuration.
 func newHTTPClient(config *ConnConfig) (*http.Client, error) {
@@ -1246,7 +1281,11 @@ func dial(config *ConnConfig) (*websocket.Conn, error) {
 
 	// The RPC server requires basic authorization, so create a custom
 	// request header with the Authorization header set.
-	login := config.User + ":" + config.Pass
+	user, pass, err := config.auth()
+	if err != nil {
+		return nil, err
+	}
+	login := user + ":" + pass
 	auth := "Basic " + base64.StdEncoding.EncodeToString([]byte(login))
 	requestHeader := make(http.Header)
 	requestHeader.Add("Authorization", auth)
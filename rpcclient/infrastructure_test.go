@@ -4,7 +4,12 @@
 
 package rpcclient
 
-import "testing"
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
 
 func TestClientStringer(t *testing.T) {
 	type test struct {
@@ -36,3 +41,67 @@ func TestClientStringer(t *testing.T) {
 		}
 	}
 }
+
+// TestConnConfigAuth ensures the credentials used to authenticate to the RPC
+// server are read from the cookie file when no passphrase is configured.
+func TestConnConfigAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rpcclientcookie")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	cookiePath := filepath.Join(dir, ".cookie")
+	malformedPath := filepath.Join(dir, ".malformed")
+	err = ioutil.WriteFile(cookiePath, []byte("__cookie__:abc:def\n"), 0600)
+	if err != nil {
+		t.Fatalf("unable to write cookie file: %v", err)
+	}
+	err = ioutil.WriteFile(malformedPath, []byte("nocolon"), 0600)
+	if err != nil {
+		t.Fatalf("unable to write cookie file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   ConnConfig
+		wantUser string
+		wantPass string
+		wantErr  bool
+	}{{
+		name:     "user and pass",
+		config:   ConnConfig{User: "user", Pass: "pass"},
+		wantUser: "user",
+		wantPass: "pass",
+	}, {
+		name: "pass takes precedence over cookie",
+		config: ConnConfig{User: "user", Pass: "pass",
+			CookiePath: cookiePath},
+		wantUser: "user",
+		wantPass: "pass",
+	}, {
+		name:     "cookie",
+		config:   ConnConfig{CookiePath: cookiePath},
+		wantUser: "__cookie__",
+		wantPass: "abc:def",
+	}, {
+		name:    "missing cookie file",
+		config:  ConnConfig{CookiePath: filepath.Join(dir, "missing")},
+		wantErr: true,
+	}, {
+		name:    "malformed cookie file",
+		config:  ConnConfig{CookiePath: malformedPath},
+		wantErr: true,
+	}}
+	for _, test := range tests {
+		user, pass, err := test.config.auth()
+		if (err != nil) != test.wantErr {
+			t.Errorf("%q: unexpected error -- got %v, want error %v",
+				test.name, err, test.wantErr)
+			continue
+		}
+		if user != test.wantUser || pass != test.wantPass {
+			t.Errorf("%q: unexpected credentials -- got %s:%s, want %s:%s",
+				test.name, user, pass, test.wantUser, test.wantPass)
+		}
+	}
+}
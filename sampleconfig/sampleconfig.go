@@ -22,6 +22,13 @@ const fileContents = `[Application Options]
 ; datadir=$LOCALAPPDATA/Dcrd/data                 ; Windows
 ; datadir=~/Library/Application Support/Dcrd/data ; macOS
 
+; The database backend to use for the block chain.  The ffldb backend stores
+; blocks in flat files alongside a leveldb database for the metadata while the
+; bboltdb backend stores everything in a single bbolt database file.  Each
+; backend keeps its own database in the data directory, so changing it requires
+; a full resync.  The default is ffldb.
+; dbtype=ffldb
+
 
 ; ------------------------------------------------------------------------------
 ; Network settings
@@ -178,15 +185,44 @@ const fileContents = `[Application Options]
 ; RPC server options - The following options control the built-in RPC server
 ; which is used to control and query information from a running dcrd process.
 ;
-; NOTE: The RPC server is disabled by default if no rpcuser or rpcpass is
-; specified.
+; NOTE: The RPC server is disabled by default if no credentials of any kind
+; (rpcuser/rpcpass, rpclimituser/rpclimitpass, rpcusersfile, rpcadminclient, or
+; rpclimitclient) are specified and rpccookie is not set.
 ; ------------------------------------------------------------------------------
 
 ; Secure the RPC API by specifying the username and password.  You must specify
-; both or the RPC server will be disabled.
+; both or the RPC server will be disabled unless other credentials are
+; specified or rpccookie is set.
 ; rpcuser=whatever_username_you_want
 ; rpcpass=
 
+; Generate a random full-access password each time dcrd starts and write it
+; along with the username __cookie__ to the .cookie file in the network-specific
+; data directory.  The file is only readable by the user running dcrd and is
+; removed on shutdown.  Local RPC clients may read it to authenticate without
+; any configured credentials.  This also enables the RPC server when no other
+; credentials are specified.
+; rpccookie=1
+
+; Load additional RPC users from a JSON file.  Each user is authenticated with a
+; bcrypt or argon2 hash of its password and is only authorized to call the
+; methods listed for it.  Methods may also be listed via named groups that are
+; defined in the file and referenced with an '@' prefix, the builtin '@limited'
+; group refers to the methods available to the limited user, and '*' authorizes
+; all methods.  Calls to methods a user is not authorized for are denied and
+; logged.  For example:
+;
+;   {
+;     "groups": {"chain": ["getbestblock", "getblock", "getblockcount"]},
+;     "users": [
+;       {"username": "explorer", "passwordhash": "$2a$10$...",
+;        "methods": ["@chain", "notifyblocks"]},
+;       {"username": "ops", "passwordhash": "$argon2id$v=19$m=65536,t=3,p=4$...",
+;        "methods": ["*"]}
+;     ]
+;   }
+; rpcusersfile=~/.dcrd/rpcusers.json
+
 ; Specify the interfaces for the RPC server listen on.  One listen address per
 ; line.  NOTE: The default port is modified by some options such as 'testnet',
 ; so it is recommended to not specify a port and allow a proper default to be
@@ -223,6 +259,14 @@ const fileContents = `[Application Options]
 ; Specify the maximum number of concurrent RPC websocket clients.
 ; rpcmaxwebsockets=25
 
+; Serve the read-only REST interface under /rest/ on the RPC listeners.  The
+; REST interface is not authenticated and provides blocks, block headers,
+; transactions, chain information, mempool information, and version 2 committed
+; filters via plain HTTP GET requests, which makes it suitable for use behind
+; caching proxies.  See the JSON-RPC API documentation for the available
+; endpoints.
+; rest=1
+
 ; Use the following setting to disable the RPC server even if the rpcuser and
 ; rpcpass are specified above.  This allows one to quickly disable the RPC
 ; server without having to remove credentials from the config file.
@@ -235,6 +279,20 @@ const fileContents = `[Application Options]
 ; Supported curves: P-521, P-256.
 ; tlscurve=P-521
 
+; Require all RPC connections to present a TLS client certificate signed by one
+; of the certificate authorities in the specified file.  Client certificates
+; signed by a local authority may be created with the gencerts utility, for
+; example:
+;   gencerts -L ca.cert ca.key
+;   gencerts -C ca.cert -K ca.key -c -n automation client.cert client.key
+; rpcclientcafile=~/.dcrd/clientca.cert
+
+; Authenticate connections with a verified client certificate whose subject has
+; the specified common name as the admin or limited RPC user when no other
+; credentials are supplied.  These may be specified multiple times.
+; rpcadminclient=automation
+; rpclimitclient=monitor
+
 
 ; ------------------------------------------------------------------------------
 ; Mempool Settings - The following options
@@ -264,6 +322,80 @@ const fileContents = `[Application Options]
 ; rejectnonstd=1
 
 
+; ------------------------------------------------------------------------------
+; Block Data Pruning
+; ------------------------------------------------------------------------------
+
+; Reduce storage requirements by removing old block data to keep the total size
+; of stored blocks near the specified target in MiB.  The minimum target is
+; 1024 MiB.  Pruned nodes do not serve historical blocks to other peers and are
+; not compatible with the txindex, addrindex, spendindex, ticketindex,
+; treasuryindex, scripthashindex, addrutxoindex, blockstatsindex, and
+; nulldataindex options.  Pruning is disabled by default.
+; prune=4096
+
+
+; ------------------------------------------------------------------------------
+; Unspent Transaction Output Cache
+; ------------------------------------------------------------------------------
+
+; The maximum size in MiB of the cache of unspent transaction outputs.  Changes
+; to the unspent transaction outputs are held in the cache and written to the
+; database in batches once it reaches the maximum size, periodically, and on
+; shutdown.  Larger values reduce the time it takes to sync the chain at the
+; cost of higher memory usage.  The valid range is 25 to 32768 MiB and the
+; default is 150 MiB.
+; utxocachemaxsize=150
+
+
+; ------------------------------------------------------------------------------
+; Assumed Valid Block
+; ------------------------------------------------------------------------------
+
+; The hash of a block for which the scripts of it and all of its ancestors are
+; assumed to be valid.  Script validation is skipped for those blocks during the
+; initial chain sync when the block is part of a header chain with at least the
+; minimum known work for the network.  All other consensus rules are still
+; enforced.  The default is a recent block hash that is updated with each
+; release.  Use 0 to validate the scripts of all blocks.
+; assumevalid=0
+
+
+; ------------------------------------------------------------------------------
+; UTXO Set Snapshot
+; ------------------------------------------------------------------------------
+
+; Initialize a new chain from a utxo set snapshot created with the dumputxoset
+; RPC instead of syncing from the genesis block.  The snapshot must be one that
+; is committed to by the active network and may only be loaded into a new data
+; directory.  The chain is usable as soon as the snapshot is loaded while the
+; history that leads to it is downloaded and validated in the background.  The
+; optional indexes are not available until the history has been validated.
+; Neither mainnet nor testnet commit to any snapshots yet, so this option is
+; currently only usable with networks whose parameters commit to snapshots.
+; loadutxosnapshot=~/utxoset.snapshot
+
+
+; ------------------------------------------------------------------------------
+; Database Integrity
+; ------------------------------------------------------------------------------
+
+; Verify the integrity of the block database on start up, write a report of any
+; issues that are found to dbverify.json in the data directory, and then exit.
+; The stored blocks are checked against the block index and the spend journals,
+; utxo set, and utxo set statistics are checked against the blocks in the main
+; chain.
+; verifydb=0
+
+; Verify the integrity of the block database like verifydb and also repair the
+; issues that are found where possible.  Invalid block index entries are
+; removed, stored blocks that are missing from the block index are added back
+; to it, and the spend journals, utxo set, and utxo set statistics are rebuilt
+; from the blocks in the main chain when they do not match.  Rebuilding requires
+; all blocks in the main chain, so it is not possible for pruned nodes.
+; repairdb=0
+
+
 ; ------------------------------------------------------------------------------
 ; Optional Transaction Indexes
 ; ------------------------------------------------------------------------------
@@ -271,6 +403,27 @@ const fileContents = `[Application Options]
 ; Delete the entire address index on start up, then exit.
 ; dropaddrindex=0
 
+; Delete the entire spend index on start up, then exit.
+; dropspendindex=0
+
+; Delete the entire ticket index on start up, then exit.
+; dropticketindex=0
+
+; Delete the entire treasury index on start up, then exit.
+; droptreasuryindex=0
+
+; Delete the entire script hash index on start up, then exit.
+; dropscripthashindex=0
+
+; Delete the entire address utxo index on start up, then exit.
+; dropaddrutxoindex=0
+
+; Delete the entire block stats index on start up, then exit.
+; dropblockstatsindex=0
+
+; Delete the entire null data index on start up, then exit.
+; dropnulldataindex=0
+
 
 ; ------------------------------------------------------------------------------
 ; Optional Indexes
@@ -284,6 +437,50 @@ const fileContents = `[Application Options]
 ; searchrawtransactions RPC available.
 ; addrindex=1
 
+; Build and maintain a full outpoint-based spend index which makes the
+; getspendinginfo RPC available.
+; spendindex=1
+
+; Build and maintain a full ticket history index which makes the getticketinfo
+; RPC and historical tickets in the ticketsforaddress RPC available.
+; ticketindex=1
+
+; Build and maintain a full treasury transaction history index which makes the
+; gettreasuryhistory RPC available.
+; treasuryindex=1
+
+; Build and maintain a full script hash index which makes the
+; getscripthashhistory and getscripthashbalance RPCs available.  The index is
+; keyed by the SHA256 hash of the full public key script, so it also covers
+; scripts that do not map to an address.
+; scripthashindex=1
+
+; Build and maintain a full address-based unspent transaction output index
+; which makes the getaddressbalance and getaddressutxos RPCs available.  This
+; also enables the address index since it is used to include unconfirmed
+; transactions.
+; addrutxoindex=1
+
+; Build and maintain a full block statistics index which makes the
+; getblockstats RPC available.  The statistics include the fees and fee rates,
+; the number of each type of transaction, the treasury flows, and the change in
+; the number of unspent transaction outputs for every block.
+; blockstatsindex=1
+
+; Build and maintain a full null data (OP_RETURN) payload index which makes the
+; searchnulldata RPC available.  Only null data outputs in the regular
+; transaction tree are indexed, excluding the coinbase.
+; nulldataindex=1
+
+; Rewind an enabled index to the given height on start up so the blocks after
+; it are indexed again without dropping the index.  This is useful after an
+; update that fixes an issue with the entries an index stores.  The value is the
+; name of the option used to enable the index, or the index name as reported by
+; the getindexinfo RPC, followed by a colon and the height.  The indexes that
+; depend on the index, such as the address index for the transaction index, are
+; rewound as well while the other indexes are not modified.
+; reindex=addrindex:500000
+
 
 ; ------------------------------------------------------------------------------
 ; Signature Verification Cache
@@ -368,6 +565,23 @@ const fileContents = `[Application Options]
 ;   profile=192.168.1.123:6061
 ; Listen on ipv6 loopback interface:
 ;   profile=[::1]:6061
+
+; ------------------------------------------------------------------------------
+; Metrics - serve Prometheus metrics
+; ------------------------------------------------------------------------------
+
+; The metrics server will be disabled if this option is not specified.  Metrics
+; in the Prometheus text exposition format can be accessed at
+; http://ipaddr:<metricsport>/metrics once running.  They include the best chain
+; height, block processing latency, peer and network traffic totals, mempool
+; size, block template generation timing, RPC request counts and latency by
+; method, and fee estimates.  Note that the IP address will default to
+; 127.0.0.1 if an IP address is not specified.  The metrics are not
+; authenticated, so they should only be exposed to trusted networks.
+; Listen on selected port on localhost only:
+;   metricslisten=9111
+; Listen on selected port on all network interfaces:
+;   metricslisten=:9111
 `
 
 // DcrctlSampleConfig is a string containing the commented example config for dcrctl.
@@ -10,6 +10,7 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
 	"encoding/binary"
 	"errors"
 	"fmt"
@@ -73,7 +74,7 @@ const (
 	connectionRetryInterval = time.Second * 5
 
 	// maxProtocolVersion is the max protocol version the server supports.
-	maxProtocolVersion = wire.InitStateVersion
+	maxProtocolVersion = wire.BatchedCFiltersV2Version
 
 	// maxKnownAddrsPerPeer is the maximum number of items to keep in the
 	// per-peer known address cache.
@@ -468,6 +469,8 @@ type server struct {
 	txMemPool            *mempool.TxPool
 	feeEstimator         *fees.Estimator
 	cpuMiner             *cpuminer.CPUMiner
+	metrics              *nodeMetrics
+	metricsListener      net.Listener
 	modifyRebroadcastInv chan interface{}
 	newPeers             chan *serverPeer
 	donePeers            chan *serverPeer
@@ -488,8 +491,16 @@ type server struct {
 	// do not need to be protected for concurrent access.
 	txIndex         *indexers.TxIndex
 	addrIndex       *indexers.AddrIndex
+	spendIndex      *indexers.SpendIndex
+	ticketIndex     *indexers.TicketIndex
+	treasuryIndex   *indexers.TreasuryIndex
+	scriptHashIndex *indexers.ScriptHashIndex
+	addrUtxoIndex   *indexers.AddrUtxoIndex
+	blockStatsIndex *indexers.BlockStatsIndex
+	nullDataIndex   *indexers.NullDataIndex
 	existsAddrIndex *indexers.ExistsAddrIndex
 	cfIndex         *indexers.CFIndex
+	indexManager    *indexers.Manager
 
 	// The following fields are used to filter duplicate block announcements.
 	announcedBlockMtx sync.Mutex
@@ -1361,6 +1372,39 @@ func (sp *serverPeer) OnGetCFilterV2(_ *peer.Peer, msg *wire.MsgGetCFilterV2) {
 	sp.QueueMessage(cfilterMsg, nil)
 }
 
+// OnGetCFiltersV2 is invoked when a peer receives a getcfsv2 wire message.
+func (sp *serverPeer) OnGetCFiltersV2(_ *peer.Peer, msg *wire.MsgGetCFsV2) {
+	// Ignore request if the chain is not yet synced.
+	if !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	// Attempt to obtain the requested filters along with the proofs that they
+	// are committed to by their block headers.
+	//
+	// Ignore request for unknown blocks, invalid ranges, or otherwise missing
+	// filters.
+	chain := sp.server.chain
+	filters, err := chain.LocateCFiltersV2(&msg.StartHash, &msg.EndHash,
+		wire.MaxCFiltersV2PerBatch)
+	if err != nil {
+		peerLog.Debugf("Unable to obtain requested filters for %s: %v", sp, err)
+		return
+	}
+
+	cfilterMsgs := make([]wire.MsgCFilterV2, 0, len(filters))
+	for i := range filters {
+		filter := &filters[i]
+		cfilterMsgs = append(cfilterMsgs, wire.MsgCFilterV2{
+			BlockHash:   filter.BlockHash,
+			Data:        filter.Filter.Bytes(),
+			ProofIndex:  filter.ProofIndex,
+			ProofHashes: filter.ProofHashes,
+		})
+	}
+	sp.QueueMessage(wire.NewMsgCFiltersV2(cfilterMsgs), nil)
+}
+
 // OnGetCFHeaders is invoked when a peer receives a getcfheader wire message.
 func (sp *serverPeer) OnGetCFHeaders(p *peer.Peer, msg *wire.MsgGetCFHeaders) {
 	// Disconnect and/or ban depending on the node cf services flag and
@@ -2256,6 +2300,7 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnGetHeaders:     sp.OnGetHeaders,
 			OnGetCFilter:     sp.OnGetCFilter,
 			OnGetCFilterV2:   sp.OnGetCFilterV2,
+			OnGetCFiltersV2:  sp.OnGetCFiltersV2,
 			OnGetCFHeaders:   sp.OnGetCFHeaders,
 			OnGetCFTypes:     sp.OnGetCFTypes,
 			OnGetAddr:        sp.OnGetAddr,
@@ -3162,6 +3207,16 @@ func (s *server) Run(ctx context.Context) {
 	// This is needed since not all of the subsystems support context.
 	serverCtx, shutdownServer := context.WithCancel(ctx)
 
+	// Start syncing the optional indexes with the main chain in the
+	// background.
+	if s.indexManager != nil {
+		s.wg.Add(1)
+		go func(s *server) {
+			s.indexManager.Run(serverCtx)
+			s.wg.Done()
+		}(s)
+	}
+
 	// Start the peer handler which in turn starts the address and block
 	// managers.
 	s.wg.Add(1)
@@ -3215,6 +3270,15 @@ func (s *server) Run(ctx context.Context) {
 		}
 	}
 
+	// Start serving metrics when enabled.
+	if s.metricsListener != nil {
+		s.wg.Add(1)
+		go func(s *server) {
+			s.metrics.serve(serverCtx, s.metricsListener)
+			s.wg.Done()
+		}(s)
+	}
+
 	// Wait until the server is signalled to shutdown.
 	<-ctx.Done()
 	atomic.AddInt32(&s.shutdown, 1)
@@ -3227,6 +3291,12 @@ func (s *server) Run(ctx context.Context) {
 	// down.
 	shutdownServer()
 	s.wg.Wait()
+
+	// Write any unspent transaction output changes that are still only
+	// cached to the database now that no more blocks will be processed.
+	if err := s.chain.ShutdownUtxoCache(); err != nil {
+		srvrLog.Errorf("Unable to flush utxo cache: %v", err)
+	}
 }
 
 // parseListeners determines whether each listen address is IPv4 and IPv6 and
@@ -3425,6 +3495,22 @@ func setupRPCListeners() ([]net.Listener, error) {
 			MinVersion:   tls.VersionTLS12,
 		}
 
+		// Require and verify client certificates signed by the configured
+		// certificate authorities when requested.
+		if cfg.RPCClientCAFile != "" {
+			caCerts, err := ioutil.ReadFile(cfg.RPCClientCAFile)
+			if err != nil {
+				return nil, err
+			}
+			clientCAs := x509.NewCertPool()
+			if !clientCAs.AppendCertsFromPEM(caCerts) {
+				return nil, fmt.Errorf("no valid certificates found in %s",
+					cfg.RPCClientCAFile)
+			}
+			tlsConfig.ClientCAs = clientCAs
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+
 		// Change the standard net.Listen function to the tls one.
 		listenFunc = func(net string, laddr string) (net.Listener, error) {
 			return tls.Listen(net, laddr, &tlsConfig)
@@ -3457,6 +3543,32 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 	if cfg.NoCFilters {
 		services &^= wire.SFNodeCF
 	}
+	if cfg.Prune != 0 {
+		// Pruned nodes are not able to serve the full block chain.
+		services &^= wire.SFNodeNetwork
+	}
+
+	// Nodes that were bootstrapped from a utxo set snapshot are not able to
+	// serve the full block chain or build the optional indexes until the
+	// history that leads to the snapshot has been validated.
+	snapshotPending, err := blockchain.SnapshotHistoryPending(db)
+	if err != nil {
+		return nil, err
+	}
+	if snapshotPending {
+		if cfg.TxIndex || cfg.AddrIndex || cfg.SpendIndex || cfg.TicketIndex ||
+			cfg.TreasuryIndex || cfg.ScriptHashIndex || cfg.AddrUtxoIndex ||
+			cfg.BlockStatsIndex || cfg.NullDataIndex {
+
+			return nil, errors.New("the --txindex, --addrindex, " +
+				"--spendindex, --ticketindex, --treasuryindex, " +
+				"--scripthashindex, --addrutxoindex, --blockstatsindex, and " +
+				"--nulldataindex options may not be used until the history " +
+				"that leads to the loaded utxo set snapshot has been " +
+				"validated")
+		}
+		services &^= wire.SFNodeNetwork | wire.SFNodeCF
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, dcrdLookup)
 
@@ -3497,6 +3609,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 		sigCache:             sigCache,
 		subsidyCache:         standalone.NewSubsidyCache(chainParams),
 		lotteryDataBroadcast: make(map[chainhash.Hash]struct{}),
+		metrics:              newNodeMetrics(),
 	}
 
 	// Create the transaction and address indexes if needed.
@@ -3506,6 +3619,13 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 	// addrindex is run first, it may not have the transactions from the
 	// current block indexed.
 	var indexes []indexers.Indexer
+	if cfg.AddrUtxoIndex && !cfg.AddrIndex {
+		// Enable address index if the address utxo index is enabled since
+		// it relies on it for unconfirmed transactions.
+		indxLog.Infof("Address index enabled because it is required by " +
+			"the address utxo index")
+		cfg.AddrIndex = true
+	}
 	if cfg.TxIndex || cfg.AddrIndex {
 		// Enable transaction index if address index is enabled since it
 		// requires it.
@@ -3525,12 +3645,51 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 		s.addrIndex = indexers.NewAddrIndex(db, chainParams)
 		indexes = append(indexes, s.addrIndex)
 	}
-	if !cfg.NoExistsAddrIndex {
+	if cfg.SpendIndex {
+		indxLog.Info("Spend index is enabled")
+		s.spendIndex = indexers.NewSpendIndex(db)
+		indexes = append(indexes, s.spendIndex)
+	}
+	if cfg.TicketIndex {
+		indxLog.Info("Ticket index is enabled")
+		s.ticketIndex = indexers.NewTicketIndex(db, chainParams)
+		indexes = append(indexes, s.ticketIndex)
+	}
+	if cfg.TreasuryIndex {
+		indxLog.Info("Treasury index is enabled")
+		s.treasuryIndex = indexers.NewTreasuryIndex(db, chainParams)
+		indexes = append(indexes, s.treasuryIndex)
+	}
+	if cfg.ScriptHashIndex {
+		indxLog.Info("Script hash index is enabled")
+		s.scriptHashIndex = indexers.NewScriptHashIndex(db)
+		indexes = append(indexes, s.scriptHashIndex)
+	}
+	if cfg.AddrUtxoIndex {
+		indxLog.Info("Address utxo index is enabled")
+		s.addrUtxoIndex = indexers.NewAddrUtxoIndex(db, chainParams)
+		indexes = append(indexes, s.addrUtxoIndex)
+	}
+	if cfg.BlockStatsIndex {
+		indxLog.Info("Block stats index is enabled")
+		s.blockStatsIndex = indexers.NewBlockStatsIndex(db, chainParams)
+		indexes = append(indexes, s.blockStatsIndex)
+	}
+	if cfg.NullDataIndex {
+		indxLog.Info("Null data index is enabled")
+		s.nullDataIndex = indexers.NewNullDataIndex(db)
+		indexes = append(indexes, s.nullDataIndex)
+	}
+	if snapshotPending {
+		indxLog.Info("Exists address and CF indexes are disabled until the " +
+			"history that leads to the utxo set snapshot is validated")
+	}
+	if !cfg.NoExistsAddrIndex && !snapshotPending {
 		indxLog.Info("Exists address index is enabled")
 		s.existsAddrIndex = indexers.NewExistsAddrIndex(db, chainParams)
 		indexes = append(indexes, s.existsAddrIndex)
 	}
-	if !cfg.NoCFilters {
+	if !cfg.NoCFilters && !snapshotPending {
 		indxLog.Info("CF index is enabled")
 		s.cfIndex = indexers.NewCfIndex(db, chainParams)
 		indexes = append(indexes, s.cfIndex)
@@ -3559,7 +3718,8 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager indexers.IndexManager
 	if len(indexes) > 0 {
-		indexManager = indexers.NewManager(db, indexes, chainParams)
+		s.indexManager = indexers.NewManager(db, indexes, chainParams)
+		indexManager = s.indexManager
 	}
 
 	// Only configure checkpoints when enabled.
@@ -3571,19 +3731,36 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 	// Create a new block chain instance with the appropriate configuration.
 	s.chain, err = blockchain.New(ctx,
 		&blockchain.Config{
-			DB:            s.db,
-			ChainParams:   s.chainParams,
-			Checkpoints:   checkpoints,
-			TimeSource:    s.timeSource,
-			Notifications: s.handleBlockchainNotification,
-			SigCache:      s.sigCache,
-			SubsidyCache:  s.subsidyCache,
-			IndexManager:  indexManager,
+			DB:               s.db,
+			ChainParams:      s.chainParams,
+			Checkpoints:      checkpoints,
+			TimeSource:       s.timeSource,
+			Notifications:    s.handleBlockchainNotification,
+			SigCache:         s.sigCache,
+			SubsidyCache:     s.subsidyCache,
+			IndexManager:     indexManager,
+			PruneTarget:      cfg.Prune * 1024 * 1024,
+			UtxoCacheMaxSize: uint64(cfg.UtxoCacheMaxSize) * 1024 * 1024,
+			AssumeValid:      cfg.assumeValid,
 		})
 	if err != nil {
 		return nil, err
 	}
 
+	// Rewind the requested index so the blocks after the specified height are
+	// indexed again once the index manager is started.
+	if cfg.reindexName != "" {
+		if s.indexManager == nil {
+			return nil, fmt.Errorf("unable to rewind the %s: no indexes "+
+				"are enabled", indexers.IndexName(cfg.reindexName))
+		}
+		err := s.indexManager.Reindex(ctx, cfg.reindexName,
+			cfg.reindexHeight)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	txC := mempool.Config{
 		Policy: mempool.Policy{
 			EnableAncestorTracking: len(cfg.miningAddrs) > 0,
@@ -3661,9 +3838,11 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 			return s.rpcServer
 		},
 		DisableCheckpoints: cfg.DisableCheckpoints,
+		AssumeValid:        cfg.assumeValid,
 		NoMiningStateSync:  cfg.NoMiningStateSync,
 		MaxPeers:           cfg.MaxPeers,
 		MaxOrphanTxs:       cfg.MaxOrphanTxs,
+		ProcessBlockTime:   s.metrics.processBlockTime,
 	})
 	if err != nil {
 		return nil, err
@@ -3849,6 +4028,17 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 			return nil, errors.New("no usable rpc listen addresses")
 		}
 
+		// Load the additional RPC users when a users file is specified.
+		var rpcUsers []rpcserver.User
+		if cfg.RPCUsersFile != "" {
+			rpcUsers, err = rpcserver.LoadUsersFile(cfg.RPCUsersFile)
+			if err != nil {
+				return nil, err
+			}
+			rpcsLog.Infof("Loaded %d RPC users from %s", len(rpcUsers),
+				cfg.RPCUsersFile)
+		}
+
 		rpcsConfig := rpcserver.Config{
 			Listeners:    rpcListeners,
 			ConnMgr:      &rpcConnManager{&s},
@@ -3867,6 +4057,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 				chainParams: chainParams,
 			},
 			DB:                   db,
+			DataDir:              dataDir,
 			TxMempooler:          s.txMemPool,
 			CPUMiner:             &rpcCPUMiner{s.cpuMiner},
 			NetInfo:              cfg.generateNetworkInfo(),
@@ -3876,9 +4067,14 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 			RPCPass:              cfg.RPCPass,
 			RPCLimitUser:         cfg.RPCLimitUser,
 			RPCLimitPass:         cfg.RPCLimitPass,
+			RPCUsers:             rpcUsers,
+			RPCCookiePath:        cfg.rpcCookiePath,
+			RPCAdminClients:      cfg.RPCAdminClients,
+			RPCLimitClients:      cfg.RPCLimitClients,
 			RPCMaxClients:        cfg.RPCMaxClients,
 			RPCMaxConcurrentReqs: cfg.RPCMaxConcurrentReqs,
 			RPCMaxWebsockets:     cfg.RPCMaxWebsockets,
+			EnableREST:           cfg.EnableREST,
 			TestNet:              cfg.TestNet,
 			MiningAddrs:          cfg.miningAddrs,
 			AllowUnsyncedMining:  cfg.AllowUnsyncedMining,
@@ -3886,6 +4082,7 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 			UserAgentVersion:     userAgentVersion,
 			LogManager:           &rpcLogManager{},
 			FiltererV2:           s.chain,
+			RequestObserver:      s.metrics,
 		}
 		if s.existsAddrIndex != nil {
 			rpcsConfig.ExistsAddresser = s.existsAddrIndex
@@ -3899,9 +4096,33 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 		if s.addrIndex != nil {
 			rpcsConfig.AddrIndexer = s.addrIndex
 		}
+		if s.spendIndex != nil {
+			rpcsConfig.SpendIndexer = s.spendIndex
+		}
+		if s.ticketIndex != nil {
+			rpcsConfig.TicketIndexer = s.ticketIndex
+		}
+		if s.treasuryIndex != nil {
+			rpcsConfig.TreasuryIndexer = s.treasuryIndex
+		}
+		if s.scriptHashIndex != nil {
+			rpcsConfig.ScriptHashIndexer = s.scriptHashIndex
+		}
+		if s.addrUtxoIndex != nil {
+			rpcsConfig.AddrUtxoIndexer = s.addrUtxoIndex
+		}
+		if s.blockStatsIndex != nil {
+			rpcsConfig.BlockStatsIndexer = s.blockStatsIndex
+		}
+		if s.nullDataIndex != nil {
+			rpcsConfig.NullDataIndexer = s.nullDataIndex
+		}
 		if s.cfIndex != nil {
 			rpcsConfig.Filterer = s.cfIndex
 		}
+		if s.indexManager != nil {
+			rpcsConfig.IndexSyncer = s.indexManager
+		}
 
 		s.rpcServer, err = rpcserver.New(&rpcsConfig)
 		if err != nil {
@@ -3915,6 +4136,18 @@ func newServer(ctx context.Context, listenAddrs []string, db database.DB, chainP
 		}()
 	}
 
+	// Register the metrics that are obtained from the subsystems created above
+	// and listen for metrics requests when enabled.
+	s.metrics.registerServer(&s)
+	if cfg.MetricsListen != "" {
+		var listenConfig net.ListenConfig
+		s.metricsListener, err = listenConfig.Listen(ctx, "tcp",
+			cfg.MetricsListen)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &s, nil
 }
 
@@ -133,6 +133,10 @@ const (
 	// ErrTooManyTSpends is returned when the number of tspend hashes
 	// exceeds the maximum allowed.
 	ErrTooManyTSpends
+
+	// ErrTooManyCFilters is returned when the number of committed filters
+	// exceeds the maximum allowed in a batch.
+	ErrTooManyCFilters
 )
 
 // Map of ErrorCode values back to their constant names for pretty printing.
@@ -168,6 +172,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrTooManyInitStateTypes:         "ErrTooManyInitStateTypes",
 	ErrInitStateTypeTooLong:          "ErrInitStateTypeTooLong",
 	ErrTooManyTSpends:                "ErrTooManyTSpends",
+	ErrTooManyCFilters:               "ErrTooManyCFilters",
 }
 
 // String returns the ErrorCode as a human-readable name.
@@ -58,6 +58,8 @@ const (
 	CmdCFilterV2      = "cfilterv2"
 	CmdGetInitState   = "getinitstate"
 	CmdInitState      = "initstate"
+	CmdGetCFiltersV2  = "getcfsv2"
+	CmdCFiltersV2     = "cfiltersv2"
 )
 
 // Message is an interface that describes a Decred message.  A type that
@@ -168,6 +170,12 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdInitState:
 		msg = &MsgInitState{}
 
+	case CmdGetCFiltersV2:
+		msg = &MsgGetCFsV2{}
+
+	case CmdCFiltersV2:
+		msg = &MsgCFiltersV2{}
+
 	default:
 		str := fmt.Sprintf("unhandled command [%s]", command)
 		return nil, messageError(op, ErrUnknownCmd, str)
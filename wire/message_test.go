@@ -80,6 +80,8 @@ func TestMessage(t *testing.T) {
 	msgReject := NewMsgReject("block", RejectDuplicate, "duplicate block")
 	msgGetInitState := NewMsgGetInitState()
 	msgInitState := NewMsgInitState()
+	msgGetCFsV2 := NewMsgGetCFsV2(&chainhash.Hash{}, &chainhash.Hash{})
+	msgCFiltersV2 := NewMsgCFiltersV2([]MsgCFilterV2{})
 
 	tests := []struct {
 		in     Message     // Value to encode
@@ -112,6 +114,8 @@ func TestMessage(t *testing.T) {
 		{msgCFTypes, msgCFTypes, pver, MainNet, 26},           // [26]
 		{msgGetInitState, msgGetInitState, pver, MainNet, 25}, // [27]
 		{msgInitState, msgInitState, pver, MainNet, 27},       // [28]
+		{msgGetCFsV2, msgGetCFsV2, pver, MainNet, 88},         // [29]
+		{msgCFiltersV2, msgCFiltersV2, pver, MainNet, 25},     // [30]
 	}
 
 	t.Logf("Running %d tests", len(tests))
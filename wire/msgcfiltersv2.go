@@ -0,0 +1,115 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxCFiltersV2PerBatch is the maximum number of committed filters that can be
+// requested or delivered in a single batch.
+const MaxCFiltersV2PerBatch = 100
+
+// MsgCFiltersV2 implements the Message interface and represents a cfiltersv2
+// message.  It is used to deliver a batch of version 2 committed gcs filters
+// for a contiguous range of blocks along with proofs that can be used to prove
+// each filter is committed to by its block header.  The filters are ordered by
+// the height of their associated blocks.  Note that the proofs are only useful
+// once the vote to enable header commitments is active.
+//
+// It is delivered in response to a getcfsv2 message (MsgGetCFsV2).
+type MsgCFiltersV2 struct {
+	CFilters []MsgCFilterV2
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFiltersV2) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgCFiltersV2.BtcDecode"
+	if pver < BatchedCFiltersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	// Read num filters and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxCFiltersV2PerBatch {
+		msg := fmt.Sprintf("too many committed filters for message "+
+			"[count %v, max %v]", count, MaxCFiltersV2PerBatch)
+		return messageError(op, ErrTooManyCFilters, msg)
+	}
+
+	// Create a contiguous slice of filters to deserialize into in order to
+	// reduce the number of allocations.
+	msg.CFilters = make([]MsgCFilterV2, count)
+	for i := uint64(0); i < count; i++ {
+		err := msg.CFilters[i].BtcDecode(r, pver)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFiltersV2) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgCFiltersV2.BtcEncode"
+	if pver < BatchedCFiltersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	count := len(msg.CFilters)
+	if count > MaxCFiltersV2PerBatch {
+		msg := fmt.Sprintf("too many committed filters for message "+
+			"[count %v, max %v]", count, MaxCFiltersV2PerBatch)
+		return messageError(op, ErrTooManyCFilters, msg)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for i := range msg.CFilters {
+		err := msg.CFilters[i].BtcEncode(w, pver)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFiltersV2) Command() string {
+	return CmdCFiltersV2
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFiltersV2) MaxPayloadLength(pver uint32) uint32 {
+	// Num filters (varint) + max filters.
+	var filter MsgCFilterV2
+	return uint32(VarIntSerializeSize(MaxCFiltersV2PerBatch)) +
+		MaxCFiltersV2PerBatch*filter.MaxPayloadLength(pver)
+}
+
+// NewMsgCFiltersV2 returns a new cfiltersv2 message that conforms to the
+// Message interface using the passed filters.
+func NewMsgCFiltersV2(filters []MsgCFilterV2) *MsgCFiltersV2 {
+	return &MsgCFiltersV2{
+		CFilters: filters,
+	}
+}
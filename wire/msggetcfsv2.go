@@ -0,0 +1,75 @@
+// Copyright (c) 2021 The Decred developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/decred/dcrd/chaincfg/chainhash"
+)
+
+// MsgGetCFsV2 implements the Message interface and represents a decred
+// getcfsv2 message.  It is used to request a batch of version 2 committed gcs
+// filters for a contiguous range of blocks in the main chain, starting with the
+// block identified by the start hash and ending with the block identified by
+// the end hash (inclusive), along with proofs that can be used to prove each
+// filter is committed to by its block header.  The range must not contain more
+// than MaxCFiltersV2PerBatch blocks.
+//
+// The filters are returned via a cfiltersv2 message (MsgCFiltersV2).  Requests
+// for unknown blocks or otherwise invalid ranges are ignored.
+type MsgGetCFsV2 struct {
+	StartHash chainhash.Hash
+	EndHash   chainhash.Hash
+}
+
+// BtcDecode decodes r using the Decred protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFsV2) BtcDecode(r io.Reader, pver uint32) error {
+	const op = "MsgGetCFsV2.BtcDecode"
+	if pver < BatchedCFiltersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	return readElements(r, &msg.StartHash, &msg.EndHash)
+}
+
+// BtcEncode encodes the receiver to w using the Decred protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFsV2) BtcEncode(w io.Writer, pver uint32) error {
+	const op = "MsgGetCFsV2.BtcEncode"
+	if pver < BatchedCFiltersV2Version {
+		msg := fmt.Sprintf("%s message invalid for protocol version %d",
+			msg.Command(), pver)
+		return messageError(op, ErrMsgInvalidForPVer, msg)
+	}
+
+	return writeElements(w, &msg.StartHash, &msg.EndHash)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFsV2) Command() string {
+	return CmdGetCFiltersV2
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFsV2) MaxPayloadLength(pver uint32) uint32 {
+	// Start hash + end hash.
+	return chainhash.HashSize * 2
+}
+
+// NewMsgGetCFsV2 returns a new Decred getcfsv2 message that conforms to the
+// Message interface using the passed parameters.
+func NewMsgGetCFsV2(startHash, endHash *chainhash.Hash) *MsgGetCFsV2 {
+	return &MsgGetCFsV2{
+		StartHash: *startHash,
+		EndHash:   *endHash,
+	}
+}
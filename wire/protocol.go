@@ -17,7 +17,7 @@ const (
 	InitialProcotolVersion uint32 = 1
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 8
+	ProtocolVersion uint32 = 9
 
 	// NodeBloomVersion is the protocol version which added the SFNodeBloom
 	// service flag (unused).
@@ -47,6 +47,10 @@ const (
 	// InitStateVersion is the protocol version which adds the initstate
 	// and getinitstate messages.
 	InitStateVersion uint32 = 8
+
+	// BatchedCFiltersV2Version is the protocol version which adds the
+	// getcfsv2 and cfiltersv2 messages.
+	BatchedCFiltersV2Version uint32 = 9
 )
 
 // ServiceFlag identifies services supported by a Decred peer.